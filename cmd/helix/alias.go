@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/config"
+
+	"github.com/fatih/color"
+)
+
+// handleAliasCommand lists, shows, defines or removes aliases
+func handleAliasCommand(input string) {
+	arg := strings.TrimSpace(strings.TrimPrefix(input, "/alias"))
+
+	switch {
+	case arg == "" || arg == "list":
+		listAliases()
+	case strings.HasPrefix(arg, "remove "):
+		name := strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(arg, "remove ")), "/")
+		if err := cfg.RemoveAlias(name); err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		color.Green("✅ Removed alias %s", name)
+	case strings.Contains(arg, "="):
+		name, expansion, err := config.ParseAliasDefinition(arg)
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		_, existed := cfg.FindAlias(name)
+		if err := cfg.SetAlias(name, expansion); err != nil {
+			color.Red("❌ Could not save alias: %v", err)
+			return
+		}
+		if existed {
+			color.Green("✅ Updated alias %s", name)
+		} else {
+			color.Green("✅ Added alias %s", name)
+		}
+		color.Cyan("💡 Run it with /%s or /cmd %s", name, name)
+	default:
+		alias, ok := cfg.FindAlias(strings.TrimPrefix(arg, "/"))
+		if !ok {
+			color.Red("❌ No alias %s", arg)
+			color.Yellow("💡 Usage: /alias <name> = \"<request>\" | /alias <name> = \"!<command>\" | /alias remove <name>")
+			return
+		}
+		printAlias(alias)
+	}
+}
+
+// listAliases prints every alias
+func listAliases() {
+	aliases := cfg.ListAliases()
+	if len(aliases) == 0 {
+		color.Yellow("💡 No aliases yet. Example: /alias cleanup = \"remove all node_modules older than 30 days\"")
+		return
+	}
+	color.Cyan("🔖 Aliases:")
+	for _, alias := range aliases {
+		printAlias(alias)
+	}
+}
+
+// printAlias prints one alias and whether it runs a command or asks the model
+func printAlias(alias config.Alias) {
+	if alias.Literal {
+		color.White("  %-16s command  %s", alias.Name, alias.Expansion)
+	} else {
+		color.White("  %-16s request  %s", alias.Name, alias.Expansion)
+	}
+}
+
+// runAliasInput runs input of the form /<alias> [more words] and reports
+// whether it named an alias
+func runAliasInput(input string, mockMode bool) bool {
+	if !strings.HasPrefix(input, "/") || cfg == nil {
+		return false
+	}
+	name, _, _ := strings.Cut(input[1:], " ")
+	if _, ok := cfg.FindAlias(name); !ok {
+		return false
+	}
+	handleCmdCommand("/cmd "+input[1:], mockMode)
+	return true
+}
+
+// expandAlias replaces an alias at the start of a /cmd request with its
+// expansion, before the prompt is built
+func expandAlias(request string) (config.Alias, string, bool) {
+	if cfg == nil {
+		return config.Alias{}, request, false
+	}
+	alias, expanded, ok := cfg.ExpandAlias(request)
+	if ok {
+		color.Cyan("🔖 Alias %s → %s", alias.Name, expanded)
+	}
+	return alias, expanded, ok
+}
+
+// runLiteralAlias runs the command of a literal alias without the model,
+// confirming it first like a snippet
+func runLiteralAlias(alias config.Alias, command string, runConfig commands.ExecuteConfig) {
+	if placeholders := commands.FindPlaceholders(command); len(placeholders) > 0 {
+		filled, err := commands.FillPlaceholders(command, placeholders, env)
+		if err != nil {
+			color.Yellow("❌ Alias cancelled: %v", err)
+			return
+		}
+		command = filled
+	}
+
+	syntaxHighlighter.PrintHighlightedCommand(fmt.Sprintf("Alias %s", alias.Name), command)
+	if !commands.AskForConfirmation("Execute this command?") {
+		color.Yellow("💡 Command ready to use: %s", command)
+		return
+	}
+
+	if err := sandbox.WrapCommand(command, runConfig, env); err != nil {
+		color.Red("❌ Command failed: %v", err)
+		showExitStatusHint(command, err)
+		return
+	}
+	color.Green("✅ Command executed successfully!")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+	"helix/internal/config"
+	"helix/internal/ux"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// approvalsFile returns the pending-approvals file of two-person mode
+func approvalsFile() string {
+	if cfg.UserPrefs.ApprovalsFile != "" {
+		return cfg.UserPrefs.ApprovalsFile
+	}
+	return filepath.Join(filepath.Dir(cfg.ConfigPath), "approvals.json")
+}
+
+// applyTwoPersonMode turns two-person mode on or off as the preferences say
+func applyTwoPersonMode() {
+	if !cfg.UserPrefs.TwoPerson {
+		commands.SetApprovalFile(nil)
+		return
+	}
+	commands.SetApprovalFile(commands.NewApprovalFile(approvalsFile()))
+}
+
+// handleTwoPersonCommand shows or changes two-person mode:
+// /two-person [on [file]|off]
+func handleTwoPersonCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/two-person"))
+	switch {
+	case len(args) == 0:
+		if !cfg.UserPrefs.TwoPerson {
+			color.Cyan("🔐 Two-person mode is off (/two-person on [file] holds destructive commands for a second operator)")
+			return
+		}
+		color.Cyan("🔐 Two-person mode is on: destructive commands wait for helix approve")
+		color.Cyan("   Approvals file: %s", approvalsFile())
+		return
+	case args[0] == "on" && len(args) <= 2:
+		cfg.UserPrefs.TwoPerson = true
+		if len(args) == 2 {
+			path, err := filepath.Abs(expandHomePath(trimQuotes(args[1])))
+			if err != nil {
+				color.Red("❌ %v", err)
+				return
+			}
+			cfg.UserPrefs.ApprovalsFile = path
+		}
+	case args[0] == "off" && len(args) == 1:
+		cfg.UserPrefs.TwoPerson = false
+	default:
+		color.Red("❌ Usage: /two-person [on [file]|off]")
+		return
+	}
+
+	applyTwoPersonMode()
+	if err := cfg.SavePreferences(); err != nil {
+		color.Red("❌ Failed to save preferences: %v", err)
+		return
+	}
+	if cfg.UserPrefs.TwoPerson {
+		color.Green("🔐 Two-person mode on: destructive commands need a second operator's helix approve")
+		color.Yellow("💡 Requests go to %s; share it with the approvers (a network drive or synced folder)", approvalsFile())
+	} else {
+		color.Green("🔓 Two-person mode off")
+	}
+}
+
+// handleApprovalsCommand lists the requests in the approvals file
+func handleApprovalsCommand() {
+	file := commands.NewApprovalFile(approvalsFile())
+	list, err := file.Approvals()
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	if len(list) == 0 {
+		color.Cyan("🔐 No approval requests in %s", file.Path())
+		return
+	}
+	color.Cyan("🔐 Approval requests in %s:", file.Path())
+	for _, approval := range list {
+		showApproval(approval)
+	}
+}
+
+// showApproval prints one approval request
+func showApproval(approval commands.Approval) {
+	status := approval.CurrentStatus()
+	line := fmt.Sprintf("  %s  %-8s  %s  %s", approval.Hash, status, approval.Requested.Format("2006-01-02 15:04"), approval.RequestedBy)
+	switch status {
+	case commands.ApprovalPending:
+		color.Yellow(line)
+	case commands.ApprovalApproved, commands.ApprovalUsed:
+		color.Green(line + "  (by " + approval.DecidedBy + ")")
+	default:
+		color.White(line)
+	}
+	fmt.Printf("      %s %s\n", ux.RiskColor(string(approval.Risk)).Sprint(approval.Risk.Badge()), approval.Command)
+	fmt.Printf("      in %s", approval.WorkDir)
+	if len(approval.Reasons) > 0 {
+		fmt.Printf(" · %s", strings.Join(approval.Reasons, ", "))
+	}
+	fmt.Println()
+}
+
+// runApproveCommand implements `helix approve [--reject] [--file path]
+// [hash]`: a second operator approves or rejects a pending request, or
+// lists them without a hash
+func runApproveCommand(args []string) int {
+	fs := flag.NewFlagSet("approve", flag.ContinueOnError)
+	reject := fs.Bool("reject", false, "reject the request instead of approving it")
+	path := fs.String("file", "", "the approvals file (default: the one in the config)")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		color.Yellow("Usage: helix approve [--reject] [--file approvals.json] [hash]")
+		return exitUsage
+	}
+
+	var err error
+	cfg, err = config.DefaultConfig()
+	if err != nil {
+		color.Red("❌ Error loading config: %v", err)
+		return exitUsage
+	}
+	applyDisplayPreferences()
+	if *path == "" {
+		*path = approvalsFile()
+	}
+	file := commands.NewApprovalFile(*path)
+
+	if fs.NArg() == 0 {
+		list, err := file.Approvals()
+		if err != nil {
+			color.Red("❌ %v", err)
+			return exitUsage
+		}
+		pending := 0
+		for _, approval := range list {
+			if approval.CurrentStatus() == commands.ApprovalPending {
+				if pending == 0 {
+					color.Cyan("🔐 Pending requests in %s:", file.Path())
+				}
+				showApproval(approval)
+				pending++
+			}
+		}
+		if pending == 0 {
+			color.Cyan("🔐 Nothing waiting for approval in %s", file.Path())
+		}
+		return exitOK
+	}
+
+	approval, err := file.Find(fs.Arg(0))
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+	showApproval(approval)
+	if approval.CurrentStatus() == commands.ApprovalPending && term.IsTerminal(int(os.Stdin.Fd())) {
+		verb := "Approve"
+		if *reject {
+			verb = "Reject"
+		}
+		if !commands.AskForConfirmation(fmt.Sprintf("%s this command as %s?", verb, commands.OperatorName())) {
+			color.Yellow("❌ Nothing decided")
+			return exitFailed
+		}
+	}
+
+	approval, err = file.Decide(approval.Hash, commands.OperatorName(), !*reject)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitBlocked
+	}
+	if *reject {
+		color.Yellow("🚫 Request %s rejected", approval.Hash)
+		return exitOK
+	}
+	color.Green("✅ Request %s approved; %s can run it once within %s", approval.Hash, approval.RequestedBy, commands.ApprovalTTL.Round(time.Hour))
+	return exitOK
+}
@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"helix/internal/ai"
+	"helix/internal/commands"
+	"helix/internal/config"
+	"helix/internal/rag"
+	"helix/internal/shell"
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// Batch task statuses
+const (
+	batchStatusPlanned    = "planned"
+	batchStatusSucceeded  = "succeeded"
+	batchStatusFailed     = "failed"
+	batchStatusSkipped    = "skipped"
+	batchStatusBlocked    = "blocked"
+	batchStatusRefused    = "refused"
+	batchStatusInvalid    = "invalid"
+	batchStatusNeedsInput = "needs_input"
+	batchStatusModelError = "model_error"
+	batchStatusTimeout    = "timeout"
+	batchStatusError      = "error"
+)
+
+// batchMaxAutoRisk is the highest risk level --yes executes without a
+// human, unless it names narrower levels
+const batchMaxAutoRisk = commands.RiskMedium
+
+// batchDefaultTimeout stops unattended commands when no timeout is configured
+const batchDefaultTimeout = 10 * time.Minute
+
+// assumeYesEnv scopes auto-approval like --yes when the flag is not given
+const assumeYesEnv = "HELIX_ASSUME_YES"
+
+// BatchOptions holds the flags for `helix batch`
+type BatchOptions struct {
+	TasksFile   string
+	DryRun      bool
+	ReportPath  string
+	Yes         bool               // --yes or HELIX_ASSUME_YES: execute without a human
+	AutoApprove commands.RiskLevel // highest risk level executed without a human
+	NoModel     bool               // package operations need no model
+	Mock        bool               // generate commands with the built-in mock instead of the model
+}
+
+// BatchTaskResult is the outcome of one natural-language task
+type BatchTaskResult struct {
+	Line         int                      `json:"line"`
+	Request      string                   `json:"request"`
+	RawResponse  string                   `json:"raw_response,omitempty"`
+	Command      string                   `json:"command,omitempty"`
+	Risk         *commands.RiskAssessment `json:"risk,omitempty"`
+	Status       string                   `json:"status"`
+	Reason       string                   `json:"reason,omitempty"`
+	Placeholders []string                 `json:"placeholders,omitempty"`
+	Execution    *commands.CommandResult  `json:"execution,omitempty"`
+	Duration     time.Duration            `json:"duration"`
+}
+
+// BatchSummary counts task outcomes by status
+type BatchSummary struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+}
+
+// BatchReport is the machine-readable result of a batch run
+type BatchReport struct {
+	HelixVersion string            `json:"helix_version"`
+	TasksFile    string            `json:"tasks_file"`
+	DryRun       bool              `json:"dry_run"`
+	AutoApprove  string            `json:"auto_approve"`
+	MockAI       bool              `json:"mock_ai"`
+	RAGEnabled   bool              `json:"rag_enabled"`
+	OS           string            `json:"os"`
+	Shell        string            `json:"shell"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   time.Time         `json:"finished_at"`
+	Summary      BatchSummary      `json:"summary"`
+	Tasks        []BatchTaskResult `json:"tasks"`
+}
+
+// batchTask is a single request read from the tasks file
+type batchTask struct {
+	line    int
+	request string
+}
+
+// runBatchCommand implements `helix batch <tasks-file> [--dry-run] [--report file] [--yes[=levels]]`
+// and returns the process exit code. Only the approved commands are written
+// to stdout; progress, warnings and errors go to stderr.
+func runBatchCommand(args []string) int {
+	stdout, restore := redirectOutputToStderr()
+	defer restore()
+
+	opts, err := parseBatchArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		color.Red("❌ %v", err)
+		color.Yellow("Usage: helix batch <tasks-file> [--dry-run] [--report report.json] [--yes[=low,medium]] [--mock] (--help explains --yes)")
+		return exitUsage
+	}
+
+	tasks, err := readBatchTasks(opts.TasksFile)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+	if len(tasks) == 0 {
+		color.Yellow("⚠️  No tasks found in %s", opts.TasksFile)
+		return exitOK
+	}
+
+	mockAI, err := initBatchEnvironment(opts)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return initExitCode(err)
+	}
+	if !mockAI {
+		defer ai.CloseModel()
+	}
+
+	report := BatchReport{
+		HelixVersion: config.HelixVersion,
+		TasksFile:    opts.TasksFile,
+		DryRun:       opts.DryRun,
+		AutoApprove:  autoApproveScope(opts.AutoApprove),
+		MockAI:       mockAI,
+		RAGEnabled:   pb.IsRAGAvailable(),
+		OS:           env.OSName,
+		Shell:        env.Shell,
+		StartedAt:    time.Now(),
+		Summary:      BatchSummary{ByStatus: make(map[string]int)},
+	}
+
+	if !opts.Yes && !opts.DryRun {
+		color.Yellow("⚠️  Without --yes (or %s) no command runs: each task is generated, scored and refused", assumeYesEnv)
+	}
+	color.Cyan("📋 Processing %d tasks from %s", len(tasks), opts.TasksFile)
+	for i, task := range tasks {
+		color.Blue("▶️  [%d/%d] %s", i+1, len(tasks), task.request)
+
+		result := processBatchTask(task, opts, mockAI)
+		report.Tasks = append(report.Tasks, result)
+		report.Summary.Total++
+		report.Summary.ByStatus[result.Status]++
+
+		printBatchTaskResult(result)
+		if approvedBatchCommand(result) {
+			fmt.Fprintln(stdout, result.Command)
+		}
+	}
+	report.FinishedAt = time.Now()
+
+	printBatchSummary(report)
+
+	if opts.ReportPath != "" {
+		if err := writeBatchReport(report, opts.ReportPath); err != nil {
+			color.Red("❌ Failed to write report: %v", err)
+			return exitFailed
+		}
+		color.Green("📄 Report written to %s", opts.ReportPath)
+	}
+
+	return batchExitCode(report.Tasks)
+}
+
+// approvedBatchCommand reports whether a task's command was approved to run
+// (or would be, in a dry run), which is what batch mode prints on stdout
+func approvedBatchCommand(result BatchTaskResult) bool {
+	switch result.Status {
+	case batchStatusPlanned, batchStatusSucceeded, batchStatusFailed, batchStatusTimeout:
+		return result.Command != ""
+	}
+	return false
+}
+
+// parseBatchArgs parses batch flags, allowing them before or after the tasks file
+func parseBatchArgs(args []string) (BatchOptions, error) {
+	var opts BatchOptions
+
+	var yes yesFlag
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "generate and score commands without executing them")
+	fs.StringVar(&opts.ReportPath, "report", "", "write a JSON report to this file")
+	fs.Var(&yes, "yes", "execute commands up to medium risk without a human, or only up to the given levels (e.g. --yes=low)")
+	fs.BoolVar(&opts.Mock, "mock", false, "generate commands with the built-in mock generator instead of the model")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: helix batch <tasks-file> [--dry-run] [--report report.json] [--yes[=low,medium]] [--mock]")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Without --yes (or "+assumeYesEnv+") no command is executed: each task is generated,")
+		fmt.Fprintln(out, "scored and refused with exit code 7. --yes executes LOW and MEDIUM risk commands;")
+		fmt.Fprintln(out, "--yes=low lowers that ceiling. HIGH and CRITICAL commands are never executed unattended.")
+		fmt.Fprintln(out, "")
+		fs.PrintDefaults()
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != 1 {
+		return opts, fmt.Errorf("expected exactly one tasks file, got %d", len(positional))
+	}
+	opts.TasksFile = positional[0]
+
+	// The environment opts in only when --yes is not given at all
+	source := "--yes"
+	given := false
+	fs.Visit(func(f *flag.Flag) { given = given || f.Name == "yes" })
+	if value := os.Getenv(assumeYesEnv); !given && value != "" {
+		source = assumeYesEnv
+		yes.Set(value)
+	}
+	if !yes.set {
+		// No ceiling without --yes: nothing runs without a human
+		return opts, nil
+	}
+
+	opts.Yes, opts.AutoApprove = true, batchMaxAutoRisk
+	if yes.scope != "" {
+		level, err := commands.ParseAutoApproveLevels(yes.scope)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %w", source, err)
+		}
+		opts.AutoApprove = level
+	}
+	return opts, nil
+}
+
+// autoApproveScope lists the risk levels approved up to a level, e.g. "low,medium"
+func autoApproveScope(highest commands.RiskLevel) string {
+	var levels []string
+	for _, level := range []commands.RiskLevel{commands.RiskLow, commands.RiskMedium} {
+		if level.Rank() <= highest.Rank() {
+			levels = append(levels, string(level))
+		}
+	}
+	return strings.Join(levels, ",")
+}
+
+// readBatchTasks reads one request per line, skipping blank lines and # comments
+func readBatchTasks(path string) ([]batchTask, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open tasks file: %w", err)
+	}
+	defer file.Close()
+
+	var tasks []batchTask
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tasks = append(tasks, batchTask{line: lineNo, request: line})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tasks file: %w", err)
+	}
+	return tasks, nil
+}
+
+// errModelUnavailable is returned when the model cannot be loaded for a
+// non-interactive run
+var errModelUnavailable = errors.New("AI model unavailable")
+
+// initExitCode is the exit code for an initBatchEnvironment error
+func initExitCode(err error) int {
+	if errors.Is(err, errModelUnavailable) {
+		return exitModelError
+	}
+	return exitUsage
+}
+
+// initBatchEnvironment prepares the globals used by the command pipeline without
+// any interactive prompts. It returns true when commands must be mocked,
+// which only --mock asks for: a model that fails to load is
+// errModelUnavailable.
+func initBatchEnvironment(opts BatchOptions) (bool, error) {
+	var err error
+	cfg, err = config.DefaultConfig()
+	if err != nil {
+		return false, fmt.Errorf("error loading config: %w", err)
+	}
+	applyDisplayPreferences()
+
+	env = shell.DetectEnvironment()
+	online = utils.IsOnline(5 * time.Second)
+
+	sandbox = commands.NewDirectorySandbox()
+	sandbox.SetPaths(cfg.Sandbox)
+	execConfig = commands.DefaultExecuteConfig().WithLimits(cfg.ExecuteConfig)
+	execConfig.DryRun = opts.DryRun
+	execConfig.NeverSudo = cfg.UserPrefs.NeverSudo
+	commands.SetPreferredPackageManager(cfg.UserPrefs.PackageManager)
+	commands.SetPackageSources(cfg.Packages)
+	// Nobody is there to press Ctrl+C
+	if execConfig.Timeout == 0 {
+		execConfig.Timeout = batchDefaultTimeout
+	}
+
+	syntaxHighlighter = utils.NewSyntaxHighlighter()
+	commands.SetSyntaxHighlighter(syntaxHighlighter)
+
+	// Team policy packs apply to batch runs too
+	teamSync = config.NewTeamSync(cfg)
+	loadSharedContent()
+	loadCommandPolicy()
+	applyProjectProfile()
+	loadHooks()
+	commands.SetViolationLog(auditLog())
+	commands.SetPackageLedger(packageLedger())
+	applyTwoPersonMode()
+	applySnapshots()
+
+	if opts.NoModel {
+		return true, nil
+	}
+	if opts.Mock {
+		color.Yellow("🎭 --mock: commands come from the built-in mock generator, not the model")
+		pb = ai.NewPromptBuilder(env, online)
+		return true, nil
+	}
+
+	// Never prompt to download the model in batch mode
+	if err := ai.LoadModel(cfg.ModelFile); err != nil {
+		return false, fmt.Errorf("%w: %v (run Helix interactively to download it, or use --mock)", errModelUnavailable, err)
+	}
+
+	// Use an existing RAG index if there is one, but don't build it here
+	ragSystem = rag.NewSystem(env)
+	if !ragSystem.LoadExistingIndex() {
+		color.Yellow("💡 No RAG index found - run Helix interactively once to build it")
+	} else if ragSystem.IsIndexStale() {
+		color.Yellow("💡 RAG index was built by an older Helix - run Helix interactively to rebuild it")
+	}
+	pb = ai.NewEnhancedPromptBuilder(env, online, ragSystem)
+
+	return false, nil
+}
+
+// processBatchTask runs one request through generation, validation, risk scoring
+// and (unless dry-run) execution
+func processBatchTask(task batchTask, opts BatchOptions, mockAI bool) BatchTaskResult {
+	start := time.Now()
+	result := BatchTaskResult{Line: task.line, Request: task.request}
+
+	request, err := commands.RunHooks(commands.HookEvent{Stage: commands.HookPreGenerate, Request: task.request})
+	if err != nil {
+		return finishBatchTask(result, start, batchStatusBlocked, err.Error())
+	}
+
+	raw, err := generateBatchResponse(request.Request, request.Context, mockAI)
+	result.RawResponse = raw
+	if err != nil {
+		return finishBatchTask(result, start, batchStatusModelError, err.Error())
+	}
+
+	command := ai.ExtractCommand(raw)
+	if command == "" {
+		return finishBatchTask(result, start, batchStatusModelError, "AI didn't generate a valid command")
+	}
+
+	command = attemptCommandFix(command)
+	cleaned, err := commands.ValidateAndCleanCommand(command)
+	if err != nil {
+		result.Command = command
+		return finishBatchTask(result, start, batchStatusInvalid, err.Error())
+	}
+	result.Command = cleaned
+
+	generated, err := commands.RunHooks(commands.HookEvent{
+		Stage: commands.HookPostGenerate, Request: request.Request, Command: cleaned,
+	})
+	if err != nil {
+		return finishBatchTask(result, start, batchStatusBlocked, err.Error())
+	}
+	if generated.Command != cleaned {
+		result.Command = generated.Command
+		if cleaned, err = commands.ValidateAndCleanCommand(generated.Command); err != nil {
+			return finishBatchTask(result, start, batchStatusInvalid, err.Error())
+		}
+		result.Command = cleaned
+	}
+
+	risk := commands.AssessRisk(cleaned)
+	result.Risk = &risk
+
+	// Placeholders need a human to fill them in
+	if placeholders := commands.FindPlaceholders(cleaned); len(placeholders) > 0 {
+		for _, p := range placeholders {
+			result.Placeholders = append(result.Placeholders, p.Token)
+		}
+		return finishBatchTask(result, start, batchStatusNeedsInput, "command contains placeholders")
+	}
+
+	if valid, reason := sandbox.ValidateCommand(cleaned); !valid {
+		return finishBatchTask(result, start, batchStatusBlocked, "sandbox: "+reason)
+	}
+
+	if decision := commands.CheckPolicy(cleaned); decision.Blocked {
+		return finishBatchTask(result, start, batchStatusBlocked, "policy "+decision.Pack+": "+decision.Pattern)
+	} else if decision.RequiresConfirm && !opts.DryRun {
+		return finishBatchTask(result, start, batchStatusSkipped, "policy "+decision.Pack+" requires interactive confirmation")
+	}
+
+	if opts.DryRun {
+		return finishBatchTask(result, start, batchStatusPlanned, "")
+	}
+
+	if !opts.Yes || opts.AutoApprove == "" {
+		return finishBatchTask(result, start, batchStatusRefused, "not auto-approved: run with --yes to execute, or --dry-run to only plan")
+	}
+	if risk.Level.Rank() > opts.AutoApprove.Rank() {
+		return finishBatchTask(result, start, batchStatusRefused,
+			fmt.Sprintf("%s risk is not auto-approved (--yes=%s)", risk.Level, autoApproveScope(opts.AutoApprove)))
+	}
+	if risk.ConfirmToken != "" {
+		return finishBatchTask(result, start, batchStatusRefused,
+			fmt.Sprintf("destroys %s, which must be confirmed by typing its name", strings.Join(risk.ConfirmTargets, ", ")))
+	}
+
+	return executeBatchTask(result, start)
+}
+
+// executeBatchTask runs a task's approved command and records the outcome
+func executeBatchTask(result BatchTaskResult, start time.Time) BatchTaskResult {
+	execution, err := commands.RunCommandCapture(result.Command, sandbox.Guard(execConfig), env)
+	result.Execution = &execution
+	if execution.Command != "" {
+		result.Command = execution.Command
+	}
+	var veto *commands.HookVetoError
+	if errors.As(err, &veto) {
+		return finishBatchTask(result, start, batchStatusBlocked, err.Error())
+	}
+	if errors.Is(err, commands.ErrCommandTimeout) {
+		return finishBatchTask(result, start, batchStatusTimeout, err.Error())
+	}
+	if errors.Is(err, commands.ErrOutputLimit) {
+		return finishBatchTask(result, start, batchStatusFailed, err.Error())
+	}
+	if err != nil {
+		return finishBatchTask(result, start, batchStatusError, err.Error())
+	}
+	if execution.ExitCode != 0 {
+		return finishBatchTask(result, start, batchStatusFailed, fmt.Sprintf("exit code %d", execution.ExitCode))
+	}
+
+	return finishBatchTask(result, start, batchStatusSucceeded, "")
+}
+
+// finishBatchTask records the final status of a task
+func finishBatchTask(result BatchTaskResult, start time.Time, status, reason string) BatchTaskResult {
+	result.Status = status
+	result.Reason = reason
+	result.Duration = time.Since(start)
+	return result
+}
+
+// generateBatchResponse asks the model (or the mock generator) for a command
+func generateBatchResponse(request string, hookContext []string, mockAI bool) (string, error) {
+	if mockAI {
+		return generateMockCommand(request, env), nil
+	}
+
+	response, err := ai.RunModel(buildCommandPrompt(request, hookContext))
+	if err != nil {
+		return "", fmt.Errorf("AI error: %w", err)
+	}
+
+	if strings.TrimSpace(response) == "" {
+		if fallback := generateFallbackCommand(request, env); fallback != "" {
+			return fallback, nil
+		}
+		return "", fmt.Errorf("AI returned empty response")
+	}
+
+	return response, nil
+}
+
+// printBatchTaskResult prints a one-line outcome for a task
+func printBatchTaskResult(result BatchTaskResult) {
+	risk := ""
+	if result.Risk != nil {
+		risk = fmt.Sprintf(" %s (risk %d)", result.Risk.Level.Badge(), result.Risk.Score)
+	}
+
+	switch result.Status {
+	case batchStatusSucceeded, batchStatusPlanned:
+		color.Green("   ✅ %s: %s%s", result.Status, result.Command, risk)
+	case batchStatusSkipped, batchStatusNeedsInput, batchStatusRefused, batchStatusInvalid:
+		color.Yellow("   ⏭️  %s: %s%s (%s)", result.Status, result.Command, risk, result.Reason)
+	default:
+		color.Red("   ❌ %s: %s%s (%s)", result.Status, result.Command, risk, result.Reason)
+	}
+}
+
+// printBatchSummary prints totals for the batch run
+func printBatchSummary(report BatchReport) {
+	color.Cyan("📊 Batch completed: %d tasks in %s", report.Summary.Total,
+		utils.FormatDuration(report.FinishedAt.Sub(report.StartedAt)))
+
+	for _, status := range []string{
+		batchStatusPlanned, batchStatusSucceeded, batchStatusFailed, batchStatusTimeout, batchStatusSkipped,
+		batchStatusBlocked, batchStatusRefused, batchStatusInvalid, batchStatusNeedsInput,
+		batchStatusModelError, batchStatusError,
+	} {
+		if count := report.Summary.ByStatus[status]; count > 0 {
+			color.Cyan("   %s: %d", status, count)
+		}
+	}
+}
+
+// writeBatchReport saves the report as indented JSON
+func writeBatchReport(report BatchReport, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
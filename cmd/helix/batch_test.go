@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"helix/internal/commands"
+)
+
+func TestParseBatchArgsAutoApproval(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         string
+		wantYes     bool
+		wantCeiling commands.RiskLevel
+		wantErr     bool
+	}{
+		{name: "no --yes runs nothing", args: []string{"tasks.txt"}},
+		{name: "dry run", args: []string{"tasks.txt", "--dry-run"}},
+		{name: "bare --yes", args: []string{"tasks.txt", "--yes"}, wantYes: true, wantCeiling: commands.RiskMedium},
+		{name: "--yes=true", args: []string{"--yes=true", "tasks.txt"}, wantYes: true, wantCeiling: commands.RiskMedium},
+		{name: "--yes=low", args: []string{"tasks.txt", "--yes=low"}, wantYes: true, wantCeiling: commands.RiskLow},
+		{name: "--yes=false", args: []string{"tasks.txt", "--yes=false"}},
+		{name: "environment opts in", args: []string{"tasks.txt"}, env: "low", wantYes: true, wantCeiling: commands.RiskLow},
+		{name: "--yes=false overrides the environment", args: []string{"tasks.txt", "--yes=false"}, env: "1"},
+		{name: "high is never approved", args: []string{"tasks.txt", "--yes=high"}, wantErr: true},
+		{name: "bad environment", args: []string{"tasks.txt"}, env: "everything", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(assumeYesEnv, tt.env)
+			opts, err := parseBatchArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBatchArgs(%v) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Yes != tt.wantYes || opts.AutoApprove != tt.wantCeiling {
+				t.Errorf("parseBatchArgs(%v) = yes %v, ceiling %q; want yes %v, ceiling %q",
+					tt.args, opts.Yes, opts.AutoApprove, tt.wantYes, tt.wantCeiling)
+			}
+		})
+	}
+}
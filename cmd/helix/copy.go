@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// lastGeneratedCommand is the latest command /cmd or /fix produced, for /copy
+var lastGeneratedCommand string
+
+// handleCopyCommand puts the last generated command on the clipboard, or
+// turns copying of accepted commands on or off: /copy [auto on|off]
+func handleCopyCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/copy"))
+	switch {
+	case len(args) == 0:
+		if lastGeneratedCommand == "" {
+			color.Yellow("💡 No generated command to copy yet; /cmd creates one")
+			return
+		}
+		copyCommand(lastGeneratedCommand)
+	case args[0] == "auto" && len(args) == 1:
+		state := "off"
+		if cfg.UserPrefs.AutoCopy {
+			state = "on"
+		}
+		color.Cyan("📋 Auto-copy of accepted commands is %s", state)
+	case args[0] == "auto" && len(args) == 2 && (args[1] == "on" || args[1] == "off"):
+		cfg.UserPrefs.AutoCopy = args[1] == "on"
+		if err := cfg.SavePreferences(); err != nil {
+			color.Red("❌ Failed to save preferences: %v", err)
+			return
+		}
+		if cfg.UserPrefs.AutoCopy {
+			color.Green("📋 Every command you accept is now copied to the clipboard")
+		} else {
+			color.Green("📋 Accepted commands are no longer copied")
+		}
+	default:
+		color.Red("❌ Usage: /copy [auto on|off]")
+	}
+}
+
+// copyCommand puts a command on the clipboard and says where it went
+func copyCommand(command string) {
+	method, err := utils.CopyToClipboard(command)
+	if err != nil {
+		color.Red("❌ Cannot copy: %v", err)
+		return
+	}
+	color.Green("📋 Copied to the clipboard (%s): %s", method, command)
+}
+
+// autoCopyCommand copies an accepted command when auto-copy is on
+func autoCopyCommand(command string) {
+	if cfg != nil && cfg.UserPrefs.AutoCopy {
+		copyCommand(command)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// Exit codes of the non-interactive modes. They are part of Helix's CLI
+// contract: scripts depend on them, so existing values must never change.
+const (
+	exitOK           = 0 // every task succeeded (or was planned in a dry run)
+	exitFailed       = 1 // a command ran and exited non-zero, or could not be started
+	exitUsage        = 2 // bad flags or arguments, unreadable input
+	exitInvalid      = 3 // the generated command failed validation or still has placeholders
+	exitBlocked      = 4 // blocked by the sandbox, a policy pack or a hook
+	exitModelError   = 5 // the model failed or produced no command
+	exitTimeout      = 6 // a command exceeded its time limit
+	exitNeedsConfirm = 7 // no --yes, or the command's risk is above its scope
+)
+
+// batchStatusExitCodes maps each task status to its exit code
+var batchStatusExitCodes = map[string]int{
+	batchStatusPlanned:    exitOK,
+	batchStatusSucceeded:  exitOK,
+	batchStatusFailed:     exitFailed,
+	batchStatusError:      exitFailed,
+	batchStatusInvalid:    exitInvalid,
+	batchStatusNeedsInput: exitInvalid,
+	batchStatusBlocked:    exitBlocked,
+	batchStatusSkipped:    exitBlocked,
+	batchStatusModelError: exitModelError,
+	batchStatusTimeout:    exitTimeout,
+	batchStatusRefused:    exitNeedsConfirm,
+}
+
+// batchExitCode returns the exit code of the first task that did not
+// succeed, so a one-task run reports exactly what went wrong
+func batchExitCode(tasks []BatchTaskResult) int {
+	for _, task := range tasks {
+		if code := batchStatusExitCodes[task.Status]; code != exitOK {
+			return code
+		}
+	}
+	return exitOK
+}
+
+// redirectOutputToStderr sends everything Helix prints to stderr, so stdout
+// carries only results a script can consume. It returns the real stdout and
+// a function that undoes the redirection.
+func redirectOutputToStderr() (*os.File, func()) {
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout = os.Stderr
+	color.Output = color.Error
+	return stdout, func() {
+		os.Stdout = stdout
+		color.Output = colorOutput
+	}
+}
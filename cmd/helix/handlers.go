@@ -1,14 +1,21 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"helix/internal/ai"
 	"helix/internal/commands"
+	"helix/internal/rag"
+	"helix/internal/rag/eval"
+	"helix/internal/shell"
 	"helix/internal/utils"
 	"helix/internal/ux"
 
@@ -24,8 +31,50 @@ func handleCmdCommand(input string, mockMode bool) {
 		return
 	}
 
+	// --plan asks for several commands; --background runs the command as a job
+	forcePlan, background := false, false
+	for {
+		if rest, ok := strings.CutPrefix(commandText, "--plan "); ok {
+			commandText, forcePlan = strings.TrimSpace(rest), true
+		} else if rest, ok := strings.CutPrefix(commandText, "--background "); ok {
+			commandText, background = strings.TrimSpace(rest), true
+		} else {
+			break
+		}
+	}
+	runConfig := execConfig
+	runConfig.Background = background
+
+	// Aliases expand before the prompt is built; literal commands skip the model
+	if alias, expanded, ok := expandAlias(commandText); ok {
+		if alias.Literal {
+			runLiteralAlias(alias, expanded, runConfig)
+			return
+		}
+		commandText = expanded
+	}
+
+	// Requests that need several commands get a plan run one step at a time
+	if (forcePlan || ai.IsMultiStepRequest(commandText)) && runCommandPlan(commandText, mockMode, forcePlan) {
+		return
+	}
+
+	// Everything from here to execution is recorded for /replay
+	audit := &commands.AuditRecord{Request: commandText, Shell: env.Shell, OS: env.OSName, Mock: mockMode}
+	commands.BeginAudit(audit)
+	defer finishAudit()
+
+	// Pre-generate hooks may rewrite the request, add context or veto it
+	request, hookErr := commands.RunHooks(commands.HookEvent{Stage: commands.HookPreGenerate, Request: commandText, Interactive: true})
+	if hookErr != nil {
+		color.Red("❌ Request %v", hookErr)
+		return
+	}
+	commandText = request.Request
+
 	// Build the prompt for command generation
-	prompt := pb.BuildCommandPrompt(commandText)
+	prompt := buildCommandPrompt(commandText, request.Context)
+	audit.Prompt = prompt
 
 	// ADD THIS DEBUG
 	color.Yellow("🔍 DEBUG: Final prompt being sent to AI (%d chars):", len(prompt))
@@ -35,6 +84,98 @@ func handleCmdCommand(input string, mockMode bool) {
 
 	color.Blue("🤖 Processing: %s", commandText)
 
+	aiResponse, ok := generateCommandResponse(prompt, commandText, mockMode)
+	if !ok {
+		return
+	}
+
+	// Whatever happens next tells RAG how useful its documents were
+	outcome := rag.FeedbackRejected
+	defer func() {
+		if ragSystem != nil {
+			ragSystem.RecordFeedback(commandText, outcome)
+		}
+	}()
+
+	for {
+		command, generated, edited, ok := prepareGeneratedCommand(aiResponse, commandText, audit, mockMode)
+		if !ok {
+			return
+		}
+		lastGeneratedCommand = command
+
+		// Final confirmation before execution
+		audit.Command = command
+		color.Yellow("🔍 Final command to execute: '%s'", command)
+		showRiskAssessment(command)
+		if edited {
+			showCommandChanges(generated, command)
+		}
+
+		// Trusted commands skip the question; anything risky is still asked
+		action, execute := commands.ActionCancel, runConfig
+		if pattern := commands.MatchTrusted(command, trustedPatterns()); pattern != "" {
+			color.Green("🤝 Trusted in this project (%s), running without confirmation", pattern)
+			audit.Decisions = append(audit.Decisions, commands.AuditDecision{Question: "Trusted pattern " + pattern, Approved: true})
+			action, execute.AutoConfirm = commands.ActionExecute, true
+		} else {
+			action = commands.AskExecuteOrRefine("Execute this command?")
+		}
+		if action == commands.ActionAlways {
+			if err := trustCommand(command); err != nil {
+				color.Red("❌ Failed to save preferences: %v", err)
+			} else {
+				color.Green("🤝 '%s' will run without confirmation in this project (/untrust to undo)", command)
+			}
+			action = commands.ActionExecute
+		}
+
+		switch action {
+		case commands.ActionExecute:
+			outcome = rag.FeedbackAccepted
+			if edited {
+				outcome = rag.FeedbackEdited
+			}
+			autoCopyCommand(command)
+
+			err := sandbox.WrapCommand(command, execute, env)
+			if err != nil {
+				if !audit.Executed {
+					audit.Error = err.Error() // stopped before running, e.g. by the sandbox
+				}
+				color.Red("❌ Command failed: %v", err)
+				showExitStatusHint(command, err)
+				if audit.Executed {
+					offerFix(mockMode)
+				}
+			} else {
+				color.Green("✅ Command executed successfully!")
+			}
+			return
+		case commands.ActionRefine:
+			// A follow-up regenerates from the previous command, not from scratch
+			correction := readRefinement(command)
+			if correction == "" {
+				color.Yellow("💡 Command ready to use: %s", command)
+				return
+			}
+			audit.Refinements = append(audit.Refinements, correction)
+			prompt = pb.BuildRefinePrompt(commandText, command, correction)
+			audit.Prompt = prompt
+			color.Blue("🔄 Refining: %s", correction)
+			if aiResponse, ok = generateCommandResponse(prompt, commandText+", "+correction, mockMode); !ok {
+				return
+			}
+		default:
+			color.Yellow("💡 Command ready to use: %s", command)
+			return
+		}
+	}
+}
+
+// generateCommandResponse asks the model (or the mock generator) for a
+// command, falling back to simpler prompts when the response is empty
+func generateCommandResponse(prompt, commandText string, mockMode bool) (string, bool) {
 	var aiResponse string
 	var err error
 
@@ -48,7 +189,7 @@ func handleCmdCommand(input string, mockMode bool) {
 		aiResponse, err = ai.RunModel(prompt)
 		if err != nil {
 			color.Red("❌ AI error: %v", err)
-			return
+			return "", false
 		}
 		color.Green("✅ AI processed in %s", utils.FormatDuration(time.Since(start)))
 	}
@@ -68,7 +209,7 @@ func handleCmdCommand(input string, mockMode bool) {
 
 		if fallbackErr != nil {
 			color.Red("❌ Fallback also failed: %v", fallbackErr)
-			return
+			return "", false
 		}
 
 		if strings.TrimSpace(fallbackResponse) != "" {
@@ -82,19 +223,39 @@ func handleCmdCommand(input string, mockMode bool) {
 			color.Green("🤖 [Fallback] → %s", aiResponse)
 		}
 	}
+	return aiResponse, true
+}
 
+// prepareGeneratedCommand turns a model response into the command to run:
+// it fills placeholders, repairs and validates the command and runs the
+// post-generate hooks. It returns the command, the command as generated
+// when the user or a hook edited it, and false when there is nothing to run.
+func prepareGeneratedCommand(aiResponse, commandText string, audit *commands.AuditRecord, mockMode bool) (command, generated string, edited, ok bool) {
 	// Extract the actual command from AI response
-	command := ai.ExtractCommand(aiResponse)
+	command = ai.ExtractCommand(aiResponse)
+	audit.Response = aiResponse
+	audit.Generated = command
 
 	if command == "" {
 		color.Red("❌ AI didn't generate a valid command")
 		color.Yellow("Raw AI response: %s", aiResponse)
-		return
+		return "", "", false, false
 	}
 
 	// Show the raw command before cleaning for debugging
 	color.Yellow("🔍 Raw AI command: %s", command)
 
+	// Fill in placeholders like <branch> or <file> before validating the command
+	if placeholders := commands.FindPlaceholders(command); len(placeholders) > 0 {
+		filled, err := commands.FillPlaceholders(command, placeholders, env)
+		if err != nil {
+			color.Yellow("❌ Command cancelled: %v", err)
+			color.Yellow("💡 Command template: %s", command)
+			return "", "", false, false
+		}
+		command = filled
+	}
+
 	// NEW: Enhanced detailed analysis
 	color.Blue("🔬 Analyzing command structure:")
 	color.Blue("  - Single quotes: %d", strings.Count(command, "'"))
@@ -110,8 +271,8 @@ func handleCmdCommand(input string, mockMode bool) {
 	if strings.Contains(command, ".go") && !strings.Contains(command, "*.go") {
 		color.Red("⚠️  Detected malformed file pattern: '.go' should be '*.go'")
 	}
-	if strings.Count(command, "'")%2 != 0 || strings.Count(command, `"`)%2 != 0 {
-		color.Red("⚠️  Detected unbalanced quotes")
+	if err := syntaxError(command); err != nil {
+		color.Red("⚠️  Detected %v", err)
 	}
 
 	// NEW: Enhanced command fixing with detailed feedback
@@ -149,7 +310,7 @@ func handleCmdCommand(input string, mockMode bool) {
 		color.Yellow("Attempted command: %s", command)
 
 		// NEW: Enhanced validation error handling
-		if strings.Contains(err.Error(), "unmatched quotes") {
+		if strings.Contains(err.Error(), "unterminated") {
 			color.Yellow("💡 Quote balancing issue detected")
 			// Try one more fix attempt with enhanced repair
 			repairedCommand := utils.FixUnmatchedQuotes(command)
@@ -174,18 +335,20 @@ func handleCmdCommand(input string, mockMode bool) {
 				if manuallyEdited != "" {
 					cleanedCommand, err = commands.ValidateAndCleanCommand(manuallyEdited)
 					if err == nil {
+						generated = command
 						command = cleanedCommand
+						edited = true
 						color.Green("✅ Manual edit successful: %s", command)
 					} else {
 						color.Red("❌ Manual edit still invalid: %v", err)
-						return
+						return "", "", false, false
 					}
 				} else {
 					color.Yellow("❌ Manual edit cancelled")
-					return
+					return "", "", false, false
 				}
 			} else {
-				return
+				return "", "", false, false
 			}
 		}
 	} else {
@@ -203,6 +366,10 @@ func handleCmdCommand(input string, mockMode bool) {
 			command = strings.ReplaceAll(command, ".go", "*.go")
 			command = strings.ReplaceAll(command, "'.go", "'*.go")
 			command = strings.ReplaceAll(command, "\".go", "\"*.go")
+			if !edited {
+				generated = oldCommand
+			}
+			edited = true
 			color.Green("✅ Manually fixed: %s → %s", oldCommand, command)
 		}
 	}
@@ -212,19 +379,9 @@ func handleCmdCommand(input string, mockMode bool) {
 
 	// NEW: Enhanced validation with detailed feedback
 	color.Blue("🔍 Validating command syntax...")
-	if hasSyntaxErrors(command) {
+	if err := syntaxError(command); err != nil {
 		color.Red("❌ Command has syntax errors")
-
-		// Show specific issues
-		if strings.Contains(command, ".go") && !strings.Contains(command, "*.go") {
-			color.Red("   ✗ Malformed file pattern: '.go' should be '*.go'")
-		}
-		if strings.HasSuffix(command, ")") {
-			color.Red("   ✗ Trailing parenthesis")
-		}
-		if !utils.HasBalancedQuotes(command) {
-			color.Red("   ✗ Unbalanced quotes")
-		}
+		color.Red("   ✗ %v", err)
 
 		color.Yellow("💡 The generated command may not execute properly")
 	} else {
@@ -246,50 +403,125 @@ func handleCmdCommand(input string, mockMode bool) {
 		explainCommand(command, mockMode)
 	}
 
+	// Post-generate hooks may veto the command or change it, e.g. a linter
+	generatedEvent, hookErr := commands.RunHooks(commands.HookEvent{
+		Stage: commands.HookPostGenerate, Request: commandText, Command: command, Interactive: true,
+	})
+	if hookErr != nil {
+		color.Red("❌ Command %v", hookErr)
+		return "", "", false, false
+	}
+	if generatedEvent.Command != command {
+		hooked, err := commands.ValidateAndCleanCommand(generatedEvent.Command)
+		if err != nil {
+			color.Red("❌ Command changed by a hook is invalid: %v", err)
+			return "", "", false, false
+		}
+		if !edited {
+			generated = command
+		}
+		edited = true
+		command = hooked
+	}
+
 	// NEW: Enhanced final validation before execution
 	color.Cyan("🎯 Ready to execute:")
 	syntaxHighlighter.PrintHighlightedCommand("", command)
 
 	// NEW: Comprehensive pre-execution check
-	if hasSyntaxErrors(command) {
-		color.Red("🚨 WARNING: Command has syntax errors that may cause failure")
+	if err := syntaxError(command); err != nil {
+		color.Red("🚨 WARNING: Command has syntax errors that may cause failure: %v", err)
 		color.Yellow("💡 Recommended: Cancel and try a different phrasing")
 		if !commands.AskForConfirmation("Execute anyway? (likely to fail)") {
 			color.Yellow("❌ Execution cancelled due to syntax errors")
-			return
+			return "", "", false, false
 		}
 	} else {
 		color.Green("✅ Command looks good to execute")
 	}
+	return command, generated, edited, true
+}
+
+// Handle /fix command
+func handleFixCommand(mockMode bool) {
+	failure, ok := commands.LastFailure()
+	if !ok {
+		color.Yellow("💡 No failed command to fix")
+		color.Yellow("💡 /fix diagnoses the last command Helix ran when it exits with an error")
+		return
+	}
+	fixFailedCommand(failure, mockMode)
+}
+
+// offerFix offers to diagnose the command that just failed
+func offerFix(mockMode bool) {
+	failure, ok := commands.LastFailure()
+	if !ok {
+		return
+	}
+	if commands.AskForConfirmation("Diagnose the failure and suggest a fix?") {
+		fixFailedCommand(failure, mockMode)
+	} else {
+		color.Yellow("💡 Run /fix later to diagnose it")
+	}
+}
 
-	// Final confirmation before execution
-	color.Yellow("🔍 Final command to execute: '%s'", command)
+// fixFailedCommand asks the model why a command failed, then offers to run
+// the corrected command it proposes
+func fixFailedCommand(failure commands.Failure, mockMode bool) {
+	color.Blue("🩺 Diagnosing: %s (exit code %d)", failure.Command, failure.ExitCode)
 
-	// Execute the command
-	if commands.AskForConfirmation("Execute this command?") {
-		err := sandbox.WrapCommand(command, execConfig, env)
+	var response string
+	if mockMode {
+		response = generateMockFix(failure, env)
+		color.Green("🤖 [Mock AI] → %s", strings.ReplaceAll(response, "\n", " | "))
+	} else {
+		start := time.Now()
+		var err error
+		response, err = ai.RunModel(pb.BuildFixPrompt(failure.Command, failure.ExitCode, failure.Stderr))
 		if err != nil {
-			color.Red("❌ Command failed: %v", err)
-
-			// Enhanced error suggestions
-			if strings.Contains(err.Error(), "command not found") {
-				color.Yellow("💡 The command or program may not be installed")
-			} else if strings.Contains(err.Error(), "No such file or directory") {
-				color.Yellow("💡 Check if the file or directory exists")
-			} else if strings.Contains(err.Error(), "Permission denied") {
-				color.Yellow("💡 You may need elevated privileges for this command")
-			} else if strings.Contains(err.Error(), "syntax error") {
-				color.Yellow("💡 The command has shell syntax errors")
-				color.Yellow("💡 Try rephrasing your request differently")
-			} else if strings.Contains(err.Error(), "unmatched") {
-				color.Yellow("💡 There are unmatched quotes or parentheses")
-			}
-		} else {
-			color.Green("✅ Command executed successfully!")
+			color.Red("❌ AI error: %v", err)
+			return
 		}
-	} else {
-		color.Yellow("💡 Command ready to use: %s", command)
+		color.Green("✅ AI processed in %s", utils.FormatDuration(time.Since(start)))
+	}
+
+	diagnosis, command := ai.ParseFixResponse(response)
+	if diagnosis != "" {
+		color.Cyan("🩺 Diagnosis: %s", diagnosis)
+	}
+	if command == "" {
+		color.Yellow("💡 No corrected command suggested")
+		return
+	}
+
+	cleaned, err := commands.ValidateAndCleanCommand(command)
+	if err != nil {
+		color.Red("❌ Suggested command is invalid: %v", err)
+		color.Yellow("Suggested command: %s", command)
+		return
+	}
+	if cleaned == failure.Command {
+		color.Yellow("💡 The suggested fix is the same command; it is not run again")
+		return
+	}
+
+	lastGeneratedCommand = cleaned
+	syntaxHighlighter.PrintHighlightedCommand("Suggested fix", cleaned)
+	showRiskAssessment(cleaned)
+	if !commands.AskForConfirmation("Execute the corrected command?") {
+		color.Yellow("💡 Command ready to use: %s", cleaned)
+		return
+	}
+	autoCopyCommand(cleaned)
+
+	if err := sandbox.WrapCommand(cleaned, execConfig, env); err != nil {
+		color.Red("❌ Command failed: %v", err)
+		showExitStatusHint(cleaned, err)
+		color.Yellow("💡 Run /fix again to diagnose the new failure")
+		return
 	}
+	color.Green("✅ Command executed successfully!")
 }
 
 // Handle /ask command
@@ -301,6 +533,25 @@ func handleAskCommand(input string, mockMode bool) {
 		return
 	}
 
+	// Questions the installed documentation answers skip free generation
+	if answerFromDocs(promptText, mockMode) {
+		return
+	}
+
+	response, err := askModel(promptText, mockMode)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+
+	// Create UX manager for nice output
+	ux := ux.NewUX()
+	ux.PrintAIResponse(response, !mockMode)
+	rememberResponse("response", response)
+}
+
+// askModel answers a free-form question with the model (or the mock generator)
+func askModel(promptText string, mockMode bool) (string, error) {
 	color.Blue("🤖 Thinking about: %s", promptText)
 
 	var response string
@@ -328,8 +579,7 @@ Answer:`, promptText)
 		start := time.Now()
 		response, err = ai.RunModelWithConfig(prompt, config)
 		if err != nil {
-			color.Red("❌ AI error: %v", err)
-			return
+			return "", fmt.Errorf("AI error: %w", err)
 		}
 		color.Green("✅ AI processed in %s", utils.FormatDuration(time.Since(start)))
 
@@ -341,13 +591,58 @@ Answer:`, promptText)
 	response = strings.TrimSpace(response)
 
 	if response == "" {
-		color.Red("❌ AI generated an empty response")
-		return
+		return "", fmt.Errorf("AI generated an empty response")
+	}
+	return response, nil
+}
+
+// answerFromDocs answers a question from the indexed documentation, using the
+// model only to phrase the extracted excerpt. It reports whether it answered.
+func answerFromDocs(question string, mockMode bool) bool {
+	response, citation, ok := docAnswer(question, mockMode)
+	if !ok {
+		return false
 	}
 
-	// Create UX manager for nice output
 	ux := ux.NewUX()
 	ux.PrintAIResponse(response, !mockMode)
+	color.Cyan("📖 Source: %s", citation)
+	rememberResponse("response", response+"\n\nSource: "+citation)
+	return true
+}
+
+// docAnswer answers a question from the installed documentation, phrased by
+// the model when it is loaded. It returns the answer and its citation.
+func docAnswer(question string, mockMode bool) (string, string, bool) {
+	if ragSystem == nil || !ragSystem.IsInitialized() {
+		return "", "", false
+	}
+
+	answer, ok := ragSystem.AnswerFromDocs(question)
+	if !ok {
+		return "", "", false
+	}
+	color.Blue("📘 Answering from %s", answer.Citation())
+
+	response := answer.Excerpt
+	if !mockMode && ai.ModelIsLoaded() {
+		config := ai.ModelConfig{
+			Temperature: 0.2,
+			TopP:        0.7,
+			TopK:        20,
+			MaxTokens:   150,
+		}
+
+		start := time.Now()
+		phrased, err := ai.RunModelWithConfig(pb.BuildDocAnswerPrompt(question, answer), config)
+		if err == nil && strings.TrimSpace(phrased) != "" {
+			response = strings.TrimSpace(phrased)
+			color.Green("✅ AI processed in %s", utils.FormatDuration(time.Since(start)))
+		} else {
+			color.Yellow("⚠️  Showing the documentation excerpt as is")
+		}
+	}
+	return response, answer.Citation(), true
 }
 
 // Handle /explain command
@@ -359,46 +654,84 @@ func handleExplainCommand(input string, mockMode bool) {
 		return
 	}
 
-	color.Blue("📚 Explaining command: %s", commandText)
+	explanation, err := explainWithModel(commandText, mockMode)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
 
-	var explanation string
-	var err error
+	ux := ux.NewUX()
+	ux.PrintAIResponse(explanation, !mockMode)
+	rememberResponse("explanation", explanation)
+}
+
+// explainWithModel explains a shell command with the model (or the mock generator)
+func explainWithModel(commandText string, mockMode bool) (string, error) {
+	color.Blue("📚 Explaining command: %s", commandText)
 
 	if mockMode {
-		explanation = generateMockExplanation(commandText)
-	} else {
-		// Uses RAG-enhanced explanation automatically
-		explanation, err = ai.RunModel(pb.BuildExplainPrompt(commandText))
-		if err != nil {
-			color.Red("❌ AI error: %v", err)
-			return
-		}
+		return generateMockExplanation(commandText), nil
 	}
 
-	ux := ux.NewUX()
-	ux.PrintAIResponse(explanation, !mockMode)
+	// Uses RAG-enhanced explanation automatically
+	explanation, err := ai.RunModel(pb.BuildExplainPrompt(commandText))
+	if err != nil {
+		return "", fmt.Errorf("AI error: %w", err)
+	}
+	return explanation, nil
 }
 
 // Handle /install command
 func handleInstallCommand(input string, mockMode bool) {
 	args := strings.Fields(input)
 	if len(args) < 2 {
-		color.Red("❌ Usage: /install <package-name>")
+		color.Red("❌ Usage: /install <package-name> [--via manager]")
 		color.Yellow("💡 Example: /install git")
 		return
 	}
 
+	if args[1] == "-f" || args[1] == "--file" {
+		installManifest(args[2:], mockMode)
+		return
+	}
+
 	action := "install"
 	packageName := args[1]
 
-	commands.HandlePackageCommand([]string{action, packageName}, env, mockMode, execConfig)
+	if commands.HandlePackageCommand(append([]string{action}, args[1:]...), env, mockMode, execConfig) {
+		reindexAfterPackageChange(action, packageName)
+	}
+}
+
+// installManifest runs /install -f <manifest> [--via manager]
+func installManifest(args []string, mockMode bool) {
+	if len(args) == 0 {
+		color.Red("❌ Usage: /install -f <manifest> [--via manager]")
+		color.Yellow("💡 Manifests: one package per line, a Brewfile or a requirements.txt")
+		return
+	}
+	via, err := commands.PackageVia(args[1:])
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+
+	manifest := args[0]
+	progressDir := filepath.Join(filepath.Dir(cfg.ConfigPath), "install-progress")
+	results := commands.InstallManifest(manifest, commands.ManifestProgressPath(progressDir, manifest), via, env, mockMode, execConfig)
+	for _, result := range results {
+		if result.Status == "installed" {
+			reindexAfterPackageChange("installing packages from", manifest)
+			return
+		}
+	}
 }
 
 // Handle /update command
 func handleUpdateCommand(input string, mockMode bool) {
 	args := strings.Fields(input)
 	if len(args) < 2 {
-		color.Red("❌ Usage: /update <package-name>")
+		color.Red("❌ Usage: /update <package-name> [--via manager]")
 		color.Yellow("💡 Example: /update git")
 		return
 	}
@@ -406,14 +739,16 @@ func handleUpdateCommand(input string, mockMode bool) {
 	action := "update"
 	packageName := args[1]
 
-	commands.HandlePackageCommand([]string{action, packageName}, env, mockMode, execConfig)
+	if commands.HandlePackageCommand(append([]string{action}, args[1:]...), env, mockMode, execConfig) {
+		reindexAfterPackageChange(action, packageName)
+	}
 }
 
 // Handle /remove command
 func handleRemoveCommand(input string, mockMode bool) {
 	args := strings.Fields(input)
 	if len(args) < 2 {
-		color.Red("❌ Usage: /remove <package-name>")
+		color.Red("❌ Usage: /remove <package-name> [--via manager]")
 		color.Yellow("💡 Example: /remove git")
 		return
 	}
@@ -421,130 +756,1571 @@ func handleRemoveCommand(input string, mockMode bool) {
 	action := "remove"
 	packageName := args[1]
 
-	commands.HandlePackageCommand([]string{action, packageName}, env, mockMode, execConfig)
+	if commands.HandlePackageCommand(append([]string{action}, args[1:]...), env, mockMode, execConfig) {
+		reindexAfterPackageChange(action, packageName)
+	}
 }
 
-// Handle /sandbox command
-func handleSandboxCommand(input string) {
-	args := strings.Fields(input)
-	if len(args) < 2 {
-		// Show current status
-		sandbox.PrintStatus()
-		color.Yellow("💡 Usage: /sandbox <mode>")
-		color.Yellow("Modes: off, current, strict")
-		color.Yellow("Examples:")
-		color.Yellow("  /sandbox current  - Restrict to current directory")
-		color.Yellow("  /sandbox off      - Disable restrictions")
-		color.Yellow("  /sandbox strict   - Strict mode (current + subdirs only)")
+// handleUpgradeSystemCommand runs /upgrade-system [--via manager]: a full
+// system update with the detected package manager
+func handleUpgradeSystemCommand(input string, mockMode bool) {
+	via, err := commands.PackageVia(strings.Fields(input)[1:])
+	if err != nil {
+		color.Red("❌ Usage: /upgrade-system [--via manager]")
 		return
 	}
-
-	mode := strings.ToLower(args[1])
-	switch mode {
-	case "off", "disable", "none":
-		sandbox.SetMode(commands.SandboxDisabled)
-	case "current", "dir", "normal":
-		sandbox.SetMode(commands.SandboxCurrentDir)
-	case "strict", "tight", "restricted":
-		sandbox.SetMode(commands.SandboxStrict)
-	default:
-		color.Red("❌ Unknown sandbox mode: %s", mode)
-		color.Yellow("💡 Available modes: off, current, strict")
+	pm := commands.PackageManagerFactory(env)
+	if via != "" {
+		pm = commands.PackageManagerByName(via)
+	}
+	if pm == nil {
+		color.Red("❌ No package manager detected")
+		color.Yellow("💡 --via takes: %s", strings.Join(commands.SystemPackageManagerNames, ", "))
+		return
+	}
+	if commands.UpgradeSystem(pm, env, mockMode, execConfig) {
+		reindexAfterPackageChange("upgrading", "the system")
 	}
 }
 
-// Handle /cd command
-func handleChangeDirectory(input string) {
-	targetDir := strings.TrimSpace(strings.TrimPrefix(input, "/cd"))
-	if targetDir == "" {
-		// Show current directory
-		currentDir, _ := os.Getwd()
-		color.Cyan("📁 Current directory: %s", currentDir)
+// handlePkgCommand runs package subcommands: /pkg search <query>, /pkg list
+// [filter] and /pkg outdated, each taking --via <manager>
+func handlePkgCommand(input string, mockMode bool) {
+	args := strings.Fields(input)
+	if len(args) < 2 || (args[1] == "search" && len(args) < 3) {
+		printPkgUsage()
+		return
+	}
+	switch args[1] {
+	case "managers":
+		handlePkgManagers(args[2:])
+		return
+	case "history":
+		handlePkgHistory(args[2:])
+		return
+	case "rollback":
+		handlePkgRollback(args[2:], mockMode)
 		return
 	}
 
-	if err := sandbox.ChangeDirectory(targetDir); err != nil {
-		color.Red("❌ Failed to change directory: %v", err)
+	var words []string
+	via := ""
+	for i := 2; i < len(args); i++ {
+		switch {
+		case (args[i] == "--via" || args[i] == "--manager") && i+1 < len(args):
+			via = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--via="), strings.HasPrefix(args[i], "--manager="):
+			_, via, _ = strings.Cut(args[i], "=")
+		default:
+			words = append(words, args[i])
+		}
 	}
-}
 
-// Handle /git command
-func handleGitCommand(input string) {
-	commandText := strings.TrimSpace(strings.TrimPrefix(input, "/git"))
-	if commandText == "" {
-		color.Red("❌ Usage: /git <git operation>")
-		color.Yellow("💡 Examples:")
-		color.Yellow("  /git merge feature-branch with squash and accept all changes")
-		color.Yellow("  /git undo last commit")
-		color.Yellow("  /git clean untracked files")
-		color.Yellow("  /git status")
+	pm := commands.PackageManagerFactory(env)
+	if via != "" {
+		pm = commands.PackageManagerByName(strings.ToLower(via))
+	}
+	if pm == nil {
+		color.Red("❌ No package manager detected")
+		color.Yellow("💡 --via takes: %s", strings.Join(commands.PackageManagerNames, ", "))
 		return
 	}
 
-	if err := gitManager.HandleGitRequest(commandText); err != nil {
-		color.Red("❌ Git operation failed: %v", err)
+	switch args[1] {
+	case "search":
+		searchPackages(pm, strings.Join(words, " "), via != "")
+	case "list":
+		listPackages(pm, strings.Join(words, " "))
+	case "outdated":
+		upgradeOutdatedPackages(pm, mockMode)
+	default:
+		printPkgUsage()
 	}
 }
 
-// Handle /rag-status command
-func handleRAGStatus() {
-	color.Cyan("🧠 RAG System Status:")
+// printPkgUsage shows the /pkg subcommands
+func printPkgUsage() {
+	color.Red("❌ Usage: /pkg search <query> | list [filter] | outdated [--via manager] | managers [use <manager>|auto] | history [package] | rollback <package>")
+	color.Yellow("💡 Example: /pkg search ripgrep")
+	color.Yellow("💡 Example: /pkg outdated --via pip")
+	color.Yellow("💡 Example: /pkg managers use port")
+	color.Yellow("💡 Example: /pkg rollback nodejs")
+}
 
-	if ragSystem == nil {
-		color.Red("  ❌ RAG system not initialized")
+// pkgHistoryLimit is how many operations /pkg history shows
+const pkgHistoryLimit = 30
+
+// handlePkgHistory lists the package operations Helix ran, newest first,
+// only those of a package when one is named
+func handlePkgHistory(args []string) {
+	if len(args) > 1 {
+		color.Red("❌ Usage: /pkg history [package]")
+		return
+	}
+	pkg := ""
+	if len(args) == 1 {
+		pkg = args[0]
+	}
+	entries, err := packageLedger().Entries(pkg)
+	if err != nil {
+		color.Red("❌ Could not read the package history: %v", err)
+		return
+	}
+	if len(entries) == 0 {
+		if pkg != "" {
+			color.Yellow("📭 Helix has not installed, updated or removed %s", pkg)
+		} else {
+			color.Yellow("📭 No package operations recorded yet")
+		}
 		return
 	}
 
-	stats := ragSystem.GetSystemStats()
-	indexingStatus := "UNKNOWN"
+	var rows [][]string
+	for i := len(entries) - 1; i >= 0 && len(rows) < pkgHistoryLimit; i-- {
+		entry := entries[i]
+		status := "✅ ok"
+		if !entry.Success {
+			status = "❌ failed"
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", len(rows)+1),
+			entry.Time.Local().Format("2006-01-02 15:04"),
+			entry.Action,
+			entry.Package,
+			entry.Manager,
+			utils.TruncateString(entry.Versions(), 32),
+			status,
+		})
+	}
+	color.Cyan("📜 Package history (newest first):")
+	ux.NewUX().PrintTable([]string{"#", "Time", "Action", "Package", "Manager", "Versions", "Status"}, rows)
+	if len(entries) > len(rows) {
+		color.Yellow("💡 Showing the last %d of %d operations", len(rows), len(entries))
+	}
+	color.Yellow("💡 /pkg rollback <package> suggests how to undo the last change")
+}
 
-	// Use reflection to get the indexing status if available
-	if rs, ok := interface{}(ragSystem).(interface{ GetIndexingStatus() string }); ok {
-		indexingStatus = rs.GetIndexingStatus()
+// handlePkgRollback shows how to undo the last change to a package and
+// offers to run the first command
+func handlePkgRollback(args []string, mockMode bool) {
+	if len(args) != 1 {
+		color.Red("❌ Usage: /pkg rollback <package>")
+		color.Yellow("💡 /pkg history lists the packages Helix changed")
+		return
+	}
+	pkg := args[0]
+	entry, found, err := packageLedger().LastChange(pkg)
+	if err != nil {
+		color.Red("❌ Could not read the package history: %v", err)
+		return
+	}
+	if !found {
+		color.Yellow("📭 Helix has no successful change to %s on record", pkg)
+		color.Yellow("💡 Only packages installed, updated or removed through Helix can be rolled back")
+		return
 	}
 
-	color.Cyan("  📊 Statistics:")
+	color.Cyan("⏪ Last change: %s %s via %s on %s", entry.Action, entry.Package, entry.Manager, entry.Time.Local().Format("2006-01-02 15:04"))
+	if versions := entry.Versions(); versions != "" {
+		color.Cyan("   Versions: %s", versions)
+	}
+	hints := commands.RollbackHints(entry)
+	for _, hint := range hints {
+		switch {
+		case hint.Command != "":
+			color.Green("  %s", hint.Command)
+			fmt.Printf("     %s\n", hint.Note)
+		default:
+			color.Yellow("  💡 %s", hint.Note)
+		}
+	}
+	if len(hints) == 0 || !hints[0].Runnable() || mockMode {
+		return
+	}
+
+	if !commands.AskForConfirmation("Run the rollback command?") {
+		color.Yellow("💡 Command cancelled. You can run it manually:")
+		color.Cyan("  %s", hints[0].Command)
+		return
+	}
+	if err := commands.RunRollback(entry, hints[0], env, execConfig); err != nil {
+		color.Red("❌ Rollback failed: %v", err)
+		return
+	}
+	color.Green("✅ Rolled back %s", entry.Package)
+	reindexAfterPackageChange("rolling back", entry.Package)
+}
+
+// handlePkgManagers lists the installed package managers, or with
+// use <manager>|auto sets the default one
+func handlePkgManagers(args []string) {
+	switch {
+	case len(args) == 0:
+		listPkgManagers()
+	case len(args) == 2 && args[0] == "use":
+		name := strings.ToLower(args[1])
+		if name == "auto" {
+			name = ""
+		}
+		if name != "" && !commands.IsSystemPackageManager(name) {
+			color.Red("❌ Not a system package manager: %s", args[1])
+			color.Yellow("💡 Choose one of: %s, or auto", strings.Join(commands.SystemPackageManagerNames, ", "))
+			return
+		}
+		if _, err := exec.LookPath(name); name != "" && err != nil {
+			color.Yellow("⚠️  %s is not installed; the detected manager is used until it is", name)
+		}
+		cfg.UserPrefs.PackageManager = name
+		commands.SetPreferredPackageManager(name)
+		if err := cfg.SavePreferences(); err != nil {
+			color.Red("❌ Failed to save preferences: %v", err)
+			return
+		}
+		if name == "" {
+			color.Green("✅ Packages go through the detected package manager again")
+		} else {
+			color.Green("✅ %s is now the default package manager (--via or --manager picks another one per command)", name)
+		}
+	default:
+		color.Red("❌ Usage: /pkg managers [use <manager>|auto]")
+	}
+}
+
+// redactProxy hides the password in a proxy URL
+func redactProxy(proxy string) string {
+	if u, err := url.Parse(proxy); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return proxy
+}
+
+// listPkgManagers shows every installed package manager with its version,
+// marking the one packages go through by default
+func listPkgManagers() {
+	installed := commands.InstalledPackageManagers()
+	if len(installed) == 0 {
+		color.Yellow("⚠️  No supported package manager found on the PATH")
+		return
+	}
+
+	detected := shell.DetectPackageManager(env).Name
+	current := ""
+	if pm := commands.PackageManagerFactory(env); pm != nil {
+		current = pm.Name()
+	}
+	rows := make([][]string, 0, len(installed))
+	for _, manager := range installed {
+		version := manager.Version
+		if version == "" {
+			version = "-"
+		}
+		var notes []string
+		if manager.Name == current {
+			notes = append(notes, "default")
+		}
+		if manager.Name == commands.PreferredPackageManager() {
+			notes = append(notes, "preferred")
+		}
+		if manager.Name == detected {
+			notes = append(notes, "detected")
+		}
+		if !commands.IsSystemPackageManager(manager.Name) {
+			notes = append(notes, "--via only")
+		}
+		mirror := commands.PackageMirror(manager.Name)
+		switch {
+		case mirror == "":
+			mirror = "-"
+		case !commands.MirrorSupported(manager.Name):
+			mirror += " (unsupported: set it in the system configuration)"
+		}
+		rows = append(rows, []string{manager.Name, version, mirror, manager.Path, strings.Join(notes, ", ")})
+	}
+	color.Cyan("📦 Installed package managers:")
+	ux.NewUX().PrintTable([]string{"Manager", "Version", "Mirror", "Path", "Notes"}, rows)
+	if proxy := commands.PackageProxy(); proxy != "" {
+		color.Cyan("🌐 Downloads go through the proxy %s", redactProxy(proxy))
+	}
+	color.Yellow("💡 /pkg managers use <manager> changes the default; --via <manager> (or --manager) picks one per command")
+}
+
+// searchPackages shows the ranked search results and how to install the best
+func searchPackages(pm commands.PackageManagerHandler, query string, via bool) {
+	color.Blue("🔍 Searching %s for: %s", pm.Name(), query)
+	results, err := commands.SearchPackages(pm, query)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	if len(results) == 0 {
+		color.Yellow("⚠️  No %s packages match: %s", pm.Name(), query)
+		return
+	}
+
+	var rows [][]string
+	for i, info := range results {
+		status := ""
+		if info.Installed {
+			status = "installed"
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			info.Name,
+			utils.TruncateString(info.LatestVersion, 16),
+			status,
+			utils.TruncateString(info.Description, 60),
+		})
+	}
+	ux.NewUX().PrintTable([]string{"#", "Package", "Version", "Status", "Description"}, rows)
+
+	top := results[0].Name
+	hint := "/install " + top
+	if via {
+		hint += " --via " + pm.Name()
+	}
+	color.Cyan("💡 Install with %s (runs: %s)", hint, pm.InstallCommand(top))
+}
+
+// listPackages shows the installed packages whose name contains filter
+func listPackages(pm commands.PackageManagerHandler, filter string) {
+	color.Blue("📋 Listing packages installed with %s", pm.Name())
+	packages, err := commands.ListPackages(pm)
+	if err != nil && len(packages) == 0 {
+		color.Red("❌ %v", err)
+		return
+	}
+
+	var rows [][]string
+	for _, info := range packages {
+		if filter != "" && !strings.Contains(strings.ToLower(info.Name), strings.ToLower(filter)) {
+			continue
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", len(rows)+1),
+			info.Name,
+			utils.TruncateString(info.Version, 24),
+			utils.TruncateString(info.Description, 60),
+		})
+	}
+	if len(rows) == 0 {
+		color.Yellow("⚠️  No installed %s packages match: %s", pm.Name(), filter)
+		return
+	}
+	ux.NewUX().PrintTable([]string{"#", "Package", "Version", "Description"}, rows)
+	color.Green("✅ %d of %d %s packages", len(rows), len(packages), pm.Name())
+}
+
+// upgradeOutdatedPackages shows the packages with a newer version and
+// upgrades the ones the user picks
+func upgradeOutdatedPackages(pm commands.PackageManagerHandler, mockMode bool) {
+	color.Blue("🔍 Checking %s for upgrades", pm.Name())
+	packages, err := commands.OutdatedPackages(pm)
+	if err != nil && len(packages) == 0 {
+		color.Red("❌ %v", err)
+		return
+	}
+	if len(packages) == 0 {
+		color.Green("✅ All %s packages are up to date", pm.Name())
+		return
+	}
+
+	var rows [][]string
+	for i, info := range packages {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			info.Name,
+			utils.TruncateString(info.Version, 24),
+			utils.TruncateString(info.LatestVersion, 24),
+		})
+	}
+	ux.NewUX().PrintTable([]string{"#", "Package", "Installed", "Available"}, rows)
+
+	selected := selectPackages(packages)
+	if len(selected) == 0 {
+		color.Yellow("💡 Nothing upgraded")
+		return
+	}
+	if commands.UpgradePackages(pm, selected, env, mockMode, execConfig) {
+		reindexAfterPackageChange("upgrade", strings.Join(selected, ", "))
+	}
+}
+
+// selectPackages asks which of the listed packages to upgrade, by number
+// or a for all
+func selectPackages(packages []commands.PackageInfo) []string {
+	fmt.Print("Upgrade which packages? [numbers e.g. 1 3, a for all, Enter for none]: ")
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.ToLower(strings.TrimSpace(response))
+
+	var selected []string
+	if response == "a" || response == "all" {
+		for _, info := range packages {
+			selected = append(selected, info.Name)
+		}
+		return selected
+	}
+	seen := make(map[int]bool)
+	for _, word := range strings.FieldsFunc(response, func(r rune) bool { return r == ' ' || r == ',' }) {
+		n, err := strconv.Atoi(word)
+		if err != nil || n < 1 || n > len(packages) {
+			color.Yellow("⚠️  Skipping %s: not a package number", word)
+			continue
+		}
+		if !seen[n] {
+			seen[n] = true
+			selected = append(selected, packages[n-1].Name)
+		}
+	}
+	return selected
+}
+
+// Handle /sandbox command
+func handleSandboxCommand(input string) {
+	args := strings.Fields(input)
+	if len(args) < 2 {
+		// Show current status
+		sandbox.PrintStatus()
+		color.Yellow("💡 Usage: /sandbox <mode>")
+		color.Yellow("Modes: off, current, strict")
+		color.Yellow("Examples:")
+		color.Yellow("  /sandbox current  - Restrict to current directory")
+		color.Yellow("  /sandbox off      - Disable restrictions")
+		color.Yellow("  /sandbox strict   - Strict mode (current + subdirs only)")
+		color.Yellow("  /sandbox allow ~/projects - Also allow a path outside the current directory")
+		color.Yellow("  /sandbox deny ./secrets   - Never allow a path, even inside the current directory")
+		color.Yellow("  /sandbox remove <path>    - Forget an allowed or denied path")
+		color.Yellow("  /sandbox network off      - Block network access for this session (on to allow it)")
+		color.Yellow("  /sandbox test             - Try known escape techniques against the sandbox rules")
+		return
+	}
+
+	mode := strings.ToLower(args[1])
+	switch mode {
+	case "test":
+		runSandboxEscapeTests()
+	case "network":
+		handleSandboxNetwork(args[2:])
+	case "allow", "deny", "remove":
+		handleSandboxPath(mode, trimQuotes(strings.Join(args[2:], " ")))
+	default:
+		parsed, err := commands.ParseSandboxMode(mode)
+		if err != nil {
+			color.Red("❌ Unknown sandbox mode: %s", mode)
+			color.Yellow("💡 Available modes: off, current, strict")
+			return
+		}
+		sandbox.SetMode(parsed)
+	}
+}
+
+// runSandboxEscapeTests tries a battery of escape techniques against the
+// sandbox rules and reports which are blocked
+func runSandboxEscapeTests() {
+	results, err := sandbox.TestEscapes()
+	if err != nil {
+		color.Red("❌ Cannot set up the sandbox test: %v", err)
+		return
+	}
+	if sandbox.GetMode() == commands.SandboxDisabled {
+		color.Yellow("⚠️  The sandbox is off, so nothing is blocked; showing what current-directory mode would block")
+	}
+	color.Cyan("🧪 Sandbox escape tests (%s, in a scratch directory; nothing is run):", sandbox.ModeString())
+
+	blocked, escaped, skipped, wronglyBlocked := 0, 0, 0, 0
+	category := ""
+	for _, result := range results {
+		if result.Category != category {
+			category = result.Category
+			color.Cyan("  %s:", category)
+		}
+		switch {
+		case result.Skipped != "":
+			skipped++
+			color.Yellow("    ⏭️  %-28s %s (%s)", result.Description, result.Command, result.Skipped)
+		case result.Allowed && result.Blocked:
+			wronglyBlocked++
+			color.Red("    ⚠️  %-28s %s", result.Description, result.Command)
+			color.Red("        wrongly blocked: %s", result.Reason)
+		case result.Allowed:
+			color.Green("    ✅ %-28s %s", result.Description, result.Command)
+		case result.Blocked:
+			blocked++
+			color.Green("    🛡️  %-28s %s", result.Description, result.Command)
+			color.Green("        blocked: %s", result.Reason)
+		default:
+			escaped++
+			color.Red("    ⚠️  %-28s %s", result.Description, result.Command)
+		}
+	}
+
+	fmt.Println()
+	summary := fmt.Sprintf("🧪 %d blocked, %d got through, %d skipped", blocked, escaped, skipped)
+	if wronglyBlocked > 0 {
+		summary += fmt.Sprintf(", %d commands inside wrongly blocked", wronglyBlocked)
+	}
+	if escaped > 0 || wronglyBlocked > 0 {
+		color.Yellow(summary)
+		color.Yellow("💡 Commands that get through still need your confirmation; /sandbox deny and the policy file can close specific paths")
+	} else {
+		color.Green(summary)
+	}
+}
+
+// handleSandboxNetwork blocks or allows network access for commands:
+// /sandbox network on|off
+func handleSandboxNetwork(args []string) {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		color.Red("❌ Usage: /sandbox network on|off")
+		return
+	}
+	if args[0] == "on" {
+		sandbox.SetOffline(false)
+		color.Green("🌐 Network access allowed")
+		return
+	}
+
+	sandbox.SetOffline(true)
+	color.Yellow("🔌 Network access blocked: commands using curl, wget, ssh, nc and other network clients are refused")
+	if tool := commands.NetworkIsolationTool(); tool != "" {
+		color.Green("🛡️  Commands also run without a network (%s)", filepath.Base(tool))
+	} else {
+		color.Yellow("💡 Install firejail (or enable user namespaces for unshare) to also cut commands off from the network")
+	}
+	if env.Remote != nil {
+		color.Yellow("💡 Commands for %s are checked, but run there with its network", env.Remote.Host)
+	}
+}
+
+// handleSandboxPath allows, denies or forgets a sandbox path and saves the
+// lists in the config
+func handleSandboxPath(action, path string) {
+	if path == "" {
+		color.Red("❌ Usage: /sandbox %s <path>", action)
+		return
+	}
+
+	var err error
+	switch action {
+	case "allow":
+		if path, err = sandbox.AllowPath(path); err == nil {
+			color.Green("✅ Commands may now use %s", path)
+		}
+	case "deny":
+		if path, err = sandbox.DenyPath(path); err == nil {
+			color.Green("⛔ Commands may no longer use %s", path)
+		}
+	case "remove":
+		if !sandbox.RemovePath(path) {
+			color.Yellow("💡 %s is neither allowed nor denied", path)
+			return
+		}
+		color.Green("✅ %s removed from the sandbox paths", path)
+	}
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	if sandbox.GetMode() == commands.SandboxDisabled {
+		color.Yellow("💡 The sandbox is off; the paths apply once it is on (/sandbox current)")
+	}
+
+	cfg.Sandbox = sandbox.Paths()
+	if err := cfg.SavePreferences(); err != nil {
+		color.Yellow("⚠️  Sandbox paths applied but could not be saved: %v", err)
+	}
+}
+
+// Handle /cd command
+func handleChangeDirectory(input string) {
+	targetDir := strings.TrimSpace(strings.TrimPrefix(input, "/cd"))
+	if targetDir == "" {
+		// Show current directory
+		currentDir, _ := os.Getwd()
+		color.Cyan("📁 Current directory: %s", currentDir)
+		return
+	}
+
+	if err := sandbox.ChangeDirectory(targetDir); err != nil {
+		color.Red("❌ Failed to change directory: %v", err)
+		return
+	}
+	applyProjectProfile()
+}
+
+// Handle /pushd command
+func handlePushDirectory(input string) {
+	targetDir := strings.TrimSpace(strings.TrimPrefix(input, "/pushd"))
+	if err := sandbox.PushDirectory(targetDir); err != nil {
+		color.Red("❌ Failed to change directory: %v", err)
+		if targetDir == "" {
+			color.Yellow("💡 Usage: /pushd <dir>")
+		}
+		return
+	}
+	applyProjectProfile()
+	showDirectoryStack()
+}
+
+// Handle /popd command
+func handlePopDirectory() {
+	if err := sandbox.PopDirectory(); err != nil {
+		color.Red("❌ Failed to change directory: %v", err)
+		return
+	}
+	applyProjectProfile()
+	showDirectoryStack()
+}
+
+// showDirectoryStack prints the current directory and the stack, like dirs
+func showDirectoryStack() {
+	color.Cyan("📚 Directory stack:")
+	for i, dir := range sandbox.Directories() {
+		color.White("  %d  %s", i, dir)
+	}
+}
+
+// Handle /git command
+func handleGitCommand(input string) {
+	commandText := strings.TrimSpace(strings.TrimPrefix(input, "/git"))
+	if commandText == "" {
+		color.Red("❌ Usage: /git <git operation>")
+		color.Yellow("💡 Examples:")
+		color.Yellow("  /git merge feature-branch with squash and accept all changes")
+		color.Yellow("  /git undo last commit")
+		color.Yellow("  /git clean untracked files")
+		color.Yellow("  /git status")
+		return
+	}
+
+	if err := gitManager.HandleGitRequest(commandText); err != nil {
+		color.Red("❌ Git operation failed: %v", err)
+	}
+}
+
+// handleDockerCommand runs a natural-language container request with docker or podman
+func handleDockerCommand(input string) {
+	commandText := strings.TrimSpace(strings.TrimPrefix(input, "/docker"))
+	if commandText == "" {
+		color.Red("❌ Usage: /docker <container operation>")
+		color.Yellow("💡 Examples:")
+		color.Yellow("  /docker clean dangling images")
+		color.Yellow("  /docker show logs of web")
+		color.Yellow("  /docker rebuild the compose stack")
+		color.Yellow("  /docker list all containers")
+		return
+	}
+
+	dockerManager.SetExecuteConfig(execConfig)
+	if err := dockerManager.HandleDockerRequest(commandText); err != nil {
+		color.Red("❌ Container operation failed: %v", err)
+	}
+}
+
+// handleKubernetesCommand runs a natural-language or kubectl request against the current context
+func handleKubernetesCommand(input string) {
+	commandText := strings.TrimSpace(strings.TrimPrefix(input, "/k8s"))
+	if commandText == "" {
+		color.Red("❌ Usage: /k8s <request or kubectl command>")
+		color.Yellow("💡 Examples:")
+		color.Yellow("  /k8s show pods that keep restarting")
+		color.Yellow("  /k8s delete the failed jobs")
+		color.Yellow("  /k8s kubectl drain node-3 --ignore-daemonsets")
+		return
+	}
+
+	k8sManager.SetExecuteConfig(execConfig)
+	if err := k8sManager.HandleKubernetesRequest(commandText); err != nil {
+		color.Red("❌ Kubernetes operation failed: %v", err)
+	}
+}
+
+// Handle /rag-status command
+func handleRAGStatus() {
+	color.Cyan("🧠 RAG System Status:")
+
+	if ragSystem == nil {
+		color.Red("  ❌ RAG system not initialized")
+		return
+	}
+
+	stats := ragSystem.GetSystemStats()
+	indexingStatus := "UNKNOWN"
+
+	// Use reflection to get the indexing status if available
+	if rs, ok := interface{}(ragSystem).(interface{ GetIndexingStatus() string }); ok {
+		indexingStatus = rs.GetIndexingStatus()
+	}
+
+	color.Cyan("  📊 Statistics:")
 	color.Cyan("    • Initialized: %v", stats["initialized"])
 	color.Cyan("    • Indexed MAN Pages: %v", stats["indexed_pages"])
 	color.Cyan("    • Indexing Status: %s", indexingStatus)
+	color.Cyan("    • Index Builder: %v", stats["index_builder"])
+	if count := ragSystem.HistoryCommandCount(); count > 0 {
+		color.Cyan("    • Shell History Commands: %d (in memory only)", count)
+	}
+	if feedback := ragSystem.FeedbackStats(3); feedback.Total() > 0 {
+		color.Cyan("    • /cmd Feedback: %d accepted, %d edited, %d rejected",
+			feedback.Accepted, feedback.Edited, feedback.Rejected)
+		for _, doc := range feedback.Boosted {
+			color.Green("      ↑ %s (×%.2f)", doc.ID, doc.Weight)
+		}
+		for _, doc := range feedback.Demoted {
+			color.Yellow("      ↓ %s (×%.2f)", doc.ID, doc.Weight)
+		}
+	}
+	if stats["index_stale"].(bool) {
+		color.Yellow("    • Index built by an older Helix - run '/rag-reindex full' to rebuild")
+	}
+	if stats["rebuilding"].(bool) {
+		color.Yellow("    • Rebuild in progress (serving the existing index)")
+	}
+	if progress, running := ragSystem.IndexingProgress(); running {
+		color.Yellow("    • %s", formatIndexingProgress(progress))
+	}
+
+	if stats["initialized"].(bool) {
+		color.Green("  ✅ RAG system is ACTIVE")
+		color.Cyan("    • Vector Documents: %v", stats["total_documents"])
+		color.Cyan("    • Unique Commands: %v", stats["unique_commands"])
+		if lang := ragSystem.LocalizedLanguage(); lang != "" {
+			color.Cyan("    • Localized MAN pages: %s", lang)
+		}
+		if memory := ragSystem.MemoryStats(); memory.Budget > 0 {
+			color.Cyan("    • Memory: %s of %s budget, %d documents paged to disk (%d hits, %d reads)",
+				utils.FormatBytes(memory.Resident), utils.FormatBytes(memory.Budget), memory.Paged, memory.Hits, memory.Misses)
+		}
+	} else {
+		color.Yellow("  🔄 RAG system is %s...", indexingStatus)
+
+		// Show estimated time based on typical indexing
+		if stats["indexed_pages"].(int) > 0 {
+			color.Cyan("    • Progress: %d pages indexed", stats["indexed_pages"])
+		}
+	}
+}
+
+// Handle /rag-reindex command
+func handleRAGReindex(input string) {
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	// Results cached before the reindex would hide its changes
+	ragSystem.ClearRetrievalCache()
+
+	args := strings.Fields(input)
+	if len(args) > 1 && (args[1] == "full" || args[1] == "--full") {
+		color.Blue("🔄 Full RAG reindexing...")
+
+		// The current index keeps serving queries until the rebuilt one replaces it
+		if !ragSystem.RebuildIndex() {
+			color.Yellow("💡 A RAG rebuild is already running")
+			return
+		}
+		color.Green("✅ RAG reindexing started in background")
+		return
+	}
+
+	color.Blue("🔄 Incremental RAG reindexing (use '/rag-reindex full' to rebuild everything)...")
+	go func() {
+		summary, err := ragSystem.IncrementalReindex()
+		if err != nil {
+			color.Red("❌ RAG reindexing failed: %v", err)
+			return
+		}
+		printReindexSummary(summary)
+	}()
+}
+
+// printReindexSummary shows which commands an incremental reindex touched
+func printReindexSummary(summary *rag.ReindexSummary) {
+	for _, source := range rag.RetrievalSources() {
+		if changed := summary.Sources[source]; changed > 0 {
+			color.Cyan("   📗 %s: %d documents refreshed", source, changed)
+		}
+	}
+
+	if !summary.HasChanges() && len(summary.Failed) == 0 {
+		color.Green("✅ RAG index is up to date (%d commands unchanged, %s)",
+			summary.Unchanged, utils.FormatDuration(summary.Duration))
+		return
+	}
+
+	color.Green("✅ RAG reindex completed in %s", utils.FormatDuration(summary.Duration))
+	printCommandList("➕ Added", summary.Added)
+	printCommandList("🔄 Updated", summary.Updated)
+	printCommandList("➖ Removed", summary.Removed)
+	printCommandList("⚠️  Failed", summary.Failed)
+	color.Cyan("   Unchanged: %d", summary.Unchanged)
+	if summary.Compaction != nil {
+		printCompactionSummary(summary.Compaction)
+	}
+}
+
+// handleRAGConfig shows or changes the retrieval settings and saves them to the config file
+func handleRAGConfig(input string) {
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	args := strings.Fields(input)
+	switch {
+	case len(args) == 1:
+		// Show the current settings below
+	case args[1] == "reset":
+		cfg.Retrieval = rag.DefaultRetrievalConfig()
+		ragSystem.SetRetrievalConfig(cfg.Retrieval)
+		color.Green("✅ Retrieval settings reset to defaults")
+	case args[1] == "set" && len(args) == 4:
+		updated, err := ragSystem.RetrievalConfig().Set(args[2], args[3])
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		cfg.Retrieval = updated
+		ragSystem.SetRetrievalConfig(updated)
+		color.Green("✅ %s set to %s", args[2], args[3])
+		if strings.HasPrefix(args[2], "source.") {
+			color.Yellow("💡 Run /rag-reindex to add or remove the source's documents")
+		}
+	default:
+		color.Red("❌ Usage: /rag-config [set <key> <value> | reset]")
+		color.Yellow("💡 Keys: top_k, min_score, rerank on|off, memory_mb, weight.<source>, source.<source> on|off")
+		color.Yellow("💡 Sources: %s", strings.Join(rag.RetrievalSources(), ", "))
+		return
+	}
+
+	if len(args) > 1 {
+		if err := cfg.SavePreferences(); err != nil {
+			color.Yellow("⚠️  Settings applied but could not be saved: %v", err)
+		}
+	}
+
+	current := ragSystem.RetrievalConfig()
+	color.Cyan("🎛️  Retrieval Settings:")
+	color.Cyan("    • top_k: %d", current.TopK)
+	color.Cyan("    • min_score: %g", current.MinScore)
+	if current.MemoryMB > 0 {
+		color.Cyan("    • memory_mb: %d", current.MemoryMB)
+	} else {
+		color.Cyan("    • memory_mb: unlimited")
+	}
+	if current.Rerank {
+		color.Cyan("    • rerank: on (the local model reorders the top candidates)")
+	} else {
+		color.Cyan("    • rerank: off")
+	}
+	for _, source := range rag.RetrievalSources() {
+		if !current.Enabled(source) {
+			color.Yellow("    • weight.%s: disabled", source)
+			continue
+		}
+		color.Cyan("    • weight.%s: %g", source, current.Weight(source))
+	}
+	if len(current.DisabledSources) > 0 {
+		color.Yellow("💡 Turn a source back on with /rag-config set source.<name> on, then /rag-reindex")
+	}
+}
+
+// handleRAGCompact removes orphaned and fragmented documents and shrinks the index file
+func handleRAGCompact() {
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	color.Blue("🧹 Compacting RAG index...")
+	summary, err := ragSystem.Compact()
+	if err != nil {
+		color.Red("❌ RAG compaction failed: %v", err)
+		return
+	}
+	printCompactionSummary(summary)
+}
+
+// handleRAGVerify checks every stored document and rebuilds the damaged commands
+func handleRAGVerify() {
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	color.Blue("🔍 Verifying RAG index...")
+	report, err := ragSystem.VerifyIndex()
+	if err != nil {
+		color.Red("❌ RAG verification failed: %v", err)
+		color.Yellow("💡 Run /rag-reindex full to rebuild the index")
+		return
+	}
+
+	color.Cyan("   Schema: %s", report.Schema)
+	color.Cyan("   Documents checked: %s", utils.FormatNumber(int64(report.Documents)))
+	if report.Healthy() {
+		color.Green("✅ RAG index is intact (%s)", utils.FormatDuration(report.Duration))
+		return
+	}
+
+	if len(report.Corrupted) > 0 {
+		color.Red("❌ Corrupted documents: %d", len(report.Corrupted))
+	}
+	printCommandList("📭 Missing from index", report.Missing)
+	printCommandList("🔧 Commands to rebuild", report.Commands)
+
+	if !commands.AskForConfirmation("Rebuild the damaged part of the index?") {
+		color.Yellow("💡 Run /rag-verify again to repair, or /rag-reindex full to rebuild everything")
+		return
+	}
+
+	repaired, failed, err := ragSystem.RepairIndex(report)
+	if err != nil {
+		color.Red("❌ RAG repair failed: %v", err)
+		return
+	}
+	color.Green("✅ Rebuilt %d commands", len(repaired))
+	printCommandList("⚠️  Could not rebuild", failed)
+}
+
+// evalTestSetFile is the test set /rag-eval uses instead of the built-in one when present
+const evalTestSetFile = "rag_eval.yaml"
+
+// handleRAGEval scores retrieval against a test set of queries and expected
+// commands: /rag-eval [k] [file]
+func handleRAGEval(input string) {
+	if ragSystem == nil || !ragSystem.IsInitialized() {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	k := ragSystem.RetrievalConfig().TopK
+	path := filepath.Join(filepath.Dir(cfg.ConfigPath), evalTestSetFile)
+	if _, err := os.Stat(path); err != nil {
+		path = ""
+	}
+	for _, arg := range strings.Fields(input)[1:] {
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n < 1 || n > 20 {
+				color.Red("❌ k must be between 1 and 20")
+				return
+			}
+			k = n
+		} else {
+			path = arg
+		}
+	}
+
+	cases, err := eval.LoadTestSet(path)
+	if err != nil {
+		color.Red("❌ Could not load test set: %v", err)
+		return
+	}
+	testSet := path
+	if testSet == "" {
+		testSet = "built-in"
+	}
+
+	color.Blue("🧪 Evaluating retrieval on %d queries (k=%d, test set: %s)", len(cases), k, testSet)
+	startTime := time.Now()
+	report := eval.Run(cases, k, func(query string, k int) ([]string, error) {
+		hits, err := ragSystem.SearchCommands(query, k)
+		var commands []string
+		for _, hit := range hits {
+			commands = append(commands, hit.Command)
+		}
+		return commands, err
+	})
+
+	for _, miss := range report.Misses() {
+		retrieved := strings.Join(miss.Retrieved, ", ")
+		if retrieved == "" {
+			retrieved = "nothing"
+		}
+		color.Yellow("  ❌ %q → %s (expected %s)", miss.Query, retrieved, strings.Join(miss.Expected, " or "))
+	}
+
+	color.Cyan("📊 precision@%d: %.3f   recall@%d: %.3f   MRR: %.3f",
+		k, report.Precision, k, report.Recall, report.MRR)
+	color.Cyan("   %d of %d queries found an expected command in %s",
+		len(report.Cases)-len(report.Misses()), len(report.Cases), utils.FormatDuration(time.Since(startTime)))
+	if ragSystem.RetrievalConfig().Rerank {
+		color.Yellow("💡 Model reranking is not part of the evaluation")
+	}
+}
+
+// defaultIndexArchive is where /rag-export writes when no path is given
+const defaultIndexArchive = "helix-rag-index.tar.gz"
+
+// handleRAGExport packs the RAG index into one archive for another machine
+func handleRAGExport(input string) {
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	path := strings.TrimSpace(strings.TrimPrefix(input, "/rag-export"))
+	if path == "" {
+		path = defaultIndexArchive
+	}
+
+	color.Blue("📦 Exporting RAG index...")
+	archive, err := ragSystem.ExportIndex(path)
+	if err != nil {
+		color.Red("❌ RAG export failed: %v", err)
+		return
+	}
+
+	size := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	color.Green("✅ Exported %s documents (%s commands) to %s (%s)", utils.FormatNumber(int64(archive.Documents)),
+		utils.FormatNumber(int64(archive.Commands)), path, utils.FormatBytes(size))
+	color.Cyan("💡 On the target machine run: /rag-import %s", path)
+}
+
+// handleRAGImport replaces the RAG index with an exported archive
+func handleRAGImport(input string) {
+	path := strings.TrimSpace(strings.TrimPrefix(input, "/rag-import"))
+	if path == "" {
+		color.Red("❌ Usage: /rag-import <archive>")
+		return
+	}
+
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	if !commands.AskForConfirmation(fmt.Sprintf("Replace the current RAG index with %s?", path)) {
+		color.Yellow("❌ Import cancelled")
+		return
+	}
+
+	archive, err := ragSystem.ImportIndex(path)
+	if err != nil {
+		color.Red("❌ RAG import failed: %v", err)
+		return
+	}
+	color.Green("✅ Imported %s documents (%s commands) exported on %s",
+		utils.FormatNumber(int64(archive.Documents)), utils.FormatNumber(int64(archive.Commands)), archive.CreatedAt.Format(time.RFC822))
+}
+
+// printCompactionSummary shows what a compaction removed and reclaimed
+func printCompactionSummary(summary *rag.CompactionSummary) {
+	if !summary.HasChanges() && summary.Reclaimed() == 0 {
+		color.Green("✅ RAG index is already compact (%s)", utils.FormatBytes(summary.SizeAfter))
+		return
+	}
+
+	color.Green("✅ RAG index compacted in %s", utils.FormatDuration(summary.Duration))
+	printCommandList("🗑️  Uninstalled", summary.OrphanCommands)
+	if summary.OrphanDocs > 0 {
+		color.Cyan("   Orphaned documents removed: %d", summary.OrphanDocs)
+	}
+	if summary.MergedDocs > 0 {
+		color.Cyan("   Fragmented documents merged: %d", summary.MergedDocs)
+	}
+	if summary.EmptyDocs > 0 {
+		color.Cyan("   Empty documents removed: %d", summary.EmptyDocs)
+	}
+	color.Cyan("   Size: %s → %s (%s reclaimed)", utils.FormatBytes(summary.SizeBefore),
+		utils.FormatBytes(summary.SizeAfter), utils.FormatBytes(summary.Reclaimed()))
+}
+
+// printCommandList prints a labelled, truncated list of command names
+func printCommandList(label string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	const maxShown = 15
+	shown := names
+	if len(shown) > maxShown {
+		shown = shown[:maxShown]
+	}
+
+	line := strings.Join(shown, ", ")
+	if len(names) > maxShown {
+		line += fmt.Sprintf(", ... (+%d more)", len(names)-maxShown)
+	}
+	color.Cyan("   %s (%d): %s", label, len(names), line)
+}
+
+// handleRAGAdd indexes the current repository's README, docs, Makefile targets
+// and package.json scripts for project-aware grounding
+func handleRAGAdd(input string) {
+	path := strings.TrimSpace(strings.TrimPrefix(input, "/rag-add"))
+	if path == "" {
+		path = "."
+	}
+
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	color.Blue("📁 Indexing project documentation in %s...", path)
+	summary, err := ragSystem.AddProject(path)
+	if err != nil {
+		color.Red("❌ Project indexing failed: %v", err)
+		return
+	}
 
-	if stats["initialized"].(bool) {
-		color.Green("  ✅ RAG system is ACTIVE")
-		color.Cyan("    • Vector Documents: %v", stats["total_documents"])
-		color.Cyan("    • Unique Commands: %v", stats["unique_commands"])
-	} else {
-		color.Yellow("  🔄 RAG system is %s...", indexingStatus)
+	color.Green("✅ Project indexed: %s (%s)", summary.Root, utils.FormatDuration(summary.Duration))
+	color.Cyan("   📄 Docs: %d files, %d chunks", summary.DocFiles, summary.DocChunks)
+	color.Cyan("   🛠️  Makefile targets: %d", summary.MakeTargets)
+	color.Cyan("   📦 package.json scripts: %d", summary.Scripts)
+	color.Cyan("💡 /cmd and /ask now use this project's tooling while you work inside it")
+}
 
-		// Show estimated time based on typical indexing
-		if stats["indexed_pages"].(int) > 0 {
-			color.Cyan("    • Progress: %d pages indexed", stats["indexed_pages"])
+// handleManCommand shows a MAN page summary, in the user's language when localized pages are enabled
+func handleManCommand(input string) {
+	command := strings.TrimSpace(strings.TrimPrefix(input, "/man"))
+	if command == "" {
+		color.Red("❌ Usage: /man <command>")
+		return
+	}
+
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	page, err := ragSystem.ReadManPage(command)
+	if err != nil {
+		color.Red("❌ No MAN page found for %s", command)
+		return
+	}
+
+	language := page.Language
+	if language == "" {
+		language = "en"
+	}
+	color.Cyan("📖 %s (%s)", page.Name, language)
+	if page.Description != "" {
+		color.White("   %s", page.Description)
+	}
+	if page.Synopsis != "" {
+		color.Cyan("   Synopsis:")
+		color.White("   %s", page.Synopsis)
+	}
+	if len(page.Options) > 0 {
+		color.Cyan("   Options:")
+		for i, option := range page.Options {
+			if i == 10 {
+				color.White("   ... (+%d more, see 'man %s')", len(page.Options)-10, page.Name)
+				break
+			}
+			color.White("   %s", option)
+		}
+	}
+	if len(page.Examples) > 0 {
+		color.Cyan("   Examples:")
+		for _, example := range page.Examples {
+			color.White("   %s", example)
 		}
 	}
 }
 
-// Handle /rag-reindex command
-func handleRAGReindex() {
-	color.Blue("🔄 Manual RAG reindexing...")
+// whatisOptionLimit caps the options /whatis lists; the MAN page has the rest
+const whatisOptionLimit = 15
+
+// handleWhatisCommand answers an exact command lookup straight from the
+// index, without the model, so the output is instant and quotes the docs
+func handleWhatisCommand(input string) {
+	command := strings.TrimSpace(strings.TrimPrefix(input, "/whatis"))
+	if command == "" || strings.ContainsAny(command, " \t") {
+		color.Red("❌ Usage: /whatis <command>")
+		color.Yellow("💡 Example: /whatis tar")
+		return
+	}
 
 	if ragSystem == nil {
 		color.Red("❌ RAG system not initialized")
 		return
 	}
 
-	// Force reindex by removing state
-	homeDir, _ := os.UserHomeDir()
-	stateFile := filepath.Join(homeDir, ".helix", "rag_index", "rag_state.json")
-	os.Remove(stateFile)
+	info, err := ragSystem.LookupCommand(command)
+	if err != nil {
+		color.Red("❌ %s is not in the index", command)
+		color.Yellow("💡 Try /search %s or /explain %s", command, command)
+		return
+	}
+
+	ux.NewUX().ShowCommandExplanation(command, formatCommandInfo(info))
+	color.Cyan("📚 Source: indexed documentation (no AI)")
+}
+
+// formatCommandInfo renders indexed command information in the markup
+// ShowCommandExplanation formats
+func formatCommandInfo(info *rag.CommandInfo) string {
+	var sb strings.Builder
+
+	if info.AliasOf != "" {
+		sb.WriteString(fmt.Sprintf("Documented as %s (%s runs the same program)\n\n", info.AliasOf, info.Name))
+	}
+	sb.WriteString("**Description**\n")
+	sb.WriteString(info.Description + "\n\n")
+
+	if info.Synopsis != "" {
+		sb.WriteString("**Synopsis**\n")
+		sb.WriteString("```bash " + info.Synopsis + "\n\n")
+	}
+
+	if len(info.Options) > 0 {
+		sb.WriteString("**Options**\n")
+		for i, option := range info.Options {
+			if i == whatisOptionLimit {
+				page := info.Name
+				if info.AliasOf != "" {
+					page = info.AliasOf
+				}
+				sb.WriteString(fmt.Sprintf("... (+%d more, see 'man %s')\n", len(info.Options)-whatisOptionLimit, page))
+				break
+			}
+			sb.WriteString("  • " + option + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(info.Examples) > 0 {
+		sb.WriteString("**Examples**\n")
+		for _, example := range info.Examples {
+			sb.WriteString("```bash " + example + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{
+		{"Exit Status", info.ExitStatus},
+		{"Files", info.Files},
+		{"Environment", info.Environment},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		sb.WriteString("**" + section.title + "**\n")
+		for _, entry := range section.entries {
+			sb.WriteString("  • " + entry + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// handleRetrieveCommand runs RAG retrieval for a query without the model and
+// shows how documents ranked and what context would be injected
+func handleRetrieveCommand(input string) {
+	query := strings.TrimSpace(strings.TrimPrefix(input, "/retrieve"))
+	if query == "" {
+		color.Red("❌ Usage: /retrieve <query>")
+		return
+	}
+
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	trace, err := ragSystem.TraceRetrieval(query)
+	if err != nil {
+		color.Red("❌ Retrieval failed: %v", err)
+		return
+	}
+
+	color.Cyan("🔎 Retrieval trace for: %s", trace.Query)
+	color.Cyan("   Query terms: %s", strings.Join(trace.Terms, ", "))
+
+	if len(trace.Documents) == 0 {
+		color.Yellow("⚠️  No documents matched")
+	} else {
+		color.Cyan("📊 Ranked documents:")
+		for i, scored := range trace.Documents {
+			doc := scored.Document
+			section := doc.Metadata.Section
+			if doc.Metadata.ChunkSection != "" {
+				section = fmt.Sprintf("%s %s#%d", section, doc.Metadata.ChunkSection, doc.Metadata.ChunkIndex)
+			}
+			color.White("  %2d. %-16s %-22s score %.2f  matched: %s",
+				i+1, doc.Metadata.Command, section, scored.Score, strings.Join(scored.MatchedTerms, ", "))
+			color.White("      %s", utils.TruncateString(doc.Content, 100))
+		}
+	}
+
+	var injected []string
+	for _, cmd := range trace.Result.Commands {
+		injected = append(injected, cmd.Name)
+	}
+	if len(injected) > 0 {
+		color.Green("✅ Injected commands: %s", strings.Join(injected, ", "))
+	} else {
+		color.Yellow("⚠️  No commands would be injected; the prompt is sent unchanged")
+	}
+	if len(trace.Dropped) > 0 {
+		color.Yellow("🚫 Ranked but not injected: %s", strings.Join(trace.Dropped, ", "))
+	}
+
+	if trace.Context != "" {
+		color.Cyan("📝 Context that would be injected:")
+		fmt.Println(trace.Context)
+	}
+	color.Cyan("⏱️  Retrieval took %s", utils.FormatDuration(trace.Duration))
+}
+
+// handleSearchCommand ranks indexed commands for a query straight from the
+// vector store, without calling the model
+func handleSearchCommand(input string) {
+	query := strings.TrimSpace(strings.TrimPrefix(input, "/search"))
+	if query == "" {
+		color.Red("❌ Usage: /search <what you want to do>")
+		return
+	}
+
+	if ragSystem == nil {
+		color.Red("❌ RAG system not initialized")
+		return
+	}
+
+	const searchResultLimit = 10
+	startTime := time.Now()
+	hits, err := ragSystem.SearchCommands(query, searchResultLimit)
+	if err != nil {
+		color.Red("❌ Search failed: %v", err)
+		return
+	}
+	if len(hits) == 0 {
+		color.Yellow("⚠️  No indexed commands match: %s", query)
+		return
+	}
+
+	var rows [][]string
+	for i, hit := range hits {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			hit.Command,
+			fmt.Sprintf("%.2f", hit.Score),
+			utils.TruncateString(hit.Description, 50),
+			utils.TruncateString(hit.Example, 40),
+		})
+	}
+
+	color.Cyan("🔎 Commands matching: %s", query)
+	ux.NewUX().PrintTable([]string{"#", "Command", "Score", "Description", "Example"}, rows)
+	color.Cyan("⏱️  Found %d commands in %s", len(hits), utils.FormatDuration(time.Since(startTime)))
+}
+
+// handleJobsCommand manages background jobs: /jobs [list|logs <id> [lines]|kill <id>]
+func handleJobsCommand(input string) {
+	args := strings.Fields(input)
+	if len(args) == 1 || args[1] == "list" {
+		listJobs()
+		return
+	}
+
+	if len(args) < 3 || (args[1] != "logs" && args[1] != "kill") {
+		color.Red("❌ Usage: /jobs [list|logs <id> [lines]|kill <id>]")
+		color.Yellow("💡 Start a job with /cmd --background <request> or a command ending in &")
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[2], "%"))
+	if err != nil {
+		color.Red("❌ Invalid job ID: %s", args[2])
+		return
+	}
+
+	switch args[1] {
+	case "logs":
+		lines := 20
+		if len(args) > 3 {
+			if lines, err = strconv.Atoi(args[3]); err != nil {
+				color.Red("❌ Invalid line count: %s", args[3])
+				return
+			}
+		}
+		output, err := commands.JobLog(id, lines)
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		job, _ := commands.FindJob(id)
+		color.Cyan("📜 Job [%d] %s — %s", job.ID, job.Status, job.Command)
+		if len(output) == 0 {
+			color.Yellow("💡 No output yet")
+		}
+		for _, line := range output {
+			fmt.Println(line)
+		}
+		color.Cyan("📁 Full log: %s", job.LogPath)
+	case "kill":
+		if err := commands.KillJob(id); err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		color.Green("✅ Job [%d] killed", id)
+	}
+}
 
-	go ragSystem.IndexAvailableManPages()
-	color.Green("✅ RAG reindexing started in background")
+// listJobs shows the background jobs of this session
+func listJobs() {
+	jobs := commands.Jobs()
+	if len(jobs) == 0 {
+		color.Yellow("💡 No background jobs. Start one with /cmd --background <request>")
+		return
+	}
+
+	color.Cyan("🧵 Background Jobs:")
+	for _, job := range jobs {
+		line := fmt.Sprintf("  [%d] %-8s pid %-7d %8s  %s", job.ID, job.Status, job.PID, utils.FormatDuration(job.Duration()), job.Command)
+		switch job.Status {
+		case commands.JobRunning:
+			color.Cyan("%s", line)
+		case commands.JobDone:
+			color.Green("%s", line)
+		default:
+			color.Red("%s (exit code %d)", line, job.ExitCode)
+		}
+	}
+}
+
+// warnRunningJobs reminds the user of jobs that keep running after Helix exits
+func warnRunningJobs() {
+	if running := commands.RunningJobs(); running > 0 {
+		color.Yellow("⚠️  %d background job(s) keep running after Helix exits", running)
+	}
+}
+
+// handleLimitsCommand shows or sets the resource limits of executed commands:
+// /limits [timeout <duration>|output <size>|nice <0-19>|ionice <off|best-effort|idle>|files <n>|reset]
+func handleLimitsCommand(input string) {
+	args := strings.Fields(input)
+	switch {
+	case len(args) == 1:
+		// Show the current limits below
+	case len(args) == 2 && args[1] == "reset":
+		execConfig = execConfig.WithLimits(commands.ExecuteConfig{})
+		color.Green("✅ Command limits removed")
+	case len(args) == 3:
+		updated, err := setCommandLimit(execConfig, args[1], args[2])
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		execConfig = updated
+		color.Green("✅ %s set to %s", args[1], args[2])
+	default:
+		color.Red("❌ Usage: /limits [timeout <duration>|output <size>|nice <0-19>|ionice <off|best-effort|idle>|files <n>|reset]")
+		color.Yellow("💡 Example: /limits timeout 5m, /limits output 10MB (0 or off removes a limit)")
+		return
+	}
+
+	if len(args) > 1 {
+		cfg.ExecuteConfig = cfg.ExecuteConfig.WithLimits(execConfig)
+		if err := cfg.SavePreferences(); err != nil {
+			color.Yellow("⚠️  Limits applied but could not be saved: %v", err)
+		}
+	}
+
+	color.Cyan("⏱️  Command Limits:")
+	if execConfig.Timeout > 0 {
+		color.Cyan("    • timeout: %s", utils.FormatDuration(execConfig.Timeout))
+	} else {
+		color.Cyan("    • timeout: none (Ctrl+C stops a command)")
+	}
+	if execConfig.MaxOutputBytes > 0 {
+		color.Cyan("    • output: %s", utils.FormatBytes(execConfig.MaxOutputBytes))
+	} else {
+		color.Cyan("    • output: unlimited")
+	}
+	color.Cyan("    • nice: %d", execConfig.Nice)
+	switch execConfig.IONiceClass {
+	case commands.IONiceBestEffort:
+		color.Cyan("    • ionice: best-effort")
+	case commands.IONiceIdle:
+		color.Cyan("    • ionice: idle")
+	default:
+		color.Cyan("    • ionice: off")
+	}
+	if execConfig.BulkFileThreshold > 0 {
+		color.Cyan("    • files: type the count when rm/mv/cp globs match more than %d", execConfig.BulkFileThreshold)
+	} else {
+		color.Cyan("    • files: type the count when rm/mv/cp globs match more than %d (default)", commands.DefaultBulkFileThreshold)
+	}
+}
+
+// setCommandLimit returns the execution settings with one limit changed
+func setCommandLimit(config commands.ExecuteConfig, key, value string) (commands.ExecuteConfig, error) {
+	off := value == "0" || value == "off" || value == "none"
+	switch key {
+	case "timeout":
+		if off {
+			config.Timeout = 0
+			return config, nil
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < time.Second {
+			return config, fmt.Errorf("timeout must be a duration of at least 1s, e.g. 30s or 5m")
+		}
+		config.Timeout = timeout
+	case "output":
+		if off {
+			config.MaxOutputBytes = 0
+			return config, nil
+		}
+		size, err := utils.ParseBytes(value)
+		if err != nil || size < 1024 {
+			return config, fmt.Errorf("output must be a size of at least 1K, e.g. 512K or 10MB")
+		}
+		config.MaxOutputBytes = size
+	case "nice":
+		if off {
+			config.Nice = 0
+			return config, nil
+		}
+		nice, err := strconv.Atoi(value)
+		if err != nil || nice < 0 || nice > 19 {
+			return config, fmt.Errorf("nice must be a whole number from 0 to 19")
+		}
+		config.Nice = nice
+	case "ionice":
+		switch value {
+		case "off", "none", "0":
+			config.IONiceClass = 0
+		case "best-effort":
+			config.IONiceClass = commands.IONiceBestEffort
+		case "idle":
+			config.IONiceClass = commands.IONiceIdle
+		default:
+			return config, fmt.Errorf("ionice is off, best-effort or idle")
+		}
+	case "files":
+		if off {
+			config.BulkFileThreshold = 0 // back to the default; the preview always applies
+			return config, nil
+		}
+		files, err := strconv.Atoi(value)
+		if err != nil || files < 1 {
+			return config, fmt.Errorf("files must be a whole number of at least 1")
+		}
+		config.BulkFileThreshold = files
+	default:
+		return config, fmt.Errorf("unknown limit %q (use timeout, output, nice, ionice or files)", key)
+	}
+	return config, nil
 }
 
 // Toggle dry-run mode
 func toggleDryRun() {
 	execConfig.DryRun = !execConfig.DryRun
 	if execConfig.DryRun {
-		color.Yellow("🔒 Dry-run mode ENABLED - commands will be shown but not executed")
+		color.Yellow("🔒 Dry-run mode ENABLED - commands will be simulated, not executed")
 	} else {
 		color.Green("🚀 Dry-run mode DISABLED - commands will be executed")
 	}
@@ -578,6 +2354,7 @@ func handleRAGReset() {
 		return
 	}
 
+	ragSystem.ClearRetrievalCache()
 	color.Green("✅ RAG system reset. Will reindex on next startup.")
 }
 
@@ -612,3 +2389,223 @@ func testBasicAI() {
 	}
 	color.Green("✅ Current prompt response: '%s'", strings.TrimSpace(response3))
 }
+
+// handleHooksCommand lists the lifecycle hooks, or reloads the hook scripts
+func handleHooksCommand(input string) {
+	args := strings.Fields(strings.TrimSpace(strings.TrimPrefix(input, "/hooks")))
+	if len(args) > 0 {
+		if args[0] != "reload" {
+			color.Red("❌ Unknown hooks action: %s", args[0])
+			color.Yellow("💡 Usage: /hooks [reload]")
+			return
+		}
+		loadHooks()
+	}
+
+	hooks := commands.GetHooks()
+	if len(hooks) == 0 {
+		color.Yellow("🪝 No lifecycle hooks active")
+		color.Yellow("💡 Add executables to %s/<stage>/ for stages %s", hooksDir(), joinHookStages())
+		return
+	}
+
+	color.Cyan("🪝 Lifecycle hooks (run in this order):")
+	for _, hook := range hooks {
+		source := "plugin"
+		if hook.Script != "" {
+			source = hook.Script
+		}
+		fmt.Printf("  %-14s %-20s %s\n", hook.Stage, hook.Name, source)
+	}
+}
+
+// joinHookStages lists the hook stage names for usage hints
+func joinHookStages() string {
+	var names []string
+	for _, stage := range commands.HookStages {
+		names = append(names, string(stage))
+	}
+	return strings.Join(names, ", ")
+}
+
+// replayListLimit is how many recent actions /replay lists
+const replayListLimit = 10
+
+// Handle /replay command
+func handleReplayCommand(input string) {
+	id := strings.TrimSpace(strings.TrimPrefix(input, "/replay"))
+	log := auditLog()
+
+	if id == "" {
+		records, err := log.Records()
+		if err != nil {
+			color.Red("❌ Could not read audit log: %v", err)
+			return
+		}
+		if len(records) == 0 {
+			color.Yellow("🧾 No recorded actions yet - /cmd actions are logged to %s", log.Path())
+			return
+		}
+
+		color.Cyan("🧾 Recent actions (newest first):")
+		for i := len(records) - 1; i >= 0 && i >= len(records)-replayListLimit; i-- {
+			record := records[i]
+			summary := record.Request
+			if summary == "" {
+				summary = record.Command // a sandbox violation outside /cmd
+			}
+			fmt.Printf("  %s  %s  %-10s %s\n", record.ID, record.Time.Format("2006-01-02 15:04"),
+				record.Status(), utils.TruncateString(summary, 60))
+		}
+		color.Yellow("💡 Usage: /replay <audit-id>")
+		return
+	}
+
+	record, err := log.Find(id)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	showAuditRecord(record)
+
+	if record.Command == "" {
+		return
+	}
+	if commands.AskForConfirmation("Re-run this command as a dry run in the current environment?") {
+		replayDryRun(record)
+	}
+}
+
+// showAuditRecord reconstructs a recorded action step by step
+func showAuditRecord(record commands.AuditRecord) {
+	color.Cyan("🧾 Action %s — %s", record.ID, record.Time.Format("Mon 2006-01-02 15:04:05"))
+	color.Cyan("  📂 Directory: %s", record.WorkDir)
+	if record.Shell != "" {
+		color.Cyan("  🌍 Environment: %s (%s shell)", record.OS, record.Shell)
+	}
+	if record.Mock {
+		color.Yellow("  🔧 Generated in mock mode")
+	}
+
+	if record.Request != "" {
+		color.Blue("💬 Request: %s", record.Request)
+	}
+	for _, refinement := range record.Refinements {
+		color.Blue("🔄 Refined: %s", refinement)
+	}
+	if record.Prompt != "" {
+		color.Blue("📝 Prompt (%d chars):", len(record.Prompt))
+		color.Yellow("--- PROMPT START ---")
+		color.Yellow("%s", record.Prompt)
+		color.Yellow("--- PROMPT END ---")
+	}
+	if record.Generated != "" {
+		syntaxHighlighter.PrintHighlightedCommand("Generated command", record.Generated)
+	}
+	if record.Command != "" && record.Command != record.Generated {
+		syntaxHighlighter.PrintHighlightedCommand("Final command", record.Command)
+	}
+
+	if len(record.Decisions) > 0 {
+		color.Blue("🙋 Decisions:")
+		for _, decision := range record.Decisions {
+			if decision.Approved {
+				color.Green("  ✓ %s yes", decision.Question)
+			} else {
+				color.Yellow("  ✗ %s no", decision.Question)
+			}
+		}
+	}
+
+	for _, violation := range record.Violations {
+		color.Red("🚧 Sandbox violation (%s, %s mode in %s): %s", violation.Rule, violation.Mode, violation.Dir, violation.Reason)
+		color.Red("   %s", violation.Command)
+	}
+
+	switch {
+	case !record.Executed && record.Error != "":
+		color.Red("🚫 Not run: %s", record.Error)
+		return
+	case !record.Executed:
+		color.Yellow("⏭️  Not run")
+		return
+	}
+
+	if record.ExitCode == 0 && record.Error == "" {
+		color.Green("✅ Ran in %s, exit code 0", utils.FormatDuration(record.Duration))
+	} else {
+		color.Red("❌ Ran in %s, exit code %d", utils.FormatDuration(record.Duration), record.ExitCode)
+		if record.Error != "" {
+			color.Red("   %s", record.Error)
+		}
+	}
+	if len(record.Steps) > 0 {
+		commands.PrintChainResults(record.Steps)
+	}
+
+	switch {
+	case !record.OutputCaptured:
+		color.Yellow("📄 Output was not captured (the command used the terminal directly)")
+	case record.Output == "":
+		color.Cyan("📄 No output")
+	default:
+		if record.Truncated {
+			color.Cyan("📄 Output (last %s):", utils.FormatBytes(int64(len(record.Output))))
+		} else {
+			color.Cyan("📄 Output:")
+		}
+		fmt.Print(record.Output)
+		if !strings.HasSuffix(record.Output, "\n") {
+			fmt.Println()
+		}
+	}
+}
+
+// replayDryRun checks a recorded command against the current environment
+// without running it: directory, guardrails, risk and whether the program
+// is still installed
+func replayDryRun(record commands.AuditRecord) {
+	command := record.Command
+	color.Cyan("🧪 Dry run of: %s", command)
+
+	if cwd, err := os.Getwd(); err == nil && cwd != record.WorkDir {
+		color.Yellow("  📂 Now in %s (originally %s)", cwd, record.WorkDir)
+	}
+	if env.Shell != record.Shell {
+		color.Yellow("  🐚 Shell is now %s (originally %s)", env.Shell, record.Shell)
+	}
+
+	verdict := commands.EvaluateGuardrails(command, execConfig, sandbox)
+	switch verdict.Outcome() {
+	case "blocked":
+		color.Red("  🚫 Would be blocked (%s)", verdict.Reason())
+	case "confirm":
+		color.Yellow("  ❓ Would ask for confirmation (%s)", verdict.Reason())
+	default:
+		color.Green("  ✅ Guardrails would allow it")
+	}
+
+	if program := commandProgram(command); program != "" {
+		if path, err := exec.LookPath(program); err == nil {
+			color.Green("  📦 %s → %s", program, path)
+		} else {
+			color.Yellow("  📦 %s is not on PATH (it may be a shell builtin)", program)
+		}
+	}
+
+	fmt.Print("  ")
+	showRiskAssessment(command)
+	color.Cyan("💡 Nothing was executed")
+}
+
+// commandProgram returns the program a command line starts, skipping sudo
+// and variable assignments
+func commandProgram(command string) string {
+	for _, field := range strings.Fields(command) {
+		if field == "sudo" || strings.Contains(field, "=") {
+			continue
+		}
+		return field
+	}
+	return ""
+}
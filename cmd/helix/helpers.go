@@ -2,14 +2,18 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"helix/internal/ai"
 	"helix/internal/commands"
+	"helix/internal/rag"
 	"helix/internal/shell"
 	"helix/internal/utils"
 	"helix/internal/ux"
@@ -47,7 +51,7 @@ func generateMockCommand(request string, env shell.Env) string {
 			return "tasklist"
 		}
 	default:
-		return "echo 'Mock command for: " + request + "'"
+		return "echo " + commands.QuoteArgument("Mock command for: "+request, env)
 	}
 }
 
@@ -76,6 +80,33 @@ func generateMockExplanation(command string) string {
 	return fmt.Sprintf("The command '%s' appears to be a system command. In mock mode, I can't provide detailed explanations, but in real mode I would explain what this command does, its common options, and any potential risks.", command)
 }
 
+// generateMockFix diagnoses common failures from their exit code and error
+// output, in the format of a response to the fix prompt
+func generateMockFix(failure commands.Failure, env shell.Env) string {
+	stderr := strings.ToLower(failure.Stderr + " " + failure.Error)
+	fields := strings.Fields(failure.Command)
+	program := ""
+	if len(fields) > 0 {
+		program = filepath.Base(fields[0])
+	}
+
+	switch {
+	case failure.ExitCode == 127 || strings.Contains(stderr, "command not found") || strings.Contains(stderr, "not recognized"):
+		if pm := commands.PackageManagerFactory(env); pm != nil && program != "" {
+			return fmt.Sprintf("Diagnosis: %s is not installed\nCommand: %s", program, pm.InstallCommand(program))
+		}
+		return fmt.Sprintf("Diagnosis: %s is not installed\nCommand: none", program)
+	case strings.Contains(stderr, "permission denied") && env.IsUnixLike() && program != "sudo":
+		return fmt.Sprintf("Diagnosis: the command needs elevated privileges\nCommand: sudo %s", failure.Command)
+	case strings.Contains(stderr, "no such file or directory"):
+		return "Diagnosis: a file or directory in the command does not exist\nCommand: none"
+	case strings.Contains(stderr, "syntax error") || strings.Contains(stderr, "unmatched") || strings.Contains(stderr, "unexpected eof"):
+		return "Diagnosis: the command has shell syntax errors, such as unmatched quotes\nCommand: none"
+	default:
+		return fmt.Sprintf("Diagnosis: the command exited with status %d. This is a simulated diagnosis since we're in mock mode.\nCommand: none", failure.ExitCode)
+	}
+}
+
 // attemptCommandFix tries to fix common AI command generation issues
 func attemptCommandFix(command string) string {
 	originalCommand := command
@@ -144,36 +175,10 @@ func attemptCommandFix(command string) string {
 	return originalCommand
 }
 
-// hasSyntaxErrors checks for obvious shell syntax errors
-func hasSyntaxErrors(command string) bool {
-	// Check for completely unbalanced quotes (be more tolerant)
-	singleQuotes := strings.Count(command, "'")
-	doubleQuotes := strings.Count(command, `"`)
-
-	// Only flag as error if we have clear, multiple unbalanced quotes
-	if (singleQuotes%2 != 0 && singleQuotes > 1) || (doubleQuotes%2 != 0 && doubleQuotes > 1) {
-		return true
-	}
-
-	// Check for trailing invalid characters that break execution
-	trimmed := strings.TrimSpace(command)
-	if strings.HasSuffix(trimmed, ")") && !strings.Contains(trimmed, "(") {
-		return true
-	}
-
-	// Check for obvious shell syntax errors
-	invalidPatterns := []string{
-		"&&)", "||)", "|)", ">)", ">>)", "<)",
-		"find .)", "grep )", "ls )",
-	}
-
-	for _, pattern := range invalidPatterns {
-		if strings.Contains(command, pattern) {
-			return true
-		}
-	}
-
-	return false
+// syntaxError returns the first shell syntax error in a command, found by
+// parsing it the way the shell will
+func syntaxError(command string) error {
+	return commands.CheckSyntax(command, env)
 }
 
 // Function to determine if a command should be explained
@@ -187,6 +192,41 @@ func shouldExplainCommand(command string) bool {
 	return utils.ContainsAny(strings.ToLower(command), complexCommands)
 }
 
+// commandSeparators split a command line into the commands it runs
+var commandSeparators = regexp.MustCompile(`\|\||&&|;|\|`)
+
+// showExitStatusHint explains a failed command's exit code from the EXIT STATUS
+// section of its MAN page
+func showExitStatusHint(command string, err error) {
+	var exitErr *exec.ExitError
+	if ragSystem == nil || !errors.As(err, &exitErr) {
+		return
+	}
+
+	// The shell reports the exit status of the last command it ran
+	segments := commandSeparators.Split(command, -1)
+	program := ""
+	for _, field := range strings.Fields(segments[len(segments)-1]) {
+		if field == "sudo" || strings.Contains(field, "=") {
+			continue
+		}
+		program = filepath.Base(field)
+		break
+	}
+	if program == "" {
+		return
+	}
+
+	meanings := ragSystem.ExitStatusMeanings(program, exitErr.ExitCode())
+	if len(meanings) == 0 {
+		return
+	}
+	color.Yellow("💡 %s exited with status %d. Its manual says:", program, exitErr.ExitCode())
+	for _, meaning := range meanings {
+		color.Yellow("   • %s", meaning)
+	}
+}
+
 // Function to explain a command
 func explainCommand(command string, mockMode bool) {
 	color.Blue("📖 Getting explanation...")
@@ -308,6 +348,36 @@ func manualCommandEdit(currentCommand string) string {
 	return edited
 }
 
+// readRefinement asks how a generated command should change, e.g.
+// "exclude the vendor directory"; an empty answer cancels
+func readRefinement(command string) string {
+	color.Cyan("🔄 Refine: %s", command)
+	color.Cyan("What should change? (press Enter to cancel): ")
+
+	reader := bufio.NewReader(os.Stdin)
+	correction, _ := reader.ReadString('\n')
+	return strings.TrimSpace(correction)
+}
+
+// showCommandChanges highlights what a manual edit changed about a generated
+// command, beyond whether it still parses
+func showCommandChanges(generated, edited string) {
+	changes := commands.CompareCommands(generated, edited)
+	if len(changes) == 0 {
+		color.Green("✅ Your edit does not change what the command does")
+		return
+	}
+
+	color.Cyan("📝 Compared with the generated command, your edit:")
+	for _, change := range changes {
+		if change.Severe {
+			color.Red("   ⚠️  %s", change.Message)
+		} else {
+			color.Yellow("   • %s", change.Message)
+		}
+	}
+}
+
 // For testing the AI model with various prompts - /test-ai command
 func testAIModel() {
 	color.Cyan("🧪 Testing AI model with different prompts...")
@@ -408,16 +478,215 @@ func checkRAGProgress() {
 		return
 	}
 
-	stats := ragSystem.GetSystemStats()
-	if pages, ok := stats["indexed_pages"]; ok {
-		if pageCount, ok := pages.(int); ok && pageCount > 0 {
-			status := ragSystem.GetIndexingStatus()
-			color.Magenta("🧠 RAG Progress: %d pages (%s)", pageCount, status)
+	if progress, running := ragSystem.IndexingProgress(); running {
+		color.Magenta("🧠 RAG Progress: %s", formatIndexingProgress(progress))
+	}
+}
+
+// reindexAfterPackageChange refreshes the RAG index in the background after a
+// package changed, so its MAN pages are used (or dropped) without /rag-reindex
+func reindexAfterPackageChange(action, packageName string) {
+	if ragSystem == nil || !ragSystem.IsInitialized() {
+		return
+	}
+	if ragSystem.ScheduleReindex() {
+		color.Blue("📚 Updating the RAG index in the background after %s %s", action, packageName)
+	}
+}
+
+// rerankWithModel scores rerank candidates with the loaded model; scores
+// should not vary between runs, so sampling is nearly greedy
+func rerankWithModel(prompt string) (string, error) {
+	config := cfg.ModelConfig
+	config.Temperature = 0.1
+	config.MaxTokens = 60
+	return ai.RunModelWithConfig(prompt, config)
+}
+
+// listMarker matches the bullet or number before a list item
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// suggestPackageNames asks the model which names a package goes by with a
+// manager, from the manager's search results and what the RAG index knows
+// about the package. It returns the names the model gave, best first.
+func suggestPackageNames(pkg, manager string, found []commands.PackageInfo) []string {
+	if !ai.ModelIsLoaded() {
+		return nil
+	}
 
-			// Show when RAG becomes available
-			if ragSystem.IsInitialized() {
-				color.Green("🎉 RAG system is now ACTIVE! Enhanced commands available.")
+	var context []string
+	for i, info := range found {
+		if i == 8 {
+			break
+		}
+		context = append(context, fmt.Sprintf("- %s: %s", info.Name, utils.TruncateString(info.Description, 80)))
+	}
+	if ragSystem != nil && ragSystem.IsInitialized() {
+		if suggestions, err := ragSystem.GetCommandSuggestions(pkg); err == nil {
+			for i, suggestion := range suggestions {
+				if i == 3 {
+					break
+				}
+				context = append(context, fmt.Sprintf("- installed command %s: %s", suggestion.Command, utils.TruncateString(suggestion.Description, 80)))
 			}
 		}
 	}
+
+	prompt := fmt.Sprintf(`The user wants to install "%s" with the %s package manager on %s, but %s has no package by that name. It may be misspelled or named differently there.
+
+Related packages and commands:
+%s
+
+List up to 3 exact %s package names the user most likely means, one per line, best first. Output ONLY the names.
+
+Names:`, pkg, manager, env.OSName, manager, strings.Join(context, "\n"), manager)
+
+	config := cfg.ModelConfig
+	config.Temperature = 0.1
+	config.MaxTokens = 40
+	response, err := ai.RunModelWithConfig(prompt, config)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, line := range strings.Split(response, "\n") {
+		line = listMarker.ReplaceAllString(line, "")
+		if fields := strings.Fields(strings.Trim(line, "`\"'")); len(fields) > 0 {
+			names = append(names, strings.Trim(fields[0], "`\"',"))
+		}
+		if len(names) == 3 {
+			break
+		}
+	}
+	return names
+}
+
+// formatIndexingProgress renders index build progress as one status line
+func formatIndexingProgress(p rag.IndexingProgress) string {
+	var line string
+	switch p.Phase {
+	case rag.PhaseScanning:
+		line = fmt.Sprintf("🔍 Scanning MAN pages: %d found, %d parsed", p.PagesScanned, p.PagesParsed)
+	case rag.PhaseParsing:
+		line = fmt.Sprintf("📖 Parsing MAN pages: %d/%d", p.PagesParsed, p.PagesScanned)
+	case rag.PhaseEmbedding:
+		line = fmt.Sprintf("🔧 Building vector index: %d/%d pages, %d documents",
+			p.PagesEmbedded, p.PagesToEmbed, p.DocsEmbedded)
+	default:
+		line = fmt.Sprintf("🔄 RAG indexing: %s", p.Phase)
+	}
+
+	line += fmt.Sprintf(" (%s elapsed", utils.FormatDuration(p.Elapsed))
+	if p.ETA > 0 {
+		line += fmt.Sprintf(", ~%s left", utils.FormatDuration(p.ETA))
+	}
+	return line + ")"
+}
+
+// paletteEnv overrides the palette preference, e.g. HELIX_PALETTE=colorblind
+const paletteEnv = "HELIX_PALETTE"
+
+// applyDisplayPreferences makes reports print sizes, numbers and safety
+// signals as configured
+func applyDisplayPreferences() {
+	format, err := cfg.NumberFormat()
+	if err != nil {
+		color.Yellow("⚠️  Invalid size_units preference: %v", err)
+	}
+	utils.SetNumberFormat(format)
+
+	palette := cfg.UserPrefs.Palette
+	if value := os.Getenv(paletteEnv); value != "" {
+		palette = value
+	}
+	if err := ux.SetPalette(palette); err != nil {
+		color.Yellow("⚠️  Invalid palette: %v", err)
+	}
+}
+
+// showRiskAssessment prints a command's risk level as a symbol and a word in
+// the palette's color, with what kind of command it is and the reasons
+func showRiskAssessment(command string) {
+	risk := commands.AssessRisk(command)
+	badge := ux.RiskColor(string(risk.Level)).Sprint(risk.Level.Badge())
+	if len(risk.Reasons) == 0 {
+		fmt.Printf("🛡️  Risk: %s (%s)\n", badge, risk.CategoryNames())
+	} else {
+		fmt.Printf("🛡️  Risk: %s (%s) — %s\n", badge, risk.CategoryNames(), strings.Join(risk.Reasons, ", "))
+	}
+	showElevation(command)
+}
+
+// showElevation explains why a command asks for root or an administrator
+func showElevation(command string) {
+	if env.OSName == "windows" {
+		if reason := commands.WindowsElevationReason(command); reason != "" {
+			color.Yellow("🔑 Needs an administrator shell: it %s", reason)
+		}
+		return
+	}
+	if !commands.UsesSudo(command) {
+		return
+	}
+	switch reasons := commands.ExplainElevation(command); {
+	case execConfig.NeverSudo:
+		color.Red("🔑 Uses sudo, which your never-sudo setting blocks (/sudo allow)")
+	case len(reasons) == 0:
+		color.Yellow("🔑 Uses sudo, though nothing in it obviously needs root; try it without sudo first")
+	default:
+		color.Yellow("🔑 Uses sudo because %s", strings.Join(reasons, "; "))
+	}
+}
+
+// hooksDir is where lifecycle hook scripts live, one subdirectory per stage
+func hooksDir() string {
+	return filepath.Join(filepath.Dir(cfg.ConfigPath), "hooks")
+}
+
+// loadHooks loads the lifecycle hook scripts from the hooks directory
+func loadHooks() {
+	if err := commands.LoadHookScripts(hooksDir()); err != nil {
+		color.Yellow("⚠️  Could not load hooks: %v", err)
+		return
+	}
+	if hooks := commands.GetHooks(); len(hooks) > 0 {
+		color.Green("🪝 %d lifecycle hooks active", len(hooks))
+	}
+}
+
+// auditLog is where /cmd actions are recorded for /replay
+func auditLog() *commands.AuditLog {
+	return commands.NewAuditLog(filepath.Join(filepath.Dir(cfg.ConfigPath), "audit.jsonl"))
+}
+
+// packageLedger opens the history of package operations next to the config
+func packageLedger() *commands.PackageLedger {
+	return commands.NewPackageLedger(filepath.Join(filepath.Dir(cfg.ConfigPath), "packages.jsonl"))
+}
+
+// finishAudit saves the /cmd action being recorded
+func finishAudit() {
+	record := commands.EndAudit()
+	if record == nil {
+		return
+	}
+	recordSessionAction(*record)
+	if err := auditLog().Append(*record); err != nil {
+		color.Yellow("⚠️  Could not write audit log: %v", err)
+		return
+	}
+	if record.Command != "" {
+		color.Cyan("🧾 Audit ID: %s (replay with /replay %s)", record.ID, record.ID)
+	}
+}
+
+// buildCommandPrompt builds the command generation prompt, with any context
+// the pre-generate hooks added in front of it
+func buildCommandPrompt(request string, hookContext []string) string {
+	prompt := pb.BuildCommandPrompt(request)
+	if len(hookContext) == 0 {
+		return prompt
+	}
+	return "ADDITIONAL CONTEXT FROM HOOKS:\n" + strings.Join(hookContext, "\n") + "\n\n" + prompt
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"helix/internal/ai"
+	"helix/internal/shell"
+)
+
+func TestBuildCommandPromptKeepsSecrets(t *testing.T) {
+	pb = ai.NewPromptBuilder(shell.Env{OSName: "linux", Shell: "bash"}, false)
+	t.Cleanup(func() { pb = nil })
+
+	// The local model needs the real values to write a working command
+	prompt := buildCommandPrompt("deploy with DB_PASSWORD=hunter2secret",
+		[]string{"vault token: hvs_Zq8XkP2mW4rT9bL6nV3cY7dF1gH5jK0s"})
+	for _, secret := range []string{"hunter2secret", "hvs_Zq8XkP2mW4rT9bL6nV3cY7dF1gH5jK0s"} {
+		if !strings.Contains(prompt, secret) {
+			t.Errorf("prompt lacks %s:\n%s", secret, prompt)
+		}
+	}
+}
+
+func TestGenerateMockCommandQuotesRequest(t *testing.T) {
+	request := "say it's $(rm -rf ~) `id`"
+	for _, shellName := range []string{"bash", "zsh"} {
+		command := generateMockCommand(request, shell.Env{Shell: shellName})
+		script, err := shell.Parse(command)
+		if err != nil {
+			t.Fatalf("%s: parse %q: %v", shellName, command, err)
+		}
+		cmds := script.Commands()
+		if len(cmds) != 1 || len(cmds[0].Args) != 2 || cmds[0].Args[1].Expands || cmds[0].Args[1].Value != "Mock command for: "+request {
+			t.Fatalf("%s: generateMockCommand = %q, want one echo of the request", shellName, command)
+		}
+	}
+}
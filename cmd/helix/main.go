@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"os"
 	"strings"
 	"time"
@@ -25,12 +24,37 @@ var (
 	online            bool
 	execConfig        commands.ExecuteConfig
 	gitManager        *commands.GitManager
+	dockerManager     *commands.DockerManager
+	k8sManager        *commands.KubernetesManager
 	syntaxHighlighter *utils.SyntaxHighlighter
 	sandbox           *commands.DirectorySandbox
 	ragSystem         *rag.RAGSystem
+	teamSync          *config.TeamSync
+	sharedContent     *config.SharedContent
 )
 
 func main() {
+	// Non-interactive subcommands run without the REPL
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "batch":
+			os.Exit(runBatchCommand(os.Args[2:]))
+		case "run":
+			os.Exit(runRequestsCommand(os.Args[2:]))
+		case "replay":
+			os.Exit(runReplayCommand(os.Args[2:]))
+		case "approve":
+			os.Exit(runApproveCommand(os.Args[2:]))
+		case "pkg":
+			os.Exit(runPkgOperation(os.Args[2:]))
+		case oneShotCmd, oneShotAsk, oneShotExplain:
+			os.Exit(runOneShotCommand(os.Args[1], os.Args[2:]))
+		}
+	}
+
+	// Route Ctrl+C to running commands instead of terminating Helix
+	setupInterruptHandling()
+
 	// Initialize color output
 	color.Cyan("🚀 Helix v%s — AI-Powered CLI Assistant", config.HelixVersion)
 	color.Yellow("Repository: https://github.com/Nibir1/Helix")
@@ -42,6 +66,7 @@ func main() {
 		color.Red("Error loading config: %v", err)
 		return
 	}
+	applyDisplayPreferences()
 
 	// Detect environment
 	env = shell.DetectEnvironment()
@@ -57,13 +82,46 @@ func main() {
 
 	// Initialize directory sandbox
 	sandbox = commands.NewDirectorySandbox()
+	sandbox.SetPaths(cfg.Sandbox)
 
 	// Set execution config
-	execConfig = commands.DefaultExecuteConfig()
+	execConfig = commands.DefaultExecuteConfig().WithLimits(cfg.ExecuteConfig)
+	execConfig.NeverSudo = cfg.UserPrefs.NeverSudo
+	commands.SetPreferredPackageManager(cfg.UserPrefs.PackageManager)
+	commands.SetPackageSources(cfg.Packages)
 
 	// Initialize Git manager
 	gitManager = commands.NewGitManager(env, execConfig, sandbox)
 
+	// Initialize container manager (docker or podman)
+	dockerManager = commands.NewDockerManager(env, execConfig, sandbox)
+
+	// Initialize Kubernetes manager
+	k8sManager = commands.NewKubernetesManager(env, execConfig, sandbox)
+
+	// Load team-shared snippets, git templates and policy packs, and the policy file
+	teamSync = config.NewTeamSync(cfg)
+	loadSharedContent()
+	loadCommandPolicy()
+
+	// A .helix.yaml in the project sets its sandbox and policy
+	applyProjectProfile()
+
+	// Load lifecycle hook scripts
+	loadHooks()
+
+	// Sandbox violations outside /cmd actions still reach the audit log
+	commands.SetViolationLog(auditLog())
+
+	// Package operations go to their own history for /pkg history and rollback
+	commands.SetPackageLedger(packageLedger())
+
+	// Two-person mode holds destructive commands for a second operator
+	applyTwoPersonMode()
+
+	// Snapshot files before commands change them, for /undo
+	applySnapshots()
+
 	// Initialize syntax highlighter
 	syntaxHighlighter = utils.NewSyntaxHighlighter()
 	commands.SetSyntaxHighlighter(syntaxHighlighter)
@@ -76,7 +134,16 @@ func main() {
 
 	// Download model if not present FIRST - before any other initialization
 	color.Blue("📥 Checking for AI model...")
-	if err := ai.DownloadModel(cfg.ModelFile, config.ModelURL, config.ModelChecksum); err != nil {
+	// A build whose pinned keys are broken must not fall back to treating
+	// the model as unsigned, which the user could then wave through
+	trustedKeys, err := config.TrustedKeys()
+	if err != nil {
+		color.Red("❌ Pinned release keys are invalid: %v", err)
+		color.Red("This build cannot verify model downloads; rebuild it with valid keys in config.ReleaseSigningKeys")
+		return
+	}
+	allowUnsigned := os.Getenv(utils.AllowUnsignedEnv) == "1"
+	if err := ai.DownloadModel(cfg.ModelFile, config.ModelURL, config.ModelChecksum, trustedKeys, allowUnsigned); err != nil {
 		color.Yellow("⚠️  Model download error: %v", err)
 		color.Yellow("Running in enhanced mock mode.")
 		runEnhancedMockMode()
@@ -92,9 +159,7 @@ func main() {
 		return
 	}
 
-	color.Green("✅ Model file exists: %s (Size: %.2f MB)",
-		cfg.ModelFile,
-		float64(fileInfo.Size())/(1024*1024))
+	color.Green("✅ Model file exists: %s (Size: %s)", cfg.ModelFile, utils.FormatBytes(fileInfo.Size()))
 
 	// Load LLaMA model
 	color.Blue("🔧 Loading AI model...")
@@ -115,6 +180,12 @@ func main() {
 	// NOW initialize RAG system AFTER model is confirmed available
 	color.Blue("🧠 Initializing RAG system...")
 	ragSystem = rag.NewSystem(env)
+	ragSystem.SetIndexLocalizedPages(cfg.UserPrefs.IndexLocalizedMan)
+	ragSystem.SetShellHistoryEnabled(cfg.UserPrefs.IndexShellHistory)
+	ragSystem.SetIndexingConfig(cfg.Indexing)
+	ragSystem.SetRetrievalConfig(cfg.Retrieval)
+	ragSystem.SetReranker(rerankWithModel)
+	commands.SetPackageNameAdvisor(suggestPackageNames)
 
 	// Check RAG status and provide clear feedback
 	if ragSystem.IsInitialized() {
@@ -207,52 +278,36 @@ func main() {
 	runEnhancedCLI()
 }
 
-// monitorRAGInitialization periodically checks if RAG system becomes initialized
-func monitorRAGInitialization(pb *ai.PromptBuilder, ragSystem *rag.RAGSystem) {
-	ticker := time.NewTicker(3 * time.Second) // More frequent checking
-	defer ticker.Stop()
-
-	// Reduced timeout since indexing should be faster now
-	timeout := time.After(90 * time.Second) // 1.5 minutes timeout
+// progressRedrawInterval limits how often the indexing status line is redrawn
+const progressRedrawInterval = 250 * time.Millisecond
 
-	checks := 0
-	maxChecks := 30 // 30 checks * 3 seconds = 90 seconds total
-
-	for {
-		select {
-		case <-ticker.C:
-			checks++
-
-			// Use the new status method for better feedback
-			status := ragSystem.GetInitializationStatus()
-			color.Cyan("🔄 RAG Status: %s (check %d/%d)", status, checks, maxChecks)
-
-			if ragSystem.IsInitialized() {
-				color.Green("🎉 RAG system is now ACTIVE! Enhanced commands available.")
-				return
-			}
-
-			// Stop if we've checked enough times
-			if checks >= maxChecks {
-				color.Yellow("⏰ RAG monitoring completed - system still initializing")
-				color.Yellow("💡 RAG features will enable automatically when ready")
-				return
-			}
+// monitorRAGInitialization shows background indexing as a single updating
+// status line and announces when RAG becomes active
+func monitorRAGInitialization(pb *ai.PromptBuilder, ragSystem *rag.RAGSystem) {
+	updates, stop := ragSystem.WatchIndexing()
+	defer stop()
 
-		case <-timeout:
-			// Use the new method to check if indexing is complete
-			if !ragSystem.IsIndexingComplete() {
-				color.Yellow("⏰ RAG initialization timeout - continuing without RAG features")
-			} else {
-				color.Green("✅ RAG initialization completed during timeout window")
-			}
-			return
+	display := ux.NewUX()
+	var lastDraw time.Time
+	for progress := range updates {
+		if progress.Phase == rag.PhaseDone || time.Since(lastDraw) < progressRedrawInterval {
+			continue
 		}
+		display.ShowProgressLine(formatIndexingProgress(progress))
+		lastDraw = time.Now()
+	}
+
+	if pb.IsRAGAvailable() {
+		display.FinishProgressLine(color.GreenString("🎉 RAG system is now ACTIVE! Enhanced commands available."))
+	} else {
+		display.FinishProgressLine(color.YellowString("⚠️  RAG indexing finished without a usable index - continuing without RAG features"))
 	}
 }
 
 // runEnhancedMockMode remains the same (no RAG in mock mode)
 func runEnhancedMockMode() {
+	defer warnRunningJobs()
+	defer stopSessionRecording()
 	color.Yellow("\n🔧 ENHANCED MOCK MODE ACTIVATED")
 	color.Yellow("AI commands will be simulated with intelligent responses")
 
@@ -260,11 +315,19 @@ func runEnhancedMockMode() {
 	env = shell.DetectEnvironment()
 	pb = ai.NewPromptBuilder(env, online)
 
-	reader := bufio.NewReader(os.Stdin)
+	prompt := newPromptInput()
 	for {
-		color.Cyan("[helix-mock]> ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input, exit := prompt.ReadLine("[helix-mock]> ")
+		if exit {
+			color.Green("Exiting Helix. Goodbye! 👋")
+			return
+		}
+
+		input, stopRedirect, ok := redirectOutput(input)
+		if !ok {
+			continue
+		}
+		beginSessionInput(input)
 
 		switch {
 		case input == "/exit":
@@ -280,6 +343,12 @@ func runEnhancedMockMode() {
 			handleAskCommand(input, true)
 		case strings.HasPrefix(input, "/explain"):
 			handleExplainCommand(input, true)
+		case input == "/fix":
+			handleFixCommand(true)
+		case input == "/pkg" || strings.HasPrefix(input, "/pkg "):
+			handlePkgCommand(input, true)
+		case input == "/upgrade-system" || strings.HasPrefix(input, "/upgrade-system "):
+			handleUpgradeSystemCommand(input, true)
 		case strings.HasPrefix(input, "/install"):
 			handleInstallCommand(input, true)
 		case strings.HasPrefix(input, "/update"):
@@ -288,24 +357,68 @@ func runEnhancedMockMode() {
 			handleRemoveCommand(input, true)
 		case strings.HasPrefix(input, "/dry-run"):
 			toggleDryRun()
+		case input == "/limits" || strings.HasPrefix(input, "/limits "):
+			handleLimitsCommand(input)
+		case input == "/jobs" || strings.HasPrefix(input, "/jobs "):
+			handleJobsCommand(input)
+		case input == "/record" || strings.HasPrefix(input, "/record "):
+			handleRecordCommand(input)
+		case input == "/replay" || strings.HasPrefix(input, "/replay "):
+			handleReplayCommand(input)
 		case input == "/online":
 			checkOnlineStatus()
+		case input == "/ps" || strings.HasPrefix(input, "/ps "):
+			handleProcessCommand(input, true)
+		case input == "/net" || strings.HasPrefix(input, "/net "):
+			handleNetCommand(input, true)
+		case input == "/save" || strings.HasPrefix(input, "/save "):
+			handleSaveCommand(input)
+		case input == "/copy" || strings.HasPrefix(input, "/copy "):
+			handleCopyCommand(input)
+		case input == "/sudo" || strings.HasPrefix(input, "/sudo "):
+			handleSudoCommand(input)
+		case input == "/abort":
+			handleAbortCommand()
+		case input == "/two-person" || strings.HasPrefix(input, "/two-person "):
+			handleTwoPersonCommand(input)
+		case input == "/approvals":
+			handleApprovalsCommand()
+		case input == "/snapshots" || strings.HasPrefix(input, "/snapshots "):
+			handleSnapshotsCommand(input)
+		case input == "/undo" || strings.HasPrefix(input, "/undo "):
+			handleUndoCommand(input)
+		case input == "/trust" || strings.HasPrefix(input, "/trust "):
+			handleTrustCommand(input)
+		case input == "/untrust" || strings.HasPrefix(input, "/untrust "):
+			handleUntrustCommand(input)
+		case input == "/batch" || strings.HasPrefix(input, "/batch "):
+			handleBatchCommand(input, true)
+		case input == "/alias" || strings.HasPrefix(input, "/alias "):
+			handleAliasCommand(input)
+		case runAliasInput(input, true):
 		default:
 			color.Yellow("❓ Unknown command. Type '/help' for available commands.")
 		}
+		stopRedirect()
+		endSessionInput()
 	}
 }
 
 // CLI loop to include RAG commands
 func runEnhancedCLI() {
-	reader := bufio.NewReader(os.Stdin)
+	defer warnRunningJobs()
+	defer disconnectRemote()
+	defer stopSessionRecording()
+	prompt := newPromptInput()
 	lastRAGCheck := time.Now()
 	ragEnabledShown := false
 
 	for {
-		color.Cyan("[helix]> ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input, exit := prompt.ReadLine(promptLabel("helix"))
+		if exit {
+			color.Green("Exiting Helix. Goodbye! 👋")
+			return
+		}
 
 		// Use dynamic checking for RAG availability
 		if !ragEnabledShown && pb.IsRAGAvailable() {
@@ -330,6 +443,13 @@ func runEnhancedCLI() {
 			utils.AppendHistory(cfg.HistoryPath, input)
 		}
 
+		// "> file" after a Helix command copies what it prints into the file
+		input, stopRedirect, ok := redirectOutput(input)
+		if !ok {
+			continue
+		}
+		beginSessionInput(input)
+
 		// Command handling
 		switch {
 		case input == "/debug":
@@ -342,8 +462,30 @@ func runEnhancedCLI() {
 			testAIModel()
 		case input == "/rag-status":
 			handleRAGStatus()
-		case input == "/rag-reindex":
-			handleRAGReindex()
+		case strings.HasPrefix(input, "/rag-reindex"):
+			handleRAGReindex(input)
+		case input == "/rag-config" || strings.HasPrefix(input, "/rag-config "):
+			handleRAGConfig(input)
+		case input == "/rag-compact":
+			handleRAGCompact()
+		case input == "/rag-verify":
+			handleRAGVerify()
+		case input == "/rag-eval" || strings.HasPrefix(input, "/rag-eval "):
+			handleRAGEval(input)
+		case input == "/rag-export" || strings.HasPrefix(input, "/rag-export "):
+			handleRAGExport(input)
+		case input == "/rag-import" || strings.HasPrefix(input, "/rag-import "):
+			handleRAGImport(input)
+		case input == "/rag-add" || strings.HasPrefix(input, "/rag-add "):
+			handleRAGAdd(input)
+		case input == "/retrieve" || strings.HasPrefix(input, "/retrieve "):
+			handleRetrieveCommand(input)
+		case input == "/search" || strings.HasPrefix(input, "/search "):
+			handleSearchCommand(input)
+		case input == "/man" || strings.HasPrefix(input, "/man "):
+			handleManCommand(input)
+		case input == "/whatis" || strings.HasPrefix(input, "/whatis "):
+			handleWhatisCommand(input)
 		case input == "/rag-reset":
 			handleRAGReset()
 		case input == "/test-basic-ai":
@@ -354,24 +496,89 @@ func runEnhancedCLI() {
 			handleAskCommand(input, false)
 		case strings.HasPrefix(input, "/explain"):
 			handleExplainCommand(input, false)
+		case input == "/fix":
+			handleFixCommand(false)
+		case input == "/pkg" || strings.HasPrefix(input, "/pkg "):
+			handlePkgCommand(input, false)
+		case input == "/upgrade-system" || strings.HasPrefix(input, "/upgrade-system "):
+			handleUpgradeSystemCommand(input, false)
 		case strings.HasPrefix(input, "/install"):
 			handleInstallCommand(input, false)
 		case strings.HasPrefix(input, "/update"):
 			handleUpdateCommand(input, false)
 		case strings.HasPrefix(input, "/git"):
 			handleGitCommand(input)
+		case input == "/docker" || strings.HasPrefix(input, "/docker "):
+			handleDockerCommand(input)
+		case input == "/k8s" || strings.HasPrefix(input, "/k8s "):
+			handleKubernetesCommand(input)
+		case input == "/ssh" || strings.HasPrefix(input, "/ssh "):
+			handleSSHCommand(input)
 		case strings.HasPrefix(input, "/sandbox"):
 			handleSandboxCommand(input)
 		case strings.HasPrefix(input, "/cd"):
 			handleChangeDirectory(input)
+		case input == "/pushd" || strings.HasPrefix(input, "/pushd "):
+			handlePushDirectory(input)
+		case input == "/popd":
+			handlePopDirectory()
+		case input == "/dirs":
+			showDirectoryStack()
 		case strings.HasPrefix(input, "/remove"):
 			handleRemoveCommand(input, false)
 		case strings.HasPrefix(input, "/dry-run"):
 			toggleDryRun()
+		case input == "/limits" || strings.HasPrefix(input, "/limits "):
+			handleLimitsCommand(input)
+		case input == "/jobs" || strings.HasPrefix(input, "/jobs "):
+			handleJobsCommand(input)
+		case strings.HasPrefix(input, "/sync"):
+			handleSyncCommand(input)
+		case strings.HasPrefix(input, "/snippet"):
+			handleSnippetCommand(input)
+		case input == "/hooks" || strings.HasPrefix(input, "/hooks "):
+			handleHooksCommand(input)
+		case input == "/record" || strings.HasPrefix(input, "/record "):
+			handleRecordCommand(input)
+		case input == "/replay" || strings.HasPrefix(input, "/replay "):
+			handleReplayCommand(input)
+		case input == "/policy" || strings.HasPrefix(input, "/policy "):
+			handlePolicyCommand(input)
+		case input == "/ps" || strings.HasPrefix(input, "/ps "):
+			handleProcessCommand(input, false)
+		case input == "/net" || strings.HasPrefix(input, "/net "):
+			handleNetCommand(input, false)
+		case input == "/save" || strings.HasPrefix(input, "/save "):
+			handleSaveCommand(input)
+		case input == "/copy" || strings.HasPrefix(input, "/copy "):
+			handleCopyCommand(input)
+		case input == "/sudo" || strings.HasPrefix(input, "/sudo "):
+			handleSudoCommand(input)
+		case input == "/abort":
+			handleAbortCommand()
+		case input == "/two-person" || strings.HasPrefix(input, "/two-person "):
+			handleTwoPersonCommand(input)
+		case input == "/approvals":
+			handleApprovalsCommand()
+		case input == "/snapshots" || strings.HasPrefix(input, "/snapshots "):
+			handleSnapshotsCommand(input)
+		case input == "/undo" || strings.HasPrefix(input, "/undo "):
+			handleUndoCommand(input)
+		case input == "/trust" || strings.HasPrefix(input, "/trust "):
+			handleTrustCommand(input)
+		case input == "/untrust" || strings.HasPrefix(input, "/untrust "):
+			handleUntrustCommand(input)
+		case input == "/batch" || strings.HasPrefix(input, "/batch "):
+			handleBatchCommand(input, false)
+		case input == "/alias" || strings.HasPrefix(input, "/alias "):
+			handleAliasCommand(input)
+		case runAliasInput(input, false):
 		default:
 			if input != "" {
 				color.Yellow("💡 Tip: Start with '/ask' for questions or '/cmd' for command generation")
 			}
 		}
+		stopRedirect()
+		endSessionInput()
 	}
 }
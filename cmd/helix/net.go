@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+)
+
+// latencySamples is how many connections /net ping times
+const latencySamples = 5
+
+// handleNetCommand answers connectivity questions natively in Go, and
+// generates a command for anything it has no native check for
+func handleNetCommand(input string, mockMode bool) {
+	text := strings.TrimSpace(strings.TrimPrefix(input, "/net"))
+	if text == "" {
+		color.Red("❌ Usage: /net <question>")
+		color.Yellow("💡 Examples:")
+		color.Yellow("  /net port db.internal 5432")
+		color.Yellow("  /net dns example.com")
+		color.Yellow("  /net ping github.com")
+		color.Yellow("  /net why can't I reach https://intranet.example.com")
+		color.Yellow("  /net interfaces")
+		return
+	}
+
+	request := commands.ParseNetRequest(text)
+	if request.Task != commands.NetUnknown && env.Remote != nil {
+		color.Yellow("💡 Native checks run from this machine, not from %s", env.Remote.Host)
+	}
+
+	switch request.Task {
+	case commands.NetPort:
+		showPortCheck(request.Target, request.Port)
+	case commands.NetDNS:
+		showDNSLookup(request.Target)
+	case commands.NetLatency:
+		port := request.Port
+		if port == 0 {
+			port = 443
+		}
+		showLatency(request.Target, port)
+	case commands.NetReach:
+		showReachability(request.Target, request.Port)
+	case commands.NetInterfaces:
+		showInterfaces()
+	default:
+		// No native check: generate a command, which /cmd explains before running
+		color.Yellow("💡 No built-in check for this; generating a command instead")
+		handleCmdCommand("/cmd "+text, mockMode)
+	}
+}
+
+// showPortCheck reports whether a TCP port accepts connections
+func showPortCheck(host string, port int) {
+	color.Blue("🔌 Checking %s port %d...", host, port)
+	result := commands.CheckPort(host, port)
+	if result.Open {
+		color.Green("✅ %s is open (connected in %s)", result.Address, result.Latency.Round(time.Millisecond))
+		return
+	}
+	color.Red("❌ %s is not reachable: %v", result.Address, result.Err)
+	color.Yellow("💡 /net why %s:%d looks for the cause", host, port)
+}
+
+// showDNSLookup prints the DNS records of a name, or the names of an address
+func showDNSLookup(name string) {
+	color.Blue("📖 Looking up %s...", name)
+	result := commands.LookupDNS(name)
+	if result.Err != nil && len(result.Addresses) == 0 && len(result.Names) == 0 {
+		color.Red("❌ %v", result.Err)
+		return
+	}
+
+	printRecords := func(label string, values []string) {
+		if len(values) > 0 {
+			color.Cyan("   %-6s %s", label, strings.Join(values, ", "))
+		}
+	}
+	printRecords("PTR", result.Names)
+	if result.CNAME != "" {
+		color.Cyan("   %-6s %s", "CNAME", result.CNAME)
+	}
+	printRecords("A/AAAA", result.Addresses)
+	printRecords("MX", result.MX)
+	printRecords("NS", result.NS)
+	for _, txt := range result.TXT {
+		color.White("   %-6s %s", "TXT", txt)
+	}
+}
+
+// showLatency times repeated TCP connections, like ping without privileges
+func showLatency(host string, port int) {
+	color.Blue("⏱️  Timing %d TCP connections to %s:%d...", latencySamples, host, port)
+	result := commands.MeasureLatency(host, port, latencySamples)
+	if len(result.Samples) == 0 {
+		color.Red("❌ No connection succeeded: %v", result.Err)
+		return
+	}
+	color.Cyan("   min %s / avg %s / max %s", result.Min().Round(time.Microsecond),
+		result.Average().Round(time.Microsecond), result.Max().Round(time.Microsecond))
+	if result.Failures > 0 {
+		color.Yellow("   ⚠️  %d of %d attempts failed (last: %v)", result.Failures, latencySamples, result.Err)
+	} else {
+		color.Green("   ✅ %d/%d connected", len(result.Samples), latencySamples)
+	}
+}
+
+// showReachability walks through why a host or URL cannot be reached
+func showReachability(target string, port int) {
+	color.Blue("🩺 Diagnosing %s...", target)
+	for _, step := range commands.DiagnoseReachability(target, port) {
+		if step.OK {
+			color.Green("   ✅ %-8s %s", step.Name, step.Detail)
+			continue
+		}
+		color.Red("   ❌ %-8s %s", step.Name, step.Detail)
+		if step.Hint != "" {
+			color.Yellow("      💡 %s", step.Hint)
+		}
+	}
+}
+
+// showInterfaces lists network interfaces and their addresses
+func showInterfaces() {
+	interfaces, err := commands.ListInterfaces()
+	if err != nil {
+		color.Red("❌ Cannot list interfaces: %v", err)
+		return
+	}
+	color.Cyan("🌐 Network interfaces:")
+	for _, iface := range interfaces {
+		state := "down"
+		if iface.Up {
+			state = "up"
+		}
+		if iface.Loopback {
+			state += ", loopback"
+		}
+		color.White("   %-12s (%s) %s", iface.Name, state, strings.Join(iface.Addresses, ", "))
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"helix/internal/ai"
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// One-shot subcommands, run once without the REPL
+const (
+	oneShotCmd     = "cmd"
+	oneShotAsk     = "ask"
+	oneShotExplain = "explain"
+)
+
+// OneShotOptions holds the flags for `helix cmd|ask|explain`
+type OneShotOptions struct {
+	Mode        string
+	Text        string
+	Yes         bool               // run without asking, up to AutoApprove
+	AutoApprove commands.RiskLevel // highest risk level --yes runs
+	DryRun      bool
+	JSON        bool
+	Quiet       bool
+	Mock        bool // use the built-in mock generator instead of the model
+}
+
+// OneShotResult is what --json prints
+type OneShotResult struct {
+	Mode     string           `json:"mode"`
+	Input    string           `json:"input"`
+	Answer   string           `json:"answer,omitempty"` // ask and explain
+	Source   string           `json:"source,omitempty"` // documentation an answer came from
+	Task     *BatchTaskResult `json:"task,omitempty"`   // cmd
+	MockAI   bool             `json:"mock_ai"`
+	Error    string           `json:"error,omitempty"`
+	ExitCode int              `json:"exit_code"`
+}
+
+// yesFlag is --yes, optionally scoped to risk levels as in --yes=low
+type yesFlag struct {
+	set   bool
+	scope string
+}
+
+func (f *yesFlag) String() string   { return f.scope }
+func (f *yesFlag) IsBoolFlag() bool { return true }
+
+func (f *yesFlag) Set(value string) error {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "false", "0", "no":
+		f.set, f.scope = false, ""
+	case "true", "1", "yes":
+		f.set, f.scope = true, ""
+	default:
+		f.set, f.scope = true, value
+	}
+	return nil
+}
+
+// runOneShotCommand implements `helix cmd|ask|explain "<text>" [--yes[=levels]]
+// [--dry-run] [--json] [--quiet]` and returns the process exit code. Results
+// go to stdout; progress, prompts and errors go to stderr.
+func runOneShotCommand(mode string, args []string) int {
+	stdout, restore := redirectOutputToStderr()
+	defer restore()
+
+	opts, err := parseOneShotArgs(mode, args)
+	if err != nil {
+		color.Red("❌ %v", err)
+		if mode == oneShotCmd {
+			color.Yellow("Usage: helix cmd \"<request>\" [--yes[=low,medium]] [--dry-run] [--json] [--quiet] [--mock]")
+		} else {
+			color.Yellow("Usage: helix %s \"<text>\" [--json] [--quiet] [--mock]", mode)
+		}
+		return exitUsage
+	}
+
+	if opts.Quiet {
+		color.Output = io.Discard
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			defer devNull.Close()
+			os.Stdout = devNull
+		}
+	}
+
+	mockAI, err := initBatchEnvironment(BatchOptions{DryRun: opts.DryRun, Mock: opts.Mock})
+	if err != nil {
+		color.Red("❌ %v", err)
+		return initExitCode(err)
+	}
+	if !mockAI {
+		defer ai.CloseModel()
+	}
+
+	result := OneShotResult{Mode: opts.Mode, Input: opts.Text, MockAI: mockAI}
+	switch opts.Mode {
+	case oneShotCmd:
+		task := runOneShotTask(opts, mockAI, stdout)
+		result.Task = &task
+		result.ExitCode = batchStatusExitCodes[task.Status]
+	case oneShotAsk:
+		answer, source, ok := docAnswer(opts.Text, mockAI)
+		if !ok {
+			answer, err = askModel(opts.Text, mockAI)
+		}
+		result.Answer, result.Source = answer, source
+	case oneShotExplain:
+		result.Answer, err = explainWithModel(opts.Text, mockAI)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.ExitCode = exitModelError
+		color.Red("❌ %v", err)
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			color.Red("❌ %v", err)
+			return exitFailed
+		}
+		fmt.Fprintln(stdout, string(data))
+		return result.ExitCode
+	}
+
+	printOneShotResult(stdout, result)
+	return result.ExitCode
+}
+
+// parseOneShotArgs parses the flags of a one-shot subcommand; the words that
+// are not flags make up the request
+func parseOneShotArgs(mode string, args []string) (OneShotOptions, error) {
+	opts := OneShotOptions{Mode: mode}
+
+	var yes yesFlag
+	fs := flag.NewFlagSet(mode, flag.ContinueOnError)
+	fs.Var(&yes, "yes", "run the command without asking, up to medium risk (or the given levels, e.g. --yes=low)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "print the generated command without running it")
+	fs.BoolVar(&opts.JSON, "json", false, "print the result as JSON")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "print only the result; never ask for confirmation")
+	fs.BoolVar(&opts.Mock, "mock", false, "use the built-in mock generator instead of the model")
+
+	var words []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	opts.Text = strings.TrimSpace(strings.Join(words, " "))
+	if opts.Text == "" {
+		return opts, fmt.Errorf("nothing to %s", mode)
+	}
+
+	opts.Yes = yes.set
+	if !opts.Yes {
+		// No ceiling without --yes: every command is confirmed
+		return opts, nil
+	}
+	opts.AutoApprove = batchMaxAutoRisk
+	if yes.scope != "" {
+		level, err := commands.ParseAutoApproveLevels(yes.scope)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --yes: %w", err)
+		}
+		opts.AutoApprove = level
+	}
+	return opts, nil
+}
+
+// runOneShotTask generates a command and runs it: with --yes up to the
+// approved risk, otherwise after the user confirms it on a terminal
+func runOneShotTask(opts OneShotOptions, mockAI bool, stdout *os.File) BatchTaskResult {
+	task := batchTask{line: 1, request: opts.Text}
+	if opts.Yes || opts.DryRun {
+		result := processBatchTask(task, BatchOptions{DryRun: opts.DryRun, Yes: opts.Yes, AutoApprove: opts.AutoApprove}, mockAI)
+		printBatchTaskResult(result)
+		return result
+	}
+
+	// Plan first, then let a human decide
+	start := time.Now()
+	result := processBatchTask(task, BatchOptions{DryRun: true}, mockAI)
+	if result.Status != batchStatusPlanned {
+		printBatchTaskResult(result)
+		return result
+	}
+
+	if opts.Quiet || !term.IsTerminal(int(os.Stdin.Fd())) {
+		result = finishBatchTask(result, start, batchStatusRefused, "not confirmed (run with --yes to execute without a prompt)")
+		printBatchTaskResult(result)
+		return result
+	}
+
+	color.Cyan("💡 Command: %s", result.Command)
+	risk := commands.AssessRisk(result.Command)
+	if result.Risk != nil {
+		risk = *result.Risk
+	}
+	color.Cyan("   Risk: %s (score %d)", risk.Level.Badge(), risk.Score)
+	// Risky commands are confirmed as in the REPL: the reasons first, or the
+	// name of what they destroy typed out
+	approved := false
+	if risk.Confirmation() >= commands.ConfirmWarn {
+		approved = commands.ConfirmRisk(risk) == nil
+	} else {
+		approved = commands.AskForConfirmation("Execute this command?")
+	}
+	if !approved {
+		result = finishBatchTask(result, start, batchStatusRefused, "cancelled")
+		printBatchTaskResult(result)
+		return result
+	}
+
+	result = runConfirmedTask(result, start, stdout)
+	printBatchTaskResult(result)
+	return result
+}
+
+// runConfirmedTask runs a command the user confirmed the way the REPL does:
+// attached to the terminal with its output streamed to stdout, and with the
+// questions a policy or a file preview still asks
+func runConfirmedTask(result BatchTaskResult, start time.Time, stdout *os.File) BatchTaskResult {
+	config := sandbox.Guard(execConfig)
+	config.AutoConfirm, config.Stdout = true, stdout
+
+	began := time.Now()
+	err := commands.ExecuteCommand(result.Command, config, env)
+	execution := commands.CommandResult{Command: result.Command, Duration: time.Since(began)}
+	result.Execution = &execution
+
+	var veto *commands.HookVetoError
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return finishBatchTask(result, start, batchStatusSucceeded, "")
+	case errors.As(err, &veto):
+		return finishBatchTask(result, start, batchStatusBlocked, err.Error())
+	case errors.Is(err, commands.ErrCommandTimeout):
+		return finishBatchTask(result, start, batchStatusTimeout, err.Error())
+	case errors.As(err, &exitErr):
+		execution.ExitCode = exitErr.ExitCode()
+		return finishBatchTask(result, start, batchStatusFailed, fmt.Sprintf("exit code %d", execution.ExitCode))
+	case errors.Is(err, commands.ErrOutputLimit), errors.Is(err, commands.ErrCommandInterrupted):
+		return finishBatchTask(result, start, batchStatusFailed, err.Error())
+	}
+	return finishBatchTask(result, start, batchStatusError, err.Error())
+}
+
+// printOneShotResult writes a one-shot result for scripts: the command's own
+// output when it ran, the command when it only was generated, or the answer
+func printOneShotResult(stdout io.Writer, result OneShotResult) {
+	if result.Answer != "" {
+		fmt.Fprintln(stdout, result.Answer)
+		if result.Source != "" {
+			color.Cyan("📖 Source: %s", result.Source)
+		}
+		return
+	}
+
+	task := result.Task
+	if task == nil {
+		return
+	}
+	if task.Execution != nil {
+		fmt.Fprint(stdout, task.Execution.Stdout)
+		fmt.Fprint(os.Stderr, task.Execution.Stderr)
+		return
+	}
+	if approvedBatchCommand(*task) {
+		fmt.Fprintln(stdout, task.Command)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// PkgOperationOptions holds the flags for `helix pkg`
+type PkgOperationOptions struct {
+	Action  string
+	Package string
+	Via     string
+	Yes     bool
+	DryRun  bool
+	JSON    bool
+	Quiet   bool
+}
+
+// pkgStatusExitCodes maps each package operation status to its exit code
+var pkgStatusExitCodes = map[string]int{
+	commands.PackageStatusPlanned:   exitOK,
+	commands.PackageStatusSucceeded: exitOK,
+	commands.PackageStatusUnchanged: exitOK,
+	commands.PackageStatusFailed:    exitFailed,
+	commands.PackageStatusNotFound:  exitFailed,
+	commands.PackageStatusError:     exitFailed,
+}
+
+// runPkgOperation implements `helix pkg install|update|remove <package>
+// [--via manager] [--yes] [--dry-run] [--json] [--quiet]` and returns the
+// process exit code. The result goes to stdout; progress, prompts and
+// errors go to stderr.
+func runPkgOperation(args []string) int {
+	stdout, restore := redirectOutputToStderr()
+	defer restore()
+
+	opts, err := parsePkgOperationArgs(args)
+	if err != nil {
+		color.Red("❌ %v", err)
+		color.Yellow("Usage: helix pkg install|update|remove <package> [--via manager] [--yes] [--dry-run] [--json] [--quiet]")
+		return exitUsage
+	}
+
+	if opts.Quiet {
+		color.Output = io.Discard
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			defer devNull.Close()
+			os.Stdout = devNull
+		}
+	}
+
+	if _, err := initBatchEnvironment(BatchOptions{DryRun: opts.DryRun, NoModel: true}); err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+
+	result := commands.PlanPackageOperation(opts.Action, opts.Package, opts.Via, env)
+	exitCode := pkgStatusExitCodes[result.Status]
+	if result.Status == commands.PackageStatusPlanned && !opts.DryRun {
+		if confirmPkgOperation(opts, result) {
+			result = commands.ExecutePackageOperation(result, env, execConfig)
+			exitCode = pkgStatusExitCodes[result.Status]
+			if result.Success {
+				reindexAfterPackageChange(result.Action, result.Package)
+			}
+		} else {
+			result.Message = "not confirmed (run with --yes to execute without a prompt)"
+			exitCode = exitNeedsConfirm
+		}
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			color.Red("❌ %v", err)
+			return exitFailed
+		}
+		fmt.Fprintln(stdout, string(data))
+		return exitCode
+	}
+
+	printPkgOperationResult(stdout, result)
+	return exitCode
+}
+
+// parsePkgOperationArgs parses the action, the package and the flags of
+// `helix pkg`
+func parsePkgOperationArgs(args []string) (PkgOperationOptions, error) {
+	var opts PkgOperationOptions
+	fs := flag.NewFlagSet("pkg", flag.ContinueOnError)
+	fs.StringVar(&opts.Via, "via", "", "the package manager to use, e.g. pip or snap")
+	fs.StringVar(&opts.Via, "manager", "", "the same as --via")
+	fs.BoolVar(&opts.Yes, "yes", false, "run the package command without asking")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "print the package command without running it")
+	fs.BoolVar(&opts.JSON, "json", false, "print the result as JSON")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "print only the result; never ask for confirmation")
+
+	var words []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(words) != 2 {
+		return opts, fmt.Errorf("expected an action and a package")
+	}
+	opts.Action, opts.Package, opts.Via = strings.ToLower(words[0]), words[1], strings.ToLower(opts.Via)
+	switch opts.Action {
+	case "install", "update", "remove":
+	default:
+		return opts, fmt.Errorf("unknown action %s", opts.Action)
+	}
+	return opts, nil
+}
+
+// confirmPkgOperation reports whether a planned package command may run:
+// with --yes, or once the user confirms it on a terminal
+func confirmPkgOperation(opts PkgOperationOptions, result commands.PackageOperationResult) bool {
+	if opts.Yes {
+		return true
+	}
+	if opts.Quiet || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	color.Cyan("💡 Command: %s", result.Command)
+	if pm := commands.PackageManagerByName(result.Manager); result.Action == "remove" && pm != nil {
+		if !commands.PreviewRemoval(pm, result.Package, false) {
+			return false
+		}
+	}
+	return commands.AskForConfirmation("Execute this command?")
+}
+
+// printPkgOperationResult writes a package operation result for scripts: the
+// command's output when it ran, then one status line
+func printPkgOperationResult(stdout io.Writer, result commands.PackageOperationResult) {
+	fmt.Fprint(stdout, result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+
+	versions := result.VersionBefore
+	switch {
+	case result.VersionAfter != "" && result.VersionAfter != result.VersionBefore:
+		versions = strings.TrimPrefix(result.VersionBefore+" → ", " → ") + result.VersionAfter
+	case result.Status == commands.PackageStatusPlanned && result.LatestVersion != "" && result.VersionBefore != "":
+		versions = result.VersionBefore + " → " + result.LatestVersion
+	}
+
+	line := fmt.Sprintf("%s %s %s", result.Status, result.Action, result.Package)
+	if result.Manager != "" {
+		line += " via " + result.Manager
+	}
+	if versions != "" {
+		line += " (" + versions + ")"
+	}
+	for _, detail := range []string{result.Message, result.Error} {
+		if detail != "" {
+			line += ": " + detail
+		}
+	}
+	fmt.Fprintln(stdout, line)
+	if result.Status == commands.PackageStatusPlanned {
+		fmt.Fprintln(stdout, result.Command)
+	}
+	if len(result.Candidates) > 0 {
+		color.Yellow("💡 Did you mean: %s", strings.Join(result.Candidates, ", "))
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"helix/internal/ai"
+	"helix/internal/commands"
+	"helix/internal/shell"
+	"helix/internal/utils"
+	"helix/internal/ux"
+
+	"github.com/fatih/color"
+)
+
+// runCommandPlan generates an ordered plan of commands for a request and runs
+// it step by step. It returns false, without running anything, when the model
+// answers with a single command and the plan was not asked for explicitly.
+func runCommandPlan(request string, mockMode, forced bool) bool {
+	color.Blue("🗺️  Planning: %s", request)
+
+	var response string
+	if mockMode {
+		response = generateMockPlan(request, env)
+		color.Green("🤖 [Mock AI] → %d step(s)", len(ai.ExtractPlan(response)))
+	} else {
+		start := time.Now()
+		var err error
+		response, err = ai.RunModel(pb.BuildPlanPrompt(request))
+		if err != nil {
+			color.Red("❌ AI error: %v", err)
+			return true
+		}
+		color.Green("✅ AI processed in %s", utils.FormatDuration(time.Since(start)))
+	}
+
+	steps := ai.ExtractPlan(response)
+	if len(steps) == 0 {
+		color.Red("❌ AI didn't generate a plan")
+		color.Yellow("Raw AI response: %s", response)
+		return true
+	}
+	if len(steps) == 1 && !forced {
+		color.Yellow("💡 This request needs a single command")
+		return false
+	}
+
+	// Every step must pass the same validation as a single command
+	for i, step := range steps {
+		cleaned, err := commands.ValidateAndCleanCommand(step)
+		if err != nil {
+			color.Red("❌ Step %d is invalid: %v", i+1, err)
+			color.Yellow("Attempted command: %s", step)
+			return true
+		}
+		steps[i] = cleaned
+	}
+
+	plan := commands.NewPlan(steps, func(step int, command string) error {
+		return sandbox.WrapCommand(command, execConfig, env)
+	})
+	plan.Confirm = true
+
+	color.Cyan("📋 Plan with %d steps:", len(steps))
+	plan.PrintChecklist()
+	showPlanRisk(steps)
+	fmt.Println()
+
+	if !commands.AskForConfirmation("Execute this plan step by step?") {
+		color.Yellow("💡 Plan ready to use:")
+		for _, step := range steps {
+			fmt.Println("  " + step)
+		}
+		return true
+	}
+
+	commands.ClearLastFailure() // so /fix only offers a failure from this plan
+	err := plan.Execute()
+	fmt.Println()
+	color.Cyan("📋 Plan result:")
+	plan.PrintChecklist()
+
+	counts := plan.Counts()
+	switch {
+	case err == nil:
+		color.Green("🎉 Plan finished: %d done, %d skipped", counts[commands.StepDone], counts[commands.StepSkipped])
+	case errors.Is(err, commands.ErrPlanAborted):
+		color.Yellow("💡 %d step(s) not run", counts[commands.StepPending])
+	default:
+		color.Yellow("💡 Plan stopped; %d step(s) not run", counts[commands.StepPending])
+		offerFix(mockMode)
+	}
+	return true
+}
+
+// showPlanRisk shows the highest risk among a plan's steps
+func showPlanRisk(steps []string) {
+	var highest commands.RiskAssessment
+	riskiest := ""
+	for _, step := range steps {
+		if risk := commands.AssessRisk(step); riskiest == "" || risk.Score > highest.Score {
+			highest, riskiest = risk, step
+		}
+	}
+	badge := ux.RiskColor(string(highest.Level)).Sprint(highest.Level.Badge())
+	color.Cyan("🛡️  Highest risk: %s (%s)", badge, riskiest)
+}
+
+// generateMockPlan splits a request at its connectors and generates a mock
+// command for each part
+func generateMockPlan(request string, env shell.Env) string {
+	lower := strings.ToLower(request)
+	if strings.Contains(lower, "venv") || strings.Contains(lower, "virtualenv") {
+		return "1. python3 -m venv .venv\n2. .venv/bin/pip install -r requirements.txt"
+	}
+
+	parts := strings.FieldsFunc(lower, func(r rune) bool { return r == ',' || r == ';' })
+	var steps []string
+	for _, part := range parts {
+		for _, piece := range strings.Split(part, " then ") {
+			for _, subpart := range strings.Split(piece, " and ") {
+				if subpart = strings.TrimSpace(subpart); subpart != "" {
+					steps = append(steps, fmt.Sprintf("%d. %s", len(steps)+1, generateMockCommand(subpart, env)))
+				}
+			}
+		}
+	}
+	return strings.Join(steps, "\n")
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/ux"
+
+	"github.com/fatih/color"
+)
+
+// handlePolicyCommand handles /policy [test <command> | test --file <path>]
+func handlePolicyCommand(input string) {
+	args := strings.TrimSpace(strings.TrimPrefix(input, "/policy"))
+	action, rest, _ := strings.Cut(args, " ")
+
+	switch action {
+	case "":
+		showPolicyPacks()
+	case "test":
+		handlePolicyTest(strings.TrimSpace(rest))
+	default:
+		color.Red("❌ Unknown policy action: %s", action)
+		color.Yellow("💡 Usage: /policy | /policy test <command> | /policy test --file <path>")
+	}
+}
+
+// policyFile is the administrator's command policy, next to config.json
+func policyFile() string {
+	return filepath.Join(filepath.Dir(cfg.ConfigPath), "policy.yaml")
+}
+
+// loadCommandPolicy loads the policy file. A file that cannot be read
+// blocks every command until it is fixed, rather than silently allowing all.
+func loadCommandPolicy() {
+	path := policyFile()
+	policy, err := commands.LoadCommandPolicy(path)
+	if err != nil {
+		color.Red("❌ Policy file not applied; every command is blocked until it is fixed: %v", err)
+		policy = &commands.CommandPolicy{Source: path, Invalid: err.Error()}
+	} else if policy != nil {
+		color.Green("📜 Policy file active: %s", path)
+	}
+	commands.SetCommandPolicy(policy)
+}
+
+// showPolicyPacks lists the policy file's rules and the active policy packs
+func showPolicyPacks() {
+	showCommandPolicy()
+
+	packs := commands.GetPolicyPacks()
+	if len(packs) == 0 {
+		color.Yellow("📜 No policy packs active")
+		color.Yellow("💡 Policy packs come from /sync; local overrides live next to the synced repository")
+		return
+	}
+
+	color.Cyan("📜 Active policy packs:")
+	for _, pack := range packs {
+		fmt.Printf("  %-20s %-7s %d block, %d confirm  %s\n",
+			pack.Name, pack.Source, len(pack.Block), len(pack.Confirm), pack.Description)
+	}
+	color.Yellow("💡 Try a command against them with /policy test <command>")
+}
+
+// showCommandPolicy summarizes the rules of the policy file
+func showCommandPolicy() {
+	policy := commands.GetCommandPolicy()
+	if policy == nil {
+		color.Yellow("📜 No policy file (%s)", policyFile())
+		return
+	}
+	if policy.Invalid != "" {
+		color.Red("📜 Policy file %s is invalid, so every command is blocked: %s", policy.Source, policy.Invalid)
+		return
+	}
+
+	color.Cyan("📜 Policy file %s:", policy.Source)
+	for _, section := range []struct {
+		name  string
+		rules commands.PolicyRules
+	}{{"allow", policy.Allow}, {"deny", policy.Deny}, {"confirm", policy.Confirm}} {
+		if section.rules.Empty() {
+			continue
+		}
+		fmt.Printf("  %-8s", section.name)
+		if len(section.rules.Binaries) > 0 {
+			fmt.Printf(" binaries: %s;", strings.Join(section.rules.Binaries, ", "))
+		}
+		if len(section.rules.Paths) > 0 {
+			fmt.Printf(" paths: %s;", strings.Join(section.rules.Paths, ", "))
+		}
+		if len(section.rules.Patterns) > 0 {
+			fmt.Printf(" %d patterns", len(section.rules.Patterns))
+		}
+		fmt.Println()
+	}
+}
+
+// handlePolicyTest shows how the guardrails would treat one command or each
+// line of a file, without executing anything. The policy file and shared
+// content are reloaded first so edits to them apply immediately.
+func handlePolicyTest(arg string) {
+	if arg == "" {
+		color.Red("❌ Usage: /policy test <command> | /policy test --file <path>")
+		color.Yellow("💡 Example: /policy test \"rm -rf ./build\"")
+		return
+	}
+	loadCommandPolicy()
+	loadSharedContent()
+
+	path, isFile := strings.CutPrefix(arg, "--file ")
+	if !isFile {
+		showGuardrailVerdict(commands.EvaluateGuardrails(trimQuotes(arg), execConfig, sandbox))
+		color.Cyan("💡 Nothing was executed; hooks were not run")
+		return
+	}
+
+	tests, err := readPolicyTests(strings.TrimSpace(path))
+	if err != nil {
+		color.Red("❌ Could not read test commands: %v", err)
+		return
+	}
+	if len(tests) == 0 {
+		color.Yellow("💡 No test commands in %s", path)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, command := range tests {
+		verdict := commands.EvaluateGuardrails(command, execConfig, sandbox)
+		counts[verdict.Outcome()]++
+
+		line := fmt.Sprintf("  %-8s %-13s %s", strings.ToUpper(verdict.Outcome()), verdict.Risk.Level.Badge(), command)
+		if reason := verdict.Reason(); reason != "" {
+			line += "  — " + reason
+		}
+		outcomeColor(verdict.Outcome()).Println(line)
+	}
+	color.Cyan("📊 %d commands: %d blocked, %d need confirmation, %d allowed",
+		len(tests), counts["blocked"], counts["confirm"], counts["allowed"])
+	color.Cyan("💡 Nothing was executed; hooks were not run")
+}
+
+// showGuardrailVerdict prints each guardrail's decision on a command
+func showGuardrailVerdict(verdict commands.GuardrailVerdict) {
+	color.Cyan("🧪 %s", verdict.Command)
+	outcomeColor(verdict.Outcome()).Printf("  Verdict: %s", strings.ToUpper(verdict.Outcome()))
+	if reason := verdict.Reason(); reason != "" {
+		fmt.Printf(" (%s)", reason)
+	}
+	fmt.Println()
+
+	if verdict.Invalid != "" {
+		color.Red("  ✗ Validation: %s", verdict.Invalid)
+	}
+	if verdict.Sandbox != "" {
+		color.Red("  🔒 Sandbox (%s): %s", sandbox.ModeString(), verdict.Sandbox)
+	} else {
+		color.Green("  🔒 Sandbox (%s): allowed", sandbox.ModeString())
+	}
+	switch {
+	case !execConfig.SafeMode:
+		color.Yellow("  🚨 Safe mode: off")
+	case verdict.SafeModeBlocked:
+		color.Red("  🚨 Safe mode: blocked (dangerous pattern)")
+	default:
+		color.Green("  🚨 Safe mode: allowed")
+	}
+	switch {
+	case verdict.Policy.Blocked:
+		color.Red("  📜 Policy: blocked by %s (pattern: %s)", verdict.Policy.Pack, verdict.Policy.Pattern)
+	case verdict.Policy.RequiresConfirm:
+		color.Yellow("  📜 Policy: %s requires confirmation (pattern: %s)", verdict.Policy.Pack, verdict.Policy.Pattern)
+	default:
+		color.Green("  📜 Policy: no rule matches (%d packs active)", len(commands.GetPolicyPacks()))
+	}
+	if verdict.DangerPrompt {
+		color.Yellow("  ❓ Asks %q", verdict.Risk.Question())
+	}
+
+	badge := ux.RiskColor(string(verdict.Risk.Level)).Sprint(verdict.Risk.Level.Badge())
+	if len(verdict.Risk.Reasons) == 0 {
+		fmt.Printf("  🛡️  Risk: %s (score %d, %s)\n", badge, verdict.Risk.Score, verdict.Risk.CategoryNames())
+	} else {
+		fmt.Printf("  🛡️  Risk: %s (score %d, %s) — %s\n", badge, verdict.Risk.Score, verdict.Risk.CategoryNames(), strings.Join(verdict.Risk.Reasons, ", "))
+	}
+	switch {
+	case verdict.Blocked():
+		color.Red("  🤖 Batch mode: blocked")
+	case verdict.Policy.RequiresConfirm:
+		color.Yellow("  🤖 Batch mode: skipped (the policy needs interactive confirmation)")
+	case verdict.Risk.Level.Rank() > commands.MaxAutoApproveLevel.Rank():
+		color.Yellow("  🤖 Batch mode: never auto-approved")
+	default:
+		color.Cyan("  🤖 Batch mode: runs when --yes includes %s", verdict.Risk.Level)
+	}
+}
+
+// outcomeColor colors a guardrail outcome with the palette's risk colors
+func outcomeColor(outcome string) *color.Color {
+	switch outcome {
+	case "blocked":
+		return ux.RiskColor(string(commands.RiskCritical))
+	case "confirm":
+		return ux.RiskColor(string(commands.RiskMedium))
+	default:
+		return ux.RiskColor(string(commands.RiskLow))
+	}
+}
+
+// readPolicyTests reads one test command per line, skipping blank lines and # comments
+func readPolicyTests(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tests []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tests = append(tests, line)
+		}
+	}
+	return tests, scanner.Err()
+}
+
+// trimQuotes removes one pair of quotes around an argument
+func trimQuotes(arg string) string {
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+		return arg[1 : len(arg)-1]
+	}
+	return arg
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/rag"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// projectKey returns the project the working directory belongs to: its
+// repository root, or the directory itself
+func projectKey() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	root, err := rag.FindProjectRoot(cwd)
+	if err != nil {
+		return cwd
+	}
+	return root
+}
+
+// projectProfile is the .helix.yaml in effect and the user's settings it
+// replaced, which come back when Helix leaves the project
+var projectProfile struct {
+	active *commands.ProjectProfile
+	mode   commands.SandboxMode
+	dryRun bool
+}
+
+// applyProjectProfile applies the .helix.yaml of the project Helix is in, on
+// start and after changing directory. Leaving a project with a profile
+// restores the user's sandbox mode and dry-run setting. A profile only
+// tightens the user's settings unless the user trusts that version of it.
+func applyProjectProfile() {
+	root := projectKey()
+	if active := projectProfile.active; active != nil {
+		if active.Root == root {
+			return
+		}
+		leaveProjectProfile()
+	}
+	if root == "" {
+		return
+	}
+
+	profile, err := commands.LoadProjectProfile(root)
+	if err != nil {
+		// A broken profile fails closed, like the policy file
+		color.Red("❌ Project profile not applied; every command is blocked until it is fixed: %v", err)
+		strict := commands.SandboxStrict
+		profile = &commands.ProjectProfile{Root: root, Mode: &strict, Invalid: err.Error()}
+	}
+	if profile == nil {
+		return
+	}
+	if loosened := profile.Loosening(sandbox); len(loosened) > 0 && !trustProjectProfile(profile, loosened) {
+		profile = profile.Tightened(sandbox)
+	}
+
+	projectProfile.active = profile
+	projectProfile.mode, projectProfile.dryRun = sandbox.GetMode(), execConfig.DryRun
+	commands.SetProjectProfile(profile)
+	sandbox.SetProjectPaths(profile.Paths)
+	if profile.Invalid == "" {
+		color.Cyan("📋 Project profile: %s", profile.Source)
+	}
+	if profile.Mode != nil && *profile.Mode != sandbox.GetMode() {
+		sandbox.SetMode(*profile.Mode)
+	}
+	if profile.DryRun && !execConfig.DryRun {
+		execConfig.DryRun = true
+		color.Yellow("🔒 Dry-run mode ENABLED by the project - commands will be simulated, not executed")
+	}
+	if len(profile.Paths.Allowed) > 0 {
+		color.Cyan("   ✅ Also allowed: %s", strings.Join(profile.Paths.Allowed, ", "))
+	}
+	if len(profile.Paths.Denied) > 0 {
+		color.Cyan("   ⛔ Denied: %s", strings.Join(profile.Paths.Denied, ", "))
+	}
+	if len(profile.BlockedBinaries) > 0 {
+		color.Cyan("   🚫 Blocked programs: %s", strings.Join(profile.BlockedBinaries, ", "))
+	}
+}
+
+// leaveProjectProfile removes the active profile and restores the settings
+// it replaced
+func leaveProjectProfile() {
+	profile := projectProfile.active
+	projectProfile.active = nil
+	commands.SetProjectProfile(nil)
+	sandbox.SetProjectPaths(commands.SandboxPaths{})
+	color.Cyan("📋 Left %s; your own sandbox settings apply again", profile.Root)
+	if profile.Mode != nil && sandbox.GetMode() != projectProfile.mode {
+		sandbox.SetMode(projectProfile.mode)
+	}
+	if profile.DryRun {
+		execConfig.DryRun = projectProfile.dryRun
+	}
+}
+
+// trustProjectProfile reports whether a profile may loosen the user's
+// sandbox. Trust is kept per project for the file's exact content, so the
+// user is asked again whenever the file changes; without a terminal to ask
+// on, the profile is not trusted.
+func trustProjectProfile(profile *commands.ProjectProfile, loosened []string) bool {
+	if profile.Hash != "" && cfg.TrustedProfiles[profile.Root] == profile.Hash {
+		return true
+	}
+	color.Yellow("⚠️  %s would loosen your sandbox:", profile.Source)
+	for _, setting := range loosened {
+		color.Yellow("   • %s", setting)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !commands.AskForConfirmation("Trust this version of the project profile?") {
+		color.Yellow("🔒 Only the settings that tighten your sandbox apply")
+		return false
+	}
+	if cfg.TrustedProfiles == nil {
+		cfg.TrustedProfiles = make(map[string]string)
+	}
+	cfg.TrustedProfiles[profile.Root] = profile.Hash
+	if err := cfg.SavePreferences(); err != nil {
+		color.Red("❌ Failed to save trust in the project profile: %v", err)
+	}
+	return true
+}
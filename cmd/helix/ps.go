@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// defaultProcessLimit is how many processes /ps shows without a filter
+const defaultProcessLimit = 15
+
+// maxActionProcesses is the most matches /ps offers to kill or renice at once
+const maxActionProcesses = 10
+
+// handleProcessCommand lists processes, filtered by a plain-English query,
+// and offers to kill or renice the matches:
+// /ps [query] | /ps kill <pid>... [--force] | /ps renice <niceness> <pid>...
+func handleProcessCommand(input string, mockMode bool) {
+	args := strings.Fields(strings.TrimPrefix(input, "/ps"))
+	if len(args) > 0 && (args[0] == "kill" || args[0] == "renice") {
+		runProcessAction(args)
+		return
+	}
+
+	query := strings.Join(args, " ")
+	filter := commands.ParseProcessQuery(query)
+	if filter.IsEmpty() && filter.Limit == 0 {
+		filter.Limit = defaultProcessLimit
+	}
+
+	processes, err := commands.ListProcesses(env)
+	if err != nil {
+		color.Red("❌ Cannot list processes: %v", err)
+		return
+	}
+	matches, err := commands.FilterProcesses(processes, filter, env)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+
+	if len(matches) == 0 {
+		color.Yellow("🔍 No processes match %q", query)
+		if filter.Port > 0 {
+			color.Yellow("💡 Processes of other users may need sudo to be seen")
+		}
+		return
+	}
+
+	printProcesses(matches, query)
+	if filter.IsEmpty() {
+		color.Cyan("💡 Filter with e.g. /ps whatever is using port 8080, /ps node, /ps top 5 by memory")
+		return
+	}
+	if len(matches) <= maxActionProcesses {
+		offerProcessAction(matches)
+	}
+}
+
+// printProcesses prints a process table
+func printProcesses(processes []commands.Process, query string) {
+	if query == "" {
+		color.Cyan("⚙️  Top processes:")
+	} else {
+		color.Cyan("⚙️  Processes matching %q:", query)
+	}
+	color.White("  %7s  %-10s %6s %9s  %s", "PID", "USER", "CPU%", "MEMORY", "COMMAND")
+	for _, process := range processes {
+		command := process.Command
+		if len(command) > 70 {
+			command = command[:67] + "..."
+		}
+		color.White("  %7d  %-10s %6.1f %9s  %s", process.PID, process.User, process.CPU,
+			utils.FormatBytes(process.Memory), command)
+	}
+}
+
+// offerProcessAction asks whether to kill or renice the listed processes
+func offerProcessAction(processes []commands.Process) {
+	pids := make([]int, len(processes))
+	for i, process := range processes {
+		pids[i] = process.PID
+	}
+
+	var response string
+	fmt.Printf("Act on %s? [k]ill/[f]orce kill/[r]enice/[N]othing: ", pluralProcesses(len(pids)))
+	fmt.Scanln(&response)
+
+	var command string
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "k", "kill":
+		command = commands.KillCommand(pids, false, env)
+	case "f", "force":
+		command = commands.KillCommand(pids, true, env)
+	case "r", "renice":
+		fmt.Print("Niceness (-20 to 19, higher is lower priority) [10]: ")
+		var value string
+		fmt.Scanln(&value)
+		niceness := 10
+		if value = strings.TrimSpace(value); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				color.Red("❌ Niceness must be a whole number")
+				return
+			}
+			niceness = parsed
+		}
+		reniced, err := commands.ReniceCommand(pids, niceness, env)
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		command = reniced
+	default:
+		return
+	}
+	runProcessCommand(command)
+}
+
+// runProcessAction handles /ps kill and /ps renice with explicit PIDs
+func runProcessAction(args []string) {
+	force := false
+	var values []int
+	for _, arg := range args[1:] {
+		if arg == "--force" || arg == "-9" {
+			force = true
+			continue
+		}
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			color.Red("❌ Not a number: %s", arg)
+			return
+		}
+		values = append(values, value)
+	}
+
+	var command string
+	switch args[0] {
+	case "kill":
+		if len(values) == 0 {
+			color.Red("❌ Usage: /ps kill <pid>... [--force]")
+			return
+		}
+		command = commands.KillCommand(values, force, env)
+	case "renice":
+		if len(values) < 2 {
+			color.Red("❌ Usage: /ps renice <niceness> <pid>...")
+			return
+		}
+		reniced, err := commands.ReniceCommand(values[1:], values[0], env)
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		command = reniced
+	}
+	runProcessCommand(command)
+}
+
+// runProcessCommand confirms and runs a kill or renice command
+func runProcessCommand(command string) {
+	syntaxHighlighter.PrintHighlightedCommand("Process action", command)
+	if !commands.AskForConfirmation("Run this command?") {
+		color.Yellow("💡 Command ready to use: %s", command)
+		return
+	}
+	if err := sandbox.WrapCommand(command, execConfig, env); err != nil {
+		color.Red("❌ Command failed: %v", err)
+		return
+	}
+	color.Green("✅ Done")
+}
+
+// pluralProcesses returns "1 process" or "n processes"
+func pluralProcesses(n int) string {
+	if n == 1 {
+		return "1 process"
+	}
+	return fmt.Sprintf("%d processes", n)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+	"helix/internal/config"
+	"helix/internal/shell"
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// maxRecordingLine bounds one line of a recording; audit output is capped
+// well below it
+const maxRecordingLine = 4 << 20
+
+// sessionEntry is one line of a session recording: the header first, then
+// one entry per input with what it produced
+type sessionEntry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"` // "session" or "input"
+
+	// The header describes where the session ran
+	Version string `json:"version,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Shell   string `json:"shell,omitempty"`
+	WorkDir string `json:"work_dir,omitempty"`
+	Host    string `json:"host,omitempty"` // the remote host commands ran on
+
+	Input   string                 `json:"input,omitempty"`
+	Actions []commands.AuditRecord `json:"actions,omitempty"` // /cmd actions: generated commands, confirmations, output
+	// A command run outside /cmd, e.g. by a snippet or /git, and its output
+	Command   string `json:"command,omitempty"`
+	Output    string `json:"output,omitempty"`
+	Truncated bool   `json:"output_truncated,omitempty"`
+}
+
+// sessionRecording is the /record file being written and the input in
+// progress
+var sessionRecording struct {
+	file    *os.File
+	path    string
+	entries int
+	pending *sessionEntry
+}
+
+// handleRecordCommand starts or stops recording the session:
+// /record start [file], /record stop, /record
+func handleRecordCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/record"))
+	switch {
+	case len(args) == 0:
+		if sessionRecording.file == nil {
+			color.Cyan("⏺️  Not recording (/record start [file] begins)")
+			return
+		}
+		color.Cyan("⏺️  Recording to %s (%d inputs so far)", sessionRecording.path, sessionRecording.entries)
+	case args[0] == "start" && len(args) <= 2:
+		path := ""
+		if len(args) == 2 {
+			path = args[1]
+		}
+		startSessionRecording(path)
+	case args[0] == "stop" && len(args) == 1:
+		if sessionRecording.file == nil {
+			color.Yellow("💡 Not recording")
+			return
+		}
+		stopSessionRecording()
+	default:
+		color.Red("❌ Usage: /record start [file] | /record stop")
+	}
+}
+
+// startSessionRecording opens a recording file and writes its header; by
+// default the file goes to ~/.helix/recordings
+func startSessionRecording(path string) {
+	if sessionRecording.file != nil {
+		color.Yellow("💡 Already recording to %s (/record stop first)", sessionRecording.path)
+		return
+	}
+
+	var file *os.File
+	var err error
+	if path == "" {
+		dir := filepath.Join(filepath.Dir(cfg.ConfigPath), "recordings")
+		if err = os.MkdirAll(dir, 0700); err == nil {
+			path = filepath.Join(dir, "session-"+time.Now().Format("20060102-150405")+".jsonl")
+			file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		}
+	} else {
+		file, err = openSaveFile(path, false)
+	}
+	if err != nil {
+		color.Red("❌ Cannot start recording: %v", err)
+		return
+	}
+
+	header := sessionEntry{Time: time.Now(), Kind: "session", Version: config.HelixVersion, OS: env.OSName, Shell: env.Shell}
+	header.WorkDir, _ = os.Getwd()
+	if env.Remote != nil {
+		header.Host = env.Remote.Host
+	}
+	sessionRecording.file, sessionRecording.path, sessionRecording.entries = file, path, 0
+	if err := writeSessionEntry(header); err != nil {
+		color.Red("❌ Cannot start recording: %v", err)
+		file.Close()
+		sessionRecording.file = nil
+		return
+	}
+	color.Green("⏺️  Recording the session to %s", path)
+	color.Yellow("💡 Inputs, generated commands, your answers and output are saved; /record stop ends it")
+}
+
+// stopSessionRecording closes the recording file, if one is open
+func stopSessionRecording() {
+	if sessionRecording.file == nil {
+		return
+	}
+	sessionRecording.pending = nil
+	if err := sessionRecording.file.Close(); err != nil {
+		color.Red("❌ Cannot finish recording: %v", err)
+	} else {
+		color.Green("⏹️  Recorded %d inputs to %s (review with: helix replay %s)",
+			sessionRecording.entries, sessionRecording.path, sessionRecording.path)
+	}
+	sessionRecording.file = nil
+}
+
+// beginSessionInput starts the recording entry of an input
+func beginSessionInput(input string) {
+	if sessionRecording.file == nil || strings.TrimSpace(input) == "" {
+		return
+	}
+	sessionRecording.pending = &sessionEntry{Time: time.Now(), Kind: "input", Input: input}
+}
+
+// recordSessionAction adds a finished /cmd action to the input in progress
+func recordSessionAction(record commands.AuditRecord) {
+	if sessionRecording.pending != nil {
+		sessionRecording.pending.Actions = append(sessionRecording.pending.Actions, record)
+	}
+}
+
+// endSessionInput writes the input in progress with the output of a command
+// it ran outside /cmd
+func endSessionInput() {
+	entry := sessionRecording.pending
+	sessionRecording.pending = nil
+	if entry == nil || sessionRecording.file == nil {
+		return
+	}
+	if output, ok := commands.LastOutput(); ok && output.Time.After(entry.Time) && len(entry.Actions) == 0 {
+		entry.Command, entry.Output, entry.Truncated = output.Command, output.Output, output.Truncated
+	}
+	if err := writeSessionEntry(*entry); err != nil {
+		color.Red("❌ Recording stopped: %v", err)
+		sessionRecording.file.Close()
+		sessionRecording.file = nil
+		return
+	}
+	sessionRecording.entries++
+}
+
+// writeSessionEntry appends one JSON line to the recording, with secrets
+// masked
+func writeSessionEntry(entry sessionEntry) error {
+	entry.Input = utils.RedactSecrets(entry.Input)
+	entry.Command = utils.RedactSecrets(entry.Command)
+	entry.Output = utils.RedactSecrets(entry.Output)
+	actions := make([]commands.AuditRecord, len(entry.Actions))
+	for i, action := range entry.Actions {
+		actions[i] = action.Redacted()
+	}
+	entry.Actions = actions
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = sessionRecording.file.Write(append(data, '\n'))
+	return err
+}
+
+// readSessionRecording reads a recording's header and entries
+func readSessionRecording(path string) (sessionEntry, []sessionEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sessionEntry{}, nil, err
+	}
+	defer file.Close()
+
+	var header sessionEntry
+	var entries []sessionEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), maxRecordingLine)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry sessionEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return header, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if entry.Kind == "session" {
+			header = entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return header, nil, err
+	}
+	if header.Kind == "" {
+		return header, nil, fmt.Errorf("%s is not a Helix session recording", path)
+	}
+	return header, entries, nil
+}
+
+// runReplayCommand implements `helix replay <file>`: it walks through a
+// recorded session and dry-runs its commands against the current
+// environment, without running anything
+func runReplayCommand(args []string) int {
+	if len(args) != 1 {
+		color.Red("❌ Usage: helix replay <recording.jsonl>")
+		return exitUsage
+	}
+	header, entries, err := readSessionRecording(args[0])
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+
+	cfg, err = config.DefaultConfig()
+	if err != nil {
+		color.Red("❌ Error loading config: %v", err)
+		return exitUsage
+	}
+	applyDisplayPreferences()
+	env = shell.DetectEnvironment()
+	sandbox = commands.NewDirectorySandbox()
+	sandbox.SetPaths(cfg.Sandbox)
+	execConfig = commands.DefaultExecuteConfig().WithLimits(cfg.ExecuteConfig)
+	execConfig.DryRun = true
+	execConfig.NeverSudo = cfg.UserPrefs.NeverSudo
+	commands.SetPreferredPackageManager(cfg.UserPrefs.PackageManager)
+	commands.SetPackageSources(cfg.Packages)
+	syntaxHighlighter = utils.NewSyntaxHighlighter()
+	commands.SetSyntaxHighlighter(syntaxHighlighter)
+	teamSync = config.NewTeamSync(cfg)
+	loadSharedContent()
+	loadCommandPolicy()
+
+	color.Cyan("🎬 Session recorded %s with Helix %s", header.Time.Format("Mon 2006-01-02 15:04:05"), header.Version)
+	color.Cyan("   📂 %s · %s (%s shell)", header.WorkDir, header.OS, header.Shell)
+	if header.Host != "" {
+		color.Cyan("   🌐 Commands ran on %s", header.Host)
+	}
+	color.Yellow("🔒 Replaying as a dry run: nothing is executed")
+
+	for i, entry := range entries {
+		fmt.Println()
+		color.Blue("▶️  [%d/%d] %s  %s", i+1, len(entries), entry.Time.Format("15:04:05"), entry.Input)
+		for _, action := range entry.Actions {
+			showAuditRecord(action)
+			if action.Command != "" {
+				replayDryRun(action)
+			}
+		}
+		if entry.Command != "" {
+			syntaxHighlighter.PrintHighlightedCommand("Ran", entry.Command)
+			if entry.Output != "" {
+				color.Cyan("📄 Output:")
+				fmt.Print(entry.Output)
+				if !strings.HasSuffix(entry.Output, "\n") {
+					fmt.Println()
+				}
+			}
+			replayDryRun(commands.AuditRecord{Command: entry.Command, WorkDir: header.WorkDir, Shell: header.Shell})
+		}
+	}
+	fmt.Println()
+	color.Green("🎬 End of recording (%d inputs)", len(entries))
+	return exitOK
+}
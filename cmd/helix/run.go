@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helix/internal/ai"
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// RunOptions holds the flags for `helix run` and /batch
+type RunOptions struct {
+	RequestsFile   string
+	Yes            bool               // run without asking, up to AutoApprove
+	AutoApprove    commands.RiskLevel // highest risk level --yes runs
+	DryRun         bool
+	TranscriptPath string
+}
+
+// runItem is one request of a run with what its command printed
+type runItem struct {
+	BatchTaskResult
+	Output    string
+	Truncated bool
+}
+
+// runAction is the user's answer before a request's command runs
+type runAction int
+
+const (
+	runExecute runAction = iota
+	runSkip
+	runAll
+	runQuit
+)
+
+// runRequestsCommand implements `helix run <requests-file> [--yes[=levels]]
+// [--dry-run] [--transcript file]` and returns the process exit code. Unlike
+// helix batch it is meant for a terminal: each command is confirmed and runs
+// attached to it.
+func runRequestsCommand(args []string) int {
+	opts, err := parseRunArgs("run", args)
+	if err != nil {
+		color.Red("❌ %v", err)
+		color.Yellow("Usage: helix run <requests-file> [--yes[=low,medium]] [--dry-run] [--transcript file.md]")
+		return exitUsage
+	}
+
+	mockAI, err := initBatchEnvironment(BatchOptions{DryRun: opts.DryRun})
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+	if !mockAI {
+		defer ai.CloseModel()
+	}
+	setupInterruptHandling()
+
+	items, err := runRequestFile(opts, mockAI)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return exitUsage
+	}
+	results := make([]BatchTaskResult, len(items))
+	for i, item := range items {
+		results[i] = item.BatchTaskResult
+	}
+	return batchExitCode(results)
+}
+
+// handleBatchCommand runs a file of requests from the REPL:
+// /batch <file> [--yes[=levels]] [--dry-run] [--transcript file.md]
+func handleBatchCommand(input string, mockMode bool) {
+	opts, err := parseRunArgs("batch", strings.Fields(strings.TrimPrefix(input, "/batch")))
+	if err != nil {
+		color.Red("❌ %v", err)
+		color.Yellow("💡 Usage: /batch <requests-file> [--yes[=low,medium]] [--dry-run] [--transcript file.md]")
+		return
+	}
+	opts.DryRun = opts.DryRun || execConfig.DryRun
+
+	if _, err := runRequestFile(opts, mockMode); err != nil {
+		color.Red("❌ %v", err)
+	}
+}
+
+// parseRunArgs parses the flags of helix run and /batch, allowing them
+// before or after the requests file
+func parseRunArgs(name string, args []string) (RunOptions, error) {
+	var opts RunOptions
+
+	var yes yesFlag
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Var(&yes, "yes", "run commands without asking, up to medium risk (or the given levels, e.g. --yes=low)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "generate the commands without running them")
+	fs.StringVar(&opts.TranscriptPath, "transcript", "", "write the transcript to this file (default <requests-file>.transcript.md)")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 1 {
+		return opts, fmt.Errorf("expected exactly one requests file, got %d", len(positional))
+	}
+	opts.RequestsFile = positional[0]
+	if opts.TranscriptPath == "" {
+		opts.TranscriptPath = opts.RequestsFile + ".transcript.md"
+	}
+
+	opts.Yes = yes.set
+	if !opts.Yes {
+		// No ceiling without --yes: every command is confirmed
+		return opts, nil
+	}
+	opts.AutoApprove = batchMaxAutoRisk
+	if yes.scope != "" {
+		level, err := commands.ParseAutoApproveLevels(yes.scope)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --yes: %w", err)
+		}
+		opts.AutoApprove = level
+	}
+	return opts, nil
+}
+
+// runRequestFile generates a command for each request of a file in order,
+// runs it once confirmed (or unasked within the --yes scope) and writes a
+// transcript of the run
+func runRequestFile(opts RunOptions, mockAI bool) ([]runItem, error) {
+	tasks, err := readBatchTasks(opts.RequestsFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		color.Yellow("⚠️  No requests found in %s", opts.RequestsFile)
+		return nil, nil
+	}
+	if err := sandbox.ValidatePath(opts.TranscriptPath); err != nil {
+		return nil, err
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	startedAt := time.Now()
+	var items []runItem
+	quit := false
+	for i, task := range tasks {
+		color.Cyan("\n📋 [%d/%d] %s", i+1, len(tasks), task.request)
+		start := time.Now()
+
+		// Plan first; nothing runs before it is approved
+		item := runItem{BatchTaskResult: processBatchTask(task, BatchOptions{DryRun: true}, mockAI)}
+		switch {
+		case quit:
+			item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusSkipped, "run stopped")
+		case item.Status != batchStatusPlanned || opts.DryRun:
+		default:
+			syntaxHighlighter.PrintHighlightedCommand("Command", item.Command)
+			if item.Risk != nil {
+				color.Cyan("   Risk: %s (score %d)", item.Risk.Level.Badge(), item.Risk.Score)
+			}
+
+			action := runSkip
+			switch {
+			case opts.Yes && autoApproved(item.BatchTaskResult, opts.AutoApprove):
+				action = runExecute
+			case !interactive:
+				reason := "not confirmed (--yes runs it without a prompt)"
+				if opts.Yes {
+					reason = fmt.Sprintf("not confirmed (--yes=%s does not cover it)", autoApproveScope(opts.AutoApprove))
+				}
+				item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusRefused, reason)
+			default:
+				action = askRunAction("Execute this command?")
+			}
+
+			switch action {
+			case runAll:
+				// Answering all is the same as --yes
+				opts.Yes = true
+				if opts.AutoApprove == "" {
+					opts.AutoApprove = batchMaxAutoRisk
+				}
+				color.Yellow("⏩ Running the remaining commands up to %s risk without asking", opts.AutoApprove)
+				item = executeRunItem(item, start)
+			case runExecute:
+				item = executeRunItem(item, start)
+			case runQuit:
+				quit = true
+				item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusSkipped, "run stopped")
+			default:
+				if item.Status == batchStatusPlanned {
+					item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusSkipped, "skipped")
+				}
+			}
+		}
+
+		printBatchTaskResult(item.BatchTaskResult)
+		items = append(items, item)
+	}
+
+	summary := BatchReport{StartedAt: startedAt, FinishedAt: time.Now(), Summary: BatchSummary{ByStatus: map[string]int{}}}
+	for _, item := range items {
+		summary.Summary.Total++
+		summary.Summary.ByStatus[item.Status]++
+	}
+	fmt.Println()
+	printBatchSummary(summary)
+
+	if err := writeRunTranscript(opts, items, startedAt); err != nil {
+		return items, fmt.Errorf("failed to write transcript: %w", err)
+	}
+	color.Green("📄 Transcript written to %s", opts.TranscriptPath)
+	return items, nil
+}
+
+// autoApproved reports whether --yes may run a planned command unasked;
+// commands a policy pack wants confirmed are always asked
+func autoApproved(result BatchTaskResult, highest commands.RiskLevel) bool {
+	if highest == "" || (result.Risk != nil && result.Risk.Level.Rank() > highest.Rank()) {
+		return false
+	}
+	return !commands.CheckPolicy(result.Command).RequiresConfirm
+}
+
+// askRunAction asks whether to run a request's command, skip it, run it and
+// the rest without asking, or stop; anything else skips
+func askRunAction(prompt string) runAction {
+	var response string
+	fmt.Printf("%s [y]es/[S]kip/[a]ll/[q]uit: ", prompt)
+	fmt.Scanln(&response)
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		return runExecute
+	case "a", "all":
+		return runAll
+	case "q", "quit":
+		return runQuit
+	}
+	return runSkip
+}
+
+// executeRunItem runs an approved command attached to the terminal, with the
+// same checks as /cmd, and keeps its output for the transcript
+func executeRunItem(item runItem, start time.Time) runItem {
+	err := sandbox.WrapCommand(item.Command, execConfig, env)
+
+	execution := commands.CommandResult{Command: item.Command, Duration: time.Since(start)}
+	if output, ok := commands.LastOutput(); ok && output.Time.After(start) {
+		execution.Command = output.Command
+		item.Output, item.Truncated = output.Output, output.Truncated
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		execution.ExitCode = exitErr.ExitCode()
+	}
+	item.Execution = &execution
+
+	var veto *commands.HookVetoError
+	switch {
+	case err == nil:
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusSucceeded, "")
+	case exitErr != nil:
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusFailed, fmt.Sprintf("exit code %d", exitErr.ExitCode()))
+	case errors.Is(err, commands.ErrCommandTimeout):
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusTimeout, err.Error())
+	case errors.As(err, &veto) || strings.HasPrefix(err.Error(), "sandbox violation"):
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusBlocked, err.Error())
+	case strings.Contains(err.Error(), "cancelled"):
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusRefused, err.Error())
+	default:
+		item.BatchTaskResult = finishBatchTask(item.BatchTaskResult, start, batchStatusFailed, err.Error())
+	}
+	return item
+}
+
+// writeRunTranscript writes the requests, their commands, outcomes and
+// output as Markdown
+func writeRunTranscript(opts RunOptions, items []runItem, startedAt time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Helix run: %s\n\n", filepath.Base(opts.RequestsFile))
+	fmt.Fprintf(&b, "Started %s on %s (%s shell)", startedAt.Format("2006-01-02 15:04:05"), env.OSName, env.Shell)
+	if opts.DryRun {
+		b.WriteString(", dry run")
+	}
+	b.WriteString("\n")
+
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, item.Request)
+		fmt.Fprintf(&b, "Line %d · %s", item.Line, item.Status)
+		if item.Reason != "" {
+			fmt.Fprintf(&b, " (%s)", item.Reason)
+		}
+		if item.Risk != nil {
+			fmt.Fprintf(&b, " · %s risk", item.Risk.Level)
+		}
+		b.WriteString("\n")
+		if item.Command != "" {
+			fmt.Fprintf(&b, "\n```sh\n%s\n```\n", item.Command)
+		}
+		if output := strings.TrimRight(ansiPattern.ReplaceAllString(item.Output, ""), "\n"); output != "" {
+			b.WriteString("\nOutput")
+			if item.Truncated {
+				b.WriteString(" (end only)")
+			}
+			fmt.Fprintf(&b, ":\n\n```\n%s\n```\n", output)
+		}
+	}
+	return os.WriteFile(opts.TranscriptPath, []byte(b.String()), 0644)
+}
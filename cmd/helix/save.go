@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+)
+
+// lastResponse is the latest answer from /ask or explanation from /explain
+var lastResponse struct {
+	kind string // "response" or "explanation"
+	text string
+	time time.Time
+}
+
+// ansiPattern matches the color escapes stripped from saved output
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// unredirectedCommands take free text, a request, question or command, in
+// which > is part of what was asked, so Helix never treats it as a
+// redirection. /save explanation and /save response keep their answers.
+var unredirectedCommands = []string{
+	"/cmd", "/ask", "/explain", "/git", "/docker", "/k8s", "/k", "/search", "/retrieve",
+	"/net", "/ps", "/policy", "/trust", "/untrust", "/alias", "/save", "/ssh", "/exit",
+}
+
+// rememberResponse keeps an AI response or explanation for /save
+func rememberResponse(kind, text string) {
+	lastResponse.kind = kind
+	lastResponse.text = text
+	lastResponse.time = time.Now()
+}
+
+// handleSaveCommand writes the last command output, AI response or
+// explanation to a file: /save [output|response|explanation] [--append] <path>
+func handleSaveCommand(input string) {
+	fields := strings.Fields(strings.TrimPrefix(input, "/save"))
+	kind, appendTo := "", false
+	var path string
+	for _, field := range fields {
+		switch {
+		case kind == "" && path == "" && (field == "output" || field == "response" || field == "explanation"):
+			kind = field
+		case field == "-a" || field == "--append" || field == ">>":
+			appendTo = true
+		case field == ">":
+		case path == "":
+			path = field
+		default:
+			color.Red("❌ Only one file can be given: %s", field)
+			return
+		}
+	}
+	if path == "" {
+		color.Red("❌ Usage: /save [output|response|explanation] [--append] <path>")
+		color.Yellow("💡 Example: /save output build.log, /save response --append notes.md")
+		color.Yellow("💡 Without a kind, the most recent of the three is saved")
+		return
+	}
+
+	output, hasOutput := commands.LastOutput()
+	if kind == "" {
+		kind = "output"
+		if lastResponse.text != "" && (!hasOutput || lastResponse.time.After(output.Time)) {
+			kind = lastResponse.kind
+		}
+	}
+
+	var content string
+	switch kind {
+	case "output":
+		if !hasOutput {
+			color.Yellow("💡 No command output to save yet")
+			return
+		}
+		content = output.Output
+		if output.Truncated {
+			color.Yellow("⚠️  The output was long; only its last part was kept")
+		}
+	default:
+		if lastResponse.text == "" || lastResponse.kind != kind {
+			color.Yellow("💡 No %s to save yet", kind)
+			return
+		}
+		content = lastResponse.text
+	}
+
+	file, err := openSaveFile(path, appendTo)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if _, err := io.WriteString(file, ansiPattern.ReplaceAllString(content, "")); err != nil {
+		color.Red("❌ Cannot write %s: %v", path, err)
+		return
+	}
+	verb := "Saved"
+	if appendTo {
+		verb = "Appended"
+	}
+	color.Green("💾 %s the last %s to %s", verb, kind, file.Name())
+}
+
+// expandHomePath expands a leading ~ to the home directory
+func expandHomePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// openSaveFile opens a file for /save or a redirection, within the sandbox
+func openSaveFile(path string, appendTo bool) (*os.File, error) {
+	path = expandHomePath(path)
+	if err := sandbox.ValidatePath(path); err != nil {
+		return nil, err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// splitRedirect separates a trailing "> file" or ">> file" from a Helix
+// command, e.g. "/man tar >> notes.md". Quoted > and commands that take
+// free text are left alone.
+func splitRedirect(input string) (command, path string, appendTo, ok bool) {
+	if !strings.HasPrefix(input, "/") {
+		return input, "", false, false
+	}
+	name := strings.Fields(input)[0]
+	for _, unredirected := range unredirectedCommands {
+		if name == unredirected {
+			return input, "", false, false
+		}
+	}
+
+	operator := -1
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		switch c := input[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '>':
+			operator = i
+		}
+	}
+	if operator < 0 {
+		return input, "", false, false
+	}
+
+	start := operator
+	if start > 0 && input[start-1] == '>' {
+		start--
+		appendTo = true
+	}
+	// "2>/dev/null" inside an explained command is not a redirection of Helix's output
+	if start == 0 || (input[start-1] != ' ' && input[start-1] != '\t') {
+		return input, "", false, false
+	}
+	path = strings.TrimSpace(input[operator+1:])
+	if path == "" || strings.ContainsAny(path, " \t&>") {
+		return input, "", false, false
+	}
+	return strings.TrimSpace(input[:start]), path, appendTo, true
+}
+
+// redirectOutput starts copying what a Helix command prints into the file
+// its input redirects to. It returns the command without the redirection,
+// a function to call once the command is done, and false when the
+// redirection cannot be set up.
+func redirectOutput(input string) (string, func(), bool) {
+	command, path, appendTo, ok := splitRedirect(input)
+	if !ok {
+		return input, func() {}, true
+	}
+
+	file, err := openSaveFile(path, appendTo)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return "", nil, false
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		color.Red("❌ Cannot redirect output: %v", err)
+		return "", nil, false
+	}
+
+	// Output still reaches the terminal, so prompts stay visible, like tee
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = writer, writer
+	var captured bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(stdout, &captured), reader)
+		close(done)
+	}()
+
+	return command, func() {
+		os.Stdout, color.Output = stdout, colorOutput
+		writer.Close()
+		<-done
+		reader.Close()
+
+		_, err := file.Write(ansiPattern.ReplaceAll(captured.Bytes(), nil))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			color.Red("❌ Cannot write %s: %v", path, err)
+			return
+		}
+		color.Green("💾 Output written to %s", path)
+	}, true
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestSplitRedirect(t *testing.T) {
+	tests := []struct {
+		input    string
+		command  string
+		path     string
+		appendTo bool
+		ok       bool
+	}{
+		{input: "/man tar > tar.txt", command: "/man tar", path: "tar.txt", ok: true},
+		{input: "/man tar >> notes.md", command: "/man tar", path: "notes.md", appendTo: true, ok: true},
+		{input: "/dirs >notes.md", command: "/dirs", path: "notes.md", ok: true},
+		{input: "/jobs\t> jobs.txt", command: "/jobs", path: "jobs.txt", ok: true},
+
+		// Free text keeps its >
+		{input: "/explain tar >> notes.md"},
+		{input: "/explain ls > out.txt"},
+		{input: "/ask what does > do in bash"},
+		{input: "/ask is 2 > 1"},
+		{input: "/cmd list files bigger than 10M > big.txt"},
+		{input: "/git log > changes.txt"},
+		{input: "/docker ps > containers.txt"},
+		{input: "/k8s get pods > pods.txt"},
+		{input: "/k get pods > pods.txt"},
+		{input: "/search redirect output > file"},
+		{input: "/retrieve compare a > b"},
+		{input: "/net why is ping > 100ms"},
+		{input: "/ps memory > 1G"},
+		{input: "/policy test echo hi > /etc/motd"},
+		{input: "/trust echo * > log.txt"},
+		{input: "/untrust echo * > log.txt"},
+		{input: "/alias save ls -la > files.txt"},
+		{input: "/save output > build.log"},
+		{input: "/ssh web-1 > x"},
+
+		// Not a redirection of Helix's output
+		{input: "/man tar 2>/dev/null"},
+		{input: `/whatis "a > b"`},
+		{input: "/man tar > two words"},
+		{input: "/man tar >"},
+		{input: "/man tar > a&b"},
+		{input: "ls > files.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			command, path, appendTo, ok := splitRedirect(tt.input)
+			if !tt.ok {
+				tt.command = tt.input
+			}
+			if command != tt.command || path != tt.path || appendTo != tt.appendTo || ok != tt.ok {
+				t.Fatalf("splitRedirect(%q) = %q, %q, %v, %v; want %q, %q, %v, %v", tt.input,
+					command, path, appendTo, ok, tt.command, tt.path, tt.appendTo, tt.ok)
+			}
+		})
+	}
+}
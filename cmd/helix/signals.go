@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+
+	"github.com/fatih/color"
+)
+
+// exitInterruptWindow is how soon a second Ctrl+C must follow the first to offer exiting
+const exitInterruptWindow = 2 * time.Second
+
+// promptInterrupts receives Ctrl+C presses that were not consumed by a running command
+var promptInterrupts = make(chan struct{}, 1)
+
+// setupInterruptHandling routes Ctrl+C to the running child command's process
+// group, or to the REPL prompt when nothing is running, instead of killing
+// Helix. A second Ctrl+C within exitInterruptWindow is the kill switch: it
+// kills the command's whole process group.
+func setupInterruptHandling() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	go func() {
+		var lastInterrupt time.Time
+		for range sigChan {
+			if !lastInterrupt.IsZero() && time.Since(lastInterrupt) <= exitInterruptWindow && commands.KillRunningCommand() {
+				color.Red("\n🛑 Running command killed with its process group")
+				lastInterrupt = time.Time{}
+				continue
+			}
+			if commands.InterruptRunningCommand() {
+				color.Yellow("\n⛔ Interrupt sent to running command (Ctrl+C again to kill it)")
+				lastInterrupt = time.Now()
+				continue
+			}
+			lastInterrupt = time.Time{}
+
+			select {
+			case promptInterrupts <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}
+
+// handleAbortCommand is the panic button at the prompt: it kills everything
+// Helix started that is still running and removes its temporary files
+func handleAbortCommand() {
+	report := commands.AbortAll()
+	if !report.Command && report.Groups == 0 && report.Jobs == 0 && report.TempFiles == 0 {
+		color.Green("✅ Nothing to abort: no command, leftover process or background job is running")
+		return
+	}
+	if report.Groups > 0 {
+		color.Red("🛑 Killed processes left running by %d finished command(s)", report.Groups)
+	}
+	if report.Jobs > 0 {
+		color.Red("🛑 Killed %d background job(s) (their logs stay under /jobs)", report.Jobs)
+	}
+	if report.TempFiles > 0 {
+		color.Yellow("🧹 Removed %d temporary file(s)", report.TempFiles)
+	}
+}
+
+// drainPromptInterrupts discards Ctrl+C presses that happened while Helix was busy
+func drainPromptInterrupts() {
+	for {
+		select {
+		case <-promptInterrupts:
+		default:
+			return
+		}
+	}
+}
+
+// lineResult is a single line read from stdin
+type lineResult struct {
+	line string
+	err  error
+}
+
+// promptInput reads REPL lines in the background so Ctrl+C can be handled while waiting.
+// Reads only happen on request, so handlers reading stdin directly are never raced.
+type promptInput struct {
+	reader   *bufio.Reader
+	requests chan struct{}
+	results  chan lineResult
+	pending  bool
+}
+
+// newPromptInput creates a prompt reader on stdin
+func newPromptInput() *promptInput {
+	pi := &promptInput{
+		reader:   bufio.NewReader(os.Stdin),
+		requests: make(chan struct{}),
+		results:  make(chan lineResult),
+	}
+
+	go func() {
+		for range pi.requests {
+			line, err := pi.reader.ReadString('\n')
+			pi.results <- lineResult{line: line, err: err}
+		}
+	}()
+
+	return pi
+}
+
+// request asks the background reader for the next line if none is outstanding
+func (pi *promptInput) request() {
+	if !pi.pending {
+		pi.requests <- struct{}{}
+		pi.pending = true
+	}
+}
+
+// ReadLine shows the prompt and waits for input. A single Ctrl+C at the prompt
+// prints a hint; a second one within exitInterruptWindow asks to exit.
+// The second return value is true when Helix should exit.
+func (pi *promptInput) ReadLine(prompt string) (string, bool) {
+	drainPromptInterrupts()
+	color.Cyan(prompt)
+
+	var lastInterrupt time.Time
+	for {
+		pi.request()
+
+		select {
+		case res := <-pi.results:
+			pi.pending = false
+			if res.err == io.EOF && res.line == "" {
+				return "", true
+			}
+			return strings.TrimSpace(res.line), false
+
+		case <-promptInterrupts:
+			fmt.Println()
+			if !lastInterrupt.IsZero() && time.Since(lastInterrupt) <= exitInterruptWindow {
+				if pi.confirmExit() {
+					return "", true
+				}
+				lastInterrupt = time.Time{}
+			} else {
+				lastInterrupt = time.Now()
+				color.Yellow("💡 Press Ctrl+C again to exit Helix (or type /exit)")
+			}
+			color.Cyan(prompt)
+		}
+	}
+}
+
+// confirmExit asks whether to leave Helix using the outstanding line read
+func (pi *promptInput) confirmExit() bool {
+	fmt.Print("Exit Helix? [y/N]: ")
+
+	select {
+	case res := <-pi.results:
+		pi.pending = false
+		if res.err == io.EOF && res.line == "" {
+			return true
+		}
+		answer := strings.ToLower(strings.TrimSpace(res.line))
+		return answer == "y" || answer == "yes"
+
+	case <-promptInterrupts:
+		fmt.Println()
+		color.Yellow("❌ Exit cancelled")
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"helix/internal/commands"
+	"helix/internal/shell"
+)
+
+func TestDoubleCtrlCKillsCommandIgnoringSIGINT(t *testing.T) {
+	setupInterruptHandling()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := commands.RunCommandCapture("trap '' INT; sleep 30", commands.ExecuteConfig{}, shell.Env{OSName: runtime.GOOS, Shell: "bash"})
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !commands.HasRunningCommand() {
+		if time.Now().After(deadline) {
+			t.Fatal("command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// Ctrl+C reaches Helix, which keeps the terminal, and not the command
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case err := <-done:
+		t.Fatalf("command ignoring SIGINT exited after one Ctrl+C: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("killed command reported success")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Ctrl+C did not kill the command")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"helix/internal/ai"
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// localEnv is the environment /ssh exit returns to
+var localEnv shell.Env
+
+// handleSSHCommand switches command generation and execution to a remote
+// host: /ssh <host> | /ssh exit | /ssh
+func handleSSHCommand(input string) {
+	arg := strings.TrimSpace(strings.TrimPrefix(input, "/ssh"))
+	switch arg {
+	case "":
+		if env.Remote == nil {
+			color.Cyan("🖥️  Running locally. Use /ssh <host> to work on a remote host")
+			return
+		}
+		color.Cyan("🔗 Connected to %s: %s (%s shell) as %s", env.Remote.Host, env.OSName, env.Shell, env.User)
+	case "exit", "off", "close":
+		if env.Remote == nil {
+			color.Yellow("💡 Not connected to a remote host")
+			return
+		}
+		disconnectRemote()
+	default:
+		connectRemote(arg)
+	}
+}
+
+// connectRemote opens an SSH session and makes prompts, retrieval and
+// execution target the remote host
+func connectRemote(host string) {
+	disconnectRemote()
+
+	color.Blue("🔗 Connecting to %s...", host)
+	session, err := shell.Connect(host)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	remoteEnv, err := session.DetectEnvironment()
+	if err != nil {
+		session.Close()
+		color.Red("❌ %v", err)
+		return
+	}
+
+	localEnv = env
+	env = remoteEnv
+	sandbox.SetRemote(host)
+	pb = ai.NewEnhancedPromptBuilder(env, online, ragSystem)
+	if ragSystem != nil {
+		ragSystem.SetToolLookup(session.HasProgram)
+	}
+
+	color.Green("✅ Connected to %s: %s (%s shell) as %s", host, env.OSName, env.Shell, env.User)
+	color.Cyan("💡 /cmd now generates commands for %s and runs them there, with the same confirmations, risk checks and policies", host)
+	color.Yellow("⚠️  The sandbox's path rules, the file previews of rm, mv and cp and /undo snapshots only see this machine's files, so they are off for commands on %s", host)
+	color.Cyan("💡 Suggestions only use tools installed on %s. /ssh exit returns to this machine", host)
+}
+
+// disconnectRemote closes the SSH session, if any, and returns to the local machine
+func disconnectRemote() {
+	if env.Remote == nil {
+		return
+	}
+	host := env.Remote.Host
+	env.Remote.Close()
+
+	env = localEnv
+	sandbox.SetRemote("")
+	pb = ai.NewEnhancedPromptBuilder(env, online, ragSystem)
+	if ragSystem != nil {
+		ragSystem.SetToolLookup(nil)
+	}
+	color.Green("🖥️  Disconnected from %s; commands run locally again", host)
+}
+
+// promptLabel returns the REPL prompt, naming the remote host in /ssh mode
+func promptLabel(name string) string {
+	if env.Remote != nil {
+		return fmt.Sprintf("[%s@%s]> ", name, env.Remote.Host)
+	}
+	return fmt.Sprintf("[%s]> ", name)
+}
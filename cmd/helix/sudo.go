@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// handleSudoCommand shows or changes whether commands may use sudo:
+// /sudo [never|allow]
+func handleSudoCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/sudo"))
+	switch {
+	case len(args) == 0:
+		if cfg.UserPrefs.NeverSudo {
+			color.Cyan("🔑 Never sudo: commands using sudo, doas or pkexec are refused (/sudo allow to change it)")
+		} else {
+			color.Cyan("🔑 Commands may use sudo; Helix explains why and asks for the password before they start (/sudo never to refuse them)")
+		}
+	case len(args) == 1 && (args[0] == "never" || args[0] == "allow"):
+		cfg.UserPrefs.NeverSudo = args[0] == "never"
+		execConfig.NeverSudo = cfg.UserPrefs.NeverSudo
+		if err := cfg.SavePreferences(); err != nil {
+			color.Red("❌ Failed to save preferences: %v", err)
+			return
+		}
+		if cfg.UserPrefs.NeverSudo {
+			color.Green("🔒 Commands using sudo, doas or pkexec are now refused")
+		} else {
+			color.Green("🔑 Commands may use sudo again")
+		}
+	default:
+		color.Red("❌ Usage: /sudo [never|allow]")
+	}
+}
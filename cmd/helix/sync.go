@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/config"
+
+	"github.com/fatih/color"
+)
+
+// loadSharedContent loads synced snippets, git templates and policy packs and activates them
+func loadSharedContent() {
+	if teamSync == nil {
+		return
+	}
+
+	content, err := teamSync.Load()
+	if err != nil {
+		color.Yellow("⚠️  Could not load shared content: %v", err)
+		return
+	}
+
+	if err := commands.SetPolicyPacks(content.Policies); err != nil {
+		color.Red("❌ Policy packs not applied: %v", err)
+	}
+	if gitManager != nil {
+		gitManager.SetTemplates(content.GitTemplates)
+	}
+	sharedContent = content
+
+	if len(content.Snippets)+len(content.GitTemplates)+len(content.Policies) > 0 {
+		color.Green("📦 Shared content: %d snippets, %d git templates, %d policy packs",
+			len(content.Snippets), len(content.GitTemplates), len(content.Policies))
+	}
+}
+
+// handleSyncCommand handles /sync setup|pull|status
+func handleSyncCommand(input string) {
+	args := strings.Fields(strings.TrimSpace(strings.TrimPrefix(input, "/sync")))
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+
+	switch args[0] {
+	case "setup":
+		handleSyncSetup(args[1:])
+	case "pull":
+		handleSyncPull(args[1:])
+	case "status":
+		showSyncStatus()
+	default:
+		color.Red("❌ Unknown sync action: %s", args[0])
+		color.Yellow("💡 Usage: /sync setup <git-url> [branch] [--require-signed] | /sync pull [--force] | /sync status")
+	}
+}
+
+// handleSyncSetup clones the team repository
+func handleSyncSetup(args []string) {
+	var positional []string
+	requireSigned := false
+	for _, arg := range args {
+		if arg == "--require-signed" {
+			requireSigned = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) == 0 {
+		color.Red("❌ Usage: /sync setup <git-url> [branch] [--require-signed]")
+		return
+	}
+
+	remote := positional[0]
+	branch := ""
+	if len(positional) > 1 {
+		branch = positional[1]
+	}
+
+	color.Blue("🔗 Cloning shared repository %s...", remote)
+	if err := teamSync.Setup(remote, branch, requireSigned); err != nil {
+		color.Red("❌ Sync setup failed: %v", err)
+		return
+	}
+
+	settings := teamSync.Settings()
+	color.Green("✅ Sync configured: %s (%s) at %s", settings.Remote, settings.Branch, config.ShortCommit(settings.LastCommit))
+	if settings.RequireSigned {
+		color.Green("🔏 Commit signature verified")
+	}
+	loadSharedContent()
+}
+
+// handleSyncPull updates shared content from the team repository
+func handleSyncPull(args []string) {
+	force := len(args) > 0 && args[0] == "--force"
+
+	color.Blue("🔄 Pulling shared content...")
+	result, err := teamSync.Pull(force)
+	if err != nil {
+		color.Red("❌ Sync failed: %v", err)
+		return
+	}
+
+	if result.PreviousCommit == result.Commit {
+		color.Green("✅ Shared content already up to date (%s)", config.ShortCommit(result.Commit))
+	} else {
+		color.Green("✅ Updated shared content %s → %s",
+			config.ShortCommit(result.PreviousCommit), config.ShortCommit(result.Commit))
+	}
+	if result.Verified {
+		color.Green("🔏 Commit signature verified")
+	}
+	loadSharedContent()
+}
+
+// showSyncStatus prints the sync configuration and loaded content
+func showSyncStatus() {
+	if !teamSync.IsConfigured() {
+		color.Yellow("💡 Sync not configured. Use /sync setup <git-url> [branch] [--require-signed]")
+	} else {
+		settings := teamSync.Settings()
+		color.Cyan("🔗 Remote: %s (%s)", settings.Remote, settings.Branch)
+		color.Cyan("📌 Commit: %s (synced %s)", config.ShortCommit(settings.LastCommit),
+			settings.LastSynced.Format("2006-01-02 15:04"))
+		color.Cyan("🔏 Require signed commits: %v", settings.RequireSigned)
+	}
+	color.Cyan("📁 Local overrides: %s", teamSync.OverrideDir())
+
+	if sharedContent == nil {
+		return
+	}
+
+	for _, snippet := range sharedContent.Snippets {
+		color.White("   snippet  %-20s [%s] %s", snippet.Name, snippet.Source, snippet.Description)
+	}
+	for _, template := range sharedContent.GitTemplates {
+		color.White("   git      %-20s [%s] %s", template.Name, template.Source, template.Description)
+	}
+	for _, pack := range sharedContent.Policies {
+		color.White("   policy   %-20s [%s] %d block, %d confirm rules",
+			pack.Name, pack.Source, len(pack.Block), len(pack.Confirm))
+	}
+}
+
+// handleSnippetCommand lists snippets or runs one by name
+func handleSnippetCommand(input string) {
+	name := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(input, "/snippets"), "/snippet"))
+
+	if sharedContent == nil || len(sharedContent.Snippets) == 0 {
+		color.Yellow("💡 No snippets available. Use /sync setup or add JSON files to %s/snippets",
+			teamSync.OverrideDir())
+		return
+	}
+
+	if name == "" {
+		color.Cyan("📋 Snippets:")
+		for _, snippet := range sharedContent.Snippets {
+			color.Cyan("  %-20s %s", snippet.Name, snippet.Description)
+			color.White("  %-20s %s", "", snippet.Command)
+		}
+		color.Yellow("💡 Run one with /snippet <name>")
+		return
+	}
+
+	snippet, found := sharedContent.FindSnippet(name)
+	if !found {
+		color.Red("❌ Unknown snippet: %s", name)
+		return
+	}
+
+	command := snippet.Command
+	if placeholders := commands.FindPlaceholders(command); len(placeholders) > 0 {
+		filled, err := commands.FillPlaceholders(command, placeholders, env)
+		if err != nil {
+			color.Yellow("❌ Snippet cancelled: %v", err)
+			return
+		}
+		command = filled
+	}
+
+	syntaxHighlighter.PrintHighlightedCommand(fmt.Sprintf("Snippet %s", snippet.Name), command)
+	if !commands.AskForConfirmation("Execute this snippet?") {
+		color.Yellow("💡 Command ready to use: %s", command)
+		return
+	}
+
+	if err := sandbox.WrapCommand(command, execConfig, env); err != nil {
+		color.Red("❌ Snippet failed: %v", err)
+		return
+	}
+	color.Green("✅ Snippet executed successfully!")
+}
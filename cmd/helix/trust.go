@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// trustedPatterns returns the commands and globs trusted in this project
+func trustedPatterns() []string {
+	return cfg.Trusted[projectKey()]
+}
+
+// trustCommand adds a pattern to this project's trusted list and saves it
+func trustCommand(pattern string) error {
+	key := projectKey()
+	for _, existing := range cfg.Trusted[key] {
+		if existing == pattern {
+			return nil
+		}
+	}
+	if cfg.Trusted == nil {
+		cfg.Trusted = make(map[string][]string)
+	}
+	cfg.Trusted[key] = append(cfg.Trusted[key], pattern)
+	return cfg.SavePreferences()
+}
+
+// handleTrustCommand lists or adds the commands that run without
+// confirmation in this project: /trust [pattern]
+func handleTrustCommand(input string) {
+	pattern := trimQuotes(strings.TrimSpace(strings.TrimPrefix(input, "/trust")))
+	if pattern == "" {
+		patterns := trustedPatterns()
+		if len(patterns) == 0 {
+			color.Cyan("🤝 Nothing is trusted in %s (/trust <command or glob> adds one, e.g. /trust \"ls *\")", projectKey())
+			return
+		}
+		color.Cyan("🤝 Run without confirmation in %s:", projectKey())
+		for _, p := range patterns {
+			color.White("   • %s", p)
+		}
+		color.Yellow("💡 High-risk and destructive commands are always confirmed; /untrust <pattern> removes one")
+		return
+	}
+
+	if err := trustCommand(pattern); err != nil {
+		color.Red("❌ Failed to save preferences: %v", err)
+		return
+	}
+	color.Green("🤝 '%s' now runs without confirmation in %s", pattern, projectKey())
+}
+
+// handleUntrustCommand removes a trusted pattern from this project:
+// /untrust <pattern>
+func handleUntrustCommand(input string) {
+	pattern := trimQuotes(strings.TrimSpace(strings.TrimPrefix(input, "/untrust")))
+	if pattern == "" {
+		color.Red("❌ Usage: /untrust <pattern>")
+		return
+	}
+
+	key := projectKey()
+	patterns := cfg.Trusted[key]
+	for i, existing := range patterns {
+		if existing != pattern {
+			continue
+		}
+		cfg.Trusted[key] = append(patterns[:i:i], patterns[i+1:]...)
+		if len(cfg.Trusted[key]) == 0 {
+			delete(cfg.Trusted, key)
+		}
+		if err := cfg.SavePreferences(); err != nil {
+			color.Red("❌ Failed to save preferences: %v", err)
+			return
+		}
+		color.Green("🔒 '%s' asks for confirmation again", pattern)
+		return
+	}
+	color.Yellow("💡 '%s' is not trusted here (/trust lists the patterns)", pattern)
+}
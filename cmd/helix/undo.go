@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"helix/internal/commands"
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// snapshotsDir returns where file snapshots are kept
+func snapshotsDir() string {
+	return filepath.Join(filepath.Dir(cfg.ConfigPath), "snapshots")
+}
+
+// snapshotStore returns the store /undo restores from, whether or not
+// snapshots are on
+func snapshotStore() *commands.SnapshotStore {
+	return commands.NewSnapshotStore(snapshotsDir(), cfg.Snapshots)
+}
+
+// applySnapshots turns snapshots before file-changing commands on or off as
+// the config says
+func applySnapshots() {
+	if !cfg.Snapshots.Enabled {
+		commands.SetSnapshotStore(nil)
+		return
+	}
+	commands.SetSnapshotStore(snapshotStore())
+}
+
+// handleSnapshotsCommand shows or changes the snapshot settings:
+// /snapshots [on|off|keep <n>|size <bytes>]
+func handleSnapshotsCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/snapshots"))
+	switch {
+	case len(args) == 0:
+		state := "off"
+		if cfg.Snapshots.Enabled {
+			state = "on"
+		}
+		keep, size := cfg.Snapshots.Keep, cfg.Snapshots.MaxBytes
+		if keep <= 0 {
+			keep = commands.DefaultSnapshotKeep
+		}
+		if size <= 0 {
+			size = commands.DefaultSnapshotBytes
+		}
+		color.Cyan("📸 Snapshots are %s: the last %d kept, up to %s, in %s", state, keep, utils.FormatBytes(size), snapshotsDir())
+		return
+	case args[0] == "on" && len(args) == 1:
+		cfg.Snapshots.Enabled = true
+	case args[0] == "off" && len(args) == 1:
+		cfg.Snapshots.Enabled = false
+	case args[0] == "keep" && len(args) == 2:
+		keep, err := strconv.Atoi(args[1])
+		if err != nil || keep < 1 {
+			color.Red("❌ Invalid snapshot count: %s", args[1])
+			return
+		}
+		cfg.Snapshots.Keep = keep
+	case args[0] == "size" && len(args) == 2:
+		size, err := utils.ParseBytes(args[1])
+		if err != nil || size < 1 {
+			color.Red("❌ Invalid snapshot size: %s", args[1])
+			return
+		}
+		cfg.Snapshots.MaxBytes = size
+	default:
+		color.Red("❌ Usage: /snapshots [on|off|keep <n>|size <bytes>]")
+		return
+	}
+
+	applySnapshots()
+	if err := cfg.SavePreferences(); err != nil {
+		color.Red("❌ Failed to save preferences: %v", err)
+		return
+	}
+	switch args[0] {
+	case "on":
+		color.Green("📸 Snapshots on: files a command changes are saved first, /undo restores them")
+	case "off":
+		color.Green("📸 Snapshots off")
+	default:
+		color.Green("📸 Snapshot limits updated")
+	}
+}
+
+// handleUndoCommand restores the files of the last snapshot, or of one by
+// ID: /undo [list|<id>]
+func handleUndoCommand(input string) {
+	args := strings.Fields(strings.TrimPrefix(input, "/undo"))
+	store := snapshotStore()
+	if len(args) > 1 {
+		color.Red("❌ Usage: /undo [list|<id>]")
+		return
+	}
+	// Snapshots hold this machine's files, never the remote host's
+	if env.Remote != nil && !(len(args) == 1 && args[0] == "list") {
+		color.Red("❌ /undo restores files on this machine, not on %s", env.Remote.Host)
+		color.Yellow("💡 Run /ssh exit first to restore a local snapshot")
+		return
+	}
+
+	if len(args) == 1 && args[0] == "list" {
+		list, err := store.List()
+		if err != nil {
+			color.Red("❌ %v", err)
+			return
+		}
+		if len(list) == 0 {
+			color.Cyan("📸 No snapshots")
+			return
+		}
+		color.Cyan("📸 Snapshots, newest first:")
+		for _, snapshot := range list {
+			fmt.Printf("  %s  %d path(s)  %s\n", snapshot.ID, len(snapshot.Paths), snapshot.Command)
+		}
+		return
+	}
+
+	id := ""
+	if len(args) == 1 {
+		id = args[0]
+	}
+	snapshot, err := store.Find(id)
+	if err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	color.Cyan("📸 Snapshot %s, before: %s", snapshot.ID, snapshot.Command)
+	for _, path := range snapshot.Paths {
+		if path.Existed {
+			fmt.Printf("  restore %s\n", path.Path)
+		} else {
+			fmt.Printf("  remove  %s\n", path.Path)
+		}
+	}
+	if !commands.AskForConfirmation("Restore these paths? Changes made since are lost") {
+		color.Yellow("❌ Nothing restored")
+		return
+	}
+	if err := store.Restore(snapshot); err != nil {
+		color.Red("❌ %v", err)
+		return
+	}
+	color.Green("✅ Restored %d path(s) from snapshot %s", len(snapshot.Paths), snapshot.ID)
+}
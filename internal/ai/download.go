@@ -3,6 +3,7 @@ package ai
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,21 +11,30 @@ import (
 	"path/filepath"
 	"time"
 
+	"helix/internal/utils"
+
 	"github.com/schollz/progressbar/v3"
 )
 
+// maxSignatureSize bounds a downloaded .minisig file
+const maxSignatureSize = 4096
+
 // DownloadModel checks if the model exists; if not, it asks the user for permission,
-// downloads it with a progress bar, and verifies integrity.
-func DownloadModel(modelPath, url, expectedChecksum string) error {
+// downloads it with a progress bar, and verifies its checksum and its minisign
+// signature against the trusted keys. An unsigned model is only installed
+// when allowUnsigned is set or the user explicitly confirms it. The download
+// goes to a temporary file next to the model, which only becomes the model
+// once verified; a model already installed is checked against its signature.
+func DownloadModel(modelPath, url, expectedChecksum string, trustedKeys []utils.PublicKey, allowUnsigned bool) error {
 	// Ensure model directory exists
 	if err := os.MkdirAll(filepath.Dir(modelPath), 0755); err != nil {
 		return fmt.Errorf("failed to create model directory: %w", err)
 	}
 
-	// Skip download if already present
+	signaturePath := modelPath + ".minisig"
 	if _, err := os.Stat(modelPath); err == nil {
 		fmt.Println("✅ Model already exists locally.")
-		return nil
+		return verifyInstalledModel(modelPath, signaturePath, trustedKeys)
 	}
 
 	var consent string
@@ -35,9 +45,29 @@ func DownloadModel(modelPath, url, expectedChecksum string) error {
 		return nil
 	}
 
+	// Fetch the signature first, so an unsigned model is refused before the
+	// large download rather than after it
+	client := &http.Client{Timeout: 0}
+	partialSignature := signaturePath + ".part"
+	defer os.Remove(partialSignature)
+	signed, err := downloadSignature(client, url+".minisig", partialSignature)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !signed:
+		if !confirmUnsigned("the model has no signature", allowUnsigned) {
+			return fmt.Errorf("%w: refusing to install the model (set %s=1 to override)", utils.ErrUnsigned, utils.AllowUnsignedEnv)
+		}
+	case len(trustedKeys) == 0:
+		if !confirmUnsigned("this build pins no release signing keys", allowUnsigned) {
+			return fmt.Errorf("%w: refusing to install the model (set %s=1 to override)", utils.ErrUnsigned, utils.AllowUnsignedEnv)
+		}
+		signed = false
+	}
+
 	// Start download
 	fmt.Println("⬇️  Downloading model from:", url)
-	client := &http.Client{Timeout: 0}
 	resp, err := client.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download model: %w", err)
@@ -48,10 +78,12 @@ func DownloadModel(modelPath, url, expectedChecksum string) error {
 		return fmt.Errorf("bad response: %s", resp.Status)
 	}
 
-	out, err := os.Create(modelPath)
+	out, err := os.CreateTemp(filepath.Dir(modelPath), filepath.Base(modelPath)+".part-*")
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
+	partialModel := out.Name()
+	defer os.Remove(partialModel)
 	defer out.Close()
 
 	bar := progressbar.NewOptions64(
@@ -69,14 +101,97 @@ func DownloadModel(modelPath, url, expectedChecksum string) error {
 	if _, err = io.Copy(writer, resp.Body); err != nil {
 		return fmt.Errorf("failed while downloading: %w", err)
 	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to save model: %w", err)
+	}
 
 	actualChecksum := hex.EncodeToString(hasher.Sum(nil))
 	fmt.Println("\nVerifying model integrity...")
 	if expectedChecksum != "" && actualChecksum != expectedChecksum {
-		os.Remove(modelPath)
 		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksum)
 	}
 
+	if signed {
+		fmt.Println("🔏 Verifying model signature...")
+		sig, key, err := utils.VerifyFileSignature(partialModel, partialSignature, trustedKeys)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔏 Signed by key %s: %s\n", key.KeyID(), sig.TrustedComment)
+		if err := os.Rename(partialSignature, signaturePath); err != nil {
+			return fmt.Errorf("failed to save signature: %w", err)
+		}
+	}
+	if err := os.Rename(partialModel, modelPath); err != nil {
+		return fmt.Errorf("failed to save model: %w", err)
+	}
+
 	fmt.Println("✅ Model downloaded and verified successfully!")
 	return nil
 }
+
+// verifyInstalledModel checks an installed model against the signature
+// saved with it, so a model swapped on disk is not loaded. A model
+// installed unsigned, or a build without pinned keys, can only be warned
+// about.
+func verifyInstalledModel(modelPath, signaturePath string, trustedKeys []utils.PublicKey) error {
+	if _, err := os.Stat(signaturePath); errors.Is(err, os.ErrNotExist) {
+		fmt.Println("⚠️  The model has no signature; it was installed unsigned.")
+		return nil
+	}
+	if len(trustedKeys) == 0 {
+		fmt.Println("⚠️  This build pins no release signing keys; the model's signature is not checked.")
+		return nil
+	}
+	fmt.Println("🔏 Verifying model signature...")
+	sig, key, err := utils.VerifyFileSignature(modelPath, signaturePath, trustedKeys)
+	if err != nil {
+		return fmt.Errorf("installed model failed verification (delete %s to download it again): %w", modelPath, err)
+	}
+	fmt.Printf("🔏 Signed by key %s: %s\n", key.KeyID(), sig.TrustedComment)
+	return nil
+}
+
+// downloadSignature fetches an artifact's .minisig file. It reports false
+// when the artifact has none.
+func downloadSignature(client *http.Client, url, path string) (bool, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return false, fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("bad response for signature: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize+1))
+	if err != nil {
+		return false, fmt.Errorf("failed to download signature: %w", err)
+	}
+	if len(data) > maxSignatureSize {
+		return false, errors.New("signature file is too large")
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to save signature: %w", err)
+	}
+	return true, nil
+}
+
+// confirmUnsigned asks before installing an artifact whose signature cannot
+// be verified, unless the override is already set
+func confirmUnsigned(reason string, allowUnsigned bool) bool {
+	fmt.Printf("⚠️  Cannot verify the model's signature: %s.\n", reason)
+	if allowUnsigned {
+		fmt.Printf("⚠️  Installing it anyway because %s=1.\n", utils.AllowUnsignedEnv)
+		return true
+	}
+
+	var answer string
+	fmt.Print("Type 'unsigned' to install it anyway: ")
+	fmt.Scanln(&answer)
+	return answer == "unsigned"
+}
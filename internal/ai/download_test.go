@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helix/internal/utils"
+)
+
+// A minisign signature of the 4-byte file "test" and its public key
+const (
+	testPublicKey = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	testSignature = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+)
+
+func testKeys(t *testing.T) []utils.PublicKey {
+	t.Helper()
+	keys, err := utils.ParsePublicKeys(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// answerStdin feeds the download's consent prompt
+func answerStdin(t *testing.T, answer string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString(answer + "\n")
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin; r.Close() })
+}
+
+func TestDownloadModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		wantErr bool
+	}{
+		{name: "verified", model: "test"},
+		{name: "tampered", model: "tesT", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, ".minisig") {
+					w.Write([]byte(testSignature))
+					return
+				}
+				w.Write([]byte(tt.model))
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			modelPath := filepath.Join(dir, "model.gguf")
+			answerStdin(t, "yes")
+			err := DownloadModel(modelPath, server.URL+"/model.gguf", "", testKeys(t), false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadModel error = %v, want error %v", err, tt.wantErr)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			want := "model.gguf model.gguf.minisig"
+			if tt.wantErr {
+				want = "" // nothing is left behind, not even a partial file
+			}
+			if got := strings.Join(names, " "); got != want {
+				t.Fatalf("model directory holds %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDownloadModelVerifiesInstalledModel(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.gguf")
+	if err := os.WriteFile(modelPath+".minisig", []byte(testSignature), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		model   string
+		wantErr bool
+	}{{"test", false}, {"tesT", true}} {
+		if err := os.WriteFile(modelPath, []byte(tt.model), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := DownloadModel(modelPath, "http://127.0.0.1:0/unused", "", testKeys(t), false)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("installed model %q: DownloadModel error = %v, want error %v", tt.model, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxPlanSteps caps how many commands a plan may have
+const maxPlanSteps = 8
+
+// planStepPattern matches a numbered plan line like "1. cmd" or "2) cmd"
+var planStepPattern = regexp.MustCompile(`(?i)^\s*(?:step\s*)?\d+\s*[.):]\s*(.+)$`)
+
+// multiStepConnectors join the parts of a request that needs several commands
+var multiStepConnectors = []string{" then ", " after that ", " afterwards ", " followed by ", "; ", "step by step"}
+
+// multiStepActions are verbs that start a separate step after "and", as in
+// "create a venv and install requirements"
+var multiStepActions = []string{
+	"install", "run", "start", "restart", "activate", "configure", "build", "create",
+	"init", "initialize", "clone", "commit", "push", "deploy", "enable", "compile", "test",
+}
+
+// IsMultiStepRequest reports whether a request asks for several commands run
+// in order rather than one command
+func IsMultiStepRequest(request string) bool {
+	request = " " + strings.ToLower(strings.TrimSpace(request)) + " "
+	if strings.HasPrefix(request, " set up ") || strings.HasPrefix(request, " setup ") {
+		return true
+	}
+	for _, connector := range multiStepConnectors {
+		if strings.Contains(request, connector) {
+			return true
+		}
+	}
+	for _, action := range multiStepActions {
+		if strings.Contains(request, " and "+action+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildPlanPrompt asks for an ordered list of commands that together carry out
+// a request, with RAG context when available
+func (pb *PromptBuilder) BuildPlanPrompt(userInput string) string {
+	originalPrompt := fmt.Sprintf(`You are Helix, an advanced CLI assistant. Break the user's request into an ordered plan of shell commands for %s (%s).
+
+STRICT RULES – FOLLOW EXACTLY:
+1. Output ONLY a numbered list with one command per line, like "1. command"
+2. Never include backticks, code blocks, explanations or extra text
+3. Each step is a single, safe, fully executable command
+4. Use at most %d steps, in the order they must run
+5. Every step runs in a new shell: do not rely on cd, source or activate from an earlier step; use paths instead (e.g. .venv/bin/pip)
+6. Use the correct package manager or system tool for the OS
+7. Avoid destructive operations like rm -rf
+
+User request: %s
+
+Plan:`, pb.env.OSName, pb.env.Shell, maxPlanSteps, userInput)
+
+	if !pb.IsRAGAvailable() {
+		return originalPrompt
+	}
+	return pb.rag.EnhancePrompt(userInput, originalPrompt)
+}
+
+// ExtractPlan reads the commands of a numbered plan from a model response.
+// A response without numbering is read one command per line.
+func ExtractPlan(aiOutput string) []string {
+	var numbered, plain []string
+	for _, line := range strings.Split(aiOutput, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if match := planStepPattern.FindStringSubmatch(line); match != nil {
+			if command := ExtractCommand(match[1]); command != "" {
+				numbered = append(numbered, command)
+			}
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			continue // a heading like "Here is the plan:"
+		}
+		if command := ExtractCommand(strings.TrimPrefix(line, "- ")); command != "" {
+			plain = append(plain, command)
+		}
+	}
+
+	steps := numbered
+	if len(steps) == 0 {
+		steps = plain
+	}
+	if len(steps) > maxPlanSteps {
+		steps = steps[:maxPlanSteps]
+	}
+	return steps
+}
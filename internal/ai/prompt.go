@@ -139,6 +139,107 @@ func (pb *PromptBuilder) BuildExplainPrompt(command string) string {
 	return originalPrompt
 }
 
+// BuildFixPrompt asks the model why a command failed and for a corrected
+// command, with RAG context about the command when available
+func (pb *PromptBuilder) BuildFixPrompt(command string, exitCode int, stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		stderr = "(no error output captured)"
+	}
+
+	originalPrompt := fmt.Sprintf(`You are Helix, an advanced CLI assistant. A shell command failed on %s (%s). Find the cause and correct the command.
+
+RULES:
+1. Answer in exactly two lines, formatted as shown below
+2. The diagnosis is one short sentence about the cause
+3. The command is a single, safe, fully executable shell command with no backticks
+4. If a program is missing, the command installs it with the system package manager
+5. If the command cannot be fixed, write "Command: none"
+
+Failed command: %s
+Exit code: %d
+Error output:
+%s
+
+Diagnosis: <cause>
+Command: <corrected command>
+
+Diagnosis:`, pb.env.OSName, pb.env.Shell, command, exitCode, stderr)
+
+	if !pb.IsRAGAvailable() {
+		return originalPrompt
+	}
+	return pb.rag.EnhancePrompt(command, originalPrompt)
+}
+
+// BuildRefinePrompt asks the model to change a generated command according
+// to a follow-up, keeping the original request as context
+func (pb *PromptBuilder) BuildRefinePrompt(request, command, correction string) string {
+	originalPrompt := fmt.Sprintf(`You are Helix, an advanced CLI assistant. You generated a shell command for %s (%s); the user wants it changed.
+
+RULES:
+1. Output ONLY the revised raw shell command with no explanations or formatting
+2. Start from the previous command and apply the requested change; keep everything else
+3. Never include backticks, code blocks, or extra punctuation
+4. Always produce a safe, fully executable command with properly matched quotes
+
+Original request: %s
+Previous command: %s
+Requested change: %s
+
+Command:`, pb.env.OSName, pb.env.Shell, request, command, correction)
+
+	if !pb.IsRAGAvailable() {
+		return originalPrompt
+	}
+	return pb.rag.EnhancePrompt(request+" "+correction, originalPrompt)
+}
+
+// ParseFixResponse splits a response to BuildFixPrompt into the diagnosis
+// and the corrected command, which is empty when the model found none
+func ParseFixResponse(response string) (diagnosis, command string) {
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "**", ""))
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "command:"):
+			if command == "" {
+				command = ExtractCommand(line[len("command:"):])
+			}
+		case strings.HasPrefix(lower, "diagnosis:"):
+			if diagnosis == "" {
+				diagnosis = strings.TrimSpace(line[len("diagnosis:"):])
+			}
+		case diagnosis == "" && command == "" && line != "":
+			// The prompt ends with "Diagnosis:", so the answer starts with it
+			diagnosis = line
+		}
+	}
+
+	if strings.EqualFold(command, "none") {
+		command = ""
+	}
+	return diagnosis, command
+}
+
+// BuildDocAnswerPrompt asks the model to phrase an answer taken from
+// documentation, without adding anything the excerpt does not say
+func (pb *PromptBuilder) BuildDocAnswerPrompt(question string, answer *rag.DocAnswer) string {
+	return fmt.Sprintf(`Answer the question using ONLY the documentation excerpt below.
+
+RULES:
+1. Do not add facts that are not in the excerpt
+2. Keep it under 3 sentences
+3. Quote flags and file names exactly as written
+
+Documentation (%s):
+%s
+
+Question: %s
+
+Answer:`, answer.Citation(), answer.Excerpt, question)
+}
+
 // BuildPackagePrompt creates package management prompts (unchanged)
 func (pb *PromptBuilder) BuildPackagePrompt(packageName, action string) string {
 	actions := map[string]string{
@@ -281,8 +382,8 @@ func ExtractCommand(aiOutput string) string {
 		}
 	}
 
-	// Remove any leading/trailing quotes
-	command = strings.Trim(command, `"'`)
+	// Remove quotes wrapped around the whole command
+	command = shell.TrimWrappingQuotes(command)
 
 	// Final cleanup - remove any non-command text
 	// Look for the first occurrence of common command patterns
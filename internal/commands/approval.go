@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// ApprovalTTL is how long a request waits for a second operator, and how
+// long an approval stays valid before it is used
+const ApprovalTTL = 24 * time.Hour
+
+// ApprovalStatus is where an approval request stands
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalUsed     ApprovalStatus = "used"
+	ApprovalExpired  ApprovalStatus = "expired"
+)
+
+// Approval is a destructive command waiting for, or given, a second
+// operator's approval in two-person mode
+type Approval struct {
+	Hash        string         `json:"hash"`
+	Command     string         `json:"command"`
+	WorkDir     string         `json:"work_dir"`
+	Risk        RiskLevel      `json:"risk"`
+	Reasons     []string       `json:"reasons,omitempty"`
+	RequestedBy string         `json:"requested_by"`
+	Requested   time.Time      `json:"requested"`
+	Status      ApprovalStatus `json:"status"`
+	DecidedBy   string         `json:"decided_by,omitempty"`
+	Decided     *time.Time     `json:"decided,omitempty"`
+}
+
+// CurrentStatus returns the status, counting requests and approvals older
+// than ApprovalTTL as expired
+func (a Approval) CurrentStatus() ApprovalStatus {
+	since := a.Requested
+	if a.Decided != nil {
+		since = *a.Decided
+	}
+	if (a.Status == ApprovalPending || a.Status == ApprovalApproved) && time.Since(since) > ApprovalTTL {
+		return ApprovalExpired
+	}
+	return a.Status
+}
+
+// ApprovalFile is the pending-approvals file shared by the operators, e.g.
+// on a network drive or in a synced directory
+type ApprovalFile struct {
+	path string
+}
+
+// NewApprovalFile opens the approvals file at path; it is created on the
+// first request
+func NewApprovalFile(path string) *ApprovalFile {
+	return &ApprovalFile{path: path}
+}
+
+// Path returns where the approvals are stored
+func (f *ApprovalFile) Path() string {
+	return f.path
+}
+
+// Approvals returns every request in the file, oldest first
+func (f *ApprovalFile) Approvals() ([]Approval, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var approvals []Approval
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	return approvals, nil
+}
+
+// approvalLockWait is how long an operator waits for another to finish
+// with the file, and approvalLockStale how old a lock left by a crash is
+// before it is broken
+const (
+	approvalLockWait  = 10 * time.Second
+	approvalLockStale = time.Minute
+)
+
+// lock keeps other operators out of the file while one reads, changes and
+// saves it. It creates a lock file next to it with O_EXCL, which also works
+// on network drives, and returns the function that removes it.
+func (f *ApprovalFile) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return nil, err
+	}
+	path := f.path + ".lock"
+	deadline := time.Now().Add(approvalLockWait)
+	for {
+		lockFile, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(lockFile, "%s %d\n", OperatorName(), os.Getpid())
+			lockFile.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > approvalLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another operator; remove %s if no one is using it", f.path, path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// save replaces the file; it is written aside and renamed so another
+// operator never reads half of it
+func (f *ApprovalFile) save(approvals []Approval) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(approvals, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := f.path + ".tmp"
+	if err := os.WriteFile(temp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, f.path)
+}
+
+// Find returns the request with a hash or unique hash prefix
+func (f *ApprovalFile) Find(hash string) (Approval, error) {
+	approvals, err := f.Approvals()
+	if err != nil {
+		return Approval{}, err
+	}
+	i, err := findApproval(approvals, hash)
+	if err != nil {
+		return Approval{}, err
+	}
+	return approvals[i], nil
+}
+
+// Decide approves or rejects a pending request as operator. The operator
+// who asked cannot decide their own request.
+func (f *ApprovalFile) Decide(hash, operator string, approve bool) (Approval, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return Approval{}, err
+	}
+	defer unlock()
+
+	approvals, err := f.Approvals()
+	if err != nil {
+		return Approval{}, err
+	}
+	i, err := findApproval(approvals, hash)
+	if err != nil {
+		return Approval{}, err
+	}
+	approval := &approvals[i]
+	if status := approval.CurrentStatus(); status != ApprovalPending {
+		return *approval, fmt.Errorf("request %s is %s, not pending", approval.Hash, status)
+	}
+	if approval.RequestedBy == operator {
+		return *approval, fmt.Errorf("%s asked for %s; a second operator must decide it", operator, approval.Hash)
+	}
+
+	now := time.Now()
+	approval.Status, approval.DecidedBy, approval.Decided = ApprovalRejected, operator, &now
+	if approve {
+		approval.Status = ApprovalApproved
+	}
+	return *approval, f.save(approvals)
+}
+
+// findApproval returns the index of the request with a hash or unique
+// hash prefix
+func findApproval(approvals []Approval, hash string) (int, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" {
+		return -1, fmt.Errorf("no approval hash given")
+	}
+	found := -1
+	for i, approval := range approvals {
+		if !strings.HasPrefix(approval.Hash, hash) {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("approval hash %s is ambiguous", hash)
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("no approval request %s", hash)
+	}
+	return found, nil
+}
+
+// claim uses the operator's approved request for a command in a directory,
+// or files a new request and returns it with used false. A request already
+// pending is returned as is.
+func (f *ApprovalFile) claim(command, dir, operator string, risk RiskAssessment) (Approval, bool, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return Approval{}, false, err
+	}
+	defer unlock()
+
+	approvals, err := f.Approvals()
+	if err != nil {
+		return Approval{}, false, err
+	}
+	for i := len(approvals) - 1; i >= 0; i-- {
+		approval := &approvals[i]
+		if approval.Command != command || approval.WorkDir != dir || approval.RequestedBy != operator {
+			continue
+		}
+		switch approval.CurrentStatus() {
+		case ApprovalApproved:
+			// The file is shared and plain JSON, so an approval is only
+			// trusted when someone else decided it
+			if approval.DecidedBy == "" || approval.DecidedBy == approval.RequestedBy || approval.Decided == nil {
+				return *approval, false, fmt.Errorf("request %s is marked approved, but not by a second operator", approval.Hash)
+			}
+			approval.Status = ApprovalUsed
+			return *approval, true, f.save(approvals)
+		case ApprovalPending:
+			return *approval, false, nil
+		}
+	}
+
+	now := time.Now()
+	sum := sha256.Sum256([]byte(strings.Join([]string{command, dir, operator, now.Format(time.RFC3339Nano)}, "\x00")))
+	approval := Approval{
+		Hash:        hex.EncodeToString(sum[:])[:12],
+		Command:     command,
+		WorkDir:     dir,
+		Risk:        risk.Level,
+		Reasons:     risk.Reasons,
+		RequestedBy: operator,
+		Requested:   now,
+		Status:      ApprovalPending,
+	}
+	return approval, false, f.save(append(approvals, approval))
+}
+
+// OperatorName identifies the person running Helix by their OS account.
+// The host is left out, so one person cannot approve their own request
+// from a second machine.
+func OperatorName() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// approvals is the approvals file of two-person mode, nil when it is off
+var approvals struct {
+	mu   sync.Mutex
+	file *ApprovalFile
+}
+
+// SetApprovalFile turns two-person mode on with the shared approvals file,
+// or off with nil
+func SetApprovalFile(file *ApprovalFile) {
+	approvals.mu.Lock()
+	defer approvals.mu.Unlock()
+	approvals.file = file
+}
+
+// GetApprovalFile returns the approvals file of two-person mode, or nil
+func GetApprovalFile() *ApprovalFile {
+	approvals.mu.Lock()
+	defer approvals.mu.Unlock()
+	return approvals.file
+}
+
+// NeedsApproval reports whether two-person mode holds a command for a
+// second operator: it is destructive at high risk or above
+func NeedsApproval(risk RiskAssessment) bool {
+	return risk.Has(CategoryDestructive) && risk.Level.Rank() >= RiskHigh.Rank()
+}
+
+// requireApproval lets a destructive command run in two-person mode only
+// once a second operator approved it. Without an approval the command is
+// filed as a request and refused; running it again after `helix approve`
+// uses the approval, once.
+func requireApproval(command string) error {
+	file := GetApprovalFile()
+	if file == nil {
+		return nil
+	}
+	risk := AssessRisk(command)
+	if !NeedsApproval(risk) {
+		return nil
+	}
+
+	dir, _ := os.Getwd()
+	approval, approved, err := file.claim(command, dir, OperatorName(), risk)
+	if err != nil {
+		return fmt.Errorf("two-person mode: cannot use the approvals file: %w", err)
+	}
+	if approved {
+		color.Green("🔐 Approved by %s (request %s)", approval.DecidedBy, approval.Hash)
+		recordDecision("Two-person approval "+approval.Hash, true)
+		return nil
+	}
+
+	color.Yellow("🔐 Two-person mode: a second operator must approve this command")
+	color.Yellow("   Request %s is pending in %s", approval.Hash, file.Path())
+	color.Cyan("💡 Ask them to run: helix approve %s", approval.Hash)
+	color.Cyan("💡 Then run the same command again from %s", dir)
+	recordDecision("Two-person approval "+approval.Hash, false)
+	return fmt.Errorf("waiting for a second operator to approve request %s", approval.Hash)
+}
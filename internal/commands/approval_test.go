@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOperatorNameLeavesOutHost(t *testing.T) {
+	if name := OperatorName(); strings.Contains(name, "@") {
+		t.Fatalf("OperatorName() = %q, want the OS account alone", name)
+	}
+}
+
+func TestClaimRejectsSelfApproval(t *testing.T) {
+	tests := []struct {
+		name      string
+		decidedBy string
+		wantUsed  bool
+		wantErr   bool
+	}{
+		{name: "second operator", decidedBy: "bob", wantUsed: true},
+		{name: "requester edited the file", decidedBy: "alice", wantErr: true},
+		{name: "no decider", decidedBy: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := NewApprovalFile(filepath.Join(t.TempDir(), "approvals.json"))
+			risk := AssessRisk("rm -rf build")
+			pending, used, err := file.claim("rm -rf build", "/work", "alice", risk)
+			if err != nil || used {
+				t.Fatalf("first claim = %v, %v; want a pending request", used, err)
+			}
+
+			// Approve by hand, as anyone with access to the file could
+			approvals, err := file.Approvals()
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			approvals[0].Status, approvals[0].DecidedBy, approvals[0].Decided = ApprovalApproved, tt.decidedBy, &now
+			data, _ := json.Marshal(approvals)
+			if err := os.WriteFile(file.Path(), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			approval, used, err := file.claim("rm -rf build", "/work", "alice", risk)
+			if (err != nil) != tt.wantErr || used != tt.wantUsed {
+				t.Fatalf("claim = used %v, err %v; want used %v, error %v", used, err, tt.wantUsed, tt.wantErr)
+			}
+			if approval.Hash != pending.Hash {
+				t.Fatalf("claim returned request %s, want %s", approval.Hash, pending.Hash)
+			}
+		})
+	}
+}
+
+func TestClaimConcurrentRequestsAreAllKept(t *testing.T) {
+	file := NewApprovalFile(filepath.Join(t.TempDir(), "approvals.json"))
+	risk := AssessRisk("rm -rf build")
+	const operators = 8
+	var wg sync.WaitGroup
+	for i := 0; i < operators; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := filepath.Join("/work", string(rune('a'+i)))
+			if _, _, err := file.claim("rm -rf build", dir, "alice", risk); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	approvals, err := file.Approvals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != operators {
+		t.Fatalf("%d requests in the file, want %d", len(approvals), operators)
+	}
+	if _, err := os.Stat(file.Path() + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// maxAuditOutput caps the output kept per audit record; the end of the
+// output is kept since that is where errors and summaries are
+const maxAuditOutput = 64 << 10
+
+// terminalPrograms take over the terminal, so their output is not captured:
+// teeing it would take the terminal away from them
+var terminalPrograms = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "nano": true, "emacs": true,
+	"less": true, "more": true, "man": true, "top": true, "htop": true,
+	"watch": true, "ssh": true, "tmux": true, "screen": true,
+}
+
+// AuditDecision is one confirmation question and its answer
+type AuditDecision struct {
+	Question string `json:"question"`
+	Approved bool   `json:"approved"`
+}
+
+// AuditRecord reconstructs one /cmd action: what was asked, what the model
+// saw and produced, what the user agreed to and what ran
+type AuditRecord struct {
+	ID             string             `json:"id"`
+	Time           time.Time          `json:"time"`
+	Request        string             `json:"request"`
+	Prompt         string             `json:"prompt,omitempty"`
+	Response       string             `json:"response,omitempty"`    // raw model output
+	Generated      string             `json:"generated,omitempty"`   // command before fixes and edits
+	Refinements    []string           `json:"refinements,omitempty"` // follow-ups that regenerated the command
+	Command        string             `json:"command,omitempty"`     // final command, as run
+	WorkDir        string             `json:"work_dir"`
+	Shell          string             `json:"shell"`
+	OS             string             `json:"os"`
+	Mock           bool               `json:"mock,omitempty"`
+	Decisions      []AuditDecision    `json:"decisions,omitempty"`
+	Executed       bool               `json:"executed"`
+	ExitCode       int                `json:"exit_code"`
+	Error          string             `json:"error,omitempty"`
+	Output         string             `json:"output,omitempty"`
+	OutputCaptured bool               `json:"output_captured"`
+	Truncated      bool               `json:"output_truncated,omitempty"`
+	Duration       time.Duration      `json:"duration,omitempty"`
+	Steps          []ChainStepResult  `json:"steps,omitempty"` // how each step of an && / || chain ended
+	Violations     []SandboxViolation `json:"violations,omitempty"`
+}
+
+// Status summarizes how the action ended
+func (r AuditRecord) Status() string {
+	switch {
+	case !r.Executed && r.Command == "":
+		return "no command"
+	case !r.Executed && len(r.Violations) > 0:
+		return "blocked"
+	case !r.Executed:
+		return "not run"
+	case r.Error != "" && r.ExitCode <= 0:
+		return "failed"
+	case r.ExitCode != 0:
+		return fmt.Sprintf("exit %d", r.ExitCode)
+	default:
+		return "ok"
+	}
+}
+
+// Redacted returns a copy of the record with API keys, passwords and
+// tokens masked in everything the user, the model or the command wrote
+func (r AuditRecord) Redacted() AuditRecord {
+	r.Request = utils.RedactSecrets(r.Request)
+	r.Prompt = utils.RedactSecrets(r.Prompt)
+	r.Response = utils.RedactSecrets(r.Response)
+	r.Generated = utils.RedactSecrets(r.Generated)
+	r.Command = utils.RedactSecrets(r.Command)
+	r.Error = utils.RedactSecrets(r.Error)
+	r.Output = utils.RedactSecrets(r.Output)
+	r.Refinements = append([]string(nil), r.Refinements...)
+	for i := range r.Refinements {
+		r.Refinements[i] = utils.RedactSecrets(r.Refinements[i])
+	}
+	r.Steps = append([]ChainStepResult(nil), r.Steps...)
+	for i := range r.Steps {
+		r.Steps[i].Command = utils.RedactSecrets(r.Steps[i].Command)
+		r.Steps[i].Error = utils.RedactSecrets(r.Steps[i].Error)
+	}
+	r.Violations = append([]SandboxViolation(nil), r.Violations...)
+	for i := range r.Violations {
+		r.Violations[i].Command = utils.RedactSecrets(r.Violations[i].Command)
+	}
+	return r
+}
+
+// activeAudit is the record of the action in progress, filled in by
+// confirmations and command execution
+var activeAudit struct {
+	mu     sync.Mutex
+	record *AuditRecord
+}
+
+// BeginAudit starts recording confirmations and execution into a record,
+// giving it an ID and timestamp
+func BeginAudit(record *AuditRecord) {
+	if record.ID == "" {
+		record.ID = newAuditID()
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.WorkDir == "" {
+		record.WorkDir, _ = os.Getwd()
+	}
+
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	activeAudit.record = record
+}
+
+// EndAudit stops recording and returns the finished record, or nil
+func EndAudit() *AuditRecord {
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	record := activeAudit.record
+	activeAudit.record = nil
+	return record
+}
+
+// auditing reports whether an action is being recorded
+func auditing() bool {
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	return activeAudit.record != nil
+}
+
+// recordDecision adds a confirmation answer to the action being recorded
+func recordDecision(question string, approved bool) {
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	if activeAudit.record != nil {
+		activeAudit.record.Decisions = append(activeAudit.record.Decisions, AuditDecision{Question: question, Approved: approved})
+	}
+}
+
+// violationLog receives sandbox violations that happen outside a recorded
+// action, e.g. in a snippet or /git command
+var violationLog struct {
+	mu  sync.Mutex
+	log *AuditLog
+}
+
+// SetViolationLog sets where sandbox violations outside a /cmd action are
+// recorded; nil stops recording them
+func SetViolationLog(log *AuditLog) {
+	violationLog.mu.Lock()
+	defer violationLog.mu.Unlock()
+	violationLog.log = log
+}
+
+// recordViolation adds a sandbox violation to the action being recorded, or
+// logs it as a record of its own
+func recordViolation(violation SandboxViolation) {
+	activeAudit.mu.Lock()
+	if record := activeAudit.record; record != nil {
+		record.Violations = append(record.Violations, violation)
+		activeAudit.mu.Unlock()
+		return
+	}
+	activeAudit.mu.Unlock()
+
+	violationLog.mu.Lock()
+	log := violationLog.log
+	violationLog.mu.Unlock()
+	if log == nil {
+		return
+	}
+	record := AuditRecord{ID: newAuditID(), Time: violation.Time, Command: violation.Command,
+		WorkDir: violation.Dir, OS: runtime.GOOS, Violations: []SandboxViolation{violation}}
+	if err := log.Append(record); err != nil {
+		color.Yellow("⚠️  Could not record the sandbox violation: %v", err)
+	}
+}
+
+// recordExecution stores the outcome of the command run for the action being recorded
+func recordExecution(command string, exitCode int, err error, output *tailBuffer, duration time.Duration) {
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	record := activeAudit.record
+	if record == nil {
+		return
+	}
+
+	record.Command = command
+	record.Executed = true
+	record.ExitCode = exitCode
+	record.Duration = duration
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if output != nil {
+		record.Output = output.String()
+		record.OutputCaptured = true
+		record.Truncated = output.truncated
+	}
+}
+
+// recordChainSteps stores the steps of the chain run for the action being recorded
+func recordChainSteps(steps []ChainStepResult) {
+	activeAudit.mu.Lock()
+	defer activeAudit.mu.Unlock()
+	if activeAudit.record != nil {
+		activeAudit.record.Steps = steps
+	}
+}
+
+// capturesOutput reports whether a command's output can be copied into the
+// audit log without taking the terminal away from it
+func capturesOutput(command string) bool {
+	fields := strings.Fields(command)
+	for len(fields) > 0 && (fields[0] == "sudo" || fields[0] == "exec" || strings.Contains(fields[0], "=")) {
+		fields = fields[1:]
+	}
+	return len(fields) > 0 && !terminalPrograms[filepath.Base(fields[0])]
+}
+
+// tailBuffer keeps the last maxAuditOutput bytes written to it
+type tailBuffer struct {
+	mu        sync.Mutex
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if over := len(b.data) - maxAuditOutput; over > 0 {
+		b.data = append(b.data[:0], b.data[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// newAuditID returns a short random ID that is easy to type
+func newAuditID() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// AuditLog is an append-only JSON Lines file of audit records
+type AuditLog struct {
+	path string
+}
+
+// NewAuditLog opens the audit log at path; the file is created on first write
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Path returns where the log is stored
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Append adds a record to the log. Records can hold command output, so the
+// file is readable by its owner only, and secrets are masked first.
+func (l *AuditLog) Append(record AuditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record.Redacted())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, string(data))
+	return err
+}
+
+// Records returns every record in the log, oldest first. Damaged lines are skipped.
+func (l *AuditLog) Records() ([]AuditRecord, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*maxAuditOutput)
+	for scanner.Scan() {
+		var record AuditRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.ID != "" {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Find returns the record with an ID or unique ID prefix
+func (l *AuditLog) Find(id string) (AuditRecord, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	records, err := l.Records()
+	if err != nil {
+		return AuditRecord{}, err
+	}
+
+	var matches []AuditRecord
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+		if strings.HasPrefix(record.ID, id) {
+			matches = append(matches, record)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return AuditRecord{}, fmt.Errorf("no audit record %q", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return AuditRecord{}, fmt.Errorf("audit ID %q is ambiguous (%d records match)", id, len(matches))
+	}
+}
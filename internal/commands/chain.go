@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// ChainStep is one pipeline of a command chain and the operator joining it
+// to the next step
+type ChainStep struct {
+	Command string
+	Op      string // "&&", "||", ";" or "" for the last step
+}
+
+// ChainStepResult is how one step of a chain ended
+type ChainStepResult struct {
+	Command  string `json:"command"`
+	Ran      bool   `json:"ran"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	Reason   string `json:"reason,omitempty"` // why a step did not run
+}
+
+// chainStateCommands change the state of the shell they run in, which later
+// steps would lose if each step ran in a shell of its own
+var chainStateCommands = map[string]bool{
+	"cd": true, "pushd": true, "popd": true, "export": true, "unset": true, "set": true,
+	"source": true, ".": true, "alias": true, "unalias": true, "shopt": true, "setopt": true,
+	"umask": true, "ulimit": true, "declare": true, "typeset": true, "local": true,
+	"readonly": true, "eval": true, "exec": true, "trap": true, "hash": true, "wait": true,
+	"exit": true, "return": true, "break": true, "continue": true, "function": true,
+	// Builtins that set variables
+	"read": true, "let": true, "mapfile": true, "readarray": true, "getopts": true,
+	// Compound commands are read as plain words by the parser
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "for": true, "while": true,
+	"until": true, "do": true, "done": true, "{": true, "}": true,
+}
+
+// SplitChain splits a chain like "make && make test || echo failed" into
+// steps Helix runs one at a time. It returns nil for a single command, a
+// chain without && or ||, or one whose steps share shell state (cd, export,
+// variables, $?, here-documents), which would be lost between separate shells.
+func SplitChain(command string, env shell.Env) []ChainStep {
+	if !env.UsesPOSIXSyntax() {
+		return nil
+	}
+	script, err := shell.Parse(command)
+	if err != nil || len(script.Stmts) < 2 {
+		return nil
+	}
+
+	conditional := false
+	steps := make([]ChainStep, 0, len(script.Stmts))
+	for _, stmt := range script.Stmts {
+		if stmt.Op == "&" || sharesShellState(stmt.Pipeline) {
+			return nil
+		}
+		conditional = conditional || stmt.Op == "&&" || stmt.Op == "||"
+		steps = append(steps, ChainStep{Command: stmt.Text, Op: stmt.Op})
+	}
+	if !conditional {
+		return nil
+	}
+	steps[len(steps)-1].Op = ""
+	return steps
+}
+
+// sharesShellState reports whether a pipeline changes or reads shell state
+// that a chain's other steps depend on
+func sharesShellState(pipeline *shell.Pipeline) bool {
+	for _, cmd := range pipeline.Commands {
+		if cmd.Subshell != nil {
+			continue // ( ... ) keeps its changes to itself
+		}
+		for _, redir := range cmd.Redirs {
+			if redir.Op == "<<" || redir.Op == "<<-" {
+				return true // the body follows the whole line, not this step
+			}
+		}
+		if cmd.Group != nil {
+			// { ...; } runs in the current shell
+			for _, stmt := range cmd.Group.Stmts {
+				if sharesShellState(stmt.Pipeline) {
+					return true
+				}
+			}
+			continue
+		}
+		if len(cmd.Args) == 0 {
+			return true // NAME=value for later steps
+		}
+		if chainStateCommands[cmd.Args[0].Value] {
+			return true
+		}
+		for _, word := range append(append([]*shell.Word{}, cmd.Assigns...), cmd.Args...) {
+			if strings.Contains(word.Raw, "$?") || strings.Contains(word.Raw, "$!") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runChain runs the steps of a chain with the shell's branching: a step
+// after && runs only if the previous status is zero, one after || only if
+// it is not, and one after ; always. The chain's status is that of the
+// last step that ran.
+func runChain(command string, steps []ChainStep, config ExecuteConfig, env shell.Env) error {
+	output := &tailBuffer{}
+	var failedStderr *tailBuffer
+	failedCommand := command
+
+	start := time.Now()
+	results := make([]ChainStepResult, len(steps))
+	status := 0
+	var err error
+	for i, step := range steps {
+		results[i].Command = step.Command
+		if i > 0 {
+			if reason := chainSkipReason(steps[i-1].Op, status); reason != "" {
+				results[i].Reason = reason
+				continue
+			}
+		}
+		// Ctrl+C or a limit stops the whole chain, like the shell would
+		if errors.Is(err, ErrCommandInterrupted) || errors.Is(err, ErrCommandTimeout) || errors.Is(err, ErrOutputLimit) {
+			results[i].Reason = "chain stopped"
+			continue
+		}
+
+		color.Cyan("🔗 Step %d/%d: %s", i+1, len(steps), step.Command)
+		stderr := &tailBuffer{}
+		var exitCode int
+		exitCode, err = runForeground(step.Command, config, env, output, stderr)
+		results[i].Ran, results[i].ExitCode = true, exitCode
+		status = exitCode
+		if err != nil {
+			results[i].Error = err.Error()
+			if status == 0 {
+				status = 1 // e.g. the shell could not be started
+			}
+			failedCommand, failedStderr = step.Command, stderr
+		}
+	}
+	PrintChainResults(results)
+
+	exitCode := status
+	recordExecution(command, exitCode, err, output, time.Since(start))
+	recordChainSteps(results)
+	recordFailure(failedCommand, exitCode, err, failedStderr)
+	recordOutput(command, output)
+	runPostExecuteHooks(command, exitCode, err, true)
+	return executionError(err)
+}
+
+// chainSkipReason returns why a step after op does not run given the
+// previous status, or "" when it runs
+func chainSkipReason(op string, status int) string {
+	switch {
+	case op == "&&" && status != 0:
+		return "skipped: the previous step failed"
+	case op == "||" && status == 0:
+		return "skipped: the previous step succeeded"
+	}
+	return ""
+}
+
+// PrintChainResults reports how each step of a chain ended
+func PrintChainResults(results []ChainStepResult) {
+	color.Cyan("🔗 Chain results:")
+	for i, result := range results {
+		switch {
+		case !result.Ran:
+			color.Yellow("   ⏭️  %d. %s (%s)", i+1, result.Command, result.Reason)
+		case result.Error == "":
+			color.Green("   ✅ %d. %s", i+1, result.Command)
+		default:
+			color.Red("   ❌ %d. %s (exit code %d)", i+1, result.Command, result.ExitCode)
+		}
+	}
+}
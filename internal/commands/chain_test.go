@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"helix/internal/shell"
+)
+
+func TestSplitChain(t *testing.T) {
+	env := shell.Env{OSName: "linux", Shell: "bash"}
+	tests := []struct {
+		name    string
+		command string
+		want    []ChainStep
+	}{
+		{
+			name:    "and then or",
+			command: "make && make test || echo failed",
+			want:    []ChainStep{{"make", "&&"}, {"make test", "||"}, {"echo failed", ""}},
+		},
+		{
+			name:    "operators inside [[ ]]",
+			command: "[[ -f go.mod && -d internal ]] && echo ok",
+			want:    []ChainStep{{"[[ -f go.mod && -d internal ]]", "&&"}, {"echo ok", ""}},
+		},
+		{
+			name:    "[[ ]] with || and a regex",
+			command: `[[ $x == a || $x =~ ^(b|c)$ ]] || echo other`,
+			want:    []ChainStep{{`[[ $x == a || $x =~ ^(b|c)$ ]]`, "||"}, {"echo other", ""}},
+		},
+		{
+			name:    "arithmetic (( ))",
+			command: "(( 1 > 0 && 2 > 1 )) && echo yes",
+			want:    []ChainStep{{"(( 1 > 0 && 2 > 1 ))", "&&"}, {"echo yes", ""}},
+		},
+		{
+			name:    "subshell keeps its chain",
+			command: "(cd build && make) && echo built",
+			want:    []ChainStep{{"(cd build && make)", "&&"}, {"echo built", ""}},
+		},
+		{
+			name:    "brace group keeps its chain",
+			command: "{ make && make install; } || echo failed",
+			want:    []ChainStep{{"{ make && make install; }", "||"}, {"echo failed", ""}},
+		},
+		{
+			name:    "brace group changing directory",
+			command: "{ cd build; } && make",
+		},
+		{
+			name:    "here-document body is not split",
+			command: "cat <<EOF > notes.txt && echo written\na && b || c\nEOF",
+		},
+		{
+			name:    "shared shell state",
+			command: "cd build && make",
+		},
+		{name: "read sets a variable", command: "read x && echo $x"},
+		{name: "let sets a variable", command: "let i=1 && echo $i"},
+		{name: "mapfile sets an array", command: "mapfile a < f && echo ${a[0]}"},
+		{name: "readarray sets an array", command: "readarray -t lines < f && echo ${#lines[@]}"},
+		{name: "getopts sets a variable", command: "getopts ab opt && echo $opt"},
+		{name: "declare sets a variable", command: "declare -i n=2 && echo $n"},
+		{name: "set changes options", command: "set -e && false || echo reached"},
+		{
+			name:    "no conditional operator",
+			command: "echo a; echo b",
+		},
+		{
+			name:    "single command",
+			command: "[[ -n $HOME && -d $HOME ]]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitChain(tt.command, env); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitChain(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package commands
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile makes dst a copy-on-write clone of src, on file systems that
+// share extents (btrfs, XFS)
+func cloneFile(dst, src *os.File) error {
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package commands
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile is unsupported here; files are copied instead
+func cloneFile(dst, src *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"helix/internal/shell"
+)
+
+// diskPrograms format or partition the device they are given
+var diskPrograms = map[string]bool{
+	"mkfs": true, "wipefs": true, "fdisk": true, "parted": true, "mkswap": true, "sgdisk": true,
+}
+
+// confirmationTargets returns what a destructive command destroys, like gh
+// and terraform ask for it: the directories a recursive delete removes, the
+// branch a force push overwrites or the devices a format erases. It is nil
+// when the command has no such target.
+func confirmationTargets(command string) []string {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil
+	}
+	var targets []string
+	for _, cmd := range script.Commands() {
+		name, args, _ := resolveProgram(cmd)
+		values := make([]string, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+			if strings.EqualFold(name, "Remove-Item") {
+				values[i] = strings.Trim(arg.Raw, `'"`) // PowerShell paths keep their backslashes
+			}
+		}
+		for _, target := range destructiveTargets(name, values) {
+			targets = appendPath(targets, target)
+		}
+	}
+	return targets
+}
+
+// confirmationToken returns what the user types to confirm destroying
+// targets: the most dangerous of them, or "" when there are none
+func confirmationToken(targets []string) string {
+	token, worst := "", -1
+	for _, target := range targets {
+		if danger := targetDanger(target); danger > worst {
+			token, worst = target, danger
+		}
+	}
+	return token
+}
+
+// targetDanger ranks how much destroying a target can lose: the root, the
+// home or the working directory, then system paths, then paths outside the
+// working directory, and the closer to the root the worse
+func targetDanger(target string) int {
+	danger := 0
+	switch target {
+	case "/", "~", "$HOME", ".", "..", "*", `C:\`:
+		danger = 300
+	default:
+		switch {
+		case isSystemPath(target):
+			danger = 200
+		case filepath.IsAbs(target) || strings.HasPrefix(target, "~") || strings.HasPrefix(target, ".."):
+			danger = 100
+		}
+	}
+	if strings.ContainsAny(target, "*?") {
+		danger += 50
+	}
+	return danger - strings.Count(strings.ReplaceAll(target, `\`, "/"), "/")
+}
+
+// destructiveTargets returns the targets of a destructive program's
+// arguments
+func destructiveTargets(name string, args []string) []string {
+	options, operands := splitOperands(args)
+	switch {
+	case name == "rm" && hasAnyOption(options, "r", "R", "--recursive"),
+		strings.EqualFold(name, "Remove-Item") && hasAnyOption(options, "-Recurse"):
+		targets := make([]string, len(operands))
+		for i, operand := range operands {
+			targets[i] = filepath.Clean(operand)
+		}
+		return targets
+	case name == "git":
+		if branch := forcePushBranch(args); branch != "" {
+			return []string{branch}
+		}
+	case name == "dd":
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "of=") {
+				return []string{strings.TrimPrefix(arg, "of=")}
+			}
+		}
+	case diskPrograms[name] || strings.HasPrefix(name, "mkfs."):
+		var devices []string
+		for _, operand := range operands {
+			if strings.HasPrefix(operand, "/dev/") {
+				devices = append(devices, operand)
+			}
+		}
+		if len(devices) == 0 && len(operands) > 0 {
+			return operands[len(operands)-1:]
+		}
+		return devices
+	}
+	return nil
+}
+
+// splitOperands separates options from the words they apply to
+func splitOperands(args []string) (options, operands []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return options, append(operands, args[i+1:]...)
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			options = append(options, arg)
+		} else {
+			operands = append(operands, arg)
+		}
+	}
+	return options, operands
+}
+
+// hasAnyOption reports whether options contain one of the names; a one-letter
+// name also matches inside combined short options like -rf
+func hasAnyOption(options []string, names ...string) bool {
+	for _, option := range options {
+		for _, name := range names {
+			switch {
+			case len(name) == 1:
+				if !strings.HasPrefix(option, "--") && strings.Contains(option[1:], name) {
+					return true
+				}
+			case strings.EqualFold(option, name):
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// forcePushBranch returns the branch a git push --force overwrites: the
+// refspec's destination, or else the current branch
+func forcePushBranch(args []string) string {
+	push := -1
+	for i, arg := range args {
+		if arg == "push" {
+			push = i
+			break
+		}
+	}
+	if push < 0 {
+		return ""
+	}
+
+	options, operands := splitOperands(args[push+1:])
+	force := hasAnyOption(options, "f", "--force", "--force-with-lease") ||
+		(len(operands) > 1 && strings.HasPrefix(operands[len(operands)-1], "+"))
+	for _, option := range options {
+		force = force || strings.HasPrefix(option, "--force-with-lease=")
+	}
+	if !force {
+		return ""
+	}
+
+	if len(operands) > 1 {
+		refspec := strings.TrimPrefix(operands[len(operands)-1], "+")
+		if _, destination, ok := strings.Cut(refspec, ":"); ok {
+			refspec = destination
+		}
+		return strings.TrimPrefix(refspec, "refs/heads/")
+	}
+	if branch, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+		if name := strings.TrimSpace(string(branch)); name != "HEAD" {
+			return name
+		}
+	}
+	if len(operands) == 1 {
+		return operands[0] // the remote, when the branch is unknown
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfirmationTargets(t *testing.T) {
+	tests := []struct {
+		command string
+		targets []string
+		token   string
+	}{
+		{"rm -rf build", []string{"build"}, "build"},
+		{"rm -rf build/ dist", []string{"build", "dist"}, "build"},
+		{"rm -rf build ~/.config /tmp/cache", []string{"build", "~/.config", "/tmp/cache"}, "~/.config"},
+		{"rm -rf build/out node_modules /etc/nginx", []string{"build/out", "node_modules", "/etc/nginx"}, "/etc/nginx"},
+		{"rm -rf a/b/c a", []string{"a/b/c", "a"}, "a"},
+		{"rm -rf dist .", []string{"dist", "."}, "."},
+		{"rm -rf old; rm -rf new", []string{"old", "new"}, "old"},
+		{"rm notes.txt", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			risk := AssessRisk(tt.command)
+			if !reflect.DeepEqual(risk.ConfirmTargets, tt.targets) || risk.ConfirmToken != tt.token {
+				t.Fatalf("targets %q, token %q; want %q, %q", risk.ConfirmTargets, risk.ConfirmToken, tt.targets, tt.token)
+			}
+		})
+	}
+}
+
+func TestQuestionListsEveryTarget(t *testing.T) {
+	question := AssessRisk("rm -rf build dist /var/lib/app").Question()
+	for _, want := range []string{"3 targets", "build", "dist", `Type "/var/lib/app"`} {
+		if !strings.Contains(question, want) {
+			t.Errorf("Question() = %q, want it to contain %q", question, want)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"helix/internal/ai"
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// DockerManager handles container operations for docker or podman with AI assistance
+type DockerManager struct {
+	env        shell.Env
+	execConfig ExecuteConfig
+	sandbox    *DirectorySandbox
+	engine     string // docker or podman; empty when neither is installed
+	compose    string // e.g. "docker compose", "docker-compose" or "podman-compose"
+}
+
+// ContainerOperation represents a container operation with safety checks
+type ContainerOperation struct {
+	Description  string
+	Command      string // ${ENGINE}, ${COMPOSE} and ${CONTAINER} are filled in before running
+	Confirmation string
+	Risks        []string
+	Destructive  bool
+}
+
+// NewDockerManager creates a container manager for whichever engine is installed
+func NewDockerManager(env shell.Env, execConfig ExecuteConfig, sandbox *DirectorySandbox) *DockerManager {
+	dm := &DockerManager{env: env, execConfig: execConfig, sandbox: sandbox}
+	dm.detectEngine()
+	return dm
+}
+
+// SetExecuteConfig updates the execution settings, e.g. after /dry-run
+func (dm *DockerManager) SetExecuteConfig(execConfig ExecuteConfig) {
+	dm.execConfig = execConfig
+}
+
+// Engine returns the detected container engine, or "" when there is none
+func (dm *DockerManager) Engine() string {
+	return dm.engine
+}
+
+// detectEngine finds docker or podman and the matching compose command
+func (dm *DockerManager) detectEngine() {
+	for _, engine := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(engine); err == nil {
+			dm.engine = engine
+			break
+		}
+	}
+
+	switch {
+	case dm.engine != "" && exec.Command(dm.engine, "compose", "version").Run() == nil:
+		dm.compose = dm.engine + " compose"
+	case dm.engine == "podman" && hasProgram("podman-compose"):
+		dm.compose = "podman-compose"
+	case hasProgram("docker-compose"):
+		dm.compose = "docker-compose"
+	}
+}
+
+// hasProgram reports whether a program is on the PATH
+func hasProgram(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// HandleDockerRequest processes natural language container requests
+func (dm *DockerManager) HandleDockerRequest(request string) error {
+	request = strings.ToLower(strings.TrimSpace(request))
+
+	color.Blue("🐳 Processing container request: %s", request)
+
+	if dm.engine == "" {
+		color.Red("❌ Neither docker nor podman is installed")
+		color.Yellow("💡 Install one with /install docker or /install podman")
+		return fmt.Errorf("no container engine")
+	}
+
+	if operation := dm.detectContainerOperation(request); operation != nil {
+		return dm.executeContainerOperation(operation, request)
+	}
+
+	// Fall back to AI for other container requests
+	return dm.handleAIDockerRequest(request)
+}
+
+// detectContainerOperation identifies common container workflows
+func (dm *DockerManager) detectContainerOperation(request string) *ContainerOperation {
+	has := func(words ...string) bool {
+		for _, word := range words {
+			if strings.Contains(request, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	// Remove dangling (untagged) images
+	case has("dangling") || (has("clean", "prune", "remove", "delete") && has("untagged")):
+		return &ContainerOperation{
+			Description:  "Remove dangling images",
+			Command:      "${ENGINE} image prune -f",
+			Confirmation: "This will delete all untagged images that no container uses.",
+			Risks: []string{
+				"Deleted images must be pulled or built again",
+			},
+			Destructive: true,
+		}
+
+	// Remove every unused image
+	case has("clean", "prune", "remove", "delete") && has("unused images", "all images", "old images"):
+		return &ContainerOperation{
+			Description:  "Remove all images not used by a container",
+			Command:      "${ENGINE} image prune -a -f",
+			Confirmation: "This will delete every image that no container uses, tagged or not.",
+			Risks: []string{
+				"Base images must be pulled again on the next build",
+				"Cannot be undone",
+			},
+			Destructive: true,
+		}
+
+	// Clean up stopped containers, unused networks and dangling images
+	case has("system prune", "clean up everything", "clean everything", "free space", "reclaim space"):
+		return &ContainerOperation{
+			Description:  "Remove stopped containers, unused networks and dangling images",
+			Command:      "${ENGINE} system prune -f",
+			Confirmation: "This will delete stopped containers, unused networks, dangling images and build cache.",
+			Risks: []string{
+				"Stopped containers and their writable layers are lost",
+				"Cannot be undone",
+			},
+			Destructive: true,
+		}
+
+	// Disk usage
+	case has("disk", "space", "usage", "df"):
+		return &ContainerOperation{
+			Description:  "Show disk usage of images, containers and volumes",
+			Command:      "${ENGINE} system df",
+			Confirmation: "Show container disk usage?",
+		}
+
+	// Rebuild and restart the compose stack
+	case has("rebuild", "build") && has("compose", "stack", "services"):
+		return &ContainerOperation{
+			Description:  "Rebuild the compose stack and restart it in the background",
+			Command:      "${COMPOSE} up -d --build",
+			Confirmation: "This will rebuild the images of this compose project and recreate changed containers.",
+			Risks: []string{
+				"Recreated containers lose data not kept in volumes",
+			},
+		}
+
+	// Stop the compose stack
+	case has("compose", "stack") && has("down", "stop", "tear down", "shut down"):
+		return &ContainerOperation{
+			Description:  "Stop and remove the compose stack",
+			Command:      "${COMPOSE} down",
+			Confirmation: "This will stop and remove the containers and networks of this compose project.",
+			Risks: []string{
+				"Container data not kept in volumes is lost",
+			},
+			Destructive: true,
+		}
+
+	// Show logs of a container
+	case has("log"):
+		command := "${ENGINE} logs --tail 100 ${CONTAINER}"
+		if has("follow", "live", "tail -f", "stream") {
+			command = "${ENGINE} logs -f --tail 100 ${CONTAINER}"
+		}
+		return &ContainerOperation{
+			Description:  "Show the latest logs of a container",
+			Command:      command,
+			Confirmation: "Show these logs?",
+		}
+
+	// Open a shell in a container
+	case has("shell", "exec", "attach", "bash"):
+		return &ContainerOperation{
+			Description:  "Open a shell inside a running container",
+			Command:      "${ENGINE} exec -it ${CONTAINER} sh",
+			Confirmation: "Open a shell in this container?",
+		}
+
+	// Remove stopped containers
+	case has("clean", "prune", "remove", "delete") && has("stopped", "exited", "dead"):
+		return &ContainerOperation{
+			Description:  "Remove all stopped containers",
+			Command:      "${ENGINE} container prune -f",
+			Confirmation: "This will delete every container that is not running.",
+			Risks: []string{
+				"Data in the writable layers of stopped containers is lost",
+				"Cannot be undone",
+			},
+			Destructive: true,
+		}
+
+	// Restart or stop a container
+	case has("restart"):
+		return &ContainerOperation{
+			Description:  "Restart a container",
+			Command:      "${ENGINE} restart ${CONTAINER}",
+			Confirmation: "This will restart the container.",
+			Risks: []string{
+				"Active connections to the container are dropped",
+			},
+		}
+	case has("stop"):
+		return &ContainerOperation{
+			Description:  "Stop a container",
+			Command:      "${ENGINE} stop ${CONTAINER}",
+			Confirmation: "This will stop the container.",
+			Risks: []string{
+				"The service it provides is unavailable until it starts again",
+			},
+		}
+
+	// List containers
+	case has("list", "running", "containers", "ps"):
+		command := "${ENGINE} ps"
+		if has("all", "stopped", "exited") {
+			command = "${ENGINE} ps -a"
+		}
+		return &ContainerOperation{
+			Description:  "List containers",
+			Command:      command,
+			Confirmation: "List containers?",
+		}
+	}
+
+	return nil
+}
+
+// executeContainerOperation safely executes a container operation with confirmation
+func (dm *DockerManager) executeContainerOperation(operation *ContainerOperation, request string) error {
+	command := strings.ReplaceAll(operation.Command, "${ENGINE}", dm.engine)
+	if strings.Contains(command, "${COMPOSE}") {
+		if dm.compose == "" {
+			return fmt.Errorf("no compose command found; install the %s compose plugin", dm.engine)
+		}
+		command = strings.ReplaceAll(command, "${COMPOSE}", dm.compose)
+	}
+	if strings.Contains(command, "${CONTAINER}") {
+		container, err := dm.getTargetContainer(request)
+		if err != nil {
+			return err
+		}
+		command = strings.ReplaceAll(command, "${CONTAINER}", container)
+		color.Green("🎯 Container: %s", container)
+	}
+
+	color.Cyan("\n📋 Operation: %s", operation.Description)
+	color.Yellow("🚀 Command: %s", command)
+	color.Blue("🐳 Engine: %s", dm.engine)
+
+	// Show risks
+	if len(operation.Risks) > 0 {
+		color.Red("⚠️  Risks:")
+		for _, risk := range operation.Risks {
+			color.Red("   • %s", risk)
+		}
+		fmt.Println()
+	}
+
+	if !AskForConfirmation(operation.Confirmation) {
+		color.Yellow("❌ Operation cancelled")
+		return nil
+	}
+
+	// Final confirmation for destructive operations
+	if operation.Destructive {
+		if !AskForConfirmation("🚨 This is a destructive operation. Final confirmation?") {
+			color.Yellow("❌ Operation cancelled")
+			return nil
+		}
+	}
+
+	color.Green("✅ Executing container operation...")
+	return dm.sandbox.WrapCommand(command, dm.execConfig, dm.env)
+}
+
+// listContainers returns the names of all containers, including stopped ones
+func (dm *DockerManager) listContainers() ([]string, error) {
+	output, err := exec.Command(dm.engine, "ps", "-a", "--format", "{{.Names}}").Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// getTargetContainer picks the container named in the request, or asks for one
+func (dm *DockerManager) getTargetContainer(request string) (string, error) {
+	containers, err := dm.listContainers()
+	if err != nil {
+		color.Yellow("⚠️  Could not list containers")
+	}
+	for _, word := range strings.Fields(request) {
+		for _, container := range containers {
+			if strings.EqualFold(word, container) {
+				return container, nil
+			}
+		}
+	}
+
+	if len(containers) > 0 {
+		color.Cyan("📦 Containers:")
+		for i, container := range containers {
+			if i < 10 { // Show first 10 containers
+				color.Cyan("   %s", container)
+			}
+		}
+	}
+
+	color.Cyan("\n🔍 Enter container name or ID: ")
+	var container string
+	fmt.Scanln(&container)
+	container = strings.TrimSpace(container)
+
+	if container == "" {
+		return "", fmt.Errorf("no container specified")
+	}
+	return container, nil
+}
+
+// handleAIDockerRequest uses AI for other container operations
+func (dm *DockerManager) handleAIDockerRequest(request string) error {
+	prompt := fmt.Sprintf(`You are a %s expert. Provide a single %s command for: "%s"
+
+Rules:
+- Output ONLY the command
+- No explanations, no markdown, no backticks
+- Use %s, not any other container engine
+- Avoid destructive options unless clearly requested
+
+Command:`, dm.engine, dm.engine, request, dm.engine)
+
+	color.Blue("🤖 Generating %s command with AI...", dm.engine)
+	response, err := ai.RunModel(prompt)
+	if err != nil {
+		return fmt.Errorf("AI %s command generation failed: %w", dm.engine, err)
+	}
+
+	command := ai.ExtractCommand(response)
+	if command == "" {
+		return fmt.Errorf("AI didn't generate a valid %s command", dm.engine)
+	}
+
+	color.Cyan("💡 Generated command: %s", command)
+
+	// Use the installed engine whatever the model wrote; compose tools are kept
+	switch program := strings.Fields(command)[0]; {
+	case program == "docker" || program == "podman":
+		command = dm.engine + strings.TrimPrefix(command, program)
+	case !strings.HasSuffix(program, "-compose"):
+		command = dm.engine + " " + command
+	}
+
+	// Let the user fill placeholders like <container> instead of running them literally
+	if placeholders := FindPlaceholders(command); len(placeholders) > 0 {
+		command, err = FillPlaceholders(command, placeholders, dm.env)
+		if err != nil {
+			return err
+		}
+	}
+
+	if AskForConfirmation(fmt.Sprintf("Execute this %s command?", dm.engine)) {
+		return dm.sandbox.WrapCommand(command, dm.execConfig, dm.env)
+	}
+
+	color.Yellow("💡 Command ready: %s", command)
+	return nil
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// networkClients are programs whose purpose is to reach other hosts
+var networkClients = map[string]bool{
+	"curl": true, "wget": true, "ssh": true, "scp": true, "sftp": true, "nc": true, "ncat": true,
+	"netcat": true, "socat": true, "telnet": true, "ftp": true, "rsync": true, "aria2c": true,
+	"http": true, "xh": true, "ping": true, "ping6": true, "dig": true, "nslookup": true,
+	"Invoke-WebRequest": true, "Invoke-RestMethod": true, "iwr": true, "irm": true,
+}
+
+// NetworkUseIn returns the network client or endpoint a command uses, or ""
+// when it does not appear to reach the network
+func NetworkUseIn(command string) string {
+	sim, err := SimulateCommand(command)
+	if err != nil {
+		return ""
+	}
+	for _, program := range sim.Programs {
+		if networkClients[program] {
+			return program
+		}
+	}
+	if len(sim.Endpoints) > 0 {
+		return sim.Endpoints[0]
+	}
+	return ""
+}
+
+// networkIsolation is the command prefix that runs a program without
+// network access: firejail or an unprivileged network namespace on Linux,
+// or nil when neither is available
+var networkIsolation = sync.OnceValue(func() []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	if path, err := exec.LookPath("firejail"); err == nil {
+		return []string{path, "--quiet", "--noprofile", "--net=none"}
+	}
+	if path, err := exec.LookPath("unshare"); err == nil {
+		// User namespaces may be disabled, so try it once
+		if exec.Command(path, "--map-root-user", "--net", "true").Run() == nil {
+			return []string{path, "--map-root-user", "--net"}
+		}
+	}
+	return nil
+})
+
+// NetworkIsolationTool names the program that cuts commands off from the
+// network, or returns "" when commands are only checked
+func NetworkIsolationTool() string {
+	if prefix := networkIsolation(); len(prefix) > 0 {
+		return prefix[0]
+	}
+	return ""
+}
+
+// withNetworkIsolation runs a command line without network access when the
+// sandbox blocks the network and a tool for it exists
+func (c ExecuteConfig) withNetworkIsolation(args []string) []string {
+	if !c.offline {
+		return args
+	}
+	return append(append([]string(nil), networkIsolation()...), args...)
+}
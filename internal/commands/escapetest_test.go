@@ -0,0 +1,26 @@
+package commands
+
+import "testing"
+
+func TestEscapeBattery(t *testing.T) {
+	for _, mode := range []SandboxMode{SandboxCurrentDir, SandboxStrict} {
+		sandbox := &DirectorySandbox{allowedDir: t.TempDir(), mode: mode}
+		results, err := sandbox.TestEscapes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(escapeAttempts) {
+			t.Fatalf("TestEscapes returned %d results for %d attempts", len(results), len(escapeAttempts))
+		}
+		for _, result := range results {
+			if result.Skipped != "" {
+				t.Logf("%s: skipped: %s", result.Command, result.Skipped)
+				continue
+			}
+			if result.Blocked == result.Allowed {
+				t.Errorf("mode %s, %s (%s): %q blocked = %v, want %v (%s)", sandbox.ModeString(), result.Category,
+					result.Description, result.Command, result.Blocked, !result.Allowed, result.Reason)
+			}
+		}
+	}
+}
@@ -1,32 +1,57 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"helix/internal/ai"
 	"helix/internal/shell"
 	"helix/internal/utils"
+	"helix/internal/ux"
 
 	"github.com/fatih/color"
 )
 
-// Dangerous commands and patterns to block
-var dangerousPatterns = []string{
-	"rm -rf /", "rm -rf /*", "format c:", "mkfs", "fdisk", "dd if=/dev/zero",
-	"> /dev/sda", "chmod -R 777 /", "mv / /dev/null", "> /etc/passwd",
-	":(){ :|:& };:", "fork bomb", "debugfs", "mkswap", "swapoff", "> /boot",
-}
-
 // ExecuteConfig holds execution preferences
 type ExecuteConfig struct {
 	DryRun      bool
 	AutoConfirm bool
 	SafeMode    bool
+	Background  bool // run detached as a job, as if the command ended in &
+
+	// Resource limits; zero values leave a command unrestricted
+	Timeout        time.Duration // stop a command that runs longer
+	MaxOutputBytes int64         // stop a command that prints more, stdout and stderr together
+	Nice           int           // lower the CPU priority with nice -n (1-19, Unix)
+	IONiceClass    int           // I/O class for ionice -c: IONiceBestEffort or IONiceIdle (Linux)
+
+	// BulkFileThreshold is how many files globs of rm, mv or cp may match
+	// before the count must be typed to confirm; 0 means DefaultBulkFileThreshold
+	BulkFileThreshold int
+
+	// validate re-checks a command changed by a pre-execute hook against
+	// the sandbox the command was validated for
+	validate func(command string) (bool, string)
+
+	// NeverSudo refuses commands that use sudo, doas or pkexec
+	NeverSudo bool
+
+	// Stdout receives a foreground command's output; nil is os.Stdout. A
+	// one-shot run keeps os.Stdout on stderr for its own messages.
+	Stdout *os.File
+
+	// offline runs commands without network access, when the sandbox blocks it
+	offline bool
 }
 
 // DefaultExecuteConfig returns safe default execution settings
@@ -38,25 +63,6 @@ func DefaultExecuteConfig() ExecuteConfig {
 	}
 }
 
-// IsCommandSafe checks if a command contains dangerous patterns
-func IsCommandSafe(command string) bool {
-	cmdLower := strings.ToLower(command)
-
-	for _, pattern := range dangerousPatterns {
-		if strings.Contains(cmdLower, pattern) {
-			return false
-		}
-	}
-
-	// Additional safety checks
-	if strings.Contains(cmdLower, "rm -rf") && strings.Contains(cmdLower, "home") {
-		// Allow rm -rf in home directory but warn
-		return true
-	}
-
-	return true
-}
-
 // ValidateAndCleanCommand ensures the command is safe and properly formatted
 func ValidateAndCleanCommand(command string) (string, error) {
 	command = strings.TrimSpace(command)
@@ -75,23 +81,23 @@ func ValidateAndCleanCommand(command string) (string, error) {
 	command = strings.ReplaceAll(command, "**", "")
 	command = strings.ReplaceAll(command, "*", "")
 
-	// Remove leading/trailing quotes
-	command = strings.Trim(command, `"'`)
+	// Remove quotes wrapped around the whole command
+	command = shell.TrimWrappingQuotes(command)
 
 	// FIXED: Use utils package
 	command = utils.FixUnmatchedQuotes(command)
 
-	// FIXED: Use utils package
-	color.Yellow("🔍 DEBUG: Before HasBalancedQuotes check: '%s'", command)
-	if !utils.HasBalancedQuotes(command) {
-		return "", fmt.Errorf("command has unmatched quotes: %s", command)
-	}
-
 	// Check if command is empty after cleaning
 	if command == "" {
 		return "", fmt.Errorf("empty command after cleaning")
 	}
 
+	// A masked secret came back from the history or the index; running the
+	// marker would send it as the password
+	if strings.Contains(command, utils.RedactedMarker) {
+		return "", fmt.Errorf("command contains %s, a masked secret: type the real value yourself", utils.RedactedMarker)
+	}
+
 	// Basic command structure validation
 	if strings.Contains(command, "\n") {
 		// Take only the first line for multi-line commands
@@ -99,6 +105,11 @@ func ValidateAndCleanCommand(command string) (string, error) {
 		command = strings.TrimSpace(lines[0])
 	}
 
+	// Quotes, parentheses, pipes and redirections are checked by parsing
+	if err := CheckSyntax(command, currentEnv()); err != nil {
+		return "", err
+	}
+
 	// Safety validation
 	if err := utils.ValidateCommand(command); err != nil {
 		return "", err
@@ -107,8 +118,33 @@ func ValidateAndCleanCommand(command string) (string, error) {
 	return command, nil
 }
 
+// CheckSyntax parses a command as the shell will and returns its first
+// syntax error. Commands for PowerShell, cmd and fish are not checked.
+func CheckSyntax(command string, env shell.Env) error {
+	if !env.UsesPOSIXSyntax() {
+		return nil
+	}
+	if _, err := shell.Parse(command); err != nil {
+		return fmt.Errorf("shell syntax error: %w", err)
+	}
+	return nil
+}
+
+// currentEnv is the environment commands validated without one will run in
+var currentEnv = sync.OnceValue(shell.DetectEnvironment)
+
 // ExecuteCommand runs a shell command with safety checks
 func ExecuteCommand(command string, config ExecuteConfig, env shell.Env) error {
+	// Hooks run first so the checks below also cover a command they change
+	command, err := runPreExecuteHooks(strings.TrimSpace(command), config, true)
+	if err != nil {
+		return err
+	}
+
+	// A trailing & runs the command as a background job
+	command, background := SplitBackground(command)
+	background = background || config.Background
+
 	// Light validation only - command should already be cleaned
 	command = strings.TrimSpace(command)
 	if command == "" {
@@ -120,26 +156,20 @@ func ExecuteCommand(command string, config ExecuteConfig, env shell.Env) error {
 		return fmt.Errorf("command blocked for safety: %s", command)
 	}
 
-	// Quick quote balance check without aggressive fixing
-	singleQuotes := strings.Count(command, "'")
-	doubleQuotes := strings.Count(command, `"`)
-	if (singleQuotes%2 != 0 && singleQuotes > 1) || (doubleQuotes%2 != 0 && doubleQuotes > 1) {
-		return fmt.Errorf("command has unbalanced quotes: %s", command)
+	// Structural syntax check, e.g. for a command changed by a hook
+	if err := CheckSyntax(command, env); err != nil {
+		return err
 	}
 
-	command = strings.TrimSpace(command)
-	if command == "" {
-		return fmt.Errorf("empty command")
-	}
-
-	// Safety check
-	if config.SafeMode && !IsCommandSafe(command) {
-		return fmt.Errorf("command blocked for safety: %s", command)
+	if err := checkSudoAllowed(command, config); err != nil {
+		return err
 	}
 
 	// NEW: Display the command with syntax highlighting
 	if config.DryRun {
 		fmt.Printf("%s ", color.YellowString("🚀 Dry Run:"))
+	} else if background {
+		fmt.Printf("%s ", color.YellowString("🧵 Starting in background:"))
 	} else {
 		fmt.Printf("%s ", color.YellowString("🚀 Executing:"))
 	}
@@ -152,58 +182,240 @@ func ExecuteCommand(command string, config ExecuteConfig, env shell.Env) error {
 		fmt.Println(command)
 	}
 
-	// Ask for confirmation for potentially dangerous commands
-	if !config.AutoConfirm && isPotentiallyDangerous(command) {
-		if !AskForConfirmation("This command might be dangerous. Continue?") {
-			return fmt.Errorf("command cancelled by user")
+	// Team policy packs apply even when auto-confirm is on
+	if err := enforcePolicy(command, true); err != nil {
+		return err
+	}
+
+	// A dry run simulates the command instead of running it
+	if config.DryRun {
+		sim, err := SimulateCommand(command)
+		if err != nil {
+			return fmt.Errorf("dry run: cannot parse command: %w", err)
 		}
+		sim.Background = sim.Background || background
+		PrintSimulation(sim)
+		return nil
 	}
 
-	// Execute based on shell type
-	var cmd *exec.Cmd
-	switch env.Shell {
-	case "powershell":
-		cmd = exec.Command("powershell", "-Command", command)
-	case "cmd":
-		cmd = exec.Command("cmd", "/C", command)
-	case "bash", "zsh", "fish":
-		cmd = exec.Command(env.Shell, "-c", command)
-	default:
-		// Fallback to system default
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/C", command)
-		} else {
-			cmd = exec.Command("sh", "-c", command)
+	// Globs of rm, mv and cp are expanded to show exactly which files change;
+	// on a remote host they would be expanded against the wrong files
+	if env.Remote == nil {
+		if err := confirmFileOperations(command, config, true); err != nil {
+			return err
 		}
 	}
 
+	// The risk score decides how strictly the command is confirmed
+	if !config.AutoConfirm {
+		if err := ConfirmRisk(AssessRisk(command)); err != nil {
+			return err
+		}
+	}
+
+	// In two-person mode destructive commands also need a second operator
+	if err := requireApproval(command); err != nil {
+		return err
+	}
+
+	// Files the command changes are snapshotted first, so /undo can restore them
+	takeSnapshot(command, env)
+
+	// sudo asks for its password now rather than in the middle of the output
+	handedOff, err := prepareElevation(command, env, true)
+	if err != nil || handedOff {
+		return err
+	}
+
+	if background {
+		job, err := startJob(command, config, env)
+		if err != nil {
+			return fmt.Errorf("command execution failed: %w", err)
+		}
+		announceJob(job)
+		return nil
+	}
+
+	// && and || chains run one step at a time, so each step's status is reported
+	if steps := SplitChain(command, env); steps != nil {
+		return runChain(command, steps, config, env)
+	}
+
+	// A copy of the output is kept for /save and, in audited actions, /replay;
+	// error output is kept so /fix can diagnose a failure
+	var output, stderr *tailBuffer
+	if capturesOutput(command) {
+		output, stderr = &tailBuffer{}, &tailBuffer{}
+	}
+
+	start := time.Now()
+	exitCode, err := runForeground(command, config, env, output, stderr)
+	recordExecution(command, exitCode, err, output, time.Since(start))
+	recordFailure(command, exitCode, err, stderr)
+	recordOutput(command, output)
+	runPostExecuteHooks(command, exitCode, err, true)
+	return executionError(err)
+}
+
+// runForeground runs a command attached to the terminal within the
+// configured limits, copying its output into output and stderr when given,
+// and returns its exit code
+func runForeground(command string, config ExecuteConfig, env shell.Env, output, stderr *tailBuffer) (int, error) {
+	// Execute based on shell type, within the configured limits
+	ctx, cancel := config.commandContext()
+	defer cancel()
+	cmd := buildShellCommand(ctx, command, env, config)
+
+	stdout := os.Stdout
+	if config.Stdout != nil {
+		stdout = config.Stdout
+	}
+
 	// Capture output
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	// Execute
-	if err := cmd.Run(); err != nil {
+	// Programs that need the terminal, like editors, are not copied
+	if capturesOutput(command) {
+		if output != nil {
+			cmd.Stdout = io.MultiWriter(stdout, output)
+			cmd.Stderr = io.MultiWriter(os.Stderr, output)
+		}
+		if stderr != nil {
+			cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+		}
+	}
+	explain := config.applyLimits(ctx, cancel, cmd)
+
+	// Execute in its own process group so Ctrl+C stops the command, not Helix
+	err := explain(runAttached(cmd, !capturesOutput(command)))
+	return cmd.ProcessState.ExitCode(), err
+}
+
+// executionError describes why a command did not succeed; interrupts and
+// limits are returned as is so callers can tell them apart
+func executionError(err error) error {
+	if err != nil {
+		if errors.Is(err, ErrCommandInterrupted) || errors.Is(err, ErrCommandTimeout) || errors.Is(err, ErrOutputLimit) {
+			return err
+		}
 		return fmt.Errorf("command execution failed: %w", err)
 	}
 
 	return nil
 }
 
-// isPotentiallyDangerous checks for commands that need extra confirmation
-func isPotentiallyDangerous(command string) bool {
-	cmdLower := strings.ToLower(command)
-	dangerousKeywords := []string{
-		"rm -rf", "chmod", "chown", "mv ", "dd ", "format",
-		"fdisk", "mkfs", "> ", ">> ", "curl | sh", "wget | sh",
+// CommandResult holds the captured outcome of a non-interactive command
+type CommandResult struct {
+	Command  string        `json:"command"` // as run, after pre-execute hooks
+	ExitCode int           `json:"exit_code"`
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunCommandCapture runs a command without a terminal, capturing its output.
+// It never prompts, so callers must decide beforehand whether the command may run.
+func RunCommandCapture(command string, config ExecuteConfig, env shell.Env) (CommandResult, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return CommandResult{}, fmt.Errorf("empty command")
+	}
+
+	command, err := runPreExecuteHooks(command, config, false)
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	if config.SafeMode && !IsCommandSafe(command) {
+		return CommandResult{Command: command}, fmt.Errorf("command blocked for safety: %s", command)
+	}
+
+	if err := checkSudoAllowed(command, config); err != nil {
+		return CommandResult{Command: command}, err
+	}
+
+	if err := enforcePolicy(command, false); err != nil {
+		return CommandResult{Command: command}, err
+	}
+
+	if err := requireApproval(command); err != nil {
+		return CommandResult{Command: command}, err
+	}
+	takeSnapshot(command, env)
+
+	// Without a terminal, sudo can only run if it needs no password
+	if _, err := prepareElevation(command, env, false); err != nil {
+		return CommandResult{Command: command}, err
+	}
+
+	if env.Remote == nil {
+		if err := confirmFileOperations(command, config, false); err != nil {
+			return CommandResult{Command: command}, err
+		}
+	}
+
+	ctx, cancel := config.commandContext()
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := buildShellCommand(ctx, command, env, config)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	explain := config.applyLimits(ctx, cancel, cmd)
+
+	// Tracked like a foreground command, so Ctrl+C and /abort reach it
+	start := time.Now()
+	err = explain(runAttached(cmd, false))
+	result := CommandResult{
+		Command:  command,
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+	output := &tailBuffer{}
+	output.Write([]byte(result.Stdout + result.Stderr))
+	recordOutput(command, output)
+	runPostExecuteHooks(command, result.ExitCode, err, false)
+
+	// A non-zero exit is reported in the result, not as an error
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return result, fmt.Errorf("command execution failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildShellCommand wraps a command line in the detected shell, at the
+// configured priority, ending when ctx does
+func buildShellCommand(ctx context.Context, command string, env shell.Env, config ExecuteConfig) *exec.Cmd {
+	// In /ssh mode the remote login shell runs the command
+	if env.Remote != nil {
+		return env.Remote.Command(ctx, command)
 	}
 
-	for _, keyword := range dangerousKeywords {
-		if strings.Contains(cmdLower, keyword) {
-			return true
+	var args []string
+	switch env.Shell {
+	case "powershell":
+		args = []string{"powershell", "-Command", command}
+	case "cmd":
+		args = []string{"cmd", "/C", command}
+	case "bash", "zsh", "fish":
+		args = []string{env.Shell, "-c", command}
+	default:
+		// Fallback to system default
+		if runtime.GOOS == "windows" {
+			args = []string{"cmd", "/C", command}
+		} else {
+			args = []string{"sh", "-c", command}
 		}
 	}
-	return false
+
+	args = config.withNetworkIsolation(config.withPriority(args))
+	return exec.CommandContext(ctx, args[0], args[1:]...)
 }
 
 // AskForConfirmation asks for user confirmation
@@ -213,7 +425,93 @@ func AskForConfirmation(prompt string) bool {
 	fmt.Scanln(&response)
 
 	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
+	approved := response == "y" || response == "yes"
+	recordDecision(prompt, approved)
+	return approved
+}
+
+// CommandAction is the user's answer before a generated command runs
+type CommandAction int
+
+const (
+	ActionCancel CommandAction = iota
+	ActionExecute
+	ActionRefine
+	ActionAlways // execute, and trust the command from now on
+)
+
+// riskQuestions are the questions asked before a command of each
+// confirmation strictness runs
+var riskQuestions = map[Confirmation]string{
+	ConfirmPrompt: "This command might be dangerous. Continue?",
+	ConfirmWarn:   "This command is high risk. Continue?",
+	ConfirmTyped:  `This command is critical risk. Type "yes" to run it`,
+}
+
+// Question returns what the user is asked before the command runs
+func (a RiskAssessment) Question() string {
+	if len(a.ConfirmTargets) > 1 {
+		return fmt.Sprintf(`This command destroys %d targets (%s). Type "%s" to run it`,
+			len(a.ConfirmTargets), strings.Join(firstStrings(a.ConfirmTargets, 5), ", "), a.ConfirmToken)
+	}
+	if a.ConfirmToken != "" {
+		return fmt.Sprintf(`This command is destructive. Type "%s" to run it`, a.ConfirmToken)
+	}
+	return riskQuestions[a.Confirmation()]
+}
+
+// ConfirmRisk asks before a risky command runs, more strictly the higher
+// its score: a y/N question, the reasons first, or typing the name of what
+// it destroys (or "yes")
+func ConfirmRisk(risk RiskAssessment) error {
+	confirmation := risk.Confirmation()
+	if confirmation == ConfirmNone {
+		return nil
+	}
+	if confirmation >= ConfirmWarn {
+		fmt.Printf("%s %s (%s)\n", ux.RiskColor(string(risk.Level)).Sprint("⚠️  "+risk.Level.Badge()),
+			strings.Join(risk.Reasons, ", "), risk.CategoryNames())
+	}
+
+	question := risk.Question()
+	approved := false
+	if confirmation == ConfirmTyped {
+		word := risk.ConfirmToken
+		if word == "" {
+			word = "yes"
+		}
+		ux.RiskColor(string(risk.Level)).Printf("🚨 %s: ", question)
+		// A whole line, since a path may contain spaces
+		response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		approved = strings.TrimSpace(response) == word
+		recordDecision(question, approved)
+	} else {
+		approved = AskForConfirmation(question)
+	}
+	if !approved {
+		return fmt.Errorf("command cancelled by user")
+	}
+	return nil
+}
+
+// AskExecuteOrRefine asks whether to execute a generated command, execute
+// and always trust it, or refine it with a follow-up; anything else cancels
+func AskExecuteOrRefine(prompt string) CommandAction {
+	var response string
+	fmt.Printf("%s [y]es/[a]lways/[r]efine/[N]o: ", prompt)
+	fmt.Scanln(&response)
+
+	action := ActionCancel
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		action = ActionExecute
+	case "a", "always":
+		action = ActionAlways
+	case "r", "refine":
+		action = ActionRefine
+	}
+	recordDecision(prompt, action == ActionExecute || action == ActionAlways)
+	return action
 }
 
 // ExplainCommand uses AI to explain what a command does
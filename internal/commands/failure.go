@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxFailureStderr caps the stderr kept for diagnosing a failed command; the
+// end is kept since that is where the error usually is
+const maxFailureStderr = 4 << 10
+
+// Failure describes the last command that exited unsuccessfully
+type Failure struct {
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exit_code"`        // -1 when the command did not run to completion
+	Stderr   string    `json:"stderr,omitempty"` // end of the error output, when it could be captured
+	Error    string    `json:"error"`
+	Dir      string    `json:"dir"`
+	Time     time.Time `json:"time"`
+}
+
+var lastFailure struct {
+	mu      sync.Mutex
+	failure *Failure
+}
+
+// LastFailure returns the most recent command if it failed
+func LastFailure() (Failure, bool) {
+	lastFailure.mu.Lock()
+	defer lastFailure.mu.Unlock()
+	if lastFailure.failure == nil {
+		return Failure{}, false
+	}
+	return *lastFailure.failure, true
+}
+
+// ClearLastFailure forgets the last failure, e.g. once it has been fixed
+func ClearLastFailure() {
+	lastFailure.mu.Lock()
+	defer lastFailure.mu.Unlock()
+	lastFailure.failure = nil
+}
+
+// recordFailure remembers a failed command for diagnosis; a command that
+// succeeds or that the user interrupted replaces it with nothing
+func recordFailure(command string, exitCode int, err error, stderr *tailBuffer) {
+	if err == nil || errors.Is(err, ErrCommandInterrupted) {
+		ClearLastFailure()
+		return
+	}
+
+	failure := &Failure{Command: command, ExitCode: exitCode, Error: err.Error(), Time: time.Now()}
+	if dir, dirErr := os.Getwd(); dirErr == nil {
+		failure.Dir = dir
+	}
+	if stderr != nil {
+		output := stderr.String()
+		if len(output) > maxFailureStderr {
+			output = output[len(output)-maxFailureStderr:]
+		}
+		failure.Stderr = output
+	}
+
+	lastFailure.mu.Lock()
+	defer lastFailure.mu.Unlock()
+	lastFailure.failure = failure
+}
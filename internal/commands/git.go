@@ -19,6 +19,7 @@ type GitManager struct {
 	execConfig ExecuteConfig
 	workingDir string
 	sandbox    *DirectorySandbox
+	templates  []GitOperation
 }
 
 // NewGitManager creates a new Git manager
@@ -36,12 +37,49 @@ func NewGitManager(env shell.Env, execConfig ExecuteConfig, sandbox *DirectorySa
 	}
 }
 
-// GitOperation represents a git operation with safety checks
+// GitOperation represents a git operation with safety checks.
+// Shared templates also set Name and the Keywords that trigger them.
 type GitOperation struct {
-	Description  string
-	Command      string
-	Confirmation string
-	Risks        []string
+	Name         string   `json:"name,omitempty"`
+	Keywords     []string `json:"keywords,omitempty"`
+	Description  string   `json:"description"`
+	Command      string   `json:"command"`
+	Confirmation string   `json:"confirmation"`
+	Risks        []string `json:"risks,omitempty"`
+	Source       string   `json:"-"`
+}
+
+// SetTemplates installs shared git-operation templates, checked before the built-in operations
+func (gm *GitManager) SetTemplates(templates []GitOperation) {
+	gm.templates = templates
+}
+
+// GetTemplates returns the installed git-operation templates
+func (gm *GitManager) GetTemplates() []GitOperation {
+	return gm.templates
+}
+
+// matchTemplate returns the first template whose keywords all appear in the request
+func (gm *GitManager) matchTemplate(request string) *GitOperation {
+	for _, template := range gm.templates {
+		if len(template.Keywords) == 0 {
+			continue
+		}
+
+		matched := true
+		for _, keyword := range template.Keywords {
+			if !strings.Contains(request, strings.ToLower(keyword)) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			operation := template
+			return &operation
+		}
+	}
+	return nil
 }
 
 // HandleGitRequest processes natural language git requests
@@ -117,6 +155,11 @@ func (gm *GitManager) getAvailableBranches() ([]string, error) {
 
 // detectComplexGitOperation identifies common complex git workflows
 func (gm *GitManager) detectComplexGitOperation(request string) *GitOperation {
+	// Team-shared templates take precedence over the built-in workflows
+	if operation := gm.matchTemplate(request); operation != nil {
+		return operation
+	}
+
 	// Your specific use case: merge with squash and accept all incoming
 	if strings.Contains(request, "merge") && strings.Contains(request, "squash") &&
 		(strings.Contains(request, "accept all") || strings.Contains(request, "incoming")) {
@@ -267,12 +310,6 @@ func (gm *GitManager) executeMultiCommandOperation(operation *GitOperation) erro
 		return fmt.Errorf("no commands to execute")
 	}
 
-	color.Cyan("🔧 This operation will execute %d commands:", len(commands))
-	for i, cmd := range commands {
-		color.Cyan("  %d. %s", i+1, cmd)
-	}
-	fmt.Println()
-
 	// Get target branch if needed
 	targetBranch := ""
 	if strings.Contains(operation.Command, "${BRANCH}") {
@@ -284,6 +321,20 @@ func (gm *GitManager) executeMultiCommandOperation(operation *GitOperation) erro
 		color.Green("🎯 Target branch: %s", targetBranch)
 	}
 
+	plan := NewPlan(commands, func(step int, rawCommand string) error {
+		// SPECIAL HANDLING: For commit step, use a completely different approach
+		if step == len(commands)-1 && strings.Contains(rawCommand, "${COMMIT_CMD}") {
+			return gm.executeCommitStep(targetBranch)
+		}
+		// Replace branch placeholder
+		command := strings.ReplaceAll(rawCommand, "${BRANCH}", targetBranch)
+		return gm.sandbox.WrapCommand(command, gm.execConfig, gm.env)
+	})
+
+	color.Cyan("🔧 This operation will execute %d commands:", len(commands))
+	plan.PrintChecklist()
+	fmt.Println()
+
 	// Final confirmation
 	if !AskForConfirmation("Execute these commands sequentially?") {
 		color.Yellow("❌ Operation cancelled")
@@ -291,31 +342,9 @@ func (gm *GitManager) executeMultiCommandOperation(operation *GitOperation) erro
 	}
 
 	// Execute commands one by one
-	for i, rawCommand := range commands {
-		// Replace branch placeholder
-		command := strings.ReplaceAll(rawCommand, "${BRANCH}", targetBranch)
-
-		color.Blue("\n📝 Step %d/%d: %s", i+1, len(commands), command)
-
-		// SPECIAL HANDLING: For commit step, use a completely different approach
-		if i == len(commands)-1 && strings.Contains(rawCommand, "${COMMIT_CMD}") {
-			// This is the commit step - handle it specially
-			if err := gm.executeCommitStep(targetBranch); err != nil {
-				color.Red("❌ Commit failed: %v", err)
-				color.Yellow("💡 Operation incomplete. Check git status.")
-				return err
-			}
-			color.Green("✅ Step %d completed", i+1)
-			continue
-		}
-
-		if err := gm.sandbox.WrapCommand(command, gm.execConfig, gm.env); err != nil {
-			color.Red("❌ Command failed at step %d: %v", i+1, err)
-			color.Yellow("💡 Operation incomplete. Check git status.")
-			return err
-		}
-
-		color.Green("✅ Step %d completed", i+1)
+	if err := plan.Execute(); err != nil {
+		color.Yellow("💡 Operation incomplete. Check git status.")
+		return err
 	}
 
 	color.Green("🎉 All commands completed successfully!")
@@ -368,7 +397,7 @@ func (gm *GitManager) executeCommitWithMessage(targetBranch string, message stri
 		color.Yellow("⚠️  Could not create temp file, using git editor instead")
 		return ExecuteCommand("git commit", gm.execConfig, gm.env)
 	}
-	defer os.Remove(tempFile.Name())
+	defer trackTempFile(tempFile.Name())()
 
 	// Write message to temp file
 	if _, err := tempFile.WriteString(message); err != nil {
@@ -432,21 +461,7 @@ func (gm *GitManager) getTargetBranch() (string, error) {
 
 // isDestructiveOperation checks if an operation is potentially destructive
 func (gm *GitManager) isDestructiveOperation(operation *GitOperation) bool {
-	dangerousPatterns := []string{
-		"checkout --theirs",
-		"reset --hard",
-		"clean -fd",
-		"push --force",
-		"branch -D",
-		"reset --soft",
-	}
-
-	for _, pattern := range dangerousPatterns {
-		if strings.Contains(operation.Command, pattern) {
-			return true
-		}
-	}
-	return false
+	return AssessRisk(operation.Command).Has(CategoryDestructive)
 }
 
 // handleAIGitRequest uses AI for other git operations
@@ -486,6 +501,14 @@ Command:`, request, gm.workingDir, currentBranch)
 		command = "git " + command
 	}
 
+	// Let the user fill placeholders like <branch> instead of running them literally
+	if placeholders := FindPlaceholders(command); len(placeholders) > 0 {
+		command, err = FillPlaceholders(command, placeholders, gm.env)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Show current directory context
 	color.Blue("📍 Executing in: %s", gm.workingDir)
 
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+
+	"helix/internal/utils"
+)
+
+// GuardrailVerdict is how the execution guardrails would treat a command,
+// worked out without running it or its hooks
+type GuardrailVerdict struct {
+	Command         string         `json:"command"`
+	Invalid         string         `json:"invalid,omitempty"` // why command validation rejects it
+	Sandbox         string         `json:"sandbox,omitempty"` // why the sandbox blocks it
+	SafeModeBlocked bool           `json:"safe_mode_blocked"`
+	Policy          PolicyDecision `json:"policy"`
+	Risk            RiskAssessment `json:"risk"`
+	DangerPrompt    bool           `json:"danger_prompt"` // the risk score asks for confirmation
+}
+
+// Blocked reports whether the command would never run
+func (v GuardrailVerdict) Blocked() bool {
+	return v.Invalid != "" || v.Sandbox != "" || v.SafeModeBlocked || v.Policy.Blocked
+}
+
+// NeedsConfirmation reports whether running the command would ask the user first
+func (v GuardrailVerdict) NeedsConfirmation() bool {
+	return !v.Blocked() && (v.Policy.RequiresConfirm || v.DangerPrompt)
+}
+
+// Outcome names what would happen: "blocked", "confirm" or "allowed"
+func (v GuardrailVerdict) Outcome() string {
+	switch {
+	case v.Blocked():
+		return "blocked"
+	case v.NeedsConfirmation():
+		return "confirm"
+	default:
+		return "allowed"
+	}
+}
+
+// Reason explains the outcome by the first guardrail that decided it
+func (v GuardrailVerdict) Reason() string {
+	switch {
+	case v.Invalid != "":
+		return "validation: " + v.Invalid
+	case v.Sandbox != "":
+		return "sandbox: " + v.Sandbox
+	case v.SafeModeBlocked:
+		return "safe mode: matches a dangerous pattern"
+	case v.Policy.Blocked:
+		return fmt.Sprintf("policy %s blocks %s", v.Policy.Pack, v.Policy.Pattern)
+	case v.Policy.RequiresConfirm:
+		return fmt.Sprintf("policy %s confirms %s", v.Policy.Pack, v.Policy.Pattern)
+	case v.DangerPrompt:
+		return fmt.Sprintf("%s risk command", v.Risk.Level)
+	default:
+		return ""
+	}
+}
+
+// EvaluateGuardrails runs a command through the checks ExecuteCommand and
+// the sandbox apply, in the same order, without executing anything
+func EvaluateGuardrails(command string, config ExecuteConfig, ds *DirectorySandbox) GuardrailVerdict {
+	verdict := GuardrailVerdict{
+		Command: command,
+		Risk:    AssessRisk(command),
+		Policy:  CheckPolicy(command),
+	}
+
+	if err := utils.ValidateCommand(command); err != nil {
+		verdict.Invalid = err.Error()
+	}
+	if ds != nil {
+		if valid, reason := ds.ValidateCommand(command); !valid {
+			verdict.Sandbox = reason
+		}
+	}
+	verdict.SafeModeBlocked = config.SafeMode && !IsCommandSafe(command)
+	verdict.DangerPrompt = !config.AutoConfirm && verdict.Risk.Confirmation() != ConfirmNone
+
+	return verdict
+}
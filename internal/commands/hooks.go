@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// HookStage is a point in the command lifecycle that hooks subscribe to
+type HookStage string
+
+const (
+	HookPreGenerate  HookStage = "pre-generate"  // before the model is asked; may rewrite the request, add context or veto
+	HookPostGenerate HookStage = "post-generate" // after a command is generated; may rewrite or veto it
+	HookPreExecute   HookStage = "pre-execute"   // before a command runs; may rewrite or veto it
+	HookPostExecute  HookStage = "post-execute"  // after a command ran; observes the outcome only
+)
+
+// HookStages lists the stages in lifecycle order
+var HookStages = []HookStage{HookPreGenerate, HookPostGenerate, HookPreExecute, HookPostExecute}
+
+// hookScriptTimeout bounds a hook script, so a hung approval system cannot
+// hang Helix. A pre-stage script that times out vetoes the command.
+const hookScriptTimeout = 30 * time.Second
+
+// HookEvent is what a hook receives. Script hooks get it as JSON on stdin.
+type HookEvent struct {
+	Stage       HookStage `json:"stage"`
+	Request     string    `json:"request,omitempty"` // the natural language request, when there is one
+	Command     string    `json:"command,omitempty"`
+	Context     []string  `json:"context,omitempty"` // prompt context added by earlier hooks
+	ExitCode    int       `json:"exit_code"`         // post-execute only
+	Error       string    `json:"error,omitempty"`   // post-execute only
+	Interactive bool      `json:"interactive"`
+	WorkDir     string    `json:"work_dir"`
+}
+
+// HookResult is a hook's answer. Script hooks print it as JSON on stdout;
+// printing nothing leaves the event unchanged.
+type HookResult struct {
+	Request string   `json:"request,omitempty"` // replaces the request (pre-generate)
+	Command string   `json:"command,omitempty"` // replaces the command (post-generate, pre-execute)
+	Context []string `json:"context,omitempty"` // appended to the prompt (pre-generate)
+	Veto    bool     `json:"veto,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+// HookFunc is a hook compiled into Helix by a plugin
+type HookFunc func(event HookEvent) (HookResult, error)
+
+// Hook is one subscription to a stage
+type Hook struct {
+	Name   string    `json:"name"`
+	Stage  HookStage `json:"stage"`
+	Script string    `json:"script,omitempty"` // path of a script hook
+	run    HookFunc
+}
+
+// HookVetoError reports a command stopped by a hook
+type HookVetoError struct {
+	Hook   string
+	Stage  HookStage
+	Reason string
+}
+
+func (e *HookVetoError) Error() string {
+	return fmt.Sprintf("vetoed by %s hook %s: %s", e.Stage, e.Hook, e.Reason)
+}
+
+var activeHooks struct {
+	mu      sync.RWMutex
+	plugins []Hook
+	scripts []Hook
+}
+
+// RegisterHook subscribes a plugin function to a stage. Hooks run in the
+// order they were registered, before script hooks.
+func RegisterHook(name string, stage HookStage, fn HookFunc) {
+	activeHooks.mu.Lock()
+	defer activeHooks.mu.Unlock()
+	activeHooks.plugins = append(activeHooks.plugins, Hook{Name: name, Stage: stage, run: fn})
+}
+
+// LoadHookScripts replaces the script hooks with the executables in
+// <dir>/<stage>/, which run in file name order like git hooks
+func LoadHookScripts(dir string) error {
+	var scripts []Hook
+	for _, stage := range HookStages {
+		entries, err := os.ReadDir(filepath.Join(dir, string(stage)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		for _, entry := range entries {
+			path := filepath.Join(dir, string(stage), entry.Name())
+			if !isHookScript(entry, path) {
+				continue
+			}
+			hook := Hook{Name: entry.Name(), Stage: stage, Script: path}
+			hook.run = func(event HookEvent) (HookResult, error) { return runHookScript(path, event) }
+			scripts = append(scripts, hook)
+		}
+	}
+
+	activeHooks.mu.Lock()
+	defer activeHooks.mu.Unlock()
+	activeHooks.scripts = scripts
+	return nil
+}
+
+// isHookScript reports whether a directory entry can run as a hook. Hidden
+// files and editor backups are skipped.
+func isHookScript(entry os.DirEntry, path string) bool {
+	name := entry.Name()
+	if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd", ".ps1":
+			return true
+		}
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
+
+// GetHooks returns the active hooks in the order they run
+func GetHooks() []Hook {
+	activeHooks.mu.RLock()
+	defer activeHooks.mu.RUnlock()
+
+	var hooks []Hook
+	for _, stage := range HookStages {
+		hooks = append(hooks, stageHooks(stage)...)
+	}
+	return hooks
+}
+
+// stageHooks returns the hooks of a stage (caller holds the lock)
+func stageHooks(stage HookStage) []Hook {
+	var hooks []Hook
+	for _, hook := range append(append([]Hook(nil), activeHooks.plugins...), activeHooks.scripts...) {
+		if hook.Stage == stage {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// RunHooks runs the hooks of the event's stage, each seeing the changes of
+// the ones before it, and returns the changed event. A veto stops the chain
+// with a *HookVetoError. A hook that fails vetoes in the pre stages, so an
+// unreachable approval system fails closed; after generation and execution
+// failures are only reported.
+func RunHooks(event HookEvent) (HookEvent, error) {
+	activeHooks.mu.RLock()
+	hooks := stageHooks(event.Stage)
+	activeHooks.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return event, nil
+	}
+	if event.WorkDir == "" {
+		event.WorkDir, _ = os.Getwd()
+	}
+
+	for _, hook := range hooks {
+		result, err := hook.run(event)
+		if err != nil {
+			if event.Stage == HookPreGenerate || event.Stage == HookPreExecute {
+				return event, &HookVetoError{Hook: hook.Name, Stage: event.Stage, Reason: err.Error()}
+			}
+			color.Yellow("⚠️  %s hook %s failed: %v", event.Stage, hook.Name, err)
+			continue
+		}
+
+		if event.Stage == HookPostExecute {
+			continue // the command already ran
+		}
+		if result.Veto {
+			reason := result.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			return event, &HookVetoError{Hook: hook.Name, Stage: event.Stage, Reason: reason}
+		}
+
+		if result.Request != "" && event.Stage == HookPreGenerate && result.Request != event.Request {
+			color.Cyan("🪝 Hook %s rewrote the request: %s", hook.Name, result.Request)
+			event.Request = result.Request
+		}
+		if result.Command != "" && event.Stage != HookPreGenerate && result.Command != event.Command {
+			color.Cyan("🪝 Hook %s changed the command: %s", hook.Name, result.Command)
+			event.Command = result.Command
+		}
+		if event.Stage == HookPreGenerate {
+			event.Context = append(event.Context, result.Context...)
+		}
+	}
+	return event, nil
+}
+
+// runHookScript sends the event to a script on stdin and reads its result
+// from stdout. A non-zero exit is a failure whose reason is the script's stderr.
+func runHookScript(path string, event HookEvent) (HookResult, error) {
+	var result HookResult
+	input, err := json.Marshal(event)
+	if err != nil {
+		return result, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookScriptTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	if strings.EqualFold(filepath.Ext(path), ".ps1") {
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-File", path)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("timed out after %s", hookScriptTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return result, errors.New(message)
+		}
+		return result, err
+	}
+
+	if output := bytes.TrimSpace(stdout.Bytes()); len(output) > 0 {
+		if err := json.Unmarshal(output, &result); err != nil {
+			return result, fmt.Errorf("invalid result (want JSON): %w", err)
+		}
+	}
+	return result, nil
+}
+
+// runPreExecuteHooks passes a command through the pre-execute hooks. A
+// command a hook changed is checked again by the validator the caller's
+// sandbox installed.
+func runPreExecuteHooks(command string, config ExecuteConfig, interactive bool) (string, error) {
+	event, err := RunHooks(HookEvent{Stage: HookPreExecute, Command: command, Interactive: interactive})
+	if err != nil {
+		return "", err
+	}
+	if event.Command != command && config.validate != nil {
+		if valid, reason := config.validate(event.Command); !valid {
+			return "", fmt.Errorf("sandbox violation in hook-changed command: %s", reason)
+		}
+	}
+	return strings.TrimSpace(event.Command), nil
+}
+
+// runPostExecuteHooks reports a command's outcome to the post-execute hooks
+func runPostExecuteHooks(command string, exitCode int, runErr error, interactive bool) {
+	event := HookEvent{Stage: HookPostExecute, Command: command, ExitCode: exitCode, Interactive: interactive}
+	if runErr != nil {
+		event.Error = runErr.Error()
+	}
+	RunHooks(event)
+}
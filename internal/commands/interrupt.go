@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// runningCommand tracks the child process currently attached to the terminal
+// and the process groups of finished commands that left processes behind
+var runningCommand struct {
+	mu       sync.Mutex
+	process  *os.Process
+	aborted  bool
+	leftover []int // process group IDs
+}
+
+// tempFiles are files Helix created for commands in flight, removed by /abort
+var tempFiles struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// ErrCommandInterrupted is returned when the user interrupts a running command
+var ErrCommandInterrupted = errors.New("command interrupted")
+
+// ErrCommandAborted is returned when the kill switch stopped a running
+// command; it is an interrupt too
+var ErrCommandAborted = fmt.Errorf("%w and killed", ErrCommandInterrupted)
+
+// setRunningCommand registers the process that receives forwarded interrupts
+func setRunningCommand(p *os.Process) {
+	runningCommand.mu.Lock()
+	defer runningCommand.mu.Unlock()
+	runningCommand.process = p
+	runningCommand.aborted = false
+}
+
+// clearRunningCommand forgets the tracked process once it has exited and
+// reports whether the kill switch stopped it
+func clearRunningCommand() bool {
+	runningCommand.mu.Lock()
+	defer runningCommand.mu.Unlock()
+	runningCommand.process = nil
+	return runningCommand.aborted
+}
+
+// HasRunningCommand reports whether a child command is currently executing
+func HasRunningCommand() bool {
+	runningCommand.mu.Lock()
+	defer runningCommand.mu.Unlock()
+	return runningCommand.process != nil
+}
+
+// InterruptRunningCommand sends an interrupt to the running child's process group.
+// It returns false when no command is running so the caller can handle Ctrl+C itself.
+func InterruptRunningCommand() bool {
+	runningCommand.mu.Lock()
+	defer runningCommand.mu.Unlock()
+
+	if runningCommand.process == nil {
+		return false
+	}
+
+	interruptProcessGroup(runningCommand.process)
+	return true
+}
+
+// KillRunningCommand is the kill switch: it kills the running child's whole
+// process group at once, for commands that ignore an interrupt. It returns
+// false when no command is running.
+func KillRunningCommand() bool {
+	runningCommand.mu.Lock()
+	defer runningCommand.mu.Unlock()
+
+	if runningCommand.process == nil {
+		return false
+	}
+
+	runningCommand.aborted = true
+	killProcessGroup(runningCommand.process)
+	return true
+}
+
+// AbortReport is what AbortAll stopped and removed
+type AbortReport struct {
+	Command   bool // the command attached to the terminal
+	Groups    int  // process groups finished commands left running
+	Jobs      int  // background jobs
+	TempFiles int
+}
+
+// AbortAll stops everything Helix started that is still running: the
+// running command's process group, processes finished commands left
+// behind, and background jobs. Temporary files made for them are removed.
+func AbortAll() AbortReport {
+	report := AbortReport{Command: KillRunningCommand()}
+
+	runningCommand.mu.Lock()
+	for _, pgid := range runningCommand.leftover {
+		if processGroupAlive(pgid) {
+			killProcessGroupID(pgid)
+			report.Groups++
+		}
+	}
+	runningCommand.leftover = nil
+	runningCommand.mu.Unlock()
+
+	for _, job := range Jobs() {
+		if job.Status == JobRunning && KillJob(job.ID) == nil {
+			report.Jobs++
+		}
+	}
+
+	report.TempFiles = removeTempFiles()
+	return report
+}
+
+// trackTempFile registers a temporary file made for a command; the returned
+// function removes it and forgets it
+func trackTempFile(path string) func() {
+	tempFiles.mu.Lock()
+	if tempFiles.paths == nil {
+		tempFiles.paths = make(map[string]bool)
+	}
+	tempFiles.paths[path] = true
+	tempFiles.mu.Unlock()
+
+	return func() {
+		tempFiles.mu.Lock()
+		delete(tempFiles.paths, path)
+		tempFiles.mu.Unlock()
+		os.Remove(path)
+	}
+}
+
+// removeTempFiles removes every tracked temporary file and returns how many
+// there were
+func removeTempFiles() int {
+	tempFiles.mu.Lock()
+	defer tempFiles.mu.Unlock()
+
+	removed := 0
+	for path := range tempFiles.paths {
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	tempFiles.paths = nil
+	return removed
+}
+
+// runAttached starts cmd in its own process group, tracks it for interrupt
+// forwarding, and waits for it to finish. takesTerminal is set for programs
+// like editors that need the terminal to themselves.
+func runAttached(cmd *exec.Cmd, takesTerminal bool) error {
+	release := configureProcessGroup(cmd, takesTerminal)
+
+	if err := cmd.Start(); err != nil {
+		release()
+		return err
+	}
+
+	setRunningCommand(cmd.Process)
+	err := cmd.Wait()
+	aborted := clearRunningCommand()
+	release()
+
+	// A negative exit code means the child was terminated by a signal
+	var exitErr *exec.ExitError
+	interrupted := errors.As(err, &exitErr) && exitErr.ExitCode() == -1
+
+	// The shell is the group leader, so its PID is the group ID. Processes
+	// it started may outlive it: after an interrupt they go too, otherwise
+	// /abort can stop them later.
+	if pgid := cmd.Process.Pid; processGroupAlive(pgid) {
+		if interrupted || aborted {
+			killProcessGroupID(pgid)
+		} else {
+			runningCommand.mu.Lock()
+			runningCommand.leftover = append(runningCommand.leftover, pgid)
+			runningCommand.mu.Unlock()
+			color.Yellow("⚠️  Processes the command started are still running (/abort stops them)")
+		}
+	}
+
+	switch {
+	case aborted:
+		return ErrCommandAborted
+	case interrupted:
+		return ErrCommandInterrupted
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+//go:build !windows
+
+package commands
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// waitForRunningCommand waits until runAttached has registered its child
+func waitForRunningCommand(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !HasRunningCommand() {
+		if time.Now().After(deadline) {
+			t.Fatal("command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSecondInterruptKillsCommandIgnoringSIGINT(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "trap '' INT; sleep 30")
+	done := make(chan error, 1)
+	go func() { done <- runAttached(cmd, false) }()
+	waitForRunningCommand(t)
+	// Give the shell time to install its trap
+	time.Sleep(200 * time.Millisecond)
+
+	if !InterruptRunningCommand() {
+		t.Fatal("InterruptRunningCommand found no running command")
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("command ignoring SIGINT exited after the first interrupt: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if !KillRunningCommand() {
+		t.Fatal("KillRunningCommand found no running command")
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCommandAborted) {
+			t.Fatalf("runAttached returned %v, want ErrCommandAborted", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command still running after the kill switch")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for processGroupAlive(cmd.Process.Pid) {
+		if time.Now().After(deadline) {
+			t.Fatal("processes of the killed command are still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+	JobKilled  JobStatus = "killed"
+)
+
+// Job is a command running detached from the REPL, with its output in a log file
+type Job struct {
+	ID       int
+	Command  string
+	PID      int
+	LogPath  string
+	Started  time.Time
+	Ended    time.Time // zero while running
+	Status   JobStatus
+	ExitCode int
+	Err      string
+
+	cancel context.CancelFunc
+}
+
+// Duration returns how long the job ran, or has been running
+func (j Job) Duration() time.Duration {
+	if j.Ended.IsZero() {
+		return time.Since(j.Started)
+	}
+	return j.Ended.Sub(j.Started)
+}
+
+// jobs is the table of background jobs started in this session
+var jobs struct {
+	mu     sync.Mutex
+	list   []*Job
+	nextID int
+	logDir string
+}
+
+// SplitBackground removes a trailing & that asks for a command to run in the
+// background, as in a shell; && is left alone
+func SplitBackground(command string) (string, bool) {
+	trimmed := strings.TrimSpace(command)
+	if !strings.HasSuffix(trimmed, "&") || strings.HasSuffix(trimmed, "&&") {
+		return command, false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(trimmed, "&")), true
+}
+
+// startJob starts a command detached from the terminal. Its output goes to a
+// log file, and the configured limits apply as they do in the foreground.
+func startJob(command string, config ExecuteConfig, env shell.Env) (*Job, error) {
+	jobs.mu.Lock()
+	jobs.nextID++
+	id := jobs.nextID
+	jobs.mu.Unlock()
+
+	logFile, err := newJobLog(id)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create job log: %w", err)
+	}
+
+	ctx, cancel := config.commandContext()
+	cmd := buildShellCommand(ctx, command, env, config)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	configureProcessGroup(cmd, false) // no stdin, so the job gets its own group in the background
+	explain := config.applyLimits(ctx, cancel, cmd)
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		logFile.Close()
+		return nil, err
+	}
+
+	job := &Job{
+		ID:      id,
+		Command: command,
+		PID:     cmd.Process.Pid,
+		LogPath: logFile.Name(),
+		Started: time.Now(),
+		Status:  JobRunning,
+		cancel:  cancel,
+	}
+	jobs.mu.Lock()
+	jobs.list = append(jobs.list, job)
+	jobs.mu.Unlock()
+
+	go func() {
+		err := explain(cmd.Wait())
+		cancel()
+		logFile.Close()
+
+		jobs.mu.Lock()
+		defer jobs.mu.Unlock()
+		job.Ended = time.Now()
+		job.ExitCode = cmd.ProcessState.ExitCode()
+		switch {
+		case job.Status == JobKilled:
+		case err != nil:
+			job.Status = JobFailed
+			job.Err = err.Error()
+		default:
+			job.Status = JobDone
+		}
+	}()
+
+	return job, nil
+}
+
+// newJobLog creates the log file of a job in this session's job directory
+func newJobLog(id int) (*os.File, error) {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	if jobs.logDir == "" {
+		dir, err := os.MkdirTemp("", "helix-jobs-")
+		if err != nil {
+			return nil, err
+		}
+		jobs.logDir = dir
+	}
+	return os.Create(filepath.Join(jobs.logDir, fmt.Sprintf("job-%d.log", id)))
+}
+
+// Jobs returns the background jobs of this session, oldest first
+func Jobs() []Job {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	list := make([]Job, 0, len(jobs.list))
+	for _, job := range jobs.list {
+		list = append(list, *job)
+	}
+	return list
+}
+
+// FindJob returns the background job with an ID
+func FindJob(id int) (Job, bool) {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	for _, job := range jobs.list {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return Job{}, false
+}
+
+// RunningJobs returns how many background jobs are still running
+func RunningJobs() int {
+	count := 0
+	for _, job := range Jobs() {
+		if job.Status == JobRunning {
+			count++
+		}
+	}
+	return count
+}
+
+// KillJob stops a running background job together with its child processes
+func KillJob(id int) error {
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+	for _, job := range jobs.list {
+		if job.ID != id {
+			continue
+		}
+		if job.Status != JobRunning {
+			return fmt.Errorf("job %d is not running (%s)", id, job.Status)
+		}
+		job.Status = JobKilled
+		job.cancel()
+		return nil
+	}
+	return fmt.Errorf("no job %d", id)
+}
+
+// JobLog returns the last lines of a job's output, or all of it when lines < 1
+func JobLog(id, lines int) ([]string, error) {
+	job, ok := FindJob(id)
+	if !ok {
+		return nil, fmt.Errorf("no job %d", id)
+	}
+	file, err := os.Open(job.LogPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var output []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		output = append(output, scanner.Text())
+		if lines > 0 && len(output) > lines {
+			output = output[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return output, err
+	}
+	return output, nil
+}
+
+// announceJob tells the user where a background job's output goes
+func announceJob(job *Job) {
+	color.Green("🧵 Started job [%d] (pid %d) in the background", job.ID, job.PID)
+	color.Cyan("💡 Follow it with /jobs logs %d; stop it with /jobs kill %d", job.ID, job.ID)
+}
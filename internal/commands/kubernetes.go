@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"helix/internal/ai"
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// KubernetesManager handles kubectl operations with AI assistance, aware of
+// the current context and namespace
+type KubernetesManager struct {
+	env        shell.Env
+	execConfig ExecuteConfig
+	sandbox    *DirectorySandbox
+}
+
+// riskyKubectlVerbs are the kubectl verbs that change or remove workloads,
+// with what can go wrong
+var riskyKubectlVerbs = map[string]string{
+	"delete":   "Deletes resources; pods, services and their data may not come back",
+	"drain":    "Evicts every pod from the node",
+	"cordon":   "Stops new pods from being scheduled on the node",
+	"uncordon": "Lets pods be scheduled on the node again",
+	"taint":    "Changes which pods may run on the node and can evict running ones",
+	"scale":    "Changes how many replicas run; scaling to 0 stops the workload",
+	"replace":  "Replaces resources; --force deletes and recreates them",
+	"patch":    "Changes live resources in place",
+	"rollout":  "Restarts or rolls back a workload",
+	"apply":    "Creates or changes resources in the cluster",
+}
+
+// nodeKubectlVerbs act on nodes, which belong to no namespace
+var nodeKubectlVerbs = map[string]bool{"drain": true, "cordon": true, "uncordon": true, "taint": true}
+
+// dryRunKubectlVerbs accept --dry-run=client
+var dryRunKubectlVerbs = map[string]bool{
+	"delete": true, "drain": true, "cordon": true, "uncordon": true, "taint": true, "scale": true,
+	"replace": true, "patch": true, "apply": true, "create": true, "label": true, "annotate": true,
+	"run": true, "expose": true, "set": true,
+}
+
+// kubectlFlagsWithValue are the global kubectl flags followed by a value
+var kubectlFlagsWithValue = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true, "--user": true,
+	"--kubeconfig": true, "-s": true, "--server": true,
+}
+
+// KubectlCommand is a kubectl command line with the parts its safety checks need
+type KubectlCommand struct {
+	Command       string
+	Verb          string // e.g. get, delete, drain
+	Namespace     string // from -n/--namespace, or the current namespace
+	AllNamespaces bool
+}
+
+// NewKubernetesManager creates a new Kubernetes manager
+func NewKubernetesManager(env shell.Env, execConfig ExecuteConfig, sandbox *DirectorySandbox) *KubernetesManager {
+	return &KubernetesManager{env: env, execConfig: execConfig, sandbox: sandbox}
+}
+
+// SetExecuteConfig updates the execution settings, e.g. after /dry-run
+func (km *KubernetesManager) SetExecuteConfig(execConfig ExecuteConfig) {
+	km.execConfig = execConfig
+}
+
+// CurrentContext returns the kubectl context in use
+func (km *KubernetesManager) CurrentContext() (string, error) {
+	output, err := exec.Command("kubectl", "config", "current-context").Output()
+	if err != nil {
+		return "", fmt.Errorf("no current kubectl context")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CurrentNamespace returns the namespace of the current context, or "default"
+func (km *KubernetesManager) CurrentNamespace() string {
+	output, err := exec.Command("kubectl", "config", "view", "--minify", "-o", "jsonpath={..namespace}").Output()
+	if namespace := strings.TrimSpace(string(output)); err == nil && namespace != "" {
+		return namespace
+	}
+	return "default"
+}
+
+// HandleKubernetesRequest turns a natural language request into a kubectl
+// command, or takes a kubectl command as is, and runs it with safety checks
+func (km *KubernetesManager) HandleKubernetesRequest(request string) error {
+	request = strings.TrimSpace(request)
+
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		color.Red("❌ kubectl is not installed")
+		color.Yellow("💡 Install it with /install kubectl")
+		return fmt.Errorf("kubectl not found")
+	}
+
+	context, err := km.CurrentContext()
+	if err != nil {
+		color.Red("❌ %v", err)
+		color.Yellow("💡 Select one with kubectl config use-context <name>")
+		return err
+	}
+	namespace := km.CurrentNamespace()
+	color.Blue("☸️  Context: %s, namespace: %s", context, namespace)
+
+	command := request
+	if !strings.HasPrefix(request, "kubectl ") {
+		command, err = km.generateKubectlCommand(request, context, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return km.executeKubectlCommand(ParseKubectlCommand(command, namespace), context)
+}
+
+// generateKubectlCommand asks the model for a kubectl command, with the
+// current context and namespace in the prompt
+func (km *KubernetesManager) generateKubectlCommand(request, context, namespace string) (string, error) {
+	prompt := fmt.Sprintf(`You are a Kubernetes expert. Provide a single kubectl command for: "%s"
+
+Current context:
+- kubectl context: %s
+- Namespace: %s
+
+Rules:
+- Output ONLY the kubectl command
+- No explanations, no markdown, no backticks
+- Commands run in namespace %s unless the request names another one
+- Avoid destructive options unless clearly requested
+
+Command:`, request, context, namespace, namespace)
+
+	color.Blue("🤖 Generating kubectl command with AI...")
+	response, err := ai.RunModel(prompt)
+	if err != nil {
+		return "", fmt.Errorf("AI kubectl command generation failed: %w", err)
+	}
+
+	command := ai.ExtractCommand(response)
+	if command == "" {
+		return "", fmt.Errorf("AI didn't generate a valid kubectl command")
+	}
+	if !strings.HasPrefix(command, "kubectl ") {
+		command = "kubectl " + command
+	}
+
+	// Let the user fill placeholders like <pod> instead of running them literally
+	if placeholders := FindPlaceholders(command); len(placeholders) > 0 {
+		command, err = FillPlaceholders(command, placeholders, km.env)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	color.Cyan("💡 Generated command: %s", command)
+	return command, nil
+}
+
+// ParseKubectlCommand finds the verb and target namespace of a kubectl command
+func ParseKubectlCommand(command, currentNamespace string) KubectlCommand {
+	parsed := KubectlCommand{Command: command, Namespace: currentNamespace}
+
+	fields := strings.Fields(command)
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "-A" || field == "--all-namespaces":
+			parsed.AllNamespaces = true
+		case (field == "-n" || field == "--namespace") && i+1 < len(fields):
+			parsed.Namespace = fields[i+1]
+			i++
+		case strings.HasPrefix(field, "--namespace="):
+			parsed.Namespace = strings.TrimPrefix(field, "--namespace=")
+		case strings.HasPrefix(field, "-n") && len(field) > 2 && !strings.HasPrefix(field, "--"):
+			parsed.Namespace = strings.TrimPrefix(field[2:], "=")
+		case kubectlFlagsWithValue[field]:
+			i++ // skip the flag's value
+		case parsed.Verb == "" && !strings.HasPrefix(field, "-"):
+			parsed.Verb = field
+		}
+	}
+	return parsed
+}
+
+// executeKubectlCommand runs a kubectl command, offering a client-side dry
+// run first and asking for the namespace to be typed for risky verbs
+func (km *KubernetesManager) executeKubectlCommand(kc KubectlCommand, context string) error {
+	color.Yellow("🚀 Command: %s", kc.Command)
+
+	risk, risky := riskyKubectlVerbs[kc.Verb]
+	if !risky {
+		if AskForConfirmation("Execute this kubectl command?") {
+			return km.sandbox.WrapCommand(kc.Command, km.execConfig, km.env)
+		}
+		color.Yellow("💡 Command ready: %s", kc.Command)
+		return nil
+	}
+
+	// Risky verbs name what they act on before anything runs
+	scope := "namespace " + kc.Namespace
+	confirmWord := kc.Namespace
+	switch {
+	case nodeKubectlVerbs[kc.Verb]:
+		scope, confirmWord = "context "+context+" (cluster-wide node operation)", context
+	case kc.AllNamespaces:
+		scope, confirmWord = "ALL namespaces of context "+context, context
+	}
+	color.Red("⚠️  Risks:")
+	color.Red("   • %s", risk)
+	color.Red("   • Acts on %s", scope)
+	fmt.Println()
+
+	if dryRunKubectlVerbs[kc.Verb] && !strings.Contains(kc.Command, "--dry-run") &&
+		AskForConfirmation("Preview it first with --dry-run=client?") {
+		dryRun := kc.Command + " --dry-run=client"
+		if err := km.sandbox.WrapCommand(dryRun, km.execConfig, km.env); err != nil {
+			color.Red("❌ Dry run failed: %v", err)
+			if !AskForConfirmation("Continue anyway?") {
+				return nil
+			}
+		}
+	}
+
+	// Typing the name guards against running in the wrong namespace or cluster
+	question := fmt.Sprintf("Type %q to %s in %s", confirmWord, kc.Verb, scope)
+	color.Red("🚨 %s:", question)
+	var response string
+	fmt.Scanln(&response)
+	approved := strings.TrimSpace(response) == confirmWord
+	recordDecision(question, approved)
+	if !approved {
+		color.Yellow("❌ Operation cancelled")
+		return nil
+	}
+
+	color.Green("✅ Executing kubectl operation...")
+	return km.sandbox.WrapCommand(kc.Command, km.execConfig, km.env)
+}
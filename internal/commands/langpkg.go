@@ -0,0 +1,381 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Language package managers install developer tools that OS repositories
+// often lack or ship old: /install ripgrep --via cargo
+
+type PipManager struct{}
+type PipxManager struct{}
+type NpmManager struct{}
+type YarnManager struct{}
+type PnpmManager struct{}
+type CargoManager struct{}
+type GemManager struct{}
+type GoManager struct{}
+
+func (p PipManager) Name() string   { return "pip" }
+func (p PipxManager) Name() string  { return "pipx" }
+func (n NpmManager) Name() string   { return "npm" }
+func (y YarnManager) Name() string  { return "yarn" }
+func (p PnpmManager) Name() string  { return "pnpm" }
+func (c CargoManager) Name() string { return "cargo" }
+func (g GemManager) Name() string   { return "gem" }
+func (g GoManager) Name() string    { return "go" }
+
+func (p PipManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	cmd := exec.Command("pip", "show", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		info.Installed = true
+		info.Version = fieldValue(string(output), "Version")
+	}
+
+	return info, nil
+}
+
+func (p PipManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("pip install --user %s", pkg)
+}
+
+func (p PipManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("pip install --user --upgrade %s", pkg)
+}
+
+func (p PipManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("pip uninstall %s", pkg)
+}
+
+func (p PipxManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// pipx list --short prints "name version" per application
+	cmd := exec.Command("pipx", "list", "--short")
+	output, err := cmd.Output()
+	if err == nil {
+		info.Version, info.Installed = versionFromList(string(output), pkg)
+	}
+
+	return info, nil
+}
+
+func (p PipxManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("pipx install %s", pkg)
+}
+
+func (p PipxManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("pipx upgrade %s", pkg)
+}
+
+func (p PipxManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("pipx uninstall %s", pkg)
+}
+
+func (n NpmManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// npm ls -g prints the tree: "└── typescript@5.4.5"
+	cmd := exec.Command("npm", "ls", "-g", "--depth=0", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		info.Version, info.Installed = versionAfterAt(string(output), pkg)
+	}
+
+	return info, nil
+}
+
+func (n NpmManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("npm install -g %s", pkg)
+}
+
+func (n NpmManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("npm update -g %s", pkg)
+}
+
+func (n NpmManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("npm uninstall -g %s", pkg)
+}
+
+func (y YarnManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// yarn global list prints `info "typescript@5.4.5" has binaries:`
+	cmd := exec.Command("yarn", "global", "list")
+	output, err := cmd.Output()
+	if err == nil {
+		info.Version, info.Installed = versionAfterAt(strings.ReplaceAll(string(output), `"`, " "), pkg)
+	}
+
+	return info, nil
+}
+
+func (y YarnManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("yarn global add %s", pkg)
+}
+
+func (y YarnManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("yarn global upgrade %s", pkg)
+}
+
+func (y YarnManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("yarn global remove %s", pkg)
+}
+
+func (p PnpmManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// pnpm ls -g prints "typescript 5.4.5" under its dependencies
+	cmd := exec.Command("pnpm", "ls", "-g", "--depth=0", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		info.Version, info.Installed = versionFromList(string(output), pkg)
+	}
+
+	return info, nil
+}
+
+func (p PnpmManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("pnpm add -g %s", pkg)
+}
+
+func (p PnpmManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("pnpm update -g %s", pkg)
+}
+
+func (p PnpmManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("pnpm remove -g %s", pkg)
+}
+
+func (c CargoManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// cargo install --list prints "ripgrep v14.1.0:" and the binaries below
+	cmd := exec.Command("cargo", "install", "--list")
+	output, err := cmd.Output()
+	if err == nil {
+		version, installed := versionFromList(string(output), pkg)
+		info.Version, info.Installed = strings.TrimSuffix(strings.TrimPrefix(version, "v"), ":"), installed
+	}
+
+	return info, nil
+}
+
+func (c CargoManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("cargo install %s", pkg)
+}
+
+// UpdateCommand relies on cargo install replacing an older version
+func (c CargoManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("cargo install %s", pkg)
+}
+
+func (c CargoManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("cargo uninstall %s", pkg)
+}
+
+func (g GemManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// gem list -e prints "rails (7.1.3, 7.0.8)", newest first
+	cmd := exec.Command("gem", "list", "-e", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		if _, versions, ok := strings.Cut(strings.TrimSpace(string(output)), "("); ok {
+			info.Installed = true
+			info.Version = strings.TrimSpace(strings.FieldsFunc(versions, func(r rune) bool { return r == ',' || r == ')' })[0])
+		}
+	}
+
+	return info, nil
+}
+
+func (g GemManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("gem install %s", pkg)
+}
+
+func (g GemManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("gem update %s", pkg)
+}
+
+func (g GemManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("gem uninstall %s", pkg)
+}
+
+// CheckPackage looks for the binary go install builds from a package path,
+// e.g. golang.org/x/tools/gopls, and reads its module version
+func (g GoManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	binary := goBinaryPath(pkg)
+	if _, err := os.Stat(binary); err != nil {
+		return info, nil
+	}
+	info.Installed = true
+
+	// go version -m prints "\tmod\tgolang.org/x/tools/gopls\tv0.15.3\th1:..."
+	cmd := exec.Command("go", "version", "-m", binary)
+	output, err := cmd.Output()
+	if err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 3 && fields[0] == "mod" {
+				info.Version = strings.TrimPrefix(fields[2], "v")
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (g GoManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("go install %s", goLatest(pkg))
+}
+
+func (g GoManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("go install %s", goLatest(pkg))
+}
+
+// RemoveCommand deletes the binary; go has no uninstall
+func (g GoManager) RemoveCommand(pkg string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`del "%s"`, goBinaryPath(pkg))
+	}
+	return fmt.Sprintf("rm %s", goBinaryPath(pkg))
+}
+
+// goLatest adds @latest to a package path without a version
+func goLatest(pkg string) string {
+	if strings.Contains(pkg, "@") {
+		return pkg
+	}
+	return pkg + "@latest"
+}
+
+// goBinDir returns where go install puts binaries: GOBIN, or GOPATH/bin
+func goBinDir() string {
+	if output, err := exec.Command("go", "env", "GOBIN", "GOPATH").Output(); err == nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if strings.TrimSpace(lines[0]) != "" {
+			return strings.TrimSpace(lines[0])
+		}
+		if len(lines) > 1 {
+			return filepath.Join(strings.TrimSpace(strings.Split(lines[1], string(os.PathListSeparator))[0]), "bin")
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "go", "bin")
+}
+
+// goMajorVersion matches the /v2 suffix of a module path, which is not part
+// of the binary name
+var goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// goBinaryPath returns the binary go install builds from a package path
+func goBinaryPath(pkg string) string {
+	pkg, _, _ = strings.Cut(pkg, "@")
+	parts := strings.Split(strings.Trim(pkg, "/"), "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && goMajorVersion.MatchString(name) {
+		name = parts[len(parts)-2]
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(goBinDir(), name)
+}
+
+// versionFromList finds "name version" in a listing and returns the version
+func versionFromList(output, pkg string) (string, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == pkg {
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// versionAfterAt finds "name@version" in a listing and returns the version;
+// the name may be scoped itself, as in @angular/cli@17.3.0
+func versionAfterAt(output, pkg string) (string, bool) {
+	for _, field := range strings.Fields(output) {
+		if version, ok := strings.CutPrefix(field, pkg+"@"); ok {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// languageTools are well-known developer tools and the ecosystem that
+// publishes them
+var languageTools = map[string]string{
+	"black": "pip", "ruff": "pip", "poetry": "pip", "httpie": "pip", "yt-dlp": "pip",
+	"pre-commit": "pip", "tox": "pip", "mypy": "pip", "pytest": "pip", "awscli": "pip",
+	"typescript": "npm", "prettier": "npm", "eslint": "npm", "nodemon": "npm", "pm2": "npm",
+	"vercel": "npm", "netlify-cli": "npm", "ts-node": "npm",
+	"cargo-edit": "cargo", "cargo-watch": "cargo", "sccache": "cargo",
+	"rails": "gem", "bundler": "gem", "jekyll": "gem", "rubocop": "gem", "cocoapods": "gem",
+	"gopls": "go", "golangci-lint": "go", "staticcheck": "go", "delve": "go",
+}
+
+// languageToolPaths are the go install paths of the Go tools above
+var languageToolPaths = map[string]string{
+	"gopls":         "golang.org/x/tools/gopls",
+	"golangci-lint": "github.com/golangci/golangci-lint/cmd/golangci-lint",
+	"staticcheck":   "honnef.co/go/tools/cmd/staticcheck",
+	"delve":         "github.com/go-delve/delve/cmd/dlv",
+}
+
+// goPackagePath matches a Go package path: a domain, then a path
+var goPackagePath = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+/[^\s]+$`)
+
+// LanguageManagerFor guesses from a package's name which language package
+// manager publishes it: a Go package path, a scoped npm package, a cargo-
+// subcommand or a well-known tool. It returns the package name the manager
+// knows, or nil when the name says nothing; managers that are not installed
+// are not suggested.
+func LanguageManagerFor(pkg string) (PackageManagerHandler, string) {
+	ecosystem := languageTools[strings.ToLower(pkg)]
+	switch {
+	case goPackagePath.MatchString(pkg):
+		ecosystem = "go"
+	case strings.HasPrefix(pkg, "@") && strings.Contains(pkg, "/"):
+		ecosystem = "npm"
+	case strings.HasPrefix(pkg, "cargo-"):
+		ecosystem = "cargo"
+	}
+	name := pkg
+	if path, ok := languageToolPaths[strings.ToLower(pkg)]; ok {
+		name = path
+	}
+
+	// Prefer the isolated installer of an ecosystem when it is there
+	var candidates []PackageManagerHandler
+	switch ecosystem {
+	case "pip":
+		candidates = []PackageManagerHandler{PipxManager{}, PipManager{}}
+	case "npm":
+		candidates = []PackageManagerHandler{NpmManager{}, PnpmManager{}, YarnManager{}}
+	case "cargo":
+		candidates = []PackageManagerHandler{CargoManager{}}
+	case "gem":
+		candidates = []PackageManagerHandler{GemManager{}}
+	case "go":
+		candidates = []PackageManagerHandler{GoManager{}}
+	}
+	for _, manager := range candidates {
+		if _, err := exec.LookPath(manager.Name()); err == nil {
+			return manager, name
+		}
+	}
+	return nil, pkg
+}
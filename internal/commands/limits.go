@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrCommandTimeout is returned when a command runs longer than ExecuteConfig.Timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
+// ErrOutputLimit is returned when a command prints more than ExecuteConfig.MaxOutputBytes
+var ErrOutputLimit = errors.New("command output limit exceeded")
+
+// killGrace is how long a stopped command gets to exit before its output
+// pipes are closed regardless
+const killGrace = 2 * time.Second
+
+// I/O scheduling classes for ExecuteConfig.IONiceClass (Linux)
+const (
+	IONiceBestEffort = 2
+	IONiceIdle       = 3
+)
+
+// WithLimits returns the configuration with the resource limits of another,
+// e.g. the limits saved in the config file
+func (c ExecuteConfig) WithLimits(limits ExecuteConfig) ExecuteConfig {
+	c.Timeout = limits.Timeout
+	c.MaxOutputBytes = limits.MaxOutputBytes
+	c.Nice = limits.Nice
+	c.IONiceClass = limits.IONiceClass
+	c.BulkFileThreshold = limits.BulkFileThreshold
+	return c
+}
+
+// commandContext returns the context a command runs under, ending at the
+// configured timeout
+func (c ExecuteConfig) commandContext() (context.Context, context.CancelFunc) {
+	if c.Timeout > 0 {
+		return context.WithTimeout(context.Background(), c.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// applyLimits makes cmd stop with its whole process group when ctx ends and
+// returns a function that explains why the command stopped. Output beyond
+// MaxOutputBytes ends ctx through cancel.
+func (c ExecuteConfig) applyLimits(ctx context.Context, cancel context.CancelFunc, cmd *exec.Cmd) func(error) error {
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		killProcessGroup(cmd.Process)
+		return nil
+	}
+	cmd.WaitDelay = killGrace
+
+	var limit *outputLimit
+	if c.MaxOutputBytes > 0 {
+		limit = &outputLimit{remaining: c.MaxOutputBytes, cancel: cancel}
+		if cmd.Stdout != nil {
+			cmd.Stdout = limit.writer(cmd.Stdout)
+		}
+		if cmd.Stderr != nil {
+			cmd.Stderr = limit.writer(cmd.Stderr)
+		}
+	}
+
+	return func(err error) error {
+		switch {
+		case limit != nil && limit.exceeded():
+			return fmt.Errorf("%w: stopped after %d bytes", ErrOutputLimit, c.MaxOutputBytes)
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return fmt.Errorf("%w after %s", ErrCommandTimeout, c.Timeout)
+		}
+		return err
+	}
+}
+
+// outputLimit counts the output of a command across stdout and stderr
+type outputLimit struct {
+	mu        sync.Mutex
+	remaining int64
+	over      bool
+	cancel    context.CancelFunc
+}
+
+// writer passes output through to w until the limit is reached, then stops the command
+func (l *outputLimit) writer(w io.Writer) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		l.mu.Lock()
+		if l.over {
+			l.mu.Unlock()
+			return len(p), nil
+		}
+		allowed := p
+		if int64(len(p)) > l.remaining {
+			allowed = p[:l.remaining]
+			l.over = true
+			l.cancel()
+		}
+		l.remaining -= int64(len(allowed))
+		l.mu.Unlock()
+
+		if _, err := w.Write(allowed); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+// exceeded reports whether the command printed more than allowed
+func (l *outputLimit) exceeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.over
+}
+
+// writerFunc adapts a function to io.Writer
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// withPriority prefixes a command's arguments with nice and ionice when they
+// are configured and available; Windows runs commands unchanged
+func (c ExecuteConfig) withPriority(args []string) []string {
+	if runtime.GOOS == "windows" {
+		return args
+	}
+
+	var prefix []string
+	if c.Nice > 0 {
+		if path, err := exec.LookPath("nice"); err == nil {
+			prefix = append(prefix, path, "-n", fmt.Sprint(c.Nice))
+		}
+	}
+	if c.IONiceClass > 0 && runtime.GOOS == "linux" {
+		if path, err := exec.LookPath("ionice"); err == nil {
+			prefix = append(prefix, path, "-c", fmt.Sprint(c.IONiceClass))
+		}
+	}
+	return append(prefix, args...)
+}
@@ -0,0 +1,368 @@
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetTask is a network diagnostic /net runs natively
+type NetTask string
+
+const (
+	NetPort       NetTask = "port"
+	NetDNS        NetTask = "dns"
+	NetLatency    NetTask = "ping"
+	NetReach      NetTask = "why"
+	NetInterfaces NetTask = "interfaces"
+	NetUnknown    NetTask = "" // no native path; a command is generated instead
+)
+
+// NetRequest is a network question reduced to a task and its target
+type NetRequest struct {
+	Task   NetTask
+	Target string // host, IP address or URL
+	Port   int
+}
+
+// netDialTimeout bounds each connection attempt of a diagnostic
+const netDialTimeout = 5 * time.Second
+
+var (
+	netURLPattern  = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s'"]+`)
+	netHostPattern = regexp.MustCompile(`\b(?:localhost|(?:\d{1,3}\.){3}\d{1,3}|(?:[a-zA-Z0-9-]+\.)+[a-zA-Z]{2,})(?::(\d{1,5}))?\b`)
+	netPortPattern = regexp.MustCompile(`(?i)\bport\s*(\d{1,5})\b`)
+)
+
+// ParseNetRequest reads requests like "port example.com 443", "is port 5432
+// open on db.local" or "why can't I reach github.com"
+func ParseNetRequest(text string) NetRequest {
+	lower := strings.ToLower(text)
+	request := NetRequest{}
+
+	if match := netURLPattern.FindString(text); match != "" {
+		request.Target = match
+	} else if match := netHostPattern.FindStringSubmatch(text); match != nil {
+		request.Target = strings.Split(match[0], ":")[0]
+		request.Port, _ = strconv.Atoi(match[1])
+	}
+	if match := netPortPattern.FindStringSubmatch(text); match != nil {
+		request.Port, _ = strconv.Atoi(match[1])
+	} else if request.Port == 0 {
+		// "port example.com 443"
+		if fields := strings.Fields(text); len(fields) > 1 {
+			if port, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+				request.Port = port
+			}
+		}
+	}
+
+	first := strings.Fields(lower + " ")[0]
+	switch {
+	case first == "interfaces" || strings.Contains(lower, "my ip") || strings.Contains(lower, "interface") ||
+		strings.Contains(lower, "ip address"):
+		if request.Target == "" {
+			request.Task = NetInterfaces
+		}
+	case first == "why" || strings.Contains(lower, "reach") || strings.Contains(lower, "connect to") ||
+		strings.Contains(lower, "can't access") || strings.Contains(lower, "cannot access") || strings.Contains(lower, "down"):
+		request.Task = NetReach
+	case first == "dns" || strings.Contains(lower, "resolve") || strings.Contains(lower, "lookup") ||
+		strings.Contains(lower, "dns") || strings.Contains(lower, "records"):
+		request.Task = NetDNS
+	case first == "ping" || strings.Contains(lower, "latency") || strings.Contains(lower, "ping") ||
+		strings.Contains(lower, "how fast") || strings.Contains(lower, "slow"):
+		request.Task = NetLatency
+	case first == "port" || request.Port > 0 || strings.Contains(lower, "open"):
+		request.Task = NetPort
+	}
+
+	if request.Task != NetInterfaces && request.Target == "" {
+		request.Task = NetUnknown
+	}
+	if request.Task == NetPort && request.Port == 0 {
+		request.Task = NetUnknown
+	}
+	return request
+}
+
+// PortResult is the outcome of a TCP connection attempt
+type PortResult struct {
+	Address string
+	Open    bool
+	Latency time.Duration
+	Err     error
+}
+
+// CheckPort tries a TCP connection to host:port
+func CheckPort(host string, port int) PortResult {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, netDialTimeout)
+	result := PortResult{Address: address, Latency: time.Since(start), Err: err}
+	if err == nil {
+		conn.Close()
+		result.Open = true
+	}
+	return result
+}
+
+// DNSResult holds the records of a name, or the name of an address
+type DNSResult struct {
+	Name      string
+	Addresses []string // A and AAAA records
+	CNAME     string
+	MX        []string
+	NS        []string
+	TXT       []string
+	Names     []string // reverse lookup of an IP address
+	Err       error    // the address lookup failed
+}
+
+// LookupDNS resolves a name's records, or an IP address's names
+func LookupDNS(name string) DNSResult {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*netDialTimeout)
+	defer cancel()
+	resolver := net.DefaultResolver
+	result := DNSResult{Name: name}
+
+	if net.ParseIP(name) != nil {
+		result.Names, result.Err = resolver.LookupAddr(ctx, name)
+		return result
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, name)
+	result.Err = err
+	for _, addr := range addrs {
+		result.Addresses = append(result.Addresses, addr.IP.String())
+	}
+	if cname, err := resolver.LookupCNAME(ctx, name); err == nil && strings.TrimSuffix(cname, ".") != name {
+		result.CNAME = strings.TrimSuffix(cname, ".")
+	}
+	if records, err := resolver.LookupMX(ctx, name); err == nil {
+		for _, mx := range records {
+			result.MX = append(result.MX, fmt.Sprintf("%s (priority %d)", strings.TrimSuffix(mx.Host, "."), mx.Pref))
+		}
+	}
+	if records, err := resolver.LookupNS(ctx, name); err == nil {
+		for _, ns := range records {
+			result.NS = append(result.NS, strings.TrimSuffix(ns.Host, "."))
+		}
+	}
+	if records, err := resolver.LookupTXT(ctx, name); err == nil {
+		result.TXT = records
+	}
+	return result
+}
+
+// LatencyResult summarizes repeated TCP connection times to a host
+type LatencyResult struct {
+	Address  string
+	Samples  []time.Duration // successful attempts
+	Failures int
+	Err      error // the last failure
+}
+
+// Min returns the fastest connection time
+func (r LatencyResult) Min() time.Duration { return r.sorted(0) }
+
+// Max returns the slowest connection time
+func (r LatencyResult) Max() time.Duration { return r.sorted(len(r.Samples) - 1) }
+
+// Average returns the mean connection time
+func (r LatencyResult) Average() time.Duration {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, sample := range r.Samples {
+		total += sample
+	}
+	return total / time.Duration(len(r.Samples))
+}
+
+// sorted returns the i-th fastest connection time
+func (r LatencyResult) sorted(i int) time.Duration {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+	samples := append([]time.Duration(nil), r.Samples...)
+	sort.Slice(samples, func(a, b int) bool { return samples[a] < samples[b] })
+	return samples[i]
+}
+
+// MeasureLatency times count TCP connections to host:port. It needs no
+// privileges, unlike ICMP ping, and measures what applications see.
+func MeasureLatency(host string, port, count int) LatencyResult {
+	result := LatencyResult{Address: net.JoinHostPort(host, strconv.Itoa(port))}
+	for i := 0; i < count; i++ {
+		if attempt := CheckPort(host, port); attempt.Open {
+			result.Samples = append(result.Samples, attempt.Latency)
+		} else {
+			result.Failures++
+			result.Err = attempt.Err
+		}
+		if i < count-1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	return result
+}
+
+// DiagnosisStep is one check of a reachability diagnosis
+type DiagnosisStep struct {
+	Name   string
+	OK     bool
+	Detail string
+	Hint   string // what to try when the check failed
+}
+
+// DiagnoseReachability works through why a host or URL may be unreachable:
+// name resolution, general connectivity, the TCP port, then HTTP and TLS.
+// It stops at the first check that fails.
+func DiagnoseReachability(target string, port int) []DiagnosisStep {
+	host, scheme := target, ""
+	if parsed, err := url.Parse(target); err == nil && parsed.Host != "" {
+		host, scheme = parsed.Hostname(), parsed.Scheme
+		if p, err := strconv.Atoi(parsed.Port()); err == nil && port == 0 {
+			port = p
+		}
+	}
+	if port == 0 {
+		port = 443
+		if scheme == "http" {
+			port = 80
+		}
+	}
+
+	var steps []DiagnosisStep
+	for _, proxy := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY"} {
+		if value := os.Getenv(proxy); value != "" {
+			steps = append(steps, DiagnosisStep{Name: "Proxy", OK: true,
+				Detail: fmt.Sprintf("%s=%s is set; browsers and curl go through it, this check does not", proxy, value)})
+			break
+		}
+	}
+
+	// 1. Name resolution
+	if net.ParseIP(host) == nil {
+		dns := LookupDNS(host)
+		if dns.Err != nil || len(dns.Addresses) == 0 {
+			step := DiagnosisStep{Name: "DNS", Detail: fmt.Sprintf("%s does not resolve: %v", host, dns.Err),
+				Hint: "Check the spelling, your DNS server (/etc/resolv.conf) or VPN; try /net dns " + host}
+			var dnsErr *net.DNSError
+			if errors.As(dns.Err, &dnsErr) && dnsErr.IsTimeout {
+				step.Hint = "The DNS server did not answer; check your network connection or DNS settings"
+			}
+			return append(steps, step)
+		}
+		steps = append(steps, DiagnosisStep{Name: "DNS", OK: true, Detail: host + " → " + strings.Join(dns.Addresses, ", ")})
+	}
+
+	// 2. TCP connection
+	result := CheckPort(host, port)
+	if !result.Open {
+		step := DiagnosisStep{Name: "TCP", Detail: fmt.Sprintf("cannot connect to %s: %v", result.Address, result.Err)}
+		switch {
+		case isConnRefused(result.Err):
+			step.Hint = fmt.Sprintf("The host answered but nothing listens on port %d (or a firewall rejects it)", port)
+		case isTimeout(result.Err):
+			step.Hint = "No answer: a firewall may drop the traffic, or the host is down"
+		default:
+			step.Hint = "Check your routes and VPN"
+		}
+		// Tell a local network problem from a remote one
+		if internet := CheckPort("1.1.1.1", 443); !internet.Open {
+			steps = append(steps, DiagnosisStep{Name: "Internet", Detail: "cannot reach 1.1.1.1:443 either",
+				Hint: "Your own connection looks down; check Wi-Fi/cable, the gateway and any proxy"})
+		}
+		return append(steps, step)
+	}
+	steps = append(steps, DiagnosisStep{Name: "TCP", OK: true,
+		Detail: fmt.Sprintf("%s is open (%s)", result.Address, result.Latency.Round(time.Millisecond))})
+
+	// 3. HTTP and TLS for web targets
+	if scheme == "" && port != 443 && port != 80 {
+		return steps
+	}
+	if scheme == "" {
+		scheme = "https"
+		if port == 80 {
+			scheme = "http"
+		}
+	}
+	address := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+	if strings.Contains(target, "://") {
+		address = target
+	}
+	client := http.Client{Timeout: 2 * netDialTimeout, Transport: &http.Transport{Proxy: nil, DisableKeepAlives: true}}
+	resp, err := client.Head(address)
+	if err != nil {
+		step := DiagnosisStep{Name: "HTTP", Detail: err.Error(), Hint: "The port is open but the server did not complete the request"}
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			step.Name = "TLS"
+			step.Hint = "The certificate is not trusted (expired, self-signed or for another name)"
+		}
+		return append(steps, step)
+	}
+	resp.Body.Close()
+	step := DiagnosisStep{Name: "HTTP", OK: resp.StatusCode < 500, Detail: fmt.Sprintf("%s answered %s", address, resp.Status)}
+	if !step.OK {
+		step.Hint = "The server is reachable but failing; the problem is on its side"
+	}
+	return append(steps, step)
+}
+
+// isConnRefused reports whether a dial error is a refused connection; the
+// message is checked since Unix and Windows use different error numbers
+func isConnRefused(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "refused")
+}
+
+// isTimeout reports whether a dial error is a timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// InterfaceInfo is a network interface with its addresses
+type InterfaceInfo struct {
+	Name      string
+	Up        bool
+	Loopback  bool
+	Addresses []string
+}
+
+// ListInterfaces returns the network interfaces of this machine
+func ListInterfaces() ([]InterfaceInfo, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []InterfaceInfo
+	for _, iface := range interfaces {
+		info := InterfaceInfo{
+			Name:     iface.Name,
+			Up:       iface.Flags&net.FlagUp != 0,
+			Loopback: iface.Flags&net.FlagLoopback != 0,
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			info.Addresses = append(info.Addresses, addr.String())
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"sync"
+	"time"
+)
+
+// CommandOutput is what the last foreground command printed, for /save
+type CommandOutput struct {
+	Command   string
+	Output    string // stdout, then stderr when they were captured separately
+	Truncated bool   // only the end of a long output was kept
+	Time      time.Time
+}
+
+var lastOutput struct {
+	mu     sync.Mutex
+	output *CommandOutput
+}
+
+// LastOutput returns the output of the most recent command Helix ran
+func LastOutput() (CommandOutput, bool) {
+	lastOutput.mu.Lock()
+	defer lastOutput.mu.Unlock()
+	if lastOutput.output == nil {
+		return CommandOutput{}, false
+	}
+	return *lastOutput.output, true
+}
+
+// recordOutput remembers a command's output; commands whose output could
+// not be captured, like editors, leave nothing to save
+func recordOutput(command string, output *tailBuffer) {
+	lastOutput.mu.Lock()
+	defer lastOutput.mu.Unlock()
+	if output == nil {
+		lastOutput.output = nil
+		return
+	}
+	lastOutput.output = &CommandOutput{
+		Command:   command,
+		Output:    output.String(),
+		Truncated: output.truncated,
+		Time:      time.Now(),
+	}
+}
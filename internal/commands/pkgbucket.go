@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// BucketChecker is a package manager whose packages come from buckets the
+// user adds, like Scoop's. It returns the bucket a package needs and the
+// command adding it, when the bucket is not added yet.
+type BucketChecker interface {
+	MissingBucket(pkg string) (bucket, command string, missing bool)
+}
+
+// scoopKnownBuckets are the buckets scoop bucket add knows by name
+var scoopKnownBuckets = map[string]bool{
+	"main": true, "extras": true, "versions": true, "nirsoft": true, "sysinternals": true,
+	"php": true, "nerd-fonts": true, "nonportable": true, "java": true, "games": true,
+}
+
+// scoopAppBuckets are the buckets well-known apps are in outside main
+var scoopAppBuckets = map[string]string{
+	"vscode": "extras", "firefox": "extras", "googlechrome": "extras", "brave": "extras",
+	"vlc": "extras", "obs-studio": "extras", "gimp": "extras", "inkscape": "extras",
+	"windows-terminal": "extras", "powertoys": "extras", "notepadplusplus": "extras",
+	"sumatrapdf": "extras", "everything": "extras", "keepassxc": "extras", "vcredist2022": "extras",
+	"postman": "extras", "dbeaver": "extras", "wireshark": "extras", "audacity": "extras",
+	"python27": "versions", "python311": "versions", "nodejs16": "versions",
+	"firefox-esr": "versions", "vscode-insiders": "versions",
+	"openjdk": "java", "temurin-jdk": "java", "temurin-lts-jdk": "java", "zulu-jdk": "java",
+	"firacode": "nerd-fonts", "firacode-nf": "nerd-fonts", "jetbrainsmono-nf": "nerd-fonts", "cascadia-code": "nerd-fonts",
+	"procexp": "sysinternals", "procmon": "sysinternals", "autoruns": "sysinternals",
+}
+
+// scoopApp returns the app of a bucket/app name
+func scoopApp(pkg string) string {
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
+
+// scoopBuckets returns the buckets that are added
+func scoopBuckets() map[string]bool {
+	lines, _ := packageOutput("scoop", "bucket", "list")
+	added := make(map[string]bool)
+	for _, row := range scoopTable(lines) {
+		added[strings.ToLower(row["Name"])] = true
+	}
+	return added
+}
+
+func (s ScoopManager) MissingBucket(pkg string) (string, string, bool) {
+	bucket := scoopAppBuckets[strings.ToLower(pkg)]
+	if before, _, ok := strings.Cut(pkg, "/"); ok {
+		bucket = strings.ToLower(before)
+	}
+	if bucket == "" || scoopBuckets()[bucket] {
+		return "", "", false
+	}
+	if !scoopKnownBuckets[bucket] {
+		return bucket, fmt.Sprintf("scoop bucket add %s <repository-url>", bucket), true
+	}
+	return bucket, fmt.Sprintf("scoop bucket add %s", bucket), true
+}
+
+// missingBucket returns the bucket a package needs and the command adding
+// it, when the manager has buckets and that one is not added
+func missingBucket(pm PackageManagerHandler, pkg string) (string, string, bool) {
+	checker, ok := pm.(BucketChecker)
+	if !ok {
+		return "", "", false
+	}
+	return checker.MissingBucket(pkg)
+}
+
+// addMissingBucket offers to add the bucket a package needs before it is
+// installed. It returns false when the bucket is missing and was not added,
+// as in mock mode.
+func addMissingBucket(pm PackageManagerHandler, pkg string, env shell.Env, mockMode bool, execConfig ExecuteConfig) bool {
+	bucket, command, missing := missingBucket(pm, pkg)
+	if !missing {
+		return true
+	}
+	color.Yellow("🪣 %s is in the %s bucket, which %s does not have yet", pkg, bucket, pm.Name())
+	color.Cyan("💡 Add it with: %s", command)
+	if mockMode {
+		return false
+	}
+	if strings.Contains(command, "<") {
+		color.Yellow("💡 %s is not a known bucket; add it with its repository URL, then install again", bucket)
+		return false
+	}
+	if !AskForConfirmation(fmt.Sprintf("Add the %s bucket now?", bucket)) {
+		color.Yellow("💡 Add the bucket, then install again")
+		return false
+	}
+	if err := ExecuteCommand(command, execConfig, env); err != nil {
+		color.Red("❌ Adding the %s bucket failed: %v", bucket, err)
+		return false
+	}
+	color.Green("✅ Added the %s bucket", bucket)
+	return true
+}
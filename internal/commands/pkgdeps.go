@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// DependentsLister is a package manager that can tell which installed
+// packages depend on a package
+type DependentsLister interface {
+	InstalledDependents(pkg string) ([]string, error)
+}
+
+// maxDependentsShown keeps the removal preview readable
+const maxDependentsShown = 20
+
+// essentialPackages keep a system booting, logging in or managing its
+// packages; removing them, or what depends on them, needs the name typed
+var essentialPackages = map[string]bool{
+	"base": true, "base-files": true, "filesystem": true, "init": true, "systemd": true,
+	"linux": true, "linux-image-generic": true, "kernel": true, "kernel-core": true,
+	"grub2": true, "grub-pc": true, "grub-efi-amd64": true, "grub": true,
+	"libc6": true, "glibc": true, "musl": true, "busybox": true, "zlib1g": true, "zlib": true,
+	"bash": true, "dash": true, "coreutils": true, "util-linux": true, "findutils": true,
+	"sed": true, "grep": true, "tar": true, "gzip": true, "login": true, "passwd": true,
+	"shadow": true, "shadow-utils": true, "sudo": true, "perl-base": true,
+	"apt": true, "dpkg": true, "rpm": true, "dnf": true, "yum": true, "zypper": true,
+	"pacman": true, "apk-tools": true, "python3": true, "openssh-server": true,
+	"openssh": true, "network-manager": true, "networkmanager": true,
+	"ca-certificates": true, "openssl": true, "libssl3": true,
+}
+
+// InstalledDependents returns the installed packages that depend on a
+// package, sorted, when the manager can tell
+func InstalledDependents(pm PackageManagerHandler, pkg string) ([]string, error) {
+	lister, ok := pm.(DependentsLister)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot list reverse dependencies", pm.Name())
+	}
+	dependents, err := lister.InstalledDependents(pkg)
+	seen := map[string]bool{pkg: true}
+	var unique []string
+	for _, name := range dependents {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	sort.Strings(unique)
+	return unique, err
+}
+
+// importantPackages returns which of the packages are essential to the
+// system: well-known ones, and on Debian those marked Essential or of
+// required or important priority
+func importantPackages(pm PackageManagerHandler, names []string) []string {
+	var important []string
+	marked := make(map[string]bool)
+	if pm.Name() == "apt" && len(names) > 0 {
+		args := append([]string{"-W", "-f", "${Package}\\t${Essential}\\t${Priority}\\n"}, names...)
+		lines, _ := packageOutput("dpkg-query", args...)
+		for _, line := range lines {
+			if columns := strings.Split(line, "\t"); len(columns) == 3 &&
+				(columns[1] == "yes" || columns[2] == "required" || columns[2] == "important") {
+				marked[columns[0]] = true
+			}
+		}
+	}
+	for _, name := range names {
+		if essentialPackages[strings.ToLower(name)] || marked[name] {
+			important = append(important, name)
+		}
+	}
+	return important
+}
+
+// PreviewRemoval shows which installed packages depend on the package about
+// to be removed. When the package or one of them is essential the user must
+// type the package name to go on; it returns false when they do not.
+func PreviewRemoval(pm PackageManagerHandler, pkg string, mockMode bool) bool {
+	dependents, err := InstalledDependents(pm, pkg)
+	if err != nil && len(dependents) == 0 {
+		return true
+	}
+
+	if len(dependents) > 0 {
+		color.Yellow("⚠️  %d installed package(s) depend on %s:", len(dependents), pkg)
+		for i, name := range dependents {
+			if i == maxDependentsShown {
+				color.Yellow("   ... and %d more", len(dependents)-maxDependentsShown)
+				break
+			}
+			fmt.Printf("   • %s\n", name)
+		}
+		color.Yellow("💡 %s may remove them too, or leave them broken", pm.Name())
+	}
+
+	important := importantPackages(pm, append([]string{pkg}, dependents...))
+	if len(important) == 0 {
+		return true
+	}
+	shown := important
+	if len(shown) > maxDependentsShown {
+		shown = append(shown[:maxDependentsShown:maxDependentsShown], fmt.Sprintf("and %d more", len(important)-maxDependentsShown))
+	}
+	color.Red("🚨 Essential to the system: %s", strings.Join(shown, ", "))
+	if mockMode {
+		return true
+	}
+
+	question := fmt.Sprintf(`Removing %s could break the system. Type "%s" to continue`, pkg, pkg)
+	color.Red("%s: ", question)
+	// A whole line, like the typed confirmation of critical commands
+	response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	approved := strings.TrimSpace(response) == pkg
+	recordDecision(question, approved)
+	if !approved {
+		color.Yellow("❌ Cancelled, %s is kept", pkg)
+	}
+	return approved
+}
+
+func (a AptManager) InstalledDependents(pkg string) ([]string, error) {
+	// Recommends and Suggests do not break when the package goes
+	lines, err := packageOutput("apt-cache", "rdepends", "--installed", "--no-recommends", "--no-suggests",
+		"--no-conflicts", "--no-breaks", "--no-replaces", "--no-enhances", pkg)
+	var dependents []string
+	started := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Reverse Depends:") {
+			started = true
+			continue
+		}
+		if started {
+			// "|name" marks one of several alternatives
+			dependents = append(dependents, strings.TrimPrefix(strings.TrimSpace(line), "|"))
+		}
+	}
+	return dependents, err
+}
+
+// rpmDependents asks the RPM database, which dnf, yum and zypper share, for
+// the installed packages that require a package
+func rpmDependents(pkg string) ([]string, error) {
+	lines, err := packageOutput("rpm", "-q", "--whatrequires", pkg, "--queryformat", "%{NAME}\\n")
+	var dependents []string
+	for _, line := range lines {
+		// "no package requires pkg" when there are none
+		if !strings.Contains(line, " ") {
+			dependents = append(dependents, line)
+		}
+	}
+	return dependents, err
+}
+
+func (d DnfManager) InstalledDependents(pkg string) ([]string, error) {
+	return rpmDependents(pkg)
+}
+
+func (y YumManager) InstalledDependents(pkg string) ([]string, error) {
+	return rpmDependents(pkg)
+}
+
+func (z ZypperManager) InstalledDependents(pkg string) ([]string, error) {
+	return rpmDependents(pkg)
+}
+
+func (a ApkManager) InstalledDependents(pkg string) ([]string, error) {
+	// apk info -r prints "name-1.0-r0 is required by:" and one package a line
+	lines, err := packageOutput("apk", "info", "-r", pkg)
+	var dependents []string
+	for _, line := range lines {
+		if strings.HasSuffix(line, "is required by:") {
+			continue
+		}
+		if match := apkNameVersion.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			dependents = append(dependents, match[1])
+		}
+	}
+	return dependents, err
+}
+
+func (p PacmanManager) InstalledDependents(pkg string) ([]string, error) {
+	output, err := exec.Command("pacman", "-Qi", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("pacman -Qi failed: %w", err)
+	}
+	required := fieldValue(string(output), "Required By")
+	if required == "None" {
+		return nil, nil
+	}
+	return strings.Fields(required), nil
+}
+
+func (b BrewManager) InstalledDependents(pkg string) ([]string, error) {
+	lines, err := packageOutput("brew", "uses", "--installed", pkg)
+	var dependents []string
+	for _, line := range lines {
+		dependents = append(dependents, strings.Fields(line)...)
+	}
+	return dependents, err
+}
+
+func (p PortManager) InstalledDependents(pkg string) ([]string, error) {
+	// port dependents prints "curl has the following dependents:" and one
+	// port a line, or "curl has no dependents."
+	lines, err := packageOutput("port", "dependents", pkg)
+	var dependents []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, pkg+" has ") {
+			dependents = append(dependents, strings.TrimSpace(line))
+		}
+	}
+	return dependents, err
+}
+
+func (p PipManager) InstalledDependents(pkg string) ([]string, error) {
+	output, err := exec.Command("pip", "show", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("pip show failed: %w", err)
+	}
+	var dependents []string
+	for _, name := range strings.Split(fieldValue(string(output), "Required-by"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			dependents = append(dependents, name)
+		}
+	}
+	return dependents, nil
+}
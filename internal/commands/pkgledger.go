@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// AllPackages stands for every package in the ledger, for a full-system
+// upgrade the manager could not list beforehand
+const AllPackages = "*"
+
+// PackageLedgerEntry is one package Helix installed, updated or removed,
+// with the versions before and after
+type PackageLedgerEntry struct {
+	Time          time.Time `json:"time"`
+	Action        string    `json:"action"` // install, update or remove
+	Package       string    `json:"package"`
+	Manager       string    `json:"manager"`
+	VersionBefore string    `json:"version_before,omitempty"`
+	VersionAfter  string    `json:"version_after,omitempty"`
+	Command       string    `json:"command"`
+	Success       bool      `json:"success"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Versions describes the version change, e.g. 1.2 → 1.3
+func (e PackageLedgerEntry) Versions() string {
+	switch {
+	case e.VersionBefore != "" && e.VersionAfter != "" && e.VersionBefore != e.VersionAfter:
+		return e.VersionBefore + " → " + e.VersionAfter
+	case e.VersionAfter != "":
+		return e.VersionAfter
+	}
+	return e.VersionBefore
+}
+
+// PackageLedger is the JSON-lines file recording every package operation
+// Helix runs, for /pkg history and /pkg rollback
+type PackageLedger struct {
+	path string
+}
+
+// NewPackageLedger opens the ledger at path; the file is created on first write
+func NewPackageLedger(path string) *PackageLedger {
+	return &PackageLedger{path: path}
+}
+
+// Append adds entries to the ledger
+func (l *PackageLedger) Append(entries ...PackageLedgerEntry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, entry := range entries {
+		entry.Command = utils.RedactSecrets(entry.Command)
+		entry.Error = utils.RedactSecrets(entry.Error)
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(f, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries returns the entries of the ledger, oldest first, only those of a
+// package when one is named. Damaged lines are skipped.
+func (l *PackageLedger) Entries(pkg string) ([]PackageLedgerEntry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []PackageLedgerEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry PackageLedgerEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Package == "" {
+			continue
+		}
+		if pkg == "" || strings.EqualFold(entry.Package, pkg) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// LastChange returns the newest successful operation on a package
+func (l *PackageLedger) LastChange(pkg string) (PackageLedgerEntry, bool, error) {
+	entries, err := l.Entries(pkg)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Success {
+			return entries[i], true, err
+		}
+	}
+	return PackageLedgerEntry{}, false, err
+}
+
+// packageLedger is set from main; nil records nothing
+var packageLedger *PackageLedger
+
+// SetPackageLedger sets where package operations are recorded; nil stops
+// recording them
+func SetPackageLedger(ledger *PackageLedger) {
+	packageLedger = ledger
+}
+
+// logPackageEntries adds entries to the package ledger, if there is one
+func logPackageEntries(entries ...PackageLedgerEntry) {
+	if packageLedger == nil || len(entries) == 0 {
+		return
+	}
+	if err := packageLedger.Append(entries...); err != nil {
+		color.Yellow("⚠️  Could not write the package history: %v", err)
+	}
+}
+
+// recordPackageChange adds a package command that ran to the ledger, with
+// the version it left installed
+func recordPackageChange(pm PackageManagerHandler, action, pkg, before, command string, err error) {
+	entry := PackageLedgerEntry{
+		Time:          time.Now(),
+		Action:        action,
+		Package:       pkg,
+		Manager:       pm.Name(),
+		VersionBefore: before,
+		Command:       command,
+		Success:       err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if action != "remove" {
+		if info, err := pm.CheckPackage(pkg); err == nil && info.Installed {
+			entry.VersionAfter = info.Version
+		}
+	}
+	logPackageEntries(entry)
+}
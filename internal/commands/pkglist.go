@@ -0,0 +1,531 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// PackageLister is a package manager that can list what it installed
+type PackageLister interface {
+	ListPackages() ([]PackageInfo, error)
+}
+
+// OutdatedLister is a package manager that can list installed packages
+// with a newer version available
+type OutdatedLister interface {
+	OutdatedPackages() ([]PackageInfo, error)
+}
+
+// ListPackages returns the packages a manager installed, sorted by name
+func ListPackages(pm PackageManagerHandler) ([]PackageInfo, error) {
+	lister, ok := pm.(PackageLister)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot list installed packages", pm.Name())
+	}
+	packages, err := lister.ListPackages()
+	sort.Slice(packages, func(i, j int) bool { return strings.ToLower(packages[i].Name) < strings.ToLower(packages[j].Name) })
+	return packages, err
+}
+
+// OutdatedPackages returns the installed packages a manager can upgrade,
+// sorted by name
+func OutdatedPackages(pm PackageManagerHandler) ([]PackageInfo, error) {
+	lister, ok := pm.(OutdatedLister)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot list outdated packages", pm.Name())
+	}
+	packages, err := lister.OutdatedPackages()
+	for i := range packages {
+		packages[i].Installed, packages[i].UpdateAvailable = true, true
+	}
+	sort.Slice(packages, func(i, j int) bool { return strings.ToLower(packages[i].Name) < strings.ToLower(packages[j].Name) })
+	return packages, err
+}
+
+// singleUpdateManagers take one package per update command
+var singleUpdateManagers = map[string]bool{"winget": true, "pipx": true, "go": true}
+
+// UpgradeCommand returns the command that upgrades several packages: one
+// update command naming them all, or one per package chained with &&
+func UpgradeCommand(pm PackageManagerHandler, packages []string) string {
+	if !singleUpdateManagers[pm.Name()] {
+		return withPackageSources(pm.Name(), "update", pm.UpdateCommand(strings.Join(packages, " ")))
+	}
+	commands := make([]string, len(packages))
+	for i, pkg := range packages {
+		commands[i] = withPackageSources(pm.Name(), "update", pm.UpdateCommand(pkg))
+	}
+	return strings.Join(commands, " && ")
+}
+
+// UpgradePackages shows the command that upgrades the chosen packages and
+// runs it once confirmed, like HandlePackageCommand. It reports whether it
+// ran successfully.
+func UpgradePackages(pm PackageManagerHandler, packages []string, env shell.Env, mockMode bool, execConfig ExecuteConfig) bool {
+	command := UpgradeCommand(pm, packages)
+	color.Green("🔄 Upgrade command: %s", command)
+	if mockMode {
+		return false
+	}
+	if requiresSudo(pm.Name()) {
+		color.Yellow("⚠️  This command may require administrator privileges")
+	}
+	if !AskForConfirmation("Execute this command?") {
+		color.Yellow("💡 Command cancelled. You can run it manually:")
+		color.Cyan("  %s", command)
+		return false
+	}
+	before := make([]string, len(packages))
+	for i, pkg := range packages {
+		if info, err := pm.CheckPackage(pkg); err == nil {
+			before[i] = info.Version
+		}
+	}
+	err := ExecuteCommand(command, execConfig, env)
+	for i, pkg := range packages {
+		recordPackageChange(pm, "update", pkg, before[i], command, err)
+	}
+	if err != nil {
+		color.Red("❌ Command failed: %v", err)
+		return false
+	}
+	color.Green("✅ Upgraded %d package(s)", len(packages))
+	color.Yellow("💡 If an upgrade breaks something: /pkg rollback <package>")
+	return true
+}
+
+// nameVersionLines reads "name version ..." lines, as pacman -Q and pipx
+// list --short print them
+func nameVersionLines(lines []string) []PackageInfo {
+	var packages []PackageInfo
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			packages = append(packages, PackageInfo{Name: fields[0], Version: fields[1], Installed: true})
+		}
+	}
+	return packages
+}
+
+// tabLines reads tab-separated name, version and description columns
+func tabLines(lines []string) []PackageInfo {
+	var packages []PackageInfo
+	for _, line := range lines {
+		columns := strings.Split(line, "\t")
+		info := PackageInfo{Name: columns[0], Installed: true}
+		if len(columns) > 1 {
+			info.Version = columns[1]
+		}
+		if len(columns) > 2 {
+			info.Description = columns[2]
+		}
+		packages = append(packages, info)
+	}
+	return packages
+}
+
+func (a AptManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("dpkg-query", "-W", "-f", "${db:Status-Abbrev}\\t${Package}\\t${Version}\\t${binary:Summary}\\n")
+	var packages []PackageInfo
+	for _, line := range lines {
+		// Removed packages keep their configuration and a status other than ii
+		if status, rest, ok := strings.Cut(line, "\t"); ok && strings.HasPrefix(status, "ii") {
+			packages = append(packages, tabLines([]string{rest})...)
+		}
+	}
+	return packages, err
+}
+
+func (a AptManager) OutdatedPackages() ([]PackageInfo, error) {
+	// apt list --upgradable prints "name/suite 2.0 amd64 [upgradable from: 1.0]"
+	lines, err := packageOutput("apt", "list", "--upgradable")
+	var packages []PackageInfo
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		name, _, ok := strings.Cut(fields[0], "/")
+		_, from, upgradable := strings.Cut(line, "upgradable from: ")
+		if ok && upgradable && len(fields) >= 2 {
+			packages = append(packages, PackageInfo{Name: name, LatestVersion: fields[1], Version: strings.TrimSuffix(from, "]")})
+		}
+	}
+	return packages, err
+}
+
+// rpmListPackages lists the RPM database, which dnf, yum and zypper share
+func rpmListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("rpm", "-qa", "--queryformat", "%{NAME}\\t%{VERSION}-%{RELEASE}\\t%{SUMMARY}\\n")
+	return tabLines(lines), err
+}
+
+// rpmOutdatedPackages reads dnf or yum check-update from cached metadata:
+// "name.arch  version  repository" lines, exiting 100 when there are any
+func rpmOutdatedPackages(manager string) ([]PackageInfo, error) {
+	lines, err := packageOutput(manager, "-q", "-C", "check-update")
+	installed := make(map[string]string)
+	if current, err := rpmListPackages(); err == nil {
+		for _, info := range current {
+			installed[info.Name] = info.Version
+		}
+	}
+	var packages []PackageInfo
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasPrefix(line, " ") {
+			continue // "Obsoleting packages" and its indented lines
+		}
+		name := fields[0]
+		if dot := strings.LastIndex(name, "."); dot > 0 {
+			name = name[:dot]
+		}
+		packages = append(packages, PackageInfo{Name: name, Version: installed[name], LatestVersion: fields[1]})
+	}
+	return packages, err
+}
+
+func (d DnfManager) ListPackages() ([]PackageInfo, error) {
+	return rpmListPackages()
+}
+
+func (d DnfManager) OutdatedPackages() ([]PackageInfo, error) {
+	return rpmOutdatedPackages("dnf")
+}
+
+func (y YumManager) ListPackages() ([]PackageInfo, error) {
+	return rpmListPackages()
+}
+
+func (y YumManager) OutdatedPackages() ([]PackageInfo, error) {
+	return rpmOutdatedPackages("yum")
+}
+
+func (z ZypperManager) ListPackages() ([]PackageInfo, error) {
+	return rpmListPackages()
+}
+
+func (z ZypperManager) OutdatedPackages() ([]PackageInfo, error) {
+	// zypper prints "S | Repository | Name | Current Version | Available Version | Arch"
+	lines, err := packageOutput("zypper", "--no-refresh", "--quiet", "list-updates")
+	var packages []PackageInfo
+	for _, line := range lines {
+		columns := strings.Split(line, "|")
+		if len(columns) < 6 || strings.TrimSpace(columns[2]) == "Name" {
+			continue
+		}
+		packages = append(packages, PackageInfo{
+			Name:          strings.TrimSpace(columns[2]),
+			Version:       strings.TrimSpace(columns[3]),
+			LatestVersion: strings.TrimSpace(columns[4]),
+		})
+	}
+	return packages, err
+}
+
+func (a ApkManager) ListPackages() ([]PackageInfo, error) {
+	// apk info -v prints name-version-rN per installed package
+	lines, err := packageOutput("apk", "info", "-v")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if match := apkNameVersion.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			packages = append(packages, PackageInfo{Name: match[1], Version: match[2], Installed: true})
+		}
+	}
+	return packages, err
+}
+
+func (a ApkManager) OutdatedPackages() ([]PackageInfo, error) {
+	// apk version -l '<' prints "name-1.0-r0  < 1.1-r0"
+	lines, err := packageOutput("apk", "version", "-l", "<")
+	var packages []PackageInfo
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		if match := apkNameVersion.FindStringSubmatch(fields[0]); match != nil {
+			packages = append(packages, PackageInfo{Name: match[1], Version: match[2], LatestVersion: fields[2]})
+		}
+	}
+	return packages, err
+}
+
+func (p PacmanManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("pacman", "-Q")
+	return nameVersionLines(lines), err
+}
+
+func (p PacmanManager) OutdatedPackages() ([]PackageInfo, error) {
+	// pacman -Qu prints "name 1.0-1 -> 1.1-1" against the last synced database
+	lines, err := packageOutput("pacman", "-Qu")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) >= 4 && fields[2] == "->" {
+			packages = append(packages, PackageInfo{Name: fields[0], Version: fields[1], LatestVersion: fields[3]})
+		}
+	}
+	return packages, err
+}
+
+func (b BrewManager) ListPackages() ([]PackageInfo, error) {
+	// brew list --versions prints "name 1.0 0.9", newest last
+	lines, err := packageOutput("brew", "list", "--versions")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			packages = append(packages, PackageInfo{Name: fields[0], Version: fields[len(fields)-1], Installed: true})
+		}
+	}
+	return packages, err
+}
+
+func (b BrewManager) OutdatedPackages() ([]PackageInfo, error) {
+	// brew outdated --verbose prints "name (1.0) < 1.1"
+	lines, err := packageOutput("brew", "outdated", "--verbose")
+	var packages []PackageInfo
+	for _, line := range lines {
+		name, rest, _ := strings.Cut(line, " (")
+		current, latest, ok := strings.Cut(rest, ") < ")
+		if ok {
+			packages = append(packages, PackageInfo{Name: name, Version: current, LatestVersion: latest})
+		}
+	}
+	return packages, err
+}
+
+func (c ChocoManager) ListPackages() ([]PackageInfo, error) {
+	// Chocolatey 2 lists local packages only, as "name|version"
+	lines, err := packageOutput("choco", "list", "--limit-output")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if name, version, ok := strings.Cut(line, "|"); ok {
+			packages = append(packages, PackageInfo{Name: name, Version: version, Installed: true})
+		}
+	}
+	return packages, err
+}
+
+func (c ChocoManager) OutdatedPackages() ([]PackageInfo, error) {
+	// choco outdated --limit-output prints "name|current|available|pinned"
+	lines, err := packageOutput("choco", "outdated", "--limit-output")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if columns := strings.Split(line, "|"); len(columns) >= 3 {
+			packages = append(packages, PackageInfo{Name: columns[0], Version: columns[1], LatestVersion: columns[2]})
+		}
+	}
+	return packages, err
+}
+
+func (w WingetManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("winget", "list", "--accept-source-agreements")
+	var packages []PackageInfo
+	for _, row := range wingetTable(lines) {
+		packages = append(packages, PackageInfo{Name: row["Id"], Version: row["Version"], LatestVersion: row["Available"], Description: row["Name"], Installed: true})
+	}
+	return packages, err
+}
+
+func (w WingetManager) OutdatedPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("winget", "upgrade", "--accept-source-agreements")
+	var packages []PackageInfo
+	for _, row := range wingetTable(lines) {
+		if row["Available"] != "" {
+			packages = append(packages, PackageInfo{Name: row["Id"], Version: row["Version"], LatestVersion: row["Available"], Description: row["Name"]})
+		}
+	}
+	return packages, err
+}
+
+func (s ScoopManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("scoop", "list")
+	var packages []PackageInfo
+	for _, row := range scoopTable(lines) {
+		packages = append(packages, PackageInfo{Name: row["Name"], Version: row["Version"], Description: "bucket " + row["Source"], Installed: true})
+	}
+	return packages, err
+}
+
+func (s ScoopManager) OutdatedPackages() ([]PackageInfo, error) {
+	// scoop status prints "Name  Installed Version  Latest Version ..."
+	lines, err := packageOutput("scoop", "status")
+	var packages []PackageInfo
+	for _, row := range scoopTable(lines) {
+		if row["Latest Version"] != "" {
+			packages = append(packages, PackageInfo{Name: row["Name"], Version: row["Installed Version"], LatestVersion: row["Latest Version"]})
+		}
+	}
+	return packages, err
+}
+
+func (s SnapManager) ListPackages() ([]PackageInfo, error) {
+	// snap list prints "Name  Version  Rev  Tracking  Publisher  Notes"
+	lines, err := packageOutput("snap", "list")
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+	return nameVersionLines(lines), err
+}
+
+func (s SnapManager) OutdatedPackages() ([]PackageInfo, error) {
+	// snap refresh --list prints "Name  Version  Rev  Size  Publisher  Notes"
+	lines, err := packageOutput("snap", "refresh", "--list")
+	installed := make(map[string]string)
+	if current, err := s.ListPackages(); err == nil {
+		for _, info := range current {
+			installed[info.Name] = info.Version
+		}
+	}
+	var packages []PackageInfo
+	for _, info := range nameVersionLines(lines) {
+		if version, ok := installed[info.Name]; ok {
+			packages = append(packages, PackageInfo{Name: info.Name, Version: version, LatestVersion: info.Version})
+		}
+	}
+	return packages, err
+}
+
+func (f FlatpakManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("flatpak", "list", "--app", "--columns=application,version,name")
+	return tabLines(lines), err
+}
+
+func (f FlatpakManager) OutdatedPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("flatpak", "remote-ls", "--updates", "--app", "--columns=application,version")
+	installed := make(map[string]string)
+	if current, err := f.ListPackages(); err == nil {
+		for _, info := range current {
+			installed[info.Name] = info.Version
+		}
+	}
+	var packages []PackageInfo
+	for _, info := range tabLines(lines) {
+		packages = append(packages, PackageInfo{Name: info.Name, Version: installed[info.Name], LatestVersion: info.Version})
+	}
+	return packages, err
+}
+
+// pipPackage is an entry of pip list --format=json
+type pipPackage struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	LatestVersion string `json:"latest_version"`
+}
+
+// pipList runs pip list with JSON output
+func pipList(args ...string) ([]PackageInfo, error) {
+	output, err := exec.Command("pip", append([]string{"list", "--format=json"}, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("pip list failed: %w", err)
+	}
+	var entries []pipPackage
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("invalid pip list output: %w", err)
+	}
+	packages := make([]PackageInfo, len(entries))
+	for i, entry := range entries {
+		packages[i] = PackageInfo{Name: entry.Name, Version: entry.Version, LatestVersion: entry.LatestVersion, Installed: true}
+	}
+	return packages, nil
+}
+
+func (p PipManager) ListPackages() ([]PackageInfo, error) {
+	return pipList("--user")
+}
+
+func (p PipManager) OutdatedPackages() ([]PackageInfo, error) {
+	return pipList("--user", "--outdated")
+}
+
+func (p PipxManager) ListPackages() ([]PackageInfo, error) {
+	lines, err := packageOutput("pipx", "list", "--short")
+	return nameVersionLines(lines), err
+}
+
+func (n NpmManager) ListPackages() ([]PackageInfo, error) {
+	// npm ls -g --json nests the packages under "dependencies"
+	output, err := exec.Command("npm", "ls", "-g", "--depth=0", "--json").Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("npm ls failed: %w", err)
+	}
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(output, &tree); err != nil {
+		return nil, fmt.Errorf("invalid npm ls output: %w", err)
+	}
+	var packages []PackageInfo
+	for name, dependency := range tree.Dependencies {
+		packages = append(packages, PackageInfo{Name: name, Version: dependency.Version, Installed: true})
+	}
+	return packages, nil
+}
+
+func (n NpmManager) OutdatedPackages() ([]PackageInfo, error) {
+	// npm outdated exits 1 when something is outdated
+	output, err := exec.Command("npm", "outdated", "-g", "--json").Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("npm outdated failed: %w", err)
+	}
+	var outdated map[string]struct {
+		Current string `json:"current"`
+		Latest  string `json:"latest"`
+	}
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(output, &outdated); err != nil {
+		return nil, fmt.Errorf("invalid npm outdated output: %w", err)
+	}
+	var packages []PackageInfo
+	for name, versions := range outdated {
+		packages = append(packages, PackageInfo{Name: name, Version: versions.Current, LatestVersion: versions.Latest})
+	}
+	return packages, nil
+}
+
+func (c CargoManager) ListPackages() ([]PackageInfo, error) {
+	// cargo install --list prints "ripgrep v14.1.0:" and indented binaries
+	lines, err := packageOutput("cargo", "install", "--list")
+	var packages []PackageInfo
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) >= 2 && !strings.HasPrefix(line, " ") {
+			packages = append(packages, PackageInfo{Name: fields[0], Version: strings.TrimSuffix(strings.TrimPrefix(fields[1], "v"), ":"), Installed: true})
+		}
+	}
+	return packages, err
+}
+
+func (g GemManager) ListPackages() ([]PackageInfo, error) {
+	// gem list --local prints "name (1.1, 1.0)", newest first
+	lines, err := packageOutput("gem", "list", "--local")
+	var packages []PackageInfo
+	for _, line := range lines {
+		name, versions, ok := strings.Cut(line, " (")
+		if ok {
+			version, _, _ := strings.Cut(strings.TrimSuffix(versions, ")"), ",")
+			packages = append(packages, PackageInfo{Name: name, Version: strings.TrimPrefix(version, "default: "), Installed: true})
+		}
+	}
+	return packages, err
+}
+
+func (g GemManager) OutdatedPackages() ([]PackageInfo, error) {
+	// gem outdated prints "name (1.0 < 1.1)"
+	lines, err := packageOutput("gem", "outdated")
+	var packages []PackageInfo
+	for _, line := range lines {
+		name, versions, ok := strings.Cut(line, " (")
+		current, latest, found := strings.Cut(strings.TrimSuffix(versions, ")"), " < ")
+		if ok && found {
+			packages = append(packages, PackageInfo{Name: name, Version: current, LatestVersion: latest})
+		}
+	}
+	return packages, err
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -17,6 +18,7 @@ type PackageInfo struct {
 	Version         string
 	LatestVersion   string
 	UpdateAvailable bool
+	Description     string
 }
 
 // PackageManagerHandler interface for different package managers
@@ -32,15 +34,31 @@ type PackageManagerHandler interface {
 
 type AptManager struct{}
 type BrewManager struct{}
+type PortManager struct{}
 type ChocoManager struct{}
 type WingetManager struct{}
+type ScoopManager struct{}
 type PacmanManager struct{}
-
-func (a AptManager) Name() string    { return "apt" }
-func (b BrewManager) Name() string   { return "brew" }
-func (c ChocoManager) Name() string  { return "choco" }
-func (w WingetManager) Name() string { return "winget" }
-func (p PacmanManager) Name() string { return "pacman" }
+type DnfManager struct{}
+type YumManager struct{}
+type ZypperManager struct{}
+type ApkManager struct{}
+type SnapManager struct{}
+type FlatpakManager struct{}
+
+func (a AptManager) Name() string     { return "apt" }
+func (b BrewManager) Name() string    { return "brew" }
+func (p PortManager) Name() string    { return "port" }
+func (c ChocoManager) Name() string   { return "choco" }
+func (w WingetManager) Name() string  { return "winget" }
+func (s ScoopManager) Name() string   { return "scoop" }
+func (p PacmanManager) Name() string  { return "pacman" }
+func (d DnfManager) Name() string     { return "dnf" }
+func (y YumManager) Name() string     { return "yum" }
+func (z ZypperManager) Name() string  { return "zypper" }
+func (a ApkManager) Name() string     { return "apk" }
+func (s SnapManager) Name() string    { return "snap" }
+func (f FlatpakManager) Name() string { return "flatpak" }
 
 func (a AptManager) CheckPackage(pkg string) (PackageInfo, error) {
 	info := PackageInfo{Name: pkg}
@@ -80,26 +98,13 @@ func (a AptManager) RemoveCommand(pkg string) string {
 func (b BrewManager) CheckPackage(pkg string) (PackageInfo, error) {
 	info := PackageInfo{Name: pkg}
 
-	// Check if package is installed
-	cmd := exec.Command("brew", "list", pkg)
-	err := cmd.Run()
-	info.Installed = (err == nil)
-
-	if info.Installed {
-		// Get version
-		cmd := exec.Command("brew", "info", pkg)
-		output, err := cmd.Output()
-		if err == nil {
-			lines := strings.Split(string(output), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, pkg) && strings.Contains(line, "stable") {
-					parts := strings.Fields(line)
-					if len(parts) > 0 {
-						info.Version = parts[1]
-					}
-				}
-			}
-		}
+	// brew list --versions prints "name 1.2 1.1" for an installed formula
+	// or cask, newest last, and nothing otherwise
+	cmd := exec.Command("brew", "list", "--versions", pkg)
+	output, err := cmd.Output()
+	if fields := strings.Fields(string(output)); err == nil && len(fields) >= 2 {
+		info.Installed = true
+		info.Version = fields[len(fields)-1]
 	}
 
 	return info, nil
@@ -117,6 +122,37 @@ func (b BrewManager) RemoveCommand(pkg string) string {
 	return fmt.Sprintf("brew uninstall %s", pkg)
 }
 
+func (p PortManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// port installed prints "  curl @8.5.0_0+ssl (active)" for each
+	// installed version, or that none is installed
+	lines, _ := packageOutput("port", "installed", pkg)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == pkg && strings.HasPrefix(fields[1], "@") {
+			version, _, _ := strings.Cut(strings.TrimPrefix(fields[1], "@"), "+")
+			if !info.Installed || strings.HasSuffix(line, "(active)") {
+				info.Installed, info.Version = true, version
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (p PortManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo port install %s", pkg)
+}
+
+func (p PortManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo port upgrade %s", pkg)
+}
+
+func (p PortManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo port uninstall %s", pkg)
+}
+
 func (c ChocoManager) CheckPackage(pkg string) (PackageInfo, error) {
 	info := PackageInfo{Name: pkg}
 
@@ -194,6 +230,35 @@ func (w WingetManager) RemoveCommand(pkg string) string {
 	return fmt.Sprintf("winget uninstall %s", pkg)
 }
 
+func (s ScoopManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// scoop list prints a "Name Version Source Updated Info" table of the
+	// installed apps matching the query; apps may be named bucket/app
+	app := scoopApp(pkg)
+	lines, _ := packageOutput("scoop", "list", app)
+	for _, row := range scoopTable(lines) {
+		if strings.EqualFold(row["Name"], app) {
+			info.Installed = true
+			info.Version = row["Version"]
+		}
+	}
+
+	return info, nil
+}
+
+func (s ScoopManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("scoop install %s", pkg)
+}
+
+func (s ScoopManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("scoop update %s", pkg)
+}
+
+func (s ScoopManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("scoop uninstall %s", pkg)
+}
+
 func (p PacmanManager) CheckPackage(pkg string) (PackageInfo, error) {
 	info := PackageInfo{Name: pkg}
 
@@ -233,21 +298,237 @@ func (p PacmanManager) RemoveCommand(pkg string) string {
 	return fmt.Sprintf("sudo pacman -R %s", pkg)
 }
 
-// PackageManagerFactory creates the appropriate package manager handler
+// rpmPackageInfo asks the RPM database, which dnf, yum and zypper share,
+// whether a package is installed and at which version
+func rpmPackageInfo(pkg string) PackageInfo {
+	info := PackageInfo{Name: pkg}
+
+	cmd := exec.Command("rpm", "-q", "--queryformat", "%{VERSION}-%{RELEASE}\n", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		info.Installed = true
+		// Several versions may be installed side by side, e.g. kernels
+		lines := strings.Fields(string(output))
+		if len(lines) > 0 {
+			info.Version = lines[len(lines)-1]
+		}
+	}
+
+	return info
+}
+
+func (d DnfManager) CheckPackage(pkg string) (PackageInfo, error) {
+	return rpmPackageInfo(pkg), nil
+}
+
+func (d DnfManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo dnf install %s", pkg)
+}
+
+func (d DnfManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo dnf upgrade %s", pkg)
+}
+
+func (d DnfManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo dnf remove %s", pkg)
+}
+
+func (y YumManager) CheckPackage(pkg string) (PackageInfo, error) {
+	return rpmPackageInfo(pkg), nil
+}
+
+func (y YumManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo yum install %s", pkg)
+}
+
+func (y YumManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo yum update %s", pkg)
+}
+
+func (y YumManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo yum remove %s", pkg)
+}
+
+func (z ZypperManager) CheckPackage(pkg string) (PackageInfo, error) {
+	return rpmPackageInfo(pkg), nil
+}
+
+func (z ZypperManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo zypper install %s", pkg)
+}
+
+func (z ZypperManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo zypper refresh && sudo zypper update %s", pkg)
+}
+
+func (z ZypperManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo zypper remove %s", pkg)
+}
+
+func (a ApkManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// Check if package is installed (apk info -e prints the name only then)
+	cmd := exec.Command("apk", "info", "-e", pkg)
+	if err := cmd.Run(); err != nil {
+		return info, nil
+	}
+	info.Installed = true
+
+	// Extract version from apk list output ("name-1.2.3-r0 x86_64 {origin} ... [installed]")
+	cmd = exec.Command("apk", "list", "--installed", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			parts := strings.Fields(line)
+			if len(parts) > 0 && strings.HasPrefix(parts[0], pkg+"-") {
+				info.Version = strings.TrimPrefix(parts[0], pkg+"-")
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (a ApkManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo apk add %s", pkg)
+}
+
+func (a ApkManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo apk update && sudo apk upgrade %s", pkg)
+}
+
+func (a ApkManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo apk del %s", pkg)
+}
+
+func (s SnapManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// snap list prints a header, then "name version rev tracking publisher notes"
+	cmd := exec.Command("snap", "list", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if len(lines) >= 2 {
+			parts := strings.Fields(lines[1])
+			if len(parts) >= 2 && parts[0] == pkg {
+				info.Installed = true
+				info.Version = parts[1]
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (s SnapManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("sudo snap install %s", pkg)
+}
+
+func (s SnapManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("sudo snap refresh %s", pkg)
+}
+
+func (s SnapManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("sudo snap remove %s", pkg)
+}
+
+func (f FlatpakManager) CheckPackage(pkg string) (PackageInfo, error) {
+	info := PackageInfo{Name: pkg}
+
+	// Flatpak names applications by ID, e.g. org.gimp.GIMP
+	cmd := exec.Command("flatpak", "info", pkg)
+	output, err := cmd.Output()
+	if err == nil {
+		info.Installed = true
+		info.Version = fieldValue(string(output), "Version")
+	}
+
+	return info, nil
+}
+
+func (f FlatpakManager) InstallCommand(pkg string) string {
+	return fmt.Sprintf("flatpak install %s", pkg)
+}
+
+func (f FlatpakManager) UpdateCommand(pkg string) string {
+	return fmt.Sprintf("flatpak update %s", pkg)
+}
+
+func (f FlatpakManager) RemoveCommand(pkg string) string {
+	return fmt.Sprintf("flatpak uninstall %s", pkg)
+}
+
+// PackageManagerFactory creates the appropriate package manager handler: the
+// preferred one when it is installed, otherwise the one detected for the OS
 func PackageManagerFactory(env shell.Env) PackageManagerHandler {
-	pkgMgr := shell.DetectPackageManager(env)
+	if preferredPackageManager != "" {
+		if _, err := exec.LookPath(preferredPackageManager); err == nil {
+			return PackageManagerByName(preferredPackageManager)
+		}
+	}
+	return PackageManagerByName(shell.DetectPackageManager(env).Name)
+}
+
+// SystemPackageManagerNames are the system package managers Helix has
+// handlers for, which can be the default one
+var SystemPackageManagerNames = []string{
+	"apt", "dnf", "yum", "zypper", "apk", "pacman", "snap", "flatpak", "brew", "port", "choco", "winget", "scoop",
+}
+
+// PackageManagerNames are the package managers Helix has handlers for, as
+// /install --via takes them
+var PackageManagerNames = append(append([]string{}, SystemPackageManagerNames...),
+	"pip", "pipx", "npm", "yarn", "pnpm", "cargo", "gem", "go",
+)
 
-	switch pkgMgr.Name {
+// PackageManagerByName returns the handler of a package manager, or nil
+func PackageManagerByName(name string) PackageManagerHandler {
+	switch name {
 	case "apt":
 		return AptManager{}
 	case "brew":
 		return BrewManager{}
+	case "port":
+		return PortManager{}
 	case "choco":
 		return ChocoManager{}
 	case "winget":
 		return WingetManager{}
+	case "scoop":
+		return ScoopManager{}
 	case "pacman":
 		return PacmanManager{}
+	case "dnf":
+		return DnfManager{}
+	case "yum":
+		return YumManager{}
+	case "zypper":
+		return ZypperManager{}
+	case "apk":
+		return ApkManager{}
+	case "snap":
+		return SnapManager{}
+	case "flatpak":
+		return FlatpakManager{}
+	case "pip":
+		return PipManager{}
+	case "pipx":
+		return PipxManager{}
+	case "npm":
+		return NpmManager{}
+	case "yarn":
+		return YarnManager{}
+	case "pnpm":
+		return PnpmManager{}
+	case "cargo":
+		return CargoManager{}
+	case "gem":
+		return GemManager{}
+	case "go":
+		return GoManager{}
 	default:
 		return nil
 	}
@@ -263,28 +544,58 @@ func CheckPackage(pkg string, env shell.Env) (PackageInfo, error) {
 	return pm.CheckPackage(pkg)
 }
 
-// HandlePackageCommand processes package-related commands
-func HandlePackageCommand(args []string, env shell.Env, mockMode bool, execConfig ExecuteConfig) {
+// HandlePackageCommand processes package-related commands: the action, the
+// package and optionally --via <manager>. It reports whether a package
+// command ran successfully, i.e. installed files may have changed.
+func HandlePackageCommand(args []string, env shell.Env, mockMode bool, execConfig ExecuteConfig) bool {
 	if len(args) < 2 {
-		color.Red("Usage: /install <package-name>")
+		color.Red("Usage: /install <package-name> [--via manager]")
 		color.Yellow("Also available: /update <package-name>, /remove <package-name>")
-		return
+		return false
 	}
 
 	action := args[0]
 	pkg := args[1]
+	via, err := PackageVia(args[2:])
+	if err != nil {
+		color.Red("❌ %v", err)
+		return false
+	}
 
-	pm := PackageManagerFactory(env)
-	if pm == nil {
+	pm, name, language, err := packageManagerFor(pkg, via, env)
+	switch {
+	case errors.Is(err, errNoPackageManager):
 		color.Red("❌ No supported package manager detected")
-		color.Yellow("💡 Supported: apt, brew, choco, winget, pacman")
-		return
+		color.Yellow("💡 Supported: %s", strings.Join(SystemPackageManagerNames, ", "))
+		color.Yellow("💡 Developer tools: /install <package> --via pip|pipx|npm|yarn|pnpm|cargo|gem|go")
+		return false
+	case err != nil:
+		color.Red("❌ %v", err)
+		color.Yellow("💡 --via takes: %s", strings.Join(PackageManagerNames, ", "))
+		return false
+	case language:
+		color.Cyan("📦 %s is published through %s (--via picks another manager)", pkg, pm.Name())
+		pkg = name
+	case via == "":
+		// Snap and Flatpak may offer the package too; the user picks the source
+		if managers := packageManagers(pm); len(managers) > 1 {
+			source, ok := choosePackageSource(action, pkg, managers)
+			if !ok {
+				return false
+			}
+			pm, pkg = source.Manager, source.Info.Name
+		}
 	}
 
 	color.Blue("📦 Package Manager: %s", pm.Name())
 	color.Blue("🔍 Checking package: %s", pkg)
 
-	info, err := pm.CheckPackage(pkg)
+	check := pm.CheckPackage
+	if action == "update" {
+		color.Blue("🔍 Looking up the newest version of %s", pkg)
+		check = func(pkg string) (PackageInfo, error) { return CheckPackageVersions(pm, pkg) }
+	}
+	info, err := check(pkg)
 	if err != nil {
 		color.Yellow("⚠️  Could not check package status: %v", err)
 	}
@@ -294,36 +605,62 @@ func HandlePackageCommand(args []string, env shell.Env, mockMode bool, execConfi
 
 		if action == "install" {
 			color.Yellow("💡 Package is already installed. Use '/update %s' to update.", pkg)
-			return
+			return false
+		}
+		if action == "update" && info.LatestVersion != "" {
+			if !info.UpdateAvailable {
+				color.Green("✅ %s is already the newest version (v%s)", pkg, info.Version)
+				return false
+			}
+			color.Cyan("⬆️  %s %s → %s", pkg, info.Version, info.LatestVersion)
 		}
 	} else {
 		color.Yellow("📥 %s is not installed", pkg)
 
 		if action == "update" {
 			color.Yellow("💡 Package not installed. Use '/install %s' to install it first.", pkg)
-			return
+			return false
 		}
 		if action == "remove" {
 			color.Yellow("💡 Package not installed, nothing to remove.")
-			return
+			return false
+		}
+
+		// The package may be in a bucket the manager does not have yet
+		if !addMissingBucket(pm, pkg, env, mockMode, execConfig) {
+			return false
+		}
+
+		// The package may go by another name with this manager
+		if _, available := packageAvailable(pm, pkg); !available {
+			name, ok := ResolvePackageName(pm, pkg)
+			if !ok {
+				return false
+			}
+			pkg = name
+			if info, err := pm.CheckPackage(pkg); err == nil && info.Installed {
+				color.Green("✅ %s is installed (v%s)", pkg, info.Version)
+				color.Yellow("💡 Package is already installed. Use '/update %s' to update.", pkg)
+				return false
+			}
 		}
 	}
 
-	var command string
+	command, err := packageCommand(pm, action, pkg)
 	switch action {
 	case "install":
-		command = pm.InstallCommand(pkg)
 		color.Green("🚀 Installation command: %s", command)
 	case "update":
-		command = pm.UpdateCommand(pkg)
 		color.Green("🔄 Update command: %s", command)
 	case "remove":
-		command = pm.RemoveCommand(pkg)
 		color.Yellow("🗑️  Removal command: %s", command)
+		if !PreviewRemoval(pm, pkg, mockMode) {
+			return false
+		}
 	default:
-		color.Red("❌ Unknown package action: %s", action)
+		color.Red("❌ %v", err)
 		color.Yellow("💡 Available actions: install, update, remove")
-		return
+		return false
 	}
 
 	if !mockMode {
@@ -334,24 +671,51 @@ func HandlePackageCommand(args []string, env shell.Env, mockMode bool, execConfi
 
 		if AskForConfirmation("Execute this command?") {
 			err := ExecuteCommand(command, execConfig, env)
+			recordPackageChange(pm, action, pkg, info.Version, command, err)
 			if err != nil {
 				color.Red("❌ Command failed: %v", err)
 			} else {
 				color.Green("✅ Command completed successfully!")
+				switch action {
+				case "install":
+					VerifyInstall(pm, pkg, env)
+				case "update":
+					color.Yellow("💡 If the new version breaks something: /pkg rollback %s", pkg)
+				}
+				return true
 			}
 		} else {
 			color.Yellow("💡 Command cancelled. You can run it manually:")
 			color.Cyan("  %s", command)
 		}
 	}
+	return false
+}
+
+// PackageVia reads the --via <manager> (or --via=manager) option; --manager
+// is the same option
+func PackageVia(args []string) (string, error) {
+	via := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case (args[i] == "--via" || args[i] == "--manager") && i+1 < len(args):
+			via = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--via="), strings.HasPrefix(args[i], "--manager="):
+			_, via, _ = strings.Cut(args[i], "=")
+		default:
+			return "", fmt.Errorf("unexpected argument %s (usage: <package> [--via manager])", args[i])
+		}
+	}
+	return strings.ToLower(via), nil
 }
 
 // requiresSudo checks if the package manager typically requires sudo
 func requiresSudo(pmName string) bool {
 	switch pmName {
-	case "apt", "pacman":
+	case "apt", "pacman", "dnf", "yum", "zypper", "apk", "snap", "port":
 		return true
-	case "brew", "choco", "winget":
+	case "brew", "choco", "winget", "scoop", "flatpak", "pip", "pipx", "npm", "yarn", "pnpm", "cargo", "gem", "go":
 		return false
 	default:
 		return true
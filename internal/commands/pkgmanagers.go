@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// preferredPackageManager is the system package manager the user picked
+// over the detected one, e.g. port on a Mac that also has brew
+var preferredPackageManager string
+
+// SetPreferredPackageManager makes a package manager the default one while
+// it is installed, or goes back to detection with ""
+func SetPreferredPackageManager(name string) {
+	preferredPackageManager = strings.ToLower(name)
+}
+
+// PreferredPackageManager returns the package manager set as the default
+// one, or ""
+func PreferredPackageManager() string {
+	return preferredPackageManager
+}
+
+// IsSystemPackageManager reports whether Helix can use a package manager as
+// the default one
+func IsSystemPackageManager(name string) bool {
+	for _, system := range SystemPackageManagerNames {
+		if system == name {
+			return true
+		}
+	}
+	return false
+}
+
+// InstalledPackageManager is a package manager found on the PATH
+type InstalledPackageManager struct {
+	Name    string
+	Version string
+	Path    string
+}
+
+// managerVersionArgs print the version of tools that do not take --version
+var managerVersionArgs = map[string][]string{
+	"port": {"version"},
+	"go":   {"version"},
+}
+
+// managerVersionPattern finds the version in what a tool prints, e.g. 2.7.14
+// in "apt 2.7.14 (amd64)" or "Homebrew 4.3.5"
+var managerVersionPattern = regexp.MustCompile(`\d+(\.\d+)+[0-9A-Za-z.+~-]*`)
+
+// InstalledPackageManagers returns the package managers Helix has handlers
+// for that are installed, in the order of PackageManagerNames, with their
+// versions
+func InstalledPackageManagers() []InstalledPackageManager {
+	var installed []InstalledPackageManager
+	for _, name := range PackageManagerNames {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		installed = append(installed, InstalledPackageManager{Name: name, Version: managerVersion(name), Path: path})
+	}
+	return installed
+}
+
+// managerVersion returns the version a package manager reports, or ""
+func managerVersion(name string) string {
+	args, ok := managerVersionArgs[name]
+	if !ok {
+		args = []string{"--version"}
+	}
+	lines, _ := packageOutput(name, args...)
+	for _, line := range lines {
+		if version := managerVersionPattern.FindString(line); version != "" {
+			return version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"helix/internal/shell"
+	"helix/internal/utils"
+	"helix/internal/ux"
+
+	"github.com/fatih/color"
+)
+
+// ManifestItem is a package listed in an install manifest
+type ManifestItem struct {
+	Name string // the name to check, without version pins
+	Spec string // what to install, e.g. requests>=2.31 for pip
+	Via  string // the manager named by the manifest, or ""
+	Line int
+}
+
+// ManifestResult is what happened to a manifest item
+type ManifestResult struct {
+	Item    ManifestItem
+	Manager string
+	Status  string // installed, present, done earlier, failed, skipped or planned
+	Detail  string
+}
+
+// manifestProgress records the items a manifest installed, so a run that
+// stopped at a failure resumes after what already succeeded
+type manifestProgress struct {
+	Manifest string   `json:"manifest"`
+	Done     []string `json:"done"`
+}
+
+// requirementName is the distribution name at the start of a pip
+// requirement, before extras, version pins and markers
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// brewfileEntry matches the Brewfile entries Helix installs: brew "x" and
+// cask "x"
+var brewfileEntry = regexp.MustCompile(`^(brew|cask|tap|mas|vscode|whalebrew)\s+"([^"]+)"`)
+
+// ParseManifest reads the packages of a manifest. A Brewfile installs with
+// brew and requirements*.txt with pip; any other file lists one package per
+// line as /install takes it, "<package> [--via manager]". # starts a comment.
+func ParseManifest(path string) ([]ManifestItem, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	base := strings.ToLower(filepath.Base(path))
+	brewfile := base == "brewfile" || strings.HasSuffix(base, ".brewfile")
+	requirements := strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+
+	var items []ManifestItem
+	var warnings []string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case brewfile:
+			match := brewfileEntry.FindStringSubmatch(line)
+			if match == nil || (match[1] != "brew" && match[1] != "cask") {
+				warnings = append(warnings, fmt.Sprintf("line %d: skipping unsupported Brewfile entry: %s", n, line))
+				continue
+			}
+			items = append(items, ManifestItem{Name: match[2], Spec: match[2], Via: "brew", Line: n})
+		case requirements:
+			name := requirementName.FindString(line)
+			if strings.HasPrefix(line, "-") || name == "" {
+				warnings = append(warnings, fmt.Sprintf("line %d: skipping pip option: %s", n, line))
+				continue
+			}
+			spec, _, _ := strings.Cut(line, ";")
+			items = append(items, ManifestItem{Name: name, Spec: strings.TrimSpace(spec), Via: "pip", Line: n})
+		default:
+			fields := strings.Fields(line)
+			via, err := PackageVia(fields[1:])
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("line %d: %v", n, err))
+				continue
+			}
+			items = append(items, ManifestItem{Name: fields[0], Spec: fields[0], Via: via, Line: n})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, warnings, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return items, warnings, nil
+}
+
+// ManifestProgressPath returns the file in dir that records the progress
+// of a manifest, named after its absolute path
+func ManifestProgressPath(dir, manifest string) string {
+	if abs, err := filepath.Abs(manifest); err == nil {
+		manifest = abs
+	}
+	sum := sha256.Sum256([]byte(manifest))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadManifestProgress reads the items an earlier run installed
+func loadManifestProgress(path string) map[string]bool {
+	done := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return done
+	}
+	var progress manifestProgress
+	if json.Unmarshal(data, &progress) == nil {
+		for _, key := range progress.Done {
+			done[key] = true
+		}
+	}
+	return done
+}
+
+// saveManifestProgress records the items installed so far
+func saveManifestProgress(path, manifest string, done []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifestProgress{Manifest: manifest, Done: done}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// manifestKey identifies an item in the progress file
+func manifestKey(manager string, item ManifestItem) string {
+	return manager + ":" + item.Spec
+}
+
+// InstallManifest installs every package of a manifest: it shows the plan,
+// asks once, then installs the missing packages one by one, going on after
+// failures. Packages installed by an earlier run of the same manifest are
+// skipped; progressPath records them until every package succeeds. via
+// names the manager for items that do not name one.
+func InstallManifest(path, progressPath, via string, env shell.Env, mockMode bool, execConfig ExecuteConfig) []ManifestResult {
+	items, warnings, err := ParseManifest(path)
+	for _, warning := range warnings {
+		color.Yellow("⚠️  %s", warning)
+	}
+	if err != nil {
+		color.Red("❌ %v", err)
+		return nil
+	}
+	if len(items) == 0 {
+		color.Yellow("⚠️  No packages in %s", path)
+		return nil
+	}
+
+	system := PackageManagerFactory(env)
+	done := loadManifestProgress(progressPath)
+	if len(done) > 0 {
+		color.Cyan("🔁 Resuming %s: %d package(s) were installed by an earlier run", path, len(done))
+	}
+
+	color.Blue("📋 Checking %d package(s) from %s", len(items), path)
+	results := make([]ManifestResult, len(items))
+	var managers []PackageManagerHandler
+	pending := 0
+	for i, item := range items {
+		results[i] = ManifestResult{Item: item, Status: "planned"}
+		name := item.Via
+		if name == "" {
+			name = via
+		}
+		var pm PackageManagerHandler
+		switch {
+		case name != "":
+			pm = PackageManagerByName(name)
+		default:
+			if manager, pkg := LanguageManagerFor(item.Name); manager != nil {
+				pm, results[i].Item.Name, results[i].Item.Spec = manager, pkg, pkg
+			} else {
+				pm = system
+			}
+		}
+		managers = append(managers, pm)
+		if pm == nil {
+			results[i].Status, results[i].Detail = "skipped", "no package manager detected"
+			if name != "" {
+				results[i].Detail = "unknown package manager " + name
+			}
+			continue
+		}
+		results[i].Manager = pm.Name()
+
+		if done[manifestKey(pm.Name(), results[i].Item)] {
+			results[i].Status = "done earlier"
+			continue
+		}
+		if info, err := pm.CheckPackage(results[i].Item.Name); err == nil && info.Installed {
+			results[i].Status, results[i].Detail = "present", info.Version
+			continue
+		}
+		results[i].Detail = withPackageSources(pm.Name(), "install", pm.InstallCommand(QuoteArgument(results[i].Item.Spec, env)))
+		pending++
+	}
+	PrintManifestResults(results)
+
+	if pending == 0 {
+		color.Green("✅ Everything in %s is installed", path)
+		os.Remove(progressPath)
+		return results
+	}
+	if mockMode {
+		color.Yellow("💡 Mock mode: the %d install command(s) above were not run", pending)
+		return results
+	}
+	if !AskForConfirmation(fmt.Sprintf("Install %d package(s)?", pending)) {
+		color.Yellow("💡 Cancelled, nothing installed")
+		return results
+	}
+
+	var finished []string
+	for key := range done {
+		finished = append(finished, key)
+	}
+	step := 0
+	for i := range results {
+		if results[i].Status != "planned" {
+			continue
+		}
+		step++
+		pm := managers[i]
+		command := results[i].Detail
+		color.Blue("[%d/%d] 📦 %s via %s", step, pending, results[i].Item.Spec, pm.Name())
+		if requiresSudo(pm.Name()) {
+			color.Yellow("⚠️  This command may require administrator privileges")
+		}
+		err := ExecuteCommand(command, execConfig, env)
+		recordPackageChange(pm, "install", results[i].Item.Name, "", command, err)
+		if err != nil {
+			results[i].Status, results[i].Detail = "failed", err.Error()
+			color.Red("❌ %s failed: %v", results[i].Item.Spec, err)
+			continue
+		}
+		results[i].Status = "installed"
+		color.Green("✅ %s installed", results[i].Item.Spec)
+		finished = append(finished, manifestKey(pm.Name(), results[i].Item))
+		if err := saveManifestProgress(progressPath, path, finished); err != nil {
+			color.Yellow("⚠️  Could not save progress: %v", err)
+		}
+	}
+
+	summarizeManifest(path, progressPath, results)
+	return results
+}
+
+// PrintManifestResults shows the status of every manifest item
+func PrintManifestResults(results []ManifestResult) {
+	var rows [][]string
+	for i, result := range results {
+		rows = append(rows, []string{fmt.Sprintf("%d", i+1), result.Item.Spec, result.Manager, result.Status, utils.TruncateString(result.Detail, 60)})
+	}
+	ux.NewUX().PrintTable([]string{"#", "Package", "Manager", "Status", "Detail"}, rows)
+}
+
+// summarizeManifest prints the final report of a manifest run and forgets
+// its progress once nothing failed
+func summarizeManifest(path, progressPath string, results []ManifestResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	color.Cyan("📊 Summary for %s:", path)
+	PrintManifestResults(results)
+	color.Green("  ✅ %d installed, %d already present, %d done earlier", counts["installed"], counts["present"], counts["done earlier"])
+	if counts["skipped"] > 0 {
+		color.Yellow("  ⏭️  %d skipped", counts["skipped"])
+	}
+	if counts["failed"] > 0 {
+		color.Red("  ❌ %d failed", counts["failed"])
+		color.Yellow("💡 Run /install -f %s again to retry; installed packages are skipped", path)
+		return
+	}
+	os.Remove(progressPath)
+}
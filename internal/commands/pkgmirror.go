@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// PackageSourceConfig holds where package commands download from: the HTTP
+// proxies to go through and a local mirror or cache per package manager,
+// for corporate networks and air-gapped machines
+type PackageSourceConfig struct {
+	HTTPProxy  string `json:"http_proxy,omitempty"`
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	NoProxy    string `json:"no_proxy,omitempty"`
+
+	// Mirrors maps a package manager to the URL of its mirror, e.g.
+	// "pip": "https://pypi.corp.example/simple"; for winget it names a source
+	Mirrors map[string]string `json:"mirrors,omitempty"`
+}
+
+// downloadSources is set from the config at startup
+var downloadSources PackageSourceConfig
+
+// proxyVariables are the environment variables package managers take their
+// proxy from; curl and apt only read the lowercase ones
+var proxyVariables = []string{"http_proxy", "https_proxy", "no_proxy", "all_proxy", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY"}
+
+// mirrorVariables are the managers that take their mirror from an
+// environment variable rather than an option
+var mirrorVariables = map[string][]string{
+	"go":   {"GOPROXY"},
+	"brew": {"HOMEBREW_ARTIFACT_DOMAIN"},
+}
+
+// SetPackageSources applies the proxy and mirror settings: configured
+// proxies go into the environment, where every command Helix runs finds
+// them, and the mirrors into the package commands Helix generates
+func SetPackageSources(sources PackageSourceConfig) {
+	downloadSources = sources
+	for _, proxy := range []struct{ value, lower, upper string }{
+		{sources.HTTPProxy, "http_proxy", "HTTP_PROXY"},
+		{sources.HTTPSProxy, "https_proxy", "HTTPS_PROXY"},
+		{sources.NoProxy, "no_proxy", "NO_PROXY"},
+	} {
+		if proxy.value != "" {
+			os.Setenv(proxy.lower, proxy.value)
+			os.Setenv(proxy.upper, proxy.value)
+		}
+	}
+	for manager, variables := range mirrorVariables {
+		if mirror := PackageMirror(manager); mirror != "" {
+			for _, variable := range variables {
+				os.Setenv(variable, mirror)
+			}
+		}
+	}
+}
+
+// PackageMirror returns the mirror configured for a package manager, or ""
+func PackageMirror(manager string) string {
+	return strings.TrimSpace(downloadSources.Mirrors[manager])
+}
+
+// PackageProxy returns the proxy package downloads go through, from the
+// config or the environment, or ""
+func PackageProxy() string {
+	for _, variable := range []string{"https_proxy", "HTTPS_PROXY", "http_proxy", "HTTP_PROXY", "all_proxy", "ALL_PROXY"} {
+		if value := os.Getenv(variable); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// MirrorSupported reports whether Helix can point a package manager at a
+// mirror; the others take theirs from the system configuration, e.g.
+// /etc/apt/sources.list
+func MirrorSupported(manager string) bool {
+	_, option := mirrorOptions[manager]
+	_, variable := mirrorVariables[manager]
+	return option || variable
+}
+
+// mirrorOptions return the options that make a manager download from a
+// mirror
+var mirrorOptions = map[string]func(mirror string) string{
+	"pip": func(mirror string) string {
+		options := "--index-url " + mirror
+		// pip refuses plain HTTP indexes it is not told to trust
+		if u, err := url.Parse(mirror); err == nil && u.Scheme == "http" {
+			options += " --trusted-host " + u.Hostname()
+		}
+		return options
+	},
+	"pipx":   func(mirror string) string { return fmt.Sprintf("--pip-args='--index-url %s'", mirror) },
+	"npm":    func(mirror string) string { return "--registry " + mirror },
+	"yarn":   func(mirror string) string { return "--registry " + mirror },
+	"pnpm":   func(mirror string) string { return "--registry " + mirror },
+	"gem":    func(mirror string) string { return "--clear-sources --source " + mirror },
+	"cargo":  func(mirror string) string { return "--index " + mirror },
+	"choco":  func(mirror string) string { return "--source=" + mirror },
+	"winget": func(mirror string) string { return "--source " + mirror },
+	"apk":    func(mirror string) string { return "--repository " + mirror },
+	"dnf": func(mirror string) string {
+		return fmt.Sprintf("--repofrompath=helix-mirror,%s --repo=helix-mirror", mirror)
+	},
+}
+
+// withPackageSources adds the configured mirror to a command that installs
+// or updates packages, and lets sudo pass the proxy on: sudo drops the
+// caller's environment, and with it the proxy settings
+func withPackageSources(manager, action, command string) string {
+	if action != "remove" {
+		if options, ok := mirrorOptions[manager]; ok && PackageMirror(manager) != "" {
+			command += " " + options(PackageMirror(manager))
+		}
+		if manager == "choco" && PackageProxy() != "" {
+			command += " --proxy=" + PackageProxy()
+		}
+	}
+
+	if !strings.HasPrefix(command, "sudo ") {
+		return command
+	}
+	var preserved []string
+	for _, variable := range proxyVariables {
+		if os.Getenv(variable) != "" {
+			preserved = append(preserved, variable)
+		}
+	}
+	if len(preserved) == 0 {
+		return command
+	}
+	return "sudo --preserve-env=" + strings.Join(preserved, ",") + strings.TrimPrefix(command, "sudo")
+}
+
+// packageCommand returns the command that performs an action, going through
+// the configured proxy and mirror
+func packageCommand(pm PackageManagerHandler, action, pkg string) (string, error) {
+	var command string
+	switch action {
+	case "install":
+		command = pm.InstallCommand(pkg)
+	case "update":
+		command = pm.UpdateCommand(pkg)
+	case "remove":
+		command = pm.RemoveCommand(pkg)
+	default:
+		return "", fmt.Errorf("unknown package action: %s (install, update or remove)", action)
+	}
+	return withPackageSources(pm.Name(), action, command), nil
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"helix/internal/shell"
+)
+
+// Package operation statuses
+const (
+	PackageStatusPlanned   = "planned"   // the command was not run (dry run)
+	PackageStatusSucceeded = "succeeded" // the command ran and exited 0
+	PackageStatusFailed    = "failed"    // the command exited non-zero
+	PackageStatusUnchanged = "unchanged" // already installed, newest or absent
+	PackageStatusNotFound  = "not-found" // the manager does not offer the package
+	PackageStatusError     = "error"     // no manager, or the command could not run
+)
+
+// PackageOperationResult is the outcome of installing, updating or removing
+// a package without a human: what ran and the versions before and after
+type PackageOperationResult struct {
+	Action        string        `json:"action"`
+	Package       string        `json:"package"`
+	Manager       string        `json:"manager,omitempty"`
+	Command       string        `json:"command,omitempty"`
+	Status        string        `json:"status"`
+	Success       bool          `json:"success"`
+	Message       string        `json:"message,omitempty"`
+	VersionBefore string        `json:"version_before,omitempty"`
+	VersionAfter  string        `json:"version_after,omitempty"`
+	LatestVersion string        `json:"latest_version,omitempty"`
+	Candidates    []string      `json:"candidates,omitempty"` // names the manager offers instead
+	Dependents    []string      `json:"dependents,omitempty"` // installed packages needing the one removed
+	Essential     []string      `json:"essential,omitempty"`  // of the package and its dependents
+	ExitCode      int           `json:"exit_code"`
+	Stdout        string        `json:"stdout,omitempty"`
+	Stderr        string        `json:"stderr,omitempty"`
+	Duration      time.Duration `json:"duration,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// errNoPackageManager is returned when no supported manager is installed
+var errNoPackageManager = errors.New("no supported package manager detected")
+
+// packageManagerFor picks the manager for a package: the one via names, the
+// language tool publishing the package, or the system's. It returns the
+// package name that manager knows and whether a language tool was picked.
+func packageManagerFor(pkg, via string, env shell.Env) (PackageManagerHandler, string, bool, error) {
+	if via != "" {
+		if pm := PackageManagerByName(via); pm != nil {
+			return pm, pkg, false, nil
+		}
+		return nil, pkg, false, fmt.Errorf("unknown package manager: %s", via)
+	}
+	if manager, name := LanguageManagerFor(pkg); manager != nil {
+		return manager, name, true, nil
+	}
+	if pm := PackageManagerFactory(env); pm != nil {
+		return pm, pkg, false, nil
+	}
+	return nil, pkg, false, errNoPackageManager
+}
+
+// PlanPackageOperation decides what installing, updating or removing a
+// package takes, without running anything or asking: the manager, the
+// command, and the installed and newest versions. The result is planned
+// when there is a command to run.
+func PlanPackageOperation(action, pkg, via string, env shell.Env) PackageOperationResult {
+	result := PackageOperationResult{Action: action, Package: pkg, Status: PackageStatusError}
+	pm, name, _, err := packageManagerFor(pkg, via, env)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Manager, result.Package = pm.Name(), name
+
+	command, err := packageCommand(pm, action, name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	check := pm.CheckPackage
+	if action == "update" {
+		check = func(pkg string) (PackageInfo, error) { return CheckPackageVersions(pm, pkg) }
+	}
+	info, err := check(name)
+	if err != nil {
+		result.Error = fmt.Sprintf("could not check package status: %v", err)
+		return result
+	}
+	result.VersionBefore, result.LatestVersion = info.Version, info.LatestVersion
+
+	result.Status, result.Success = PackageStatusUnchanged, true
+	switch {
+	case action == "install" && info.Installed:
+		result.Message = "already installed"
+		return result
+	case action == "update" && !info.Installed:
+		result.Status, result.Success, result.Error = PackageStatusError, false, "not installed"
+		return result
+	case action == "update" && info.LatestVersion != "" && !info.UpdateAvailable:
+		result.Message = "already the newest version"
+		return result
+	case action == "remove" && !info.Installed:
+		result.Message = "not installed"
+		return result
+	}
+
+	if action == "install" {
+		if _, available := packageAvailable(pm, name); !available {
+			result.Status, result.Success, result.Message = PackageStatusNotFound, false, fmt.Sprintf("%s does not offer %s", pm.Name(), name)
+			if bucket, command, missing := missingBucket(pm, name); missing {
+				result.Message += fmt.Sprintf(" without the %s bucket (%s)", bucket, command)
+			}
+			for _, candidate := range packageNameCandidates(pm, name) {
+				result.Candidates = append(result.Candidates, candidate.Name)
+			}
+			return result
+		}
+	}
+
+	if action == "remove" {
+		result.Dependents, _ = InstalledDependents(pm, name)
+		result.Essential = importantPackages(pm, append([]string{name}, result.Dependents...))
+	}
+
+	result.Command, result.Status, result.Success = command, PackageStatusPlanned, true
+	return result
+}
+
+// RunPackageOperation installs, updates or removes a package without
+// prompting or printing, and reports what happened. Callers decide
+// beforehand whether the command may run; with dryRun it is only planned.
+func RunPackageOperation(action, pkg, via string, env shell.Env, execConfig ExecuteConfig, dryRun bool) PackageOperationResult {
+	result := PlanPackageOperation(action, pkg, via, env)
+	if result.Status != PackageStatusPlanned || dryRun {
+		return result
+	}
+	return ExecutePackageOperation(result, env, execConfig)
+}
+
+// ExecutePackageOperation runs the command of a planned operation, e.g. once
+// a human confirmed it, and reads the version it left installed
+func ExecutePackageOperation(result PackageOperationResult, env shell.Env, execConfig ExecuteConfig) PackageOperationResult {
+	if result.Status != PackageStatusPlanned {
+		return result
+	}
+
+	run, err := RunCommandCapture(result.Command, execConfig, env)
+	if run.Command != "" {
+		result.Command = run.Command
+	}
+	result.ExitCode, result.Stdout, result.Stderr, result.Duration = run.ExitCode, run.Stdout, run.Stderr, run.Duration
+	defer func() { logPackageEntries(result.ledgerEntry()) }()
+	switch {
+	case err != nil:
+		result.Status, result.Success, result.Error = PackageStatusError, false, err.Error()
+		return result
+	case run.ExitCode != 0:
+		result.Status, result.Success = PackageStatusFailed, false
+		return result
+	}
+	result.Status, result.Success = PackageStatusSucceeded, true
+
+	if pm := PackageManagerByName(result.Manager); pm != nil {
+		if info, err := pm.CheckPackage(result.Package); err == nil && info.Installed {
+			result.VersionAfter = info.Version
+		}
+	}
+	return result
+}
+
+// ledgerEntry is the package ledger entry of an operation that ran
+func (r PackageOperationResult) ledgerEntry() PackageLedgerEntry {
+	return PackageLedgerEntry{
+		Time:          time.Now(),
+		Action:        r.Action,
+		Package:       r.Package,
+		Manager:       r.Manager,
+		VersionBefore: r.VersionBefore,
+		VersionAfter:  r.VersionAfter,
+		Command:       r.Command,
+		Success:       r.Success,
+		Error:         r.Error,
+	}
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PackageNameAdvisor suggests the names a package goes by with a manager,
+// given what searching the manager found; Helix asks the model, with the
+// documentation the RAG index holds about the package
+type PackageNameAdvisor func(pkg, manager string, found []PackageInfo) []string
+
+// packageNameAdvisor is set from main when a model is loaded
+var packageNameAdvisor PackageNameAdvisor
+
+// SetPackageNameAdvisor sets how package names are suggested, or turns the
+// suggestions off with nil
+func SetPackageNameAdvisor(advisor PackageNameAdvisor) {
+	packageNameAdvisor = advisor
+}
+
+// maxNameCandidates is how many names the pick list offers
+const maxNameCandidates = 6
+
+// packageAliases are the names well-known packages go by with each
+// manager, where they differ from what people type
+var packageAliases = map[string]map[string]string{
+	"node":        {"apt": "nodejs", "dnf": "nodejs", "yum": "nodejs", "zypper": "nodejs", "apk": "nodejs", "pacman": "nodejs", "choco": "nodejs", "winget": "OpenJS.NodeJS", "scoop": "nodejs"},
+	"python":      {"apt": "python3", "dnf": "python3", "yum": "python3", "zypper": "python3", "apk": "python3", "brew": "python@3", "winget": "Python.Python.3.12"},
+	"pip":         {"apt": "python3-pip", "dnf": "python3-pip", "yum": "python3-pip", "zypper": "python3-pip", "apk": "py3-pip", "pacman": "python-pip"},
+	"fd":          {"apt": "fd-find", "dnf": "fd-find", "yum": "fd-find"},
+	"ag":          {"apt": "silversearcher-ag", "dnf": "the_silver_searcher", "yum": "the_silver_searcher", "pacman": "the_silver_searcher", "brew": "the_silver_searcher", "apk": "the_silver_searcher"},
+	"imagemagick": {"dnf": "ImageMagick", "yum": "ImageMagick", "zypper": "ImageMagick"},
+	"docker":      {"apt": "docker.io", "dnf": "moby-engine", "zypper": "docker", "winget": "Docker.DockerDesktop"},
+	"gcc":         {"apt": "build-essential", "pacman": "base-devel"},
+	"rg":          {"apt": "ripgrep", "dnf": "ripgrep", "yum": "ripgrep", "zypper": "ripgrep", "apk": "ripgrep", "pacman": "ripgrep", "brew": "ripgrep", "choco": "ripgrep", "winget": "BurntSushi.ripgrep.MSVC", "scoop": "ripgrep"},
+	"vscode":      {"snap": "code", "brew": "visual-studio-code", "choco": "vscode", "winget": "Microsoft.VisualStudioCode", "pacman": "code"},
+}
+
+// packageNamePattern is what a suggested package name may look like
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z0-9@][A-Za-z0-9+._@/:-]*$`)
+
+// packageAvailable reports whether a manager offers a package. Managers
+// that cannot tell are assumed to.
+func packageAvailable(pm PackageManagerHandler, pkg string) (PackageInfo, bool) {
+	finder, ok := pm.(PackageFinder)
+	if !ok {
+		return PackageInfo{Name: pkg}, true
+	}
+	return finder.FindPackage(pkg)
+}
+
+// asciiPrefix returns the start of a name up to its first character that is
+// not ASCII, as a mistyped keyboard layout leaves it
+func asciiPrefix(pkg string) string {
+	for i, r := range pkg {
+		if r > 127 {
+			return pkg[:i]
+		}
+	}
+	return pkg
+}
+
+// packageNameCandidates gathers the names a package may go by: the known
+// alias, the manager's search results and the advisor's suggestions that
+// the manager offers
+func packageNameCandidates(pm PackageManagerHandler, pkg string) []PackageInfo {
+	var candidates []PackageInfo
+	seen := map[string]bool{strings.ToLower(pkg): true}
+	add := func(info PackageInfo) {
+		if key := strings.ToLower(info.Name); !seen[key] && len(candidates) < maxNameCandidates {
+			seen[key] = true
+			candidates = append(candidates, info)
+		}
+	}
+
+	if alias, ok := packageAliases[strings.ToLower(pkg)][pm.Name()]; ok {
+		if info, available := packageAvailable(pm, alias); available {
+			info.Name = alias
+			add(info)
+		}
+	}
+
+	query := pkg
+	if prefix := asciiPrefix(pkg); prefix != pkg && len(prefix) >= 3 {
+		query = prefix
+	}
+	found, err := SearchPackages(pm, query)
+	if err != nil {
+		found = nil
+	}
+
+	if packageNameAdvisor != nil {
+		color.Blue("🤖 Asking the model what %s is called with %s", pkg, pm.Name())
+		for _, name := range packageNameAdvisor(pkg, pm.Name(), found) {
+			if !packageNamePattern.MatchString(name) || seen[strings.ToLower(name)] {
+				continue
+			}
+			if info, available := packageAvailable(pm, name); available {
+				info.Name = name
+				if info.Description == "" {
+					info.Description = "suggested by the model"
+				}
+				add(info)
+			}
+		}
+	}
+
+	for _, info := range found {
+		add(info)
+	}
+	return candidates
+}
+
+// ResolvePackageName finds the name a package goes by with a manager when
+// the manager does not offer it under the name given, e.g. nodejs for node
+// with apt. The user picks among the candidates; it returns false when
+// there are none or the user cancels.
+func ResolvePackageName(pm PackageManagerHandler, pkg string) (string, bool) {
+	color.Yellow("⚠️  %s does not offer a package named %s", pm.Name(), pkg)
+	candidates := packageNameCandidates(pm, pkg)
+	if len(candidates) == 0 {
+		color.Red("❌ No %s package resembles %s", pm.Name(), pkg)
+		color.Yellow("💡 Try /pkg search <keyword> or another manager with --via")
+		return "", false
+	}
+
+	color.Cyan("📦 %s may go by another name with %s:", pkg, pm.Name())
+	for i, info := range candidates {
+		line := fmt.Sprintf("  %d) %s", i+1, info.Name)
+		if info.LatestVersion != "" {
+			line += fmt.Sprintf(" (v%s)", info.LatestVersion)
+		}
+		if info.Description != "" {
+			line += " - " + info.Description
+		}
+		fmt.Println(line)
+	}
+
+	question := fmt.Sprintf("Which package should be installed instead of %s?", pkg)
+	var response string
+	fmt.Printf("%s [1-%d, Enter for 1, n to cancel]: ", question, len(candidates))
+	fmt.Scanln(&response)
+
+	response = strings.TrimSpace(response)
+	choice := 1
+	if response != "" {
+		n, err := strconv.Atoi(response)
+		if err != nil || n < 1 || n > len(candidates) {
+			recordDecision(question, false)
+			color.Yellow("❌ Cancelled")
+			return "", false
+		}
+		choice = n
+	}
+	recordDecision(question, true)
+	return candidates[choice-1].Name, true
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// RollbackHint is a way to undo a package operation: a command to run, or
+// a note on what else may help
+type RollbackHint struct {
+	Command string
+	Note    string
+}
+
+// Runnable reports whether the hint is a complete command
+func (h RollbackHint) Runnable() bool {
+	return h.Command != "" && !strings.Contains(h.Command, "<")
+}
+
+// versionedInstall returns the command installing a given version of a
+// package, going back to it when downgrade is set, or "" when the manager
+// cannot
+func versionedInstall(manager, pkg, version string, downgrade bool) string {
+	switch manager {
+	case "apt":
+		if downgrade {
+			return fmt.Sprintf("sudo apt install --allow-downgrades %s=%s", pkg, version)
+		}
+		return fmt.Sprintf("sudo apt install %s=%s", pkg, version)
+	case "dnf", "yum":
+		if downgrade {
+			return fmt.Sprintf("sudo %s downgrade %s-%s", manager, pkg, version)
+		}
+		return fmt.Sprintf("sudo %s install %s-%s", manager, pkg, version)
+	case "zypper":
+		return fmt.Sprintf("sudo zypper install --oldpackage %s=%s", pkg, version)
+	case "pacman":
+		// The package cache keeps the versions installed before
+		return fmt.Sprintf("sudo pacman -U /var/cache/pacman/pkg/%s-%s-*.pkg.tar.zst", pkg, version)
+	case "apk":
+		return fmt.Sprintf("sudo apk add %s=%s", pkg, version)
+	case "port":
+		if downgrade {
+			// MacPorts keeps the replaced version, inactive
+			return fmt.Sprintf("sudo port activate %s @%s", pkg, version)
+		}
+	case "choco":
+		return fmt.Sprintf("choco install %s --version %s --allow-downgrade -y", pkg, version)
+	case "winget":
+		return fmt.Sprintf("winget install --id %s --version %s --force", pkg, version)
+	case "scoop":
+		if downgrade {
+			// Scoop keeps the replaced version until scoop cleanup
+			return fmt.Sprintf("scoop reset %s@%s", pkg, version)
+		}
+		return fmt.Sprintf("scoop install %s@%s", pkg, version)
+	case "pip":
+		return fmt.Sprintf("pip install --user %s==%s", pkg, version)
+	case "pipx":
+		return fmt.Sprintf("pipx install --force %s==%s", pkg, version)
+	case "npm":
+		return fmt.Sprintf("npm install -g %s@%s", pkg, version)
+	case "yarn":
+		return fmt.Sprintf("yarn global add %s@%s", pkg, version)
+	case "pnpm":
+		return fmt.Sprintf("pnpm add -g %s@%s", pkg, version)
+	case "cargo":
+		return fmt.Sprintf("cargo install %s --version %s --force", pkg, version)
+	case "gem":
+		return fmt.Sprintf("gem install %s -v %s", pkg, version)
+	case "go":
+		path, _, _ := strings.Cut(pkg, "@")
+		return fmt.Sprintf("go install %s@v%s", path, strings.TrimPrefix(version, "v"))
+	}
+	return ""
+}
+
+// holdHints keep a package at its version once it is rolled back
+var holdHints = map[string]string{
+	"apt":    "sudo apt-mark hold %s keeps apt from upgrading it again (apt-mark unhold to undo)",
+	"dnf":    "sudo dnf versionlock add %s keeps dnf from upgrading it again (needs the versionlock plugin)",
+	"yum":    "sudo yum versionlock add %s keeps yum from upgrading it again (needs the versionlock plugin)",
+	"zypper": "sudo zypper addlock %s keeps zypper from upgrading it again",
+	"pacman": "add %s to IgnorePkg in /etc/pacman.conf to keep pacman from upgrading it again",
+	"brew":   "brew pin %s keeps brew from upgrading it again",
+	"snap":   "sudo snap refresh --hold %s keeps snap from refreshing it again",
+	"choco":  "choco pin add -n=%s keeps choco from upgrading it again",
+	"winget": "winget pin add --id %s keeps winget from upgrading it again",
+	"scoop":  "scoop hold %s keeps scoop from updating it again",
+}
+
+// RollbackHints suggests how to undo a package operation from the ledger,
+// the best way first: uninstalling what was installed, going back to the
+// version before an update, or reinstalling what was removed
+func RollbackHints(entry PackageLedgerEntry) []RollbackHint {
+	pm := PackageManagerByName(entry.Manager)
+	if pm == nil || entry.Package == AllPackages {
+		return []RollbackHint{{Note: fmt.Sprintf("Helix cannot undo this %s operation package by package", entry.Manager)}}
+	}
+
+	var hints []RollbackHint
+	switch entry.Action {
+	case "install":
+		hints = append(hints, RollbackHint{Command: pm.RemoveCommand(entry.Package), Note: "uninstalls it again"})
+
+	case "remove":
+		command := ""
+		if entry.VersionBefore != "" {
+			command = versionedInstall(entry.Manager, entry.Package, entry.VersionBefore, false)
+		}
+		if command == "" {
+			command = pm.InstallCommand(entry.Package)
+		}
+		hints = append(hints, RollbackHint{Command: command, Note: "installs it again"})
+
+	case "update":
+		switch {
+		case entry.Manager == "snap":
+			hints = append(hints, RollbackHint{Command: fmt.Sprintf("sudo snap revert %s", entry.Package), Note: "goes back to the revision before the refresh"})
+		case entry.Manager == "flatpak":
+			hints = append(hints, RollbackHint{
+				Command: fmt.Sprintf("flatpak update --commit=<commit> %s", entry.Package),
+				Note:    fmt.Sprintf("goes back to an earlier commit; flatpak remote-info --log flathub %s lists them", entry.Package),
+			})
+		case entry.Manager == "brew":
+			major, _, _ := strings.Cut(entry.VersionBefore, ".")
+			note := "brew switch no longer exists; Homebrew only offers older versions as versioned formulae"
+			if major != "" {
+				hints = append(hints, RollbackHint{Command: fmt.Sprintf("brew install %s@%s", entry.Package, major), Note: note + ", when there is one"})
+			} else {
+				hints = append(hints, RollbackHint{Note: note})
+			}
+		case entry.VersionBefore == "":
+			hints = append(hints, RollbackHint{Note: "the version before the update was not recorded"})
+		default:
+			if command := versionedInstall(entry.Manager, entry.Package, entry.VersionBefore, true); command != "" {
+				hints = append(hints, RollbackHint{Command: command, Note: "goes back to " + entry.VersionBefore})
+			}
+			if entry.Manager == "apt" {
+				hints = append(hints, RollbackHint{Note: fmt.Sprintf("apt-cache policy %s shows which versions the repositories still offer", entry.Package)})
+			}
+		}
+		if hold, ok := holdHints[entry.Manager]; ok {
+			hints = append(hints, RollbackHint{Note: fmt.Sprintf(hold, entry.Package)})
+		}
+	}
+	for i, hint := range hints {
+		if hint.Runnable() {
+			hints[i].Command = withPackageSources(entry.Manager, undoActions[entry.Action], hint.Command)
+		}
+	}
+	return hints
+}
+
+// undoActions are the actions undoing each recorded one
+var undoActions = map[string]string{"install": "remove", "remove": "install", "update": "update"}
+
+// RunRollback runs a rollback command for an operation from the ledger and
+// records it there too
+func RunRollback(entry PackageLedgerEntry, hint RollbackHint, env shell.Env, execConfig ExecuteConfig) error {
+	pm := PackageManagerByName(entry.Manager)
+	if pm == nil || !hint.Runnable() {
+		return fmt.Errorf("nothing to run for %s", entry.Package)
+	}
+	before := ""
+	if info, err := pm.CheckPackage(entry.Package); err == nil && info.Installed {
+		before = info.Version
+	}
+	if requiresSudo(pm.Name()) {
+		color.Yellow("⚠️  This command may require administrator privileges")
+	}
+	err := ExecuteCommand(hint.Command, execConfig, env)
+	recordPackageChange(pm, undoActions[entry.Action], entry.Package, before, hint.Command, err)
+	return err
+}
@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PackageSearcher is a package manager that can search its repositories
+type PackageSearcher interface {
+	SearchPackages(query string) ([]PackageInfo, error)
+}
+
+// MaxPackageResults is how many search results are kept after ranking
+const MaxPackageResults = 20
+
+// SearchPackages searches a package manager for a query and ranks the
+// results: exact name matches first, then names starting with the query,
+// names containing it, and descriptions mentioning it
+func SearchPackages(pm PackageManagerHandler, query string) ([]PackageInfo, error) {
+	searcher, ok := pm.(PackageSearcher)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot search for packages", pm.Name())
+	}
+	results, err := searcher.SearchPackages(query)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	score := func(info PackageInfo) int {
+		name := strings.ToLower(info.Name)
+		// Flatpak IDs and Go paths are matched by their last part too
+		short := name[strings.LastIndexAny(name, "./")+1:]
+		switch {
+		case name == query || short == query:
+			return 0
+		case strings.HasPrefix(name, query) || strings.HasPrefix(short, query):
+			return 1
+		case strings.Contains(name, query):
+			return 2
+		case strings.Contains(strings.ToLower(info.Description), query):
+			return 3
+		}
+		return 4
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		si, sj := score(results[i]), score(results[j])
+		if si != sj {
+			return si < sj
+		}
+		if results[i].Installed != results[j].Installed {
+			return results[i].Installed
+		}
+		return len(results[i].Name) < len(results[j].Name)
+	})
+	if len(results) > MaxPackageResults {
+		results = results[:MaxPackageResults]
+	}
+	return results, nil
+}
+
+// packageOutput runs a package manager query and returns its output lines.
+// A search without matches often exits with status 1 and no output, and
+// some managers exit non-zero when they list updates, so output wins over
+// the exit status.
+func packageOutput(name string, args ...string) ([]string, error) {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil && len(output) == 0 {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", name, args[0], err)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func (a AptManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// apt-cache search prints "name - description"
+	lines, err := packageOutput("apt-cache", "search", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		if name, description, ok := strings.Cut(line, " - "); ok {
+			results = append(results, PackageInfo{Name: name, Description: description})
+		}
+	}
+	return results, err
+}
+
+// rpmSearchPackages searches dnf or yum's cached metadata; results are
+// "name.arch : summary" below "=== ... ===" headers
+func rpmSearchPackages(manager, query string) ([]PackageInfo, error) {
+	lines, err := packageOutput(manager, "-q", "-C", "search", query)
+	var results []PackageInfo
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		name, description, ok := strings.Cut(line, " : ")
+		if !ok {
+			// dnf5 separates them with a tab
+			if name, description, ok = strings.Cut(strings.TrimSpace(line), "\t"); !ok {
+				continue
+			}
+		}
+		name = strings.TrimSpace(name)
+		if dot := strings.LastIndex(name, "."); dot > 0 {
+			name = name[:dot]
+		}
+		if !seen[name] {
+			seen[name] = true
+			results = append(results, PackageInfo{Name: name, Description: strings.TrimSpace(description)})
+		}
+	}
+	return results, err
+}
+
+func (d DnfManager) SearchPackages(query string) ([]PackageInfo, error) {
+	return rpmSearchPackages("dnf", query)
+}
+
+func (y YumManager) SearchPackages(query string) ([]PackageInfo, error) {
+	return rpmSearchPackages("yum", query)
+}
+
+func (z ZypperManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// zypper prints a table: "S  | Name | Summary | Type", i or i+ when installed
+	lines, err := packageOutput("zypper", "--no-refresh", "--quiet", "search", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		columns := strings.Split(line, "|")
+		if len(columns) < 4 || strings.TrimSpace(columns[1]) == "Name" || strings.TrimSpace(columns[3]) != "package" {
+			continue
+		}
+		results = append(results, PackageInfo{
+			Name:        strings.TrimSpace(columns[1]),
+			Installed:   strings.HasPrefix(strings.TrimSpace(columns[0]), "i"),
+			Description: strings.TrimSpace(columns[2]),
+		})
+	}
+	return results, err
+}
+
+// apkNameVersion splits apk's name-version-rN
+var apkNameVersion = regexp.MustCompile(`^(.+)-([0-9][^-]*-r[0-9]+)$`)
+
+func (a ApkManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// apk search -v prints "name-1.2.3-r0 - description"
+	lines, err := packageOutput("apk", "search", "-v", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		nameVersion, description, _ := strings.Cut(line, " - ")
+		if match := apkNameVersion.FindStringSubmatch(strings.TrimSpace(nameVersion)); match != nil {
+			results = append(results, PackageInfo{Name: match[1], LatestVersion: match[2], Description: description})
+		}
+	}
+	return results, err
+}
+
+func (p PacmanManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// pacman -Ss prints "repo/name version [installed]" and an indented description
+	lines, err := packageOutput("pacman", "-Ss", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		if strings.HasPrefix(line, " ") {
+			if len(results) > 0 {
+				results[len(results)-1].Description = strings.TrimSpace(line)
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		_, name, _ := strings.Cut(fields[0], "/")
+		results = append(results, PackageInfo{Name: name, LatestVersion: fields[1], Installed: strings.Contains(line, "[installed")})
+	}
+	return results, err
+}
+
+func (b BrewManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// brew search prints names only, below "==> Formulae" and "==> Casks"
+	lines, err := packageOutput("brew", "search", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "==>") {
+			for _, name := range strings.Fields(line) {
+				results = append(results, PackageInfo{Name: name})
+			}
+		}
+	}
+	return results, err
+}
+
+func (c ChocoManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// --limit-output prints "name|version"
+	lines, err := packageOutput("choco", "search", query, "--limit-output")
+	var results []PackageInfo
+	for _, line := range lines {
+		if name, version, ok := strings.Cut(line, "|"); ok {
+			results = append(results, PackageInfo{Name: name, LatestVersion: version})
+		}
+	}
+	return results, err
+}
+
+func (w WingetManager) SearchPackages(query string) ([]PackageInfo, error) {
+	lines, err := packageOutput("winget", "search", query, "--accept-source-agreements")
+	var results []PackageInfo
+	for _, row := range wingetTable(lines) {
+		results = append(results, PackageInfo{Name: row["Id"], LatestVersion: row["Version"], Description: row["Name"]})
+	}
+	return results, err
+}
+
+// wingetTable reads the tables winget prints, "Name  Id  Version  Source"
+// with columns aligned to the header, into rows keyed by column name
+func wingetTable(lines []string) []map[string]string {
+	var columns []string
+	var starts []int
+	var rows []map[string]string
+	for _, line := range lines {
+		if columns == nil {
+			if strings.HasPrefix(line, "Name") && strings.Contains(line, " Id ") {
+				for _, name := range strings.Fields(line) {
+					columns = append(columns, name)
+					starts = append(starts, strings.Index(line, name))
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "---") {
+			continue
+		}
+		row := make(map[string]string)
+		for k, name := range columns {
+			if starts[k] >= len(line) {
+				break
+			}
+			end := len(line)
+			if k+1 < len(starts) && starts[k+1] < end {
+				end = starts[k+1]
+			}
+			row[name] = strings.TrimSpace(line[starts[k]:end])
+		}
+		if row["Id"] != "" {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (s ScoopManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// scoop search prints "Name Version Source Binaries", Source being the
+	// bucket
+	lines, err := packageOutput("scoop", "search", query)
+	var results []PackageInfo
+	for _, row := range scoopTable(lines) {
+		results = append(results, PackageInfo{Name: row["Name"], LatestVersion: row["Version"], Description: "bucket " + row["Source"]})
+	}
+	return results, err
+}
+
+// scoopTable reads the tables scoop prints, a header row underlined with
+// dashes, into rows keyed by column name; the dashes mark where each column
+// starts, as headers such as "Installed Version" hold spaces
+func scoopTable(lines []string) []map[string]string {
+	var columns []string
+	var starts []int
+	var rows []map[string]string
+	for i, line := range lines {
+		if columns == nil {
+			if i == 0 || strings.Trim(line, "- ") != "" || !strings.Contains(line, "-") {
+				continue
+			}
+			header := lines[i-1]
+			for k := 0; k < len(line); k++ {
+				if line[k] == '-' && (k == 0 || line[k-1] == ' ') {
+					starts = append(starts, k)
+				}
+			}
+			for k, start := range starts {
+				end := len(header)
+				if k+1 < len(starts) && starts[k+1] < end {
+					end = starts[k+1]
+				}
+				name := ""
+				if start < end {
+					name = strings.TrimSpace(header[start:end])
+				}
+				columns = append(columns, name)
+			}
+			continue
+		}
+		row := make(map[string]string)
+		for k, name := range columns {
+			if starts[k] >= len(line) {
+				break
+			}
+			end := len(line)
+			if k+1 < len(starts) && starts[k+1] < end {
+				end = starts[k+1]
+			}
+			row[name] = strings.TrimSpace(line[starts[k]:end])
+		}
+		if row["Name"] != "" {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (s SnapManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// snap find prints "Name  Version  Publisher  Notes  Summary"
+	lines, err := packageOutput("snap", "find", query)
+	var results []PackageInfo
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 5 {
+			continue
+		}
+		results = append(results, PackageInfo{Name: fields[0], LatestVersion: fields[1], Description: strings.Join(fields[4:], " ")})
+	}
+	return results, err
+}
+
+func (f FlatpakManager) SearchPackages(query string) ([]PackageInfo, error) {
+	lines, err := packageOutput("flatpak", "search", "--columns=application,version,description", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		columns := strings.Split(line, "\t")
+		if len(columns) < 3 {
+			continue
+		}
+		results = append(results, PackageInfo{Name: columns[0], LatestVersion: columns[1], Description: columns[2]})
+	}
+	return results, err
+}
+
+func (n NpmManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// --parseable prints "name\tdescription\tauthor\tdate\tversion\tkeywords"
+	lines, err := packageOutput("npm", "search", "--parseable", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		columns := strings.Split(line, "\t")
+		if len(columns) < 5 {
+			continue
+		}
+		results = append(results, PackageInfo{Name: columns[0], Description: columns[1], LatestVersion: columns[4]})
+	}
+	return results, err
+}
+
+func (c CargoManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// cargo search prints `name = "version"    # description`
+	lines, err := packageOutput("cargo", "search", query, "--limit", fmt.Sprint(MaxPackageResults))
+	var results []PackageInfo
+	for _, line := range lines {
+		name, rest, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		version, description, _ := strings.Cut(rest, "#")
+		results = append(results, PackageInfo{Name: strings.TrimSpace(name), LatestVersion: strings.Trim(strings.TrimSpace(version), `"`), Description: strings.TrimSpace(description)})
+	}
+	return results, err
+}
+
+func (g GemManager) SearchPackages(query string) ([]PackageInfo, error) {
+	// gem search prints "name (version)"
+	lines, err := packageOutput("gem", "search", query)
+	var results []PackageInfo
+	for _, line := range lines {
+		if name, version, ok := strings.Cut(line, " ("); ok {
+			results = append(results, PackageInfo{Name: name, LatestVersion: strings.TrimSuffix(version, ")")})
+		}
+	}
+	return results, err
+}
@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PackageFinder is a package manager that can tell whether it offers a
+// package before it is installed. The info it returns carries the name to
+// install, e.g. the Flatpak application ID, and the version on offer.
+type PackageFinder interface {
+	FindPackage(pkg string) (PackageInfo, bool)
+}
+
+// PackageSource is a package manager offering a package, or having it
+// installed
+type PackageSource struct {
+	Manager   PackageManagerHandler
+	Info      PackageInfo
+	Available bool
+}
+
+// packageTradeoffs are the pros and cons shown when several sources offer
+// a package
+var packageTradeoffs = map[string][2]string{
+	"snap":    {"recent versions, automatic updates, confined", "slower to start, larger, needs snapd"},
+	"flatpak": {"recent desktop apps from Flathub, sandboxed", "mostly GUI apps, shared runtimes take space"},
+}
+
+// systemTradeoff is the pros and cons of the distribution's own packages
+var systemTradeoff = [2]string{"built for your distribution, security updates with the system", "versions may lag behind upstream"}
+
+// packageManagers returns the system package manager followed by Snap and
+// Flatpak, when they are installed too
+func packageManagers(system PackageManagerHandler) []PackageManagerHandler {
+	managers := []PackageManagerHandler{system}
+	for _, extra := range []PackageManagerHandler{SnapManager{}, FlatpakManager{}} {
+		if _, err := exec.LookPath(extra.Name()); err == nil && extra.Name() != system.Name() {
+			managers = append(managers, extra)
+		}
+	}
+	return managers
+}
+
+// packageSources asks every manager about a package
+func packageSources(pkg string, managers []PackageManagerHandler) []PackageSource {
+	var sources []PackageSource
+	for _, manager := range managers {
+		source := PackageSource{Manager: manager, Info: PackageInfo{Name: pkg}, Available: true}
+		if finder, ok := manager.(PackageFinder); ok {
+			source.Info, source.Available = finder.FindPackage(pkg)
+		}
+		if info, err := manager.CheckPackage(source.Info.Name); err == nil && info.Installed {
+			source.Info.Installed, source.Info.Version = true, info.Version
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// choosePackageSource picks the manager an action uses when Snap or Flatpak
+// could serve the package too. Installing picks among the sources offering
+// it and updating or removing among those having it installed; the user
+// chooses when there are several. It returns false when the user cancels.
+func choosePackageSource(action, pkg string, managers []PackageManagerHandler) (PackageSource, bool) {
+	color.Blue("🔍 Looking for %s in %d package sources", pkg, len(managers))
+	sources := packageSources(pkg, managers)
+
+	var candidates []PackageSource
+	for _, source := range sources {
+		if source.Info.Installed {
+			candidates = append(candidates, source)
+		}
+	}
+	if action == "install" && len(candidates) == 0 {
+		for _, source := range sources {
+			if source.Available {
+				candidates = append(candidates, source)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return sources[0], true
+	case 1:
+		if candidates[0].Manager.Name() != sources[0].Manager.Name() {
+			color.Cyan("📦 Using %s: %s", candidates[0].Manager.Name(), describeSource(candidates[0]))
+		}
+		return candidates[0], true
+	}
+
+	verb := map[string]string{"install": "offer", "update": "have installed", "remove": "have installed"}[action]
+	color.Cyan("📦 %d sources %s %s:", len(candidates), verb, pkg)
+	for i, source := range candidates {
+		tradeoff, ok := packageTradeoffs[source.Manager.Name()]
+		if !ok {
+			tradeoff = systemTradeoff
+		}
+		fmt.Printf("  %d) %-8s %s\n", i+1, source.Manager.Name(), describeSource(source))
+		color.Green("       + %s", tradeoff[0])
+		color.Yellow("       - %s", tradeoff[1])
+	}
+
+	question := fmt.Sprintf("Which source should %s %s?", action, pkg)
+	var response string
+	fmt.Printf("%s [1-%d, Enter for 1, n to cancel]: ", question, len(candidates))
+	fmt.Scanln(&response)
+
+	response = strings.TrimSpace(response)
+	choice := 1
+	if response != "" {
+		n, err := strconv.Atoi(response)
+		if err != nil || n < 1 || n > len(candidates) {
+			recordDecision(question, false)
+			color.Yellow("❌ Cancelled")
+			return PackageSource{}, false
+		}
+		choice = n
+	}
+	recordDecision(question, true)
+	return candidates[choice-1], true
+}
+
+// describeSource summarizes what a source has: the name, and the installed
+// or offered version
+func describeSource(source PackageSource) string {
+	switch {
+	case source.Info.Installed && source.Info.Version != "":
+		return fmt.Sprintf("%s (installed, v%s)", source.Info.Name, source.Info.Version)
+	case source.Info.Installed:
+		return fmt.Sprintf("%s (installed)", source.Info.Name)
+	case source.Info.LatestVersion != "":
+		return fmt.Sprintf("%s (v%s)", source.Info.Name, source.Info.LatestVersion)
+	}
+	return source.Info.Name
+}
+
+// fieldValue returns the value of a "Key: value" line, as package managers
+// print package details, or ""
+func fieldValue(output, key string) string {
+	for _, line := range strings.Split(output, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func (a AptManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command("apt-cache", "policy", pkg).Output()
+	if err != nil {
+		return info, false
+	}
+	info.LatestVersion = fieldValue(string(output), "Candidate")
+	if info.LatestVersion == "(none)" {
+		info.LatestVersion = ""
+	}
+	return info, info.LatestVersion != ""
+}
+
+// rpmFindPackage reads a package's details from the cached repository
+// metadata of dnf or yum, without refreshing it
+func rpmFindPackage(manager, pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command(manager, "-q", "-C", "info", pkg).Output()
+	if err != nil || fieldValue(string(output), "Name") == "" {
+		return info, false
+	}
+	info.LatestVersion = fieldValue(string(output), "Version")
+	return info, true
+}
+
+func (d DnfManager) FindPackage(pkg string) (PackageInfo, bool) {
+	return rpmFindPackage("dnf", pkg)
+}
+
+func (y YumManager) FindPackage(pkg string) (PackageInfo, bool) {
+	return rpmFindPackage("yum", pkg)
+}
+
+func (z ZypperManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command("zypper", "--no-refresh", "--quiet", "info", pkg).Output()
+	if err != nil || fieldValue(string(output), "Name") == "" {
+		return info, false
+	}
+	info.LatestVersion = fieldValue(string(output), "Version")
+	return info, true
+}
+
+func (a ApkManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	// apk search -x prints name-version for an exact match
+	output, err := exec.Command("apk", "search", "-x", pkg).Output()
+	if err != nil {
+		return info, false
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), pkg+"-"); ok {
+			info.LatestVersion = version
+			return info, true
+		}
+	}
+	return info, false
+}
+
+func (p PacmanManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command("pacman", "-Si", pkg).Output()
+	if err != nil {
+		return info, false
+	}
+	info.LatestVersion = fieldValue(string(output), "Version")
+	return info, true
+}
+
+func (s ScoopManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	app := scoopApp(pkg)
+	// scoop search takes a regular expression
+	results, err := s.SearchPackages("^" + app + "$")
+	if err != nil {
+		return info, false
+	}
+	for _, result := range results {
+		if strings.EqualFold(result.Name, app) {
+			info.LatestVersion, info.Description = result.LatestVersion, result.Description
+			return info, true
+		}
+	}
+	return info, false
+}
+
+func (s SnapManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command("snap", "info", pkg).Output()
+	if err != nil {
+		return info, false
+	}
+	// Channels are listed as "  latest/stable: 2.43.0 2024-01-01 (123) 50MB -"
+	for _, key := range []string{"latest/stable", "stable"} {
+		if fields := strings.Fields(fieldValue(string(output), key)); len(fields) > 0 {
+			info.LatestVersion = fields[0]
+			break
+		}
+	}
+	return info, true
+}
+
+func (f FlatpakManager) FindPackage(pkg string) (PackageInfo, bool) {
+	info := PackageInfo{Name: pkg}
+	output, err := exec.Command("flatpak", "search", "--columns=application,version", pkg).Output()
+	if err != nil {
+		return info, false
+	}
+	// An application matches by its ID or the last part of it: gimp is org.gimp.GIMP
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		id := strings.TrimSpace(fields[0])
+		if id == "" || (!strings.EqualFold(id, pkg) && !strings.EqualFold(id[strings.LastIndex(id, ".")+1:], pkg)) {
+			continue
+		}
+		info.Name = id
+		if len(fields) > 1 {
+			info.LatestVersion = strings.TrimSpace(fields[1])
+		}
+		return info, true
+	}
+	return info, false
+}
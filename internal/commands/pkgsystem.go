@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"helix/internal/shell"
+	"helix/internal/utils"
+	"helix/internal/ux"
+
+	"github.com/fatih/color"
+)
+
+// SystemUpgrade is how a package manager brings everything it installed up
+// to date: refreshing what is available, then upgrading it all
+type SystemUpgrade struct {
+	Refresh string // "" when upgrading refreshes by itself
+	Upgrade string
+}
+
+// systemUpgrades are the full-system update sequences of the managers; the
+// upgrade runs without the manager's own prompt, as Helix asks first
+var systemUpgrades = map[string]SystemUpgrade{
+	"apt":     {"sudo apt update", "sudo apt upgrade -y"},
+	"dnf":     {"sudo dnf makecache", "sudo dnf upgrade -y"},
+	"yum":     {"sudo yum makecache", "sudo yum update -y"},
+	"zypper":  {"sudo zypper refresh", "sudo zypper --non-interactive update"},
+	"apk":     {"sudo apk update", "sudo apk upgrade"},
+	"pacman":  {"sudo pacman -Sy", "sudo pacman -Su --noconfirm"},
+	"brew":    {"brew update", "brew upgrade"},
+	"port":    {"sudo port selfupdate", "sudo port upgrade outdated"},
+	"snap":    {"", "sudo snap refresh"},
+	"flatpak": {"", "flatpak update -y"},
+	"choco":   {"", "choco upgrade all -y"},
+	"winget":  {"", "winget upgrade --all --accept-source-agreements --accept-package-agreements"},
+	"scoop":   {"scoop update", "scoop update --all"},
+}
+
+// SystemUpgradeFor returns the full-system update sequence of a manager,
+// going through the configured proxy
+func SystemUpgradeFor(pm PackageManagerHandler) (SystemUpgrade, bool) {
+	upgrade, ok := systemUpgrades[pm.Name()]
+	if !ok {
+		return upgrade, false
+	}
+	if upgrade.Refresh != "" {
+		upgrade.Refresh = withPackageSources(pm.Name(), "update", upgrade.Refresh)
+	}
+	upgrade.Upgrade = withPackageSources(pm.Name(), "update", upgrade.Upgrade)
+	return upgrade, true
+}
+
+// UpgradeSystem guides a full-system update: it refreshes the package
+// lists, previews the packages that will change, runs the upgrade once
+// confirmed with its output streaming, and ends with a summary. It reports
+// whether the upgrade ran successfully.
+func UpgradeSystem(pm PackageManagerHandler, env shell.Env, mockMode bool, execConfig ExecuteConfig) bool {
+	upgrade, ok := SystemUpgradeFor(pm)
+	if !ok {
+		color.Red("❌ Helix does not know how to upgrade everything with %s", pm.Name())
+		color.Yellow("💡 Try /pkg outdated --via %s", pm.Name())
+		return false
+	}
+
+	steps := []string{upgrade.Upgrade}
+	if upgrade.Refresh != "" {
+		steps = []string{upgrade.Refresh, upgrade.Upgrade}
+	}
+	color.Cyan("🔄 System update with %s:", pm.Name())
+	for i, step := range steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+	if mockMode {
+		// Listing is safe, though with package lists as they were last refreshed
+		previewSystemUpgrade(pm)
+		color.Yellow("💡 Mock mode: nothing was run")
+		return false
+	}
+	if requiresSudo(pm.Name()) {
+		color.Yellow("⚠️  These commands may require administrator privileges")
+	}
+
+	start := time.Now()
+	if upgrade.Refresh != "" {
+		if !AskForConfirmation("Refresh the package lists?") {
+			color.Yellow("💡 Cancelled; the preview needs fresh package lists")
+			return false
+		}
+		color.Blue("📡 [1/2] %s", upgrade.Refresh)
+		if err := ExecuteCommand(upgrade.Refresh, execConfig, env); err != nil {
+			color.Red("❌ Refreshing the package lists failed: %v", err)
+			return false
+		}
+	}
+
+	before, previewErr := previewSystemUpgrade(pm)
+	if previewErr == nil && len(before) == 0 {
+		color.Green("✅ Everything %s installed is up to date", pm.Name())
+		return false
+	}
+	question := fmt.Sprintf("Upgrade these %d package(s)?", len(before))
+	if previewErr != nil {
+		question = fmt.Sprintf("Upgrade everything %s installed?", pm.Name())
+	}
+	if !AskForConfirmation(question) {
+		color.Yellow("💡 Nothing upgraded. You can run it manually:")
+		color.Cyan("  %s", upgrade.Upgrade)
+		return false
+	}
+
+	color.Blue("⬆️  [%d/%d] %s", len(steps), len(steps), upgrade.Upgrade)
+	err := ExecuteCommand(upgrade.Upgrade, execConfig, env)
+	summarizeSystemUpgrade(pm, upgrade.Upgrade, before, previewErr == nil, err, time.Since(start))
+	return err == nil
+}
+
+// previewSystemUpgrade shows the packages an upgrade will change, when the
+// manager can list them
+func previewSystemUpgrade(pm PackageManagerHandler) ([]PackageInfo, error) {
+	color.Blue("🔍 Checking %s for upgrades", pm.Name())
+	packages, err := OutdatedPackages(pm)
+	if err != nil && len(packages) == 0 {
+		color.Yellow("⚠️  Cannot preview the upgrade: %v", err)
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, nil
+	}
+	rows := make([][]string, 0, len(packages))
+	for i, info := range packages {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			info.Name,
+			utils.TruncateString(info.Version, 24),
+			utils.TruncateString(info.LatestVersion, 24),
+		})
+	}
+	ux.NewUX().PrintTable([]string{"#", "Package", "Installed", "Available"}, rows)
+	return packages, nil
+}
+
+// summarizeSystemUpgrade tells how the upgrade went: what was upgraded, what
+// is still outdated and how long it took. Every package goes to the ledger.
+func summarizeSystemUpgrade(pm PackageManagerHandler, upgrade string, before []PackageInfo, previewed bool, err error, took time.Duration) {
+	color.Cyan("📋 System update summary (%s, took %s):", pm.Name(), utils.FormatDuration(took))
+	entry := PackageLedgerEntry{Time: time.Now(), Action: "update", Manager: pm.Name(), Command: upgrade, Success: err == nil}
+	if err != nil {
+		entry.Error = err.Error()
+		color.Red("   ❌ The upgrade failed: %v", err)
+	}
+	if !previewed {
+		entry.Package = AllPackages
+		logPackageEntries(entry)
+		if err == nil {
+			color.Green("   ✅ Upgrade finished")
+		}
+		return
+	}
+
+	after, _ := OutdatedPackages(pm)
+	outdated := make(map[string]bool)
+	for _, info := range after {
+		outdated[info.Name] = true
+	}
+	var upgraded, remaining []string
+	var entries []PackageLedgerEntry
+	for _, info := range before {
+		entry.Package, entry.VersionBefore, entry.VersionAfter = info.Name, info.Version, ""
+		if outdated[info.Name] {
+			remaining = append(remaining, info.Name)
+			entry.Success = false
+		} else {
+			upgraded = append(upgraded, info.Name)
+			entry.Success, entry.VersionAfter = true, info.LatestVersion
+		}
+		entries = append(entries, entry)
+	}
+	logPackageEntries(entries...)
+	color.Green("   ✅ %d of %d package(s) upgraded", len(upgraded), len(before))
+	if len(upgraded) > 0 {
+		color.Yellow("   💡 /pkg history lists them; /pkg rollback <package> if one breaks something")
+	}
+	if len(remaining) > 0 {
+		color.Yellow("   ⚠️  Still outdated: %s", strings.Join(remaining, ", "))
+		if pm.Name() == "apt" {
+			color.Yellow("   💡 apt keeps back upgrades that add or remove packages; sudo apt full-upgrade installs them")
+		}
+	}
+}
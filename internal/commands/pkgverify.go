@@ -0,0 +1,353 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// BinaryLister is a package manager that can tell which executables a
+// package installed, as full paths
+type BinaryLister interface {
+	PackageBinaries(pkg string) ([]string, error)
+}
+
+// maxBinariesShown keeps the post-install check to one line
+const maxBinariesShown = 5
+
+// binaryDirs are the directories executables are installed into
+var binaryDirs = map[string]bool{"bin": true, "sbin": true, "games": true, "shims": true}
+
+// executables keeps the files of a package listing that are executables in
+// a bin directory
+func executables(paths []string) []string {
+	var binaries []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !binaryDirs[filepath.Base(filepath.Dir(path))] {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && (runtime.GOOS == "windows" || info.Mode()&0o111 != 0) {
+			binaries = append(binaries, path)
+		}
+	}
+	return binaries
+}
+
+// packageBinaries returns the executables a package installed. When the
+// manager cannot list them it guesses one named after the package, in the
+// directory the manager installs into; listed tells which it is.
+func packageBinaries(pm PackageManagerHandler, pkg string) (binaries []string, listed bool) {
+	if lister, ok := pm.(BinaryLister); ok {
+		if binaries, err := lister.PackageBinaries(pkg); err == nil {
+			return binaries, true
+		}
+	}
+	name := pkg[strings.LastIndex(pkg, "/")+1:]
+	if at := strings.Index(name, "@"); at > 0 {
+		name = name[:at]
+	}
+	if dir := managerBinDir(pm.Name()); dir != "" {
+		return []string{filepath.Join(dir, name)}, false
+	}
+	return []string{name}, false
+}
+
+// managerBinDir returns where a package manager puts the commands it
+// installs when they do not land in a system directory, or ""
+func managerBinDir(manager string) string {
+	home, _ := os.UserHomeDir()
+	switch manager {
+	case "pip":
+		if output, err := exec.Command("python3", "-m", "site", "--user-base").Output(); err == nil {
+			return filepath.Join(strings.TrimSpace(string(output)), "bin")
+		}
+		return filepath.Join(home, ".local", "bin")
+	case "pipx":
+		if dir := os.Getenv("PIPX_BIN_DIR"); dir != "" {
+			return dir
+		}
+		return filepath.Join(home, ".local", "bin")
+	case "cargo":
+		if dir := os.Getenv("CARGO_HOME"); dir != "" {
+			return filepath.Join(dir, "bin")
+		}
+		return filepath.Join(home, ".cargo", "bin")
+	case "go":
+		return goBinDir()
+	case "npm", "yarn", "pnpm":
+		if output, err := exec.Command("npm", "prefix", "-g").Output(); err == nil {
+			if runtime.GOOS == "windows" {
+				return strings.TrimSpace(string(output))
+			}
+			return filepath.Join(strings.TrimSpace(string(output)), "bin")
+		}
+	case "gem":
+		if output, err := exec.Command("gem", "environment").Output(); err == nil {
+			return fieldValue(strings.ReplaceAll(string(output), "- EXECUTABLE DIRECTORY", "EXECUTABLE DIRECTORY"), "EXECUTABLE DIRECTORY")
+		}
+	case "snap":
+		return "/snap/bin"
+	case "scoop":
+		return filepath.Join(home, "scoop", "shims")
+	}
+	return ""
+}
+
+// VerifyInstall checks that the commands a package installed can be run.
+// When none is on the PATH it says where they are, explains the extra steps
+// some managers need, and offers to add the directory to the PATH.
+func VerifyInstall(pm PackageManagerHandler, pkg string, env shell.Env) {
+	if env.Remote != nil {
+		return
+	}
+	binaries, listed := packageBinaries(pm, pkg)
+	if listed && len(binaries) == 0 {
+		// A library or a metapackage: nothing to run
+		return
+	}
+
+	if pm.Name() == "flatpak" {
+		color.Cyan("💡 Flatpak apps start from the desktop menu or with: flatpak run %s", pkg)
+		return
+	}
+
+	// A keg-only formula is left out of brew's bin directory, so the same
+	// command on the PATH is the one macOS ships
+	kegOnly := pm.Name() == "brew" && brewKegOnly(pkg)
+	if kegOnly {
+		color.Yellow("⚠️  %s is keg-only: brew did not link it into its bin directory, so it does not shadow the version macOS ships", pkg)
+		if prefix, err := exec.Command("brew", "--prefix", pkg).Output(); err == nil {
+			binaries = []string{filepath.Join(strings.TrimSpace(string(prefix)), "bin", filepath.Base(binaries[0]))}
+		}
+	} else {
+		var ready []string
+		for _, binary := range binaries {
+			if _, err := exec.LookPath(filepath.Base(binary)); err == nil {
+				ready = append(ready, filepath.Base(binary))
+			}
+		}
+		if len(ready) > 0 {
+			if len(ready) > maxBinariesShown {
+				ready = append(ready[:maxBinariesShown], "...")
+			}
+			color.Green("✅ Ready to run: %s", strings.Join(ready, ", "))
+			return
+		}
+	}
+
+	// Only directories holding the commands tell where they went; a guessed
+	// name may simply be wrong
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, binary := range binaries {
+		dir := filepath.Dir(binary)
+		if _, err := os.Stat(binary); err == nil && filepath.IsAbs(dir) && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	name := filepath.Base(binaries[0])
+	if len(dirs) == 0 {
+		// A winget ID such as BurntSushi.ripgrep.MSVC is no command name
+		if !kegOnly && (listed || filepath.IsAbs(binaries[0]) || !strings.Contains(name, ".")) {
+			color.Yellow("⚠️  No command named %s is on your PATH; the package may name its command differently, or a new terminal may be needed", name)
+		}
+		return
+	}
+	if !kegOnly {
+		color.Yellow("⚠️  %s is installed, but %s is not found on your PATH", pkg, name)
+	}
+	for _, dir := range dirs {
+		color.Cyan("📁 Installed in: %s", dir)
+		offerPathUpdate(dir, env)
+	}
+}
+
+// brewKegOnly reports whether brew keeps a formula out of its bin directory
+func brewKegOnly(pkg string) bool {
+	output, err := exec.Command("brew", "info", "--json=v2", pkg).Output()
+	if err != nil {
+		return false
+	}
+	var info struct {
+		Formulae []struct {
+			KegOnly bool `json:"keg_only"`
+		} `json:"formulae"`
+	}
+	return json.Unmarshal(output, &info) == nil && len(info.Formulae) > 0 && info.Formulae[0].KegOnly
+}
+
+// shellProfile returns the startup file of the user's shell and the line
+// that adds a directory to the PATH there
+func shellProfile(dir string, env shell.Env) (string, string) {
+	home := env.HomeDir
+	switch env.Shell {
+	case "fish":
+		return filepath.Join(home, ".config", "fish", "config.fish"), fmt.Sprintf("fish_add_path %q", dir)
+	case "zsh":
+		return filepath.Join(home, ".zshrc"), fmt.Sprintf(`export PATH="%s:$PATH"`, dir)
+	case "bash":
+		if env.OSName == "darwin" {
+			return filepath.Join(home, ".bash_profile"), fmt.Sprintf(`export PATH="%s:$PATH"`, dir)
+		}
+		return filepath.Join(home, ".bashrc"), fmt.Sprintf(`export PATH="%s:$PATH"`, dir)
+	}
+	return filepath.Join(home, ".profile"), fmt.Sprintf(`export PATH="%s:$PATH"`, dir)
+}
+
+// offerPathUpdate offers to add a directory to the PATH: in the shell's
+// startup file for new terminals, and right away for Helix. On Windows it
+// explains how instead.
+func offerPathUpdate(dir string, env shell.Env) {
+	if env.OSName == "windows" {
+		color.Cyan("💡 Add it to your PATH (new terminals pick it up):")
+		color.Cyan(`  PowerShell: [Environment]::SetEnvironmentVariable("Path", $env:Path + ";%s", "User")`, dir)
+		return
+	}
+
+	profile, line := shellProfile(dir, env)
+	if data, err := os.ReadFile(profile); err == nil && strings.Contains(string(data), dir) {
+		color.Yellow("💡 %s already adds it; open a new terminal or run: source %s", profile, profile)
+		return
+	}
+	if !AskForConfirmation(fmt.Sprintf("Add %s to your PATH in %s?", dir, profile)) {
+		color.Yellow("💡 To do it yourself, add this line to %s:", profile)
+		color.Cyan("  %s", line)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(profile), 0o755); err != nil {
+		color.Red("❌ Failed to update %s: %v", profile, err)
+		return
+	}
+	file, err := os.OpenFile(profile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err == nil {
+		_, err = fmt.Fprintf(file, "\n# Added by Helix\n%s\n", line)
+		file.Close()
+	}
+	if err != nil {
+		color.Red("❌ Failed to update %s: %v", profile, err)
+		return
+	}
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	color.Green("✅ Added to %s; Helix can run it now, and new terminals too (or run: source %s)", profile, profile)
+}
+
+func (a AptManager) PackageBinaries(pkg string) ([]string, error) {
+	lines, err := packageOutput("dpkg", "-L", pkg)
+	if err != nil {
+		return nil, err
+	}
+	return executables(lines), nil
+}
+
+// rpmBinaries lists a package's files from the RPM database, which dnf, yum
+// and zypper share
+func rpmBinaries(pkg string) ([]string, error) {
+	lines, err := packageOutput("rpm", "-ql", pkg)
+	if err != nil {
+		return nil, err
+	}
+	return executables(lines), nil
+}
+
+func (d DnfManager) PackageBinaries(pkg string) ([]string, error) {
+	return rpmBinaries(pkg)
+}
+
+func (y YumManager) PackageBinaries(pkg string) ([]string, error) {
+	return rpmBinaries(pkg)
+}
+
+func (z ZypperManager) PackageBinaries(pkg string) ([]string, error) {
+	return rpmBinaries(pkg)
+}
+
+func (p PacmanManager) PackageBinaries(pkg string) ([]string, error) {
+	lines, err := packageOutput("pacman", "-Qlq", pkg)
+	if err != nil {
+		return nil, err
+	}
+	return executables(lines), nil
+}
+
+func (a ApkManager) PackageBinaries(pkg string) ([]string, error) {
+	// apk info -L prints "pkg-1.0-r0 contains:" and paths without the
+	// leading slash
+	lines, err := packageOutput("apk", "info", "-L", pkg)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "contains:") {
+			paths = append(paths, "/"+strings.TrimSpace(line))
+		}
+	}
+	return executables(paths), nil
+}
+
+func (b BrewManager) PackageBinaries(pkg string) ([]string, error) {
+	// brew list prints the files of a formula in its Cellar
+	lines, err := packageOutput("brew", "list", "--formula", pkg)
+	if err != nil {
+		return nil, err
+	}
+	return executables(lines), nil
+}
+
+func (c CargoManager) PackageBinaries(pkg string) ([]string, error) {
+	// cargo install --list prints "ripgrep v14.1.0:" and its binaries
+	// indented below
+	lines, err := packageOutput("cargo", "install", "--list")
+	if err != nil {
+		return nil, err
+	}
+	var binaries []string
+	current := ""
+	for _, line := range lines {
+		if !strings.HasPrefix(line, " ") {
+			current = strings.Fields(line)[0]
+			continue
+		}
+		if current == pkg {
+			binaries = append(binaries, filepath.Join(managerBinDir("cargo"), strings.TrimSpace(line)))
+		}
+	}
+	return binaries, nil
+}
+
+func (g GoManager) PackageBinaries(pkg string) ([]string, error) {
+	return []string{goBinaryPath(pkg)}, nil
+}
+
+func (p PipManager) PackageBinaries(pkg string) ([]string, error) {
+	// pip show -f lists the files relative to Location, commands as
+	// ../../../bin/black
+	output, err := exec.Command("pip", "show", "-f", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("pip show failed: %w", err)
+	}
+	location := fieldValue(string(output), "Location")
+	var paths []string
+	files := false
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "Files:") {
+			files = true
+			continue
+		}
+		if files && strings.HasPrefix(line, " ") {
+			paths = append(paths, filepath.Join(location, strings.TrimSpace(line)))
+		}
+	}
+	return executables(paths), nil
+}
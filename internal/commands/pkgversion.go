@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VersionLookup is a package manager that can tell the newest version it
+// offers of a package
+type VersionLookup interface {
+	LatestVersion(pkg string) (string, error)
+}
+
+// CheckPackageVersions checks a package like CheckPackage and, when it is
+// installed, looks up the newest version on offer and whether it is newer
+// than the installed one
+func CheckPackageVersions(pm PackageManagerHandler, pkg string) (PackageInfo, error) {
+	info, err := pm.CheckPackage(pkg)
+	if err != nil || !info.Installed {
+		return info, err
+	}
+	switch lookup := pm.(type) {
+	case VersionLookup:
+		info.LatestVersion, _ = lookup.LatestVersion(pkg)
+	case PackageFinder:
+		if found, ok := lookup.FindPackage(pkg); ok {
+			info.LatestVersion = found.LatestVersion
+		}
+	}
+	if info.Version != "" && info.LatestVersion != "" {
+		info.UpdateAvailable = CompareVersions(info.LatestVersion, info.Version) > 0
+	}
+	return info, nil
+}
+
+// preReleaseTags mark versions that come before the release they name:
+// 2.0-rc1 is older than 2.0
+var preReleaseTags = []string{"alpha", "beta", "pre", "rc", "dev"}
+
+// CompareVersions compares two version strings and returns -1, 0 or 1. It
+// understands semantic versions (v1.2.10 > v1.2.9, 2.0.0-rc1 < 2.0.0) and
+// distribution versions with an epoch, a revision or ~ (1:2.3-1,
+// 1.0~beta < 1.0): numbers compare as numbers and letters as text.
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(strings.TrimSpace(a), "v"), strings.TrimPrefix(strings.TrimSpace(b), "v")
+	if a == b {
+		return 0
+	}
+
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if epochA != epochB {
+		return compareInts(epochA, epochB)
+	}
+
+	partsA, partsB := versionParts(restA), versionParts(restB)
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		if i >= len(partsA) {
+			return -tailOrder(partsB[i:])
+		}
+		if i >= len(partsB) {
+			return tailOrder(partsA[i:])
+		}
+		pa, pb := partsA[i], partsB[i]
+		switch {
+		case pa == pb:
+			continue
+		case pa == "~" || pb == "~":
+			if pa == "~" {
+				return -1
+			}
+			return 1
+		case isNumber(pa) && isNumber(pb):
+			if c := compareNumbers(pa, pb); c != 0 {
+				return c
+			}
+		case isNumber(pa):
+			// 1.0.1 is newer than 1.0rc1
+			return 1
+		case isNumber(pb):
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(pa), strings.ToLower(pb)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// splitEpoch splits the epoch off a version such as 1:2.3-1
+func splitEpoch(version string) (int, string) {
+	if before, after, ok := strings.Cut(version, ":"); ok {
+		if epoch, err := strconv.Atoi(before); err == nil {
+			return epoch, after
+		}
+	}
+	return 0, version
+}
+
+// versionParts splits a version into runs of digits and letters, keeping ~
+// and dropping the separators between them
+func versionParts(version string) []string {
+	var parts []string
+	current := ""
+	kind := 0 // 1 digits, 2 letters
+	flush := func() {
+		if current != "" {
+			parts = append(parts, current)
+		}
+		current, kind = "", 0
+	}
+	for _, r := range version {
+		switch {
+		case r == '~':
+			flush()
+			parts = append(parts, "~")
+		case unicode.IsDigit(r):
+			if kind != 1 {
+				flush()
+			}
+			current, kind = current+string(r), 1
+		case unicode.IsLetter(r):
+			if kind != 2 {
+				flush()
+			}
+			current, kind = current+string(r), 2
+		default:
+			flush()
+		}
+	}
+	flush()
+	return parts
+}
+
+// tailOrder orders a version with the extra parts tail against the same
+// version without them: 1.0.1 is newer than 1.0, 1.0.0 the same, and
+// 1.0rc1 and 1.0~1 older
+func tailOrder(tail []string) int {
+	first := strings.ToLower(tail[0])
+	if first == "~" {
+		return -1
+	}
+	for _, tag := range preReleaseTags {
+		if first == tag {
+			return -1
+		}
+	}
+	for _, part := range tail {
+		if strings.TrimLeft(part, "0") != "" {
+			return 1
+		}
+	}
+	// 1.0.0 is 1.0
+	return 0
+}
+
+// isNumber reports whether a version part is a run of digits
+func isNumber(part string) bool {
+	return part != "" && unicode.IsDigit(rune(part[0]))
+}
+
+// compareNumbers compares runs of digits of any length
+func compareNumbers(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInts(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts returns -1, 0 or 1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// brewInfo is the part of brew info --json=v2 that holds versions
+type brewInfo struct {
+	Formulae []struct {
+		Versions struct {
+			Stable string `json:"stable"`
+		} `json:"versions"`
+	} `json:"formulae"`
+	Casks []struct {
+		Version string `json:"version"`
+	} `json:"casks"`
+}
+
+func (b BrewManager) LatestVersion(pkg string) (string, error) {
+	output, err := exec.Command("brew", "info", "--json=v2", pkg).Output()
+	if err != nil {
+		return "", fmt.Errorf("brew info failed: %w", err)
+	}
+	var info brewInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return "", fmt.Errorf("invalid brew info output: %w", err)
+	}
+	if len(info.Formulae) > 0 {
+		return info.Formulae[0].Versions.Stable, nil
+	}
+	if len(info.Casks) > 0 {
+		return info.Casks[0].Version, nil
+	}
+	return "", fmt.Errorf("brew has no %s", pkg)
+}
+
+func (p PortManager) LatestVersion(pkg string) (string, error) {
+	// port info --version prints "version: 8.5.0"
+	lines, err := packageOutput("port", "info", "--version", pkg)
+	for _, line := range lines {
+		if _, version, ok := strings.Cut(line, "version:"); ok {
+			return strings.TrimSpace(version), nil
+		}
+	}
+	return "", err
+}
+
+func (c ChocoManager) LatestVersion(pkg string) (string, error) {
+	// --exact --limit-output prints "name|version"
+	lines, err := packageOutput("choco", "search", pkg, "--exact", "--limit-output")
+	for _, line := range lines {
+		if name, version, ok := strings.Cut(line, "|"); ok && strings.EqualFold(name, pkg) {
+			return version, nil
+		}
+	}
+	return "", err
+}
+
+func (w WingetManager) LatestVersion(pkg string) (string, error) {
+	output, err := exec.Command("winget", "show", pkg, "--exact", "--accept-source-agreements").Output()
+	if err != nil {
+		return "", fmt.Errorf("winget show failed: %w", err)
+	}
+	return fieldValue(string(output), "Version"), nil
+}
+
+func (p PipManager) LatestVersion(pkg string) (string, error) {
+	// pip index versions prints "requests (2.32.3)" first
+	lines, err := packageOutput("pip", "index", "versions", pkg)
+	if len(lines) > 0 {
+		if _, version, ok := strings.Cut(lines[0], "("); ok {
+			return strings.TrimSuffix(version, ")"), nil
+		}
+	}
+	return "", err
+}
+
+// npmLatest asks the npm registry for the newest version, with the tool
+// that manages the package
+func npmLatest(tool, pkg string) (string, error) {
+	args := []string{"view", pkg, "version"}
+	if tool == "yarn" {
+		args = []string{"info", pkg, "version", "--silent"}
+	}
+	lines, err := packageOutput(tool, args...)
+	if len(lines) > 0 {
+		return strings.TrimSpace(lines[len(lines)-1]), nil
+	}
+	return "", err
+}
+
+func (n NpmManager) LatestVersion(pkg string) (string, error) {
+	return npmLatest("npm", pkg)
+}
+
+func (y YarnManager) LatestVersion(pkg string) (string, error) {
+	return npmLatest("yarn", pkg)
+}
+
+func (p PnpmManager) LatestVersion(pkg string) (string, error) {
+	return npmLatest("pnpm", pkg)
+}
+
+func (c CargoManager) LatestVersion(pkg string) (string, error) {
+	results, err := c.SearchPackages(pkg)
+	for _, info := range results {
+		if info.Name == pkg {
+			return info.LatestVersion, nil
+		}
+	}
+	return "", err
+}
+
+func (g GemManager) LatestVersion(pkg string) (string, error) {
+	// gem list --remote --exact prints "rails (7.1.3)"
+	lines, err := packageOutput("gem", "list", "--remote", "--exact", pkg)
+	for _, line := range lines {
+		if name, version, ok := strings.Cut(line, " ("); ok && name == pkg {
+			version, _, _ = strings.Cut(strings.TrimSuffix(version, ")"), ",")
+			return version, nil
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,319 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// PlaceholderKind describes what a placeholder stands for so matching completions can be offered
+type PlaceholderKind string
+
+const (
+	PlaceholderBranch    PlaceholderKind = "branch"
+	PlaceholderFile      PlaceholderKind = "file"
+	PlaceholderDirectory PlaceholderKind = "directory"
+	PlaceholderPackage   PlaceholderKind = "package"
+	PlaceholderText      PlaceholderKind = "text"
+)
+
+// maxCompletions caps how many completion candidates are listed at once
+const maxCompletions = 20
+
+// ErrPlaceholderCancelled is returned when the user declines to fill a placeholder
+var ErrPlaceholderCancelled = errors.New("placeholder filling cancelled")
+
+// placeholderPattern matches tokens like <branch>, <file-name>, <remote url> or
+// <path/to/file>. Redirections (sort < in > out) and heredocs (<<EOF) do not match.
+var placeholderPattern = regexp.MustCompile(`(^|[^<])(<([A-Za-z][A-Za-z0-9_./-]*(?: [A-Za-z0-9_./-]+){0,3})>)`)
+
+// safeArgPattern matches values that can be substituted without quoting
+var safeArgPattern = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,~-]+$`)
+
+// Placeholder is a value the model left for the user to fill in
+type Placeholder struct {
+	Token string // Literal text in the command, e.g. "<branch>"
+	Name  string // Name inside the brackets, e.g. "branch"
+	Kind  PlaceholderKind
+}
+
+// FindPlaceholders returns the unique placeholders in a generated command in order of appearance
+func FindPlaceholders(command string) []Placeholder {
+	var placeholders []Placeholder
+	seen := make(map[string]bool)
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+		token, name := match[2], match[3]
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		placeholders = append(placeholders, Placeholder{
+			Token: token,
+			Name:  name,
+			Kind:  classifyPlaceholder(name),
+		})
+	}
+
+	return placeholders
+}
+
+// classifyPlaceholder guesses the kind of value a placeholder name asks for
+func classifyPlaceholder(name string) PlaceholderKind {
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.Contains(lower, "branch"):
+		return PlaceholderBranch
+	case strings.Contains(lower, "dir") || strings.Contains(lower, "folder"):
+		return PlaceholderDirectory
+	case strings.Contains(lower, "file") || strings.Contains(lower, "path"):
+		return PlaceholderFile
+	case strings.Contains(lower, "package") || strings.Contains(lower, "pkg"):
+		return PlaceholderPackage
+	default:
+		return PlaceholderText
+	}
+}
+
+// FillPlaceholders prompts the user for each placeholder and substitutes the answers.
+// Typing a prefix followed by Tab or '?' lists completions; a number picks a listed one.
+func FillPlaceholders(command string, placeholders []Placeholder, env shell.Env) (string, error) {
+	color.Yellow("📝 The generated command contains %d placeholder(s) to fill in", len(placeholders))
+	color.Cyan("💡 Type a prefix then Tab or '?' + Enter for completions, a number to pick one, Enter to cancel")
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, p := range placeholders {
+		value, err := promptPlaceholder(reader, p, env)
+		if err != nil {
+			return "", err
+		}
+
+		command = substitutePlaceholder(command, p.Token, value, env)
+		color.Green("✅ %s → %s", p.Token, value)
+	}
+
+	return command, nil
+}
+
+// promptPlaceholder reads a value for a single placeholder, offering completions on request
+func promptPlaceholder(reader *bufio.Reader, p Placeholder, env shell.Env) (string, error) {
+	var shown []string
+
+	for {
+		fmt.Printf("%s ", color.CyanString("✏️  %s (%s):", p.Token, p.Kind))
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", ErrPlaceholderCancelled
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// A trailing Tab or '?' asks for completions of what was typed so far
+		if strings.HasSuffix(line, "\t") || strings.HasSuffix(line, "?") {
+			prefix := strings.TrimSpace(strings.TrimRight(line, "\t?"))
+			candidates := PlaceholderCompletions(p.Kind, prefix, env)
+
+			switch len(candidates) {
+			case 0:
+				color.Yellow("⚠️  No completions for '%s'", prefix)
+			case 1:
+				color.Green("💡 Completed: %s", candidates[0])
+				if AskForConfirmation(fmt.Sprintf("Use '%s'?", candidates[0])) {
+					return candidates[0], nil
+				}
+			default:
+				shown = candidates
+				for i, candidate := range shown {
+					fmt.Printf("  %2d) %s\n", i+1, candidate)
+				}
+			}
+			continue
+		}
+
+		value := strings.TrimSpace(line)
+		if value == "" {
+			return "", ErrPlaceholderCancelled
+		}
+
+		if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= len(shown) {
+			return shown[n-1], nil
+		}
+
+		return value, nil
+	}
+}
+
+// PlaceholderCompletions lists candidate values of the given kind starting with prefix
+func PlaceholderCompletions(kind PlaceholderKind, prefix string, env shell.Env) []string {
+	var candidates []string
+
+	switch kind {
+	case PlaceholderBranch:
+		candidates = gitRefCompletions(prefix)
+	case PlaceholderFile:
+		candidates = pathCompletions(prefix, false)
+	case PlaceholderDirectory:
+		candidates = pathCompletions(prefix, true)
+	case PlaceholderPackage:
+		candidates = packageCompletions(prefix, env)
+	default:
+		// Free text still benefits from path completion
+		candidates = pathCompletions(prefix, false)
+	}
+
+	if len(candidates) > maxCompletions {
+		candidates = candidates[:maxCompletions]
+	}
+	return candidates
+}
+
+// gitRefCompletions lists local and remote branches matching prefix
+func gitRefCompletions(prefix string) []string {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short)", "refs/heads", "refs/remotes")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var branches []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, "/HEAD") {
+			continue
+		}
+		if strings.HasPrefix(line, prefix) {
+			branches = append(branches, line)
+		}
+	}
+	return branches
+}
+
+// pathCompletions lists files (or only directories) matching prefix
+func pathCompletions(prefix string, dirsOnly bool) []string {
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			paths = append(paths, match+string(filepath.Separator))
+		} else if !dirsOnly {
+			paths = append(paths, match)
+		}
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// packageCompletions asks the system package manager for package names matching prefix
+func packageCompletions(prefix string, env shell.Env) []string {
+	if prefix == "" {
+		// Listing every available package is not useful
+		return nil
+	}
+
+	pm := PackageManagerFactory(env)
+	if pm == nil {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	switch pm.Name() {
+	case "apt":
+		cmd = exec.Command("apt-cache", "pkgnames", prefix)
+	case "pacman":
+		cmd = exec.Command("pacman", "-Ssq", "^"+regexp.QuoteMeta(prefix))
+	case "brew":
+		cmd = exec.Command("brew", "search", prefix)
+	default:
+		return nil
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var packages []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "==>") && strings.HasPrefix(line, prefix) {
+			packages = append(packages, line)
+		}
+	}
+
+	sort.Strings(packages)
+	return packages
+}
+
+// substitutePlaceholder replaces every occurrence of token, quoting the value
+// when it is not already inside quotes and contains shell metacharacters
+func substitutePlaceholder(command, token, value string, env shell.Env) string {
+	var result strings.Builder
+	rest := command
+
+	for {
+		idx := strings.Index(rest, token)
+		if idx < 0 {
+			result.WriteString(rest)
+			break
+		}
+
+		result.WriteString(rest[:idx])
+		if insideQuotes(result.String()) || safeArgPattern.MatchString(value) {
+			result.WriteString(value)
+		} else {
+			result.WriteString(QuoteArgument(value, env))
+		}
+		rest = rest[idx+len(token):]
+	}
+
+	return result.String()
+}
+
+// insideQuotes reports whether the end of text is within an open quoted string
+func insideQuotes(text string) bool {
+	var quote rune
+	for _, r := range text {
+		switch {
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+	}
+	return quote != 0
+}
+
+// QuoteArgument quotes a value for the detected shell so none of it is
+// interpreted. cmd has no escape inside quotes, but doubling a quote keeps
+// it inside them.
+func QuoteArgument(value string, env shell.Env) string {
+	switch env.Shell {
+	case "powershell":
+		return powerShellQuote(value)
+	case "cmd":
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"testing"
+
+	"helix/internal/shell"
+)
+
+func TestQuoteArgument(t *testing.T) {
+	env := shell.Env{Shell: "bash"}
+	for _, value := range []string{
+		"ripgrep",
+		"requests>=2.31,<3",
+		"pkg`id`",
+		"pkg$(rm -rf ~)",
+		`it's "quoted" \ here`,
+		"a; rm -rf / #",
+	} {
+		quoted := QuoteArgument(value, env)
+		script, err := shell.Parse("echo " + quoted)
+		if err != nil {
+			t.Fatalf("parse %q: %v", quoted, err)
+		}
+		cmds := script.Commands()
+		if len(cmds) != 1 || len(cmds[0].Args) != 2 || cmds[0].Args[1].Expands || cmds[0].Args[1].Value != value {
+			t.Errorf("QuoteArgument(%q) = %s, want a single literal word", value, quoted)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// StepStatus is the state of one step of a plan
+type StepStatus string
+
+const (
+	StepPending StepStatus = "pending"
+	StepDone    StepStatus = "done"
+	StepSkipped StepStatus = "skipped"
+	StepFailed  StepStatus = "failed"
+)
+
+// ErrPlanAborted is returned when the user stops a plan before its last step
+var ErrPlanAborted = errors.New("plan aborted")
+
+// PlanStep is one command of a multi-step plan
+type PlanStep struct {
+	Command string
+	Status  StepStatus
+}
+
+// Plan runs commands one after another and stops at the first failure
+type Plan struct {
+	Steps   []PlanStep
+	Confirm bool                                 // ask before each step whether to run, skip or abort it
+	Run     func(step int, command string) error // runs one step; step counts from 0
+}
+
+// NewPlan creates a plan of pending steps
+func NewPlan(commands []string, run func(step int, command string) error) *Plan {
+	plan := &Plan{Run: run}
+	for _, command := range commands {
+		plan.Steps = append(plan.Steps, PlanStep{Command: command, Status: StepPending})
+	}
+	return plan
+}
+
+// PrintChecklist shows the steps as a numbered checklist
+func (p *Plan) PrintChecklist() {
+	for i, step := range p.Steps {
+		line := fmt.Sprintf("  %s %d. %s", step.Status.mark(), i+1, step.Command)
+		switch step.Status {
+		case StepDone:
+			color.Green("%s", line)
+		case StepFailed:
+			color.Red("%s", line)
+		case StepSkipped:
+			color.Yellow("%s", line)
+		default:
+			color.Cyan("%s", line)
+		}
+	}
+}
+
+// Counts returns how many steps ended in each status
+func (p *Plan) Counts() map[StepStatus]int {
+	counts := make(map[StepStatus]int)
+	for _, step := range p.Steps {
+		counts[step.Status]++
+	}
+	return counts
+}
+
+// Execute runs the pending steps in order. It returns ErrPlanAborted when the
+// user stops the plan, or the error of the step that failed; later steps
+// stay pending either way.
+func (p *Plan) Execute() error {
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status != StepPending {
+			continue
+		}
+
+		color.Blue("\n📝 Step %d/%d: %s", i+1, len(p.Steps), step.Command)
+		if p.Confirm {
+			switch askStepAction(fmt.Sprintf("Run step %d?", i+1)) {
+			case stepSkip:
+				step.Status = StepSkipped
+				color.Yellow("⏭️  Step %d skipped", i+1)
+				continue
+			case stepAbort:
+				color.Yellow("🛑 Plan aborted at step %d", i+1)
+				return ErrPlanAborted
+			}
+		}
+
+		if err := p.Run(i, step.Command); err != nil {
+			step.Status = StepFailed
+			color.Red("❌ Command failed at step %d: %v", i+1, err)
+			return err
+		}
+		step.Status = StepDone
+		color.Green("✅ Step %d completed", i+1)
+	}
+	return nil
+}
+
+// mark is the checklist box for a status
+func (s StepStatus) mark() string {
+	switch s {
+	case StepDone:
+		return "[✓]"
+	case StepFailed:
+		return "[✗]"
+	case StepSkipped:
+		return "[-]"
+	default:
+		return "[ ]"
+	}
+}
+
+// stepAction is the user's answer before a step runs
+type stepAction int
+
+const (
+	stepRun stepAction = iota
+	stepSkip
+	stepAbort
+)
+
+// askStepAction asks whether to run, skip or abort a step; anything but yes
+// or skip aborts
+func askStepAction(prompt string) stepAction {
+	var response string
+	fmt.Printf("%s [y]es/[s]kip/[A]bort: ", prompt)
+	fmt.Scanln(&response)
+
+	action := stepAbort
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		action = stepRun
+	case "s", "skip":
+		action = stepSkip
+	}
+	recordDecision(prompt, action == stepRun)
+	return action
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// PolicyPack is a named set of command rules, typically shared by a team
+type PolicyPack struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Block       []string `json:"block"`   // Regex patterns that are never executed
+	Confirm     []string `json:"confirm"` // Regex patterns that always require confirmation
+	Source      string   `json:"-"`
+}
+
+// PolicyDecision is the result of checking a command against the active policy packs
+type PolicyDecision struct {
+	Blocked         bool
+	RequiresConfirm bool
+	Pack            string
+	Pattern         string
+}
+
+// compiledPolicy is a policy pack with its patterns compiled
+type compiledPolicy struct {
+	pack    PolicyPack
+	block   []*regexp.Regexp
+	confirm []*regexp.Regexp
+}
+
+var activePolicies struct {
+	mu    sync.RWMutex
+	packs []compiledPolicy
+}
+
+// SetPolicyPacks replaces the active policy packs. Packs with invalid patterns are rejected.
+func SetPolicyPacks(packs []PolicyPack) error {
+	var compiled []compiledPolicy
+	for _, pack := range packs {
+		cp := compiledPolicy{pack: pack}
+		for _, pattern := range pack.Block {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("policy %s: invalid block pattern %q: %w", pack.Name, pattern, err)
+			}
+			cp.block = append(cp.block, re)
+		}
+		for _, pattern := range pack.Confirm {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("policy %s: invalid confirm pattern %q: %w", pack.Name, pattern, err)
+			}
+			cp.confirm = append(cp.confirm, re)
+		}
+		compiled = append(compiled, cp)
+	}
+
+	activePolicies.mu.Lock()
+	defer activePolicies.mu.Unlock()
+	activePolicies.packs = compiled
+	return nil
+}
+
+// GetPolicyPacks returns the active policy packs
+func GetPolicyPacks() []PolicyPack {
+	activePolicies.mu.RLock()
+	defer activePolicies.mu.RUnlock()
+
+	packs := make([]PolicyPack, 0, len(activePolicies.packs))
+	for _, cp := range activePolicies.packs {
+		packs = append(packs, cp.pack)
+	}
+	return packs
+}
+
+// CheckPolicy checks a command against the project profile, the policy file
+// and the active policy packs. Block rules win over confirm rules.
+func CheckPolicy(command string) PolicyDecision {
+	var decision PolicyDecision
+	if profile := GetProjectProfile(); profile != nil {
+		if decision = profile.Check(command); decision.Blocked {
+			return decision
+		}
+	}
+	if policy := GetCommandPolicy(); policy != nil {
+		if decision = policy.Check(command); decision.Blocked {
+			return decision
+		}
+	}
+
+	activePolicies.mu.RLock()
+	defer activePolicies.mu.RUnlock()
+
+	for _, cp := range activePolicies.packs {
+		for _, re := range cp.block {
+			if re.MatchString(command) {
+				return PolicyDecision{Blocked: true, Pack: cp.pack.Name, Pattern: re.String()}
+			}
+		}
+		if !decision.RequiresConfirm {
+			for _, re := range cp.confirm {
+				if re.MatchString(command) {
+					decision = PolicyDecision{RequiresConfirm: true, Pack: cp.pack.Name, Pattern: re.String()}
+					break
+				}
+			}
+		}
+	}
+	return decision
+}
+
+// enforcePolicy applies the active policy packs before a command runs
+func enforcePolicy(command string, interactive bool) error {
+	decision := CheckPolicy(command)
+
+	if decision.Blocked {
+		return fmt.Errorf("command blocked by policy %s (pattern: %s)", decision.Pack, decision.Pattern)
+	}
+
+	if decision.RequiresConfirm {
+		if !interactive {
+			return fmt.Errorf("policy %s requires confirmation (pattern: %s)", decision.Pack, decision.Pattern)
+		}
+		color.Yellow("📜 Policy %s requires confirmation for this command", decision.Pack)
+		if !AskForConfirmation("Run this command anyway?") {
+			return fmt.Errorf("command cancelled by user")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"helix/internal/shell"
+	"helix/internal/utils"
+)
+
+// PolicyRules match commands by the programs they run, the paths they
+// touch or a regular expression over the whole command line
+type PolicyRules struct {
+	Binaries []string // program names, e.g. nc or sudo
+	Paths    []string // path globs; ** matches any depth, a plain path everything below it
+	Patterns []string // regular expressions
+
+	patterns []*regexp.Regexp
+}
+
+// Empty reports whether no rule is set
+func (r PolicyRules) Empty() bool {
+	return len(r.Binaries)+len(r.Paths)+len(r.Patterns) == 0
+}
+
+// CommandPolicy is the administrator's policy file, ~/.helix/policy.yaml.
+// Deny rules block a command, confirm rules always ask first, and once any
+// allow rule is set only commands the allow rules cover may run.
+type CommandPolicy struct {
+	Allow   PolicyRules
+	Deny    PolicyRules
+	Confirm PolicyRules
+	Source  string
+	Invalid string // why the file could not be read; every command is then blocked
+}
+
+// policySections are the rule sections of a policy file
+var policySections = []string{"allow", "deny", "confirm"}
+
+var activeCommandPolicy struct {
+	mu     sync.RWMutex
+	policy *CommandPolicy
+}
+
+// LoadCommandPolicy reads a policy file. It returns nil without an error
+// when the file does not exist.
+func LoadCommandPolicy(path string) (*CommandPolicy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParseCommandPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	policy.Source = path
+	return policy, nil
+}
+
+// ParseCommandPolicy parses a policy file:
+//
+//	deny:
+//	  binaries: [nc, telnet]
+//	  paths: ["/etc/**"]
+//	  patterns: ['(curl|wget)[^|]*\|\s*(sudo\s+)?(ba)?sh']
+//	confirm:
+//	  binaries: [terraform]
+func ParseCommandPolicy(data []byte) (*CommandPolicy, error) {
+	values, err := utils.FlattenYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &CommandPolicy{}
+	sections := map[string]*PolicyRules{"allow": &policy.Allow, "deny": &policy.Deny, "confirm": &policy.Confirm}
+	for key, list := range values {
+		section, field, _ := strings.Cut(key, ".")
+		rules, ok := sections[section]
+		if !ok {
+			return nil, fmt.Errorf("unknown section %q (use %s)", section, strings.Join(policySections, ", "))
+		}
+		switch field {
+		case "binaries":
+			rules.Binaries = append(rules.Binaries, list...)
+		case "paths":
+			rules.Paths = append(rules.Paths, list...)
+		case "patterns":
+			rules.Patterns = append(rules.Patterns, list...)
+		default:
+			return nil, fmt.Errorf("unknown key %q (use binaries, paths or patterns)", key)
+		}
+	}
+
+	for _, section := range policySections {
+		rules := sections[section]
+		for _, pattern := range rules.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern %q: %w", section, pattern, err)
+			}
+			rules.patterns = append(rules.patterns, re)
+		}
+	}
+	return policy, nil
+}
+
+// SetCommandPolicy replaces the active policy file; nil removes it
+func SetCommandPolicy(policy *CommandPolicy) {
+	activeCommandPolicy.mu.Lock()
+	defer activeCommandPolicy.mu.Unlock()
+	activeCommandPolicy.policy = policy
+}
+
+// GetCommandPolicy returns the active policy file, or nil
+func GetCommandPolicy() *CommandPolicy {
+	activeCommandPolicy.mu.RLock()
+	defer activeCommandPolicy.mu.RUnlock()
+	return activeCommandPolicy.policy
+}
+
+// policyTarget is what a policy looks at in a command
+type policyTarget struct {
+	binaries []string
+	paths    []string
+}
+
+// Check decides how the policy treats a command. Deny rules win over allow
+// rules, which win over confirm rules.
+func (p *CommandPolicy) Check(command string) PolicyDecision {
+	name := "policy.yaml"
+	if p.Source != "" {
+		name = filepath.Base(p.Source)
+	}
+	if p.Invalid != "" {
+		return PolicyDecision{Blocked: true, Pack: name, Pattern: "invalid policy file: " + p.Invalid}
+	}
+
+	target := inspectPolicyTarget(command)
+	if rule := p.Deny.match(command, target); rule != "" {
+		return PolicyDecision{Blocked: true, Pack: name, Pattern: "deny " + rule}
+	}
+	if reason := p.notAllowed(command, target); reason != "" {
+		return PolicyDecision{Blocked: true, Pack: name, Pattern: reason}
+	}
+	if rule := p.Confirm.match(command, target); rule != "" {
+		return PolicyDecision{RequiresConfirm: true, Pack: name, Pattern: "confirm " + rule}
+	}
+	return PolicyDecision{}
+}
+
+// match returns the first rule that matches the command, or ""
+func (r PolicyRules) match(command string, target policyTarget) string {
+	for _, binary := range target.binaries {
+		if containsString(r.Binaries, binary) {
+			return "binary " + binary
+		}
+	}
+	for _, glob := range r.Paths {
+		for _, path := range target.paths {
+			if matchPathGlob(glob, path) {
+				return fmt.Sprintf("path %s (%s)", glob, path)
+			}
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(command) {
+			return "pattern " + re.String()
+		}
+	}
+	return ""
+}
+
+// notAllowed explains why allow rules do not cover a command, or returns ""
+// when there are no allow rules or they do. A matching pattern allows the
+// whole command; otherwise each program and path must be listed, for the
+// kinds of allow rules that are set.
+func (p *CommandPolicy) notAllowed(command string, target policyTarget) string {
+	if p.Allow.Empty() {
+		return ""
+	}
+	for _, re := range p.Allow.patterns {
+		if re.MatchString(command) {
+			return ""
+		}
+	}
+	if len(p.Allow.Binaries) == 0 && len(p.Allow.Paths) == 0 {
+		return "no allow pattern matches"
+	}
+
+	if len(p.Allow.Binaries) > 0 {
+		for _, binary := range target.binaries {
+			if !containsString(p.Allow.Binaries, binary) {
+				return fmt.Sprintf("%s is not an allowed binary", binary)
+			}
+		}
+	}
+	if len(p.Allow.Paths) > 0 {
+		for _, path := range target.paths {
+			allowed := false
+			for _, glob := range p.Allow.Paths {
+				allowed = allowed || matchPathGlob(glob, path)
+			}
+			if !allowed {
+				return fmt.Sprintf("%s is outside the allowed paths", path)
+			}
+		}
+	}
+	return ""
+}
+
+// inspectPolicyTarget finds every program a command runs, including sudo
+// and wrappers like env, and the absolute paths it reads or writes
+func inspectPolicyTarget(command string) policyTarget {
+	var target policyTarget
+	script, err := shell.Parse(command)
+	if err != nil {
+		// Not shell syntax the parser knows, e.g. PowerShell: use the first word
+		if fields := strings.Fields(command); len(fields) > 0 {
+			target.binaries = []string{filepath.Base(fields[0])}
+		}
+		return target
+	}
+
+	for _, cmd := range script.Commands() {
+		target.binaries = append(target.binaries, programChain(cmd)...)
+	}
+	if sim, err := SimulateCommand(command); err == nil {
+		cwd, _ := os.Getwd()
+		for _, path := range append(sim.Reads, sim.Writes...) {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(cwd, path)
+			}
+			target.paths = append(target.paths, filepath.Clean(path))
+		}
+	}
+	target.binaries = uniqueStrings(target.binaries)
+	target.paths = uniqueStrings(target.paths)
+	return target
+}
+
+// programChain returns the programs a simple command starts: sudo and
+// wrappers such as env or nice, then the program they run
+func programChain(cmd *shell.Command) []string {
+	args := cmd.Args
+	for len(args) > 0 && compoundWords[args[0].Value] {
+		if args[0].Value == "for" {
+			return nil
+		}
+		args = args[1:]
+	}
+
+	var programs []string
+	for len(args) > 0 {
+		name := filepath.Base(args[0].Value)
+		programs = append(programs, name)
+		if !privilegePrograms[name] && !wrapperPrograms[name] {
+			break
+		}
+		args = skipOptions(name, args[1:])
+	}
+	return programs
+}
+
+// matchPathGlob reports whether a path matches a policy glob. * matches
+// within one directory, ** across directories (so /etc/** covers /etc too),
+// and a glob without wildcards matches the path itself and everything below it.
+func matchPathGlob(glob, path string) bool {
+	glob = filepath.Clean(expandHome(glob))
+	if !strings.ContainsAny(glob, "*?") {
+		return path == glob || strings.HasPrefix(path, strings.TrimSuffix(glob, string(filepath.Separator))+string(filepath.Separator))
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '/', '\\':
+			// A /** directory level may also be no directory at all
+			if strings.HasPrefix(glob[i+1:], "**") && (i+3 == len(glob) || glob[i+3] == '/' || glob[i+3] == '\\') {
+				pattern.WriteString(`([/\\].*)?`)
+				i += 2
+			} else {
+				pattern.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				pattern.WriteString(".*")
+				i++
+			} else {
+				pattern.WriteString(`[^/\\]*`)
+			}
+		case '?':
+			pattern.WriteString(`[^/\\]`)
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	return err == nil && re.MatchString(path)
+}
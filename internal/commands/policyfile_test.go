@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"/etc/**", "/etc", true},
+		{"/etc/**", "/etc/passwd", true},
+		{"/etc/**", "/etc/ssh/sshd_config", true},
+		{"/etc/**", "/etcetera", false},
+		{"/etc/**", "/usr/etc", false},
+		{"/etc/*", "/etc/passwd", true},
+		{"/etc/*", "/etc/ssh/sshd_config", false},
+		{"/etc/*.conf", "/etc/resolv.conf", true},
+		{"/var/**/log", "/var/log", true},
+		{"/var/**/log", "/var/app/data/log", true},
+		{"/var/**/log", "/var/catalog", false},
+		{"/srv/app?", "/srv/app1", true},
+		{"/srv/app?", "/srv/app", false},
+		{"/etc", "/etc", true},
+		{"/etc", "/etc/hosts", true},
+		{"/etc", "/etcd", false},
+	}
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommandPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    PolicyRules // the deny section
+		wantErr bool
+	}{
+		{
+			name:  "inline and block lists",
+			input: "deny:\n  binaries: [nc, telnet]\n  paths:\n    - \"/etc/**\"\n  patterns: ['(curl|wget)[^|]*\\|\\s*(sudo\\s+)?(ba)?sh']\n",
+			want: PolicyRules{Binaries: []string{"nc", "telnet"}, Paths: []string{"/etc/**"},
+				Patterns: []string{`(curl|wget)[^|]*\|\s*(sudo\s+)?(ba)?sh`}},
+		},
+		{name: "single binary", input: "deny:\n  binaries: nc\n", want: PolicyRules{Binaries: []string{"nc"}}},
+		{name: "unknown section", input: "block:\n  binaries: [nc]\n", wantErr: true},
+		{name: "unknown key", input: "deny:\n  programs: [nc]\n", wantErr: true},
+		{name: "bad pattern", input: "deny:\n  patterns: ['(']\n", wantErr: true},
+		{name: "bad yaml", input: "deny: [nc\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseCommandPolicy([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCommandPolicy error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			policy.Deny.patterns = nil
+			if !reflect.DeepEqual(policy.Deny, tt.want) {
+				t.Fatalf("deny = %+v, want %+v", policy.Deny, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// DefaultBulkFileThreshold is how many files rm, mv or cp may affect through
+// globs before the user has to type the count to go ahead
+const DefaultBulkFileThreshold = 20
+
+// maxPreviewFiles limits how many matched files a preview lists
+const maxPreviewFiles = 20
+
+// previewPrograms are the file operations whose globs are expanded and
+// shown before they run
+var previewPrograms = map[string]bool{"rm": true, "mv": true, "cp": true}
+
+// FilePreview is the files a glob argument of rm, mv or cp matches right now
+type FilePreview struct {
+	Program string
+	Pattern string
+	Files   []string
+}
+
+// PreviewFileOperations expands the glob arguments of rm, mv and cp in a
+// command the way the shell will, without running anything
+func PreviewFileOperations(command string) ([]FilePreview, error) {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var previews []FilePreview
+	for _, cmd := range script.Commands() {
+		name, args, _ := resolveProgram(cmd)
+		if !previewPrograms[name] {
+			continue
+		}
+		for _, arg := range args {
+			if !arg.Glob || len(arg.Substs) > 0 {
+				continue
+			}
+			pattern := arg.Value
+			if arg.Expands {
+				pattern = os.ExpandEnv(pattern)
+			}
+			files, err := filepath.Glob(expandHome(pattern))
+			if err != nil {
+				return nil, fmt.Errorf("bad pattern %s: %w", arg.Raw, err)
+			}
+			previews = append(previews, FilePreview{Program: name, Pattern: arg.Raw, Files: files})
+		}
+	}
+	return previews, nil
+}
+
+// countPreviewFiles returns how many distinct files the previews cover
+func countPreviewFiles(previews []FilePreview) int {
+	seen := make(map[string]bool)
+	for _, preview := range previews {
+		for _, file := range preview.Files {
+			seen[file] = true
+		}
+	}
+	return len(seen)
+}
+
+// bulkFileThreshold returns the configured threshold, or the default
+func (c ExecuteConfig) bulkFileThreshold() int {
+	if c.BulkFileThreshold > 0 {
+		return c.BulkFileThreshold
+	}
+	return DefaultBulkFileThreshold
+}
+
+// PrintFilePreview shows the files each glob matches, the first few of each
+func PrintFilePreview(previews []FilePreview) {
+	for _, preview := range previews {
+		if len(preview.Files) == 0 {
+			color.Yellow("🗂️  %s %s matches no files right now", preview.Program, preview.Pattern)
+			continue
+		}
+		color.Cyan("🗂️  %s %s matches %d file(s):", preview.Program, preview.Pattern, len(preview.Files))
+		shown := preview.Files
+		if len(shown) > maxPreviewFiles {
+			shown = shown[:maxPreviewFiles]
+		}
+		for _, file := range shown {
+			color.White("   %s", file)
+		}
+		if len(preview.Files) > len(shown) {
+			color.White("   … and %d more", len(preview.Files)-len(shown))
+		}
+	}
+}
+
+// confirmFileOperations previews the files a command's globs affect and,
+// above the threshold, makes the user type the file count to continue.
+// Without a terminal to ask, such commands are refused.
+func confirmFileOperations(command string, config ExecuteConfig, interactive bool) error {
+	previews, err := PreviewFileOperations(command)
+	if err != nil || len(previews) == 0 {
+		return nil // syntax is checked elsewhere; nothing to preview
+	}
+
+	total := countPreviewFiles(previews)
+	threshold := config.bulkFileThreshold()
+	if !interactive {
+		if total > threshold {
+			return fmt.Errorf("command would affect %d files through globs (more than %d); run it interactively to confirm", total, threshold)
+		}
+		return nil
+	}
+
+	PrintFilePreview(previews)
+	if total <= threshold {
+		return nil
+	}
+
+	question := fmt.Sprintf("This affects %d files. Type %d to continue", total, total)
+	color.Red("⚠️  %s:", question)
+	var response string
+	fmt.Scanln(&response)
+	approved := strings.TrimSpace(response) == strconv.Itoa(total)
+	recordDecision(question, approved)
+	if !approved {
+		return fmt.Errorf("command cancelled by user")
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// rootPrograms explain why a program is commonly run with sudo
+var rootPrograms = map[string]string{
+	"apt": "installs or removes system packages", "apt-get": "installs or removes system packages",
+	"dnf": "installs or removes system packages", "yum": "installs or removes system packages",
+	"pacman": "installs or removes system packages", "zypper": "installs or removes system packages",
+	"snap": "installs or removes system packages", "dpkg": "installs or removes system packages",
+	"apk": "installs or removes system packages", "rpm": "installs or removes system packages",
+	"systemctl": "manages system services", "service": "manages system services",
+	"mount": "mounts file systems", "umount": "unmounts file systems",
+	"useradd": "changes user accounts", "usermod": "changes user accounts", "userdel": "changes user accounts",
+	"groupadd": "changes user groups", "passwd": "changes passwords",
+	"iptables": "changes the firewall", "nft": "changes the firewall", "ufw": "changes the firewall",
+	"chown": "changes file ownership", "modprobe": "loads kernel modules", "sysctl": "changes kernel settings",
+	"shutdown": "powers off the machine", "reboot": "restarts the machine", "journalctl": "reads the system journal",
+	"lsof": "sees other users' processes", "ss": "sees other users' sockets", "tcpdump": "captures network traffic",
+}
+
+// windowsElevationPatterns match Windows commands that need an
+// administrator shell
+var windowsElevationPatterns = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`(?i)\bchoco\s+(install|uninstall|upgrade)\b|\bwinget\s+(install|uninstall|upgrade)\b.*--scope\s+machine`), "installs software for all users"},
+	{regexp.MustCompile(`(?i)\b(sc(\.exe)?\s+(config|create|delete|stop|start)|(Stop|Start|Restart|Set)-Service)\b`), "manages Windows services"},
+	{regexp.MustCompile(`(?i)\bnet\s+(user|localgroup)\b.*\s/(add|delete)\b`), "changes user accounts"},
+	{regexp.MustCompile(`(?i)\b(netsh|bcdedit|diskpart|dism|sfc)\b`), "changes system configuration"},
+	{regexp.MustCompile(`(?i)\breg\s+(add|delete)\s+HKLM|HKLM:\\`), "changes machine-wide registry settings"},
+	{regexp.MustCompile(`(?i)Set-ExecutionPolicy\b.*LocalMachine`), "changes the machine's execution policy"},
+	{regexp.MustCompile(`(?i)C:\\(Windows|Program Files)`), "writes to a system folder"},
+}
+
+// sudoPattern finds sudo in commands the shell parser cannot read
+var sudoPattern = regexp.MustCompile(`\b(sudo|doas|pkexec)\b`)
+
+// UsesSudo reports whether a command runs something with sudo, doas or pkexec
+func UsesSudo(command string) bool {
+	if sim, err := SimulateCommand(command); err == nil {
+		return sim.Sudo
+	}
+	return sudoPattern.MatchString(command)
+}
+
+// checkSudoAllowed refuses sudo when the never-sudo setting is on
+func checkSudoAllowed(command string, config ExecuteConfig) error {
+	if config.NeverSudo && UsesSudo(command) {
+		return fmt.Errorf("command uses sudo, which the never-sudo setting blocks (/sudo allow to change it)")
+	}
+	return nil
+}
+
+// ExplainElevation lists why the parts of a command run with sudo need root,
+// e.g. "apt installs or removes system packages". It is empty when nothing
+// obviously needs it.
+func ExplainElevation(command string) []string {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil
+	}
+
+	var reasons []string
+	for _, cmd := range script.Commands() {
+		name, _, sudo := resolveProgram(cmd)
+		if !sudo || name == "" {
+			continue
+		}
+		if reason, ok := rootPrograms[name]; ok {
+			reasons = append(reasons, fmt.Sprintf("%s %s", name, reason))
+		}
+	}
+	if sim, err := SimulateCommand(command); err == nil {
+		for _, path := range sim.Writes {
+			if isSystemPath(path) {
+				reasons = append(reasons, "writes "+path)
+			}
+		}
+	}
+	return uniqueStrings(reasons)
+}
+
+// WindowsElevationReason returns why a Windows command needs an
+// administrator shell, or ""
+func WindowsElevationReason(command string) string {
+	for _, rule := range windowsElevationPatterns {
+		if rule.pattern.MatchString(command) {
+			return rule.reason
+		}
+	}
+	return ""
+}
+
+// isElevated reports whether Helix itself runs as root or, on Windows, as
+// an administrator
+var isElevated = sync.OnceValue(func() bool {
+	if runtime.GOOS == "windows" {
+		// Only administrators may list sessions
+		return exec.Command("net", "session").Run() == nil
+	}
+	return os.Geteuid() == 0
+})
+
+// sudoCached reports whether sudo runs without asking for a password,
+// because of a recent authentication or NOPASSWD
+func sudoCached() bool {
+	return exec.Command("sudo", "-n", "true").Run() == nil
+}
+
+// prepareElevation deals with a command's privileges before it runs: sudo
+// asks for its password up front, so the prompt does not interleave with
+// the command's output, and on Windows a command that needs an
+// administrator can be run in an elevated window. It returns true when the
+// command was handed to that window.
+func prepareElevation(command string, env shell.Env, interactive bool) (bool, error) {
+	if UsesSudo(command) {
+		if env.Remote != nil || isElevated() || runtime.GOOS == "windows" {
+			return false, nil // sudo runs on the remote host, or is not needed here
+		}
+		if sudoCached() {
+			return false, nil
+		}
+		if !interactive || !term.IsTerminal(int(os.Stdin.Fd())) {
+			return false, fmt.Errorf("command uses sudo, which needs a password; run it interactively")
+		}
+
+		color.Yellow("🔑 sudo asks for your password before the command starts")
+		auth := exec.Command("sudo", "-v")
+		auth.Stdin, auth.Stdout, auth.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := auth.Run(); err != nil {
+			return false, fmt.Errorf("sudo authentication failed: %w", err)
+		}
+		return false, nil
+	}
+
+	if env.OSName != "windows" || env.Remote != nil || !interactive || isElevated() {
+		return false, nil
+	}
+	reason := WindowsElevationReason(command)
+	if reason == "" {
+		return false, nil
+	}
+
+	color.Yellow("🛡️  This command %s, which needs an administrator shell, and Helix is not running as administrator", reason)
+	switch askElevationAction() {
+	case elevateWindow:
+		if err := runElevated(command, env); err != nil {
+			return false, fmt.Errorf("could not open an elevated window: %w", err)
+		}
+		color.Green("🪟 The command runs in a new administrator window (Windows may ask for permission first)")
+		return true, nil
+	case elevateRunHere:
+		return false, nil
+	default:
+		return false, fmt.Errorf("command cancelled by user")
+	}
+}
+
+// elevationAction is the user's answer when a Windows command needs an
+// administrator shell
+type elevationAction int
+
+const (
+	elevateCancel elevationAction = iota
+	elevateWindow
+	elevateRunHere
+)
+
+// askElevationAction asks whether to run a command in an elevated window,
+// here anyway, or not at all
+func askElevationAction() elevationAction {
+	question := "Run it in an administrator window?"
+	var response string
+	fmt.Printf("%s [y]es/[h]ere anyway/[N]o: ", question)
+	fmt.Scanln(&response)
+
+	action := elevateCancel
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		action = elevateWindow
+	case "h", "here":
+		action = elevateRunHere
+	}
+	recordDecision(question, action != elevateCancel)
+	return action
+}
+
+// runElevated starts a command in a new administrator window through UAC
+func runElevated(command string, env shell.Env) error {
+	program, args := "powershell", []string{"'-NoExit'", "'-Command'", powerShellQuote(command)}
+	if env.Shell == "cmd" {
+		program, args = "cmd", []string{"'/K'", powerShellQuote(command)}
+	}
+	start := fmt.Sprintf("Start-Process %s -Verb RunAs -ArgumentList %s", program, strings.Join(args, ","))
+	return exec.Command("powershell", "-NoProfile", "-Command", start).Run()
+}
+
+// powerShellQuote quotes a string for PowerShell, doubling single quotes
+func powerShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,363 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helix/internal/shell"
+)
+
+// Process is one entry of the process table
+type Process struct {
+	PID     int
+	PPID    int
+	User    string
+	CPU     float64 // percent of one core; unknown on Windows
+	Memory  int64   // resident memory in bytes
+	Name    string
+	Command string // full command line, or the image name on Windows
+}
+
+// ProcessSort orders a process listing
+type ProcessSort int
+
+const (
+	SortByCPU ProcessSort = iota
+	SortByMemory
+	SortByPID
+)
+
+// ProcessFilter selects processes, usually parsed from a plain-English query
+type ProcessFilter struct {
+	Terms []string // match the name or command line; any term may match
+	Port  int      // has a socket on this local port
+	PID   int
+	User  string
+	Sort  ProcessSort
+	Limit int // 0 shows every match
+}
+
+// IsEmpty reports whether the filter selects every process
+func (f ProcessFilter) IsEmpty() bool {
+	return len(f.Terms) == 0 && f.Port == 0 && f.PID == 0 && f.User == ""
+}
+
+var (
+	portQueryPattern  = regexp.MustCompile(`(?i)(?:\bport\s*|:)(\d{1,5})\b`)
+	pidQueryPattern   = regexp.MustCompile(`(?i)\bpid\s*(\d+)\b`)
+	userQueryPattern  = regexp.MustCompile(`(?i)\b(?:owned by|run by|started by|user)\s+([\w.-]+)`)
+	limitQueryPattern = regexp.MustCompile(`(?i)\btop\s*(\d+)\b`)
+	memoryWordPattern = regexp.MustCompile(`(?i)\b(?:memory|mem|ram|rss)\b`)
+	cpuWordPattern    = regexp.MustCompile(`(?i)\bcpu\b`)
+)
+
+// processQueryWords carry no name to match in a process query
+var processQueryWords = map[string]bool{
+	"show": true, "list": true, "find": true, "me": true, "whatever": true, "what": true, "whats": true,
+	"which": true, "who": true, "is": true, "are": true, "using": true, "uses": true, "on": true,
+	"process": true, "processes": true, "running": true, "the": true, "all": true, "that": true,
+	"with": true, "top": true, "most": true, "by": true, "of": true, "any": true, "a": true, "an": true,
+	"named": true, "called": true, "eating": true, "hogging": true, "much": true, "cpu": true,
+	"memory": true, "mem": true, "ram": true, "listening": true, "programs": true, "apps": true,
+	"rss": true, "port": true, "pid": true, "my": true, "in": true, "and": true, "it": true, "taking": true,
+}
+
+// ParseProcessQuery turns a request like "show whatever is using port 8080"
+// or "top 5 by memory" into a filter
+func ParseProcessQuery(query string) ProcessFilter {
+	var filter ProcessFilter
+
+	if match := portQueryPattern.FindStringSubmatch(query); match != nil {
+		filter.Port, _ = strconv.Atoi(match[1])
+		query = strings.Replace(query, match[0], " ", 1)
+	}
+	if match := pidQueryPattern.FindStringSubmatch(query); match != nil {
+		filter.PID, _ = strconv.Atoi(match[1])
+		query = strings.Replace(query, match[0], " ", 1)
+	}
+	if match := userQueryPattern.FindStringSubmatch(query); match != nil {
+		filter.User = match[1]
+		query = strings.Replace(query, match[0], " ", 1)
+	}
+	if match := limitQueryPattern.FindStringSubmatch(query); match != nil {
+		filter.Limit, _ = strconv.Atoi(match[1])
+		query = strings.Replace(query, match[0], " ", 1)
+	}
+
+	if memoryWordPattern.MatchString(query) && !cpuWordPattern.MatchString(query) {
+		filter.Sort = SortByMemory
+	}
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '?' || r == '\'' || r == '"'
+	}) {
+		if len(word) > 1 && !processQueryWords[word] {
+			filter.Terms = append(filter.Terms, word)
+		}
+	}
+	return filter
+}
+
+// ListProcesses reads the process table with ps, or tasklist on Windows
+func ListProcesses(env shell.Env) ([]Process, error) {
+	if env.OSName == "windows" {
+		return listWindowsProcesses()
+	}
+
+	output, err := exec.Command("ps", "-axo", "pid=,ppid=,user=,pcpu=,rss=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ps failed: %w", err)
+	}
+
+	var processes []Process
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		cpu, _ := strconv.ParseFloat(fields[3], 64)
+		rss, _ := strconv.ParseInt(fields[4], 10, 64)
+		command := strings.Join(fields[5:], " ")
+		processes = append(processes, Process{
+			PID:     pid,
+			PPID:    ppid,
+			User:    fields[2],
+			CPU:     cpu,
+			Memory:  rss * 1024,
+			Name:    filepath.Base(fields[5]),
+			Command: command,
+		})
+	}
+	return processes, scanner.Err()
+}
+
+// listWindowsProcesses parses the CSV output of tasklist
+func listWindowsProcesses() ([]Process, error) {
+	output, err := exec.Command("tasklist", "/fo", "csv", "/nh").Output()
+	if err != nil {
+		return nil, fmt.Errorf("tasklist failed: %w", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read tasklist output: %w", err)
+	}
+
+	var processes []Process
+	for _, record := range records {
+		if len(record) < 5 {
+			continue
+		}
+		pid, err := strconv.Atoi(record[1])
+		if err != nil {
+			continue
+		}
+		// Memory looks like "12,345 K"
+		kb, _ := strconv.ParseInt(strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, record[4]), 10, 64)
+		processes = append(processes, Process{PID: pid, Name: record[0], Command: record[0], Memory: kb * 1024})
+	}
+	return processes, nil
+}
+
+// FilterProcesses returns the processes a filter selects, sorted and limited
+func FilterProcesses(processes []Process, filter ProcessFilter, env shell.Env) ([]Process, error) {
+	var portPIDs map[int]bool
+	if filter.Port > 0 {
+		pids, err := ProcessesOnPort(filter.Port, env)
+		if err != nil {
+			return nil, err
+		}
+		portPIDs = make(map[int]bool, len(pids))
+		for _, pid := range pids {
+			portPIDs[pid] = true
+		}
+	}
+
+	var matches []Process
+	for _, process := range processes {
+		switch {
+		case portPIDs != nil && !portPIDs[process.PID]:
+		case filter.PID > 0 && process.PID != filter.PID:
+		case filter.User != "" && !strings.EqualFold(process.User, filter.User):
+		case len(filter.Terms) > 0 && !matchesAnyTerm(process, filter.Terms):
+		default:
+			matches = append(matches, process)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		switch filter.Sort {
+		case SortByMemory:
+			return matches[i].Memory > matches[j].Memory
+		case SortByPID:
+			return matches[i].PID < matches[j].PID
+		}
+		return matches[i].CPU > matches[j].CPU
+	})
+	if filter.Limit > 0 && len(matches) > filter.Limit {
+		matches = matches[:filter.Limit]
+	}
+	return matches, nil
+}
+
+// matchesAnyTerm reports whether a process name or command line contains a term
+func matchesAnyTerm(process Process, terms []string) bool {
+	name := strings.ToLower(process.Name)
+	command := strings.ToLower(process.Command)
+	for _, term := range terms {
+		if strings.Contains(name, term) || strings.Contains(command, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessesOnPort returns the PIDs with a socket on a local port, using lsof,
+// /proc on Linux, or netstat on Windows
+func ProcessesOnPort(port int, env shell.Env) ([]int, error) {
+	if env.OSName == "windows" {
+		return windowsPortPIDs(port)
+	}
+
+	if lsof, err := exec.LookPath("lsof"); err == nil {
+		// lsof exits with 1 when nothing matches
+		output, _ := exec.Command(lsof, "-nP", "-t", fmt.Sprintf("-i:%d", port)).Output()
+		var pids []int
+		for _, field := range strings.Fields(string(output)) {
+			if pid, err := strconv.Atoi(field); err == nil {
+				pids = append(pids, pid)
+			}
+		}
+		return pids, nil
+	}
+
+	if env.OSName == "linux" {
+		return procPortPIDs(port)
+	}
+	return nil, fmt.Errorf("lsof is needed to find processes by port")
+}
+
+// procPortPIDs finds the sockets on a port in /proc/net and the processes
+// holding them. Only processes this user may inspect are found.
+func procPortPIDs(port int) ([]int, error) {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		data, err := os.ReadFile(filepath.Join("/proc/net", table))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n")[1:] {
+			fields := strings.Fields(line)
+			if len(fields) < 10 {
+				continue
+			}
+			_, hexPort, found := strings.Cut(fields[1], ":")
+			if localPort, err := strconv.ParseInt(hexPort, 16, 32); found && err == nil && int(localPort) == port {
+				inodes["socket:["+fields[9]+"]"] = true
+			}
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	fdDirs, _ := filepath.Glob("/proc/[0-9]*/fd")
+	var pids []int
+	for _, dir := range fdDirs {
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if target, err := os.Readlink(filepath.Join(dir, fd.Name())); err == nil && inodes[target] {
+				pid, _ := strconv.Atoi(filepath.Base(filepath.Dir(dir)))
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids, nil
+}
+
+// windowsPortPIDs parses netstat -ano for sockets on a local port
+func windowsPortPIDs(port int) ([]int, error) {
+	output, err := exec.Command("netstat", "-ano").Output()
+	if err != nil {
+		return nil, fmt.Errorf("netstat failed: %w", err)
+	}
+
+	suffix := ":" + strconv.Itoa(port)
+	seen := make(map[int]bool)
+	var pids []int
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[0] != "TCP" && fields[0] != "UDP") || !strings.HasSuffix(fields[1], suffix) {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[len(fields)-1]); err == nil && pid > 0 && !seen[pid] {
+			seen[pid] = true
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// KillCommand returns the command that stops processes, forcibly with force
+func KillCommand(pids []int, force bool, env shell.Env) string {
+	if env.OSName == "windows" {
+		parts := []string{"taskkill"}
+		if force {
+			parts = append(parts, "/F")
+		}
+		for _, pid := range pids {
+			parts = append(parts, "/PID", strconv.Itoa(pid))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	parts := []string{"kill"}
+	if force {
+		parts = append(parts, "-9")
+	}
+	for _, pid := range pids {
+		parts = append(parts, strconv.Itoa(pid))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ReniceCommand returns the command that changes the priority of processes
+func ReniceCommand(pids []int, niceness int, env shell.Env) (string, error) {
+	if env.OSName == "windows" {
+		return "", fmt.Errorf("renice is not available on Windows")
+	}
+	if niceness < -20 || niceness > 19 {
+		return "", fmt.Errorf("niceness must be from -20 to 19")
+	}
+
+	parts := []string{"renice", "-n", strconv.Itoa(niceness), "-p"}
+	for _, pid := range pids {
+		parts = append(parts, strconv.Itoa(pid))
+	}
+	return strings.Join(parts, " "), nil
+}
@@ -0,0 +1,136 @@
+//go:build !windows
+
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// stdinPollInterval is how often the stdin feed checks whether the command
+// has finished while no input arrives
+const stdinPollInterval = 100 // milliseconds
+
+func init() {
+	// Helix reclaims the terminal after each child exits; ignoring SIGTTOU
+	// keeps that tcsetpgrp call from stopping us while we are in the background
+	signal.Ignore(syscall.SIGTTOU)
+}
+
+// configureProcessGroup puts the child in its own process group, so its
+// signals never reach Helix. Helix keeps the terminal, receives Ctrl+C and
+// forwards it to the group, which lets a second Ctrl+C kill a command that
+// ignores the first; when stdin is the terminal, the child reads it through
+// a pipe Helix feeds. A program that takes over the terminal (an editor, a
+// pager) is made the foreground group instead, and quits with its own keys.
+// The returned function is called once the child has exited.
+func configureProcessGroup(cmd *exec.Cmd, takesTerminal bool) func() {
+	if cmd.Stdin != os.Stdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		return func() {}
+	}
+
+	if takesTerminal {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Foreground: true,
+			Ctty:       int(os.Stdin.Fd()),
+		}
+		return restoreForeground
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	r, w, err := os.Pipe()
+	if err != nil {
+		// Without a pipe the child reads nothing rather than stopping on SIGTTIN
+		cmd.Stdin = nil
+		return func() {}
+	}
+	cmd.Stdin = r
+	stop := feedStdin(w)
+	return func() {
+		stop()
+		r.Close()
+	}
+}
+
+// feedStdin copies what is typed on the terminal into w until the returned
+// function is called; Ctrl+D closes w so the command sees the end of its
+// input. Reads only happen once input is waiting, so nothing typed after the
+// command finished is taken from the prompt.
+func feedStdin(w *os.File) func() {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	fd := int(os.Stdin.Fd())
+
+	go func() {
+		defer close(finished)
+		defer w.Close()
+		buf := make([]byte, 4096)
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			ready, err := unix.Poll(fds, stdinPollInterval)
+			if err == unix.EINTR || (err == nil && ready == 0) {
+				continue
+			}
+			if err != nil {
+				return
+			}
+			n, err := unix.Read(fd, buf)
+			if err == unix.EINTR || err == unix.EAGAIN {
+				continue
+			}
+			if n <= 0 || err != nil {
+				return
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return // the command closed its stdin
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// restoreForeground hands the terminal back to Helix's process group
+func restoreForeground() {
+	unix.IoctlSetPointerInt(int(os.Stdin.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+}
+
+// killProcessGroup kills every process in the child's group, e.g. when it
+// ran out of time
+func killProcessGroup(p *os.Process) {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil {
+		p.Kill()
+	}
+}
+
+// killProcessGroupID kills every process left in a process group
+func killProcessGroupID(pgid int) {
+	syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// processGroupAlive reports whether any process is still in a group
+func processGroupAlive(pgid int) bool {
+	return syscall.Kill(-pgid, 0) == nil
+}
+
+// interruptProcessGroup delivers SIGINT to every process in the child's group
+func interruptProcessGroup(p *os.Process) {
+	// The child is the group leader, so its PID is the group ID
+	if err := syscall.Kill(-p.Pid, syscall.SIGINT); err != nil {
+		p.Signal(os.Interrupt)
+	}
+}
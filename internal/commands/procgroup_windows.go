@@ -0,0 +1,37 @@
+//go:build windows
+
+package commands
+
+import (
+	"os"
+	"os/exec"
+)
+
+// configureProcessGroup leaves the child attached to the console; Windows
+// delivers Ctrl+C to every process sharing it
+func configureProcessGroup(cmd *exec.Cmd, takesTerminal bool) func() {
+	return func() {}
+}
+
+// restoreForeground is a no-op on Windows
+func restoreForeground() {}
+
+// killProcessGroup terminates the child
+func killProcessGroup(p *os.Process) {
+	p.Kill()
+}
+
+// killProcessGroupID is a no-op on Windows, where commands get no process
+// group of their own to outlive them
+func killProcessGroupID(pgid int) {}
+
+// processGroupAlive reports false on Windows; see killProcessGroupID
+func processGroupAlive(pgid int) bool {
+	return false
+}
+
+// interruptProcessGroup terminates the child since Windows cannot signal a
+// single console process group from Go
+func interruptProcessGroup(p *os.Process) {
+	p.Kill()
+}
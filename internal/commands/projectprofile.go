@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"helix/internal/utils"
+)
+
+// ProjectProfileFile is the profile a repository keeps in its root
+const ProjectProfileFile = ".helix.yaml"
+
+// ProjectProfile is a repository's .helix.yaml: the sandbox and policy
+// Helix uses while working inside it, e.g. strict mode for a production-ops
+// repository
+type ProjectProfile struct {
+	Root            string
+	Source          string
+	Mode            *SandboxMode // nil keeps the user's mode
+	Paths           SandboxPaths // absolute; relative paths in the file are relative to Root
+	BlockedBinaries []string
+	DryRun          bool   // start in dry-run mode; false leaves the user's setting
+	Invalid         string // why the file could not be read; every command is then blocked
+	Hash            string // SHA-256 of the file; trust to loosen the sandbox is tied to it
+}
+
+var activeProjectProfile struct {
+	mu      sync.RWMutex
+	profile *ProjectProfile
+}
+
+// LoadProjectProfile reads the .helix.yaml in a project root. It returns
+// nil without an error when there is none.
+func LoadProjectProfile(root string) (*ProjectProfile, error) {
+	path := filepath.Join(root, ProjectProfileFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ParseProjectProfile(data, root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	profile.Source = path
+	sum := sha256.Sum256(data)
+	profile.Hash = hex.EncodeToString(sum[:])
+	return profile, nil
+}
+
+// ParseProjectProfile parses a project profile:
+//
+//	sandbox:
+//	  mode: strict            # off, current or strict
+//	  allowed: [../shared]
+//	  denied: [secrets, .env]
+//	blocked_binaries: [terraform, kubectl]
+//	dry_run: true
+func ParseProjectProfile(data []byte, root string) (*ProjectProfile, error) {
+	values, err := utils.FlattenYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &ProjectProfile{Root: root}
+	for key, list := range values {
+		switch key {
+		case "sandbox.mode", "dry_run":
+			if len(list) != 1 {
+				return nil, fmt.Errorf("%s takes one value", key)
+			}
+		}
+		switch key {
+		case "sandbox.mode":
+			mode, err := ParseSandboxMode(list[0])
+			if err != nil {
+				return nil, err
+			}
+			profile.Mode = &mode
+		case "sandbox.allowed":
+			profile.Paths.Allowed = append(profile.Paths.Allowed, profilePaths(root, list)...)
+		case "sandbox.denied":
+			profile.Paths.Denied = append(profile.Paths.Denied, profilePaths(root, list)...)
+		case "blocked_binaries":
+			profile.BlockedBinaries = append(profile.BlockedBinaries, list...)
+		case "dry_run":
+			switch strings.ToLower(list[0]) {
+			case "true", "yes", "on":
+				profile.DryRun = true
+			case "false", "no", "off":
+			default:
+				return nil, fmt.Errorf("dry_run must be true or false, not %q", list[0])
+			}
+		default:
+			return nil, fmt.Errorf("unknown key %q (use sandbox.mode, sandbox.allowed, sandbox.denied, blocked_binaries or dry_run)", key)
+		}
+	}
+	return profile, nil
+}
+
+// profilePaths makes a profile's paths absolute, relative to the project
+// root; ~ is left for the sandbox to expand
+func profilePaths(root string, paths []string) []string {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !filepath.IsAbs(path) && !strings.HasPrefix(path, "~") {
+			path = filepath.Join(root, path)
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved
+}
+
+// ParseSandboxMode reads a sandbox mode name: off, current or strict, or
+// one of their synonyms
+func ParseSandboxMode(name string) (SandboxMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "off", "disable", "disabled", "none":
+		return SandboxDisabled, nil
+	case "current", "dir", "normal":
+		return SandboxCurrentDir, nil
+	case "strict", "tight", "restricted":
+		return SandboxStrict, nil
+	}
+	return SandboxDisabled, fmt.Errorf("unknown sandbox mode %q (use off, current or strict)", name)
+}
+
+// Loosening lists what the profile would relax in the user's sandbox: a
+// weaker mode, or allowed paths outside the user's own. Without the user's
+// trust only the rest of the profile applies.
+func (p *ProjectProfile) Loosening(ds *DirectorySandbox) []string {
+	var loosened []string
+	if p.Mode != nil && *p.Mode < ds.GetMode() {
+		loosened = append(loosened, fmt.Sprintf("sandbox mode %s instead of %s", modeName(*p.Mode), modeName(ds.GetMode())))
+	}
+	for _, path := range p.Paths.Allowed {
+		if !ds.userAllows(path) {
+			loosened = append(loosened, "access to "+path)
+		}
+	}
+	return loosened
+}
+
+// Tightened returns the profile without the settings Loosening lists: the
+// mode only when it is stricter, and the allowed paths the user allows too
+func (p *ProjectProfile) Tightened(ds *DirectorySandbox) *ProjectProfile {
+	tightened := *p
+	if p.Mode != nil && *p.Mode < ds.GetMode() {
+		tightened.Mode = nil
+	}
+	tightened.Paths.Allowed = nil
+	for _, path := range p.Paths.Allowed {
+		if ds.userAllows(path) {
+			tightened.Paths.Allowed = append(tightened.Paths.Allowed, path)
+		}
+	}
+	return &tightened
+}
+
+// userAllows reports whether path is inside one of the user's own allowed
+// paths
+func (ds *DirectorySandbox) userAllows(path string) bool {
+	resolved := ds.resolvePath(path)
+	for _, allowed := range ds.paths.Allowed {
+		if ds.pathWithin(resolved, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// modeName is the name of a sandbox mode in .helix.yaml
+func modeName(mode SandboxMode) string {
+	switch mode {
+	case SandboxDisabled:
+		return "off"
+	case SandboxStrict:
+		return "strict"
+	}
+	return "current"
+}
+
+// SetProjectProfile replaces the active project profile; nil removes it
+func SetProjectProfile(profile *ProjectProfile) {
+	activeProjectProfile.mu.Lock()
+	defer activeProjectProfile.mu.Unlock()
+	activeProjectProfile.profile = profile
+}
+
+// GetProjectProfile returns the active project profile, or nil
+func GetProjectProfile() *ProjectProfile {
+	activeProjectProfile.mu.RLock()
+	defer activeProjectProfile.mu.RUnlock()
+	return activeProjectProfile.profile
+}
+
+// Check decides how the project profile treats a command: it blocks the
+// programs the profile lists, or everything when the file is invalid
+func (p *ProjectProfile) Check(command string) PolicyDecision {
+	if p.Invalid != "" {
+		return PolicyDecision{Blocked: true, Pack: ProjectProfileFile, Pattern: "invalid profile: " + p.Invalid}
+	}
+	if len(p.BlockedBinaries) == 0 {
+		return PolicyDecision{}
+	}
+	for _, binary := range inspectPolicyTarget(command).binaries {
+		if containsString(p.BlockedBinaries, binary) {
+			return PolicyDecision{Blocked: true, Pack: ProjectProfileFile, Pattern: "blocked binary " + binary}
+		}
+	}
+	return PolicyDecision{}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseProjectProfile(t *testing.T) {
+	root := filepath.FromSlash("/work/app")
+	strict := SandboxStrict
+	tests := []struct {
+		name    string
+		input   string
+		want    ProjectProfile
+		wantErr bool
+	}{
+		{
+			name:  "every key",
+			input: "sandbox:\n  mode: strict  # off, current or strict\n  allowed: [../shared]\n  denied:\n    - secrets\n    - ~/.aws\nblocked_binaries: [terraform, kubectl]\ndry_run: yes\n",
+			want: ProjectProfile{Root: root, Mode: &strict,
+				Paths: SandboxPaths{Allowed: []string{filepath.Join(root, "../shared")},
+					Denied: []string{filepath.Join(root, "secrets"), "~/.aws"}},
+				BlockedBinaries: []string{"terraform", "kubectl"}, DryRun: true},
+		},
+		{name: "empty", input: "# nothing set\n", want: ProjectProfile{Root: root}},
+		{name: "unknown key", input: "sandbox:\n  modes: strict\n", wantErr: true},
+		{name: "two modes", input: "sandbox:\n  mode: [strict, off]\n", wantErr: true},
+		{name: "bad dry_run", input: "dry_run: maybe\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseProjectProfile([]byte(tt.input), root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProjectProfile error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(*profile, tt.want) {
+				t.Fatalf("profile = %+v, want %+v", *profile, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectProfileLoosening(t *testing.T) {
+	root := t.TempDir()
+	shared := filepath.Join(root, "shared")
+	off, strict := SandboxDisabled, SandboxStrict
+	sandbox := &DirectorySandbox{allowedDir: root, mode: SandboxCurrentDir}
+	sandbox.SetPaths(SandboxPaths{Allowed: []string{shared}})
+
+	tests := []struct {
+		name     string
+		profile  ProjectProfile
+		loosened int
+		want     ProjectProfile
+	}{
+		{
+			name:    "stricter mode and a path the user allows",
+			profile: ProjectProfile{Mode: &strict, Paths: SandboxPaths{Allowed: []string{filepath.Join(shared, "lib")}}},
+			want:    ProjectProfile{Mode: &strict, Paths: SandboxPaths{Allowed: []string{filepath.Join(shared, "lib")}}},
+		},
+		{
+			name:     "weaker mode",
+			profile:  ProjectProfile{Mode: &off, BlockedBinaries: []string{"kubectl"}},
+			loosened: 1,
+			want:     ProjectProfile{BlockedBinaries: []string{"kubectl"}},
+		},
+		{
+			name:     "paths outside the user's",
+			profile:  ProjectProfile{Paths: SandboxPaths{Allowed: []string{"/", filepath.Join(shared, "lib")}, Denied: []string{"/etc"}}},
+			loosened: 1,
+			want:     ProjectProfile{Paths: SandboxPaths{Allowed: []string{filepath.Join(shared, "lib")}, Denied: []string{"/etc"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if loosened := tt.profile.Loosening(sandbox); len(loosened) != tt.loosened {
+				t.Fatalf("Loosening = %q, want %d settings", loosened, tt.loosened)
+			}
+			if got := tt.profile.Tightened(sandbox); !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("Tightened = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"helix/internal/utils"
+)
+
+// RiskLevel classifies how much damage a command could do
+type RiskLevel string
+
+const (
+	RiskLow      RiskLevel = "low"
+	RiskMedium   RiskLevel = "medium"
+	RiskHigh     RiskLevel = "high"
+	RiskCritical RiskLevel = "critical"
+)
+
+// riskLevels lists the levels from least to most severe
+var riskLevels = []RiskLevel{RiskLow, RiskMedium, RiskHigh, RiskCritical}
+
+// Rank returns the severity order of the level (low = 0)
+func (l RiskLevel) Rank() int {
+	for i, level := range riskLevels {
+		if level == l {
+			return i
+		}
+	}
+	return len(riskLevels)
+}
+
+// riskSymbols tell the levels apart by shape, for readers who cannot tell
+// the colors apart
+var riskSymbols = map[RiskLevel]string{
+	RiskLow:      "○",
+	RiskMedium:   "△",
+	RiskHigh:     "▲",
+	RiskCritical: "✖",
+}
+
+// Badge labels the level with a symbol and a word, e.g. "▲ [HIGH]", so the
+// level reads the same without color
+func (l RiskLevel) Badge() string {
+	symbol, known := riskSymbols[l]
+	if !known {
+		symbol = "?"
+	}
+	return fmt.Sprintf("%s [%s]", symbol, strings.ToUpper(string(l)))
+}
+
+// MaxAutoApproveLevel is the highest risk level that may ever run without a
+// human confirming it. High and critical commands always fail closed.
+const MaxAutoApproveLevel = RiskMedium
+
+// ParseAutoApproveLevels parses an auto-approval scope such as "low,medium"
+// and returns the highest level it approves. Listing a level above
+// MaxAutoApproveLevel is an error rather than being silently ignored.
+func ParseAutoApproveLevels(spec string) (RiskLevel, error) {
+	var highest RiskLevel
+	for _, part := range strings.Split(spec, ",") {
+		level := RiskLevel(strings.ToLower(strings.TrimSpace(part)))
+		switch {
+		case level == "":
+			continue
+		case level.Rank() == len(riskLevels):
+			return "", fmt.Errorf("unknown risk level %q (use low or medium)", part)
+		case level.Rank() > MaxAutoApproveLevel.Rank():
+			return "", fmt.Errorf("%s risk commands can never be auto-approved", level)
+		}
+		if highest == "" || level.Rank() > highest.Rank() {
+			highest = level
+		}
+	}
+	if highest == "" {
+		return "", fmt.Errorf("no risk levels given (use e.g. low,medium)")
+	}
+	return highest, nil
+}
+
+// RiskCategory names a kind of effect a command has
+type RiskCategory string
+
+const (
+	CategoryReadOnly      RiskCategory = "read-only"
+	CategoryModifiesFiles RiskCategory = "modifies files"
+	CategoryDestructive   RiskCategory = "destructive"
+	CategoryNeedsRoot     RiskCategory = "needs root"
+	CategoryNetwork       RiskCategory = "network egress"
+)
+
+// RiskAssessment is the scored risk of a single command
+type RiskAssessment struct {
+	Level      RiskLevel      `json:"level"`
+	Score      int            `json:"score"`
+	Categories []RiskCategory `json:"categories,omitempty"`
+	Blocked    bool           `json:"blocked,omitempty"` // matches a blocked dangerous pattern
+	// ConfirmToken is what the user types to run a destructive command: the
+	// directory, branch or device it destroys, the most dangerous one when
+	// it destroys several, which ConfirmTargets lists
+	ConfirmToken   string   `json:"confirm_token,omitempty"`
+	ConfirmTargets []string `json:"confirm_targets,omitempty"`
+	Reasons        []string `json:"reasons,omitempty"`
+}
+
+// Has reports whether the command was found to have an effect of the category
+func (a RiskAssessment) Has(category RiskCategory) bool {
+	for _, c := range a.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryNames lists the categories for display, e.g. "destructive, needs root"
+func (a RiskAssessment) CategoryNames() string {
+	names := make([]string, len(a.Categories))
+	for i, category := range a.Categories {
+		names[i] = string(category)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Confirmation is how strictly a command is confirmed before it runs
+type Confirmation int
+
+const (
+	ConfirmNone   Confirmation = iota // runs without an extra question
+	ConfirmPrompt                     // asks y/N
+	ConfirmWarn                       // shows why the command is risky, then asks y/N
+	ConfirmTyped                      // the user must type the target or "yes"
+)
+
+// Confirmation returns how strictly a command of this risk is confirmed.
+// Destroying a named directory, branch or device always needs its name typed.
+func (a RiskAssessment) Confirmation() Confirmation {
+	if a.ConfirmToken != "" {
+		return ConfirmTyped
+	}
+	switch a.Level {
+	case RiskMedium:
+		return ConfirmPrompt
+	case RiskHigh:
+		return ConfirmWarn
+	case RiskCritical:
+		return ConfirmTyped
+	}
+	return ConfirmNone
+}
+
+// add records an effect of the command with its score and reason
+func (a *RiskAssessment) add(category RiskCategory, score int, reason string) {
+	a.Score += score
+	a.Reasons = append(a.Reasons, reason)
+	if !a.Has(category) {
+		a.Categories = append(a.Categories, category)
+	}
+}
+
+// riskRule adds score when a command matches its pattern
+type riskRule struct {
+	pattern  *regexp.Regexp
+	category RiskCategory
+	score    int
+	reason   string
+}
+
+// dangerousPatterns are always blocked in safe mode
+var dangerousPatterns = []string{
+	"rm -rf /", "rm -rf /*", "format c:", "mkfs", "fdisk", "dd if=/dev/zero",
+	"> /dev/sda", "chmod -R 777 /", "mv / /dev/null", "> /etc/passwd",
+	":(){ :|:& };:", "fork bomb", "debugfs", "mkswap", "swapoff", "> /boot",
+}
+
+// riskRules are the heuristics used to score commands
+var riskRules = []riskRule{
+	{regexp.MustCompile(`\brm\s+(-[a-zA-Z]*[rR][a-zA-Z]*f|-[a-zA-Z]*f[a-zA-Z]*[rR]|-[rR]\s+-f|-f\s+-[rR])`), CategoryDestructive, 60, "recursive forced deletion"},
+	{regexp.MustCompile(`\b(rm|rmdir|del|Remove-Item)\b`), CategoryDestructive, 30, "deletes files"},
+	{regexp.MustCompile(`\bfind\b.*\s(-delete\b|-exec(dir)?\s+(rm|shred|unlink)\b)`), CategoryDestructive, 60, "deletes every file find matches"},
+	{regexp.MustCompile(`\b(shred|truncate)\b`), CategoryDestructive, 50, "destroys file contents"},
+	{regexp.MustCompile(`\b(perl|python[0-9.]*|ruby|node|php)\b.*\s-[a-zA-Z]*[ceE]\b.*\b(unlink(Sync)?|rmtree|remove|rmdir(Sync)?|rm(_rf|_r|Sync)?|rm_f|delete)\b`), CategoryDestructive, 60, "deletes files from an interpreter one-liner"},
+	{regexp.MustCompile(`\b(docker|podman)\s+((container|image|volume|network|system|builder)\s+)?(rm|rmi|prune)\b`), CategoryDestructive, 50, "removes containers, images or volumes"},
+	{regexp.MustCompile(`\bkubectl\b.*\sdelete\b`), CategoryDestructive, 60, "deletes cluster resources"},
+	{regexp.MustCompile(`\|\s*(sudo\s+)?(sh|bash|zsh|dash|ksh)\b`), CategoryModifiesFiles, 50, "runs piped text as a shell script"},
+	{regexp.MustCompile(`\b(sudo|doas|runas|pkexec)\b`), CategoryNeedsRoot, 25, "runs with elevated privileges"},
+	{regexp.MustCompile(`\b(chmod|chown|chgrp|icacls)\b`), CategoryModifiesFiles, 25, "changes permissions or ownership"},
+	{regexp.MustCompile(`\b(dd|mkfs(\.\w+)?|fdisk|parted|wipefs|mkswap)\b`), CategoryDestructive, 70, "writes directly to disks"},
+	{regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(sh|bash|zsh)\b`), CategoryNetwork, 60, "pipes a download into a shell"},
+	{regexp.MustCompile(`\bgit\s+(push\b.*(--force|-f\b|\s\+\S)|reset\s+--(hard|soft)|clean\s+-[a-zA-Z]*f|branch\s+-D|checkout\s+--(theirs|ours))`), CategoryDestructive, 40, "discards or rewrites git changes"},
+	{regexp.MustCompile(`\b(kill|killall|pkill|shutdown|reboot|halt)\b|\bsystemctl\s+(stop|restart|disable|mask)\b`), CategoryDestructive, 35, "stops processes or services"},
+	{regexp.MustCompile(`\b(apt|apt-get|yum|dnf|zypper|pacman|brew|choco|winget)\b.*\b(install|remove|purge|upgrade|uninstall|-S|-R)\b`), CategoryModifiesFiles, 20, "modifies installed packages"},
+	{regexp.MustCompile(`\bapk\b.*\b(add|del|upgrade)\b`), CategoryModifiesFiles, 20, "modifies installed packages"},
+	{regexp.MustCompile(`(^|[^>&0-9])>\s*[^\s&]`), CategoryModifiesFiles, 20, "overwrites a file via redirection"},
+	{regexp.MustCompile(`\b(mv|move|Move-Item)\b`), CategoryModifiesFiles, 20, "moves or renames files"},
+}
+
+// systemPaths are directories only root may normally write to
+var systemPaths = []string{
+	"/etc", "/usr", "/bin", "/sbin", "/lib", "/boot", "/var", "/opt", "/System", "/Library",
+	`C:\Windows`, `C:\Program Files`,
+}
+
+// RiskEngine scores commands by what they do: read, modify or destroy
+// files, need root, or reach the network
+type RiskEngine struct {
+	blockedPatterns []string
+	rules           []riskRule
+}
+
+// NewRiskEngine creates a risk engine with Helix's built-in rules
+func NewRiskEngine() *RiskEngine {
+	return &RiskEngine{blockedPatterns: dangerousPatterns, rules: riskRules}
+}
+
+// defaultRiskEngine scores the commands Helix runs
+var defaultRiskEngine = NewRiskEngine()
+
+// AssessRisk scores a command with the default risk engine
+func AssessRisk(command string) RiskAssessment {
+	return defaultRiskEngine.Assess(command)
+}
+
+// IsCommandSafe checks if a command contains dangerous patterns
+func IsCommandSafe(command string) bool {
+	return !defaultRiskEngine.matchesBlockedPattern(command)
+}
+
+// matchesBlockedPattern reports whether a command contains a pattern that
+// is never run in safe mode
+func (e *RiskEngine) matchesBlockedPattern(command string) bool {
+	cmdLower := strings.ToLower(command)
+	for _, pattern := range e.blockedPatterns {
+		if strings.Contains(cmdLower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Assess scores a command. The rules match the command line, and parsing it
+// as the shell would finds the files it writes, system paths and hosts the
+// rules do not name.
+func (e *RiskEngine) Assess(command string) RiskAssessment {
+	assessment := RiskAssessment{}
+
+	// Blocked patterns are always critical
+	if e.matchesBlockedPattern(command) || utils.ValidateCommand(command) != nil {
+		assessment.Blocked = true
+		assessment.add(CategoryDestructive, 100, "matches a blocked dangerous pattern")
+	}
+
+	normalized := expandLongOptions(command)
+	for _, rule := range e.rules {
+		if rule.pattern.MatchString(normalized) {
+			assessment.add(rule.category, rule.score, rule.reason)
+		}
+	}
+
+	if sim, err := SimulateCommand(command); err == nil {
+		e.assessEffects(&assessment, sim)
+	}
+	if len(assessment.Categories) == 0 {
+		assessment.Categories = []RiskCategory{CategoryReadOnly}
+	}
+	if assessment.Has(CategoryDestructive) {
+		assessment.ConfirmTargets = confirmationTargets(command)
+		assessment.ConfirmToken = confirmationToken(assessment.ConfirmTargets)
+	}
+
+	if assessment.Score > 100 {
+		assessment.Score = 100
+	}
+
+	switch {
+	case assessment.Score >= 80:
+		assessment.Level = RiskCritical
+	case assessment.Score >= 50:
+		assessment.Level = RiskHigh
+	case assessment.Score >= 20:
+		assessment.Level = RiskMedium
+	default:
+		assessment.Level = RiskLow
+	}
+
+	return assessment
+}
+
+// longOption matches the long options the rules know by their short form
+var longOption = regexp.MustCompile(`(\s)--(recursive|force)(\s|$)`)
+
+// expandLongOptions rewrites long options as their short forms, so
+// "rm --recursive --force" scores like "rm -r -f"
+func expandLongOptions(command string) string {
+	expand := func(match string) string {
+		return strings.Replace(strings.Replace(match, "--recursive", "-r", 1), "--force", "-f", 1)
+	}
+	// Adjacent options share a space, so a second pass catches the ones the
+	// first skipped
+	return longOption.ReplaceAllStringFunc(longOption.ReplaceAllStringFunc(command, expand), expand)
+}
+
+// assessEffects scores what the parsed command touches beyond the rules
+func (e *RiskEngine) assessEffects(assessment *RiskAssessment, sim *Simulation) {
+	var system, other []string
+	for _, path := range sim.Writes {
+		if isSystemPath(path) {
+			system = append(system, path)
+		} else {
+			other = append(other, path)
+		}
+	}
+	if len(system) > 0 {
+		assessment.add(CategoryNeedsRoot, 25, "writes to system paths ("+strings.Join(firstStrings(system, 3), ", ")+")")
+	}
+
+	if len(other) > 0 && !assessment.Has(CategoryModifiesFiles) && !assessment.Has(CategoryDestructive) {
+		assessment.add(CategoryModifiesFiles, 15, "writes files ("+strings.Join(firstStrings(other, 3), ", ")+")")
+	}
+
+	if len(sim.Endpoints) > 0 && !assessment.Has(CategoryNetwork) {
+		assessment.add(CategoryNetwork, 10, "contacts "+strings.Join(firstStrings(sim.Endpoints, 3), ", "))
+	}
+}
+
+// isSystemPath reports whether a path is inside a system directory
+func isSystemPath(path string) bool {
+	for _, dir := range systemPaths {
+		if path == dir || strings.HasPrefix(path, dir+"/") || strings.HasPrefix(path, dir+`\`) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstStrings returns at most n values, noting how many were left out
+func firstStrings(values []string, n int) []string {
+	if len(values) <= n {
+		return values
+	}
+	return append(values[:n:n], fmt.Sprintf("%d more", len(values)-n))
+}
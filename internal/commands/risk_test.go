@@ -0,0 +1,52 @@
+package commands
+
+import "testing"
+
+func TestAssessRiskRules(t *testing.T) {
+	tests := []struct {
+		command  string
+		minScore int
+		category RiskCategory
+	}{
+		{"rm -rf build", 90, CategoryDestructive},
+		{"rm --recursive --force build", 90, CategoryDestructive},
+		{"rm --force --recursive build", 90, CategoryDestructive},
+		{"rm -R -f build", 90, CategoryDestructive},
+		{"find . -delete", 50, CategoryDestructive},
+		{"find . -name '*.log' -exec rm {} +", 80, CategoryDestructive},
+		{"shred -u x", 50, CategoryDestructive},
+		{"truncate -s0 x", 50, CategoryDestructive},
+		{`perl -e 'unlink glob("*")'`, 50, CategoryDestructive},
+		{`python3 -c "import shutil; shutil.rmtree('src')"`, 50, CategoryDestructive},
+		{`node -e "require('fs').rmSync('src', {recursive: true})"`, 50, CategoryDestructive},
+		{"docker system prune -af", 50, CategoryDestructive},
+		{"podman rm -f web", 50, CategoryDestructive},
+		{"docker image rm nginx", 50, CategoryDestructive},
+		{"kubectl delete ns prod", 50, CategoryDestructive},
+		{"kubectl -n prod delete pod web", 50, CategoryDestructive},
+		{"cat x | sh", 50, CategoryModifiesFiles},
+		{"echo ls | sudo bash", 50, CategoryModifiesFiles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			assessment := AssessRisk(tt.command)
+			if assessment.Score < tt.minScore || !assessment.Has(tt.category) {
+				t.Fatalf("AssessRisk(%q) = score %d %v, want at least %d and %s", tt.command, assessment.Score, assessment.Categories, tt.minScore, tt.category)
+			}
+		})
+	}
+}
+
+func TestAssessRiskReadOnly(t *testing.T) {
+	for _, command := range []string{
+		"find . -name '*.go'",
+		"docker ps -a",
+		"kubectl get pods",
+		"python3 -c 'print(1)'",
+		"ls --recursive",
+	} {
+		if assessment := AssessRisk(command); assessment.Has(CategoryDestructive) {
+			t.Errorf("AssessRisk(%q) = %v, want no destructive category", command, assessment.Reasons)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"time"
 
 	"helix/internal/shell"
 
@@ -21,11 +23,24 @@ const (
 	SandboxStrict
 )
 
+// SandboxPaths are the paths the sandbox allows besides its directory, and
+// the paths it never allows, even inside it
+type SandboxPaths struct {
+	Allowed []string `json:"allowed,omitempty"`
+	Denied  []string `json:"denied,omitempty"`
+}
+
 // DirectorySandbox manages execution restrictions
 type DirectorySandbox struct {
 	allowedDir  string
 	mode        SandboxMode
 	originalDir string
+	stack       []string // directories saved by PushDirectory, most recent last
+	paths       SandboxPaths
+	project     SandboxPaths // from the project's .helix.yaml, kept apart from the user's
+	offline     bool         // commands may not use the network
+	windows     bool         // paths follow Windows rules: drives, UNC shares, backslashes, no case
+	remote      string       // host commands run on in /ssh mode, whose files the path rules cannot see
 }
 
 // NewDirectorySandbox creates a new sandbox instance
@@ -39,55 +54,391 @@ func NewDirectorySandbox() *DirectorySandbox {
 		allowedDir:  currentDir,
 		mode:        SandboxCurrentDir,
 		originalDir: currentDir,
+		windows:     runtime.GOOS == "windows",
 	}
 }
 
+// SandboxViolation is a command the sandbox blocked and the rule that
+// blocked it, as recorded in the audit log
+type SandboxViolation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Rule    string    `json:"rule"` // network, denied-path, absolute-path, indirect-path, directory-escape or external-operation
+	Reason  string    `json:"reason"`
+	Mode    string    `json:"mode"`
+	Dir     string    `json:"dir"`
+}
+
 // ValidateCommand checks if a command is allowed within the sandbox
 func (ds *DirectorySandbox) ValidateCommand(command string) (bool, string) {
+	if violation := ds.Check(command); violation != nil {
+		return false, violation.Reason
+	}
+	return true, ""
+}
+
+// Check returns the violation a command commits, or nil when the sandbox
+// allows it
+func (ds *DirectorySandbox) Check(command string) *SandboxViolation {
+	violation := func(rule, reason string) *SandboxViolation {
+		return &SandboxViolation{Time: time.Now(), Command: command, Rule: rule, Reason: reason,
+			Mode: ds.ModeString(), Dir: ds.allowedDir}
+	}
+
+	// Blocking the network is a choice of its own, kept with the sandbox off
+	if ds.offline {
+		if use := NetworkUseIn(command); use != "" {
+			return violation("network", fmt.Sprintf("Network access is blocked in this session (%s)", use))
+		}
+	}
+
 	if ds.mode == SandboxDisabled {
-		return true, "" // No restrictions
+		return nil // No restrictions
 	}
 
-	command = strings.ToLower(command)
+	// The path rules resolve directories, symlinks and ~ on this machine,
+	// which says nothing about the files of a remote host
+	if ds.remote != "" {
+		return nil
+	}
 
-	// Always block absolute path traversal attempts
+	// Denied paths are blocked even inside the sandbox directory
+	if path := ds.deniedPathIn(command); path != "" {
+		return violation("denied-path", fmt.Sprintf("Command touches denied path %s", path))
+	}
+
+	// Block absolute paths outside the allowed paths
 	if ds.containsAbsolutePathTraversal(command) {
-		return false, "Command contains absolute path traversal"
+		return violation("absolute-path", "Command contains absolute path traversal")
+	}
+
+	// Paths that leave only once expanded or followed: ~, $HOME, symlinks
+	if reason := ds.indirectEscapeIn(command); reason != "" {
+		return violation("indirect-path", reason)
+	}
+
+	// .. is resolved for every program, readers included, in / and \ form
+	if word := ds.parentEscapeIn(command); word != "" {
+		return violation("directory-escape", fmt.Sprintf("%s leads out of the sandbox directory", word))
+	}
+
+	// A path the shell builds while running cannot be checked beforehand
+	if word := ds.unresolvedPathIn(command); word != "" {
+		return violation("indirect-path", fmt.Sprintf("%s is only known once the command runs", word))
 	}
 
+	lower := strings.ToLower(command)
+
 	// Check for attempts to escape the sandbox directory
-	if ds.containsDirectoryEscape(command) {
-		return false, "Command attempts to escape sandbox directory"
+	if ds.containsDirectoryEscape(lower) {
+		return violation("directory-escape", "Command attempts to escape sandbox directory")
 	}
 
 	// Check for dangerous operations outside sandbox
-	if ds.containsDangerousExternalOperations(command) {
-		return false, "Command performs dangerous operations outside sandbox"
+	if ds.containsDangerousExternalOperations(lower) {
+		return violation("external-operation", "Command performs dangerous operations outside sandbox")
 	}
 
-	return true, ""
+	return nil
 }
 
-// containsAbsolutePathTraversal checks for absolute path usage
+// containsAbsolutePathTraversal checks for absolute paths outside the
+// sandbox directory and its allowed paths
 func (ds *DirectorySandbox) containsAbsolutePathTraversal(command string) bool {
-	// Match absolute paths (Unix: /, Windows: C:\, D:\, etc.)
-	absolutePathPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`\s/(?:[^/]\S*)?`), // Unix absolute paths
-		regexp.MustCompile(`(?i)\s[a-z]:\\`),  // Windows drive letters (case-insensitive)
-		regexp.MustCompile(`rm\s+-rf\s+/`),    // Dangerous rm with root
-		regexp.MustCompile(`chmod\s+.*\s+/`),  // chmod on root
-		regexp.MustCompile(`chown\s+.*\s+/`),  // chown on root
+	for i, path := range ds.pathWordsIn(command) {
+		// The program itself may be given by its full path, e.g. /usr/bin/env
+		if i == 0 && strings.HasPrefix(strings.TrimSpace(command), path) {
+			continue
+		}
+		if ds.isAbsolutePath(path) && !harmlessDevices[path] && ds.isOutsideSandbox(path) {
+			return true
+		}
 	}
+	return false
+}
+
+// indirectEscapeIn explains how a command leaves the sandbox through a
+// path the shell expands (~, $HOME), a symlink the sandbox directory
+// contains, or cd without a directory, which goes home. It returns "" when
+// it does not.
+func (ds *DirectorySandbox) indirectEscapeIn(command string) string {
+	resolvedDir := ds.allowedDir
+	if dir, err := filepath.EvalSymlinks(ds.allowedDir); err == nil {
+		resolvedDir = dir
+	}
+
+	for i, word := range ds.pathWordsIn(command) {
+		if i == 0 && strings.HasPrefix(strings.TrimSpace(command), word) {
+			continue
+		}
+		expanded := word
+		if ds.windows {
+			var unset []string
+			if expanded, unset = expandWindowsVariables(word); len(unset) > 0 {
+				return fmt.Sprintf("%s names a directory outside the sandbox", unset[0])
+			}
+		}
+		expanded = expandHome(os.Expand(expanded, expandPathVariable))
+		if ds.isAbsolutePath(expanded) {
+			if expanded != word && !harmlessDevices[expanded] && ds.isOutsideSandbox(expanded) {
+				return fmt.Sprintf("%s expands to %s, outside the sandbox", word, expanded)
+			}
+			continue
+		}
+		if ds.windows && runtime.GOOS != "windows" {
+			continue // /sandbox test has no Windows directory whose links to follow
+		}
+
+		path := filepath.Join(ds.allowedDir, expanded)
+		if ds.isOutsideSandbox(path) {
+			continue // .. is judged by the directory escape rules
+		}
+		if target := resolveExisting(path); target != path && ds.isOutsideSandbox(target) && !isWithin(target, resolvedDir) {
+			return fmt.Sprintf("%s leads through a symlink to %s, outside the sandbox", word, target)
+		}
+	}
+
+	if script, err := shell.Parse(command); err == nil {
+		for _, cmd := range script.Commands() {
+			if len(cmd.Args) > 0 && isChangeDirectory(cmd.Args[0].Value) && (len(cmd.Args) == 1 || cmd.Args[1].Value == "-") {
+				return "cd without a directory leaves the sandbox"
+			}
+		}
+	}
+	return ""
+}
 
-	for _, pattern := range absolutePathPatterns {
-		if pattern.MatchString(command) {
+// parentEscapeIn returns the first word whose .. components, with either
+// separator, lead out of the sandbox, or ""
+func (ds *DirectorySandbox) parentEscapeIn(command string) string {
+	for _, word := range ds.pathWordsIn(command) {
+		path := strings.ReplaceAll(word, `\`, "/")
+		if !containsString(strings.Split(path, "/"), "..") {
+			continue
+		}
+		if ds.windows {
+			path = word
+		}
+		if ds.isOutsideSandbox(path) {
+			return word
+		}
+	}
+	return ""
+}
+
+// printPrograms only print or test their arguments, so words the shell
+// builds while running are safe to give them
+var printPrograms = map[string]bool{
+	"echo": true, "printf": true, "test": true, "[": true, "[[": true, "true": true, "false": true,
+	"export": true, "set": true, "let": true, "expr": true, "read": true, "local": true,
+	"declare": true, "typeset": true, "readonly": true, "unset": true,
+}
+
+// variableReference matches $NAME and ${NAME}
+var variableReference = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// unresolvedPathIn returns the first argument the shell builds while the
+// command runs: a command substitution, as in "$(printf '\057etc')", or a
+// variable the sandbox cannot expand, as in ${P}c/passwd. The directory
+// variables indirectEscapeIn expands and the variables of for loops, whose
+// words are checked themselves, are known. It returns "" when there is
+// none.
+func (ds *DirectorySandbox) unresolvedPathIn(command string) string {
+	if ds.windows {
+		return "" // %VAR% and $env: are expanded by indirectEscapeIn
+	}
+	script, err := shell.Parse(command)
+	if err != nil {
+		return ""
+	}
+	commands := script.Commands()
+	known := make(map[string]bool)
+	for name := range pathVariables {
+		known[name] = true
+	}
+	for _, cmd := range commands {
+		if len(cmd.Args) > 1 && cmd.Args[0].Value == "for" {
+			known[cmd.Args[1].Value] = true
+		}
+	}
+
+	unresolved := func(word *shell.Word) bool {
+		if len(word.Substs) > 0 {
 			return true
 		}
+		for _, match := range variableReference.FindAllStringSubmatch(word.Raw, -1) {
+			if !known[match[1]] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, cmd := range commands {
+		name, args, _ := resolveProgram(cmd)
+		if name == "" || printPrograms[name] {
+			continue
+		}
+		for _, arg := range args {
+			if arg.Expands && unresolved(arg) {
+				return arg.Raw
+			}
+		}
+		for _, redirect := range cmd.Redirs {
+			if redirect.Target != nil && redirect.Target.Expands && unresolved(redirect.Target) {
+				return redirect.Target.Raw
+			}
+		}
 	}
+	return ""
+}
 
+// isChangeDirectory reports whether a program changes the shell's directory,
+// PowerShell's Set-Location included
+func isChangeDirectory(program string) bool {
+	switch strings.ToLower(program) {
+	case "cd", "set-location", "sl":
+		return true
+	}
 	return false
 }
 
+// pathVariables name directories outside the sandbox the shell substitutes
+var pathVariables = map[string]bool{"HOME": true, "OLDPWD": true, "TMPDIR": true, "USERPROFILE": true}
+
+// expandPathVariable expands the directory variables and leaves others, like
+// $PATH, as written
+func expandPathVariable(name string) string {
+	if value := os.Getenv(name); pathVariables[name] && value != "" {
+		return value
+	}
+	return "${" + name + "}"
+}
+
+// resolveExisting follows the symlinks in the longest part of a path that
+// exists and appends the rest
+func resolveExisting(path string) string {
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// harmlessDevices may be written to from anywhere
+var harmlessDevices = map[string]bool{"/dev/null": true, "/dev/stdout": true, "/dev/stderr": true}
+
+// windowsDrivePattern matches a path starting with a drive letter, e.g. C:\
+var windowsDrivePattern = regexp.MustCompile(`^[a-zA-Z]:\\`)
+
+// isAbsolutePathWord reports whether a word is a Unix or Windows absolute path
+func isAbsolutePathWord(word string) bool {
+	return strings.HasPrefix(word, "/") || windowsDrivePattern.MatchString(word)
+}
+
+// isAbsolutePath reports whether a word is an absolute path under the
+// sandbox's path rules. On Windows that includes \\server\share, C:/x and
+// \x, the root of the current drive.
+func (ds *DirectorySandbox) isAbsolutePath(word string) bool {
+	if ds.windows {
+		return isWindowsAbsolute(word)
+	}
+	return isAbsolutePathWord(word)
+}
+
+// pathWordsIn returns the words of a command that may be paths, like
+// commandPathWords. On Windows the switches of cmd programs, as in
+// del /s /q, are left out.
+func (ds *DirectorySandbox) pathWordsIn(command string) []string {
+	if !ds.windows {
+		return commandPathWords(command)
+	}
+	var words []string
+	for _, segment := range strings.FieldsFunc(command, func(r rune) bool {
+		return r == '\n' || strings.ContainsRune(";|&()`", r)
+	}) {
+		segmentWords := commandPathWords(segment)
+		for i, word := range segmentWords {
+			if i == 0 || !isWindowsSwitch(segmentWords[0], word) {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
+
+// commandPathWords splits a command into the words that may be paths:
+// arguments without their quotes, redirection targets and the values of
+// --option=value
+func commandPathWords(command string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(command, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || strings.ContainsRune(";|&<>()`", r)
+	}) {
+		field = strings.Trim(field, `"'`)
+		if strings.HasPrefix(field, "-") {
+			_, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			field = value
+		}
+		if field != "" {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// deniedPathIn returns the first denied path a command refers to, or ""
+func (ds *DirectorySandbox) deniedPathIn(command string) string {
+	deniedPaths := ds.deniedPaths()
+	if len(deniedPaths) == 0 {
+		return ""
+	}
+	for _, word := range ds.pathWordsIn(command) {
+		path := ds.resolvePath(word)
+		for _, denied := range deniedPaths {
+			if ds.pathWithin(path, denied) {
+				return denied
+			}
+		}
+	}
+	return ""
+}
+
+// resolvePath makes a path absolute relative to the sandbox directory,
+// expanding a leading ~
+func (ds *DirectorySandbox) resolvePath(path string) string {
+	if ds.windows {
+		return resolveWindowsPath(expandHome(path), ds.allowedDir)
+	}
+	path = filepath.Clean(expandHome(path))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ds.allowedDir, path)
+	}
+	return path
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	relativePath, err := filepath.Rel(dir, path)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// pathWithin reports whether path, relative to the sandbox directory or
+// absolute, is dir or inside it under the sandbox's path rules
+func (ds *DirectorySandbox) pathWithin(path, dir string) bool {
+	if ds.windows {
+		return isWithinWindows(resolveWindowsPath(path, ds.allowedDir), resolveWindowsPath(dir, ds.allowedDir))
+	}
+	return isWithin(path, dir)
+}
+
 // containsDirectoryEscape checks for attempts to escape current directory
 func (ds *DirectorySandbox) containsDirectoryEscape(command string) bool {
 	// Patterns that attempt to move up directory hierarchy
@@ -96,6 +447,11 @@ func (ds *DirectorySandbox) containsDirectoryEscape(command string) bool {
 		"rm -rf ../", "rm -rf ..\\",
 		"../", "..\\",
 	}
+	if ds.windows {
+		// cmd takes cd.. and cd\ without a space; PowerShell has its own names
+		escapePatterns = append(escapePatterns, "cd..", "cd\\", "chdir ..", "chdir..",
+			"set-location ..", "sl ..", "pushd ..", "push-location ..")
+	}
 
 	for _, pattern := range escapePatterns {
 		if strings.Contains(command, pattern) {
@@ -116,6 +472,13 @@ func (ds *DirectorySandbox) containsDangerousExternalOperations(command string)
 		"rm -rf", "chmod", "chown", "mv ", "cp ", "dd ",
 		"format", "mkfs", "fdisk",
 	}
+	if ds.windows {
+		dangerousCommands = append(dangerousCommands,
+			"del ", "erase ", "rd ", "rmdir ", "move ", "copy ", "xcopy ", "robocopy ", "ren ",
+			"icacls ", "takeown ", "attrib ", "cipher ",
+			"remove-item", "move-item", "copy-item", "rename-item", "set-content",
+			"clear-content", "out-file", "set-acl")
+	}
 
 	// If command contains dangerous operations with relative paths that escape
 	for _, dangerousCmd := range dangerousCommands {
@@ -137,6 +500,11 @@ func (ds *DirectorySandbox) isJustChecking(command string) bool {
 		"stat", "du", "df", "pwd", "echo", "print",
 	}
 
+	if ds.windows {
+		safePatterns = append(safePatterns, "dir", "type", "more", "tree", "findstr", "where",
+			"get-childitem", "gci", "get-content", "gc ", "get-item", "test-path", "select-string")
+	}
+
 	for _, pattern := range safePatterns {
 		if strings.HasPrefix(command, pattern) {
 			return true
@@ -170,8 +538,8 @@ func (ds *DirectorySandbox) extractFileArguments(command string) []string {
 	for i := 1; i < len(words); i++ {
 		word := words[i]
 
-		// Skip flags
-		if strings.HasPrefix(word, "-") {
+		// Skip flags, and switches such as /s on Windows
+		if strings.HasPrefix(word, "-") || (ds.windows && isWindowsSwitch(words[0], word)) {
 			continue
 		}
 
@@ -204,8 +572,18 @@ func (ds *DirectorySandbox) isCommonNonFileArgument(arg string) bool {
 	return false
 }
 
-// isOutsideSandbox checks if a path is outside the allowed directory
+// isOutsideSandbox checks if a path is outside the allowed directory and
+// the additional allowed paths
 func (ds *DirectorySandbox) isOutsideSandbox(path string) bool {
+	if ds.windows {
+		for _, dir := range append([]string{ds.allowedDir}, ds.allowedPaths()...) {
+			if ds.pathWithin(strings.Trim(path, `"'`), dir) {
+				return false
+			}
+		}
+		return true
+	}
+
 	// Clean and resolve the path
 	cleanPath := filepath.Clean(path)
 
@@ -216,12 +594,142 @@ func (ds *DirectorySandbox) isOutsideSandbox(path string) bool {
 
 	// Check if the resolved path is within the allowed directory
 	relativePath, err := filepath.Rel(ds.allowedDir, cleanPath)
-	if err != nil {
-		return true // Error means it's likely outside
+	if err == nil && !strings.HasPrefix(relativePath, "..") {
+		return false
 	}
 
-	// If relative path starts with .., it's outside
-	return strings.HasPrefix(relativePath, "..")
+	for _, allowed := range ds.allowedPaths() {
+		if isWithin(cleanPath, allowed) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidatePath checks that Helix may write a file at path, e.g. for /save
+func (ds *DirectorySandbox) ValidatePath(path string) error {
+	if ds.mode == SandboxDisabled {
+		return nil
+	}
+
+	cleanPath := filepath.Clean(path)
+	if !filepath.IsAbs(cleanPath) {
+		cleanPath = filepath.Join(ds.allowedDir, cleanPath)
+	}
+	// A symlinked directory inside the sandbox may point outside it
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(cleanPath)); err == nil {
+		cleanPath = filepath.Join(dir, filepath.Base(cleanPath))
+	}
+	resolve := func(dir string) string {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return resolved
+		}
+		return dir
+	}
+
+	for _, denied := range ds.deniedPaths() {
+		if isWithin(cleanPath, resolve(denied)) {
+			return fmt.Errorf("sandbox violation: %s is in denied path %s", path, denied)
+		}
+	}
+	for _, allowed := range append([]string{ds.allowedDir}, ds.allowedPaths()...) {
+		if isWithin(cleanPath, resolve(allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("sandbox violation: %s is outside %s", path, ds.allowedDir)
+}
+
+// SetPaths replaces the additional allowed and the denied paths, e.g. with
+// those saved in the config
+func (ds *DirectorySandbox) SetPaths(paths SandboxPaths) {
+	ds.paths = SandboxPaths{}
+	for _, path := range paths.Allowed {
+		ds.paths.Allowed = appendPath(ds.paths.Allowed, ds.resolvePath(path))
+	}
+	for _, path := range paths.Denied {
+		ds.paths.Denied = appendPath(ds.paths.Denied, ds.resolvePath(path))
+	}
+}
+
+// SetProjectPaths replaces the allowed and denied paths of the project's
+// profile. They apply with the user's own but are not returned by Paths, so
+// they are never saved to the config.
+func (ds *DirectorySandbox) SetProjectPaths(paths SandboxPaths) {
+	ds.project = SandboxPaths{}
+	for _, path := range paths.Allowed {
+		ds.project.Allowed = appendPath(ds.project.Allowed, ds.resolvePath(path))
+	}
+	for _, path := range paths.Denied {
+		ds.project.Denied = appendPath(ds.project.Denied, ds.resolvePath(path))
+	}
+}
+
+// allowedPaths returns the user's and the project's allowed paths
+func (ds *DirectorySandbox) allowedPaths() []string {
+	return append(append([]string(nil), ds.paths.Allowed...), ds.project.Allowed...)
+}
+
+// deniedPaths returns the user's and the project's denied paths
+func (ds *DirectorySandbox) deniedPaths() []string {
+	return append(append([]string(nil), ds.paths.Denied...), ds.project.Denied...)
+}
+
+// Paths returns the additional allowed and the denied paths
+func (ds *DirectorySandbox) Paths() SandboxPaths {
+	return SandboxPaths{
+		Allowed: append([]string(nil), ds.paths.Allowed...),
+		Denied:  append([]string(nil), ds.paths.Denied...),
+	}
+}
+
+// AllowPath allows commands to use a path outside the sandbox directory,
+// and returns it as stored. A path cannot be both allowed and denied.
+func (ds *DirectorySandbox) AllowPath(path string) (string, error) {
+	resolved := ds.resolvePath(path)
+	if containsString(ds.paths.Denied, resolved) {
+		return "", fmt.Errorf("%s is denied; remove it with /sandbox remove %s first", resolved, path)
+	}
+	ds.paths.Allowed = appendPath(ds.paths.Allowed, resolved)
+	return resolved, nil
+}
+
+// DenyPath blocks commands from using a path, even inside the sandbox
+// directory, and returns it as stored
+func (ds *DirectorySandbox) DenyPath(path string) (string, error) {
+	resolved := ds.resolvePath(path)
+	if containsString(ds.paths.Allowed, resolved) {
+		return "", fmt.Errorf("%s is allowed; remove it with /sandbox remove %s first", resolved, path)
+	}
+	ds.paths.Denied = appendPath(ds.paths.Denied, resolved)
+	return resolved, nil
+}
+
+// RemovePath drops a path from the allowed and denied paths and reports
+// whether it was in either
+func (ds *DirectorySandbox) RemovePath(path string) bool {
+	resolved := ds.resolvePath(path)
+	removed := false
+	for _, list := range []*[]string{&ds.paths.Allowed, &ds.paths.Denied} {
+		kept := (*list)[:0]
+		for _, existing := range *list {
+			if existing == resolved {
+				removed = true
+				continue
+			}
+			kept = append(kept, existing)
+		}
+		*list = kept
+	}
+	return removed
+}
+
+// appendPath adds a path to a list unless it is already there
+func appendPath(paths []string, path string) []string {
+	if containsString(paths, path) {
+		return paths
+	}
+	return append(paths, path)
 }
 
 // SetMode changes the sandbox restriction level
@@ -230,6 +738,24 @@ func (ds *DirectorySandbox) SetMode(mode SandboxMode) {
 	color.Yellow("🔒 Sandbox mode set to: %s", ds.ModeString())
 }
 
+// SetOffline blocks or allows network access for commands. Blocked, commands
+// using network clients are refused and, where a tool for it exists, the
+// rest run without a network.
+func (ds *DirectorySandbox) SetOffline(offline bool) {
+	ds.offline = offline
+}
+
+// Offline reports whether commands may not use the network
+func (ds *DirectorySandbox) Offline() bool {
+	return ds.offline
+}
+
+// SetRemote turns the path rules off while commands run on a remote host,
+// and back on when host is ""
+func (ds *DirectorySandbox) SetRemote(host string) {
+	ds.remote = host
+}
+
 // GetMode returns the current sandbox mode
 func (ds *DirectorySandbox) GetMode() SandboxMode {
 	return ds.mode
@@ -252,7 +778,14 @@ func (ds *DirectorySandbox) ModeString() string {
 // ChangeDirectory safely changes the current working directory
 func (ds *DirectorySandbox) ChangeDirectory(newDir string) error {
 	if ds.mode == SandboxDisabled {
-		return os.Chdir(newDir)
+		if err := os.Chdir(newDir); err != nil {
+			return err
+		}
+		// Keep the sandbox on the new directory for when it is enabled again
+		if dir, err := os.Getwd(); err == nil {
+			ds.allowedDir = dir
+		}
+		return nil
 	}
 
 	// Clean the path
@@ -296,19 +829,102 @@ func (ds *DirectorySandbox) ResetToOriginal() error {
 	}
 
 	ds.allowedDir = ds.originalDir
+	ds.stack = nil
 	color.Green("📁 Reset to original directory: %s", ds.originalDir)
 	return nil
 }
 
+// PushDirectory saves the current directory on the stack and changes to
+// newDir within the sandbox. Without a directory it swaps the current
+// directory with the top of the stack, like pushd.
+func (ds *DirectorySandbox) PushDirectory(newDir string) error {
+	current := ds.allowedDir
+	if newDir == "" {
+		if len(ds.stack) == 0 {
+			return fmt.Errorf("no other directory on the stack")
+		}
+		top := len(ds.stack) - 1
+		if err := ds.returnTo(ds.stack[top]); err != nil {
+			return err
+		}
+		ds.stack[top] = current
+		return nil
+	}
+
+	if err := ds.ChangeDirectory(newDir); err != nil {
+		return err
+	}
+	ds.stack = append(ds.stack, current)
+	return nil
+}
+
+// PopDirectory returns to the directory on top of the stack and removes it
+func (ds *DirectorySandbox) PopDirectory() error {
+	if len(ds.stack) == 0 {
+		return fmt.Errorf("directory stack is empty")
+	}
+	top := len(ds.stack) - 1
+	if err := ds.returnTo(ds.stack[top]); err != nil {
+		return err
+	}
+	ds.stack = ds.stack[:top]
+	return nil
+}
+
+// returnTo changes back to a directory saved on the stack. It was the
+// sandbox's directory when pushed, so it is allowed again even when it is
+// a parent of the current one.
+func (ds *DirectorySandbox) returnTo(dir string) error {
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	ds.allowedDir = dir
+	color.Green("📁 Changed to directory: %s", dir)
+	return nil
+}
+
+// Directories returns the current directory followed by the stack, most
+// recently pushed first, like dirs
+func (ds *DirectorySandbox) Directories() []string {
+	dirs := []string{ds.allowedDir}
+	for i := len(ds.stack) - 1; i >= 0; i-- {
+		dirs = append(dirs, ds.stack[i])
+	}
+	return dirs
+}
+
 // WrapCommand wraps a command with sandbox safety checks
 func (ds *DirectorySandbox) WrapCommand(command string, execConfig ExecuteConfig, env shell.Env) error {
 	// Validate command against sandbox rules
-	if valid, reason := ds.ValidateCommand(command); !valid {
+	if valid, reason := ds.validateAndRecord(command); !valid {
 		return fmt.Errorf("sandbox violation: %s", reason)
 	}
+	// The policy file is checked again after pre-execute hooks
+	if decision := CheckPolicy(command); decision.Blocked {
+		return fmt.Errorf("command blocked by policy %s (pattern: %s)", decision.Pack, decision.Pattern)
+	}
 
 	// Execute the command with current directory context
-	return ExecuteCommand(command, execConfig, env)
+	return ExecuteCommand(command, ds.Guard(execConfig), env)
+}
+
+// Guard returns an execution config under which a command changed by a
+// pre-execute hook must still pass the sandbox rules
+func (ds *DirectorySandbox) Guard(execConfig ExecuteConfig) ExecuteConfig {
+	execConfig.validate = ds.validateAndRecord
+	execConfig.offline = ds.offline
+	return execConfig
+}
+
+// validateAndRecord validates a command about to run and records a
+// violation in the audit log
+func (ds *DirectorySandbox) validateAndRecord(command string) (bool, string) {
+	violation := ds.Check(command)
+	if violation == nil {
+		return true, ""
+	}
+	recordViolation(*violation)
+	return false, violation.Reason
 }
 
 // PrintStatus shows current sandbox status
@@ -317,6 +933,32 @@ func (ds *DirectorySandbox) PrintStatus() {
 	color.Cyan("  Mode: %s", ds.ModeString())
 	color.Cyan("  Allowed Directory: %s", ds.allowedDir)
 	color.Cyan("  Original Directory: %s", ds.originalDir)
+	if ds.remote != "" {
+		color.Yellow("  Path rules: off while commands run on %s (they only see this machine's files)", ds.remote)
+	}
+	if len(ds.stack) > 0 {
+		color.Cyan("  Directory Stack: %s", strings.Join(ds.Directories()[1:], " "))
+	}
+	switch {
+	case !ds.offline:
+		color.Cyan("  Network: allowed")
+	case NetworkIsolationTool() != "":
+		color.Cyan("  Network: blocked (commands run isolated with %s)", filepath.Base(NetworkIsolationTool()))
+	default:
+		color.Cyan("  Network: blocked (network clients are refused)")
+	}
+	if len(ds.paths.Allowed) > 0 {
+		color.Cyan("  Also Allowed: %s", strings.Join(ds.paths.Allowed, ", "))
+	}
+	if len(ds.paths.Denied) > 0 {
+		color.Cyan("  Denied: %s", strings.Join(ds.paths.Denied, ", "))
+	}
+	if len(ds.project.Allowed) > 0 {
+		color.Cyan("  Allowed by the project: %s", strings.Join(ds.project.Allowed, ", "))
+	}
+	if len(ds.project.Denied) > 0 {
+		color.Cyan("  Denied by the project: %s", strings.Join(ds.project.Denied, ", "))
+	}
 
 	// Show current working directory for comparison
 	currentDir, _ := os.Getwd()
@@ -0,0 +1,61 @@
+package commands
+
+import "testing"
+
+func TestSandboxRemoteSkipsPathRules(t *testing.T) {
+	ds := &DirectorySandbox{allowedDir: t.TempDir(), mode: SandboxStrict}
+	tests := []struct {
+		name     string
+		remote   string
+		offline  bool
+		command  string
+		wantRule string // "" for no violation
+	}{
+		{name: "local path outside the sandbox", command: "cat /etc/passwd", wantRule: "absolute-path"},
+		{name: "remote path is not judged locally", remote: "web-1", command: "cat /etc/passwd"},
+		{name: "remote home is not judged locally", remote: "web-1", command: "ls ~/.ssh"},
+		{name: "network rule still applies remotely", remote: "web-1", offline: true, command: "curl https://example.com", wantRule: "network"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds.SetRemote(tt.remote)
+			ds.SetOffline(tt.offline)
+			rule := ""
+			if violation := ds.Check(tt.command); violation != nil {
+				rule = violation.Rule
+			}
+			if rule != tt.wantRule {
+				t.Fatalf("Check(%q) violated %q, want %q", tt.command, rule, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestSandboxBlocksReadsOutside(t *testing.T) {
+	dir := t.TempDir()
+	sandbox := &DirectorySandbox{allowedDir: dir, mode: SandboxCurrentDir}
+	windows := &DirectorySandbox{allowedDir: windowsEscapeDir, mode: SandboxCurrentDir, windows: true}
+	tests := []struct {
+		sandbox *DirectorySandbox
+		command string
+		blocked bool
+	}{
+		{sandbox, "cat ../../../etc/passwd", true},
+		{sandbox, "cat inside/../../escape.txt", true},
+		{sandbox, `cat ..\..\secrets.txt`, true},
+		{sandbox, `cat "$(printf '\057etc\057passwd')"`, true},
+		{sandbox, "P=/et; cat ${P}c/passwd", true},
+		{sandbox, "cat $CONFIG_DIR/app.yaml", true},
+		{sandbox, "cat src/../go.mod", false},
+		{sandbox, "git diff HEAD..main", false},
+		{sandbox, "echo $PATH", false},
+		{sandbox, `for f in *.log; do gzip "$f"; done`, false},
+		{windows, `type ..\..\secrets.txt`, true},
+		{windows, `type src\..\README.md`, false},
+	}
+	for _, tt := range tests {
+		if violation := tt.sandbox.Check(tt.command); (violation != nil) != tt.blocked {
+			t.Errorf("Check(%q) = %v, want blocked %v", tt.command, violation, tt.blocked)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+// Simulation is what a dry run learns about a command without running it
+type Simulation struct {
+	Programs   []string // programs it would run, in order
+	Reads      []string // files and directories it would read
+	Writes     []string // files and directories it would create, change or delete
+	Endpoints  []string // hosts and URLs it would contact
+	Sudo       bool     // runs something with elevated privileges
+	Background bool     // leaves something running in the background
+	Notes      []string // what can only be known at run time
+}
+
+// writingPrograms change every path argument they get
+var writingPrograms = map[string]bool{
+	"rm": true, "rmdir": true, "touch": true, "mkdir": true, "chmod": true, "chown": true,
+	"chgrp": true, "truncate": true, "shred": true, "unlink": true, "tee": true, "gzip": true,
+	"gunzip": true, "bzip2": true, "xz": true, "mv": true,
+}
+
+// copyingPrograms read their arguments and write the last one
+var copyingPrograms = map[string]bool{"cp": true, "ln": true, "install": true, "rsync": true, "scp": true}
+
+// outputFlags are the options whose value is a file the program writes
+var outputFlags = map[string][]string{
+	"curl": {"-o", "--output"},
+	"wget": {"-O", "--output-document"},
+	"sort": {"-o", "--output"},
+	"gcc":  {"-o"},
+	"cc":   {"-o"},
+	"go":   {"-o"},
+}
+
+// hostPrograms take a host as their first argument
+var hostPrograms = map[string]bool{
+	"ssh": true, "ping": true, "ping6": true, "telnet": true, "nc": true, "ncat": true, "ftp": true,
+	"sftp": true, "dig": true, "nslookup": true, "host": true, "traceroute": true, "mtr": true, "whois": true,
+}
+
+// networkSubcommands are subcommands that talk to a remote or a registry
+var networkSubcommands = map[string]map[string]string{
+	"git":     {"clone": "git remote", "fetch": "git remote", "pull": "git remote", "push": "git remote", "ls-remote": "git remote"},
+	"apt":     {"install": "package repositories", "update": "package repositories", "upgrade": "package repositories"},
+	"apt-get": {"install": "package repositories", "update": "package repositories", "upgrade": "package repositories"},
+	"brew":    {"install": "package repositories", "update": "package repositories", "upgrade": "package repositories"},
+	"pip":     {"install": "package index (PyPI)", "download": "package index (PyPI)"},
+	"pip3":    {"install": "package index (PyPI)", "download": "package index (PyPI)"},
+	"npm":     {"install": "npm registry", "i": "npm registry", "publish": "npm registry", "update": "npm registry"},
+	"docker":  {"pull": "container registry", "push": "container registry", "login": "container registry"},
+	"go":      {"get": "Go module proxy", "install": "Go module proxy"},
+}
+
+// privilegePrograms run the rest of the command as another user
+var privilegePrograms = map[string]bool{"sudo": true, "doas": true, "pkexec": true}
+
+// wrapperPrograms run the command that follows their own options
+var wrapperPrograms = map[string]bool{
+	"env": true, "nice": true, "ionice": true, "nohup": true, "time": true, "timeout": true,
+	"xargs": true, "exec": true, "command": true, "builtin": true,
+}
+
+// compoundWords start or continue a compound command and are not programs
+var compoundWords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "do": true, "done": true,
+	"while": true, "until": true, "for": true, "in": true, "{": true, "}": true, "!": true,
+}
+
+var (
+	urlPattern    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://[^\s]+`)
+	remotePattern = regexp.MustCompile(`^(?:[^@/\s]+@)?([a-zA-Z0-9.-]+):`)
+)
+
+// SimulateCommand parses a command with a shell parser and works out what
+// it would touch, without running anything
+func SimulateCommand(command string) (*Simulation, error) {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{Background: script.Background()}
+	for _, cmd := range script.Commands() {
+		sim.addCommand(cmd)
+	}
+	for _, cmd := range script.Commands() {
+		for _, redir := range cmd.Redirs {
+			sim.addRedirect(redir)
+		}
+	}
+
+	sim.Reads = uniqueStrings(sim.Reads)
+	sim.Writes = uniqueStrings(sim.Writes)
+	sim.Endpoints = uniqueStrings(sim.Endpoints)
+	sim.Notes = uniqueStrings(sim.Notes)
+	return sim, nil
+}
+
+// addCommand records what one simple command would do
+func (sim *Simulation) addCommand(cmd *shell.Command) {
+	name, args, sudo := resolveProgram(cmd)
+	sim.Sudo = sim.Sudo || sudo
+	switch name {
+	case "":
+	case "su":
+		sim.Notes = append(sim.Notes, "su starts a shell as another user")
+	default:
+		sim.addProgram(name, args)
+	}
+}
+
+// resolveProgram looks through compound words, sudo and wrappers to the
+// program that does the work and its arguments. sudo reports whether it
+// runs with elevated privileges; su is returned without arguments.
+func resolveProgram(cmd *shell.Command) (name string, args []*shell.Word, sudo bool) {
+	args = cmd.Args
+	for len(args) > 0 && compoundWords[args[0].Value] {
+		if args[0].Value == "for" && len(args) > 1 {
+			return "", nil, false // for NAME in WORDS only names the loop's values
+		}
+		args = args[1:]
+	}
+
+	for len(args) > 0 {
+		name = filepath.Base(args[0].Value)
+		switch {
+		case privilegePrograms[name]:
+			sudo = true
+		case name == "su":
+			return name, nil, true
+		case !wrapperPrograms[name]:
+			return name, args[1:], sudo
+		}
+		args = skipOptions(name, args[1:])
+	}
+	return "", nil, sudo
+}
+
+// optionsWithValue are the options of sudo and wrappers that take a value
+var optionsWithValue = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true, "-D": true, "-p": true, "-U": true},
+	"doas":    {"-u": true, "-C": true},
+	"env":     {"-u": true, "-C": true, "-S": true},
+	"nice":    {"-n": true},
+	"ionice":  {"-c": true, "-n": true, "-p": true},
+	"timeout": {"-s": true, "-k": true},
+	"xargs":   {"-n": true, "-I": true, "-P": true, "-d": true, "-L": true, "-s": true, "-a": true, "-E": true},
+}
+
+// skipOptions drops the options of sudo or a wrapper, and VAR=value words
+// of env, up to the command they run
+func skipOptions(name string, args []*shell.Word) []*shell.Word {
+	for len(args) > 0 {
+		value := args[0].Value
+		switch {
+		case value == "--":
+			args = args[1:]
+		case optionsWithValue[name][value] && len(args) > 1:
+			args = args[2:]
+			continue
+		case strings.HasPrefix(value, "-") || (name == "env" && strings.Contains(value, "=")):
+			args = args[1:]
+			continue
+		}
+		break
+	}
+	if name == "timeout" && len(args) > 0 {
+		args = args[1:] // the duration
+	}
+	return args
+}
+
+// addProgram records a program and what its arguments refer to
+func (sim *Simulation) addProgram(name string, args []*shell.Word) {
+	sim.Programs = append(sim.Programs, name)
+	if name == "tar" {
+		sim.addTar(args)
+		return
+	}
+
+	var positional []*shell.Word
+	for i := 0; i < len(args); i++ {
+		word := args[i]
+		value := word.Value
+
+		if flags, ok := outputFlags[name]; ok && i+1 < len(args) && containsString(flags, value) {
+			sim.Writes = append(sim.Writes, sim.paths(args[i+1])...)
+			i++
+			continue
+		}
+		if strings.HasPrefix(value, "--output=") || strings.HasPrefix(value, "of=") {
+			_, file, _ := strings.Cut(value, "=")
+			sim.Writes = append(sim.Writes, file)
+			continue
+		}
+		if strings.HasPrefix(value, "if=") {
+			sim.Reads = append(sim.Reads, strings.TrimPrefix(value, "if="))
+			continue
+		}
+		if urlPattern.MatchString(value) {
+			sim.Endpoints = append(sim.Endpoints, value)
+			continue
+		}
+		if strings.HasPrefix(value, "-") {
+			continue
+		}
+		positional = append(positional, word)
+	}
+
+	if sub := networkSubcommands[name]; sub != nil && len(positional) > 0 {
+		if endpoint, ok := sub[positional[0].Value]; ok {
+			sim.Endpoints = append(sim.Endpoints, endpoint)
+		}
+	}
+	if hostPrograms[name] && len(positional) > 0 {
+		sim.Endpoints = append(sim.Endpoints, positional[0].Value)
+		positional = positional[1:]
+	}
+
+	for i, word := range positional {
+		if match := remotePattern.FindStringSubmatch(word.Value); match != nil && (name == "scp" || name == "rsync") {
+			sim.Endpoints = append(sim.Endpoints, match[1])
+			continue
+		}
+		if word.Expands && len(word.Substs) > 0 {
+			sim.Notes = append(sim.Notes, fmt.Sprintf("%s depends on the output of a command", word.Raw))
+			continue
+		}
+
+		switch {
+		case writingPrograms[name]:
+			sim.Writes = append(sim.Writes, sim.paths(word)...)
+		case copyingPrograms[name] && i == len(positional)-1 && i > 0:
+			sim.Writes = append(sim.Writes, sim.paths(word)...)
+		case copyingPrograms[name]:
+			sim.Reads = append(sim.Reads, sim.paths(word)...)
+		case name == "sed" && hasOption(args, "-i"):
+			if i > 0 {
+				sim.Writes = append(sim.Writes, sim.paths(word)...)
+			}
+		case looksLikePath(word):
+			sim.Reads = append(sim.Reads, sim.paths(word)...)
+		}
+	}
+}
+
+// addTar records tar's archive and files, whose roles depend on its mode
+func (sim *Simulation) addTar(args []*shell.Word) {
+	mode := ""
+	var archive *shell.Word
+	dir := "."
+	var files []*shell.Word
+
+	for i := 0; i < len(args); i++ {
+		value := args[i].Value
+		switch {
+		case strings.HasPrefix(value, "--file="):
+			archive = &shell.Word{Value: strings.TrimPrefix(value, "--file="), Raw: value}
+		case strings.HasPrefix(value, "--directory="):
+			dir = strings.TrimPrefix(value, "--directory=")
+		case value == "-C" && i+1 < len(args):
+			dir = args[i+1].Value
+			i++
+		case strings.HasPrefix(value, "--"):
+			for _, m := range []string{"create", "extract", "list", "append"} {
+				if value == "--"+m {
+					mode = m[:1]
+				}
+			}
+		case strings.HasPrefix(value, "-") || (i == 0 && !strings.Contains(value, "/") && !strings.Contains(value, ".")):
+			// A group of one-letter options, like -czf or the old style czf
+			for _, letter := range strings.TrimPrefix(value, "-") {
+				if strings.ContainsRune("cxtru", letter) {
+					mode = string(letter)
+				}
+			}
+			if strings.Contains(value, "f") && i+1 < len(args) {
+				archive = args[i+1]
+				i++
+			}
+		default:
+			files = append(files, args[i])
+		}
+	}
+
+	if archive != nil {
+		if mode == "x" || mode == "t" {
+			sim.Reads = append(sim.Reads, sim.paths(archive)...)
+		} else {
+			sim.Writes = append(sim.Writes, sim.paths(archive)...)
+		}
+	}
+	if mode == "x" {
+		sim.Writes = append(sim.Writes, dir+" (extracted files)")
+		return
+	}
+	for _, file := range files {
+		sim.Reads = append(sim.Reads, sim.paths(file)...)
+	}
+}
+
+// addRedirect records the file a redirection reads or writes
+func (sim *Simulation) addRedirect(redir *shell.Redirect) {
+	switch redir.Op {
+	case "<<", "<<-", "<<<", "<&", ">&":
+		return // here-documents and file descriptor copies touch no files
+	case "<":
+		sim.Reads = append(sim.Reads, sim.paths(redir.Target)...)
+	default:
+		for _, path := range sim.paths(redir.Target) {
+			if path != "/dev/null" {
+				sim.Writes = append(sim.Writes, path)
+			}
+		}
+	}
+}
+
+// paths resolves a word to the paths it names: globs are matched against
+// the file system, ~ and simple variables are expanded
+func (sim *Simulation) paths(word *shell.Word) []string {
+	value := word.Value
+	if word.Expands {
+		expanded := os.ExpandEnv(value)
+		if len(word.Substs) > 0 || strings.Contains(value, "$((") {
+			sim.Notes = append(sim.Notes, fmt.Sprintf("%s is only known at run time", word.Raw))
+			return []string{value}
+		}
+		value = expanded
+	}
+	value = expandHome(value)
+
+	if word.Glob {
+		matches, err := filepath.Glob(value)
+		if err != nil || len(matches) == 0 {
+			sim.Notes = append(sim.Notes, fmt.Sprintf("%s matches no files right now", word.Raw))
+			return []string{value}
+		}
+		return matches
+	}
+	return []string{value}
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(value string) string {
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + strings.TrimPrefix(value, "~")
+		}
+	}
+	return value
+}
+
+// looksLikePath reports whether an argument probably names a file rather
+// than being a pattern, a message or a subcommand
+func looksLikePath(word *shell.Word) bool {
+	value := word.Value
+	if value == "" || strings.ContainsAny(value, "\n") {
+		return false
+	}
+	if word.Glob || strings.HasPrefix(value, "~") || strings.HasPrefix(value, ".") || strings.Contains(value, "/") {
+		return true
+	}
+	if _, err := os.Stat(value); err == nil {
+		return true
+	}
+	return false
+}
+
+// hasOption reports whether an argument list has a flag, also as a prefix like -i.bak
+func hasOption(args []*shell.Word, flag string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg.Value, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueStrings removes duplicates, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// PrintSimulation shows what a dry run found
+func PrintSimulation(sim *Simulation) {
+	color.Cyan("🔬 Dry-run simulation (nothing was executed):")
+	if len(sim.Programs) > 0 {
+		color.Cyan("   Programs:  %s", strings.Join(sim.Programs, ", "))
+	}
+	printPaths("   Reads:     ", sim.Reads, color.Cyan)
+	printPaths("   Writes:    ", sim.Writes, color.Yellow)
+	if len(sim.Endpoints) > 0 {
+		color.Yellow("   Network:   %s", strings.Join(sim.Endpoints, ", "))
+	} else {
+		color.Green("   Network:   none")
+	}
+	if sim.Sudo {
+		color.Red("   Privileges: runs with sudo")
+	}
+	if sim.Background {
+		color.Yellow("   Background: leaves a process running")
+	}
+	for _, note := range sim.Notes {
+		color.Yellow("   ⚠️  %s", note)
+	}
+}
+
+// maxShownPaths limits how many matched paths a simulation lists
+const maxShownPaths = 10
+
+// printPaths prints a list of paths, shortened when a glob matched many
+func printPaths(label string, paths []string, print func(string, ...interface{})) {
+	if len(paths) == 0 {
+		return
+	}
+	shown := paths
+	if len(shown) > maxShownPaths {
+		shown = shown[:maxShownPaths]
+	}
+	line := strings.Join(shown, ", ")
+	if len(paths) > len(shown) {
+		line += fmt.Sprintf(" … and %d more", len(paths)-len(shown))
+	}
+	print("%s%s", label, line)
+}
@@ -0,0 +1,498 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"helix/internal/shell"
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// Snapshot retention defaults
+const (
+	DefaultSnapshotKeep  = 20
+	DefaultSnapshotBytes = 512 << 20
+)
+
+// SnapshotConfig says whether commands that change files are snapshotted
+// first, so /undo can restore the files, and how many snapshots are kept
+type SnapshotConfig struct {
+	Enabled  bool  `json:"enabled"`
+	Keep     int   `json:"keep,omitempty"`      // most snapshots kept; 0 means DefaultSnapshotKeep
+	MaxBytes int64 `json:"max_bytes,omitempty"` // most bytes all copies take together; 0 means DefaultSnapshotBytes
+}
+
+// keep returns how many snapshots are kept
+func (c SnapshotConfig) keep() int {
+	if c.Keep > 0 {
+		return c.Keep
+	}
+	return DefaultSnapshotKeep
+}
+
+// maxBytes returns how many bytes the copies may take
+func (c SnapshotConfig) maxBytes() int64 {
+	if c.MaxBytes > 0 {
+		return c.MaxBytes
+	}
+	return DefaultSnapshotBytes
+}
+
+// SnapshotPath is one path a command was about to change
+type SnapshotPath struct {
+	Path    string `json:"path"`
+	Existed bool   `json:"existed"`       // false: the command creates it, so undo removes it
+	Git     bool   `json:"git,omitempty"` // restored from the git snapshot instead of a copy
+}
+
+// Snapshot is the state of the paths a command changes, taken before it ran
+type Snapshot struct {
+	ID      string         `json:"id"`
+	Time    time.Time      `json:"time"`
+	Command string         `json:"command"`
+	WorkDir string         `json:"work_dir"`
+	Paths   []SnapshotPath `json:"paths"`
+	Bytes   int64          `json:"bytes"` // size of the copies
+	// Tracked files of a git repository are kept as a commit made by git
+	// stash create, which leaves the working tree and the stash list alone
+	GitRoot string `json:"git_root,omitempty"`
+	GitRef  string `json:"git_ref,omitempty"`
+}
+
+// SnapshotStore keeps snapshots in a directory, e.g. ~/.helix/snapshots:
+// one directory per snapshot with its description and copies
+type SnapshotStore struct {
+	dir    string
+	config SnapshotConfig
+}
+
+// NewSnapshotStore opens the snapshot directory; it is created on the first
+// snapshot
+func NewSnapshotStore(dir string, config SnapshotConfig) *SnapshotStore {
+	return &SnapshotStore{dir: dir, config: config}
+}
+
+// snapshotRefPrefix keeps git snapshot commits reachable until /undo or
+// pruning drops them
+const snapshotRefPrefix = "refs/helix/snapshots/"
+
+// Take snapshots the files inside the working directory a command would
+// change. It returns nil without an error when the command changes none.
+func (s *SnapshotStore) Take(command string) (*Snapshot, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	paths := snapshotTargets(command, cwd)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	snapshot := &Snapshot{Time: time.Now(), Command: command, WorkDir: cwd}
+	if root, ref := gitSnapshot(cwd); ref != "" {
+		snapshot.GitRoot, snapshot.GitRef = root, ref
+	}
+	var size int64
+	for _, path := range paths {
+		entry := SnapshotPath{Path: path}
+		if _, err := os.Lstat(path); err == nil {
+			entry.Existed = true
+			entry.Git = snapshot.GitRef != "" && gitCoversPath(snapshot.GitRoot, path)
+			if !entry.Git {
+				size += pathSize(path)
+			}
+		}
+		snapshot.Paths = append(snapshot.Paths, entry)
+	}
+	if size > s.config.maxBytes() {
+		return nil, fmt.Errorf("%s to copy is over the %s snapshot limit", utils.FormatBytes(size), utils.FormatBytes(s.config.maxBytes()))
+	}
+
+	if snapshot.ID, err = s.reserveID(newSnapshotID()); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(s.dir, snapshot.ID)
+	if err := os.Mkdir(filepath.Join(dir, "files"), 0700); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	for i, entry := range snapshot.Paths {
+		if !entry.Existed || entry.Git {
+			continue
+		}
+		if err := copyPath(entry.Path, snapshot.copyPath(s.dir, i)); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("cannot copy %s: %w", entry.Path, err)
+		}
+	}
+	snapshot.Bytes = size
+	if snapshot.GitRef != "" {
+		exec.Command("git", "-C", snapshot.GitRoot, "update-ref", snapshotRefPrefix+snapshot.ID, snapshot.GitRef).Run()
+	}
+	if err := s.save(*snapshot); err != nil {
+		s.Remove(snapshot.ID)
+		return nil, err
+	}
+	s.prune()
+	return snapshot, nil
+}
+
+// reserveID creates the directory of a new snapshot under an ID no other
+// snapshot has: snapshots taken within the same millisecond get a -2, -3,
+// ... suffix
+func (s *SnapshotStore) reserveID(id string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", err
+	}
+	for n := 1; ; n++ {
+		candidate := id
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", id, n)
+		}
+		err := os.Mkdir(filepath.Join(s.dir, candidate), 0700)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+// copyPath returns where the copy of a snapshot's i-th path is kept
+func (snapshot Snapshot) copyPath(storeDir string, i int) string {
+	return filepath.Join(storeDir, snapshot.ID, "files", strconv.Itoa(i))
+}
+
+// save writes a snapshot's description
+func (s *SnapshotStore) save(snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, snapshot.ID, "snapshot.json"), data, 0600)
+}
+
+// List returns the snapshots, newest first
+func (s *SnapshotStore) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name(), "snapshot.json"))
+		if err != nil {
+			continue
+		}
+		var snapshot Snapshot
+		if json.Unmarshal(data, &snapshot) == nil && snapshot.ID == entry.Name() {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.After(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// Find returns the snapshot with an ID or unique ID prefix, or the newest
+// one for ""
+func (s *SnapshotStore) Find(id string) (Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(snapshots) == 0 {
+		return Snapshot{}, fmt.Errorf("no snapshots to undo")
+	}
+	if id == "" {
+		return snapshots[0], nil
+	}
+	var found []Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.ID == id {
+			return snapshot, nil // the ID of one is a prefix of the next one's
+		}
+		if strings.HasPrefix(snapshot.ID, id) {
+			found = append(found, snapshot)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot %s", id)
+	case 1:
+		return found[0], nil
+	}
+	return Snapshot{}, fmt.Errorf("snapshot ID %s is ambiguous", id)
+}
+
+// Restore puts back the paths of a snapshot as they were before its command
+// ran, removes what the command created among them, and drops the snapshot
+func (s *SnapshotStore) Restore(snapshot Snapshot) error {
+	for i := len(snapshot.Paths) - 1; i >= 0; i-- {
+		entry := snapshot.Paths[i]
+		if err := clearPath(entry.Path); err != nil {
+			return fmt.Errorf("cannot restore %s: %w", entry.Path, err)
+		}
+		switch {
+		case !entry.Existed:
+		case entry.Git:
+			output, err := exec.Command("git", "-C", snapshot.GitRoot, "restore", "--source="+snapshot.GitRef, "--worktree", "--", entry.Path).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("cannot restore %s from git: %s", entry.Path, strings.TrimSpace(string(output)))
+			}
+		default:
+			if err := copyPath(snapshot.copyPath(s.dir, i), entry.Path); err != nil {
+				return fmt.Errorf("cannot restore %s: %w", entry.Path, err)
+			}
+		}
+	}
+	return s.Remove(snapshot.ID)
+}
+
+// Remove deletes a snapshot and its copies
+func (s *SnapshotStore) Remove(id string) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, id, "snapshot.json"))
+	var snapshot Snapshot
+	if err == nil && json.Unmarshal(data, &snapshot) == nil && snapshot.GitRef != "" {
+		exec.Command("git", "-C", snapshot.GitRoot, "update-ref", "-d", snapshotRefPrefix+id).Run()
+	}
+	return os.RemoveAll(filepath.Join(s.dir, id))
+}
+
+// prune drops the oldest snapshots beyond the retention limits
+func (s *SnapshotStore) prune() {
+	snapshots, err := s.List()
+	if err != nil {
+		return
+	}
+	var total int64
+	for i, snapshot := range snapshots {
+		total += snapshot.Bytes
+		if i >= s.config.keep() || (i > 0 && total > s.config.maxBytes()) {
+			s.Remove(snapshot.ID)
+		}
+	}
+}
+
+// newSnapshotID returns a sortable, readable snapshot ID
+func newSnapshotID() string {
+	now := time.Now()
+	return fmt.Sprintf("%s-%03d", now.Format("20060102-150405"), now.Nanosecond()/int(time.Millisecond))
+}
+
+// snapshotTargets returns the paths inside dir a command would create,
+// change or delete, outermost first and without paths inside others
+func snapshotTargets(command, dir string) []string {
+	sim, err := SimulateCommand(command)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, path := range sim.Writes {
+		if strings.ContainsAny(path, "*?[$") {
+			continue // only known at run time
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path = filepath.Clean(path)
+		if isWithin(path, dir) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+
+	var targets []string
+	for _, path := range paths {
+		covered := false
+		for _, target := range targets {
+			covered = covered || isWithin(path, target)
+		}
+		if !covered {
+			targets = append(targets, path)
+		}
+	}
+	return targets
+}
+
+// gitSnapshot records the working tree of the repository containing dir
+// with git stash create, or HEAD when nothing is changed. It returns empty
+// strings outside a repository.
+func gitSnapshot(dir string) (root, ref string) {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", ""
+	}
+	root = strings.TrimSpace(string(output))
+	if output, err = exec.Command("git", "-C", root, "stash", "create", "helix snapshot").Output(); err == nil && len(strings.TrimSpace(string(output))) > 0 {
+		return root, strings.TrimSpace(string(output))
+	}
+	if output, err = exec.Command("git", "-C", root, "rev-parse", "HEAD").Output(); err == nil {
+		return root, strings.TrimSpace(string(output))
+	}
+	return "", ""
+}
+
+// gitCoversPath reports whether git can restore a path by itself: it is
+// tracked and holds no untracked or ignored files
+func gitCoversPath(root, path string) bool {
+	tracked, err := exec.Command("git", "-C", root, "ls-files", "--", path).Output()
+	if err != nil || len(tracked) == 0 {
+		return false
+	}
+	others, err := exec.Command("git", "-C", root, "ls-files", "--others", "--", path).Output()
+	return err == nil && len(others) == 0
+}
+
+// pathSize returns the bytes of the regular files at or under a path
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// clearPath removes a path before it is restored. A directory is emptied
+// rather than removed, since it may be the working directory.
+func clearPath(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return os.Remove(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPath copies a file, symlink or directory tree, keeping modes. Files
+// are cloned copy-on-write where the file system can (btrfs, XFS).
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relative)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil // sockets, devices and pipes are not kept
+	})
+}
+
+// copyFile copies one regular file
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if cloneFile(out, in) != nil {
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// snapshots is the store of file snapshots, nil when they are off
+var snapshots struct {
+	mu    sync.Mutex
+	store *SnapshotStore
+}
+
+// SetSnapshotStore turns snapshots before file-changing commands on with a
+// store, or off with nil
+func SetSnapshotStore(store *SnapshotStore) {
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+	snapshots.store = store
+}
+
+// GetSnapshotStore returns the snapshot store, or nil when snapshots are off
+func GetSnapshotStore() *SnapshotStore {
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+	return snapshots.store
+}
+
+// takeSnapshot snapshots what a command is about to change, when snapshots
+// are on. A failed snapshot is reported but does not stop the command.
+func takeSnapshot(command string, env shell.Env) {
+	store := GetSnapshotStore()
+	if store == nil {
+		return
+	}
+	// Snapshots copy local files, which a remote command does not touch
+	if env.Remote != nil {
+		if sim, err := SimulateCommand(command); err == nil && len(sim.Writes) > 0 {
+			color.Yellow("⚠️  No snapshot taken: this command changes files on %s, which /undo cannot restore", env.Remote.Host)
+		}
+		return
+	}
+	snapshot, err := store.Take(command)
+	if err != nil {
+		color.Yellow("⚠️  No snapshot taken, /undo cannot restore this command's changes: %v", err)
+		return
+	}
+	if snapshot != nil {
+		color.Cyan("📸 Snapshot %s of %d path(s) taken (/undo restores them)", snapshot.ID, len(snapshot.Paths))
+	}
+}
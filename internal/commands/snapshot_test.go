@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helix/internal/shell"
+)
+
+func TestTakeSnapshotSkipsRemoteCommands(t *testing.T) {
+	work := t.TempDir()
+	if err := os.WriteFile(filepath.Join(work, "notes.txt"), []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	store := NewSnapshotStore(t.TempDir(), SnapshotConfig{Enabled: true})
+	SetSnapshotStore(store)
+	t.Cleanup(func() { SetSnapshotStore(nil) })
+
+	tests := []struct {
+		name  string
+		env   shell.Env
+		wantN int
+	}{
+		{name: "remote", env: shell.Env{OSName: "linux", Shell: "bash", Remote: &shell.RemoteSession{Host: "web-1"}}, wantN: 0},
+		{name: "local", env: shell.Env{OSName: "linux", Shell: "bash"}, wantN: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeSnapshot("rm notes.txt", tt.env)
+			list, err := store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tt.wantN {
+				t.Fatalf("%d snapshot(s) after a %s command, want %d", len(list), tt.name, tt.wantN)
+			}
+		})
+	}
+}
+
+// readTree returns the files under dir by relative path, with their contents
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// chdir moves into dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	work := t.TempDir()
+	for path, content := range map[string]string{
+		"notes.txt":       "original notes",
+		"gone.txt":        "deleted by the command",
+		"build/out.bin":   "build output",
+		"build/sub/a.txt": "nested",
+	} {
+		path = filepath.Join(work, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir(t, work)
+	before := readTree(t, work)
+
+	store := NewSnapshotStore(t.TempDir(), SnapshotConfig{Enabled: true})
+	snapshot, err := store.Take("rm gone.txt && rm -r build && echo changed > notes.txt && touch created.txt")
+	if err != nil || snapshot == nil {
+		t.Fatalf("Take = %v, %v", snapshot, err)
+	}
+
+	// What the command does
+	os.Remove(filepath.Join(work, "gone.txt"))
+	os.RemoveAll(filepath.Join(work, "build"))
+	os.WriteFile(filepath.Join(work, "notes.txt"), []byte("changed\n"), 0o644)
+	os.WriteFile(filepath.Join(work, "created.txt"), nil, 0o644)
+
+	if err := store.Restore(*snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if after := readTree(t, work); !reflect.DeepEqual(after, before) {
+		t.Fatalf("after restore the tree is %v, want %v", after, before)
+	}
+	if list, _ := store.List(); len(list) != 0 {
+		t.Fatalf("%d snapshot(s) left after restoring, want 0", len(list))
+	}
+}
+
+func TestSnapshotIDsAreUnique(t *testing.T) {
+	work := t.TempDir()
+	chdir(t, work)
+	store := NewSnapshotStore(t.TempDir(), SnapshotConfig{Enabled: true})
+
+	first, err := store.reserveID("20261016-151219-024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.reserveID("20261016-151219-024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "20261016-151219-024" || second != "20261016-151219-024-2" {
+		t.Fatalf("reserveID gave %q and %q for the same ID", first, second)
+	}
+	os.RemoveAll(filepath.Join(store.dir, first))
+	os.RemoveAll(filepath.Join(store.dir, second))
+
+	// Snapshots taken back to back often share a millisecond
+	seen := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		snapshot, err := store.Take("touch file.txt")
+		if err != nil || snapshot == nil {
+			t.Fatalf("Take = %v, %v", snapshot, err)
+		}
+		if seen[snapshot.ID] {
+			t.Fatalf("snapshot ID %s was given twice", snapshot.ID)
+		}
+		seen[snapshot.ID] = true
+		if found, err := store.Find(snapshot.ID); err != nil || found.ID != snapshot.ID {
+			t.Fatalf("Find(%s) = %s, %v", snapshot.ID, found.ID, err)
+		}
+	}
+	if list, _ := store.List(); len(list) != 5 {
+		t.Fatalf("%d snapshots listed, want 5", len(list))
+	}
+}
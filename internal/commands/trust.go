@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"helix/internal/shell"
+)
+
+// MatchTrusted returns the trusted pattern that lets a command run without
+// confirmation, or "". A pattern is an exact command like "git status" or a
+// glob like "ls *": its first word matches the program name and each other
+// word one argument, where * matches anything and ? one character, and a
+// final lone * stands for any arguments. Each step of a chain or pipeline
+// must be covered by a glob, so "ls *" does not trust "ls; rm -rf build";
+// redirections and substitutions need the exact command. Commands that
+// delete or write files, run a shell, are above medium risk or are
+// confirmed by a policy are never trusted.
+func MatchTrusted(command string, patterns []string) string {
+	command = strings.TrimSpace(command)
+	if command == "" || len(patterns) == 0 || !trustable(command) {
+		return ""
+	}
+
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == command {
+			return pattern
+		}
+	}
+
+	script, err := shell.Parse(command)
+	if err != nil || len(script.Stmts) == 0 {
+		return ""
+	}
+	matched := ""
+	for _, stmt := range script.Stmts {
+		if stmt.Op == "&" {
+			return ""
+		}
+		for _, cmd := range stmt.Pipeline.Commands {
+			pattern := trustedPatternFor(cmd, patterns)
+			if pattern == "" {
+				return ""
+			}
+			if matched == "" {
+				matched = pattern
+			}
+		}
+	}
+	return matched
+}
+
+// shellPrograms run the code they are given, which no pattern can vouch for
+var shellPrograms = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true, "csh": true,
+	"tcsh": true, "cmd": true, "powershell": true, "pwsh": true, "eval": true, "source": true, ".": true,
+}
+
+// trustable reports whether a command may run on trust at all: it neither
+// deletes nor writes files, runs no shell, is at most medium risk and no
+// policy asks for confirmation
+func trustable(command string) bool {
+	risk := AssessRisk(command)
+	if risk.Level.Rank() > RiskMedium.Rank() || risk.ConfirmToken != "" || risk.Blocked ||
+		risk.Has(CategoryDestructive) || risk.Has(CategoryModifiesFiles) {
+		return false
+	}
+	if decision := CheckPolicy(command); decision.Blocked || decision.RequiresConfirm {
+		return false
+	}
+	sim, err := SimulateCommand(command)
+	if err != nil || len(sim.Writes) > 0 {
+		return false
+	}
+	for _, program := range sim.Programs {
+		if shellPrograms[program] {
+			return false
+		}
+	}
+	return true
+}
+
+// trustedPatternFor returns the glob that covers a simple command, or ""
+func trustedPatternFor(cmd *shell.Command, patterns []string) string {
+	if cmd.Subshell != nil || cmd.Group != nil || len(cmd.Redirs) > 0 || len(cmd.Assigns) > 0 || len(cmd.Args) == 0 {
+		return ""
+	}
+	words := make([]string, 0, len(cmd.Args))
+	for _, word := range cmd.Args {
+		if len(word.Substs) > 0 {
+			return ""
+		}
+		words = append(words, word.Value)
+	}
+	for _, pattern := range patterns {
+		if matchCommandGlob(strings.Fields(pattern), words) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// matchCommandGlob reports whether a command's words match a trusted
+// pattern's: the program first, then each argument
+func matchCommandGlob(pattern, words []string) bool {
+	if len(pattern) == 0 || !matchWordGlob(pattern[0], words[0]) {
+		return false
+	}
+	args, patternArgs := words[1:], pattern[1:]
+	for i, p := range patternArgs {
+		if p == "*" && i == len(patternArgs)-1 {
+			return true // any arguments, or none
+		}
+		if i >= len(args) || !matchWordGlob(p, args[i]) {
+			return false
+		}
+	}
+	return len(args) == len(patternArgs)
+}
+
+// matchWordGlob reports whether a single word matches a glob
+func matchWordGlob(pattern, word string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == word
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	matched, err := regexp.MatchString(re.String(), word)
+	return err == nil && matched
+}
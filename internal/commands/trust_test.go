@@ -0,0 +1,43 @@
+package commands
+
+import "testing"
+
+func TestMatchTrusted(t *testing.T) {
+	patterns := []string{"ls *", "git status", "git log *", "cat ?.txt", "bash *", "rm *", "touch *"}
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ls", "ls *"},
+		{"ls -la src", "ls *"},
+		{"ls -la | grep go", ""},
+		{"ls && git status", "ls *"},
+		{"git status", "git status"},
+		{"git status --short", ""},
+		{"git log --oneline -5", "git log *"},
+		{"cat a.txt", "cat ?.txt"},
+		{"cat ab.txt", ""},
+		{"lsblk", ""},            // the program must match, not a prefix of the line
+		{"lsof -i :80", ""},      // as above
+		{"ls; rm -rf build", ""}, // every step must match
+		{"ls > files.txt", ""},   // writes a file
+		{"ls $(whoami)", ""},     // substitution
+		{"bash -c 'ls'", ""},     // runs a shell
+		{"rm notes.txt", ""},     // deletes
+		{"touch notes.txt", ""},  // writes
+		{"FOO=1 ls", ""},         // assignment
+	}
+	for _, tt := range tests {
+		if got := MatchTrusted(tt.command, patterns); got != tt.want {
+			t.Errorf("MatchTrusted(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTrustedExactCommandStillChecked(t *testing.T) {
+	for _, command := range []string{"rm -f notes.txt", "echo hi > notes.txt", "sh -c 'ls'"} {
+		if got := MatchTrusted(command, []string{command}); got != "" {
+			t.Errorf("MatchTrusted(%q) trusted by its exact pattern, want it asked", command)
+		}
+	}
+}
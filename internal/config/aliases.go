@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// literalAliasPrefix marks an alias expansion as a shell command to run as
+// is; other expansions are natural-language requests for /cmd
+const literalAliasPrefix = "!"
+
+// aliasNamePattern is what alias names may look like
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Alias is a named shortcut for a frequent request or command
+type Alias struct {
+	Name      string
+	Expansion string // without the ! of literal commands
+	Literal   bool
+}
+
+// ParseAliasDefinition splits "name = expansion" and removes one pair of
+// quotes around the expansion
+func ParseAliasDefinition(definition string) (name, expansion string, err error) {
+	name, expansion, found := strings.Cut(definition, "=")
+	if !found {
+		return "", "", fmt.Errorf("expected <name> = <request or !command>")
+	}
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	expansion = strings.TrimSpace(expansion)
+	if len(expansion) >= 2 && (expansion[0] == '"' || expansion[0] == '\'') && expansion[len(expansion)-1] == expansion[0] {
+		expansion = expansion[1 : len(expansion)-1]
+	}
+
+	if !aliasNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid alias name %q: use letters, digits, - and _", name)
+	}
+	if strings.TrimSpace(strings.TrimPrefix(expansion, literalAliasPrefix)) == "" {
+		return "", "", fmt.Errorf("alias %s needs a request or !command", name)
+	}
+	return name, expansion, nil
+}
+
+// SetAlias defines or replaces an alias and saves the configuration
+func (cfg *Config) SetAlias(name, expansion string) error {
+	if cfg.Aliases == nil {
+		cfg.Aliases = make(map[string]string)
+	}
+	cfg.Aliases[name] = expansion
+	return cfg.SavePreferences()
+}
+
+// RemoveAlias deletes an alias and saves the configuration
+func (cfg *Config) RemoveAlias(name string) error {
+	if _, ok := cfg.Aliases[name]; !ok {
+		return fmt.Errorf("no alias %s", name)
+	}
+	delete(cfg.Aliases, name)
+	return cfg.SavePreferences()
+}
+
+// FindAlias returns the alias with a name
+func (cfg *Config) FindAlias(name string) (Alias, bool) {
+	expansion, ok := cfg.Aliases[name]
+	if !ok {
+		return Alias{}, false
+	}
+	literal := strings.HasPrefix(expansion, literalAliasPrefix)
+	return Alias{
+		Name:      name,
+		Expansion: strings.TrimSpace(strings.TrimPrefix(expansion, literalAliasPrefix)),
+		Literal:   literal,
+	}, true
+}
+
+// ListAliases returns every alias sorted by name
+func (cfg *Config) ListAliases() []Alias {
+	names := make([]string, 0, len(cfg.Aliases))
+	for name := range cfg.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliases := make([]Alias, 0, len(names))
+	for _, name := range names {
+		alias, _ := cfg.FindAlias(name)
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// ExpandAlias replaces an alias name at the start of text with its
+// expansion, keeping any words after it (e.g. "cleanup in ~/src")
+func (cfg *Config) ExpandAlias(text string) (Alias, string, bool) {
+	text = strings.TrimSpace(text)
+	name, rest, _ := strings.Cut(text, " ")
+	alias, ok := cfg.FindAlias(name)
+	if !ok {
+		return Alias{}, text, false
+	}
+	if rest = strings.TrimSpace(rest); rest != "" {
+		return alias, alias.Expansion + " " + rest, true
+	}
+	return alias, alias.Expansion, true
+}
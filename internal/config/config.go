@@ -5,20 +5,32 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"helix/internal/ai"
 	"helix/internal/commands"
+	"helix/internal/rag"
+	"helix/internal/utils"
 )
 
 // Config holds runtime configuration and paths for Helix
 type Config struct {
-	ModelDir      string                 `json:"model_dir"`
-	ModelFile     string                 `json:"model_file"`
-	HistoryPath   string                 `json:"history_path"`
-	ConfigPath    string                 `json:"config_path"`
-	UserPrefs     UserPrefs              `json:"user_preferences"`
-	ModelConfig   ai.ModelConfig         `json:"model_config"`
-	ExecuteConfig commands.ExecuteConfig `json:"execute_config"`
+	ModelDir        string                       `json:"model_dir"`
+	ModelFile       string                       `json:"model_file"`
+	HistoryPath     string                       `json:"history_path"`
+	ConfigPath      string                       `json:"config_path"`
+	UserPrefs       UserPrefs                    `json:"user_preferences"`
+	ModelConfig     ai.ModelConfig               `json:"model_config"`
+	ExecuteConfig   commands.ExecuteConfig       `json:"execute_config"`
+	Sync            SyncSettings                 `json:"sync"`
+	Retrieval       rag.RetrievalConfig          `json:"retrieval"`
+	Indexing        rag.IndexingConfig           `json:"indexing"`
+	Aliases         map[string]string            `json:"aliases,omitempty"`
+	Sandbox         commands.SandboxPaths        `json:"sandbox"`
+	Trusted         map[string][]string          `json:"trusted,omitempty"`          // project root → commands and globs run without confirmation
+	TrustedProfiles map[string]string            `json:"trusted_profiles,omitempty"` // project root → SHA-256 of the .helix.yaml allowed to loosen the sandbox
+	Snapshots       commands.SnapshotConfig      `json:"snapshots"`
+	Packages        commands.PackageSourceConfig `json:"packages"`
 }
 
 // UserPrefs holds user preferences
@@ -28,6 +40,38 @@ type UserPrefs struct {
 	TypingEffect bool   `json:"typing_effect"`
 	DefaultMode  string `json:"default_mode"` // "ask" or "cmd"
 	SafeMode     bool   `json:"safe_mode"`
+
+	// IndexLocalizedMan also indexes MAN pages in the system language for /man,
+	// even without man/<locale> directories (they enable it automatically)
+	IndexLocalizedMan bool `json:"index_localized_man"`
+
+	// IndexShellHistory lets /cmd retrieve commands from ~/.bash_history and zsh/fish history
+	IndexShellHistory bool `json:"index_shell_history"`
+
+	// SizeUnits prints sizes in "binary" (KiB, MiB) or "decimal" (kB, MB) units
+	SizeUnits string `json:"size_units,omitempty"`
+
+	// Palette colors safety signals: "default" or "colorblind", which avoids red/green
+	Palette string `json:"palette,omitempty"`
+
+	// NumberLocale overrides the locale (e.g. de_DE) whose separators reports use
+	NumberLocale string `json:"number_locale,omitempty"`
+
+	// AutoCopy puts every command you accept on the clipboard
+	AutoCopy bool `json:"auto_copy,omitempty"`
+
+	// PackageManager is the package manager /install, /update, /remove and
+	// /pkg use instead of the detected one, e.g. port next to brew
+	PackageManager string `json:"package_manager,omitempty"`
+
+	// NeverSudo refuses every command that runs sudo, doas or pkexec
+	NeverSudo bool `json:"never_sudo,omitempty"`
+
+	// TwoPerson holds destructive commands until a second operator approves
+	// them with helix approve; requests go to ApprovalsFile, by default
+	// ~/.helix/approvals.json
+	TwoPerson     bool   `json:"two_person,omitempty"`
+	ApprovalsFile string `json:"approvals_file,omitempty"`
 }
 
 // DefaultConfig returns sane default paths for Helix
@@ -60,6 +104,7 @@ func DefaultConfig() (*Config, error) {
 		},
 		ModelConfig:   ai.DefaultModelConfig(),
 		ExecuteConfig: commands.DefaultExecuteConfig(),
+		Retrieval:     rag.DefaultRetrievalConfig(),
 	}
 
 	// Load user preferences if config file exists
@@ -73,6 +118,17 @@ func (cfg *Config) EnsureModelDir() error {
 	return os.MkdirAll(cfg.ModelDir, 0755)
 }
 
+// NumberFormat returns the units and separators reports print sizes, counts
+// and durations with. An unknown unit system falls back to binary units.
+func (cfg *Config) NumberFormat() (utils.NumberFormat, error) {
+	units, err := utils.ParseUnitSystem(cfg.UserPrefs.SizeUnits)
+	locale := cfg.UserPrefs.NumberLocale
+	if locale == "" {
+		locale = utils.SystemLocale()
+	}
+	return utils.NumberFormatForLocale(locale, units), err
+}
+
 // EnsureConfigDir ensures that the config directory exists
 func (cfg *Config) EnsureConfigDir() error {
 	return os.MkdirAll(filepath.Dir(cfg.ConfigPath), 0755)
@@ -97,6 +153,19 @@ func (cfg *Config) LoadPreferences() error {
 	if prefs.ModelConfig.MaxTokens > 0 {
 		cfg.ModelConfig = prefs.ModelConfig
 	}
+	cfg.Sync = prefs.Sync
+	// Only resource limits persist; dry-run and safe mode are per session
+	cfg.ExecuteConfig = cfg.ExecuteConfig.WithLimits(prefs.ExecuteConfig)
+	if prefs.Retrieval.TopK > 0 {
+		cfg.Retrieval = prefs.Retrieval
+	}
+	cfg.Indexing = prefs.Indexing
+	cfg.Aliases = prefs.Aliases
+	cfg.Sandbox = prefs.Sandbox
+	cfg.Trusted = prefs.Trusted
+	cfg.TrustedProfiles = prefs.TrustedProfiles
+	cfg.Snapshots = prefs.Snapshots
+	cfg.Packages = prefs.Packages
 
 	return nil
 }
@@ -127,3 +196,25 @@ const (
 	ModelURL      = "https://huggingface.co/TheBloke/TinyLlama-1.1B-Chat-v1.0-GGUF/resolve/main/tinyllama-1.1b-chat-v1.0.Q4_0.gguf"
 	ModelChecksum = "da3087fb14aede55fde6eb81a0e55e886810e43509ec82ecdc7aa5d62a03b556"
 )
+
+// ReleaseSigningKeys are the minisign public keys downloaded artifacts must
+// be signed with, pinned into the binary at build time:
+//
+//	go build -ldflags "-X 'helix/internal/config.ReleaseSigningKeys=RWQ...,RWS...'"
+//
+// To rotate keys, pin the next key alongside the current one for a release
+// before signing with it; drop a key to stop trusting it.
+var ReleaseSigningKeys = ""
+
+// TrustedKeys returns the pinned release signing keys. Keys that were
+// pinned but do not parse are an error, never the same as no keys.
+func TrustedKeys() ([]utils.PublicKey, error) {
+	keys, err := utils.ParsePublicKeys(ReleaseSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 && strings.TrimSpace(ReleaseSigningKeys) != "" {
+		return nil, fmt.Errorf("no minisign public key in %q", ReleaseSigningKeys)
+	}
+	return keys, nil
+}
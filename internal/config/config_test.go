@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+func TestTrustedKeysRejectsBadPins(t *testing.T) {
+	tests := []struct {
+		name    string
+		pinned  string
+		want    int
+		wantErr bool
+	}{
+		{name: "none pinned", pinned: "", want: 0},
+		{name: "valid", pinned: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3", want: 1},
+		{name: "placeholder", pinned: "RWQ...", wantErr: true},
+		{name: "only separators", pinned: ", ,", wantErr: true},
+	}
+	saved := ReleaseSigningKeys
+	t.Cleanup(func() { ReleaseSigningKeys = saved })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ReleaseSigningKeys = tt.pinned
+			keys, err := TrustedKeys()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TrustedKeys error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(keys) != tt.want {
+				t.Fatalf("got %d keys, want %d", len(keys), tt.want)
+			}
+		})
+	}
+}
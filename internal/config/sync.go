@@ -0,0 +1,307 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"helix/internal/commands"
+)
+
+// Directories inside a sync repository (and the local overrides directory)
+const (
+	snippetsDir     = "snippets"
+	gitTemplatesDir = "git-templates"
+	policiesDir     = "policies"
+)
+
+// SyncSettings configures the team git repository Helix syncs shared content from
+type SyncSettings struct {
+	Remote        string    `json:"remote"`
+	Branch        string    `json:"branch"`
+	RequireSigned bool      `json:"require_signed"`
+	LastCommit    string    `json:"last_commit,omitempty"`
+	LastSynced    time.Time `json:"last_synced,omitempty"`
+}
+
+// Snippet is a named, vetted command shared by a team
+type Snippet struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Command     string `json:"command"`
+	Source      string `json:"-"`
+}
+
+// SharedContent is everything loaded from the sync repository and local overrides
+type SharedContent struct {
+	Snippets     []Snippet
+	GitTemplates []commands.GitOperation
+	Policies     []commands.PolicyPack
+	Commit       string
+}
+
+// SyncResult describes what a pull changed
+type SyncResult struct {
+	PreviousCommit string
+	Commit         string
+	Verified       bool
+}
+
+// TeamSync manages the local clone of the shared repository
+type TeamSync struct {
+	cfg         *Config
+	repoDir     string
+	overrideDir string
+}
+
+// NewTeamSync creates a sync manager rooted in the Helix config directory
+func NewTeamSync(cfg *Config) *TeamSync {
+	baseDir := filepath.Dir(cfg.ConfigPath)
+	return &TeamSync{
+		cfg:         cfg,
+		repoDir:     filepath.Join(baseDir, "sync", "repo"),
+		overrideDir: filepath.Join(baseDir, "overrides"),
+	}
+}
+
+// IsConfigured reports whether a sync remote has been set up
+func (ts *TeamSync) IsConfigured() bool {
+	return ts.cfg.Sync.Remote != ""
+}
+
+// Settings returns the current sync settings
+func (ts *TeamSync) Settings() SyncSettings {
+	return ts.cfg.Sync
+}
+
+// OverrideDir returns the directory holding local overrides
+func (ts *TeamSync) OverrideDir() string {
+	return ts.overrideDir
+}
+
+// Setup clones the shared repository and saves the sync settings
+func (ts *TeamSync) Setup(remote, branch string, requireSigned bool) error {
+	if branch == "" {
+		branch = "main"
+	}
+
+	if err := os.RemoveAll(ts.repoDir); err != nil {
+		return fmt.Errorf("failed to clear previous sync clone: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.repoDir), 0755); err != nil {
+		return fmt.Errorf("failed to create sync directory: %w", err)
+	}
+
+	if _, err := runGit("", "clone", "--branch", branch, "--single-branch", remote, ts.repoDir); err != nil {
+		return err
+	}
+
+	commit, err := runGit(ts.repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if requireSigned {
+		if err := verifyCommit(ts.repoDir, commit); err != nil {
+			os.RemoveAll(ts.repoDir)
+			return err
+		}
+	}
+
+	ts.cfg.Sync = SyncSettings{
+		Remote:        remote,
+		Branch:        branch,
+		RequireSigned: requireSigned,
+		LastCommit:    commit,
+		LastSynced:    time.Now(),
+	}
+	return ts.cfg.SavePreferences()
+}
+
+// Pull fetches the latest shared content. History rewrites are refused unless
+// force is set, and unsigned commits are refused when signatures are required.
+func (ts *TeamSync) Pull(force bool) (*SyncResult, error) {
+	if !ts.IsConfigured() {
+		return nil, fmt.Errorf("sync is not configured; run /sync setup <git-url> first")
+	}
+
+	settings := ts.cfg.Sync
+	if _, err := os.Stat(filepath.Join(ts.repoDir, ".git")); err != nil {
+		// The clone went missing; recreate it from the saved settings
+		if err := ts.Setup(settings.Remote, settings.Branch, settings.RequireSigned); err != nil {
+			return nil, err
+		}
+		return &SyncResult{Commit: ts.cfg.Sync.LastCommit, Verified: settings.RequireSigned}, nil
+	}
+
+	if _, err := runGit(ts.repoDir, "fetch", "origin", settings.Branch); err != nil {
+		return nil, err
+	}
+
+	commit, err := runGit(ts.repoDir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{PreviousCommit: settings.LastCommit, Commit: commit}
+
+	if settings.LastCommit != "" && settings.LastCommit != commit && !force {
+		if _, err := runGit(ts.repoDir, "merge-base", "--is-ancestor", settings.LastCommit, commit); err != nil {
+			return nil, fmt.Errorf("remote history was rewritten since %s; use /sync pull --force to accept it", ShortCommit(settings.LastCommit))
+		}
+	}
+
+	if settings.RequireSigned {
+		if err := verifyCommit(ts.repoDir, commit); err != nil {
+			return nil, err
+		}
+		result.Verified = true
+	}
+
+	// The clone is private to Helix, so it always mirrors the remote exactly
+	if _, err := runGit(ts.repoDir, "reset", "--hard", commit); err != nil {
+		return nil, err
+	}
+
+	ts.cfg.Sync.LastCommit = commit
+	ts.cfg.Sync.LastSynced = time.Now()
+	if err := ts.cfg.SavePreferences(); err != nil {
+		return nil, fmt.Errorf("failed to save sync state: %w", err)
+	}
+
+	return result, nil
+}
+
+// Load reads snippets, git templates and policy packs from the synced repository,
+// then applies local overrides, which replace shared items with the same name
+func (ts *TeamSync) Load() (*SharedContent, error) {
+	content := &SharedContent{Commit: ts.cfg.Sync.LastCommit}
+
+	var snippets []Snippet
+	var templates []commands.GitOperation
+	var policies []commands.PolicyPack
+
+	for _, root := range []string{ts.repoDir, ts.overrideDir} {
+		source := "shared"
+		if root == ts.overrideDir {
+			source = "local"
+		}
+
+		var s []Snippet
+		if err := loadJSONDir(filepath.Join(root, snippetsDir), &s); err != nil {
+			return nil, err
+		}
+		for i := range s {
+			s[i].Source = source
+		}
+		snippets = mergeByName(snippets, s, func(item Snippet) string { return item.Name })
+
+		var t []commands.GitOperation
+		if err := loadJSONDir(filepath.Join(root, gitTemplatesDir), &t); err != nil {
+			return nil, err
+		}
+		for i := range t {
+			t[i].Source = source
+		}
+		templates = mergeByName(templates, t, func(item commands.GitOperation) string { return item.Name })
+
+		var p []commands.PolicyPack
+		if err := loadJSONDir(filepath.Join(root, policiesDir), &p); err != nil {
+			return nil, err
+		}
+		for i := range p {
+			p[i].Source = source
+		}
+		policies = mergeByName(policies, p, func(item commands.PolicyPack) string { return item.Name })
+	}
+
+	sort.Slice(snippets, func(i, j int) bool { return snippets[i].Name < snippets[j].Name })
+
+	content.Snippets = snippets
+	content.GitTemplates = templates
+	content.Policies = policies
+	return content, nil
+}
+
+// FindSnippet returns the snippet with the given name
+func (sc *SharedContent) FindSnippet(name string) (Snippet, bool) {
+	for _, snippet := range sc.Snippets {
+		if strings.EqualFold(snippet.Name, name) {
+			return snippet, true
+		}
+	}
+	return Snippet{}, false
+}
+
+// loadJSONDir appends the JSON arrays from every *.json file in dir to out
+func loadJSONDir[T any](dir string, out *[]T) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		var items []T
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("invalid %s (expected a JSON array): %w", file, err)
+		}
+		*out = append(*out, items...)
+	}
+	return nil
+}
+
+// mergeByName adds overrides to base, replacing items that share a name
+func mergeByName[T any](base, overrides []T, name func(T) string) []T {
+	for _, override := range overrides {
+		replaced := false
+		for i := range base {
+			if name(base[i]) == name(override) {
+				base[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, override)
+		}
+	}
+	return base
+}
+
+// verifyCommit checks the commit's GPG/SSH signature with git verify-commit
+func verifyCommit(repoDir, commit string) error {
+	if _, err := runGit(repoDir, "verify-commit", commit); err != nil {
+		return fmt.Errorf("commit %s failed signature verification: %w", ShortCommit(commit), err)
+	}
+	return nil
+}
+
+// runGit runs a git command and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ShortCommit abbreviates a commit hash for display
+func ShortCommit(commit string) string {
+	if len(commit) > 10 {
+		return commit[:10]
+	}
+	return commit
+}
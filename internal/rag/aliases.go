@@ -0,0 +1,226 @@
+package rag
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxRedirectPageSize is the largest MAN page file read for a ".so"
+// redirection; real pages are far larger than a one-line include
+const maxRedirectPageSize = 512
+
+// defaultCommandAliases name the page documenting commands that are commonly
+// installed under another name without a MAN page of their own
+var defaultCommandAliases = map[string][]string{
+	"vi":      {"vim", "nvi", "nvim"},
+	"view":    {"vim"},
+	"ex":      {"vim"},
+	"python":  {"python3"},
+	"pip":     {"pip3"},
+	"awk":     {"gawk", "mawk"},
+	"egrep":   {"grep"},
+	"fgrep":   {"grep"},
+	"gunzip":  {"gzip"},
+	"zcat":    {"gzip"},
+	"unxz":    {"xz"},
+	"bunzip2": {"bzip2"},
+	"pager":   {"less"},
+	"editor":  {"nano", "vim"},
+}
+
+// pageFileCommand returns the command a MAN page file documents, e.g. vim
+// for /usr/share/man/man1/vim.1.gz
+func pageFileCommand(path string) string {
+	return strings.Split(filepath.Base(path), ".")[0]
+}
+
+// manPageAlias returns the command whose page a MAN page file stands in
+// for: the target of a symlink such as vi.1.gz -> vim.1.gz, or of a ".so"
+// include. It returns "" for a page of its own.
+func manPageAlias(path string) string {
+	if path == "" {
+		return ""
+	}
+	command := pageFileCommand(path)
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	if target := pageFileCommand(resolved); target != command {
+		return target
+	}
+
+	if target := pageFileCommand(soRedirect(resolved)); target != "" && target != command {
+		return target
+	}
+	return ""
+}
+
+// soRedirect returns the page a tiny MAN page includes with ".so", e.g.
+// "man1/grep.1" for egrep.1, or ""
+func soRedirect(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxRedirectPageSize {
+		return ""
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return ""
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ".so ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, ".so "))
+		}
+		if line != "" && !strings.HasPrefix(line, `.\"`) && !strings.HasPrefix(line, `'\"`) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// binaryAliases returns the names the executable a command runs could be
+// documented under, following symlinks and dropping version and variant
+// suffixes: /usr/bin/vi -> vim.basic gives vim.basic and vim
+func binaryAliases(command string) []string {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for name := filepath.Base(resolved); name != "" && name != command; {
+		names = append(names, name)
+		i := strings.LastIndex(name, ".")
+		if i <= 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return names
+}
+
+// aliasesOf returns the commands whose MAN pages stand in for a page
+func (mi *MANIndexer) aliasesOf(command string) []string {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+
+	var aliases []string
+	for alias, target := range mi.aliases {
+		if target == command {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// isAlias reports whether a command's MAN page only stands in for another
+// page, which is indexed under its own name instead
+func (mi *MANIndexer) isAlias(command string) bool {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	_, alias := mi.aliases[command]
+	return alias
+}
+
+// aliasCache remembers resolved names until the index changes, since
+// resolving a name that is not indexed runs man and searches PATH
+type aliasCache struct {
+	mu         sync.Mutex
+	generation uint64
+	names      map[string]string
+}
+
+// get returns a name resolved at the given index generation
+func (c *aliasCache) get(command string, generation uint64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return "", false
+	}
+	name, ok := c.names[command]
+	return name, ok
+}
+
+// put records a resolved name, dropping names from older generations
+func (c *aliasCache) put(command string, generation uint64, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.names == nil || c.generation != generation {
+		c.names = make(map[string]string)
+		c.generation = generation
+	}
+	c.names[command] = name
+}
+
+// ResolveCommand returns the name a command is documented under in the index:
+// the command itself, the page that lists it as an alias, a known alias
+// target, the target of its MAN page symlink or of its executable's symlink.
+// It returns "" when none of them is indexed.
+func (vs *VectorStore) ResolveCommand(command string) string {
+	if len(vs.commandDocuments(command)) > 0 {
+		return command
+	}
+	generation := vs.Generation()
+	if name, ok := vs.resolved.get(command, generation); ok {
+		return name
+	}
+
+	resolved := ""
+	candidates := vs.indexedAliasTargets(command)
+	candidates = append(candidates, defaultCommandAliases[command]...)
+	if target := manPageAlias(lookupManPath(command)); target != "" {
+		candidates = append(candidates, target)
+	}
+	candidates = append(candidates, binaryAliases(command)...)
+
+	for _, candidate := range candidates {
+		if candidate != command && len(vs.commandDocuments(candidate)) > 0 {
+			resolved = candidate
+			break
+		}
+	}
+	vs.resolved.put(command, generation, resolved)
+	return resolved
+}
+
+// indexedAliasTargets returns the indexed pages that list a command as an alias
+func (vs *VectorStore) indexedAliasTargets(command string) []string {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	var targets []string
+	for _, doc := range vs.documents {
+		if doc.Metadata.Section == "command" && containsString(doc.Metadata.Aliases, command) {
+			targets = append(targets, doc.Metadata.Command)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,372 @@
+package rag
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const analyzerFileName = "rag_analyzer.json"
+
+// minTermLength drops very short words; command names such as "ls" are
+// indexed separately as name tokens
+const minTermLength = 3
+
+// AnalyzerConfig configures how text is turned into index terms. The same
+// analyzer runs over documents and queries, so both always agree.
+type AnalyzerConfig struct {
+	Language  string            `json:"language"`            // stemming and default stopwords, e.g. "en"
+	Stem      bool              `json:"stem"`                // reduce words to their stem (listing -> list)
+	Stopwords []string          `json:"stopwords,omitempty"` // replaces the language's default stopwords
+	Synonyms  map[string]string `json:"synonyms,omitempty"`  // word -> canonical word, applied before stemming
+	// Expansions adds words to queries before retrieval, so "show folders"
+	// also searches for "list directories"; documents are left as written
+	Expansions map[string][]string `json:"expansions,omitempty"`
+}
+
+// defaultStopwords are the stopwords of each language with stemming support
+var defaultStopwords = map[string][]string{
+	englishLanguage: {
+		"the", "and", "for", "with", "this", "that", "from", "are", "was", "were",
+		"have", "has", "had", "will", "would", "could", "should", "can", "may", "might",
+		"which", "what", "when", "where", "why", "how", "who", "whom", "whose",
+		"into", "onto", "all", "any", "some", "its", "our", "your", "you", "them", "they",
+		"there", "their", "then", "than", "not", "but", "does", "did", "doing", "been", "being",
+	},
+}
+
+// defaultAnalyzerConfig is written to the analyzer file the first time it is missing
+var defaultAnalyzerConfig = AnalyzerConfig{
+	Language: englishLanguage,
+	Stem:     true,
+	Synonyms: map[string]string{
+		"folder":  "directory",
+		"folders": "directories",
+	},
+	Expansions: defaultExpansions,
+}
+
+// defaultExpansions maps everyday request words to the terms MAN pages use
+var defaultExpansions = map[string][]string{
+	"show":      {"list", "display"},
+	"display":   {"list", "show"},
+	"view":      {"list", "display"},
+	"delete":    {"remove"},
+	"erase":     {"remove"},
+	"folder":    {"directory"},
+	"folders":   {"directories"},
+	"search":    {"find"},
+	"locate":    {"find"},
+	"rename":    {"move"},
+	"terminate": {"kill"},
+	"stop":      {"kill"},
+	"unzip":     {"extract", "decompress"},
+	"zip":       {"compress", "archive"},
+	"download":  {"fetch", "transfer"},
+	"space":     {"disk", "usage"},
+}
+
+// TokenFilter is one step of an analyzer chain
+type TokenFilter func(tokens []string) []string
+
+// Analyzer runs text through a chain of token filters
+type Analyzer struct {
+	config    AnalyzerConfig
+	stopwords map[string]bool
+	filters   []TokenFilter
+}
+
+// NewAnalyzer builds the filter chain for a configuration:
+// split and lowercase, trim punctuation, drop short words and stopwords,
+// map synonyms, then stem
+func NewAnalyzer(config AnalyzerConfig) *Analyzer {
+	a := &Analyzer{config: config, stopwords: make(map[string]bool)}
+
+	stopwords := config.Stopwords
+	if len(stopwords) == 0 {
+		stopwords = defaultStopwords[config.Language]
+	}
+	for _, word := range stopwords {
+		a.stopwords[strings.ToLower(word)] = true
+	}
+
+	a.filters = []TokenFilter{trimPunctuationFilter, a.lengthFilter, a.stopwordFilter}
+	if len(config.Synonyms) > 0 {
+		a.filters = append(a.filters, a.synonymFilter)
+	}
+	if config.Stem && config.Language == englishLanguage {
+		a.filters = append(a.filters, stemFilter)
+	}
+	return a
+}
+
+// loadAnalyzer reads the analyzer file, creating it with the defaults when missing
+func loadAnalyzer(path string) *Analyzer {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if data, err := json.MarshalIndent(defaultAnalyzerConfig, "", "  "); err == nil {
+			if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+				os.WriteFile(path, data, 0644)
+			}
+		}
+		return NewAnalyzer(defaultAnalyzerConfig)
+	}
+	if err != nil {
+		return NewAnalyzer(defaultAnalyzerConfig)
+	}
+
+	var config AnalyzerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		color.Yellow("⚠️  Ignoring invalid %s: %v", path, err)
+		return NewAnalyzer(defaultAnalyzerConfig)
+	}
+	if config.Language == "" {
+		config.Language = englishLanguage
+	}
+	if config.Expansions == nil {
+		// Files written before expansions existed get the defaults; "{}" turns them off
+		config.Expansions = defaultExpansions
+	}
+	return NewAnalyzer(config)
+}
+
+// Analyze turns text into index terms
+func (a *Analyzer) Analyze(text string) []string {
+	tokens := strings.Fields(strings.ToLower(text))
+	for _, filter := range a.filters {
+		tokens = filter(tokens)
+	}
+	return tokens
+}
+
+// ExpandQuery appends the expansion words of a query's words that the query
+// does not already contain
+func (a *Analyzer) ExpandQuery(query string) string {
+	if len(a.config.Expansions) == 0 {
+		return query
+	}
+
+	words := trimPunctuationFilter(strings.Fields(strings.ToLower(query)))
+	present := make(map[string]bool)
+	for _, word := range words {
+		present[word] = true
+	}
+
+	var extra []string
+	for _, word := range words {
+		for _, expansion := range a.config.Expansions[word] {
+			expansion = strings.ToLower(expansion)
+			if !present[expansion] {
+				present[expansion] = true
+				extra = append(extra, expansion)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return query
+	}
+	return query + " " + strings.Join(extra, " ")
+}
+
+// IsStopWord reports whether a lowercase word is a stopword
+func (a *Analyzer) IsStopWord(word string) bool {
+	return a.stopwords[word]
+}
+
+// Language returns the language the analyzer stems and filters for
+func (a *Analyzer) Language() string {
+	return a.config.Language
+}
+
+// trimPunctuationFilter removes surrounding punctuation, keeping the dashes of flags
+func trimPunctuationFilter(tokens []string) []string {
+	result := tokens[:0]
+	for _, token := range tokens {
+		if token = strings.Trim(token, ".,!?;:\"'()[]{}`"); token != "" {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// lengthFilter drops words too short to be meaningful
+func (a *Analyzer) lengthFilter(tokens []string) []string {
+	result := tokens[:0]
+	for _, token := range tokens {
+		if len(token) >= minTermLength {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// stopwordFilter drops stopwords
+func (a *Analyzer) stopwordFilter(tokens []string) []string {
+	result := tokens[:0]
+	for _, token := range tokens {
+		if !a.stopwords[token] {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// synonymFilter maps words to their canonical form
+func (a *Analyzer) synonymFilter(tokens []string) []string {
+	for i, token := range tokens {
+		if canonical, ok := a.config.Synonyms[token]; ok {
+			tokens[i] = strings.ToLower(canonical)
+		}
+	}
+	return tokens
+}
+
+// stemFilter reduces plain English words to their stem. Flags, paths and
+// words with digits are left alone so "-exec" or "ext4" still match exactly.
+func stemFilter(tokens []string) []string {
+	for i, token := range tokens {
+		if isPlainWord(token) {
+			tokens[i] = stem(token)
+		}
+	}
+	return tokens
+}
+
+// isPlainWord reports whether a token consists of lowercase ASCII letters only
+func isPlainWord(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// ========== ENGLISH STEMMER ==========
+
+// stem applies the plural, past tense, -ing and final -e steps of the Porter
+// stemmer, which cover the forms command descriptions and questions differ in
+// (files/file, listing/list, directories/directory, deleted/delete)
+func stem(word string) string {
+	if len(word) <= 3 {
+		return word
+	}
+
+	// Step 1a: plurals
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+	case strings.HasSuffix(word, "s"):
+		word = word[:len(word)-1]
+	}
+
+	// Step 1b: past tense and gerunds
+	switch {
+	case strings.HasSuffix(word, "eed"):
+		if measure(word[:len(word)-3]) > 0 {
+			word = word[:len(word)-1]
+		}
+	case strings.HasSuffix(word, "ed") && hasVowel(word[:len(word)-2]):
+		word = restoreStem(word[:len(word)-2])
+	case strings.HasSuffix(word, "ing") && hasVowel(word[:len(word)-3]):
+		word = restoreStem(word[:len(word)-3])
+	}
+
+	// Step 1c: terminal y
+	if strings.HasSuffix(word, "y") && hasVowel(word[:len(word)-1]) {
+		word = word[:len(word)-1] + "i"
+	}
+
+	// Step 5a: final e
+	if strings.HasSuffix(word, "e") {
+		base := word[:len(word)-1]
+		if m := measure(base); m > 1 || (m == 1 && !endsCVC(base)) {
+			word = base
+		}
+	}
+
+	// Step 5b: double l
+	if strings.HasSuffix(word, "ll") && measure(word) > 1 {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+// restoreStem tidies a stem after removing -ed or -ing
+func restoreStem(word string) string {
+	switch {
+	case strings.HasSuffix(word, "at"), strings.HasSuffix(word, "bl"), strings.HasSuffix(word, "iz"):
+		return word + "e"
+	case endsDoubleConsonant(word) && !strings.HasSuffix(word, "l") &&
+		!strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "z"):
+		return word[:len(word)-1]
+	case measure(word) == 1 && endsCVC(word):
+		return word + "e"
+	}
+	return word
+}
+
+// isConsonant reports whether the letter at i is a consonant in Porter's sense
+func isConsonant(word string, i int) bool {
+	switch word[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(word, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in a word
+func measure(word string) int {
+	m := 0
+	inVowel := false
+	for i := 0; i < len(word); i++ {
+		if isConsonant(word, i) {
+			if inVowel {
+				m++
+			}
+			inVowel = false
+		} else {
+			inVowel = true
+		}
+	}
+	return m
+}
+
+// hasVowel reports whether a word contains a vowel
+func hasVowel(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if !isConsonant(word, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDoubleConsonant reports whether a word ends in a doubled consonant
+func endsDoubleConsonant(word string) bool {
+	n := len(word)
+	return n >= 2 && word[n-1] == word[n-2] && isConsonant(word, n-1)
+}
+
+// endsCVC reports whether a word ends consonant-vowel-consonant, where the
+// last consonant is not w, x or y (e.g. "hop", "mak")
+func endsCVC(word string) bool {
+	n := len(word)
+	if n < 3 || !isConsonant(word, n-3) || isConsonant(word, n-2) || !isConsonant(word, n-1) {
+		return false
+	}
+	switch word[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
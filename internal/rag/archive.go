@@ -0,0 +1,369 @@
+package rag
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	indexArchiveManifest = "helix_index.json"
+	indexArchiveFormat   = "1"
+	maxArchiveFileSize   = 2 << 30 // refuse entries larger than 2 GiB
+)
+
+// archiveDirs maps the directory names used inside an archive to the
+// directories holding the index state, vector database, MAN manifest,
+// localized pages and page caches
+func (rs *RAGSystem) archiveDirs() map[string]string {
+	return map[string]string{
+		"rag_index":        rs.indexDir,
+		"vector_index":     rs.vectorStore.indexDir,
+		"man_index":        rs.indexer.indexDir,
+		"tldr_index":       rs.tldr.indexDir,
+		"powershell_index": rs.powershell.indexDir,
+	}
+}
+
+// vectorDBEntry is the archive name of the vector database
+const vectorDBEntry = "vector_index/" + vectorDBFileName
+
+// archivedFiles lists the files an export carries, as "<dir>/<file>". Only
+// the database is taken from the vector index directory; a leftover legacy
+// JSON index would be migrated over the imported one.
+func (rs *RAGSystem) archivedFiles() []string {
+	var files []string
+	for dirName, dir := range rs.archiveDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := dirName + "/" + entry.Name()
+			if !entry.Type().IsRegular() || (dirName == "vector_index" && name != vectorDBEntry) {
+				continue
+			}
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// archivePath returns where an archive entry lives on this host, or false
+// when the name is not one an export produces
+func (rs *RAGSystem) archivePath(name string) (string, bool) {
+	dirName, file, found := strings.Cut(name, "/")
+	dir, known := rs.archiveDirs()[dirName]
+	if !found || !known || file == "" || file == "." || file == ".." || strings.ContainsAny(file, `/\`) {
+		return "", false
+	}
+	return filepath.Join(dir, file), true
+}
+
+// IndexArchive describes an exported index
+type IndexArchive struct {
+	Format    string    `json:"format"`
+	Index     IndexInfo `json:"index"`
+	Source    string    `json:"source"` // man, or help for PowerShell help indexes
+	Documents int       `json:"documents"`
+	Commands  int       `json:"commands"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []string  `json:"files"`
+}
+
+// ExportIndex writes the index state, vector database, MAN manifest and page
+// caches into one gzip-compressed tar archive
+func (rs *RAGSystem) ExportIndex(path string) (*IndexArchive, error) {
+	if !rs.initialized {
+		return nil, fmt.Errorf("RAG system not initialized")
+	}
+	if rs.IsRebuilding() {
+		return nil, fmt.Errorf("the index is being rebuilt; export it when the rebuild finishes")
+	}
+	if err := rs.vectorStore.saveVectorIndex(); err != nil {
+		return nil, err
+	}
+
+	archive := &IndexArchive{
+		Format:    indexArchiveFormat,
+		Index:     rs.vectorStore.IndexInfo(),
+		Source:    rs.vectorStore.pageSource,
+		Documents: rs.vectorStore.DocumentCount(),
+		CreatedAt: time.Now(),
+	}
+	if commands, ok := rs.vectorStore.GetStats()["unique_commands"].(int); ok {
+		archive.Commands = commands
+	}
+	archive.Files = rs.archivedFiles()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	err = rs.writeIndexArchive(file, archive)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return archive, nil
+}
+
+// writeIndexArchive writes the manifest followed by the index files
+func (rs *RAGSystem) writeIndexArchive(w io.Writer, archive *IndexArchive) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveEntry(tw, indexArchiveManifest, manifest); err != nil {
+		return err
+	}
+
+	for _, name := range archive.Files {
+		if name == vectorDBEntry {
+			if err := rs.vectorStore.writeDatabase(tw); err != nil {
+				return err
+			}
+			continue
+		}
+		path, _ := rs.archivePath(name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := writeArchiveEntry(tw, name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeArchiveEntry adds one file to a tar archive
+func writeArchiveEntry(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// writeDatabase adds a consistent copy of the vector database to a tar archive
+func (vs *VectorStore) writeDatabase(tw *tar.Writer) error {
+	db, err := vs.openDB(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		header := &tar.Header{Name: vectorDBEntry, Mode: 0644, Size: tx.Size(), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tx.WriteTo(tw)
+		return err
+	})
+}
+
+// ImportIndex replaces the index with one exported by ExportIndex. The
+// archive is unpacked and checked beside the index directories first, so a
+// damaged or incompatible archive leaves the current index untouched. Each
+// directory in the archive replaces the local one; others are kept.
+func (rs *RAGSystem) ImportIndex(path string) (*IndexArchive, error) {
+	if rs.IsRebuilding() {
+		return nil, fmt.Errorf("the index is being rebuilt; import when the rebuild finishes")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	if err := os.MkdirAll(filepath.Dir(rs.indexDir), 0755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(rs.indexDir), "rag_import")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	archive, err := rs.extractIndexArchive(file, staging)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index archive: %w", err)
+	}
+	if err := rs.checkIndexArchive(archive, staging); err != nil {
+		return nil, err
+	}
+
+	if err := rs.installArchive(archive, staging); err != nil {
+		return nil, err
+	}
+
+	// The imported database replaces everything, including unsaved changes
+	rs.vectorStore.mu.Lock()
+	rs.vectorStore.dirty = make(map[string]bool)
+	rs.vectorStore.mu.Unlock()
+	if err := rs.vectorStore.loadVectorIndex(); err != nil {
+		return nil, err
+	}
+	rs.tldr.LoadCache()
+	if rs.usesPowerShellHelp() {
+		rs.powershell.LoadCache()
+	}
+	rs.initialized = rs.vectorStore.IsInitialized()
+	rs.ClearRetrievalCache()
+
+	return archive, nil
+}
+
+// extractIndexArchive unpacks an archive into a staging directory. Only the
+// manifest and files of the index directories are accepted, so entries
+// cannot escape it.
+func (rs *RAGSystem) extractIndexArchive(r io.Reader, dir string) (*IndexArchive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var archive *IndexArchive
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %s", header.Name)
+		}
+		if header.Size > maxArchiveFileSize {
+			return nil, fmt.Errorf("entry %s is too large", header.Name)
+		}
+
+		if header.Name == indexArchiveManifest {
+			archive = &IndexArchive{}
+			if err := json.NewDecoder(tr).Decode(archive); err != nil {
+				return nil, fmt.Errorf("unreadable manifest: %w", err)
+			}
+			continue
+		}
+		if archive == nil {
+			return nil, fmt.Errorf("archive does not start with %s", indexArchiveManifest)
+		}
+		if _, valid := rs.archivePath(header.Name); !valid || !containsString(archive.Files, header.Name) {
+			return nil, fmt.Errorf("unexpected entry %s", header.Name)
+		}
+
+		staged := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.MkdirAll(filepath.Dir(staged), 0755); err != nil {
+			return nil, err
+		}
+		out, err := os.Create(staged)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(out, io.LimitReader(tr, maxArchiveFileSize))
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if archive == nil {
+		return nil, fmt.Errorf("missing %s", indexArchiveManifest)
+	}
+	for _, name := range archive.Files {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return nil, fmt.Errorf("missing %s", name)
+		}
+	}
+	return archive, nil
+}
+
+// installArchive moves the staged files into place. Files of an archived
+// directory that the archive does not have are removed, so no cache outlives
+// the index it belonged to.
+func (rs *RAGSystem) installArchive(archive *IndexArchive, staging string) error {
+	for dirName, dir := range rs.archiveDirs() {
+		if _, err := os.Stat(filepath.Join(staging, dirName)); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, entry := range entries {
+				if entry.Type().IsRegular() && !containsString(archive.Files, dirName+"/"+entry.Name()) {
+					os.Remove(filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+	}
+
+	for _, name := range archive.Files {
+		target, _ := rs.archivePath(name)
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(name)), target); err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkIndexArchive verifies that an unpacked index can be served by this
+// Helix as it is. An index from another builder would be rebuilt from local
+// MAN pages straight away, which is what importing is meant to avoid.
+func (rs *RAGSystem) checkIndexArchive(archive *IndexArchive, dir string) error {
+	if archive.Format != indexArchiveFormat {
+		return fmt.Errorf("unsupported archive format %q", archive.Format)
+	}
+	if !containsString(archive.Files, vectorDBEntry) {
+		return fmt.Errorf("archive has no vector database")
+	}
+	if archive.Source != rs.vectorStore.pageSource {
+		return fmt.Errorf("archive indexes %s pages, but this host uses %s pages", archive.Source, rs.vectorStore.pageSource)
+	}
+
+	// Archives from before a schema change are upgraded like a local database
+	staged := newVectorStoreAt(filepath.Join(dir, "vector_index"), "")
+	if err := staged.migrateSchema(); err != nil {
+		return fmt.Errorf("cannot migrate vector database: %w", err)
+	}
+	info, err := staged.readIndexInfo()
+	if err != nil {
+		return fmt.Errorf("unreadable vector database: %w", err)
+	}
+	if !info.Matches(currentIndexInfo()) {
+		return fmt.Errorf("archive was built by index builder %s, but this Helix needs %s; export it again with this version",
+			displayBuilder(info.Builder), displayBuilder(currentIndexInfo().Builder))
+	}
+	return nil
+}
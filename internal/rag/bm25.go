@@ -0,0 +1,281 @@
+package rag
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+const boostsFileName = "rag_boosts.json"
+
+// fieldWeights scale term frequencies by where a term occurs, so a match in
+// the command name outranks one in the synopsis, which outranks the description
+var fieldWeights = map[string]float64{
+	"name":        3.0,
+	"synopsis":    2.0,
+	"tldr":        1.5,
+	"command":     1.0,
+	"description": 1.0,
+	"examples":    1.0,
+	"options":     0.8,
+	"chunk":       0.9,
+	"flag":        2.5,
+	"heading":     1.5,
+	"exit_status": 1.0,
+	"files":       1.2,
+	"environment": 1.2,
+}
+
+// posting records a term's weighted frequency in one document
+type posting struct {
+	DocID string
+	TF    float64
+}
+
+// SearchBoost adjusts the scores of specific commands when a query matches.
+// Boost is added to the score, Multiplier (when non-zero) scales it.
+type SearchBoost struct {
+	WhenAll       []string `json:"when_all,omitempty"`       // every word must appear in the query
+	WhenAny       []string `json:"when_any,omitempty"`       // at least one word must appear in the query
+	UnlessAny     []string `json:"unless_any,omitempty"`     // none of these may appear in the query
+	Commands      []string `json:"commands,omitempty"`       // exact command names
+	CommandPrefix string   `json:"command_prefix,omitempty"` // or every command with this prefix
+	Boost         float64  `json:"boost,omitempty"`
+	Multiplier    float64  `json:"multiplier,omitempty"`
+}
+
+// defaultSearchBoosts are written to the boosts file the first time it is missing
+var defaultSearchBoosts = []SearchBoost{
+	{WhenAll: []string{"list", "file"}, Commands: []string{"ls", "find", "dir"}, Boost: 3.0},
+	{WhenAny: []string{"directory"}, Commands: []string{"ls", "pwd", "dir"}, Boost: 2.0},
+	{UnlessAny: []string{"git"}, CommandPrefix: "git-", Multiplier: 0.1},
+	{UnlessAny: []string{"kube"}, CommandPrefix: "kubectl", Multiplier: 0.1},
+	{UnlessAny: []string{"kill", "remove"}, Commands: []string{"killall", "rm"}, Multiplier: 0.1},
+}
+
+// loadSearchBoosts reads the boosts file, creating it with the defaults when missing
+func loadSearchBoosts(path string) []SearchBoost {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if data, err := json.MarshalIndent(defaultSearchBoosts, "", "  "); err == nil {
+			if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+				os.WriteFile(path, data, 0644)
+			}
+		}
+		return defaultSearchBoosts
+	}
+	if err != nil {
+		return defaultSearchBoosts
+	}
+
+	var boosts []SearchBoost
+	if err := json.Unmarshal(data, &boosts); err != nil {
+		color.Yellow("⚠️  Ignoring invalid %s: %v", path, err)
+		return defaultSearchBoosts
+	}
+	return boosts
+}
+
+// ReloadBoosts re-reads the search boosts file
+func (vs *VectorStore) ReloadBoosts() {
+	boosts := loadSearchBoosts(vs.boostsPath)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.boosts = boosts
+	vs.generation++
+}
+
+// matches reports whether a boost applies to the query and command
+func (b SearchBoost) matches(queryLower, command string) bool {
+	for _, word := range b.WhenAll {
+		if !strings.Contains(queryLower, word) {
+			return false
+		}
+	}
+	if len(b.WhenAny) > 0 && !containsAny(queryLower, b.WhenAny) {
+		return false
+	}
+	if containsAny(queryLower, b.UnlessAny) {
+		return false
+	}
+
+	if b.CommandPrefix != "" && strings.HasPrefix(command, b.CommandPrefix) {
+		return true
+	}
+	for _, name := range b.Commands {
+		if command == name {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether text contains any of the words
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameTokens splits a command name into searchable parts, keeping short
+// names such as "ls" and "cp" that the text tokenizer drops
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// documentTerms returns the field-weighted term frequencies of a document
+func (vs *VectorStore) documentTerms(doc VectorDocument) map[string]float64 {
+	terms := make(map[string]float64)
+
+	weight, ok := fieldWeights[doc.Metadata.Section]
+	if !ok {
+		weight = 1.0
+	}
+	for _, word := range vs.tokenize(doc.Content) {
+		terms[word] += weight
+	}
+
+	// The command name is its own, heaviest field on the command document
+	if doc.Metadata.Section == "command" {
+		for _, word := range nameTokens(doc.Metadata.Command) {
+			terms[word] += fieldWeights["name"]
+		}
+	}
+
+	// Chunks are also found by the heading they sit under
+	for _, word := range vs.tokenize(doc.Metadata.ChunkSection) {
+		terms[word] += fieldWeights["heading"]
+	}
+
+	// A chunk documenting a flag outranks one that merely mentions it
+	if doc.Metadata.Section == "chunk" {
+		for _, flag := range doc.Metadata.Options {
+			terms[strings.ToLower(flag)] += fieldWeights["flag"]
+		}
+	}
+
+	return terms
+}
+
+// addToIndex adds a document's postings to the inverted index (caller holds the lock)
+func (vs *VectorStore) addToIndex(doc VectorDocument) {
+	length := 0.0
+	for term, tf := range vs.documentTerms(doc) {
+		vs.index[term] = append(vs.index[term], posting{DocID: doc.ID, TF: tf})
+		length += tf
+	}
+	vs.docLengths[doc.ID] = length
+	vs.totalLength += length
+	vs.generation++
+}
+
+// rebuildIndex regenerates the inverted index from the documents and pages
+// out bodies over the memory budget (caller holds the lock)
+func (vs *VectorStore) rebuildIndex() {
+	vs.index = make(map[string][]posting)
+	vs.docLengths = make(map[string]float64)
+	vs.totalLength = 0
+	vs.generation++
+
+	docs := make([]VectorDocument, 0, len(vs.documents))
+	for _, doc := range vs.documents {
+		docs = append(docs, doc)
+	}
+	// Paged out bodies are read back to be tokenized, then paged out again
+	for _, doc := range vs.fullDocuments(docs) {
+		vs.addToIndex(doc)
+	}
+	vs.pageOut()
+}
+
+// queryTerms tokenizes a query for BM25, including short command-name words
+// that are present in the index
+func (vs *VectorStore) queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+
+	for _, word := range vs.tokenize(query) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	for _, word := range nameTokens(query) {
+		if _, indexed := vs.index[word]; indexed && !seen[word] && !vs.isStopWord(word) {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// bm25Scores scores every document containing a query term. When matched is
+// non-nil it also records which query terms each document matched.
+// (caller holds the lock)
+func (vs *VectorStore) bm25Scores(query string, matched map[string][]string) map[string]float64 {
+	scores := make(map[string]float64)
+
+	totalDocs := float64(len(vs.documents))
+	if totalDocs == 0 {
+		return scores
+	}
+	avgLength := vs.totalLength / totalDocs
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	for _, term := range vs.queryTerms(query) {
+		postings := vs.index[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		docFreq := float64(len(postings))
+		idf := math.Log(1 + (totalDocs-docFreq+0.5)/(docFreq+0.5))
+
+		for _, p := range postings {
+			norm := 1 - bm25B + bm25B*vs.docLengths[p.DocID]/avgLength
+			scores[p.DocID] += idf * p.TF * (bm25K1 + 1) / (p.TF + bm25K1*norm)
+			if matched != nil {
+				matched[p.DocID] = append(matched[p.DocID], term)
+			}
+		}
+	}
+
+	return scores
+}
+
+// applyBoosts adjusts scores with the configured boosts (caller holds the lock)
+func (vs *VectorStore) applyBoosts(query string, scores map[string]float64) {
+	queryLower := strings.ToLower(query)
+
+	for _, boost := range vs.boosts {
+		for docID, doc := range vs.documents {
+			if !boost.matches(queryLower, strings.ToLower(doc.Metadata.Command)) {
+				continue
+			}
+			if boost.Boost != 0 {
+				scores[docID] += boost.Boost
+			}
+			if boost.Multiplier != 0 {
+				scores[docID] *= boost.Multiplier
+			}
+		}
+	}
+}
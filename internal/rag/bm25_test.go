@@ -0,0 +1,79 @@
+package rag
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore creates a vector store kept in a temporary directory
+func newTestStore(t *testing.T, dir string) *VectorStore {
+	t.Helper()
+	return newVectorStoreAt(filepath.Join(dir, "vector_index"), filepath.Join(dir, boostsFileName))
+}
+
+// addTestDocuments puts documents in the store and indexes them
+func addTestDocuments(vs *VectorStore, docs ...VectorDocument) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	for _, doc := range docs {
+		vs.putDocument(doc)
+	}
+	vs.rebuildIndex()
+	vs.initialized = len(vs.documents) > 0
+}
+
+// testDocument builds a document of one section of a command
+func testDocument(command, section, content string) VectorDocument {
+	return VectorDocument{
+		ID:       command + "-" + section,
+		Content:  content,
+		Metadata: Metadata{Command: command, Section: section},
+	}
+}
+
+func TestBM25ScoringOrder(t *testing.T) {
+	vs := newTestStore(t, t.TempDir())
+	addTestDocuments(vs,
+		testDocument("alpha", "synopsis", "compress archive"),
+		testDocument("beta", "description", "compress archive"),
+		testDocument("gamma", "description", "compress archive together with many unrelated words describing terminals, colours, printers and networks"),
+		testDocument("delta", "description", "list directory contents"),
+	)
+
+	scores := vs.bm25Scores("compress archive", nil)
+	// A heavier field outranks a lighter one, and a short document a long one
+	order := []string{"alpha-synopsis", "beta-description", "gamma-description"}
+	for i := 1; i < len(order); i++ {
+		if scores[order[i-1]] <= scores[order[i]] {
+			t.Fatalf("%s scored %.3f, not above %s with %.3f",
+				order[i-1], scores[order[i-1]], order[i], scores[order[i]])
+		}
+	}
+	if _, scored := scores["delta-description"]; scored {
+		t.Fatal("a document without any query term was scored")
+	}
+
+	// A rare term counts for more than one most documents contain
+	scores = vs.bm25Scores("compress directory", nil)
+	if scores["delta-description"] <= scores["beta-description"] {
+		t.Fatalf("the only document with the rare term scored %.3f, not above %.3f",
+			scores["delta-description"], scores["beta-description"])
+	}
+}
+
+func TestBM25MatchesShortCommandNames(t *testing.T) {
+	vs := newTestStore(t, t.TempDir())
+	addTestDocuments(vs,
+		testDocument("ls", "command", "list directory contents"),
+		testDocument("find", "command", "search for files in a directory hierarchy"),
+	)
+
+	matched := make(map[string][]string)
+	scores := vs.bm25Scores("ls", matched)
+	if len(scores) != 1 || scores["ls-command"] == 0 {
+		t.Fatalf("bm25Scores(ls) = %v, want only ls-command", scores)
+	}
+	if len(matched["ls-command"]) != 1 || matched["ls-command"][0] != "ls" {
+		t.Fatalf("ls-command matched %v, want [ls]", matched["ls-command"])
+	}
+}
@@ -0,0 +1,282 @@
+package rag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	vectorDBFileName      = "vector_index.db"
+	legacyIndexFileName   = "vector_index.json"
+	vectorDBOpenTimeout   = 2 * time.Second
+	vectorDBSchemaVersion = "2" // 2 added per-document checksums
+)
+
+var (
+	documentsBucket = []byte("documents")
+	checksumsBucket = []byte("checksums") // document ID -> SHA-256 of its stored JSON
+	metaBucket      = []byte("meta")
+	schemaKey       = []byte("schema")
+)
+
+// dbPath returns the location of the bbolt database
+func (vs *VectorStore) dbPath() string {
+	return filepath.Join(vs.indexDir, vectorDBFileName)
+}
+
+// openDB opens the database for a single operation. It is not kept open so
+// several Helix processes (e.g. the REPL and a batch run) can share the index.
+func (vs *VectorStore) openDB(readOnly bool) (*bolt.DB, error) {
+	if readOnly {
+		if _, err := os.Stat(vs.dbPath()); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(vs.dbPath(), 0644, &bolt.Options{Timeout: vectorDBOpenTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vector database: %w", err)
+	}
+	return db, nil
+}
+
+// markDirty records a document ID whose stored copy is out of date
+// (caller holds the lock)
+func (vs *VectorStore) markDirty(docID string) {
+	vs.dirty[docID] = true
+}
+
+// putDocument stores a document in memory and schedules it for writing
+// (caller holds the lock)
+func (vs *VectorStore) putDocument(doc VectorDocument) {
+	doc.paged = false
+	vs.documents[doc.ID] = doc
+	vs.pager.forget(doc.ID)
+	vs.markDirty(doc.ID)
+}
+
+// deleteDocument removes a document from memory and schedules its deletion
+// (caller holds the lock)
+func (vs *VectorStore) deleteDocument(docID string) {
+	delete(vs.documents, docID)
+	vs.pager.forget(docID)
+	vs.markDirty(docID)
+}
+
+// writeDirtyDocuments writes only the documents changed since the last save
+// in one transaction, so an interrupted save leaves the previous index intact
+func (vs *VectorStore) writeDirtyDocuments() (int, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if len(vs.dirty) == 0 {
+		return 0, nil
+	}
+
+	db, err := vs.openDB(false)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		// A new database is stamped with the current builder; existing ones keep theirs
+		if meta.Get(builderKey) == nil {
+			info := currentIndexInfo()
+			if err := meta.Put(schemaKey, []byte(info.Schema)); err != nil {
+				return err
+			}
+			if err := meta.Put(builderKey, []byte(info.Builder)); err != nil {
+				return err
+			}
+			vs.info = info
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists(documentsBucket)
+		if err != nil {
+			return err
+		}
+		checksums, err := tx.CreateBucketIfNotExists(checksumsBucket)
+		if err != nil {
+			return err
+		}
+
+		for docID := range vs.dirty {
+			doc, exists := vs.documents[docID]
+			if !exists {
+				if err := bucket.Delete([]byte(docID)); err != nil {
+					return err
+				}
+				if err := checksums.Delete([]byte(docID)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			data, err := json.Marshal(doc)
+			if err != nil {
+				return fmt.Errorf("failed to marshal document %s: %w", docID, err)
+			}
+			if err := bucket.Put([]byte(docID), data); err != nil {
+				return err
+			}
+			if err := checksums.Put([]byte(docID), documentChecksum(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write vector database: %w", err)
+	}
+
+	written := len(vs.dirty)
+	vs.dirty = make(map[string]bool)
+	vs.pageOut()
+	return written, nil
+}
+
+// readDocuments loads documents from the database. When prefix is set only
+// documents whose ID starts with it are read, using the B+tree cursor rather
+// than scanning the whole index. Documents that are unreadable or fail their
+// checksum are skipped and their IDs returned, so they can be rebuilt.
+func (vs *VectorStore) readDocuments(prefix string) (map[string]VectorDocument, []string, error) {
+	db, err := vs.openDB(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	documents := make(map[string]VectorDocument)
+	var corrupted []string
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(documentsBucket)
+		if bucket == nil {
+			return nil
+		}
+		checksums := tx.Bucket(checksumsBucket)
+
+		cursor := bucket.Cursor()
+		for key, value := cursor.Seek([]byte(prefix)); key != nil; key, value = cursor.Next() {
+			if !bytes.HasPrefix(key, []byte(prefix)) {
+				break
+			}
+
+			doc, err := decodeDocument(key, value, checksums)
+			if err != nil {
+				// One bad record must not take down the whole index
+				color.Yellow("⚠️  Skipping corrupted vector document %s: %v", key, err)
+				corrupted = append(corrupted, string(key))
+				continue
+			}
+			documents[doc.ID] = doc
+		}
+		return nil
+	})
+	return documents, corrupted, err
+}
+
+// readDocumentsByID reads the given documents from the database in one
+// transaction, for bodies paged out of memory. Missing or damaged documents
+// are left out.
+func (vs *VectorStore) readDocumentsByID(ids []string) (map[string]VectorDocument, error) {
+	db, err := vs.openDB(true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	documents := make(map[string]VectorDocument)
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(documentsBucket)
+		if bucket == nil {
+			return nil
+		}
+		checksums := tx.Bucket(checksumsBucket)
+
+		for _, id := range ids {
+			value := bucket.Get([]byte(id))
+			if value == nil {
+				continue
+			}
+			if doc, err := decodeDocument([]byte(id), value, checksums); err == nil {
+				documents[doc.ID] = doc
+			}
+		}
+		return nil
+	})
+	return documents, err
+}
+
+// loadCommandDocuments reads just one command's documents from disk, for
+// lookups before the full index has been loaded
+func (vs *VectorStore) loadCommandDocuments(command string) []VectorDocument {
+	documents, _, err := vs.readDocuments(command + "-")
+	if err != nil {
+		return nil
+	}
+
+	var result []VectorDocument
+	for _, doc := range documents {
+		// The prefix also matches longer names such as "git-log" for "git"
+		if doc.Metadata.Command == command {
+			result = append(result, doc)
+		}
+	}
+	return result
+}
+
+// migrateLegacyIndex imports the old vector_index.json into the database once
+// and moves the JSON file aside
+func (vs *VectorStore) migrateLegacyIndex() error {
+	legacyFile := filepath.Join(vs.indexDir, legacyIndexFileName)
+	data, err := os.ReadFile(legacyFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read legacy index: %w", err)
+	}
+
+	var documents map[string]VectorDocument
+	if err := json.Unmarshal(data, &documents); err != nil {
+		// A truncated write keeps every document before the cut; the
+		// commands after it are rebuilt once the index is loaded
+		documents = salvageLegacyDocuments(data)
+		if len(documents) == 0 {
+			return fmt.Errorf("failed to parse legacy index: %w", err)
+		}
+		color.Yellow("⚠️  %s is damaged (%v); recovered %d documents", legacyIndexFileName, err, len(documents))
+	}
+
+	color.Blue("📦 Migrating %d documents from %s to %s...", len(documents), legacyIndexFileName, vectorDBFileName)
+
+	vs.mu.Lock()
+	for _, doc := range documents {
+		vs.putDocument(doc)
+	}
+	vs.mu.Unlock()
+
+	if _, err := vs.writeDirtyDocuments(); err != nil {
+		return err
+	}
+
+	// JSON indexes predate builder fingerprints, so they are always rebuilt
+	if err := vs.writeIndexInfo(IndexInfo{Schema: vectorDBSchemaVersion, Builder: legacyBuilder}); err != nil {
+		return err
+	}
+
+	return os.Rename(legacyFile, legacyFile+".migrated")
+}
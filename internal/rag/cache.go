@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxCachedQueries bounds the retrieval cache; it is cleared when full
+const maxCachedQueries = 128
+
+// manualResults are the MAN/tldr commands found for a query, before project
+// and history results are added
+type manualResults struct {
+	exact    []CommandInfo
+	relevant []CommandInfo
+}
+
+// retrievalCache remembers manual results per normalized query for the
+// session. Entries belong to one index generation, so any reindex drops them.
+type retrievalCache struct {
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string]manualResults
+}
+
+// normalizeQuery makes queries that differ only in case, spacing or trailing
+// punctuation share a cache entry
+func normalizeQuery(query string) string {
+	return strings.Trim(strings.Join(strings.Fields(strings.ToLower(query)), " "), ".?!")
+}
+
+// get returns a copy of the cached results for a query
+func (c *retrievalCache) get(key string, generation uint64) (manualResults, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return manualResults{}, false
+	}
+	results, ok := c.entries[key]
+	if !ok {
+		return manualResults{}, false
+	}
+	return manualResults{exact: cloneCommands(results.exact), relevant: cloneCommands(results.relevant)}, true
+}
+
+// put stores a copy of the results for a query
+func (c *retrievalCache) put(key string, generation uint64, results manualResults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation || c.entries == nil || len(c.entries) >= maxCachedQueries {
+		c.generation = generation
+		c.entries = make(map[string]manualResults)
+	}
+	c.entries[key] = manualResults{exact: cloneCommands(results.exact), relevant: cloneCommands(results.relevant)}
+}
+
+// clear drops every cached result
+func (c *retrievalCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+// cloneCommands copies commands so callers cannot modify cached slices
+func cloneCommands(commands []CommandInfo) []CommandInfo {
+	if commands == nil {
+		return nil
+	}
+	cloned := make([]CommandInfo, len(commands))
+	for i, cmd := range commands {
+		cmd.Options = append([]string(nil), cmd.Options...)
+		cmd.Examples = append([]string(nil), cmd.Examples...)
+		cmd.Passages = append([]string(nil), cmd.Passages...)
+		cmd.Documents = append([]string(nil), cmd.Documents...)
+		cmd.ExitStatus = append([]string(nil), cmd.ExitStatus...)
+		cmd.Files = append([]string(nil), cmd.Files...)
+		cmd.Environment = append([]string(nil), cmd.Environment...)
+		cloned[i] = cmd
+	}
+	return cloned
+}
+
+// ClearRetrievalCache forgets cached retrieval results and installed-tool lookups
+func (rs *RAGSystem) ClearRetrievalCache() {
+	rs.cache.clear()
+	rs.tools.clear()
+}
+
+// Generation returns a counter that changes whenever the index changes
+func (vs *VectorStore) Generation() uint64 {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return vs.generation
+}
@@ -0,0 +1,171 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Chunk sizes are in words. Consecutive chunks share chunkOverlapWords words
+// so a flag described across a chunk boundary is still found whole in one chunk.
+const (
+	chunkMaxWords        = 120
+	chunkOverlapWords    = 25
+	maxChunksPerSection  = 40
+	maxPassagesPerResult = 2
+)
+
+// chunkedSections are the long MAN sections split into retrievable chunks.
+// find documents its tests and actions (e.g. -exec) under EXPRESSION.
+var chunkedSections = map[string]bool{
+	"DESCRIPTION": true,
+	"OPTIONS":     true,
+	"EXPRESSION":  true,
+}
+
+// flagPattern matches the flags an option entry starts with, e.g. "-a, --all"
+var flagPattern = regexp.MustCompile(`^(?:[-]{1,2}[A-Za-z0-9][\w-]*[,\s]*)+`)
+
+// flagNamePattern extracts each flag name from an entry header
+var flagNamePattern = regexp.MustCompile(`[-]{1,2}[A-Za-z0-9][\w-]*`)
+
+// SectionChunk is one overlapping piece of a long MAN page section
+type SectionChunk struct {
+	Section string   `json:"section"`
+	Index   int      `json:"index"`
+	Text    string   `json:"text"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// paragraph is a blank-line separated block of section text
+type paragraph struct {
+	words []string
+	flags []string
+}
+
+// splitParagraphs splits section content on blank lines
+func splitParagraphs(content string) []paragraph {
+	var paragraphs []paragraph
+	var current []string
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		text := strings.Join(current, " ")
+		paragraphs = append(paragraphs, paragraph{
+			words: strings.Fields(text),
+			flags: flagNamePattern.FindAllString(flagPattern.FindString(current[0]), -1),
+		})
+		current = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return paragraphs
+}
+
+// chunkSection splits a section into chunks of whole paragraphs where possible.
+// Paragraphs longer than a chunk are split by words. Each chunk after the
+// first starts with the last words of the previous one.
+func chunkSection(section, content string) []SectionChunk {
+	var chunks []SectionChunk
+	var words []string
+	var flags []string
+	fresh := 0 // words in the current chunk that are not overlap
+
+	emit := func() {
+		if fresh == 0 {
+			return
+		}
+		chunks = append(chunks, SectionChunk{
+			Section: section,
+			Index:   len(chunks),
+			Text:    strings.Join(words, " "),
+			Flags:   flags,
+		})
+
+		overlap := chunkOverlapWords
+		if overlap > len(words) {
+			overlap = len(words)
+		}
+		words = append([]string(nil), words[len(words)-overlap:]...)
+		flags = nil
+		fresh = 0
+	}
+
+	for _, para := range splitParagraphs(content) {
+		if fresh > 0 && len(words)+len(para.words) > chunkMaxWords {
+			emit()
+		}
+		for _, flag := range para.flags {
+			if !containsString(flags, flag) {
+				flags = append(flags, flag)
+			}
+		}
+
+		for _, word := range para.words {
+			if len(words) >= chunkMaxWords {
+				emit()
+			}
+			words = append(words, word)
+			fresh++
+		}
+
+		if len(chunks) >= maxChunksPerSection {
+			break
+		}
+	}
+	emit()
+
+	if len(chunks) > maxChunksPerSection {
+		chunks = chunks[:maxChunksPerSection]
+	}
+	return chunks
+}
+
+// createChunkDocuments turns a page's section chunks into vector documents
+func (vs *VectorStore) createChunkDocuments(page MANPage) []VectorDocument {
+	var documents []VectorDocument
+	for _, chunk := range page.Chunks {
+		documents = append(documents, VectorDocument{
+			ID:      fmt.Sprintf("%s-chunk-%s-%d", page.Name, strings.ToLower(chunk.Section), chunk.Index),
+			Content: chunk.Text,
+			Metadata: Metadata{
+				Command:      page.Name,
+				Section:      "chunk",
+				Options:      chunk.Flags,
+				Language:     page.Language,
+				ChunkSection: chunk.Section,
+				ChunkIndex:   chunk.Index,
+			},
+		})
+	}
+	return documents
+}
+
+// formatPassage labels a chunk or reference section with the section it came from
+func formatPassage(doc VectorDocument) string {
+	if header, ok := referenceHeaders[doc.Metadata.Section]; ok {
+		return fmt.Sprintf("[%s] %s", header, strings.Join(doc.Metadata.Entries, " | "))
+	}
+	return fmt.Sprintf("[%s] %s", doc.Metadata.ChunkSection, doc.Content)
+}
+
+// containsString reports whether a slice contains a string
+func containsString(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package rag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testParagraph returns a paragraph of n words documenting flag
+func testParagraph(flag string, n int) string {
+	words := []string{flag}
+	for i := 1; i < n; i++ {
+		words = append(words, fmt.Sprintf("%s_w%d", strings.TrimLeft(flag, "-"), i))
+	}
+	return strings.Join(words, " ")
+}
+
+// checkOverlap fails when a chunk does not start with the last words of the previous one
+func checkOverlap(t *testing.T, chunks []SectionChunk) {
+	t.Helper()
+	for i, chunk := range chunks {
+		words := strings.Fields(chunk.Text)
+		if len(words) > chunkMaxWords {
+			t.Fatalf("chunk %d has %d words, more than %d", i, len(words), chunkMaxWords)
+		}
+		if chunk.Index != i {
+			t.Fatalf("chunk %d has index %d", i, chunk.Index)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := strings.Fields(chunks[i-1].Text)
+		want := previous[len(previous)-chunkOverlapWords:]
+		if !reflect.DeepEqual(words[:chunkOverlapWords], want) {
+			t.Fatalf("chunk %d starts with %v, want the last words of chunk %d %v", i, words[:chunkOverlapWords], i-1, want)
+		}
+	}
+}
+
+func TestChunkSectionKeepsParagraphsWhole(t *testing.T) {
+	var paragraphs []string
+	for _, flag := range []string{"-a", "-b", "-c", "-d"} {
+		paragraphs = append(paragraphs, testParagraph(flag, 50))
+	}
+	chunks := chunkSection("OPTIONS", strings.Join(paragraphs, "\n\n"))
+	checkOverlap(t, chunks)
+
+	// -a and -b fit one chunk; -c and -d each start a new one
+	wantFlags := [][]string{{"-a", "-b"}, {"-c"}, {"-d"}}
+	if len(chunks) != len(wantFlags) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(wantFlags))
+	}
+	for i, chunk := range chunks {
+		if !reflect.DeepEqual(chunk.Flags, wantFlags[i]) {
+			t.Fatalf("chunk %d documents %v, want %v", i, chunk.Flags, wantFlags[i])
+		}
+		if i > 0 && !strings.Contains(chunk.Text, " "+wantFlags[i][0]+" ") {
+			t.Fatalf("chunk %d does not hold paragraph %s from its start", i, wantFlags[i][0])
+		}
+		if chunk.Section != "OPTIONS" {
+			t.Fatalf("chunk %d is from section %q", i, chunk.Section)
+		}
+	}
+}
+
+func TestChunkSectionSplitsLongParagraphs(t *testing.T) {
+	content := testParagraph("--long", 300)
+	chunks := chunkSection("DESCRIPTION", content)
+	checkOverlap(t, chunks)
+	if len(chunks) < 3 {
+		t.Fatalf("a 300 word paragraph gave %d chunks", len(chunks))
+	}
+
+	// Every word is in some chunk, in order
+	var words []string
+	for i, chunk := range chunks {
+		chunkWords := strings.Fields(chunk.Text)
+		if i > 0 {
+			chunkWords = chunkWords[chunkOverlapWords:]
+		}
+		words = append(words, chunkWords...)
+	}
+	if got := strings.Join(words, " "); got != content {
+		t.Fatalf("the chunks without their overlap are\n%s\nwant\n%s", got, content)
+	}
+}
+
+func TestChunkSectionLimits(t *testing.T) {
+	if chunks := chunkSection("DESCRIPTION", " \n\n \n"); len(chunks) != 0 {
+		t.Fatalf("an empty section gave %d chunks", len(chunks))
+	}
+
+	chunks := chunkSection("DESCRIPTION", testParagraph("-x", 20))
+	if len(chunks) != 1 || len(strings.Fields(chunks[0].Text)) != 20 {
+		t.Fatalf("a short section gave %+v, want one chunk of 20 words", chunks)
+	}
+
+	huge := testParagraph("--huge", chunkMaxWords*maxChunksPerSection*2)
+	if chunks := chunkSection("DESCRIPTION", huge); len(chunks) != maxChunksPerSection {
+		t.Fatalf("a huge section gave %d chunks, want at most %d", len(chunks), maxChunksPerSection)
+	}
+}
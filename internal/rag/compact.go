@@ -0,0 +1,281 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	bolt "go.etcd.io/bbolt"
+)
+
+// compactTxMaxSize is how many bytes bolt.Compact copies per transaction
+const compactTxMaxSize = 4 * 1024 * 1024
+
+// CompactionSummary reports what a compaction removed and reclaimed
+type CompactionSummary struct {
+	OrphanCommands []string      `json:"orphan_commands"`
+	OrphanDocs     int           `json:"orphan_docs"`
+	MergedDocs     int           `json:"merged_docs"`
+	EmptyDocs      int           `json:"empty_docs"`
+	SizeBefore     int64         `json:"size_before"`
+	SizeAfter      int64         `json:"size_after"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// Reclaimed returns how many bytes the database file shrank by
+func (s *CompactionSummary) Reclaimed() int64 {
+	if s.SizeAfter > s.SizeBefore {
+		return 0
+	}
+	return s.SizeBefore - s.SizeAfter
+}
+
+// HasChanges reports whether compaction removed or merged any documents
+func (s *CompactionSummary) HasChanges() bool {
+	return s.OrphanDocs > 0 || s.MergedDocs > 0 || s.EmptyDocs > 0
+}
+
+// Compact removes documents of commands that are no longer installed, merges
+// section documents split across several IDs, and rewrites the database file
+// so the space of deleted documents is returned to the filesystem
+func (rs *RAGSystem) Compact() (*CompactionSummary, error) {
+	startTime := time.Now()
+
+	if !rs.vectorStore.IsInitialized() {
+		if err := rs.vectorStore.loadVectorIndex(); err != nil {
+			return nil, err
+		}
+	}
+	if rs.vectorStore.DocumentCount() == 0 {
+		return nil, fmt.Errorf("vector index is empty")
+	}
+
+	summary := &CompactionSummary{}
+	summary.SizeBefore = fileSize(rs.vectorStore.dbPath())
+
+	installed := rs.installedChecker()
+	summary.OrphanCommands, summary.OrphanDocs = rs.vectorStore.removeOrphans(installed)
+	summary.MergedDocs, summary.EmptyDocs = rs.vectorStore.mergeFragments()
+
+	if len(summary.OrphanCommands) > 0 {
+		rs.indexer.RemovePages(summary.OrphanCommands)
+		manifest := rs.loadManifest()
+		for _, command := range summary.OrphanCommands {
+			delete(manifest.Pages, command)
+		}
+		if len(manifest.Pages) > 0 {
+			if err := rs.saveManifest(manifest); err != nil {
+				color.Yellow("⚠️  Could not save index manifest: %v", err)
+			}
+		}
+	}
+
+	if summary.HasChanges() {
+		if _, err := rs.vectorStore.writeDirtyDocuments(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rs.vectorStore.compactFile(); err != nil {
+		return nil, err
+	}
+	summary.SizeAfter = fileSize(rs.vectorStore.dbPath())
+
+	rs.initialized = rs.vectorStore.IsInitialized()
+	summary.Duration = time.Since(startTime)
+	return summary, nil
+}
+
+// installedChecker returns a function reporting whether a command still has a
+// binary, MAN page or PowerShell help. The MAN path is scanned once up front.
+func (rs *RAGSystem) installedChecker() func(string) bool {
+	if rs.usesPowerShellHelp() {
+		return func(command string) bool {
+			if _, ok := rs.powershell.GetPage(command); ok {
+				return true
+			}
+			_, err := exec.LookPath(command)
+			return err == nil
+		}
+	}
+
+	manFiles := rs.indexer.discoverManFiles()
+	return func(command string) bool {
+		if _, ok := manFiles[command]; ok {
+			return true
+		}
+		if _, err := exec.LookPath(command); err == nil {
+			return true
+		}
+		return lookupManPath(command) != ""
+	}
+}
+
+// canonicalDocumentID returns the ID a document of this command and section is stored under
+func canonicalDocumentID(meta Metadata) string {
+	if meta.Section == "chunk" {
+		return fmt.Sprintf("%s-chunk-%s-%d", meta.Command, strings.ToLower(meta.ChunkSection), meta.ChunkIndex)
+	}
+	return fmt.Sprintf("%s-%s", meta.Command, meta.Section)
+}
+
+// removeOrphans deletes the documents of commands that are no longer installed
+func (vs *VectorStore) removeOrphans(installed func(string) bool) ([]string, int) {
+	vs.mu.RLock()
+	commands := make(map[string]bool)
+	for _, doc := range vs.documents {
+		commands[doc.Metadata.Command] = true
+	}
+	vs.mu.RUnlock()
+
+	// Checking may run man, so it happens without holding the lock
+	var orphans []string
+	for command := range commands {
+		if command != "" && !installed(command) {
+			orphans = append(orphans, command)
+		}
+	}
+	sort.Strings(orphans)
+
+	if len(orphans) == 0 {
+		return nil, 0
+	}
+	// A broken PATH or MAN setup would make everything look uninstalled
+	if len(orphans) > len(commands)/2 {
+		color.Yellow("⚠️  %d of %d commands look uninstalled; skipping orphan cleanup", len(orphans), len(commands))
+		return nil, 0
+	}
+	return orphans, vs.RemoveCommands(orphans)
+}
+
+// mergeFragments folds documents that describe the same command section under
+// different IDs (left behind by older index layouts) into one document, and
+// drops documents without content. It returns the merged and empty counts.
+func (vs *VectorStore) mergeFragments() (int, int) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	all := make([]VectorDocument, 0, len(vs.documents))
+	for _, doc := range vs.documents {
+		all = append(all, doc)
+	}
+
+	empty := 0
+	groups := make(map[string][]VectorDocument)
+	for _, doc := range vs.fullDocuments(all) {
+		docID := doc.ID
+		if doc.paged {
+			continue // body unreadable; VerifyIndex reports it
+		}
+		if strings.TrimSpace(doc.Content) == "" {
+			vs.deleteDocument(docID)
+			empty++
+			continue
+		}
+		canonical := canonicalDocumentID(doc.Metadata)
+		groups[canonical] = append(groups[canonical], doc)
+	}
+
+	merged := 0
+	for canonical, docs := range groups {
+		if len(docs) == 1 && docs[0].ID == canonical {
+			continue
+		}
+
+		// Prefer the canonical document, then the one with the most content
+		sort.Slice(docs, func(i, j int) bool {
+			if (docs[i].ID == canonical) != (docs[j].ID == canonical) {
+				return docs[i].ID == canonical
+			}
+			return len(docs[i].Content) > len(docs[j].Content)
+		})
+
+		result := docs[0]
+		for _, doc := range docs[1:] {
+			result.Metadata.Options = appendUnique(result.Metadata.Options, doc.Metadata.Options)
+			result.Metadata.OptionDetails = mergeOptions(result.Metadata.OptionDetails, doc.Metadata.OptionDetails)
+			result.Metadata.Examples = appendUnique(result.Metadata.Examples, doc.Metadata.Examples)
+			result.Metadata.Entries = appendUnique(result.Metadata.Entries, doc.Metadata.Entries)
+			if result.Metadata.Description == "" {
+				result.Metadata.Description = doc.Metadata.Description
+			}
+		}
+
+		for _, doc := range docs {
+			if doc.ID != canonical {
+				vs.deleteDocument(doc.ID)
+			}
+		}
+		result.ID = canonical
+		vs.putDocument(result)
+		merged += len(docs) - 1
+		if docs[0].ID != canonical {
+			merged++ // the survivor itself moved to the canonical ID
+		}
+	}
+
+	if merged > 0 || empty > 0 {
+		vs.rebuildIndex()
+		vs.initialized = len(vs.documents) > 0
+	}
+	return merged, empty
+}
+
+// appendUnique appends the items not already present
+func appendUnique(items, extra []string) []string {
+	for _, item := range extra {
+		if !containsString(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// compactFile rewrites the database into a fresh file. bbolt reuses freed
+// pages but never shrinks the file, so deleted documents keep their space
+// until the database is copied.
+func (vs *VectorStore) compactFile() error {
+	src, err := vs.openDB(true)
+	if err != nil {
+		return err
+	}
+
+	stagingPath := vs.dbPath() + ".compact"
+	os.Remove(stagingPath)
+	dst, err := bolt.Open(stagingPath, 0644, &bolt.Options{Timeout: vectorDBOpenTimeout})
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to create compacted database: %w", err)
+	}
+
+	err = bolt.Compact(dst, src, compactTxMaxSize)
+	src.Close()
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(stagingPath)
+		return fmt.Errorf("failed to compact vector database: %w", err)
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if err := os.Rename(stagingPath, vs.dbPath()); err != nil {
+		os.Remove(stagingPath)
+		return fmt.Errorf("failed to activate compacted database: %w", err)
+	}
+	return nil
+}
+
+// fileSize returns the size of a file, or 0 if it cannot be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
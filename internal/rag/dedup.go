@@ -0,0 +1,135 @@
+package rag
+
+import (
+	"strings"
+	"unicode"
+)
+
+// chunkOverlapThreshold is the share of the smaller chunk's words that must
+// also appear in another chunk for the two to count as duplicates
+const chunkOverlapThreshold = 0.85
+
+// minChunkWords is the size below which chunks must match exactly to be duplicates
+const minChunkWords = 3
+
+// chunkDeduper remembers context chunks already placed in a prompt
+type chunkDeduper struct {
+	seen       []map[string]bool
+	suppressed int
+}
+
+// chunkWords returns the set of lowercase words in a chunk
+func chunkWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// overlaps reports whether two word sets say essentially the same thing
+func overlaps(a, b map[string]bool) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+
+	smaller, larger := a, b
+	if len(b) < len(a) {
+		smaller, larger = b, a
+	}
+
+	shared := 0
+	for word := range smaller {
+		if larger[word] {
+			shared++
+		}
+	}
+
+	if len(smaller) < minChunkWords {
+		return shared == len(smaller) && len(smaller) == len(larger)
+	}
+	return float64(shared)/float64(len(smaller)) >= chunkOverlapThreshold
+}
+
+// keep reports whether a chunk adds new information, remembering it if so
+func (d *chunkDeduper) keep(text string) bool {
+	words := chunkWords(text)
+	if len(words) == 0 {
+		return false
+	}
+
+	for _, seen := range d.seen {
+		if overlaps(words, seen) {
+			d.suppressed++
+			return false
+		}
+	}
+
+	d.seen = append(d.seen, words)
+	return true
+}
+
+// keepAll filters a list of chunks down to those that add new information
+func (d *chunkDeduper) keepAll(chunks []string) []string {
+	var kept []string
+	for _, chunk := range chunks {
+		if d.keep(chunk) {
+			kept = append(kept, chunk)
+		}
+	}
+	return kept
+}
+
+// dedupeContext collapses repeated sections of the same command into one
+// block and drops chunks that repeat content already in the context.
+// It returns the grouped commands and how many chunks were suppressed.
+func dedupeContext(commands []CommandInfo) ([]CommandInfo, int) {
+	// Group sections of the same command
+	var order []string
+	grouped := make(map[string]*CommandInfo)
+	for _, cmd := range commands {
+		existing, ok := grouped[cmd.Name]
+		if !ok {
+			copied := cmd
+			grouped[cmd.Name] = &copied
+			order = append(order, cmd.Name)
+			continue
+		}
+		if existing.Description == "" {
+			existing.Description = cmd.Description
+		}
+		if existing.Synopsis == "" {
+			existing.Synopsis = cmd.Synopsis
+		}
+		existing.Options = append(existing.Options, cmd.Options...)
+		existing.Examples = append(existing.Examples, cmd.Examples...)
+		existing.Passages = append(existing.Passages, cmd.Passages...)
+		existing.Documents = appendUnique(existing.Documents, cmd.Documents)
+	}
+
+	deduper := &chunkDeduper{}
+	var result []CommandInfo
+	for _, name := range order {
+		cmd := *grouped[name]
+
+		if cmd.Description != "" && !deduper.keep(cmd.Description) {
+			cmd.Description = ""
+		}
+		if cmd.Synopsis != "" && !deduper.keep(cmd.Synopsis) {
+			cmd.Synopsis = ""
+		}
+		cmd.Options = deduper.keepAll(cmd.Options)
+		cmd.Examples = deduper.keepAll(cmd.Examples)
+		cmd.Passages = deduper.keepAll(cmd.Passages)
+
+		// A command whose every section repeats earlier context adds nothing
+		if cmd.Description == "" && cmd.Synopsis == "" && len(cmd.Options) == 0 && len(cmd.Examples) == 0 && len(cmd.Passages) == 0 {
+			continue
+		}
+		result = append(result, cmd)
+	}
+
+	return result, deduper.suppressed
+}
@@ -0,0 +1,292 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Limits for answers taken straight from documentation
+const (
+	docAnswerMinCoverage = 0.5 // share of the question's terms the excerpt must contain
+	maxExcerptWords      = 90
+	shortSentenceWords   = 15
+)
+
+// docQuestionPattern matches questions about what documentation says, as
+// opposed to requests for a command ("how do I ...")
+var docQuestionPattern = regexp.MustCompile(`(?i)^\s*(what|which|where|when|does|is|are|can|how (does|is|are))\b`)
+
+// numberPattern finds numbers in a question, such as an exit code
+var numberPattern = regexp.MustCompile(`\b\d+\b`)
+
+// questionWords are common in documentation questions but never in the answer
+var questionWords = []string{"mean", "meaning", "option", "flag", "command", "explain", "tell", "use"}
+
+// DocAnswer is an answer span extracted from a command's documentation
+type DocAnswer struct {
+	Question string  `json:"question"`
+	Command  string  `json:"command"`
+	Section  string  `json:"section"` // MAN page section of the excerpt, e.g. "OPTIONS"
+	Source   string  `json:"source"`  // where to read more, e.g. "man rsync"
+	Excerpt  string  `json:"excerpt"`
+	Coverage float64 `json:"coverage"` // share of the question's terms found in the excerpt
+}
+
+// Citation names the page and section the answer came from
+func (a *DocAnswer) Citation() string {
+	return fmt.Sprintf("%s (%s)", a.Source, a.Section)
+}
+
+// AnswerFromDocs answers a question about a command from its indexed
+// documentation alone. It returns false when the question is not about a
+// documented command or no excerpt covers it well enough.
+func (rs *RAGSystem) AnswerFromDocs(question string) (*DocAnswer, bool) {
+	if !rs.initialized {
+		return nil, false
+	}
+
+	flags := questionFlags(question)
+	if len(flags) == 0 && !docQuestionPattern.MatchString(question) {
+		return nil, false
+	}
+
+	command := rs.documentedCommand(question)
+	if command == "" {
+		return nil, false
+	}
+
+	answer := &DocAnswer{Question: question, Command: command, Source: "man " + command}
+	if rs.usesPowerShellHelp() {
+		answer.Source = "Get-Help " + command
+	}
+
+	// "what does rsync -a do" is answered by the option's own entry
+	if len(flags) > 0 {
+		if page, ok := rs.documentationPage(command); ok {
+			if section, entry := findOptionEntry(page, flags); entry != "" {
+				answer.Section = section
+				answer.Excerpt = limitWords(entry, maxExcerptWords)
+				answer.Coverage = 1
+				return answer, true
+			}
+		}
+	}
+
+	terms := rs.questionTerms(question, command)
+	if len(terms) == 0 {
+		// "what is rsync" is answered by the NAME line
+		info, err := rs.vectorStore.GetCommandInfo(command)
+		if err != nil || info.Description == "" {
+			return nil, false
+		}
+		answer.Section = "NAME"
+		answer.Excerpt = info.Description
+		answer.Coverage = 1
+		return answer, true
+	}
+
+	section, excerpt, coverage := rs.bestExcerpt(command, terms, numberPattern.FindAllString(question, -1))
+	if coverage < docAnswerMinCoverage {
+		return nil, false
+	}
+	answer.Section = section
+	answer.Excerpt = excerpt
+	answer.Coverage = coverage
+	return answer, true
+}
+
+// questionFlags returns the flags a question asks about, e.g. "-a" or "--archive"
+func questionFlags(question string) []string {
+	var flags []string
+	for _, field := range strings.Fields(question) {
+		field = strings.Trim(field, ".,!?;:\"'()`")
+		if flagNamePattern.FindString(field) == field && field != "" {
+			flags = append(flags, field)
+		}
+	}
+	return flags
+}
+
+// documentedCommand returns the first word of a question that names an indexed command
+func (rs *RAGSystem) documentedCommand(question string) string {
+	for _, candidate := range rs.extractPotentialCommands(question) {
+		if strings.HasPrefix(candidate, "-") {
+			continue
+		}
+		if _, err := rs.vectorStore.GetCommandInfo(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// documentationPage returns a command's full page, rendering it when the
+// page is not held in memory
+func (rs *RAGSystem) documentationPage(command string) (MANPage, bool) {
+	if rs.usesPowerShellHelp() {
+		return rs.powershell.GetPage(command)
+	}
+	if page, ok := rs.indexer.GetPage(command); ok && page.FullText != "" {
+		return page, true
+	}
+	page, err := rs.indexer.processMANPage(command)
+	return page, err == nil && page.FullText != ""
+}
+
+// findOptionEntry returns the section and text of the entry documenting one
+// of the flags. An entry is its header line plus the lines indented below it.
+func findOptionEntry(page MANPage, flags []string) (string, string) {
+	lines := strings.Split(page.FullText, "\n")
+	section := "OPTIONS"
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 && isSectionHeader(trimmed) {
+			section = canonicalSection(trimmed, page.Language)
+			continue
+		}
+
+		header := flagPattern.FindString(trimmed)
+		if header == "" || !sharesItem(flagNamePattern.FindAllString(header, -1), flags) {
+			continue
+		}
+
+		entry := []string{trimmed}
+		for _, next := range lines[i+1:] {
+			nextTrimmed := strings.TrimSpace(next)
+			if nextTrimmed == "" {
+				continue
+			}
+			if len(next)-len(strings.TrimLeft(next, " \t")) <= indent {
+				break
+			}
+			entry = append(entry, nextTrimmed)
+		}
+		return section, strings.Join(entry, " ")
+	}
+	return "", ""
+}
+
+// questionTerms returns the analyzed terms of a question, without the
+// command's own name and words every documentation question contains
+func (rs *RAGSystem) questionTerms(question, command string) []string {
+	analyzer := rs.vectorStore.analyzer
+	ignored := analyzer.Analyze(strings.Join(append(questionWords, command), " "))
+	ignored = append(ignored, nameTokens(command)...)
+
+	var terms []string
+	for _, term := range analyzer.Analyze(question) {
+		if !containsString(ignored, term) && !containsString(terms, term) && !strings.HasPrefix(term, "-") {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// bestExcerpt finds the sentence or entry of a command's documents that
+// contains the most question terms. Reference entries also match their
+// section's label ("exit status codes"), and a number from the question, such
+// as an exit code, breaks ties. Short sentences keep the one after them.
+func (rs *RAGSystem) bestExcerpt(command string, terms, numbers []string) (string, string, float64) {
+	analyzer := rs.vectorStore.analyzer
+	bestSection, bestExcerpt, bestCoverage, bestRank := "", "", 0.0, 0.0
+
+	consider := func(section, label string, units []string) {
+		for i, unit := range units {
+			unitTerms := analyzer.Analyze(label + " " + unit)
+			matched := 0
+			for _, term := range terms {
+				if containsString(unitTerms, term) {
+					matched++
+				}
+			}
+			coverage := float64(matched) / float64(len(terms))
+			rank := coverage
+			if matched > 0 && sharesItem(strings.Fields(unit), numbers) {
+				rank += 0.5
+			}
+			if rank <= bestRank {
+				continue
+			}
+
+			excerpt := unit
+			if len(strings.Fields(unit)) < shortSentenceWords && i+1 < len(units) {
+				excerpt += " " + units[i+1]
+			}
+			bestSection, bestExcerpt, bestCoverage, bestRank = section, limitWords(excerpt, maxExcerptWords), coverage, rank
+		}
+	}
+
+	// Documents come from a map, so ties go to the lowest ID
+	documents := rs.vectorStore.commandDocuments(command)
+	sort.Slice(documents, func(i, j int) bool { return documents[i].ID < documents[j].ID })
+
+	for _, doc := range documents {
+		switch {
+		case doc.Metadata.Section == "chunk":
+			consider(doc.Metadata.ChunkSection, "", splitSentences(doc.Content))
+		case doc.Metadata.Section == "description":
+			consider("DESCRIPTION", "", splitSentences(doc.Content))
+		case referenceHeaders[doc.Metadata.Section] != "":
+			consider(referenceHeaders[doc.Metadata.Section], referenceLabels[doc.Metadata.Section], doc.Metadata.Entries)
+		}
+	}
+	return bestSection, bestExcerpt, bestCoverage
+}
+
+// splitSentences splits text after ".", "!" or "?" when the next word starts
+// a new sentence, keeping abbreviations like "e.g." inside theirs
+func splitSentences(text string) []string {
+	var sentences []string
+	var current []string
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		current = append(current, word)
+
+		last := word[len(word)-1]
+		if last != '.' && last != '!' && last != '?' {
+			continue
+		}
+		switch strings.ToLower(word) {
+		case "e.g.", "i.e.", "etc.", "vs.":
+			continue
+		}
+		if i+1 < len(words) && !startsUpper(words[i+1]) {
+			continue
+		}
+		sentences = append(sentences, strings.Join(current, " "))
+		current = nil
+	}
+	if len(current) > 0 {
+		sentences = append(sentences, strings.Join(current, " "))
+	}
+	return sentences
+}
+
+// startsUpper reports whether a word starts with an uppercase ASCII letter
+func startsUpper(word string) bool {
+	return word != "" && word[0] >= 'A' && word[0] <= 'Z'
+}
+
+// limitWords truncates text to a number of words
+func limitWords(text string, max int) string {
+	words := strings.Fields(text)
+	if len(words) <= max {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:max], " ") + "..."
+}
+
+// sharesItem reports whether any of the wanted items is in the slice
+func sharesItem(items, wanted []string) bool {
+	for _, item := range wanted {
+		if containsString(items, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Package eval measures retrieval quality against a test set of queries and
+// the commands they should retrieve, so ranking changes can be compared by
+// numbers rather than by eye.
+package eval
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTestSet is the test set Helix ships with
+//
+//go:embed testset.yaml
+var DefaultTestSet []byte
+
+// Case is one query and the commands a good retrieval returns for it
+type Case struct {
+	Query    string   `json:"query"`
+	Expected []string `json:"expected"` // any of these counts as a hit
+}
+
+// Searcher returns the commands retrieved for a query, best first, at most k
+type Searcher func(query string, k int) ([]string, error)
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	Case
+	Retrieved []string `json:"retrieved"`
+	Hits      int      `json:"hits"` // retrieved commands that were expected
+	Rank      int      `json:"rank"` // position of the first hit, 0 when none
+	Err       string   `json:"error,omitempty"`
+}
+
+// Report summarizes a run over a test set
+type Report struct {
+	K         int          `json:"k"`
+	Cases     []CaseResult `json:"cases"`
+	Precision float64      `json:"precision"` // mean precision@k
+	Recall    float64      `json:"recall"`    // share of cases with a hit in the top k
+	MRR       float64      `json:"mrr"`       // mean reciprocal rank of the first hit
+}
+
+// Misses returns the cases without a hit in the top k
+func (r *Report) Misses() []CaseResult {
+	var misses []CaseResult
+	for _, result := range r.Cases {
+		if result.Rank == 0 {
+			misses = append(misses, result)
+		}
+	}
+	return misses
+}
+
+// LoadTestSet reads a test set file, or the default test set when path is empty
+func LoadTestSet(path string) ([]Case, error) {
+	if path == "" {
+		return ParseTestSet(DefaultTestSet)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTestSet(data)
+}
+
+// ParseTestSet reads the YAML test set format: a list of mappings with a
+// query and its expected commands, given as a list ([ls, dir]) or as one name
+func ParseTestSet(data []byte) ([]Case, error) {
+	var raw []struct {
+		Query    string    `yaml:"query"`
+		Expected yaml.Node `yaml:"expected"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	cases := make([]Case, 0, len(raw))
+	for i, entry := range raw {
+		c := Case{Query: entry.Query}
+		switch entry.Expected.Kind {
+		case yaml.ScalarNode:
+			c.Expected = []string{entry.Expected.Value}
+		case yaml.SequenceNode:
+			if err := entry.Expected.Decode(&c.Expected); err != nil {
+				return nil, fmt.Errorf("case %d: %w", i+1, err)
+			}
+		}
+		if c.Query == "" || len(c.Expected) == 0 {
+			return nil, fmt.Errorf("case %d needs a query and expected commands", i+1)
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Run retrieves the top k commands of every case and scores them
+func Run(cases []Case, k int, search Searcher) *Report {
+	report := &Report{K: k}
+	if len(cases) == 0 || k < 1 {
+		return report
+	}
+
+	for _, c := range cases {
+		result := CaseResult{Case: c}
+		retrieved, err := search(c.Query, k)
+		if err != nil {
+			result.Err = err.Error()
+		}
+		if len(retrieved) > k {
+			retrieved = retrieved[:k]
+		}
+		result.Retrieved = retrieved
+
+		expected := make(map[string]bool)
+		for _, command := range c.Expected {
+			expected[strings.ToLower(command)] = true
+		}
+		for i, command := range retrieved {
+			if expected[strings.ToLower(command)] {
+				result.Hits++
+				if result.Rank == 0 {
+					result.Rank = i + 1
+				}
+			}
+		}
+
+		report.Precision += float64(result.Hits) / float64(k)
+		if result.Rank > 0 {
+			report.Recall++
+			report.MRR += 1 / float64(result.Rank)
+		}
+		report.Cases = append(report.Cases, result)
+	}
+
+	total := float64(len(cases))
+	report.Precision /= total
+	report.Recall /= total
+	report.MRR /= total
+	return report
+}
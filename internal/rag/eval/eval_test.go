@@ -0,0 +1,50 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTestSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Case
+		wantErr bool
+	}{
+		{
+			name: "inline, single and block lists",
+			input: "# comment\n- query: list files\n  expected: [ls, dir]\n- query: \"show disk: usage\"\n  expected: df\n" +
+				"- query: find text\n  expected:\n    - grep\n    - rg # ripgrep\n",
+			want: []Case{
+				{Query: "list files", Expected: []string{"ls", "dir"}},
+				{Query: "show disk: usage", Expected: []string{"df"}},
+				{Query: "find text", Expected: []string{"grep", "rg"}},
+			},
+		},
+		{name: "unknown key", input: "- query: ls\n  expect: [ls]\n", wantErr: true},
+		{name: "no expected commands", input: "- query: ls\n", wantErr: true},
+		{name: "not a list", input: "query: ls\nexpected: [ls]\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTestSet([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTestSet error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseTestSet = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultTestSetParses(t *testing.T) {
+	cases, err := LoadTestSet("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("default test set has no cases")
+	}
+}
@@ -0,0 +1,249 @@
+package rag
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+const feedbackFileName = "rag_feedback.json"
+
+// Outcomes of a generated command
+const (
+	FeedbackAccepted = "accepted" // run as generated
+	FeedbackEdited   = "edited"   // run after the user changed it
+	FeedbackRejected = "rejected" // not run
+)
+
+// How much one outcome moves a document's weight, and the weight's bounds.
+// Weights are recomputed from the counts, so they never drift.
+const (
+	acceptedFeedbackStep = 0.05
+	editedFeedbackStep   = -0.02
+	rejectedFeedbackStep = -0.05
+	minFeedbackWeight    = 0.5
+	maxFeedbackWeight    = 1.5
+)
+
+// DocumentFeedback counts the outcomes of commands generated with a document in the prompt
+type DocumentFeedback struct {
+	Accepted int `json:"accepted"`
+	Edited   int `json:"edited"`
+	Rejected int `json:"rejected"`
+}
+
+// Weight returns the score multiplier the outcomes earn the document
+func (f DocumentFeedback) Weight() float64 {
+	weight := 1 + float64(f.Accepted)*acceptedFeedbackStep +
+		float64(f.Edited)*editedFeedbackStep +
+		float64(f.Rejected)*rejectedFeedbackStep
+	if weight < minFeedbackWeight {
+		return minFeedbackWeight
+	}
+	if weight > maxFeedbackWeight {
+		return maxFeedbackWeight
+	}
+	return weight
+}
+
+// WeightedDocument is a document whose weight feedback has changed
+type WeightedDocument struct {
+	ID     string  `json:"id"`
+	Weight float64 `json:"weight"`
+}
+
+// FeedbackStats summarizes the recorded feedback
+type FeedbackStats struct {
+	Accepted int                `json:"accepted"`
+	Edited   int                `json:"edited"`
+	Rejected int                `json:"rejected"`
+	Boosted  []WeightedDocument `json:"boosted"` // highest weights first
+	Demoted  []WeightedDocument `json:"demoted"` // lowest weights first
+}
+
+// Total returns how many outcomes were recorded
+func (s FeedbackStats) Total() int {
+	return s.Accepted + s.Edited + s.Rejected
+}
+
+// feedbackStore persists outcomes per document and remembers which documents
+// grounded the most recent prompt
+type feedbackStore struct {
+	path      string
+	mu        sync.Mutex
+	Totals    DocumentFeedback             `json:"totals"`
+	Documents map[string]*DocumentFeedback `json:"documents"`
+
+	pendingQuery     string
+	pendingDocuments []string
+}
+
+// loadFeedback reads the feedback file; a missing or invalid file starts empty
+func loadFeedback(path string) *feedbackStore {
+	store := &feedbackStore{path: path, Documents: make(map[string]*DocumentFeedback)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return store
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		color.Yellow("⚠️  Ignoring invalid %s: %v", path, err)
+		return &feedbackStore{path: path, Documents: make(map[string]*DocumentFeedback)}
+	}
+	if store.Documents == nil {
+		store.Documents = make(map[string]*DocumentFeedback)
+	}
+	return store
+}
+
+// save writes the feedback file (caller holds the lock)
+func (fs *feedbackStore) save() error {
+	data, err := json.MarshalIndent(fs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fs.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fs.path, data, 0644)
+}
+
+// setPending remembers the documents that grounded the prompt for a query
+func (fs *feedbackStore) setPending(query string, documents []string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.pendingQuery = query
+	fs.pendingDocuments = documents
+}
+
+// weights returns the score multiplier of every document with feedback
+func (fs *feedbackStore) weights() map[string]float64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	weights := make(map[string]float64, len(fs.Documents))
+	for docID, feedback := range fs.Documents {
+		weights[docID] = feedback.Weight()
+	}
+	return weights
+}
+
+// count adds one outcome to a tally
+func (f *DocumentFeedback) count(outcome string) {
+	switch outcome {
+	case FeedbackAccepted:
+		f.Accepted++
+	case FeedbackEdited:
+		f.Edited++
+	case FeedbackRejected:
+		f.Rejected++
+	}
+}
+
+// RecordFeedback records what the user did with the command generated for a
+// query, crediting the documents that grounded its prompt. Queries answered
+// without RAG context have nothing to credit and are ignored.
+func (rs *RAGSystem) RecordFeedback(query, outcome string) {
+	fs := rs.feedback
+	fs.mu.Lock()
+	if fs.pendingQuery != query || len(fs.pendingDocuments) == 0 {
+		fs.mu.Unlock()
+		return
+	}
+
+	fs.Totals.count(outcome)
+	for _, docID := range fs.pendingDocuments {
+		feedback, exists := fs.Documents[docID]
+		if !exists {
+			feedback = &DocumentFeedback{}
+			fs.Documents[docID] = feedback
+		}
+		feedback.count(outcome)
+	}
+	fs.pendingQuery, fs.pendingDocuments = "", nil
+
+	err := fs.save()
+	fs.mu.Unlock()
+	if err != nil {
+		color.Yellow("⚠️  Could not save RAG feedback: %v", err)
+	}
+
+	rs.vectorStore.SetFeedbackWeights(fs.weights())
+}
+
+// FeedbackStats returns the recorded outcomes and the most boosted and
+// demoted documents
+func (rs *RAGSystem) FeedbackStats(limit int) FeedbackStats {
+	fs := rs.feedback
+	fs.mu.Lock()
+	stats := FeedbackStats{
+		Accepted: fs.Totals.Accepted,
+		Edited:   fs.Totals.Edited,
+		Rejected: fs.Totals.Rejected,
+	}
+	fs.mu.Unlock()
+
+	var weighted []WeightedDocument
+	for docID, weight := range fs.weights() {
+		if weight != 1 {
+			weighted = append(weighted, WeightedDocument{ID: docID, Weight: weight})
+		}
+	}
+	sort.Slice(weighted, func(i, j int) bool {
+		if weighted[i].Weight != weighted[j].Weight {
+			return weighted[i].Weight > weighted[j].Weight
+		}
+		return weighted[i].ID < weighted[j].ID
+	})
+
+	for _, doc := range weighted {
+		if doc.Weight > 1 && len(stats.Boosted) < limit {
+			stats.Boosted = append(stats.Boosted, doc)
+		}
+	}
+	for i := len(weighted) - 1; i >= 0; i-- {
+		if weighted[i].Weight < 1 && len(stats.Demoted) < limit {
+			stats.Demoted = append(stats.Demoted, weighted[i])
+		}
+	}
+	return stats
+}
+
+// SetFeedbackWeights replaces the per-document weights learned from feedback
+func (vs *VectorStore) SetFeedbackWeights(weights map[string]float64) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.feedback = weights
+	vs.generation++
+}
+
+// applyFeedbackWeights scales the scores of documents with feedback
+// (caller holds the lock)
+func (vs *VectorStore) applyFeedbackWeights(scores map[string]float64) {
+	for docID, weight := range vs.feedback {
+		if _, scored := scores[docID]; scored {
+			scores[docID] *= weight
+		}
+	}
+}
+
+// promptDocuments returns the IDs of the documents behind the retrieved
+// commands. Commands found by name alone are credited to their command document.
+func promptDocuments(commands []CommandInfo) []string {
+	var documents []string
+	for _, cmd := range commands {
+		if cmd.Source == "project" {
+			continue
+		}
+		ids := cmd.Documents
+		if len(ids) == 0 {
+			ids = []string{cmd.Name + "-command"}
+		}
+		documents = appendUnique(documents, ids)
+	}
+	return documents
+}
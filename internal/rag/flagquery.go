@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// flagQueryBoost is added to the scores of the option documents that document
+// a flag the query names literally, for a command the query also names. Short
+// flags such as -R are below the analyzer's minimum term length, so BM25
+// alone would rank the command's general description first.
+const flagQueryBoost = 5.0
+
+// queryWords returns the lowercase words of a query without surrounding punctuation
+func queryWords(query string) []string {
+	var words []string
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		if word := strings.Trim(field, ".,!?;:\"'()`"); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// documentedFlags returns the flags an options document or section chunk documents
+func documentedFlags(doc VectorDocument) []string {
+	switch doc.Metadata.Section {
+	case "chunk":
+		return doc.Metadata.Options
+	case "options":
+		var flags []string
+		for _, option := range doc.Metadata.OptionDetails {
+			flags = append(flags, option.Flags()...)
+		}
+		if len(flags) > 0 {
+			return flags
+		}
+		for _, line := range doc.Metadata.Options {
+			flags = append(flags, flagNamePattern.FindAllString(flagPattern.FindString(line), -1)...)
+		}
+		return flags
+	}
+	return nil
+}
+
+// applyFlagBoosts ranks the documents describing a flag asked about, e.g.
+// chown's OPTIONS entry for "what does -R do in chown", above the command's
+// other documents. Flags are case-sensitive: -R and -r differ. (caller holds the lock)
+func (vs *VectorStore) applyFlagBoosts(query string, scores map[string]float64) {
+	flags := questionFlags(query)
+	if len(flags) == 0 {
+		return
+	}
+	words := queryWords(query)
+
+	for docID, doc := range vs.documents {
+		if containsString(words, strings.ToLower(doc.Metadata.Command)) && sharesItem(documentedFlags(vs.body(doc)), flags) {
+			scores[docID] += flagQueryBoost
+		}
+	}
+}
+
+// focusOnFlags replaces the passages of the commands a flag question names
+// with the entries documenting those flags, and keeps only the matching
+// option lines, so the prompt quotes the exact option rather than general text
+func (rs *RAGSystem) focusOnFlags(query string, results []CommandInfo) {
+	flags := questionFlags(query)
+	if len(flags) == 0 {
+		return
+	}
+	words := queryWords(query)
+
+	for i := range results {
+		cmd := &results[i]
+		if cmd.Source == "project" || !containsString(words, strings.ToLower(cmd.Name)) {
+			continue
+		}
+		page, ok := rs.documentationPage(cmd.Name)
+		if !ok {
+			continue
+		}
+		section, entry := findOptionEntry(page, flags)
+		if entry == "" {
+			continue
+		}
+
+		cmd.Passages = []string{fmt.Sprintf("[%s] %s", section, limitWords(entry, maxExcerptWords))}
+		var options []string
+		for _, option := range cmd.Options {
+			if sharesItem(flagNamePattern.FindAllString(flagPattern.FindString(option), -1), flags) {
+				options = append(options, option)
+			}
+		}
+		cmd.Options = options
+	}
+}
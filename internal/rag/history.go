@@ -0,0 +1,234 @@
+package rag
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Limits for the shell history collection
+const (
+	maxHistoryLines    = 5000
+	maxHistoryResults  = 3
+	historyMinScore    = 1.0
+	minHistoryCommand  = 4
+	maxHistoryLineSize = 500
+)
+
+// historySection marks documents built from shell history
+const historySection = "history"
+
+// zshExtendedHistory matches the ": <start>:<elapsed>;" prefix of zsh's extended history
+var zshExtendedHistory = regexp.MustCompile(`^: \d+:\d+;`)
+
+// historySecretPattern skips lines that look like they carry credentials
+var historySecretPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|authorization:|bearer\s)`)
+
+// trivialHistoryCommands are too common to say anything about the user's habits
+var trivialHistoryCommands = map[string]bool{
+	"ls": true, "cd": true, "pwd": true, "clear": true, "exit": true, "history": true, "helix": true,
+}
+
+// historyCollection is an in-memory index of the user's shell history. It is
+// never written to disk, so Helix keeps no second copy of the history.
+type historyCollection struct {
+	store    *VectorStore
+	counts   map[string]int // document ID -> times the command was run
+	modTimes map[string]time.Time
+}
+
+// SetShellHistoryEnabled opts in to (or out of) using shell history as a
+// retrieval source
+func (rs *RAGSystem) SetShellHistoryEnabled(enabled bool) {
+	rs.historyMu.Lock()
+	defer rs.historyMu.Unlock()
+
+	rs.historyEnabled = enabled
+	if !enabled {
+		rs.history = nil
+	}
+}
+
+// historyFiles returns the shell history files that exist for the user
+func historyFiles() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	candidates := []string{
+		os.Getenv("HISTFILE"),
+		filepath.Join(homeDir, ".bash_history"),
+		filepath.Join(homeDir, ".zsh_history"),
+		filepath.Join(homeDir, ".local", "share", "fish", "fish_history"),
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, path := range candidates {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// readHistoryFile returns the most recent commands of a bash, zsh or fish history file
+func readHistoryFile(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "- cmd: ") {
+			// fish: "- cmd: <command>" followed by "  when: <time>"
+			line = strings.TrimPrefix(line, "- cmd: ")
+		} else if strings.HasPrefix(line, "  ") && strings.HasSuffix(path, "fish_history") {
+			continue
+		}
+		line = zshExtendedHistory.ReplaceAllString(line, "")
+		line = strings.TrimSpace(line)
+
+		// bash timestamps ("#1700000000") and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) > maxHistoryLines {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
+
+// usefulHistoryCommand reports whether a history line is worth retrieving
+func usefulHistoryCommand(line string) bool {
+	if len(line) < minHistoryCommand || len(line) > maxHistoryLineSize {
+		return false
+	}
+	if historySecretPattern.MatchString(line) {
+		return false
+	}
+	fields := strings.Fields(line)
+	return len(fields) > 0 && !(len(fields) == 1 && trivialHistoryCommands[fields[0]])
+}
+
+// buildHistoryCollection indexes the history files, counting repeated commands once
+func buildHistoryCollection(files []string, boostsPath string) *historyCollection {
+	collection := &historyCollection{
+		store:    newVectorStoreAt("", boostsPath),
+		counts:   make(map[string]int),
+		modTimes: make(map[string]time.Time),
+	}
+
+	ids := make(map[string]string) // command line -> document ID
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			collection.modTimes[path] = info.ModTime()
+		}
+
+		for _, line := range readHistoryFile(path) {
+			if !usefulHistoryCommand(line) {
+				continue
+			}
+			if docID, exists := ids[line]; exists {
+				collection.counts[docID]++
+				continue
+			}
+
+			docID := fmt.Sprintf("history-%d", len(ids))
+			ids[line] = docID
+			collection.counts[docID] = 1
+
+			program := filepath.Base(strings.Fields(line)[0])
+			collection.store.documents[docID] = VectorDocument{
+				ID:      docID,
+				Content: fmt.Sprintf("%s %s", line, strings.Join(nameTokens(line), " ")),
+				Metadata: Metadata{
+					Command:     program,
+					Section:     historySection,
+					Description: line,
+				},
+			}
+		}
+	}
+
+	collection.store.rebuildIndex()
+	collection.store.initialized = len(collection.store.documents) > 0
+	return collection
+}
+
+// changed reports whether any history file was written since the collection was built
+func (hc *historyCollection) changed(files []string) bool {
+	if len(files) != len(hc.modTimes) {
+		return true
+	}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(hc.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// HistoryCommandCount returns how many distinct history commands are indexed
+func (rs *RAGSystem) HistoryCommandCount() int {
+	rs.historyMu.Lock()
+	defer rs.historyMu.Unlock()
+	if rs.history == nil {
+		return 0
+	}
+	return len(rs.history.counts)
+}
+
+// retrieveHistory returns past command lines matching a query, favouring the
+// ones the user runs most often
+func (rs *RAGSystem) retrieveHistory(query string) []string {
+	rs.historyMu.Lock()
+	defer rs.historyMu.Unlock()
+
+	if !rs.historyEnabled {
+		return nil
+	}
+
+	files := historyFiles()
+	if rs.history == nil || rs.history.changed(files) {
+		rs.history = buildHistoryCollection(files, rs.vectorStore.boostsPath)
+		rs.history.store.retrieval = rs.RetrievalConfig()
+	}
+	if !rs.history.store.initialized {
+		return nil
+	}
+
+	scored, _ := rs.history.store.ExplainSearch(query, maxHistoryResults*5)
+	for i := range scored {
+		scored[i].Score *= 1 + math.Log(float64(rs.history.counts[scored[i].Document.ID]))
+	}
+	sortScored(scored)
+
+	var lines []string
+	for _, result := range scored {
+		if result.Score < historyMinScore || len(lines) >= maxHistoryResults {
+			break
+		}
+		lines = append(lines, result.Document.Metadata.Description)
+	}
+	return lines
+}
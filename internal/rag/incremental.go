@@ -0,0 +1,512 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const manifestFileName = "man_manifest.json"
+
+// PageFingerprint records the on-disk state of a command's MAN page and binary
+type PageFingerprint struct {
+	ManPath       string    `json:"man_path"`
+	ManModTime    time.Time `json:"man_mod_time"`
+	ManSize       int64     `json:"man_size"`
+	BinaryPath    string    `json:"binary_path"`
+	BinaryModTime time.Time `json:"binary_mod_time"`
+}
+
+// Equal reports whether two fingerprints describe the same files
+func (fp PageFingerprint) Equal(other PageFingerprint) bool {
+	return fp.ManPath == other.ManPath &&
+		fp.ManModTime.Equal(other.ManModTime) &&
+		fp.ManSize == other.ManSize &&
+		fp.BinaryPath == other.BinaryPath &&
+		fp.BinaryModTime.Equal(other.BinaryModTime)
+}
+
+// IndexManifest tracks what was indexed and the directory state at that time
+type IndexManifest struct {
+	Version   string                     `json:"version"`
+	ScannedAt time.Time                  `json:"scanned_at"`
+	Dirs      map[string]time.Time       `json:"dirs"`
+	Pages     map[string]PageFingerprint `json:"pages"`
+}
+
+// ReindexSummary reports the outcome of an incremental reindex
+type ReindexSummary struct {
+	Added     []string       `json:"added"`
+	Updated   []string       `json:"updated"`
+	Removed   []string       `json:"removed"`
+	Failed    []string       `json:"failed"`
+	Unchanged int            `json:"unchanged"`
+	Sources   map[string]int `json:"sources,omitempty"` // documents changed per document source
+	Duration  time.Duration  `json:"duration"`
+
+	Compaction *CompactionSummary `json:"compaction,omitempty"` // cleanup run after a changing reindex
+}
+
+// HasChanges reports whether the reindex modified the index
+func (s *ReindexSummary) HasChanges() bool {
+	return len(s.Added) > 0 || len(s.Updated) > 0 || len(s.Removed) > 0
+}
+
+// reindexSchedule tracks background reindexes so that at most one runs at a time
+type reindexSchedule struct {
+	mu      sync.Mutex
+	running bool
+	pending bool // another change came in while running
+}
+
+// ScheduleReindex runs an incremental reindex in the background, e.g. after a
+// package was installed or removed. Requests made while one runs are folded
+// into a single follow-up run. It reports false when a full rebuild is
+// running, which picks up the change by itself.
+func (rs *RAGSystem) ScheduleReindex() bool {
+	if rs.IsRebuilding() {
+		return false
+	}
+	// Installed tools changed, so cached filtering and results are stale
+	rs.ClearRetrievalCache()
+
+	rs.scheduled.mu.Lock()
+	defer rs.scheduled.mu.Unlock()
+	if rs.scheduled.running {
+		rs.scheduled.pending = true
+		return true
+	}
+	rs.scheduled.running = true
+
+	go func() {
+		for {
+			summary, err := rs.IncrementalReindex()
+			if err != nil {
+				color.Yellow("⚠️  Background reindex failed: %v", err)
+			} else if summary.HasChanges() {
+				color.Green("✅ RAG index updated: %d added, %d updated, %d removed",
+					len(summary.Added), len(summary.Updated), len(summary.Removed))
+			}
+			rs.ClearRetrievalCache()
+
+			rs.scheduled.mu.Lock()
+			if !rs.scheduled.pending {
+				rs.scheduled.running = false
+				rs.scheduled.mu.Unlock()
+				return
+			}
+			rs.scheduled.pending = false
+			rs.scheduled.mu.Unlock()
+		}
+	}()
+	return true
+}
+
+// IncrementalReindex re-processes only MAN pages that are new or changed since
+// the last index, and drops commands whose page and binary have disappeared
+func (rs *RAGSystem) IncrementalReindex() (*ReindexSummary, error) {
+	startTime := time.Now()
+	summary := &ReindexSummary{}
+
+	if err := rs.ensureIndexDir(); err != nil {
+		return nil, fmt.Errorf("failed to create RAG index directory: %w", err)
+	}
+
+	// Make sure we diff against what is actually on disk
+	if !rs.vectorStore.IsInitialized() {
+		if err := rs.vectorStore.loadVectorIndex(); err != nil {
+			color.Yellow("⚠️  Could not load existing vector index: %v", err)
+		}
+	}
+
+	// Document sources refresh on their own schedule, independent of MAN page changes
+	summary.Sources = rs.syncSources()
+
+	// Windows has no MAN pages; PowerShell help is diffed against its cache instead
+	if rs.usesPowerShellHelp() {
+		if err := rs.reindexPowerShellHelp(summary); err != nil {
+			return nil, err
+		}
+		if summary.HasChanges() || len(summary.Sources) > 0 {
+			if err := rs.vectorStore.saveVectorIndex(); err != nil {
+				return nil, err
+			}
+		}
+		rs.initialized = rs.vectorStore.DocumentCount() > 0
+		if err := rs.saveSystemState(); err != nil {
+			color.Yellow("⚠️  Could not save RAG state: %v", err)
+		}
+		summary.Duration = time.Since(startTime)
+		return summary, nil
+	}
+
+	oldManifest := rs.loadManifest()
+	dirs := rs.indexer.scanDirModTimes()
+
+	// Fast path: no MAN or PATH directory changed since the last scan
+	if len(oldManifest.Pages) > 0 && rs.vectorStore.DocumentCount() > 0 && sameDirState(oldManifest.Dirs, dirs) {
+		if len(summary.Sources) > 0 {
+			if err := rs.vectorStore.saveVectorIndex(); err != nil {
+				return nil, err
+			}
+		}
+		summary.Unchanged = len(oldManifest.Pages)
+		summary.Duration = time.Since(startTime)
+		color.Green("✅ MAN directories unchanged since %s", oldManifest.ScannedAt.Format(time.RFC822))
+		return summary, nil
+	}
+
+	color.Blue("🔍 Detecting MAN page changes...")
+	current := rs.indexer.ScanFingerprints(oldManifest.Pages)
+
+	// If the vector store was lost, everything must be re-added
+	if rs.vectorStore.DocumentCount() == 0 {
+		oldManifest.Pages = make(map[string]PageFingerprint)
+	}
+
+	var toIndex []string
+	for name, fp := range current {
+		old, exists := oldManifest.Pages[name]
+		switch {
+		case !exists:
+			summary.Added = append(summary.Added, name)
+			toIndex = append(toIndex, name)
+		case !old.Equal(fp):
+			summary.Updated = append(summary.Updated, name)
+			toIndex = append(toIndex, name)
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	for name := range oldManifest.Pages {
+		if _, exists := current[name]; !exists {
+			summary.Removed = append(summary.Removed, name)
+		}
+	}
+
+	newManifest := IndexManifest{
+		Version:   indexVersion,
+		ScannedAt: time.Now(),
+		Dirs:      dirs,
+		Pages:     make(map[string]PageFingerprint),
+	}
+	for name, fp := range oldManifest.Pages {
+		if _, exists := current[name]; exists {
+			newManifest.Pages[name] = fp
+		}
+	}
+
+	if len(toIndex) > 0 {
+		color.Blue("📚 Processing %d new or updated MAN pages...", len(toIndex))
+		pages := rs.indexer.IndexCommands(toIndex)
+
+		processed := make(map[string]bool)
+		for _, page := range pages {
+			processed[page.Name] = true
+			newManifest.Pages[page.Name] = current[page.Name]
+		}
+
+		// Pages that could not be parsed are retried on the next reindex
+		summary.Added = keepProcessed(summary.Added, processed, &summary.Failed)
+		summary.Updated = keepProcessed(summary.Updated, processed, &summary.Failed)
+
+		if err := rs.vectorStore.UpsertMANPages(pages); err != nil {
+			return nil, fmt.Errorf("failed to update vector index: %w", err)
+		}
+	}
+
+	if len(summary.Removed) > 0 {
+		removedDocs := rs.vectorStore.RemoveCommands(summary.Removed)
+		rs.indexer.RemovePages(summary.Removed)
+		color.Yellow("🗑️  Removed %d documents for %d uninstalled commands", removedDocs, len(summary.Removed))
+	}
+
+	if summary.HasChanges() || len(summary.Sources) > 0 {
+		if err := rs.vectorStore.saveVectorIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rs.saveManifest(newManifest); err != nil {
+		color.Yellow("⚠️  Could not save index manifest: %v", err)
+	}
+
+	// Removals and rewrites leave orphans and free pages behind
+	if summary.HasChanges() {
+		if compaction, err := rs.Compact(); err == nil {
+			summary.Compaction = compaction
+		} else {
+			color.Yellow("⚠️  Index compaction skipped: %v", err)
+		}
+	}
+
+	rs.initialized = rs.vectorStore.DocumentCount() > 0
+	if err := rs.saveSystemState(); err != nil {
+		color.Yellow("⚠️  Could not save RAG state: %v", err)
+	}
+
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Updated)
+	sort.Strings(summary.Removed)
+	sort.Strings(summary.Failed)
+	summary.Duration = time.Since(startTime)
+
+	return summary, nil
+}
+
+// keepProcessed filters names to those that were processed, collecting the rest as failed
+func keepProcessed(names []string, processed map[string]bool, failed *[]string) []string {
+	var kept []string
+	for _, name := range names {
+		if processed[name] {
+			kept = append(kept, name)
+		} else {
+			*failed = append(*failed, name)
+		}
+	}
+	return kept
+}
+
+// sameDirState compares two directory modification time snapshots
+func sameDirState(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for dir, modTime := range a {
+		other, exists := b[dir]
+		if !exists || !other.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadManifest loads the index manifest, returning an empty one when missing or stale
+func (rs *RAGSystem) loadManifest() IndexManifest {
+	empty := IndexManifest{
+		Dirs:  make(map[string]time.Time),
+		Pages: make(map[string]PageFingerprint),
+	}
+
+	data, err := os.ReadFile(filepath.Join(rs.indexDir, manifestFileName))
+	if err != nil {
+		return empty
+	}
+
+	var manifest IndexManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Version != indexVersion {
+		return empty
+	}
+
+	if manifest.Dirs == nil {
+		manifest.Dirs = make(map[string]time.Time)
+	}
+	if manifest.Pages == nil {
+		manifest.Pages = make(map[string]PageFingerprint)
+	}
+	return manifest
+}
+
+// saveManifest writes the index manifest to disk
+func (rs *RAGSystem) saveManifest(manifest IndexManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rs.indexDir, manifestFileName), data, 0644)
+}
+
+// recordManifest snapshots the fingerprints of the currently indexed pages
+func (rs *RAGSystem) recordManifest() {
+	pages := make(map[string]PageFingerprint)
+	for name, fp := range rs.indexer.ScanFingerprints(nil) {
+		if _, indexed := rs.indexer.GetPage(name); indexed {
+			pages[name] = fp
+		}
+	}
+
+	manifest := IndexManifest{
+		Version:   indexVersion,
+		ScannedAt: time.Now(),
+		Dirs:      rs.indexer.scanDirModTimes(),
+		Pages:     pages,
+	}
+	if err := rs.saveManifest(manifest); err != nil {
+		color.Yellow("⚠️  Could not save index manifest: %v", err)
+	}
+}
+
+// ========== MAN INDEXER CHANGE DETECTION ==========
+
+// ScanFingerprints fingerprints every useful command with a MAN page on disk.
+// Commands from a previous manifest that the directory scan misses are looked
+// up individually so pages outside the standard layout are not reported as removed.
+func (mi *MANIndexer) ScanFingerprints(previous map[string]PageFingerprint) map[string]PageFingerprint {
+	fingerprints := make(map[string]PageFingerprint)
+
+	for command, path := range mi.discoverManFiles() {
+		fingerprints[command] = fingerprintCommand(command, path)
+	}
+
+	for command := range previous {
+		if _, found := fingerprints[command]; found || mi.isAlias(command) {
+			continue
+		}
+		path := lookupManPath(command)
+		fp := fingerprintCommand(command, path)
+		if fp.ManPath != "" || fp.BinaryPath != "" {
+			fingerprints[command] = fp
+		}
+	}
+
+	return fingerprints
+}
+
+// IndexCommands processes the given commands' MAN pages and stores them in the index
+func (mi *MANIndexer) IndexCommands(commands []string) []MANPage {
+	var wg sync.WaitGroup
+	pageChan := make(chan string, len(commands))
+	resultChan := make(chan MANPage, len(commands))
+
+	workerCount := mi.indexingConfig().workers()
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go mi.manPageWorker(&wg, pageChan, resultChan)
+	}
+
+	for _, command := range commands {
+		pageChan <- command
+	}
+	close(pageChan)
+	mi.progress.setPhase(PhaseParsing)
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	var pages []MANPage
+	for page := range resultChan {
+		mi.mu.Lock()
+		mi.indexed[page.Name] = page
+		mi.mu.Unlock()
+		pages = append(pages, page)
+	}
+
+	if err := mi.saveLocalizedPages(); err != nil {
+		color.Yellow("⚠️  Could not save localized MAN pages: %v", err)
+	}
+
+	return pages
+}
+
+// RemovePages drops commands from the in-memory MAN index
+func (mi *MANIndexer) RemovePages(commands []string) {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	for _, command := range commands {
+		delete(mi.indexed, command)
+		delete(mi.localized, command)
+	}
+}
+
+// discoverManFiles maps each useful command to its first MAN page file on the
+// MAN path. Pages that only link to another page are recorded as aliases of
+// it instead, so the same page is not indexed twice.
+func (mi *MANIndexer) discoverManFiles() map[string]string {
+	files := make(map[string]string)
+	aliases := make(map[string]string)
+
+	for _, path := range strings.Split(mi.getMANPath(), ":") {
+		for _, category := range mi.categories {
+			categoryPath := filepath.Join(path, "man"+category)
+			entries, err := os.ReadDir(categoryPath)
+			if err != nil {
+				continue
+			}
+
+			for _, entry := range entries {
+				if entry.IsDir() || !strings.Contains(entry.Name(), ".") {
+					continue
+				}
+				command := strings.Split(entry.Name(), ".")[0]
+				if _, seen := files[command]; seen || aliases[command] != "" || !mi.isUsefulCommand(command) {
+					continue
+				}
+				path := filepath.Join(categoryPath, entry.Name())
+				if target := manPageAlias(path); target != "" {
+					aliases[command] = target
+					continue
+				}
+				files[command] = path
+			}
+		}
+	}
+
+	mi.mu.Lock()
+	mi.aliases = aliases
+	mi.mu.Unlock()
+	return files
+}
+
+// scanDirModTimes snapshots modification times of MAN category and PATH directories
+func (mi *MANIndexer) scanDirModTimes() map[string]time.Time {
+	dirs := make(map[string]time.Time)
+
+	for _, path := range strings.Split(mi.getMANPath(), ":") {
+		for _, category := range mi.categories {
+			categoryPath := filepath.Join(path, "man"+category)
+			if info, err := os.Stat(categoryPath); err == nil {
+				dirs[categoryPath] = info.ModTime()
+			}
+		}
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if info, err := os.Stat(dir); err == nil {
+			dirs[dir] = info.ModTime()
+		}
+	}
+
+	return dirs
+}
+
+// fingerprintCommand stats the MAN page and binary backing a command
+func fingerprintCommand(command, manPath string) PageFingerprint {
+	var fp PageFingerprint
+
+	if manPath != "" {
+		if info, err := os.Stat(manPath); err == nil {
+			fp.ManPath = manPath
+			fp.ManModTime = info.ModTime()
+			fp.ManSize = info.Size()
+		}
+	}
+
+	if binPath, err := exec.LookPath(command); err == nil {
+		if info, err := os.Stat(binPath); err == nil {
+			fp.BinaryPath = binPath
+			fp.BinaryModTime = info.ModTime()
+		}
+	}
+
+	return fp
+}
+
+// lookupManPath asks man for the page location of a single command
+func lookupManPath(command string) string {
+	output, err := exec.Command("man", "-w", command).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Split(string(output), "\n")[0])
+}
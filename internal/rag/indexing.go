@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	// maxIndexWorkers caps the default worker count; more parallel man
+	// processes mostly contend for disk
+	maxIndexWorkers = 8
+	// defaultPageTimeout bounds rendering one MAN page so a hung man or
+	// pager does not stall a worker
+	defaultPageTimeout = 30 * time.Second
+	// maxDefaultIndexingTime caps the first-run limit on slow machines
+	maxDefaultIndexingTime = 20 * time.Minute
+)
+
+// IndexingConfig tunes MAN page indexing. Zero values adapt to the machine.
+type IndexingConfig struct {
+	Workers        int  `json:"workers,omitempty"`          // parallel man processes; 0 uses the CPU count, up to 8
+	SkipManK       bool `json:"skip_man_k,omitempty"`       // find pages by scanning MANPATH only, e.g. where the man -k database is unreadable
+	PageTimeoutSec int  `json:"page_timeout_sec,omitempty"` // limit for rendering one page; 0 is 30 seconds
+	TimeoutMin     int  `json:"timeout_min,omitempty"`      // limit for first-run indexing; 0 scales 5 minutes by how few workers run
+}
+
+// workers returns how many pages are rendered in parallel
+func (c IndexingConfig) workers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return max(1, min(runtime.NumCPU(), maxIndexWorkers))
+}
+
+// pageTimeout returns the limit for rendering one page
+func (c IndexingConfig) pageTimeout() time.Duration {
+	if c.PageTimeoutSec > 0 {
+		return time.Duration(c.PageTimeoutSec) * time.Second
+	}
+	return defaultPageTimeout
+}
+
+// timeout returns the limit for first-run indexing. Without a setting, fewer
+// workers than the old fixed six get proportionally longer.
+func (c IndexingConfig) timeout() time.Duration {
+	if c.TimeoutMin > 0 {
+		return time.Duration(c.TimeoutMin) * time.Minute
+	}
+	timeout := maxIndexingTime
+	if workers := c.workers(); workers < 6 {
+		timeout = timeout * 6 / time.Duration(workers)
+	}
+	if timeout > maxDefaultIndexingTime {
+		timeout = maxDefaultIndexingTime
+	}
+	return timeout
+}
+
+// SetIndexingConfig applies the MAN page indexing settings used by the next
+// indexing run
+func (rs *RAGSystem) SetIndexingConfig(config IndexingConfig) {
+	rs.indexer.mu.Lock()
+	defer rs.indexer.mu.Unlock()
+	rs.indexer.config = config
+}
+
+// IndexingConfig returns the MAN page indexing settings
+func (rs *RAGSystem) IndexingConfig() IndexingConfig {
+	return rs.indexer.indexingConfig()
+}
+
+// indexingConfig returns the indexer's settings
+func (mi *MANIndexer) indexingConfig() IndexingConfig {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	return mi.config
+}
@@ -0,0 +1,320 @@
+package rag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	bolt "go.etcd.io/bbolt"
+)
+
+// schemaMigration upgrades a vector database by one schema version
+type schemaMigration struct {
+	to      string
+	migrate func(tx *bolt.Tx) error
+}
+
+// schemaMigrations are keyed by the schema version they upgrade from.
+// Databases with a schema that has no path to the current one are rebuilt.
+var schemaMigrations = map[string]schemaMigration{
+	"1": {to: "2", migrate: addDocumentChecksums},
+}
+
+// IntegrityReport describes the damage found in the stored index
+type IntegrityReport struct {
+	Schema    string        `json:"schema"`
+	Documents int           `json:"documents"` // stored documents checked
+	Corrupted []string      `json:"corrupted"` // unreadable or failing their checksum
+	Missing   []string      `json:"missing"`   // MAN pages in the manifest without documents
+	Commands  []string      `json:"commands"`  // commands that must be rebuilt
+	Duration  time.Duration `json:"duration"`
+}
+
+// Healthy reports whether nothing needs rebuilding
+func (r *IntegrityReport) Healthy() bool {
+	return len(r.Corrupted) == 0 && len(r.Missing) == 0
+}
+
+// documentChecksum returns the checksum stored for a document's JSON
+func documentChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// decodeDocument verifies a stored document against its checksum and decodes
+// it. Databases from before checksums (no bucket) are only decoded.
+func decodeDocument(key, value []byte, checksums *bolt.Bucket) (VectorDocument, error) {
+	var doc VectorDocument
+	if checksums != nil {
+		sum := checksums.Get(key)
+		if sum == nil {
+			return doc, fmt.Errorf("no checksum")
+		}
+		if !bytes.Equal(sum, documentChecksum(value)) {
+			return doc, fmt.Errorf("checksum mismatch")
+		}
+	}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return doc, err
+	}
+	if doc.ID != string(key) {
+		return doc, fmt.Errorf("stored under a different ID (%s)", doc.ID)
+	}
+	return doc, nil
+}
+
+// addDocumentChecksums records a checksum for every stored document
+func addDocumentChecksums(tx *bolt.Tx) error {
+	documents := tx.Bucket(documentsBucket)
+	if documents == nil {
+		return nil
+	}
+	checksums, err := tx.CreateBucketIfNotExists(checksumsBucket)
+	if err != nil {
+		return err
+	}
+	return documents.ForEach(func(key, value []byte) error {
+		return checksums.Put(key, documentChecksum(value))
+	})
+}
+
+// migrateSchema upgrades the database to the current schema in place, so a
+// new schema does not force a full rebuild from MAN pages
+func (vs *VectorStore) migrateSchema() error {
+	info, err := vs.readIndexInfo()
+	if err != nil || info.Schema == vectorDBSchemaVersion {
+		return nil
+	}
+	if _, known := schemaMigrations[info.Schema]; !known {
+		return nil
+	}
+
+	db, err := vs.openDB(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		schema := info.Schema
+		for schema != vectorDBSchemaVersion {
+			migration, known := schemaMigrations[schema]
+			if !known {
+				break
+			}
+			if err := migration.migrate(tx); err != nil {
+				return fmt.Errorf("schema %s to %s: %w", schema, migration.to, err)
+			}
+			schema = migration.to
+		}
+		color.Blue("📦 Migrated vector database from schema %s to %s", info.Schema, schema)
+		return tx.Bucket(metaBucket).Put(schemaKey, []byte(schema))
+	})
+}
+
+// salvageLegacyDocuments decodes the documents of a truncated or damaged
+// vector_index.json up to the first one that cannot be read
+func salvageLegacyDocuments(data []byte) map[string]VectorDocument {
+	documents := make(map[string]VectorDocument)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return documents
+	}
+
+	for decoder.More() {
+		if _, err := decoder.Token(); err != nil {
+			break
+		}
+		var doc VectorDocument
+		if err := decoder.Decode(&doc); err != nil {
+			break
+		}
+		if doc.ID != "" {
+			documents[doc.ID] = doc
+		}
+	}
+	return documents
+}
+
+// commandFromDocID returns the command a document ID belongs to, for records
+// too damaged to read their metadata
+func commandFromDocID(docID string) string {
+	if i := strings.Index(docID, "-chunk-"); i > 0 {
+		return docID[:i]
+	}
+	if i := strings.LastIndex(docID, "-"); i > 0 {
+		return docID[:i]
+	}
+	return docID
+}
+
+// missingManifestPages returns the MAN pages the manifest says are indexed
+// but that have no documents, e.g. after a damaged index was salvaged
+func (rs *RAGSystem) missingManifestPages(documents map[string]VectorDocument) []string {
+	if rs.usesPowerShellHelp() {
+		return nil
+	}
+
+	indexed := make(map[string]bool)
+	for _, doc := range documents {
+		indexed[doc.Metadata.Command] = true
+	}
+
+	var missing []string
+	for name := range rs.loadManifest().Pages {
+		if !indexed[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// newIntegrityReport lists the commands to rebuild for the damage found.
+// tldr documents are restored from the tldr cache instead.
+func newIntegrityReport(schema string, documents int, corrupted, missing []string) *IntegrityReport {
+	report := &IntegrityReport{Schema: schema, Documents: documents, Corrupted: corrupted, Missing: missing}
+
+	seen := make(map[string]bool)
+	for _, docID := range corrupted {
+		if command := commandFromDocID(docID); !strings.HasSuffix(docID, "-tldr") && !seen[command] {
+			seen[command] = true
+			report.Commands = append(report.Commands, command)
+		}
+	}
+	for _, command := range missing {
+		if !seen[command] {
+			seen[command] = true
+			report.Commands = append(report.Commands, command)
+		}
+	}
+	sort.Strings(report.Commands)
+	return report
+}
+
+// VerifyIndex reads every stored document, checking its checksum, and
+// compares the stored commands against the MAN manifest
+func (rs *RAGSystem) VerifyIndex() (*IntegrityReport, error) {
+	startTime := time.Now()
+	vs := rs.vectorStore
+
+	if err := vs.migrateSchema(); err != nil {
+		color.Yellow("⚠️  Could not migrate vector database schema: %v", err)
+	}
+	info, err := vs.readIndexInfo()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open vector database: %w", err)
+	}
+	documents, corrupted, err := vs.readDocuments("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vector database: %w", err)
+	}
+
+	report := newIntegrityReport(info.Schema, len(documents)+len(corrupted), corrupted, rs.missingManifestPages(documents))
+	report.Duration = time.Since(startTime)
+	return report, nil
+}
+
+// RepairIndex deletes the corrupted records of a report and rebuilds only
+// the affected commands from their MAN pages (or PowerShell help) and the
+// document sources. It returns the commands rebuilt and those that could not be.
+func (rs *RAGSystem) RepairIndex(report *IntegrityReport) ([]string, []string, error) {
+	vs := rs.vectorStore
+
+	vs.mu.Lock()
+	for _, docID := range report.Corrupted {
+		vs.deleteDocument(docID)
+	}
+	vs.corrupted = nil
+	vs.mu.Unlock()
+
+	var pages []MANPage
+	if rs.usesPowerShellHelp() {
+		if rs.powershell.GetIndexedCount() == 0 {
+			rs.powershell.LoadCache()
+		}
+		for _, command := range report.Commands {
+			if page, ok := rs.powershell.GetPage(command); ok {
+				pages = append(pages, page)
+			}
+		}
+	} else if len(report.Commands) > 0 {
+		pages = rs.indexer.IndexCommands(report.Commands)
+	}
+	if err := vs.UpsertMANPages(pages); err != nil {
+		return nil, nil, err
+	}
+
+	// Document sources put back only what is missing or differs
+	if len(report.Corrupted) > 0 {
+		rs.syncSources()
+	}
+
+	if err := vs.saveVectorIndex(); err != nil {
+		return nil, nil, err
+	}
+	rs.initialized = vs.DocumentCount() > 0
+	rs.ClearRetrievalCache()
+
+	rebuilt := make(map[string]bool)
+	for _, page := range pages {
+		rebuilt[page.Name] = true
+	}
+	var repaired, failed []string
+	for _, command := range report.Commands {
+		if rebuilt[command] {
+			repaired = append(repaired, command)
+		} else {
+			failed = append(failed, command)
+		}
+	}
+
+	// Pages that can no longer be parsed are left to the next /rag-reindex
+	// rather than retried on every start
+	if len(failed) > 0 && !rs.usesPowerShellHelp() {
+		manifest := rs.loadManifest()
+		for _, command := range failed {
+			delete(manifest.Pages, command)
+		}
+		if err := rs.saveManifest(manifest); err != nil {
+			color.Yellow("⚠️  Could not save index manifest: %v", err)
+		}
+	}
+	return repaired, failed, nil
+}
+
+// repairAfterLoad rebuilds the commands whose documents failed to load, so a
+// damaged record costs a few page re-parses instead of disabling RAG
+func (rs *RAGSystem) repairAfterLoad() {
+	vs := rs.vectorStore
+	if vs.IsStale() {
+		return // the pending rebuild replaces everything anyway
+	}
+	vs.mu.RLock()
+	corrupted := append([]string(nil), vs.corrupted...)
+	missing := rs.missingManifestPages(vs.documents)
+	documents := len(vs.documents)
+	vs.mu.RUnlock()
+
+	report := newIntegrityReport(vs.IndexInfo().Schema, documents+len(corrupted), corrupted, missing)
+	if report.Healthy() {
+		return
+	}
+
+	color.Yellow("⚠️  RAG index is damaged: %d corrupted documents, %d MAN pages missing", len(corrupted), len(missing))
+	color.Blue("🔧 Rebuilding %d affected commands...", len(report.Commands))
+	repaired, failed, err := rs.RepairIndex(report)
+	if err != nil {
+		color.Yellow("⚠️  Index repair failed: %v (run /rag-reindex full)", err)
+		return
+	}
+	color.Green("✅ Rebuilt %d commands", len(repaired))
+	if len(failed) > 0 {
+		color.Yellow("⚠️  Could not rebuild: %s", strings.Join(failed, ", "))
+	}
+}
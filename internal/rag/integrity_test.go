@@ -0,0 +1,120 @@
+package rag
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// saveTestIndex saves an index of ls and cp documents in dir
+func saveTestIndex(t *testing.T, dir string) {
+	t.Helper()
+	vs := newTestStore(t, dir)
+	addTestDocuments(vs,
+		testDocument("ls", "command", "list directory contents"),
+		testDocument("ls", "description", "list information about the files"),
+		testDocument("cp", "command", "copy files and directories"),
+		testDocument("cp", "tldr", "cp source destination"),
+	)
+	if err := vs.saveVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// damageDocuments overwrites stored documents without updating their checksums
+func damageDocuments(t *testing.T, vs *VectorStore, ids ...string) {
+	t.Helper()
+	db, err := vs.openDB(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, id := range ids {
+		err := db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(documentsBucket).Put([]byte(id), []byte(`{"id": "`+id+`", "content": "tampe`))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCorruptedDocumentsAreSkippedAndRepaired(t *testing.T) {
+	dir := t.TempDir()
+	saveTestIndex(t, dir)
+	damageDocuments(t, newTestStore(t, dir), "ls-description", "cp-tldr")
+
+	vs := newTestStore(t, dir)
+	if err := vs.loadVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	// One bad record must not take down the rest of the index
+	if vs.DocumentCount() != 2 || !vs.IsInitialized() {
+		t.Fatalf("loaded %d documents, want the 2 undamaged ones", vs.DocumentCount())
+	}
+	if want := []string{"cp-tldr", "ls-description"}; !reflect.DeepEqual(vs.corrupted, want) {
+		t.Fatalf("corrupted = %v, want %v", vs.corrupted, want)
+	}
+
+	// tldr documents come back from the tldr cache, MAN pages are re-parsed
+	report := newIntegrityReport(vs.IndexInfo().Schema, vs.DocumentCount()+len(vs.corrupted), vs.corrupted, nil)
+	if report.Healthy() || !reflect.DeepEqual(report.Commands, []string{"ls"}) {
+		t.Fatalf("report = %+v, want ls to be rebuilt", report)
+	}
+
+	// Deleting the bad records, as RepairIndex does, leaves a clean index
+	vs.mu.Lock()
+	for _, id := range report.Corrupted {
+		vs.deleteDocument(id)
+	}
+	vs.corrupted = nil
+	vs.mu.Unlock()
+	addTestDocuments(vs, testDocument("ls", "description", "list information about the files"))
+	if err := vs.saveVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newTestStore(t, dir)
+	if err := reloaded.loadVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.corrupted) != 0 || reloaded.DocumentCount() != 3 {
+		t.Fatalf("after repair %d documents loaded and %v corrupted, want 3 and none",
+			reloaded.DocumentCount(), reloaded.corrupted)
+	}
+}
+
+func TestSalvageLegacyDocuments(t *testing.T) {
+	documents := map[string]VectorDocument{
+		"ls-command": testDocument("ls", "command", "list directory contents"),
+	}
+	data, err := json.Marshal(documents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second document cut off halfway, as by an interrupted save
+	truncated := string(data[:len(data)-1]) + `, "cp-command": {"id": "cp-command", "content": "copy fi`
+
+	salvaged := salvageLegacyDocuments([]byte(truncated))
+	if len(salvaged) != 1 || salvaged["ls-command"].Content != "list directory contents" {
+		t.Fatalf("salvaged %v, want only ls-command", salvaged)
+	}
+	if got := salvageLegacyDocuments([]byte("not json")); len(got) != 0 {
+		t.Fatalf("salvaged %v from garbage", got)
+	}
+}
+
+func TestCommandFromDocID(t *testing.T) {
+	for docID, want := range map[string]string{
+		"ls-command":                   "ls",
+		"git-commit-description":       "git-commit",
+		"find-chunk-expression-3":      "find",
+		"docker-compose-chunk-usage-0": "docker-compose",
+	} {
+		if got := commandFromDocID(docID); got != want {
+			t.Errorf("commandFromDocID(%q) = %q, want %q", docID, got, want)
+		}
+	}
+}
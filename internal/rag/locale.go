@@ -0,0 +1,414 @@
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// englishLanguage is the language MAN pages are parsed in and preferred for prompt grounding
+const englishLanguage = "en"
+
+// localizedSections maps section headers of localized MAN pages to the English
+// section names the parser understands, per language
+var localizedSections = map[string]map[string]string{
+	"de": {"NAME": "NAME", "ÜBERSICHT": "SYNOPSIS", "BESCHREIBUNG": "DESCRIPTION", "OPTIONEN": "OPTIONS", "BEISPIELE": "EXAMPLES", "BEISPIEL": "EXAMPLES",
+		"EXIT-STATUS": "EXIT STATUS", "RÜCKGABEWERT": "EXIT STATUS", "DATEIEN": "FILES", "UMGEBUNG": "ENVIRONMENT", "UMGEBUNGSVARIABLEN": "ENVIRONMENT"},
+	"fr": {"NOM": "NAME", "SYNOPSIS": "SYNOPSIS", "DESCRIPTION": "DESCRIPTION", "OPTIONS": "OPTIONS", "EXEMPLES": "EXAMPLES", "EXEMPLE": "EXAMPLES",
+		"CODE DE RETOUR": "EXIT STATUS", "ÉTAT DE SORTIE": "EXIT STATUS", "FICHIERS": "FILES", "ENVIRONNEMENT": "ENVIRONMENT"},
+	"es": {"NOMBRE": "NAME", "SINOPSIS": "SYNOPSIS", "DESCRIPCIÓN": "DESCRIPTION", "OPCIONES": "OPTIONS", "EJEMPLOS": "EXAMPLES",
+		"ESTADO DE SALIDA": "EXIT STATUS", "ARCHIVOS": "FILES", "FICHEROS": "FILES", "ENTORNO": "ENVIRONMENT"},
+	"it": {"NOME": "NAME", "SINOSSI": "SYNOPSIS", "DESCRIZIONE": "DESCRIPTION", "OPZIONI": "OPTIONS", "ESEMPI": "EXAMPLES",
+		"STATO DI USCITA": "EXIT STATUS", "FILE": "FILES", "AMBIENTE": "ENVIRONMENT"},
+	"pt": {"NOME": "NAME", "SINOPSE": "SYNOPSIS", "DESCRIÇÃO": "DESCRIPTION", "OPÇÕES": "OPTIONS", "EXEMPLOS": "EXAMPLES",
+		"STATUS DE SAÍDA": "EXIT STATUS", "ARQUIVOS": "FILES", "AMBIENTE": "ENVIRONMENT"},
+	"nl": {"NAAM": "NAME", "OVERZICHT": "SYNOPSIS", "BESCHRIJVING": "DESCRIPTION", "OPTIES": "OPTIONS", "VOORBEELDEN": "EXAMPLES",
+		"AFSLUITSTATUS": "EXIT STATUS", "BESTANDEN": "FILES", "OMGEVING": "ENVIRONMENT"},
+	"pl": {"NAZWA": "NAME", "SKŁADNIA": "SYNOPSIS", "OPIS": "DESCRIPTION", "OPCJE": "OPTIONS", "PRZYKŁADY": "EXAMPLES",
+		"KOD WYJŚCIA": "EXIT STATUS", "PLIKI": "FILES", "ŚRODOWISKO": "ENVIRONMENT"},
+	"ru": {"ИМЯ": "NAME", "НАЗВАНИЕ": "NAME", "ОБЗОР": "SYNOPSIS", "СИНТАКСИС": "SYNOPSIS", "ОПИСАНИЕ": "DESCRIPTION", "ПАРАМЕТРЫ": "OPTIONS", "ОПЦИИ": "OPTIONS", "ПРИМЕРЫ": "EXAMPLES",
+		"КОД ВЫХОДА": "EXIT STATUS", "СОСТОЯНИЕ ВЫХОДА": "EXIT STATUS", "ФАЙЛЫ": "FILES", "ОКРУЖЕНИЕ": "ENVIRONMENT"},
+	"ja": {"名前": "NAME", "書式": "SYNOPSIS", "説明": "DESCRIPTION", "オプション": "OPTIONS", "例": "EXAMPLES",
+		"終了ステータス": "EXIT STATUS", "ファイル": "FILES", "環境変数": "ENVIRONMENT"},
+	"zh": {"名称": "NAME", "概述": "SYNOPSIS", "描述": "DESCRIPTION", "选项": "OPTIONS", "范例": "EXAMPLES", "示例": "EXAMPLES",
+		"退出状态": "EXIT STATUS", "文件": "FILES", "环境": "ENVIRONMENT"},
+}
+
+// languageNames names the languages documentation can be in, for prompts
+var languageNames = map[string]string{
+	"en": "English", "de": "German", "fr": "French", "es": "Spanish", "it": "Italian", "pt": "Portuguese",
+	"nl": "Dutch", "pl": "Polish", "ru": "Russian", "ja": "Japanese", "zh": "Chinese",
+}
+
+// languageName returns a language's English name, or its code when unknown
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// translationNote tells the model how to use documentation in another
+// language: it is translated into the user's language, or into English when
+// the user's language is the documentation's own. English documentation
+// needs no note.
+func translationNote(docLang, userLang string) string {
+	if docLang == "" || docLang == englishLanguage {
+		return ""
+	}
+	target := userLang
+	if target == "" || target == docLang {
+		target = englishLanguage
+	}
+	return fmt.Sprintf("Note: this documentation is in %s; translate descriptions into %s before using them\n",
+		languageName(docLang), languageName(target))
+}
+
+// englishSections are the headers of an untranslated MAN page
+var englishSections = map[string]bool{
+	"NAME": true, "SYNOPSIS": true, "DESCRIPTION": true, "OPTIONS": true, "EXAMPLES": true,
+	"EXIT STATUS": true, "FILES": true, "ENVIRONMENT": true,
+	// Not indexed, but they end the section before them
+	"SEE ALSO": true, "REPORTING BUGS": true, "RETURN VALUE": true, "CONFORMING TO": true,
+}
+
+// sectionAliases maps other English spellings of a section to the name the parser uses
+var sectionAliases = map[string]string{
+	"EXIT CODES":            "EXIT STATUS",
+	"EXIT VALUES":           "EXIT STATUS",
+	"RETURN CODES":          "EXIT STATUS",
+	"ENVIRONMENT VARIABLES": "ENVIRONMENT",
+	"CONFIGURATION FILES":   "FILES",
+}
+
+// SystemLanguage returns the two-letter language of the user's locale, or "en"
+// for the C/POSIX locale
+func SystemLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if value == "C" || value == "POSIX" || strings.HasPrefix(value, "C.") {
+			return englishLanguage
+		}
+		// e.g. de_DE.UTF-8 or pt_BR
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return r == '_' || r == '.' || r == '@' || r == '-'
+		})
+		if len(parts) > 0 {
+			return strings.ToLower(parts[0])
+		}
+	}
+	return englishLanguage
+}
+
+// englishEnvironment returns the process environment with the locale forced to C,
+// so man renders the untranslated page
+func englishEnvironment() []string {
+	var environ []string
+	for _, entry := range os.Environ() {
+		if strings.HasPrefix(entry, "LC_ALL=") || strings.HasPrefix(entry, "LANG=") ||
+			strings.HasPrefix(entry, "LANGUAGE=") || strings.HasPrefix(entry, "LC_MESSAGES=") {
+			continue
+		}
+		environ = append(environ, entry)
+	}
+	return append(environ, "LC_ALL=C.UTF-8", "LANG=C.UTF-8")
+}
+
+// DetectPageLanguage guesses the language of rendered MAN page content from its
+// section headers. Pages with no recognizable headers are assumed to be English.
+func DetectPageLanguage(content string) string {
+	scores := make(map[string]int)
+
+	for _, line := range strings.Split(content, "\n") {
+		header := strings.TrimSpace(line)
+		if !isSectionHeader(header) {
+			continue
+		}
+		if englishSections[header] {
+			scores[englishLanguage]++
+		}
+		for lang, sections := range localizedSections {
+			if _, ok := sections[header]; ok {
+				scores[lang]++
+			}
+		}
+	}
+
+	// English wins ties, e.g. French pages share SYNOPSIS/DESCRIPTION/OPTIONS
+	best, bestScore := englishLanguage, scores[englishLanguage]
+	for _, lang := range sortedLanguages() {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
+
+// canonicalSection maps a localized section header to its English name
+func canonicalSection(header, lang string) string {
+	header = strings.ToUpper(header)
+	if lang != englishLanguage {
+		if english, ok := localizedSections[lang][header]; ok {
+			return english
+		}
+	}
+	if english, ok := sectionAliases[header]; ok {
+		return english
+	}
+	return header
+}
+
+// isSectionHeader reports whether a trimmed line looks like a MAN section header
+func isSectionHeader(line string) bool {
+	if line == "" || strings.ToUpper(line) != line {
+		return false
+	}
+	// Body text is often in capitals too, so only known headers may contain spaces
+	if strings.Contains(line, " ") {
+		return isKnownSection(line)
+	}
+	for _, r := range line {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	// Scripts without letter case (Japanese, Chinese) only match known headers
+	return isKnownSection(line)
+}
+
+// isKnownSection reports whether a header is a section name in any supported language
+func isKnownSection(header string) bool {
+	if englishSections[header] || sectionAliases[header] != "" {
+		return true
+	}
+	for _, sections := range localizedSections {
+		if _, ok := sections[header]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedLanguages returns the localized languages in a stable order
+func sortedLanguages() []string {
+	languages := make([]string, 0, len(localizedSections))
+	for lang := range localizedSections {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// ========== LOCALIZED PAGES FOR THE MAN READER ==========
+
+// localeDirLanguage returns the language of a localized MAN directory such
+// as de, de_DE or de_DE.UTF-8, or "" for other directories
+func localeDirLanguage(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '.' || r == '@'
+	})
+	if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || strings.HasPrefix(name, "man") {
+		return ""
+	}
+	for _, r := range parts[0] {
+		if !unicode.IsLower(r) {
+			return ""
+		}
+	}
+	return parts[0]
+}
+
+// localizedManCommands lists the commands with a page in the localized MAN
+// directories of a language, e.g. /usr/share/man/de/man1/ls.1.gz
+func (mi *MANIndexer) localizedManCommands(lang string) map[string]bool {
+	if lang == "" || lang == englishLanguage {
+		return nil
+	}
+
+	commands := make(map[string]bool)
+	for _, root := range strings.Split(mi.getMANPath(), ":") {
+		locales, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, locale := range locales {
+			if !locale.IsDir() || localeDirLanguage(locale.Name()) != lang {
+				continue
+			}
+			for _, category := range mi.categories {
+				entries, err := os.ReadDir(filepath.Join(root, locale.Name(), "man"+category))
+				if err != nil {
+					continue
+				}
+				for _, entry := range entries {
+					if name, _, found := strings.Cut(entry.Name(), "."); found && !entry.IsDir() {
+						commands[name] = true
+					}
+				}
+			}
+		}
+	}
+	return commands
+}
+
+// SetLocalizedLanguage enables collecting localized pages in the given language.
+// An empty language or English disables it.
+func (mi *MANIndexer) SetLocalizedLanguage(lang string) {
+	if lang == englishLanguage {
+		lang = ""
+	}
+	commands := mi.localizedManCommands(lang)
+
+	mi.mu.Lock()
+	mi.localizedLang = lang
+	mi.localized = make(map[string]MANPage)
+	mi.localizedCommands = commands
+	mi.mu.Unlock()
+
+	if lang != "" {
+		mi.loadLocalizedPages()
+	}
+}
+
+// LocalizedLanguage returns the language localized pages are collected in, if any
+func (mi *MANIndexer) LocalizedLanguage() string {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	return mi.localizedLang
+}
+
+// GetLocalizedPage returns the localized page for a command, if one was indexed
+func (mi *MANIndexer) GetLocalizedPage(name string) (MANPage, bool) {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	page, exists := mi.localized[name]
+	return page, exists
+}
+
+// hasLocalizedPage reports whether a command may have a page in the localized
+// language. Without localized MAN directories (e.g. pages found through
+// MANPATH overrides) every command is tried.
+func (mi *MANIndexer) hasLocalizedPage(command string) bool {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	if mi.localizedLang == "" {
+		return false
+	}
+	return len(mi.localizedCommands) == 0 || mi.localizedCommands[command]
+}
+
+// indexLocalizedPage renders a command's page in the user's locale and keeps it
+// if it really is translated, so English fallbacks are not stored twice
+func (mi *MANIndexer) indexLocalizedPage(command string) {
+	content, err := mi.renderMANPage(command, nil)
+	if err != nil {
+		return
+	}
+
+	page := mi.parseMANContent(command, content)
+
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	if page.Language == mi.localizedLang {
+		mi.localized[command] = page
+	}
+}
+
+// localizedCacheFile returns the cache file for the current localized language
+func (mi *MANIndexer) localizedCacheFile(lang string) string {
+	return filepath.Join(mi.indexDir, fmt.Sprintf("localized_%s.json", lang))
+}
+
+// loadLocalizedPages loads previously collected localized pages
+func (mi *MANIndexer) loadLocalizedPages() {
+	lang := mi.LocalizedLanguage()
+	data, err := os.ReadFile(mi.localizedCacheFile(lang))
+	if err != nil {
+		return
+	}
+
+	var pages map[string]MANPage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return
+	}
+
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	for name, page := range pages {
+		if page.Language == lang {
+			mi.localized[name] = page
+		}
+	}
+}
+
+// saveLocalizedPages writes the localized pages to their own cache file
+func (mi *MANIndexer) saveLocalizedPages() error {
+	mi.mu.RLock()
+	lang := mi.localizedLang
+	if lang == "" || len(mi.localized) == 0 {
+		mi.mu.RUnlock()
+		return nil
+	}
+	data, err := json.Marshal(mi.localized)
+	mi.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := mi.ensureIndexDir(); err != nil {
+		return err
+	}
+	return os.WriteFile(mi.localizedCacheFile(lang), data, 0644)
+}
+
+// SetIndexLocalizedPages enables indexing MAN pages in the user's language for
+// the /man reader. Without the setting they are still indexed when the locale
+// is not English and MAN pages for it are installed (man/<locale>/man1).
+// They are stored separately and never used for prompt grounding.
+func (rs *RAGSystem) SetIndexLocalizedPages(enabled bool) {
+	lang := SystemLanguage()
+	if !enabled && len(rs.indexer.localizedManCommands(lang)) == 0 {
+		lang = ""
+	}
+	rs.indexer.SetLocalizedLanguage(lang)
+}
+
+// LocalizedLanguage returns the language of the localized MAN pages being
+// indexed, or "" when none are
+func (rs *RAGSystem) LocalizedLanguage() string {
+	return rs.indexer.LocalizedLanguage()
+}
+
+// ReadManPage returns a command's MAN page for reading, preferring the
+// localized page when localized indexing is enabled
+func (rs *RAGSystem) ReadManPage(command string) (MANPage, error) {
+	if page, ok := rs.indexer.GetLocalizedPage(command); ok {
+		return page, nil
+	}
+
+	if lang := rs.indexer.LocalizedLanguage(); lang != "" {
+		if content, err := rs.indexer.renderMANPage(command, nil); err == nil {
+			if page := rs.indexer.parseMANContent(command, content); page.Language == lang {
+				return page, nil
+			}
+		}
+	}
+
+	if page, ok := rs.indexer.GetPage(command); ok {
+		return page, nil
+	}
+
+	return rs.indexer.processMANPage(command)
+}
+
+// isEnglish reports whether a command's documentation is English (or predates language detection)
+func isEnglish(cmd CommandInfo) bool {
+	return cmd.Language == "" || cmd.Language == englishLanguage
+}
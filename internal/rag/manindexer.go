@@ -1,6 +1,7 @@
 package rag
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -16,14 +17,21 @@ import (
 
 // MANPage represents a processed manual page
 type MANPage struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Synopsis    string   `json:"synopsis"`
-	Options     []string `json:"options"`
-	Examples    []string `json:"examples"`
-	FullText    string   `json:"full_text"`
-	Category    string   `json:"category"`
-	Path        string   `json:"path"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	Synopsis      string         `json:"synopsis"`
+	Options       []string       `json:"options"`
+	Examples      []string       `json:"examples"`
+	FullText      string         `json:"full_text"`
+	Category      string         `json:"category"`
+	Path          string         `json:"path"`
+	Language      string         `json:"language,omitempty"`
+	Chunks        []SectionChunk `json:"chunks,omitempty"`
+	ExitStatus    []string       `json:"exit_status,omitempty"`
+	Files         []string       `json:"files,omitempty"`
+	Environment   []string       `json:"environment,omitempty"`
+	Aliases       []string       `json:"aliases,omitempty"`        // commands whose MAN pages link to this one
+	OptionDetails []Option       `json:"option_details,omitempty"` // parsed options, merged by flag; Options renders them
 }
 
 // MANIndexer handles scanning and processing MAN pages
@@ -33,6 +41,14 @@ type MANIndexer struct {
 	indexed    map[string]MANPage
 	mu         sync.RWMutex
 	categories []string
+	aliases    map[string]string // commands whose MAN page links to another page, e.g. vi -> vim
+	progress   *progressTracker  // shared with the RAG system; nil when unused
+	config     IndexingConfig
+
+	// Localized pages are kept apart from the index for the /man reader
+	localizedLang     string
+	localized         map[string]MANPage
+	localizedCommands map[string]bool // commands in man/<locale>/man* directories
 }
 
 // NewMANIndexer creates a new MAN page indexer
@@ -49,27 +65,29 @@ func NewMANIndexer(env shell.Env) *MANIndexer {
 		indexDir:   indexDir,
 		indexed:    make(map[string]MANPage),
 		categories: []string{"1", "2", "3", "4", "5", "6", "7", "8"},
+		aliases:    make(map[string]string),
+		localized:  make(map[string]MANPage),
 	}
 }
 
 // IndexAvailableManPages scans and indexes all available MAN pages
 func (mi *MANIndexer) IndexAvailableManPages() error {
-	color.Blue("📚 Scanning for MAN pages...")
-
 	if err := mi.ensureIndexDir(); err != nil {
 		return fmt.Errorf("failed to create index directory: %w", err)
 	}
 
 	// Get MAN path
 	manPath := mi.getMANPath()
-	color.Cyan("🔍 MAN path: %s", manPath)
+
+	// Alias pages are skipped by the workers, so they must be known first
+	mi.discoverManFiles()
 
 	var wg sync.WaitGroup
 	pageChan := make(chan string, 100)
 	resultChan := make(chan MANPage, 100)
 
 	// Start workers to process MAN pages
-	workerCount := 6
+	workerCount := mi.indexingConfig().workers()
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
 		go mi.manPageWorker(&wg, pageChan, resultChan)
@@ -91,10 +109,6 @@ func (mi *MANIndexer) IndexAvailableManPages() error {
 		mi.indexed[page.Name] = page
 		mi.mu.Unlock()
 		processed++
-
-		if processed%50 == 0 {
-			color.Green("✅ Indexed %d MAN pages...", processed)
-		}
 	}
 
 	color.Green("🎉 MAN page indexing completed! Indexed %d pages", processed)
@@ -104,8 +118,7 @@ func (mi *MANIndexer) IndexAvailableManPages() error {
 // Enhanced findMANPages with useful command tracking
 func (mi *MANIndexer) findMANPages(manPath string, pageChan chan<- string) {
 	defer close(pageChan)
-
-	color.Cyan("🔍 Using smart MAN page discovery...")
+	defer mi.progress.setPhase(PhaseParsing)
 
 	totalFound := 0
 
@@ -114,23 +127,17 @@ func (mi *MANIndexer) findMANPages(manPath string, pageChan chan<- string) {
 		mi.tryManKEnhanced,  // Enhanced man -k
 		mi.tryDirectoryScan, // Direct directory scanning
 	}
+	if mi.indexingConfig().SkipManK {
+		methods = methods[1:]
+	}
 
-	for i, method := range methods {
-		color.Cyan("Trying method %d...", i+1)
-		count := method(pageChan)
-		if count > 0 {
-			totalFound += count
-			color.Green("✅ Method %d found %d total commands", i+1, count)
-		} else {
-			color.Yellow("⚠️  Method %d found 0 commands", i+1)
-		}
+	for _, method := range methods {
+		totalFound += method(pageChan)
 	}
 
 	if totalFound == 0 {
 		color.Red("❌ No MAN pages found using any method")
 		color.Yellow("💡 MAN pages might not be installed or paths are incorrect")
-	} else {
-		color.Green("🎉 Found %d total commands, filtering for useful ones...", totalFound)
 	}
 }
 
@@ -142,7 +149,6 @@ func (mi *MANIndexer) scanMANCategoryEnhanced(categoryPath string, ch chan<- str
 	}
 
 	count := 0
-	usefulCount := 0
 
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -160,16 +166,11 @@ func (mi *MANIndexer) scanMANCategoryEnhanced(categoryPath string, ch chan<- str
 				// Only send useful commands
 				if mi.isUsefulCommand(command) {
 					ch <- command
-					usefulCount++
 				}
 			}
 		}
 	}
 
-	if usefulCount > 0 {
-		color.Cyan("  %s: %d useful commands", filepath.Base(categoryPath), usefulCount)
-	}
-
 	return count
 }
 
@@ -239,11 +240,13 @@ func (mi *MANIndexer) manPageWorker(wg *sync.WaitGroup, pageChan <-chan string,
 
 	for command := range pageChan {
 		// FILTER: Only process useful commands
-		if !mi.isUsefulCommand(command) {
+		if !mi.isUsefulCommand(command) || mi.isAlias(command) {
 			continue
 		}
+		mi.progress.update(func(p *IndexingProgress) { p.PagesScanned++ })
 
 		page, err := mi.processMANPage(command)
+		mi.progress.update(func(p *IndexingProgress) { p.PagesParsed++ })
 		if err != nil {
 			continue // Skip pages that can't be processed
 		}
@@ -251,17 +254,40 @@ func (mi *MANIndexer) manPageWorker(wg *sync.WaitGroup, pageChan <-chan string,
 	}
 }
 
-// processMANPage extracts information from a single MAN page
+// processMANPage extracts information from a single MAN page. The English page
+// is preferred for prompt grounding; a localized page is only used when no
+// English page exists.
 func (mi *MANIndexer) processMANPage(command string) (MANPage, error) {
-	// Get raw MAN page content
-	cmd := exec.Command("man", command)
-	output, err := cmd.Output()
+	content, err := mi.renderMANPage(command, englishEnvironment())
 	if err != nil {
-		return MANPage{}, fmt.Errorf("failed to get MAN page for %s: %w", command, err)
+		content, err = mi.renderMANPage(command, nil)
+		if err != nil {
+			return MANPage{}, fmt.Errorf("failed to get MAN page for %s: %w", command, err)
+		}
+	}
+
+	if mi.hasLocalizedPage(command) {
+		mi.indexLocalizedPage(command)
 	}
 
-	content := string(output)
-	return mi.parseMANContent(command, content), nil
+	page := mi.parseMANContent(command, content)
+	page.Aliases = mi.aliasesOf(command)
+	return page, nil
+}
+
+// renderMANPage runs man for a command with the given environment (nil
+// inherits ours), giving up after the configured page timeout
+func (mi *MANIndexer) renderMANPage(command string, environ []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mi.indexingConfig().pageTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "man", command)
+	cmd.Env = environ
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
 }
 
 // parseMANContent extracts structured information from MAN page content
@@ -269,6 +295,7 @@ func (mi *MANIndexer) parseMANContent(command, content string) MANPage {
 	page := MANPage{
 		Name:     command,
 		FullText: content,
+		Language: DetectPageLanguage(content),
 	}
 
 	lines := strings.Split(content, "\n")
@@ -276,15 +303,18 @@ func (mi *MANIndexer) parseMANContent(command, content string) MANPage {
 	var sectionContent strings.Builder
 
 	for _, line := range lines {
+		// Headers start in the first column; indented capitals are body text
+		// such as the variable names listed under ENVIRONMENT
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
 		line = strings.TrimSpace(line)
 
-		// Detect section headers
-		if strings.ToUpper(line) == line && len(line) > 0 && !strings.Contains(line, " ") {
+		// Detect section headers, mapping localized names to English ones
+		if !indented && isSectionHeader(line) {
 			// Save previous section
 			mi.processSection(currentSection, sectionContent.String(), &page)
 
 			// Start new section
-			currentSection = line
+			currentSection = canonicalSection(line, page.Language)
 			sectionContent.Reset()
 			continue
 		}
@@ -295,6 +325,11 @@ func (mi *MANIndexer) parseMANContent(command, content string) MANPage {
 	// Process the last section
 	mi.processSection(currentSection, sectionContent.String(), &page)
 
+	if len(page.OptionDetails) > maxPageOptions {
+		page.OptionDetails = page.OptionDetails[:maxPageOptions]
+	}
+	page.Options = optionStrings(page.OptionDetails)
+
 	// Clean up description
 	if page.Description == "" {
 		page.Description = mi.extractDescription(content)
@@ -305,7 +340,12 @@ func (mi *MANIndexer) parseMANContent(command, content string) MANPage {
 
 // processSection processes a specific MAN page section
 func (mi *MANIndexer) processSection(section, content string, page *MANPage) {
-	switch strings.ToUpper(section) {
+	section = strings.ToUpper(section)
+	if chunkedSections[section] {
+		page.Chunks = append(page.Chunks, chunkSection(section, content)...)
+	}
+
+	switch section {
 	case "NAME":
 		page.Description = mi.extractNameDescription(content)
 	case "SYNOPSIS":
@@ -314,10 +354,23 @@ func (mi *MANIndexer) processSection(section, content string, page *MANPage) {
 		if page.Description == "" {
 			page.Description = mi.extractFirstParagraph(content)
 		}
+		if len(page.ExitStatus) == 0 {
+			page.ExitStatus = extractInlineExitStatus(content)
+		}
+		// GNU pages document their options under DESCRIPTION
+		page.OptionDetails = mergeOptions(page.OptionDetails, parseOptions(content))
 	case "OPTIONS":
-		page.Options = mi.extractOptions(content)
+		page.OptionDetails = mergeOptions(page.OptionDetails, parseOptions(content))
 	case "EXAMPLES":
 		page.Examples = mi.extractExamples(content)
+	case "EXIT STATUS":
+		if entries := extractEntries(content, exitStatusEntryPattern); len(entries) > 0 {
+			page.ExitStatus = entries
+		}
+	case "FILES":
+		page.Files = extractEntries(content, filesEntryPattern)
+	case "ENVIRONMENT":
+		page.Environment = extractEntries(content, environmentEntryPattern)
 	}
 }
 
@@ -380,28 +433,6 @@ func (mi *MANIndexer) cleanSynopsis(content string) string {
 	return content
 }
 
-// extractOptions extracts command options
-func (mi *MANIndexer) extractOptions(content string) []string {
-	var options []string
-	lines := strings.Split(content, "\n")
-
-	optionPattern := regexp.MustCompile(`^\s*[-]{1,2}[a-zA-Z0-9]`)
-
-	for _, line := range lines {
-		if optionPattern.MatchString(line) {
-			option := strings.TrimSpace(line)
-			if len(option) > 0 && len(option) < 100 {
-				options = append(options, option)
-			}
-		}
-	}
-
-	if len(options) > 10 {
-		return options[:10] // Limit to top 10 options
-	}
-	return options
-}
-
 // extractExamples extracts usage examples
 func (mi *MANIndexer) extractExamples(content string) []string {
 	var examples []string
@@ -488,7 +519,7 @@ func (mi *MANIndexer) saveIndex() error {
 	// This will be implemented in the vector store
 	// For now, we just keep in memory
 	color.Green("💾 MAN page index ready (%d pages)", len(mi.indexed))
-	return nil
+	return mi.saveLocalizedPages()
 }
 
 // GetIndexedCount returns the number of indexed pages
@@ -0,0 +1,264 @@
+package rag
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// documentOverhead approximates the bytes a document costs beyond its text
+const documentOverhead = 256
+
+// documentPager keeps full document bodies within a memory budget. With a
+// budget set, the store's document map holds light stubs for everything
+// already on disk, and bodies are read back on demand; the least recently
+// used bodies are dropped first.
+type documentPager struct {
+	mu     sync.Mutex
+	budget int64 // bytes; 0 keeps every body in the document map
+	used   int64
+	order  *list.List               // front is the most recently used
+	bodies map[string]*list.Element // document ID -> *pagedBody
+	hits   int
+	misses int
+}
+
+// pagedBody is a full document held by the pager
+type pagedBody struct {
+	doc  VectorDocument
+	size int64
+}
+
+// MemoryStats describes how much document text is held in memory
+type MemoryStats struct {
+	Budget   int64 `json:"budget"` // bytes; 0 means unlimited
+	Resident int64 `json:"resident"`
+	Paged    int   `json:"paged"` // documents whose body lives on disk only
+	Hits     int   `json:"hits"`
+	Misses   int   `json:"misses"`
+}
+
+// documentSize estimates the memory a document's body takes
+func documentSize(doc VectorDocument) int64 {
+	size := int64(documentOverhead + len(doc.ID) + len(doc.Content) + len(doc.Metadata.Description) + 4*len(doc.Embedding))
+	for _, list := range [][]string{doc.Metadata.Options, doc.Metadata.Examples, doc.Metadata.Entries, doc.Metadata.Aliases} {
+		for _, item := range list {
+			size += int64(len(item)) + 16
+		}
+	}
+	for _, option := range doc.Metadata.OptionDetails {
+		size += int64(len(option.Description)) + 64
+	}
+	return size
+}
+
+// stubDocument keeps just what ranking and bookkeeping read from every
+// document: its command, section, source, aliases and chunk flags
+func stubDocument(doc VectorDocument) VectorDocument {
+	stub := VectorDocument{
+		ID:    doc.ID,
+		paged: true,
+		Metadata: Metadata{
+			Command:      doc.Metadata.Command,
+			Section:      doc.Metadata.Section,
+			Language:     doc.Metadata.Language,
+			ChunkSection: doc.Metadata.ChunkSection,
+			ChunkIndex:   doc.Metadata.ChunkIndex,
+			Aliases:      doc.Metadata.Aliases,
+			Source:       doc.Metadata.Source,
+		},
+	}
+	if doc.Metadata.Section == "chunk" {
+		stub.Metadata.Options = doc.Metadata.Options
+	}
+	return stub
+}
+
+// get returns a cached body and marks it recently used
+func (p *documentPager) get(docID string) (VectorDocument, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if element, ok := p.bodies[docID]; ok {
+		p.order.MoveToFront(element)
+		p.hits++
+		return element.Value.(*pagedBody).doc, true
+	}
+	p.misses++
+	return VectorDocument{}, false
+}
+
+// add caches a body, dropping the least recently used ones over budget
+func (p *documentPager) add(doc VectorDocument) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.bodies == nil {
+		p.bodies = make(map[string]*list.Element)
+		p.order = list.New()
+	}
+	p.removeLocked(doc.ID)
+
+	body := &pagedBody{doc: doc, size: documentSize(doc)}
+	p.bodies[doc.ID] = p.order.PushFront(body)
+	p.used += body.size
+	p.trimLocked()
+}
+
+// forget drops a cached body, e.g. when the document changed
+func (p *documentPager) forget(docID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removeLocked(docID)
+}
+
+// setBudget changes the budget, dropping bodies that no longer fit
+func (p *documentPager) setBudget(budget int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.budget = budget
+	if budget == 0 {
+		p.bodies, p.order, p.used = nil, nil, 0
+		return
+	}
+	p.trimLocked()
+}
+
+// enabled reports whether bodies are paged
+func (p *documentPager) enabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.budget > 0
+}
+
+// removeLocked drops one body (caller holds p.mu)
+func (p *documentPager) removeLocked(docID string) {
+	if element, ok := p.bodies[docID]; ok {
+		p.used -= element.Value.(*pagedBody).size
+		p.order.Remove(element)
+		delete(p.bodies, docID)
+	}
+}
+
+// trimLocked drops least recently used bodies until the budget holds (caller holds p.mu)
+func (p *documentPager) trimLocked() {
+	for p.used > p.budget && p.order.Len() > 0 {
+		oldest := p.order.Back()
+		p.removeLocked(oldest.Value.(*pagedBody).doc.ID)
+	}
+}
+
+// pageOut replaces the bodies of documents already on disk with stubs when a
+// budget is set. Unsaved documents stay whole until they are written.
+// (caller holds the lock)
+func (vs *VectorStore) pageOut() {
+	if !vs.pager.enabled() {
+		return
+	}
+	for docID, doc := range vs.documents {
+		if doc.paged || vs.dirty[docID] {
+			continue
+		}
+		vs.pager.add(doc)
+		vs.documents[docID] = stubDocument(doc)
+	}
+}
+
+// fullDocuments returns documents with their bodies, reading paged out ones
+// from the pager or, in one pass, from disk. Order and similarity are kept;
+// a body that cannot be read leaves its stub in place. (caller holds the lock)
+func (vs *VectorStore) fullDocuments(docs []VectorDocument) []VectorDocument {
+	var missing []string
+	result := make([]VectorDocument, len(docs))
+	for i, doc := range docs {
+		result[i] = doc
+		if !doc.paged {
+			continue
+		}
+		if body, ok := vs.pager.get(doc.ID); ok {
+			body.Similarity = doc.Similarity
+			result[i] = body
+		} else {
+			missing = append(missing, doc.ID)
+		}
+	}
+	if len(missing) == 0 {
+		return result
+	}
+
+	bodies, err := vs.readDocumentsByID(missing)
+	if err != nil {
+		color.Yellow("⚠️  Could not read paged documents: %v", err)
+		return result
+	}
+	for i, doc := range result {
+		if body, ok := bodies[doc.ID]; ok && doc.paged {
+			vs.pager.add(body)
+			body.Similarity = doc.Similarity
+			result[i] = body
+		}
+	}
+	return result
+}
+
+// body returns one document with its body (caller holds the lock)
+func (vs *VectorStore) body(doc VectorDocument) VectorDocument {
+	if !doc.paged {
+		return doc
+	}
+	return vs.fullDocuments([]VectorDocument{doc})[0]
+}
+
+// SetMemoryBudget caps the memory document bodies may take, in bytes; 0
+// keeps every body in memory
+func (vs *VectorStore) SetMemoryBudget(budget int64) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	vs.pager.setBudget(budget)
+	if budget > 0 {
+		vs.pageOut()
+		return
+	}
+
+	// Without a budget everything is resident again
+	var paged []VectorDocument
+	for _, doc := range vs.documents {
+		if doc.paged {
+			paged = append(paged, doc)
+		}
+	}
+	for _, doc := range vs.fullDocuments(paged) {
+		vs.documents[doc.ID] = doc
+	}
+}
+
+// MemoryStats reports the memory held by document bodies
+func (vs *VectorStore) MemoryStats() MemoryStats {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	var stats MemoryStats
+	for _, doc := range vs.documents {
+		if doc.paged {
+			stats.Paged++
+		} else {
+			stats.Resident += documentSize(doc)
+		}
+	}
+
+	vs.pager.mu.Lock()
+	defer vs.pager.mu.Unlock()
+	stats.Budget = vs.pager.budget
+	stats.Resident += vs.pager.used
+	stats.Hits = vs.pager.hits
+	stats.Misses = vs.pager.misses
+	return stats
+}
+
+// MemoryStats reports the memory held by the main index's document bodies
+func (rs *RAGSystem) MemoryStats() MemoryStats {
+	return rs.vectorStore.MemoryStats()
+}
@@ -0,0 +1,231 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxPageOptions caps the options kept per page, in page order
+const maxPageOptions = 30
+
+// maxOptionWords caps an option's description; the first sentence or two is
+// what a prompt needs, the MAN page has the rest
+const maxOptionWords = 30
+
+// Option is one normalized command line option
+type Option struct {
+	Short       string `json:"short,omitempty"` // e.g. -R
+	Long        string `json:"long,omitempty"`  // e.g. --recursive, or -name for find-style options
+	Arg         string `json:"arg,omitempty"`   // e.g. FILE, or [WHEN] when optional
+	Description string `json:"description,omitempty"`
+}
+
+// Flags returns the option's names
+func (o Option) Flags() []string {
+	var flags []string
+	for _, flag := range []string{o.Short, o.Long} {
+		if flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// Usage returns the option as written on a command line, e.g.
+// "-I, --ignore=PATTERN" or "--color[=WHEN]"
+func (o Option) Usage() string {
+	var parts []string
+	if o.Short != "" {
+		short := o.Short
+		if o.Arg != "" && o.Long == "" {
+			short += " " + o.Arg
+		}
+		parts = append(parts, short)
+	}
+	if o.Long != "" {
+		long := o.Long
+		switch {
+		case o.Arg == "":
+		case strings.HasPrefix(o.Arg, "["):
+			long += "[=" + strings.TrimPrefix(o.Arg, "[")
+		case strings.HasPrefix(o.Long, "--"):
+			long += "=" + o.Arg
+		default:
+			long += " " + o.Arg
+		}
+		parts = append(parts, long)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String returns the option with its description, as stored in option lists
+func (o Option) String() string {
+	if o.Description == "" {
+		return o.Usage()
+	}
+	return o.Usage() + "  " + o.Description
+}
+
+var (
+	// overstrikePattern matches the bold and underline overstrikes man leaves
+	// in output that is not sent to a terminal on some systems, e.g. "a\ba"
+	overstrikePattern = regexp.MustCompile(`.\x08`)
+	// ansiPattern matches terminal color and style escapes
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// optionStartPattern matches a line that starts an option entry
+	optionStartPattern = regexp.MustCompile(`^[-]{1,2}[A-Za-z0-9]`)
+	// inlineDescriptionPattern separates an entry header from a description
+	// on the same line, as in "-R     Recursively list subdirectories"
+	inlineDescriptionPattern = regexp.MustCompile(`\s{2,}|\t`)
+)
+
+// manTextReplacer maps the typographic characters groff substitutes for
+// ASCII back, so a flag printed with a Unicode hyphen or minus is still -R
+var manTextReplacer = strings.NewReplacer(
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2212", "-",
+	"\u2018", "'", "\u2019", "'", "\u201c", `"`, "\u201d", `"`,
+	"\u00a0", " ", "\u00ad", "",
+)
+
+// cleanManText removes formatting artifacts from rendered MAN page text
+func cleanManText(text string) string {
+	text = overstrikePattern.ReplaceAllString(text, "")
+	text = ansiPattern.ReplaceAllString(text, "")
+	return manTextReplacer.Replace(text)
+}
+
+// parseOptions extracts the option entries of a section: a header line of
+// flags, then description lines up to the next blank line or header
+func parseOptions(content string) []Option {
+	var options []Option
+	var current *Option
+	var description []string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Description = limitWords(strings.Join(description, " "), maxOptionWords)
+		options = append(options, *current)
+		current, description = nil, nil
+	}
+
+	for _, line := range strings.Split(cleanManText(content), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			if current != nil && len(description) > 0 {
+				flush()
+			}
+		case optionStartPattern.MatchString(line):
+			flush()
+			option, inline := parseOptionHeader(line)
+			if len(option.Flags()) == 0 {
+				continue
+			}
+			current = &option
+			if inline != "" {
+				description = append(description, inline)
+			}
+		case current != nil:
+			description = append(description, line)
+		}
+	}
+	flush()
+
+	return options
+}
+
+// parseOptionHeader splits an entry header such as "-f FILE, --file=FILE"
+// into an option, returning any description that follows it on the line
+func parseOptionHeader(line string) (Option, string) {
+	var option Option
+	header, inline := line, ""
+	if loc := inlineDescriptionPattern.FindStringIndex(line); loc != nil {
+		header, inline = line[:loc[0]], strings.TrimSpace(line[loc[1]:])
+	}
+
+	parts := strings.Split(header, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		flag := flagNamePattern.FindString(part)
+		if flag == "" || !strings.HasPrefix(part, flag) {
+			// The rest is prose, e.g. "-a, --all, like ls"
+			inline = strings.TrimSpace(strings.Join(parts[i:], ",") + " " + inline)
+			break
+		}
+
+		if arg := optionArg(part[len(flag):]); arg != "" && option.Arg == "" {
+			option.Arg = arg
+		}
+		if len(flag) == 2 {
+			if option.Short == "" {
+				option.Short = flag
+			}
+		} else if option.Long == "" {
+			option.Long = flag
+		}
+	}
+	return option, inline
+}
+
+// optionArg normalizes the argument written after a flag: "=FILE", " FILE"
+// and "<file>" give FILE and file, and "[=WHEN]" gives [WHEN]
+func optionArg(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "[=") {
+		return "[" + strings.TrimPrefix(text, "[=")
+	}
+	text = strings.TrimSpace(strings.TrimPrefix(text, "="))
+	return strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+}
+
+// mergeOptions merges option lists, combining the entries that share a
+// flag, such as -a documented in DESCRIPTION and -a, --all in OPTIONS
+func mergeOptions(lists ...[]Option) []Option {
+	var merged []Option
+	for _, list := range lists {
+		for _, option := range list {
+			i := findOption(merged, option)
+			if i < 0 {
+				merged = append(merged, option)
+				continue
+			}
+
+			existing := &merged[i]
+			if existing.Short == "" {
+				existing.Short = option.Short
+			}
+			if existing.Long == "" {
+				existing.Long = option.Long
+			}
+			if existing.Arg == "" {
+				existing.Arg = option.Arg
+			}
+			if len(option.Description) > len(existing.Description) {
+				existing.Description = option.Description
+			}
+		}
+	}
+	return merged
+}
+
+// findOption returns the index of the option sharing a flag with another, or -1
+func findOption(options []Option, option Option) int {
+	for i, existing := range options {
+		if (option.Short != "" && existing.Short == option.Short) ||
+			(option.Long != "" && existing.Long == option.Long) {
+			return i
+		}
+	}
+	return -1
+}
+
+// optionStrings renders options for the option lists documents store
+func optionStrings(options []Option) []string {
+	var lines []string
+	for _, option := range options {
+		lines = append(lines, option.String())
+	}
+	return lines
+}
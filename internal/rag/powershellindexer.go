@@ -0,0 +1,378 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+const (
+	powerShellCacheFileName = "powershell_help.json"
+	powerShellIndexTimeout  = 4 * time.Minute
+)
+
+// powerShellHelpScript dumps Get-Help output for every Verb-Noun cmdlet and
+// function as a JSON array. Aliases are resolved so "ls" can be matched back
+// to Get-ChildItem.
+const powerShellHelpScript = `
+$ErrorActionPreference = 'SilentlyContinue'
+$ProgressPreference = 'SilentlyContinue'
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+function Join-Text($items) { (($items | ForEach-Object { $_.Text }) -join ' ').Trim() }
+$aliases = @{}
+Get-Alias | ForEach-Object {
+  if ($_.ResolvedCommandName) { $aliases[$_.ResolvedCommandName] = @($aliases[$_.ResolvedCommandName]) + $_.Name }
+}
+$result = foreach ($c in Get-Command -CommandType Cmdlet,Function | Where-Object { $_.Name -match '^[A-Za-z]+-[A-Za-z0-9]+$' }) {
+  $h = Get-Help $c.Name -Full
+  [pscustomobject]@{
+    Name        = $c.Name
+    Module      = [string]$c.Source
+    Synopsis    = ([string]$h.Synopsis).Trim()
+    Description = Join-Text $h.description
+    Syntax      = ((($h.syntax | Out-String) -replace '\s+', ' ')).Trim()
+    Parameters  = @($h.parameters.parameter | ForEach-Object { [pscustomobject]@{ Name = [string]$_.name; Description = Join-Text $_.description } })
+    Examples    = @($h.examples.example | ForEach-Object { [pscustomobject]@{ Code = ([string]$_.code).Trim(); Remarks = Join-Text $_.remarks } })
+    Aliases     = @($aliases[$c.Name] | Where-Object { $_ })
+  }
+}
+ConvertTo-Json -InputObject @($result) -Depth 4 -Compress
+`
+
+// psHelpEntry is one command as emitted by powerShellHelpScript
+type psHelpEntry struct {
+	Name        string `json:"Name"`
+	Module      string `json:"Module"`
+	Synopsis    string `json:"Synopsis"`
+	Description string `json:"Description"`
+	Syntax      string `json:"Syntax"`
+	Parameters  []struct {
+		Name        string `json:"Name"`
+		Description string `json:"Description"`
+	} `json:"Parameters"`
+	Examples []struct {
+		Code    string `json:"Code"`
+		Remarks string `json:"Remarks"`
+	} `json:"Examples"`
+	Aliases []string `json:"Aliases"`
+}
+
+// PowerShellIndexer builds command documentation from Get-Command and Get-Help on Windows
+type PowerShellIndexer struct {
+	env      shell.Env
+	indexDir string
+	indexed  map[string]MANPage
+	mu       sync.RWMutex
+}
+
+// NewPowerShellIndexer creates a new PowerShell help indexer
+func NewPowerShellIndexer(env shell.Env) *PowerShellIndexer {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = os.TempDir()
+	}
+
+	return &PowerShellIndexer{
+		env:      env,
+		indexDir: filepath.Join(homeDir, ".helix", "powershell_index"),
+		indexed:  make(map[string]MANPage),
+	}
+}
+
+// powerShellExecutable prefers PowerShell 7 (pwsh) and falls back to Windows PowerShell
+func powerShellExecutable() (string, error) {
+	for _, name := range []string{"pwsh", "powershell"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("PowerShell not found in PATH")
+}
+
+// IndexAvailableCommands collects help for all available cmdlets and functions
+func (pi *PowerShellIndexer) IndexAvailableCommands() error {
+	color.Blue("📚 Collecting PowerShell help (Get-Command / Get-Help)...")
+
+	pages, err := pi.collectHelp()
+	if err != nil {
+		return err
+	}
+
+	pi.mu.Lock()
+	pi.indexed = make(map[string]MANPage, len(pages))
+	for _, page := range pages {
+		pi.indexed[page.Name] = page
+	}
+	pi.mu.Unlock()
+
+	color.Green("🎉 PowerShell help indexing completed! Indexed %d commands", len(pages))
+	return pi.saveCache()
+}
+
+// collectHelp runs the help script and converts its output to pages
+func (pi *PowerShellIndexer) collectHelp() ([]MANPage, error) {
+	executable, err := powerShellExecutable()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), powerShellIndexTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, "-NoProfile", "-NonInteractive", "-Command", powerShellHelpScript)
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("PowerShell help collection timed out after %v", powerShellIndexTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PowerShell help collection failed: %w", err)
+	}
+
+	return parsePowerShellHelp(output)
+}
+
+// parsePowerShellHelp converts the JSON emitted by powerShellHelpScript into pages
+func parsePowerShellHelp(data []byte) ([]MANPage, error) {
+	data = []byte(strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff")))
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var entries []psHelpEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid PowerShell help output: %w", err)
+	}
+
+	pages := make([]MANPage, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name == "" {
+			continue
+		}
+		pages = append(pages, entry.toPage())
+	}
+	return pages, nil
+}
+
+// toPage maps Get-Help fields onto the MANPage layout used by the vector store
+func (e psHelpEntry) toPage() MANPage {
+	description := e.Synopsis
+	// Without downloaded help, Get-Help reports the syntax as the synopsis
+	if description == "" || strings.HasPrefix(description, e.Name+" ") || description == e.Name {
+		description = e.Description
+	}
+	if description == "" {
+		description = "No description available"
+	}
+	if len(e.Aliases) > 0 {
+		description = fmt.Sprintf("%s (aliases: %s)", description, strings.Join(e.Aliases, ", "))
+	}
+
+	var options []string
+	for _, param := range e.Parameters {
+		if param.Name == "" {
+			continue
+		}
+		option := "-" + param.Name
+		if param.Description != "" {
+			option += "  " + param.Description
+		}
+		options = append(options, option)
+	}
+
+	var examples []string
+	for _, example := range e.Examples {
+		// Example code is often prefixed with the prompt, e.g. "PS C:\> Get-Process"
+		code := strings.TrimSpace(example.Code)
+		if idx := strings.Index(code, "> "); idx >= 0 && strings.HasPrefix(code, "PS ") {
+			code = strings.TrimSpace(code[idx+2:])
+		}
+		if code == "" {
+			continue
+		}
+		if example.Remarks != "" {
+			code = fmt.Sprintf("%s  # %s", code, firstSentence(example.Remarks))
+		}
+		examples = append(examples, code)
+	}
+
+	fullText := strings.Join([]string{e.Synopsis, e.Description, e.Syntax, strings.Join(e.Aliases, " ")}, "\n")
+
+	return MANPage{
+		Name:        e.Name,
+		Description: description,
+		Synopsis:    e.Syntax,
+		Options:     options,
+		Examples:    examples,
+		FullText:    fullText,
+		Category:    "powershell",
+		Path:        e.Module,
+	}
+}
+
+// firstSentence shortens help remarks to their first sentence
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if idx := strings.Index(text, ". "); idx > 0 {
+		return text[:idx+1]
+	}
+	return text
+}
+
+// LoadCache loads previously collected help, returning false if none is usable
+func (pi *PowerShellIndexer) LoadCache() bool {
+	data, err := os.ReadFile(filepath.Join(pi.indexDir, powerShellCacheFileName))
+	if err != nil {
+		return false
+	}
+
+	var pages map[string]MANPage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return false
+	}
+
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	pi.indexed = pages
+	return len(pi.indexed) > 0
+}
+
+// saveCache writes the collected help to disk
+func (pi *PowerShellIndexer) saveCache() error {
+	if err := os.MkdirAll(pi.indexDir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	pi.mu.RLock()
+	data, err := json.Marshal(pi.indexed)
+	pi.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(pi.indexDir, powerShellCacheFileName), data, 0644)
+}
+
+// GetIndexedCount returns the number of indexed commands
+func (pi *PowerShellIndexer) GetIndexedCount() int {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+	return len(pi.indexed)
+}
+
+// GetPage retrieves a command's help page by name
+func (pi *PowerShellIndexer) GetPage(name string) (MANPage, bool) {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+	page, exists := pi.indexed[name]
+	return page, exists
+}
+
+// GetAllIndexedPages returns all indexed help pages
+func (pi *PowerShellIndexer) GetAllIndexedPages() []MANPage {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+
+	var pages []MANPage
+	for _, page := range pi.indexed {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// usesPowerShellHelp reports whether documentation comes from PowerShell instead of MAN pages
+func (rs *RAGSystem) usesPowerShellHelp() bool {
+	return rs.env.OSName == "windows"
+}
+
+// reindexPowerShellHelp re-collects PowerShell help and applies the differences
+// to the vector store. Get-Help has no files to fingerprint, so pages are compared
+// against the previously cached help.
+func (rs *RAGSystem) reindexPowerShellHelp(summary *ReindexSummary) error {
+	previous := make(map[string]MANPage)
+	if rs.powershell.GetIndexedCount() > 0 || rs.powershell.LoadCache() {
+		for _, page := range rs.powershell.GetAllIndexedPages() {
+			previous[page.Name] = page
+		}
+	}
+
+	// If the vector store was lost, everything must be re-added
+	if rs.vectorStore.DocumentCount() == 0 {
+		previous = make(map[string]MANPage)
+	}
+
+	if err := rs.powershell.IndexAvailableCommands(); err != nil {
+		return err
+	}
+
+	var changed []MANPage
+	current := make(map[string]bool)
+	for _, page := range rs.powershell.GetAllIndexedPages() {
+		current[page.Name] = true
+		old, exists := previous[page.Name]
+		switch {
+		case !exists:
+			summary.Added = append(summary.Added, page.Name)
+			changed = append(changed, page)
+		case !samePage(old, page):
+			summary.Updated = append(summary.Updated, page.Name)
+			changed = append(changed, page)
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	for name := range previous {
+		if !current[name] {
+			summary.Removed = append(summary.Removed, name)
+		}
+	}
+
+	if err := rs.vectorStore.UpsertMANPages(changed); err != nil {
+		return fmt.Errorf("failed to update vector index: %w", err)
+	}
+	if len(summary.Removed) > 0 {
+		removedDocs := rs.vectorStore.RemoveCommands(summary.Removed)
+		color.Yellow("🗑️  Removed %d documents for %d uninstalled commands", removedDocs, len(summary.Removed))
+	}
+
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Updated)
+	sort.Strings(summary.Removed)
+	return nil
+}
+
+// samePage compares the indexed content of two pages
+func samePage(a, b MANPage) bool {
+	return a.Description == b.Description &&
+		a.Synopsis == b.Synopsis &&
+		a.FullText == b.FullText &&
+		strings.Join(a.Options, "\n") == strings.Join(b.Options, "\n") &&
+		strings.Join(a.Examples, "\n") == strings.Join(b.Examples, "\n")
+}
+
+// indexDocumentation runs the platform's documentation indexer
+func (rs *RAGSystem) indexDocumentation() error {
+	if rs.usesPowerShellHelp() {
+		return rs.powershell.IndexAvailableCommands()
+	}
+	return rs.indexer.IndexAvailableManPages()
+}
+
+// indexedPageCount returns how many pages the platform's indexer has processed
+func (rs *RAGSystem) indexedPageCount() int {
+	if rs.usesPowerShellHelp() {
+		return rs.powershell.GetIndexedCount()
+	}
+	return rs.indexer.GetIndexedCount()
+}
@@ -0,0 +1,210 @@
+package rag
+
+import (
+	"sync"
+	"time"
+)
+
+// IndexingPhase is the stage an index build is in
+type IndexingPhase string
+
+const (
+	PhaseIdle      IndexingPhase = "idle"
+	PhaseScanning  IndexingPhase = "scanning"  // finding pages; parsing already runs alongside
+	PhaseParsing   IndexingPhase = "parsing"   // all pages found, parsing the rest
+	PhaseEmbedding IndexingPhase = "embedding" // turning pages into vector documents
+	PhaseDone      IndexingPhase = "done"
+)
+
+// IndexingProgress is a snapshot of a running index build
+type IndexingProgress struct {
+	Phase         IndexingPhase `json:"phase"`
+	PagesScanned  int           `json:"pages_scanned"`
+	PagesParsed   int           `json:"pages_parsed"`
+	PagesToEmbed  int           `json:"pages_to_embed"`
+	PagesEmbedded int           `json:"pages_embedded"`
+	DocsEmbedded  int           `json:"docs_embedded"`
+	StartedAt     time.Time     `json:"started_at"`
+	Elapsed       time.Duration `json:"elapsed"`
+	ETA           time.Duration `json:"eta"` // 0 while it cannot be estimated
+}
+
+// progressTracker collects the progress the indexer and the vector store
+// report and streams it to subscribers. Subscribers always get the latest
+// snapshot; intermediate ones are dropped rather than blocking indexing.
+type progressTracker struct {
+	mu          sync.Mutex
+	progress    IndexingProgress
+	phaseStart  time.Time
+	builds      int // nested start calls not yet finished
+	subscribers map[chan IndexingProgress]bool
+}
+
+// newProgressTracker creates an idle tracker
+func newProgressTracker() *progressTracker {
+	return &progressTracker{
+		progress:    IndexingProgress{Phase: PhaseIdle},
+		subscribers: make(map[chan IndexingProgress]bool),
+	}
+}
+
+// start begins tracking a build. Calls nest: a build started while another
+// is tracked, such as a rebuild kicked off during initialization, shares its
+// progress, and tracking ends with the last matching finish.
+func (t *progressTracker) start() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.builds++
+	if t.builds > 1 {
+		return
+	}
+
+	now := time.Now()
+	t.progress = IndexingProgress{Phase: PhaseScanning, StartedAt: now}
+	t.phaseStart = now
+	t.broadcast()
+}
+
+// finish ends a start call, closing the subscriptions after the last one
+func (t *progressTracker) finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.builds == 0 {
+		return
+	}
+	t.builds--
+	if t.builds > 0 {
+		return
+	}
+
+	t.progress.Phase = PhaseDone
+	t.broadcast()
+	for ch := range t.subscribers {
+		close(ch)
+		delete(t.subscribers, ch)
+	}
+}
+
+// setPhase moves the build to a later phase
+func (t *progressTracker) setPhase(phase IndexingPhase) {
+	t.update(func(p *IndexingProgress) {
+		if p.Phase != phase {
+			p.Phase = phase
+			t.phaseStart = time.Now()
+		}
+	})
+}
+
+// update changes the progress of the tracked build; it is ignored when no
+// build is tracked
+func (t *progressTracker) update(change func(p *IndexingProgress)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.builds == 0 {
+		return
+	}
+	change(&t.progress)
+	t.broadcast()
+}
+
+// snapshot returns the current progress and whether a build is running
+func (t *progressTracker) snapshot() (IndexingProgress, bool) {
+	if t == nil {
+		return IndexingProgress{Phase: PhaseIdle}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current(), t.builds > 0
+}
+
+// subscribe returns a channel of progress snapshots that is closed when the
+// build finishes, and a function to stop listening early. Without a running
+// build the channel is already closed.
+func (t *progressTracker) subscribe() (<-chan IndexingProgress, func()) {
+	ch := make(chan IndexingProgress, 1)
+	if t == nil {
+		close(ch)
+		return ch, func() {}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.builds == 0 {
+		close(ch)
+		return ch, func() {}
+	}
+	t.subscribers[ch] = true
+	ch <- t.current()
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.subscribers[ch] {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// broadcast replaces each subscriber's pending snapshot with the current one (caller holds the lock)
+func (t *progressTracker) broadcast() {
+	snapshot := t.current()
+	for ch := range t.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
+
+// current returns the progress with its timing filled in (caller holds the lock)
+func (t *progressTracker) current() IndexingProgress {
+	p := t.progress
+	if p.StartedAt.IsZero() {
+		return p
+	}
+	now := time.Now()
+	p.Elapsed = now.Sub(p.StartedAt)
+	p.ETA = 0
+
+	// Estimate from the rate of the current phase once it has some history
+	phaseElapsed := now.Sub(t.phaseStart)
+	switch p.Phase {
+	case PhaseParsing:
+		p.ETA = estimateRemaining(phaseElapsed, p.PagesParsed, p.PagesScanned)
+	case PhaseEmbedding:
+		p.ETA = estimateRemaining(phaseElapsed, p.PagesEmbedded, p.PagesToEmbed)
+	}
+	return p
+}
+
+// estimateRemaining extrapolates the time left from the work done so far
+func estimateRemaining(elapsed time.Duration, done, total int) time.Duration {
+	if done < 10 || total <= done || elapsed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) / float64(done) * float64(total-done))
+}
+
+// IndexingProgress returns the progress of the running index build, and
+// whether one is running
+func (rs *RAGSystem) IndexingProgress() (IndexingProgress, bool) {
+	return rs.progress.snapshot()
+}
+
+// WatchIndexing streams the progress of the running index build. The channel
+// is closed when the build finishes; call the returned function to stop early.
+func (rs *RAGSystem) WatchIndexing() (<-chan IndexingProgress, func()) {
+	return rs.progress.subscribe()
+}
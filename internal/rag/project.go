@@ -0,0 +1,452 @@
+package rag
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Project document sections
+const (
+	projectTaskSection = "project-task"
+	projectDocSection  = "project-doc"
+)
+
+// Limits that keep /rag-add fast on large repositories
+const (
+	maxProjectDocFiles    = 200
+	maxProjectDocBytes    = 512 * 1024
+	maxProjectResults     = 3
+	projectResultMinScore = 0.5
+)
+
+// projectDocExtensions are the documentation files indexed from docs/
+var projectDocExtensions = map[string]bool{".md": true, ".markdown": true, ".txt": true, ".rst": true, ".adoc": true}
+
+// makeTargetPattern matches "target: deps" lines, but not "VAR := value"
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_.\-/ ]*?)\s*::?(?:[^=]|$)`)
+
+// markdownHeading matches "# Heading" lines
+var markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+
+// ProjectIndexSummary reports what /rag-add indexed
+type ProjectIndexSummary struct {
+	Root        string        `json:"root"`
+	DocFiles    int           `json:"doc_files"`
+	DocChunks   int           `json:"doc_chunks"`
+	MakeTargets int           `json:"make_targets"`
+	Scripts     int           `json:"scripts"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// projectTask is a runnable command the project defines
+type projectTask struct {
+	Command     string
+	Description string
+	Recipe      []string
+}
+
+// FindProjectRoot returns the repository root containing path, or path
+// itself when it is not inside a git repository
+func FindProjectRoot(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		absPath = filepath.Dir(absPath)
+	}
+
+	for dir := absPath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return absPath, nil
+		}
+	}
+}
+
+// projectIndexDir returns where a project's index is kept, outside the
+// repository so indexing never dirties the working tree
+func (rs *RAGSystem) projectIndexDir(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	name := fmt.Sprintf("%s-%s", filepath.Base(root), hex.EncodeToString(sum[:])[:8])
+	return filepath.Join(filepath.Dir(rs.indexDir), "projects", name)
+}
+
+// AddProject indexes a repository's README, docs/, Makefile targets and
+// package.json scripts into a vector store scoped to that repository
+func (rs *RAGSystem) AddProject(path string) (*ProjectIndexSummary, error) {
+	startTime := time.Now()
+
+	root, err := FindProjectRoot(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project path: %w", err)
+	}
+
+	summary := &ProjectIndexSummary{Root: root}
+	documents := make(map[string]VectorDocument)
+
+	for _, file := range projectDocFiles(root) {
+		chunks, err := projectDocChunks(file)
+		if err != nil || len(chunks) == 0 {
+			continue
+		}
+		relPath, _ := filepath.Rel(root, file)
+		for _, doc := range createProjectDocDocuments(relPath, chunks) {
+			documents[doc.ID] = doc
+		}
+		summary.DocFiles++
+		summary.DocChunks += len(chunks)
+	}
+
+	makeTargets := parseMakefileTargets(root)
+	scripts := parsePackageScripts(root)
+	summary.MakeTargets = len(makeTargets)
+	summary.Scripts = len(scripts)
+	for _, task := range append(makeTargets, scripts...) {
+		doc := createProjectTaskDocument(task)
+		documents[doc.ID] = doc
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("no README, docs/, Makefile or package.json scripts found in %s", root)
+	}
+
+	store := newVectorStoreAt(rs.projectIndexDir(root), rs.vectorStore.boostsPath)
+	store.retrieval = rs.RetrievalConfig()
+	if err := store.ReplaceAll(documents); err != nil {
+		return nil, err
+	}
+
+	rs.projectMu.Lock()
+	rs.project = store
+	rs.projectRoot = root
+	rs.projectMu.Unlock()
+
+	summary.Duration = time.Since(startTime)
+	return summary, nil
+}
+
+// projectStore returns the project index for the current directory, loading
+// it from disk when the user has changed into another indexed repository
+func (rs *RAGSystem) projectStore() *VectorStore {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	root, err := FindProjectRoot(cwd)
+	if err != nil {
+		return nil
+	}
+
+	rs.projectMu.Lock()
+	defer rs.projectMu.Unlock()
+
+	if rs.projectRoot == root {
+		return rs.project
+	}
+
+	store := newVectorStoreAt(rs.projectIndexDir(root), rs.vectorStore.boostsPath)
+	store.retrieval = rs.RetrievalConfig()
+	documents, _, err := store.readDocuments("")
+	if err != nil || len(documents) == 0 {
+		store = nil
+	} else {
+		store.documents = documents
+		store.rebuildIndex()
+		store.initialized = true
+	}
+
+	rs.project = store
+	rs.projectRoot = root
+	return store
+}
+
+// retrieveProject returns the project commands and documentation matching a query
+func (rs *RAGSystem) retrieveProject(query string) []CommandInfo {
+	store := rs.projectStore()
+	if store == nil {
+		return nil
+	}
+
+	scored, _ := store.ExplainSearch(query, maxProjectResults*3)
+
+	var order []string
+	grouped := make(map[string]*CommandInfo)
+	for _, result := range scored {
+		if result.Score < projectResultMinScore {
+			continue
+		}
+		doc := result.Document
+		info, exists := grouped[doc.Metadata.Command]
+		if !exists {
+			if len(order) >= maxProjectResults {
+				continue
+			}
+			info = &CommandInfo{Name: doc.Metadata.Command, Source: "project"}
+			grouped[doc.Metadata.Command] = info
+			order = append(order, doc.Metadata.Command)
+		}
+
+		switch doc.Metadata.Section {
+		case projectTaskSection:
+			info.Description = doc.Metadata.Description
+			info.Examples = doc.Metadata.Examples
+		case projectDocSection:
+			if info.Description == "" {
+				info.Description = "Project documentation"
+			}
+			if len(info.Passages) < maxPassagesPerResult {
+				info.Passages = append(info.Passages, formatPassage(doc))
+			}
+		}
+	}
+
+	var results []CommandInfo
+	for _, name := range order {
+		results = append(results, *grouped[name])
+	}
+	return results
+}
+
+// projectDocFiles lists the README files at the root and the files under docs/
+func projectDocFiles(root string) []string {
+	var files []string
+
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(strings.ToUpper(entry.Name()), "README") {
+			files = append(files, filepath.Join(root, entry.Name()))
+		}
+	}
+
+	for _, dirName := range []string{"docs", "doc"} {
+		dir := filepath.Join(root, dirName)
+		filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if len(files) >= maxProjectDocFiles {
+				return filepath.SkipAll
+			}
+			if projectDocExtensions[strings.ToLower(filepath.Ext(path))] {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+
+	return files
+}
+
+// projectDocChunks splits a documentation file into chunks per heading
+func projectDocChunks(path string) ([]SectionChunk, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxProjectDocBytes {
+		return nil, fmt.Errorf("%s is too large to index", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []SectionChunk
+	heading := filepath.Base(path)
+	var body strings.Builder
+
+	flush := func() {
+		for _, chunk := range chunkSection(heading, body.String()) {
+			chunk.Index = len(chunks)
+			chunks = append(chunks, chunk)
+		}
+		body.Reset()
+	}
+
+	inFence := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if match := markdownHeading.FindStringSubmatch(line); match != nil && !inFence {
+			flush()
+			heading = match[1]
+			continue
+		}
+		body.WriteString(line + "\n")
+	}
+	flush()
+
+	return chunks, nil
+}
+
+// parseMakefileTargets reads the targets of the root Makefile, described by
+// the comment above them or a trailing "## help" comment
+func parseMakefileTargets(root string) []projectTask {
+	var file *os.File
+	for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+		if f, err := os.Open(filepath.Join(root, name)); err == nil {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+
+	var tasks []projectTask
+	var comments []string
+	var current []int // indexes of tasks receiving recipe lines
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			recipe := strings.TrimLeft(strings.TrimSpace(line), "@-+")
+			for _, i := range current {
+				if recipe != "" && len(tasks[i].Recipe) < 5 {
+					tasks[i].Recipe = append(tasks[i].Recipe, recipe)
+				}
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		}
+
+		current = nil
+		match := makeTargetPattern.FindStringSubmatch(line)
+		if match == nil {
+			comments = nil
+			continue
+		}
+
+		description := strings.Join(comments, " ")
+		if _, help, found := strings.Cut(line, "##"); found {
+			description = strings.TrimSpace(help)
+		}
+		comments = nil
+
+		for _, target := range strings.Fields(match[1]) {
+			// Special (.PHONY), pattern (%.o) and variable targets are not commands
+			if strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$") {
+				continue
+			}
+			current = append(current, len(tasks))
+			tasks = append(tasks, projectTask{Command: "make " + target, Description: description})
+		}
+	}
+
+	return tasks
+}
+
+// parsePackageScripts reads the scripts of the root package.json, run with
+// the package manager the lockfile points to
+func parsePackageScripts(root string) []projectTask {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var manifest struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+
+	runner := "npm run"
+	if _, err := os.Stat(filepath.Join(root, "pnpm-lock.yaml")); err == nil {
+		runner = "pnpm run"
+	} else if _, err := os.Stat(filepath.Join(root, "yarn.lock")); err == nil {
+		runner = "yarn run"
+	}
+
+	names := make([]string, 0, len(manifest.Scripts))
+	for name := range manifest.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []projectTask
+	for _, name := range names {
+		tasks = append(tasks, projectTask{
+			Command:     fmt.Sprintf("%s %s", runner, name),
+			Description: fmt.Sprintf("package.json script: %s", manifest.Scripts[name]),
+			Recipe:      []string{manifest.Scripts[name]},
+		})
+	}
+	return tasks
+}
+
+// createProjectTaskDocument creates a document for a Makefile target or script
+func createProjectTaskDocument(task projectTask) VectorDocument {
+	// Split names such as "test-integration" so each word is searchable
+	fields := strings.Fields(task.Command)
+	words := strings.Join(nameTokens(fields[len(fields)-1]), " ")
+	content := fmt.Sprintf("%s (%s): %s %s", task.Command, words, task.Description, strings.Join(task.Recipe, " "))
+
+	description := task.Description
+	if description == "" && len(task.Recipe) > 0 {
+		description = "runs: " + strings.Join(task.Recipe, "; ")
+	}
+	if description == "" {
+		description = "project task"
+	}
+
+	return VectorDocument{
+		ID:      fmt.Sprintf("task-%s", task.Command),
+		Content: content,
+		Metadata: Metadata{
+			Command:     task.Command,
+			Section:     projectTaskSection,
+			Description: description,
+			Examples:    []string{task.Command},
+		},
+	}
+}
+
+// createProjectDocDocuments creates one document per chunk of a documentation file
+func createProjectDocDocuments(relPath string, chunks []SectionChunk) []VectorDocument {
+	var documents []VectorDocument
+	for _, chunk := range chunks {
+		documents = append(documents, VectorDocument{
+			ID:      fmt.Sprintf("doc-%s-%d", relPath, chunk.Index),
+			Content: chunk.Text,
+			Metadata: Metadata{
+				Command:      relPath,
+				Section:      projectDocSection,
+				ChunkSection: chunk.Section,
+				ChunkIndex:   chunk.Index,
+			},
+		})
+	}
+	return documents
+}
@@ -0,0 +1,135 @@
+package rag
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// shellBuiltins are documented commands that run inside the shell, so they
+// are available without being on PATH
+var shellBuiltins = map[string]bool{
+	"alias": true, "unalias": true, "export": true, "unset": true, "source": true, "history": true,
+	"type": true, "set": true, "shopt": true, "ulimit": true, "umask": true, "fc": true, "bind": true,
+	"complete": true, "compgen": true, "dirs": true, "pushd": true, "popd": true, "wait": true,
+	"times": true, "disown": true, "suspend": true, "cd": true, "jobs": true, "fg": true, "bg": true,
+	"read": true, "eval": true, "exec": true, "trap": true, "declare": true, "local": true,
+	"let": true, "hash": true, "builtin": true, "command": true, "help": true, "echo": true,
+	"printf": true, "test": true, "kill": true, "exit": true,
+}
+
+// toolMentions are other words a query may use for a tool
+var toolMentions = map[string][]string{
+	"kubectl":   {"kube", "k8s"},
+	"docker":    {"container"},
+	"systemctl": {"systemd", "service"},
+}
+
+// toolCache remembers which tools are installed for the session
+type toolCache struct {
+	mu        sync.Mutex
+	installed map[string]bool
+}
+
+// has reports whether a tool is installed, checking PATH once per tool
+func (c *toolCache) has(tool string, lookup func(string) bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if installed, ok := c.installed[tool]; ok {
+		return installed
+	}
+	if c.installed == nil {
+		c.installed = make(map[string]bool)
+	}
+	c.installed[tool] = lookup(tool)
+	return c.installed[tool]
+}
+
+// clear forgets every lookup, e.g. after packages were installed or removed
+func (c *toolCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.installed = nil
+}
+
+// isRelevantCommand keeps a retrieved command only when the tool it runs is
+// installed here and, for a subcommand page like git-commit, the query
+// mentions that tool
+func (rs *RAGSystem) isRelevantCommand(query string, cmd CommandInfo) bool {
+	// Expanded, so "delete" counts as asking to remove
+	queryLower := strings.ToLower(rs.vectorStore.analyzer.ExpandQuery(query))
+	cmdLower := strings.ToLower(cmd.Name)
+
+	// Filter out commands with very low relevance
+	if cmd.Description == "" || len(cmd.Description) < 10 {
+		return false
+	}
+
+	// Filter out dangerous commands for safe queries
+	if (cmdLower == "killall" || cmdLower == "rm") &&
+		!strings.Contains(queryLower, "kill") && !strings.Contains(queryLower, "remove") {
+		return false
+	}
+
+	tool, ok := rs.commandTool(cmdLower)
+	if !ok {
+		return false
+	}
+	return tool == cmdLower || mentionsTool(queryLower, tool)
+}
+
+// commandTool returns the installed tool a documented command runs: the
+// command itself, or the tool of a subcommand page (git-commit runs git).
+// It reports false when neither is available.
+func (rs *RAGSystem) commandTool(command string) (string, bool) {
+	if rs.toolInstalled(command) {
+		return command, true
+	}
+	if tool, _, found := strings.Cut(command, "-"); found && tool != "" && rs.toolInstalled(tool) {
+		return tool, true
+	}
+	return "", false
+}
+
+// toolInstalled reports whether a command can run here: a shell builtin, a
+// PowerShell cmdlet with indexed help, or an executable on PATH
+func (rs *RAGSystem) toolInstalled(tool string) bool {
+	if shellBuiltins[tool] {
+		return true
+	}
+	return rs.tools.has(tool, func(name string) bool {
+		if rs.usesPowerShellHelp() {
+			if _, ok := rs.powershell.GetPage(name); ok {
+				return true
+			}
+		}
+		if rs.toolLookup != nil {
+			return rs.toolLookup(name)
+		}
+		_, err := exec.LookPath(name)
+		return err == nil
+	})
+}
+
+// SetToolLookup makes retrieval keep commands that lookup finds instead of
+// those on the local PATH, e.g. the tools of an /ssh host; nil restores the
+// local check
+func (rs *RAGSystem) SetToolLookup(lookup func(string) bool) {
+	rs.toolLookup = lookup
+	rs.tools.clear()
+	rs.cache.clear()
+}
+
+// mentionsTool reports whether a query names a tool or one of its usual aliases
+func mentionsTool(queryLower, tool string) bool {
+	if strings.Contains(queryLower, tool) {
+		return true
+	}
+	for _, alias := range toolMentions[tool] {
+		if strings.Contains(queryLower, alias) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// rerankCandidates is how many lexical results the model is asked to score
+	rerankCandidates = 10
+	// maxRerankDescription keeps the batched prompt within the model's context
+	maxRerankDescription = 100
+)
+
+// rerankScorePattern matches the "2=7" pairs of a rerank answer
+var rerankScorePattern = regexp.MustCompile(`(\d+)\s*[=:]\s*(\d+)`)
+
+// Reranker runs a prompt through the local model and returns its answer
+type Reranker func(prompt string) (string, error)
+
+// SetReranker sets the model used to rerank candidates when reranking is
+// enabled in the retrieval settings
+func (rs *RAGSystem) SetReranker(reranker Reranker) {
+	rs.reranker = reranker
+	rs.ClearRetrievalCache()
+}
+
+// reranking reports whether retrieval asks the model to rerank candidates
+func (rs *RAGSystem) reranking() bool {
+	return rs.reranker != nil && rs.RetrievalConfig().Rerank
+}
+
+// rerank orders candidates by the model's relevance scores for a query,
+// asking for all of them in one prompt. Unscored candidates keep their
+// lexical order after the scored ones; if the model fails, the lexical
+// order is kept.
+func (rs *RAGSystem) rerank(query string, candidates []CommandInfo) []CommandInfo {
+	if len(candidates) < 2 {
+		return candidates
+	}
+
+	answer, err := rs.reranker(buildRerankPrompt(query, candidates))
+	if err != nil {
+		color.Yellow("⚠️  Reranking skipped: %v", err)
+		return candidates
+	}
+	scores := parseRerankScores(answer, len(candidates))
+	if len(scores) == 0 {
+		color.Yellow("⚠️  Reranking skipped: no scores in the model's answer")
+		return candidates
+	}
+
+	ranked := make([]CommandInfo, len(candidates))
+	copy(ranked, candidates)
+	order := make(map[string]int)
+	for i, cmd := range candidates {
+		order[cmd.Name] = i
+	}
+	score := func(cmd CommandInfo) int {
+		if s, ok := scores[order[cmd.Name]]; ok {
+			return s
+		}
+		return -1
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return score(ranked[i]) > score(ranked[j])
+	})
+
+	color.Cyan("🎯 Reranked %d candidates with the local model", len(candidates))
+	return ranked
+}
+
+// buildRerankPrompt lists the candidates with their descriptions and asks
+// for one relevance score per candidate
+func buildRerankPrompt(query string, candidates []CommandInfo) string {
+	var sb strings.Builder
+	sb.WriteString("Rate how relevant each command is to the request, from 0 (unrelated) to 9 (exactly what is needed).\n\n")
+	sb.WriteString(fmt.Sprintf("Request: %s\n\nCommands:\n", query))
+	for i, cmd := range candidates {
+		description := cmd.Description
+		if len(description) > maxRerankDescription {
+			description = strings.TrimSpace(description[:maxRerankDescription]) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, cmd.Name, description))
+	}
+	sb.WriteString("\nAnswer on one line with number=score pairs, e.g. 1=7 2=0 3=4\nScores:")
+	return sb.String()
+}
+
+// parseRerankScores reads the scores of a rerank answer, keyed by
+// candidate index. Out of range numbers are ignored.
+func parseRerankScores(answer string, count int) map[int]int {
+	scores := make(map[int]int)
+	for _, match := range rerankScorePattern.FindAllStringSubmatch(answer, -1) {
+		number, _ := strconv.Atoi(match[1])
+		score, _ := strconv.Atoi(match[2])
+		if number < 1 || number > count || score > 9 {
+			continue
+		}
+		if _, seen := scores[number-1]; !seen {
+			scores[number-1] = score
+		}
+	}
+	return scores
+}
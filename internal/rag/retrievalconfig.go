@@ -0,0 +1,267 @@
+package rag
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// minMemoryMB is the smallest memory budget; below it paging would read
+// most results from disk
+const minMemoryMB = 4
+
+// Retrieval sources that can be weighted
+const (
+	SourceMan     = "man"
+	SourceTLDR    = "tldr"
+	SourceHelp    = "help" // PowerShell help on Windows
+	SourceHistory = "history"
+	SourceProject = "project"
+)
+
+// RetrievalConfig tunes how many results retrieval returns and how sources rank
+type RetrievalConfig struct {
+	TopK          int                `json:"top_k"`          // commands taken from search per query
+	MinScore      float64            `json:"min_score"`      // documents scoring below this are ignored
+	SourceWeights map[string]float64 `json:"source_weights"` // score multiplier per source; 0 disables a source
+
+	DisabledSources []string `json:"disabled_sources,omitempty"` // sources left out of the index and of results
+	Rerank          bool     `json:"rerank,omitempty"`           // let the local model reorder the top candidates
+	MemoryMB        int      `json:"memory_mb,omitempty"`        // cap on document text kept in memory; 0 is unlimited
+}
+
+// DefaultRetrievalConfig returns the retrieval settings Helix ships with
+func DefaultRetrievalConfig() RetrievalConfig {
+	return RetrievalConfig{
+		TopK:     3,
+		MinScore: 0.1,
+		SourceWeights: map[string]float64{
+			SourceMan:     1.0,
+			SourceTLDR:    1.0,
+			SourceHelp:    1.0,
+			SourceHistory: 1.0,
+			SourceProject: 1.0,
+		},
+	}
+}
+
+// withDefaults fills unset values, e.g. from an older config file
+func (c RetrievalConfig) withDefaults() RetrievalConfig {
+	defaults := DefaultRetrievalConfig()
+	if c.TopK <= 0 {
+		c.TopK = defaults.TopK
+	}
+	if c.MinScore <= 0 {
+		c.MinScore = defaults.MinScore
+	}
+
+	weights := make(map[string]float64)
+	for source, weight := range defaults.SourceWeights {
+		weights[source] = weight
+	}
+	for source, weight := range c.SourceWeights {
+		weights[source] = weight
+	}
+	c.SourceWeights = weights
+	return c
+}
+
+// Enabled reports whether a source is indexed and searched
+func (c RetrievalConfig) Enabled(source string) bool {
+	for _, disabled := range c.DisabledSources {
+		if disabled == source {
+			return false
+		}
+	}
+	return true
+}
+
+// Weight returns the score multiplier of a source; disabled sources weigh 0
+func (c RetrievalConfig) Weight(source string) float64 {
+	if !c.Enabled(source) {
+		return 0
+	}
+	if weight, ok := c.SourceWeights[source]; ok {
+		return weight
+	}
+	return 1.0
+}
+
+// Set returns a copy of the configuration with one setting changed. Keys are
+// top_k, min_score, rerank (on or off), memory_mb, weight.<source> and
+// source.<source> (on or off).
+func (c RetrievalConfig) Set(key, value string) (RetrievalConfig, error) {
+	c = c.withDefaults()
+
+	switch {
+	case key == "top_k":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 20 {
+			return c, fmt.Errorf("top_k must be a whole number between 1 and 20")
+		}
+		c.TopK = n
+	case key == "min_score":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f <= 0 {
+			return c, fmt.Errorf("min_score must be a positive number")
+		}
+		c.MinScore = f
+	case key == "rerank":
+		enable, err := parseSwitch(value)
+		if err != nil {
+			return c, fmt.Errorf("rerank is turned on or off")
+		}
+		c.Rerank = enable
+	case key == "memory_mb":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || (n > 0 && n < minMemoryMB) {
+			return c, fmt.Errorf("memory_mb must be 0 (unlimited) or at least %d", minMemoryMB)
+		}
+		c.MemoryMB = n
+	case strings.HasPrefix(key, "weight."):
+		source := strings.TrimPrefix(key, "weight.")
+		if !isKnownSource(source) {
+			return c, fmt.Errorf("unknown source %q (known: %s)", source, strings.Join(RetrievalSources(), ", "))
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 {
+			return c, fmt.Errorf("weights must be zero or positive numbers")
+		}
+		weights := make(map[string]float64)
+		for s, w := range c.SourceWeights {
+			weights[s] = w
+		}
+		weights[source] = f
+		c.SourceWeights = weights
+	case strings.HasPrefix(key, "source."):
+		source := strings.TrimPrefix(key, "source.")
+		if !isKnownSource(source) {
+			return c, fmt.Errorf("unknown source %q (known: %s)", source, strings.Join(RetrievalSources(), ", "))
+		}
+		enable, err := parseSwitch(value)
+		if err != nil {
+			return c, fmt.Errorf("sources are turned on or off")
+		}
+		var disabled []string
+		for _, s := range c.DisabledSources {
+			if s != source {
+				disabled = append(disabled, s)
+			}
+		}
+		if !enable {
+			disabled = append(disabled, source)
+			sort.Strings(disabled)
+		}
+		c.DisabledSources = disabled
+	default:
+		return c, fmt.Errorf("unknown setting %q (use top_k, min_score, rerank, memory_mb, weight.<source> or source.<source>)", key)
+	}
+	return c, nil
+}
+
+// parseSwitch reads an on/off setting value
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "enabled":
+		return true, nil
+	case "off", "false", "disabled":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off, got %q", value)
+}
+
+// RetrievalSources returns the names of the built-in and registered sources
+func RetrievalSources() []string {
+	var sources []string
+	for source := range DefaultRetrievalConfig().SourceWeights {
+		sources = append(sources, source)
+	}
+	sources = append(sources, RegisteredSources()...)
+	sort.Strings(sources)
+	return sources
+}
+
+// isKnownSource reports whether a source is built in or registered
+func isKnownSource(source string) bool {
+	for _, known := range RetrievalSources() {
+		if known == source {
+			return true
+		}
+	}
+	return false
+}
+
+// documentSource returns which source a document came from
+func (vs *VectorStore) documentSource(doc VectorDocument) string {
+	if doc.Metadata.Source != "" {
+		return doc.Metadata.Source
+	}
+	// Documents indexed before sources were recorded are told apart by section
+	switch doc.Metadata.Section {
+	case "tldr":
+		return SourceTLDR
+	case historySection:
+		return SourceHistory
+	case projectTaskSection, projectDocSection:
+		return SourceProject
+	}
+	return vs.pageSource
+}
+
+// SetRetrievalConfig changes the retrieval settings of the store
+func (vs *VectorStore) SetRetrievalConfig(config RetrievalConfig) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.retrieval = config.withDefaults()
+	vs.generation++
+}
+
+// applySourceWeights scales scores by the weight of each document's source
+// (caller holds the lock)
+func (vs *VectorStore) applySourceWeights(scores map[string]float64) {
+	for docID := range scores {
+		if doc, exists := vs.documents[docID]; exists {
+			scores[docID] *= vs.retrieval.Weight(vs.documentSource(doc))
+		}
+	}
+}
+
+// scoreDocuments ranks documents for a query: BM25 and configured boosts over
+// the expanded query, flag boosts, source weights, then weights learned from
+// feedback. When matched is non-nil it records the matched terms. (caller holds the lock)
+func (vs *VectorStore) scoreDocuments(query string, matched map[string][]string) map[string]float64 {
+	expanded := vs.analyzer.ExpandQuery(query)
+	scores := vs.bm25Scores(expanded, matched)
+	vs.applyBoosts(expanded, scores)
+	vs.applyFlagBoosts(query, scores)
+	vs.applySourceWeights(scores)
+	vs.applyFeedbackWeights(scores)
+	return scores
+}
+
+// SetRetrievalConfig applies retrieval settings to every collection
+func (rs *RAGSystem) SetRetrievalConfig(config RetrievalConfig) {
+	config = config.withDefaults()
+	rs.vectorStore.SetRetrievalConfig(config)
+	rs.vectorStore.SetMemoryBudget(int64(config.MemoryMB) << 20)
+
+	rs.projectMu.Lock()
+	if rs.project != nil {
+		rs.project.SetRetrievalConfig(config)
+	}
+	rs.projectMu.Unlock()
+
+	rs.historyMu.Lock()
+	if rs.history != nil {
+		rs.history.store.SetRetrievalConfig(config)
+	}
+	rs.historyMu.Unlock()
+}
+
+// RetrievalConfig returns the active retrieval settings
+func (rs *RAGSystem) RetrievalConfig() RetrievalConfig {
+	rs.vectorStore.mu.RLock()
+	defer rs.vectorStore.mu.RUnlock()
+	return rs.vectorStore.retrieval
+}
@@ -0,0 +1,60 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchCandidateFactor is how many documents are ranked per requested
+// command, since one command usually has several matching documents
+const searchCandidateFactor = 5
+
+// SearchHit is one command found by a direct search of the index
+type SearchHit struct {
+	Command     string  `json:"command"`
+	Score       float64 `json:"score"` // score of the command's best matching document
+	Description string  `json:"description"`
+	Example     string  `json:"example,omitempty"`
+	Section     string  `json:"section"` // section of the best matching document
+}
+
+// SearchCommands ranks indexed commands for a natural language query without
+// calling the model. Each command appears once, scored by its best document.
+func (rs *RAGSystem) SearchCommands(query string, limit int) ([]SearchHit, error) {
+	if !rs.initialized {
+		return nil, fmt.Errorf("RAG system not initialized")
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	scored, _ := rs.vectorStore.ExplainSearch(query, limit*searchCandidateFactor)
+
+	var hits []SearchHit
+	seen := make(map[string]bool)
+	for _, result := range scored {
+		doc := result.Document
+		command := doc.Metadata.Command
+		if command == "" || seen[command] {
+			continue
+		}
+		seen[command] = true
+
+		hit := SearchHit{Command: command, Score: result.Score, Section: doc.Metadata.Section}
+		if info, err := rs.vectorStore.GetCommandInfo(command); err == nil {
+			hit.Description = info.Description
+			if len(info.Examples) > 0 {
+				// tldr examples end with "  # what it does", which the description covers
+				hit.Example = strings.SplitN(info.Examples[0], "  # ", 2)[0]
+			}
+		} else {
+			hit.Description = doc.Metadata.Description
+		}
+
+		hits = append(hits, hit)
+		if len(hits) >= limit {
+			break
+		}
+	}
+	return hits, nil
+}
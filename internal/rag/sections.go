@@ -0,0 +1,201 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Document sections for the reference parts of a MAN page
+const (
+	exitStatusSection  = "exit_status"
+	filesSection       = "files"
+	environmentSection = "environment"
+)
+
+// Limits for reference section entries
+const (
+	maxSectionEntries = 15
+	maxEntryLength    = 300
+	maxExitStatusHint = 3
+)
+
+// referenceHeaders maps each reference document section to its MAN page header
+var referenceHeaders = map[string]string{
+	exitStatusSection:  "EXIT STATUS",
+	filesSection:       "FILES",
+	environmentSection: "ENVIRONMENT",
+}
+
+// referenceLabels open the content of each reference document, so questions
+// phrased like "config file" or "environment variable" match it
+var referenceLabels = map[string]string{
+	exitStatusSection:  "exit status codes",
+	filesSection:       "configuration files",
+	environmentSection: "environment variables",
+}
+
+// Each entry of a reference section starts with an exit code, a path or a variable name
+var (
+	exitStatusEntryPattern  = regexp.MustCompile(`(?i)^(\d+|>\s*0|non-?zero)\b`)
+	filesEntryPattern       = regexp.MustCompile(`^(~|/|\$[A-Z]|\.\w)`)
+	environmentEntryPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+\b`)
+)
+
+// extractEntries splits a section into entries. An entry ends at a blank line
+// or where the next one starts; its continuation lines are joined to it.
+func extractEntries(content string, start *regexp.Regexp) []string {
+	var entries []string
+	var current strings.Builder
+
+	flush := func() {
+		entry := current.String()
+		current.Reset()
+		if entry == "" || len(entries) >= maxSectionEntries {
+			return
+		}
+		if len(entry) > maxEntryLength {
+			entry = entry[:maxEntryLength] + "..."
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			flush()
+			continue
+		}
+		if start.MatchString(line) {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(" ")
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return entries
+}
+
+// extractInlineExitStatus finds the "Exit status:" block GNU tools keep
+// inside DESCRIPTION instead of a section of its own
+func extractInlineExitStatus(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if !strings.EqualFold(strings.TrimSpace(line), "exit status:") {
+			continue
+		}
+
+		var block []string
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) == "" {
+				if len(block) > 0 {
+					break
+				}
+				continue
+			}
+			block = append(block, next)
+		}
+		return extractEntries(strings.Join(block, "\n"), exitStatusEntryPattern)
+	}
+	return nil
+}
+
+// createReferenceDocuments creates the EXIT STATUS, FILES and ENVIRONMENT documents of a MAN page
+func (vs *VectorStore) createReferenceDocuments(page MANPage) []VectorDocument {
+	return []VectorDocument{
+		vs.createReferenceDocument(page, exitStatusSection, page.ExitStatus),
+		vs.createReferenceDocument(page, filesSection, page.Files),
+		vs.createReferenceDocument(page, environmentSection, page.Environment),
+	}
+}
+
+// createReferenceDocument creates a document from the entries of one reference section
+func (vs *VectorStore) createReferenceDocument(page MANPage, section string, entries []string) VectorDocument {
+	if len(entries) == 0 {
+		return VectorDocument{}
+	}
+
+	content := fmt.Sprintf("%s of %s: %s", referenceLabels[section], page.Name, strings.Join(entries, " | "))
+	if section == filesSection {
+		// "/etc/ssh/sshd_config" is one token, so its parts are added for "sshd_config" or "sshd config"
+		content += " " + strings.Join(pathTerms(entries), " ")
+	}
+
+	return VectorDocument{
+		ID:      fmt.Sprintf("%s-%s", page.Name, section),
+		Content: content,
+		Metadata: Metadata{
+			Command:  page.Name,
+			Section:  section,
+			Entries:  entries,
+			Language: page.Language,
+		},
+	}
+}
+
+// pathTerms returns the directory and file names of the paths FILES entries start with
+func pathTerms(entries []string) []string {
+	var terms []string
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 || !filesEntryPattern.MatchString(fields[0]) {
+			continue
+		}
+		for _, part := range strings.Split(fields[0], "/") {
+			for _, term := range append([]string{part}, nameTokens(part)...) {
+				if term != "" && !containsString(terms, term) {
+					terms = append(terms, term)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// isPassageSection reports whether matching documents of a section are shown as passages
+func isPassageSection(section string) bool {
+	_, reference := referenceHeaders[section]
+	return section == "chunk" || reference
+}
+
+// ExitStatusMeanings returns what a command's MAN page says about an exit
+// code: the entries for that code, else those for any failure, else the
+// section's prose
+func (rs *RAGSystem) ExitStatusMeanings(command string, code int) []string {
+	if !rs.initialized {
+		return nil
+	}
+	info, err := rs.vectorStore.GetCommandInfo(command)
+	if err != nil || len(info.ExitStatus) == 0 {
+		return nil
+	}
+
+	var exact, failure, prose []string
+	for _, entry := range info.ExitStatus {
+		prefix := strings.ToLower(strings.ReplaceAll(exitStatusEntryPattern.FindString(entry), " ", ""))
+		switch {
+		case prefix == strconv.Itoa(code):
+			exact = append(exact, entry)
+		case prefix == ">0" || strings.HasPrefix(prefix, "non"):
+			if code != 0 {
+				failure = append(failure, entry)
+			}
+		case prefix == "":
+			prose = append(prose, entry)
+		}
+	}
+
+	for _, meanings := range [][]string{exact, failure, prose} {
+		if len(meanings) > 0 {
+			if len(meanings) > maxExitStatusHint {
+				meanings = meanings[:maxExitStatusHint]
+			}
+			return meanings
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package rag
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+)
+
+// DocumentSource supplies documents to the index from outside the MAN page
+// pipeline, e.g. tldr pages, --help output, project docs or a custom URL.
+// Sources are synced on initialization and reindex; a source's documents are
+// replaced with what it returns, so commands it no longer lists are dropped.
+type DocumentSource interface {
+	// Name identifies the source in config keys and search weights
+	Name() string
+	// Scan returns the commands or topics the source currently has documents for
+	Scan() ([]string, error)
+	// Fetch returns the documents of one scanned name
+	Fetch(name string) ([]VectorDocument, error)
+}
+
+var registeredSources struct {
+	mu      sync.RWMutex
+	sources []DocumentSource
+}
+
+// RegisterSource adds a document source to every RAG system. Sources are
+// synced in the order they were registered, after the built-in ones.
+func RegisterSource(src DocumentSource) error {
+	name := src.Name()
+	if name == "" {
+		return fmt.Errorf("document source needs a name")
+	}
+	if isBuiltinSource(name) {
+		return fmt.Errorf("document source %q is built in", name)
+	}
+
+	registeredSources.mu.Lock()
+	defer registeredSources.mu.Unlock()
+	for _, existing := range registeredSources.sources {
+		if existing.Name() == name {
+			return fmt.Errorf("document source %q is already registered", name)
+		}
+	}
+	registeredSources.sources = append(registeredSources.sources, src)
+	return nil
+}
+
+// RegisteredSources returns the names of the sources added with RegisterSource
+func RegisteredSources() []string {
+	registeredSources.mu.RLock()
+	defer registeredSources.mu.RUnlock()
+
+	var names []string
+	for _, src := range registeredSources.sources {
+		names = append(names, src.Name())
+	}
+	return names
+}
+
+// isBuiltinSource reports whether a name belongs to a source Helix ships with
+func isBuiltinSource(name string) bool {
+	_, builtin := DefaultRetrievalConfig().SourceWeights[name]
+	return builtin
+}
+
+// documentSources returns the built-in document sources followed by the registered ones
+func (rs *RAGSystem) documentSources() []DocumentSource {
+	sources := []DocumentSource{&tldrSource{indexer: rs.tldr, store: rs.vectorStore}}
+
+	registeredSources.mu.RLock()
+	defer registeredSources.mu.RUnlock()
+	return append(sources, registeredSources.sources...)
+}
+
+// fetchSourceDocuments scans a source and fetches the documents of every
+// name it lists, tagging each with the source. Names that fail to fetch are skipped.
+func fetchSourceDocuments(src DocumentSource) ([]VectorDocument, error) {
+	names, err := src.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []VectorDocument
+	for _, name := range names {
+		docs, err := src.Fetch(name)
+		if err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			if doc.ID == "" || doc.Content == "" {
+				continue
+			}
+			doc.Metadata.Source = src.Name()
+			documents = append(documents, doc)
+		}
+	}
+	return documents, nil
+}
+
+// syncSources brings the documents of every document source up to date and
+// removes those of disabled sources. It returns how many documents changed
+// per source; sources without changes are left out.
+func (rs *RAGSystem) syncSources() map[string]int {
+	config := rs.RetrievalConfig()
+	changes := make(map[string]int)
+
+	for _, src := range rs.documentSources() {
+		name := src.Name()
+		if !config.Enabled(name) {
+			if removed := rs.vectorStore.ReplaceSourceDocuments(name, nil); removed > 0 {
+				color.Yellow("🚫 Removed %d documents of disabled source %s", removed, name)
+				changes[name] = removed
+			}
+			continue
+		}
+
+		documents, err := fetchSourceDocuments(src)
+		if err != nil {
+			color.Yellow("⚠️  %s documents unavailable: %v", name, err)
+			continue
+		}
+		if len(documents) == 0 {
+			// Keep what was indexed before rather than emptying the source on a bad scan
+			continue
+		}
+		if changed := rs.vectorStore.ReplaceSourceDocuments(name, documents); changed > 0 {
+			color.Green("📗 Updated %d %s documents", changed, name)
+			changes[name] = changed
+		}
+	}
+	return changes
+}
+
+// sourceDocuments returns the current documents of every enabled document
+// source, for a full rebuild
+func (rs *RAGSystem) sourceDocuments() []VectorDocument {
+	config := rs.RetrievalConfig()
+
+	var documents []VectorDocument
+	for _, src := range rs.documentSources() {
+		if !config.Enabled(src.Name()) {
+			continue
+		}
+		docs, err := fetchSourceDocuments(src)
+		if err != nil {
+			color.Yellow("⚠️  %s documents unavailable: %v", src.Name(), err)
+			continue
+		}
+		documents = append(documents, docs...)
+	}
+	return documents
+}
+
+// ReplaceSourceDocuments makes the given documents the only ones of a source,
+// rewriting just the documents that changed. It returns how many documents
+// were added, changed or removed. The caller persists the index afterwards.
+func (vs *VectorStore) ReplaceSourceDocuments(source string, docs []VectorDocument) int {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	// Stored bodies are compared, reading paged out ones back in one pass
+	var existing []VectorDocument
+	for _, doc := range docs {
+		if stored, ok := vs.documents[doc.ID]; ok {
+			existing = append(existing, stored)
+		}
+	}
+	stored := make(map[string]VectorDocument)
+	for _, doc := range vs.fullDocuments(existing) {
+		stored[doc.ID] = doc
+	}
+
+	keep := make(map[string]bool)
+	changed := 0
+	for _, doc := range docs {
+		doc.Metadata.Source = source
+		keep[doc.ID] = true
+		if old, ok := stored[doc.ID]; ok && sameDocument(old, doc) {
+			continue
+		}
+		vs.putDocument(doc)
+		changed++
+	}
+
+	for docID, doc := range vs.documents {
+		if !keep[docID] && vs.documentSource(doc) == source {
+			vs.deleteDocument(docID)
+			changed++
+		}
+	}
+
+	if changed > 0 {
+		vs.rebuildIndex()
+	}
+	vs.initialized = len(vs.documents) > 0
+	return changed
+}
+
+// sameDocument reports whether a stored document already has a new one's content
+func sameDocument(a, b VectorDocument) bool {
+	return a.Content == b.Content && reflect.DeepEqual(a.Metadata, b.Metadata)
+}
+
+// tldrSource serves practical examples from tldr-pages. Pages are downloaded
+// when online and the cache is stale, otherwise cached pages are reused.
+type tldrSource struct {
+	indexer *TLDRIndexer
+	store   *VectorStore
+}
+
+func (s *tldrSource) Name() string {
+	return SourceTLDR
+}
+
+func (s *tldrSource) Scan() ([]string, error) {
+	if s.indexer.GetPageCount() == 0 {
+		s.indexer.LoadCache()
+	}
+
+	if s.indexer.NeedsRefresh() {
+		if utils.IsOnline(3 * time.Second) {
+			if err := s.indexer.Download(); err != nil {
+				color.Yellow("⚠️  tldr pages unavailable: %v", err)
+			}
+		} else {
+			color.Yellow("📴 Offline - skipping tldr pages download")
+		}
+	}
+
+	var names []string
+	for _, page := range s.indexer.GetAllPages() {
+		names = append(names, page.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *tldrSource) Fetch(name string) ([]VectorDocument, error) {
+	page, ok := s.indexer.GetPage(name)
+	if !ok {
+		return nil, fmt.Errorf("no tldr page for %s", name)
+	}
+	if doc := s.store.createTLDRDocument(page); doc.Content != "" {
+		return []VectorDocument{doc}, nil
+	}
+	return nil, nil
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"helix/internal/shell"
@@ -20,7 +23,7 @@ import (
 const (
 	stateFileName   = "rag_state.json"
 	indexVersion    = "1.0"
-	maxIndexingTime = 5 * time.Minute // Increased from 2 minutes to 5 minutes
+	maxIndexingTime = 5 * time.Minute // first-run limit with six or more workers
 )
 
 // SystemState tracks RAG system persistence
@@ -34,12 +37,28 @@ type SystemState struct {
 
 // RAGSystem orchestrates the complete RAG pipeline
 type RAGSystem struct {
-	env         shell.Env
-	indexer     *MANIndexer
-	vectorStore *VectorStore
-	initialized bool
-	indexDir    string
-	stateFile   string
+	env            shell.Env
+	indexer        *MANIndexer
+	tldr           *TLDRIndexer
+	powershell     *PowerShellIndexer
+	vectorStore    *VectorStore
+	initialized    bool
+	indexDir       string
+	stateFile      string
+	rebuilding     atomic.Bool  // set while a background rebuild runs
+	project        *VectorStore // /rag-add index of the current repository
+	projectRoot    string
+	projectMu      sync.Mutex
+	history        *historyCollection // opt-in shell history, kept in memory only
+	historyEnabled bool
+	historyMu      sync.Mutex
+	cache          retrievalCache    // per-session results, dropped on reindex
+	tools          toolCache         // installed tools, checked when filtering results
+	toolLookup     func(string) bool // checks tools on an /ssh host instead of the local PATH
+	reranker       Reranker          // local model scoring candidates, when reranking is on
+	scheduled      reindexSchedule   // background reindexes after package changes
+	feedback       *feedbackStore    // /cmd outcomes per prompt document
+	progress       *progressTracker
 }
 
 // NewSystem creates a new RAG system
@@ -52,19 +71,34 @@ func NewSystem(env shell.Env) *RAGSystem {
 	indexDir := filepath.Join(homeDir, ".helix", "rag_index")
 	stateFile := filepath.Join(indexDir, stateFileName)
 
-	return &RAGSystem{
+	rs := &RAGSystem{
 		env:         env,
 		indexDir:    indexDir,
 		stateFile:   stateFile,
 		indexer:     NewMANIndexer(env),
+		tldr:        NewTLDRIndexer(env),
+		powershell:  NewPowerShellIndexer(env),
 		vectorStore: NewVectorStore(env),
+		feedback:    loadFeedback(filepath.Join(homeDir, ".helix", feedbackFileName)),
+		progress:    newProgressTracker(),
 	}
+	rs.indexer.progress = rs.progress
+	rs.vectorStore.progress = rs.progress
+	if rs.usesPowerShellHelp() {
+		rs.vectorStore.pageSource = SourceHelp
+	}
+	rs.vectorStore.feedback = rs.feedback.weights()
+	return rs
 }
 
 // Initialize sets up the RAG system with proper persistence
 func (rs *RAGSystem) Initialize() error {
 	color.Cyan("🚀 Initializing RAG System...")
 
+	// Progress is streamed to WatchIndexing subscribers
+	rs.progress.start()
+	defer rs.progress.finish()
+
 	if err := rs.ensureIndexDir(); err != nil {
 		return fmt.Errorf("failed to create RAG index directory: %w", err)
 	}
@@ -72,6 +106,7 @@ func (rs *RAGSystem) Initialize() error {
 	// FIRST: Try to load existing state
 	if rs.loadSystemState() {
 		color.Green("✅ RAG system loaded from existing state")
+		rs.rebuildIfStale()
 		return nil
 	}
 
@@ -79,6 +114,7 @@ func (rs *RAGSystem) Initialize() error {
 	if rs.tryLoadExistingIndex() {
 		color.Green("✅ RAG system loaded from existing index")
 		rs.saveSystemState()
+		rs.rebuildIfStale()
 		return nil
 	}
 
@@ -87,58 +123,13 @@ func (rs *RAGSystem) Initialize() error {
 	startTime := time.Now()
 
 	// Strict timeout - don't block startup
-	ctx, cancel := context.WithTimeout(context.Background(), maxIndexingTime)
+	ctx, cancel := context.WithTimeout(context.Background(), rs.IndexingConfig().timeout())
 	defer cancel()
 
-	// Progress tracking
-	progressTicker := time.NewTicker(10 * time.Second)
-	defer progressTicker.Stop()
-
-	indexingCompleted := make(chan bool, 1)
-
-	go func() {
-		for {
-			select {
-			case <-progressTicker.C:
-				if rs.initialized {
-					return
-				}
-				elapsed := time.Since(startTime)
-				indexedCount := rs.indexer.GetIndexedCount()
-				color.Yellow("🔄 RAG indexing... %d pages (%v elapsed)", indexedCount, utils.FormatDuration(elapsed))
-
-				// Show estimated time remaining for longer operations
-				if indexedCount > 100 {
-					pagesPerSecond := float64(indexedCount) / elapsed.Seconds()
-					estimatedTotal := 500 // Conservative estimate
-					remainingTime := time.Duration(float64(estimatedTotal-indexedCount)/pagesPerSecond) * time.Second
-					if remainingTime > 0 {
-						color.Cyan("   Estimated time remaining: %v", utils.FormatDuration(remainingTime))
-					}
-				}
-			case <-ctx.Done():
-				// Only show timeout message if indexing hasn't completed
-				select {
-				case <-indexingCompleted:
-					return // Indexing completed, no timeout message needed
-				default:
-					indexedCount := rs.indexer.GetIndexedCount()
-					if indexedCount > 0 {
-						color.Yellow("⏰ RAG indexing timeout after %v", utils.FormatDuration(time.Since(startTime)))
-						color.Yellow("💡 Using %d partially indexed pages", indexedCount)
-					} else {
-						color.Yellow("⏰ RAG indexing timeout - no pages indexed")
-					}
-					return
-				}
-			}
-		}
-	}()
-
 	// Run indexing with timeout
 	done := make(chan error, 1)
 	go func() {
-		if err := rs.indexer.IndexAvailableManPages(); err != nil {
+		if err := rs.indexDocumentation(); err != nil {
 			done <- err
 			return
 		}
@@ -149,8 +140,7 @@ func (rs *RAGSystem) Initialize() error {
 	select {
 	case indexingErr = <-done:
 		// Indexing completed (success or error)
-		indexingCompleted <- true // Signal that indexing completed
-		indexedCount := rs.indexer.GetIndexedCount()
+		indexedCount := rs.indexedPageCount()
 		if indexingErr != nil {
 			color.Yellow("⚠️  MAN page indexing had issues: %v", indexingErr)
 		}
@@ -165,8 +155,7 @@ func (rs *RAGSystem) Initialize() error {
 		}
 	case <-ctx.Done():
 		// Timeout - use whatever was indexed
-		indexingCompleted <- true // Signal that we're handling timeout
-		indexedCount := rs.indexer.GetIndexedCount()
+		indexedCount := rs.indexedPageCount()
 		if indexedCount > 0 {
 			color.Yellow("⏰ RAG indexing timed out after %v", utils.FormatDuration(time.Since(startTime)))
 			color.Yellow("💡 Using %d partially indexed pages", indexedCount)
@@ -187,10 +176,6 @@ func (rs *RAGSystem) Initialize() error {
 		return nil
 	}
 
-	// DEBUG: Check what pages we're sending to the vector store
-	rs.debugVectorStore(pages)
-
-	color.Blue("🔧 Building vector index with %d pages...", len(pages))
 	if err := rs.vectorStore.IndexMANPages(pages); err != nil {
 		color.Yellow("⚠️  Vector indexing failed: %v", err)
 		// Still mark as initialized to avoid re-indexing
@@ -199,9 +184,21 @@ func (rs *RAGSystem) Initialize() error {
 		return nil
 	}
 
+	// Document sources such as tldr-pages complement the MAN pages
+	if len(rs.syncSources()) > 0 {
+		if err := rs.vectorStore.saveVectorIndex(); err != nil {
+			color.Yellow("⚠️  Could not save source documents: %v", err)
+		}
+	}
+
 	rs.initialized = true
 	duration := time.Since(startTime)
 
+	// Snapshot page fingerprints so later reindexes can be incremental
+	if !rs.usesPowerShellHelp() {
+		rs.recordManifest()
+	}
+
 	// NEW: Show completion message without timeout reference
 	color.Green("🎉 RAG system initialized in %s!", utils.FormatDuration(duration))
 	color.Green("📊 Indexed %d MAN pages, %d vector documents",
@@ -211,15 +208,38 @@ func (rs *RAGSystem) Initialize() error {
 	return rs.saveSystemState()
 }
 
+// LoadExistingIndex loads a previously built index without starting any indexing
+func (rs *RAGSystem) LoadExistingIndex() bool {
+	if rs.loadSystemState() {
+		return true
+	}
+
+	if rs.tryLoadExistingIndex() {
+		rs.initialized = true
+		return true
+	}
+
+	return false
+}
+
+// IsIndexStale reports whether the loaded index was built by an older Helix
+func (rs *RAGSystem) IsIndexStale() bool {
+	return rs.vectorStore.IsStale()
+}
+
 // IndexAvailableManPages indexes MAN pages in background (non-blocking)
 func (rs *RAGSystem) IndexAvailableManPages() {
 	// Only index if we don't have an existing state
-	if rs.hasExistingState() {
+	if rs.hasExistingState() && rs.LoadExistingIndex() {
 		color.Blue("💡 RAG system already initialized, skipping background indexing")
+		rs.rebuildIfStale()
 		return
 	}
 
+	// Track from here so the CLI can subscribe before the goroutine runs
+	rs.progress.start()
 	go func() {
+		defer rs.progress.finish()
 		color.Blue("🔄 Background RAG indexing started...")
 		if err := rs.Initialize(); err != nil {
 			color.Yellow("⚠️  Background indexing completed with issues: %v", err)
@@ -239,78 +259,101 @@ func (rs *RAGSystem) Retrieve(query string) (*RetrievalResult, error) {
 		return &RetrievalResult{}, nil // Return empty result if not initialized
 	}
 
-	color.Blue("🔍 RAG Retrieval for: %s", query)
 	startTime := time.Now()
 
-	// Extract potential command names from query
-	potentialCommands := rs.extractPotentialCommands(query)
-
-	// Search for relevant commands with better filtering
-	relevantCommands, err := rs.vectorStore.GetRelevantCommands(query, 3) // Reduced from 5 to 3
+	manual, err := rs.retrieveManual(query)
 	if err != nil {
 		color.Yellow("⚠️  RAG search failed: %v", err)
 		return &RetrievalResult{}, nil
 	}
+	exactMatches, filteredCommands := manual.exact, manual.relevant
 
-	// NEW: Filter out irrelevant commands more aggressively
-	var filteredCommands []CommandInfo
-	for _, cmd := range relevantCommands {
-		if rs.isRelevantCommand(query, cmd) {
-			filteredCommands = append(filteredCommands, cmd)
-		}
-	}
-
-	// Get detailed info for potential exact matches
-	var exactMatches []CommandInfo
-	for _, cmd := range potentialCommands {
-		if info, err := rs.vectorStore.GetCommandInfo(cmd); err == nil {
-			exactMatches = append(exactMatches, *info)
-		}
-	}
+	// The project's own tooling grounds requests like "run the integration tests"
+	exactMatches = append(exactMatches, rs.retrieveProject(query)...)
 
 	// Combine and deduplicate results
 	result := rs.combineResults(exactMatches, filteredCommands)
+	result.History = rs.retrieveHistory(query)
+	result.UsedRAG = result.UsedRAG || len(result.History) > 0
 	result.RetrievalTime = time.Since(startTime)
 
 	color.Green("✅ RAG retrieved %d commands in %s",
 		len(result.Commands),
 		utils.FormatDuration(result.RetrievalTime))
+	if len(result.History) > 0 {
+		color.Cyan("📜 Matched %d commands from your shell history", len(result.History))
+	}
 
 	return result, nil
 }
 
-// NEW: Add this method to filter irrelevant commands
-func (rs *RAGSystem) isRelevantCommand(query string, cmd CommandInfo) bool {
-	queryLower := strings.ToLower(query)
-	cmdLower := strings.ToLower(cmd.Name)
-
-	// Filter out git commands for non-git queries
-	if strings.HasPrefix(cmdLower, "git-") && !strings.Contains(queryLower, "git") {
-		return false
+// retrieveManual finds the MAN and tldr commands for a query, reusing the
+// results of an identical earlier query until the index changes
+func (rs *RAGSystem) retrieveManual(query string) (manualResults, error) {
+	key := normalizeQuery(query)
+	generation := rs.vectorStore.Generation()
+	if cached, ok := rs.cache.get(key, generation); ok {
+		color.Cyan("♻️  Reusing RAG results for: %s", query)
+		return cached, nil
 	}
 
-	// Filter out kubectl commands for non-kubernetes queries
-	if strings.HasPrefix(cmdLower, "kubectl") && !strings.Contains(queryLower, "kube") {
-		return false
+	color.Blue("🔍 RAG Retrieval for: %s", query)
+
+	// Extract potential command names from query
+	potentialCommands := rs.extractPotentialCommands(query)
+
+	// Search for relevant commands with better filtering; reranking scores a
+	// wider pool of candidates and keeps the best
+	topK := rs.RetrievalConfig().TopK
+	candidates := topK
+	if rs.reranking() {
+		candidates = max(topK, rerankCandidates)
+	}
+	relevantCommands, err := rs.vectorStore.GetRelevantCommands(query, candidates)
+	if err != nil {
+		return manualResults{}, err
 	}
 
-	// Filter out dangerous commands for safe queries
-	if (cmdLower == "killall" || cmdLower == "rm") &&
-		!strings.Contains(queryLower, "kill") && !strings.Contains(queryLower, "remove") {
-		return false
+	// Suggestions must not reference tools that are not installed here
+	var filteredCommands []CommandInfo
+	for _, cmd := range relevantCommands {
+		if rs.isRelevantCommand(query, cmd) {
+			filteredCommands = append(filteredCommands, cmd)
+		}
+	}
+	if rs.reranking() {
+		filteredCommands = rs.rerank(query, filteredCommands)
+		if len(filteredCommands) > topK {
+			filteredCommands = filteredCommands[:topK]
+		}
 	}
 
-	// Filter out commands with very low relevance
-	if cmd.Description == "" || len(cmd.Description) < 10 {
-		return false
+	// Get detailed info for potential exact matches
+	var exactMatches []CommandInfo
+	for _, cmd := range potentialCommands {
+		if info, err := rs.vectorStore.GetCommandInfo(cmd); err == nil {
+			exactMatches = append(exactMatches, *info)
+		}
 	}
 
-	return true
+	// "what does -R do in chown" is grounded by the entry for -R
+	rs.focusOnFlags(query, exactMatches)
+	rs.focusOnFlags(query, filteredCommands)
+
+	// English documentation grounds the prompt best, so it goes first
+	sort.SliceStable(filteredCommands, func(i, j int) bool {
+		return isEnglish(filteredCommands[i]) && !isEnglish(filteredCommands[j])
+	})
+
+	results := manualResults{exact: exactMatches, relevant: filteredCommands}
+	rs.cache.put(key, generation, results)
+	return results, nil
 }
 
 // RetrievalResult contains the results of a RAG retrieval
 type RetrievalResult struct {
 	Commands      []CommandInfo `json:"commands"`
+	History       []string      `json:"history,omitempty"` // matching commands from the user's shell history
 	Query         string        `json:"query"`
 	RetrievalTime time.Duration `json:"retrieval_time"`
 	UsedRAG       bool          `json:"used_rag"`
@@ -323,9 +366,11 @@ func (rs *RAGSystem) EnhancePrompt(userInput, originalPrompt string) string {
 	}
 
 	result, err := rs.Retrieve(userInput)
-	if err != nil || !result.UsedRAG || len(result.Commands) == 0 {
+	if err != nil || !result.UsedRAG || (len(result.Commands) == 0 && len(result.History) == 0) {
+		rs.feedback.setPending(userInput, nil)
 		return originalPrompt
 	}
+	rs.feedback.setPending(userInput, promptDocuments(result.Commands))
 
 	color.Cyan("🎯 Enhancing prompt with %d relevant commands", len(result.Commands))
 
@@ -337,28 +382,59 @@ func (rs *RAGSystem) EnhancePrompt(userInput, originalPrompt string) string {
 func (rs *RAGSystem) buildEnhancedPrompt(userInput, originalPrompt string, result *RetrievalResult) string {
 	var sb strings.Builder
 
+	sb.WriteString(rs.buildContext(result))
+	sb.WriteString("ORIGINAL PROMPT:\n")
+	sb.WriteString(originalPrompt)
+
+	return sb.String()
+}
+
+// buildContext renders the retrieved command information injected ahead of the prompt
+func (rs *RAGSystem) buildContext(result *RetrievalResult) string {
+	var sb strings.Builder
+
+	// Repeated descriptions, options and examples only waste context tokens
+	commands, suppressed := dedupeContext(result.Commands)
+	if suppressed > 0 {
+		color.Cyan("🧹 Suppressed %d duplicate context chunks", suppressed)
+	}
+
 	sb.WriteString("ADDITIONAL CONTEXT FROM SYSTEM MANUAL PAGES:\n")
 	sb.WriteString("The following command information is available on this system:\n\n")
 
-	for i, cmd := range result.Commands {
-		sb.WriteString(fmt.Sprintf("COMMAND %d: %s\n", i+1, cmd.Name))
+	for i, cmd := range commands {
+		if cmd.Source == "project" && len(cmd.Examples) == 0 {
+			sb.WriteString(fmt.Sprintf("PROJECT DOC %d: %s\n", i+1, cmd.Name))
+		} else if cmd.Source == "project" {
+			sb.WriteString(fmt.Sprintf("COMMAND %d: %s (defined by this project)\n", i+1, cmd.Name))
+		} else {
+			sb.WriteString(fmt.Sprintf("COMMAND %d: %s\n", i+1, cmd.Name))
+		}
+
+		if cmd.AliasOf != "" {
+			sb.WriteString(fmt.Sprintf("Documented as: %s (%s runs the same program)\n", cmd.AliasOf, cmd.Name))
+		}
 
 		if cmd.Description != "" {
 			sb.WriteString(fmt.Sprintf("Description: %s\n", cmd.Description))
 		}
 
+		// Pages only installed in another language are grounded as they are
+		sb.WriteString(translationNote(cmd.Language, SystemLanguage()))
+
 		if cmd.Synopsis != "" {
 			sb.WriteString(fmt.Sprintf("Usage: %s\n", cmd.Synopsis))
 		}
 
+		// One option per line, since each carries its description
 		if len(cmd.Options) > 0 {
-			sb.WriteString("Common Options: ")
-			if len(cmd.Options) > 5 {
-				sb.WriteString(strings.Join(cmd.Options[:5], ", "))
-				sb.WriteString("...\n")
-			} else {
-				sb.WriteString(strings.Join(cmd.Options, ", "))
-				sb.WriteString("\n")
+			sb.WriteString("Common Options:\n")
+			for i, option := range cmd.Options {
+				if i == 5 {
+					sb.WriteString("  ...\n")
+					break
+				}
+				sb.WriteString("  " + option + "\n")
 			}
 		}
 
@@ -373,11 +449,23 @@ func (rs *RAGSystem) buildEnhancedPrompt(userInput, originalPrompt string, resul
 			}
 		}
 
+		if len(cmd.Passages) > 0 {
+			sb.WriteString("Relevant Documentation:\n")
+			for _, passage := range cmd.Passages {
+				sb.WriteString(fmt.Sprintf("  %s\n", passage))
+			}
+		}
+
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString("ORIGINAL PROMPT:\n")
-	sb.WriteString(originalPrompt)
+	if len(result.History) > 0 {
+		sb.WriteString("COMMANDS THIS USER HAS RUN BEFORE (prefer their flags and paths):\n")
+		for _, line := range result.History {
+			sb.WriteString(fmt.Sprintf("PREVIOUSLY RUN COMMAND: %s\n", line))
+		}
+		sb.WriteString("\n")
+	}
 
 	return sb.String()
 }
@@ -422,9 +510,36 @@ func (rs *RAGSystem) ExplainCommand(command string) (string, error) {
 		}
 	}
 
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{
+		{"Exit Status", info.ExitStatus},
+		{"Files", info.Files},
+		{"Environment", info.Environment},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		explanation.WriteString(fmt.Sprintf("\n**%s**:\n", section.title))
+		for i, entry := range section.entries {
+			if i >= 5 {
+				break
+			}
+			explanation.WriteString(fmt.Sprintf("  • %s\n", entry))
+		}
+	}
+
 	return explanation.String(), nil
 }
 
+// LookupCommand returns the indexed information about a command, for
+// answering exact lookups without the model. Before the index is loaded the
+// command's documents are read from disk.
+func (rs *RAGSystem) LookupCommand(command string) (*CommandInfo, error) {
+	return rs.vectorStore.GetCommandInfo(command)
+}
+
 // GetCommandSuggestions suggests commands based on user intent
 func (rs *RAGSystem) GetCommandSuggestions(userInput string) ([]CommandSuggestion, error) {
 	if !rs.initialized {
@@ -470,7 +585,10 @@ func (rs *RAGSystem) GetSystemStats() map[string]interface{} {
 	stats := make(map[string]interface{})
 
 	stats["initialized"] = rs.initialized
-	stats["indexed_pages"] = rs.indexer.GetIndexedCount()
+	stats["indexed_pages"] = rs.indexedPageCount()
+	stats["index_builder"] = rs.vectorStore.IndexInfo().Builder
+	stats["index_stale"] = rs.vectorStore.IsStale()
+	stats["rebuilding"] = rs.IsRebuilding()
 
 	if rs.initialized {
 		vectorStats := rs.vectorStore.GetStats()
@@ -573,23 +691,25 @@ func (rs *RAGSystem) looksLikeCommand(word string) bool {
 
 // combineResults combines and deduplicates command results
 func (rs *RAGSystem) combineResults(exactMatches, relevant []CommandInfo) *RetrievalResult {
-	seen := make(map[string]bool)
+	seen := make(map[string]int) // command -> position in combined
 	var combined []CommandInfo
 
 	// Add exact matches first (higher priority)
 	for _, cmd := range exactMatches {
-		if !seen[cmd.Name] {
-			seen[cmd.Name] = true
+		if _, exists := seen[cmd.Name]; !exists {
+			seen[cmd.Name] = len(combined)
 			combined = append(combined, cmd)
 		}
 	}
 
-	// Add relevant commands
+	// Add relevant commands, keeping the documents they matched for feedback
 	for _, cmd := range relevant {
-		if !seen[cmd.Name] {
-			seen[cmd.Name] = true
-			combined = append(combined, cmd)
+		if i, exists := seen[cmd.Name]; exists {
+			combined[i].Documents = appendUnique(combined[i].Documents, cmd.Documents)
+			continue
 		}
+		seen[cmd.Name] = len(combined)
+		combined = append(combined, cmd)
 	}
 
 	return &RetrievalResult{
@@ -645,6 +765,10 @@ func (rs *RAGSystem) calculateConfidence(userInput string, cmd CommandInfo) floa
 
 // GetAllIndexedPages returns all indexed MAN pages
 func (rs *RAGSystem) getAllIndexedPages() []MANPage {
+	if rs.usesPowerShellHelp() {
+		return rs.powershell.GetAllIndexedPages()
+	}
+
 	if rs.indexer == nil {
 		return []MANPage{}
 	}
@@ -712,6 +836,7 @@ func (rs *RAGSystem) tryLoadExistingIndex() bool {
 
 	if rs.vectorStore.IsInitialized() {
 		color.Green("✅ Loaded existing vector index")
+		rs.repairAfterLoad()
 		return true
 	}
 
@@ -741,6 +866,7 @@ func (rs *RAGSystem) loadSystemState() bool {
 	if rs.initialized {
 		if err := rs.vectorStore.loadVectorIndex(); err == nil {
 			color.Green("✅ Loaded RAG index with %d commands", state.TotalCommands)
+			rs.repairAfterLoad()
 			return true
 		}
 	}
@@ -754,7 +880,7 @@ func (rs *RAGSystem) saveSystemState() error {
 		Version:     indexVersion,
 		Initialized: rs.initialized,
 		IndexedTime: time.Now(),
-		TotalPages:  rs.indexer.GetIndexedCount(),
+		TotalPages:  rs.indexedPageCount(),
 	}
 
 	// Safely extract total_documents from vector store stats
@@ -815,20 +941,3 @@ func (rs *RAGSystem) Cleanup() {
 	color.Blue("🧹 Cleaning up RAG system...")
 	// Currently no special cleanup needed
 }
-
-// debugVectorStore outputs debug info about the vector store
-func (rs *RAGSystem) debugVectorStore(pages []MANPage) {
-	color.Cyan("🔍 DEBUG: Checking MAN pages for vector store...")
-	color.Cyan("  Total pages: %d", len(pages))
-
-	if len(pages) > 0 {
-		color.Cyan("  Sample pages:")
-		for i := 0; i < min(5, len(pages)); i++ {
-			page := pages[i]
-			color.Cyan("    %d. %s: %s", i+1, page.Name,
-				utils.TruncateString(page.Description, 50))
-		}
-	} else {
-		color.Red("  ❌ No pages available!")
-	}
-}
@@ -0,0 +1,282 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helix/internal/shell"
+
+	"github.com/fatih/color"
+)
+
+const (
+	tldrArchiveURL      = "https://github.com/tldr-pages/tldr/releases/latest/download/tldr.zip"
+	tldrCacheFileName   = "tldr_pages.json"
+	tldrRefreshInterval = 7 * 24 * time.Hour
+	tldrDownloadTimeout = 60 * time.Second
+	maxTLDRArchiveSize  = 64 << 20
+)
+
+// tldrArgPattern matches tldr's {{argument}} syntax
+var tldrArgPattern = regexp.MustCompile(`\{\{(.*?)\}\}`)
+
+// TLDRPage represents a processed tldr page with practical examples
+type TLDRPage struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Platform    string   `json:"platform"`
+	Examples    []string `json:"examples"`
+}
+
+// tldrCache is the on-disk form of the downloaded tldr pages
+type tldrCache struct {
+	DownloadedAt time.Time           `json:"downloaded_at"`
+	Platform     string              `json:"platform"`
+	Pages        map[string]TLDRPage `json:"pages"`
+}
+
+// TLDRIndexer downloads and parses tldr-pages for the current platform
+type TLDRIndexer struct {
+	env          shell.Env
+	indexDir     string
+	archiveURL   string
+	pages        map[string]TLDRPage
+	downloadedAt time.Time
+	mu           sync.RWMutex
+}
+
+// NewTLDRIndexer creates a new tldr page indexer
+func NewTLDRIndexer(env shell.Env) *TLDRIndexer {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "/tmp"
+	}
+
+	return &TLDRIndexer{
+		env:        env,
+		indexDir:   filepath.Join(homeDir, ".helix", "tldr_index"),
+		archiveURL: tldrArchiveURL,
+		pages:      make(map[string]TLDRPage),
+	}
+}
+
+// platform maps the detected OS to a tldr platform directory
+func (ti *TLDRIndexer) platform() string {
+	switch ti.env.OSName {
+	case "darwin":
+		return "osx"
+	case "windows":
+		return "windows"
+	case "freebsd", "netbsd", "openbsd":
+		return ti.env.OSName
+	default:
+		return "linux"
+	}
+}
+
+// LoadCache loads previously downloaded pages, returning false if none are usable
+func (ti *TLDRIndexer) LoadCache() bool {
+	data, err := os.ReadFile(filepath.Join(ti.indexDir, tldrCacheFileName))
+	if err != nil {
+		return false
+	}
+
+	var cache tldrCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Platform != ti.platform() {
+		return false
+	}
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ti.pages = cache.Pages
+	ti.downloadedAt = cache.DownloadedAt
+	return len(ti.pages) > 0
+}
+
+// NeedsRefresh reports whether the cached pages are missing or stale
+func (ti *TLDRIndexer) NeedsRefresh() bool {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return len(ti.pages) == 0 || time.Since(ti.downloadedAt) > tldrRefreshInterval
+}
+
+// Download fetches the tldr-pages archive, parses the pages for this platform and caches them
+func (ti *TLDRIndexer) Download() error {
+	color.Blue("📥 Downloading tldr pages...")
+
+	client := http.Client{Timeout: tldrDownloadTimeout}
+	resp, err := client.Get(ti.archiveURL)
+	if err != nil {
+		return fmt.Errorf("failed to download tldr pages: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download tldr pages: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTLDRArchiveSize))
+	if err != nil {
+		return fmt.Errorf("failed to read tldr archive: %w", err)
+	}
+
+	pages, err := ti.parseArchive(data)
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("tldr archive contained no pages for %s", ti.platform())
+	}
+
+	ti.mu.Lock()
+	ti.pages = pages
+	ti.downloadedAt = time.Now()
+	ti.mu.Unlock()
+
+	color.Green("✅ Parsed %d tldr pages for %s", len(pages), ti.platform())
+	return ti.saveCache()
+}
+
+// parseArchive extracts the English common and platform pages from the tldr zip
+func (ti *TLDRIndexer) parseArchive(data []byte) (map[string]TLDRPage, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tldr archive: %w", err)
+	}
+
+	platform := ti.platform()
+	pages := make(map[string]TLDRPage)
+
+	for _, file := range reader.File {
+		dir, name := path.Split(file.Name)
+		if !strings.HasSuffix(name, ".md") {
+			continue
+		}
+		dir = strings.TrimSuffix(dir, "/")
+
+		// Entries look like pages/common/tar.md (optionally under a top-level folder)
+		pagePlatform := path.Base(dir)
+		if path.Base(path.Dir(dir)) != "pages" || (pagePlatform != "common" && pagePlatform != platform) {
+			continue
+		}
+
+		command := strings.TrimSuffix(name, ".md")
+		// Platform-specific pages take precedence over common ones
+		if existing, exists := pages[command]; exists && existing.Platform != "common" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+
+		page := parseTLDRPage(command, string(content))
+		page.Platform = pagePlatform
+		if len(page.Examples) > 0 {
+			pages[command] = page
+		}
+	}
+
+	return pages, nil
+}
+
+// parseTLDRPage converts tldr markdown into a page, rewriting {{args}} as <args>
+func parseTLDRPage(command, content string) TLDRPage {
+	page := TLDRPage{Name: command}
+
+	var descriptions []string
+	var exampleDesc string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			if !strings.HasPrefix(text, "More information") && !strings.HasPrefix(text, "See also") {
+				descriptions = append(descriptions, text)
+			}
+		case strings.HasPrefix(line, "- "):
+			exampleDesc = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "- ")), ":")
+		case strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") && len(line) > 2:
+			example := tldrArgPattern.ReplaceAllString(strings.Trim(line, "`"), "<$1>")
+			if exampleDesc != "" {
+				example = fmt.Sprintf("%s  # %s", example, exampleDesc)
+			}
+			page.Examples = append(page.Examples, example)
+			exampleDesc = ""
+		}
+	}
+
+	page.Description = strings.Join(descriptions, " ")
+	return page
+}
+
+// saveCache writes the parsed pages to disk
+func (ti *TLDRIndexer) saveCache() error {
+	if err := os.MkdirAll(ti.indexDir, 0755); err != nil {
+		return fmt.Errorf("failed to create tldr index directory: %w", err)
+	}
+
+	ti.mu.RLock()
+	cache := tldrCache{
+		DownloadedAt: ti.downloadedAt,
+		Platform:     ti.platform(),
+		Pages:        ti.pages,
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	ti.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(ti.indexDir, tldrCacheFileName), data, 0644)
+}
+
+// GetPage returns the tldr page for a command
+func (ti *TLDRIndexer) GetPage(name string) (TLDRPage, bool) {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	page, exists := ti.pages[name]
+	return page, exists
+}
+
+// GetAllPages returns all parsed tldr pages sorted by name
+func (ti *TLDRIndexer) GetAllPages() []TLDRPage {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+
+	pages := make([]TLDRPage, 0, len(ti.pages))
+	for _, page := range ti.pages {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Name < pages[j].Name
+	})
+	return pages
+}
+
+// GetPageCount returns the number of loaded tldr pages
+func (ti *TLDRIndexer) GetPageCount() int {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return len(ti.pages)
+}
@@ -0,0 +1,116 @@
+package rag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// traceDocumentLimit is how many ranked documents a retrieval trace shows
+const traceDocumentLimit = 10
+
+// ScoredDocument is a search result with the terms that produced its score
+type ScoredDocument struct {
+	Document     VectorDocument `json:"document"`
+	Score        float64        `json:"score"`
+	MatchedTerms []string       `json:"matched_terms"`
+}
+
+// RetrievalTrace explains one retrieval: how the query was tokenized, how
+// documents ranked, which commands were kept and the exact injected context
+type RetrievalTrace struct {
+	Query     string           `json:"query"`
+	Terms     []string         `json:"terms"`
+	Documents []ScoredDocument `json:"documents"`
+	Result    *RetrievalResult `json:"result"`
+	Dropped   []string         `json:"dropped"` // ranked commands left out of the context
+	Context   string           `json:"context"`
+	Duration  time.Duration    `json:"duration"`
+}
+
+// ExplainSearch ranks documents like Search, but quietly and with the matched
+// terms of each result
+func (vs *VectorStore) ExplainSearch(query string, limit int) ([]ScoredDocument, []string) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	terms := vs.queryTerms(vs.analyzer.ExpandQuery(query))
+	matched := make(map[string][]string)
+	scores := vs.scoreDocuments(query, matched)
+
+	var results []ScoredDocument
+	for docID, score := range scores {
+		if doc, exists := vs.documents[docID]; exists && score > vs.retrieval.MinScore {
+			results = append(results, ScoredDocument{
+				Document:     doc,
+				Score:        score,
+				MatchedTerms: matched[docID],
+			})
+		}
+	}
+
+	sortScored(results)
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	docs := make([]VectorDocument, len(results))
+	for i, result := range results {
+		docs[i] = result.Document
+	}
+	for i, doc := range vs.fullDocuments(docs) {
+		results[i].Document = doc
+	}
+	return results, terms
+}
+
+// TraceRetrieval runs retrieval without calling the model and reports why
+// each command was or was not picked
+func (rs *RAGSystem) TraceRetrieval(query string) (*RetrievalTrace, error) {
+	if !rs.initialized {
+		return nil, fmt.Errorf("RAG system not initialized")
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	startTime := time.Now()
+	trace := &RetrievalTrace{Query: query}
+	trace.Documents, trace.Terms = rs.vectorStore.ExplainSearch(query, traceDocumentLimit)
+
+	result, err := rs.Retrieve(query)
+	if err != nil {
+		return nil, err
+	}
+	trace.Result = result
+
+	kept := make(map[string]bool)
+	for _, cmd := range result.Commands {
+		kept[cmd.Name] = true
+	}
+	seen := make(map[string]bool)
+	for _, doc := range trace.Documents {
+		command := doc.Document.Metadata.Command
+		if !kept[command] && !seen[command] {
+			seen[command] = true
+			trace.Dropped = append(trace.Dropped, command)
+		}
+	}
+
+	if len(result.Commands) > 0 || len(result.History) > 0 {
+		trace.Context = rs.buildContext(result)
+	}
+	trace.Duration = time.Since(startTime)
+	return trace, nil
+}
+
+// sortScored orders results by descending score, then by document ID
+func sortScored(results []ScoredDocument) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Document.ID < results[j].Document.ID
+	})
+}
@@ -1,10 +1,9 @@
 package rag
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"helix/internal/shell"
-	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -21,22 +20,47 @@ type VectorDocument struct {
 	Embedding  []float32 `json:"embedding"`
 	Metadata   Metadata  `json:"metadata"`
 	Similarity float32   `json:"similarity,omitempty"`
+
+	paged bool // body is on disk only; see documentPager
 }
 
 // Metadata contains document metadata
 type Metadata struct {
-	Command     string   `json:"command"`
-	Section     string   `json:"section"`
-	Description string   `json:"description"`
-	Options     []string `json:"options"`
-	Examples    []string `json:"examples"`
+	Command       string   `json:"command"`
+	Section       string   `json:"section"`
+	Description   string   `json:"description"`
+	Options       []string `json:"options"`
+	Examples      []string `json:"examples"`
+	Language      string   `json:"language,omitempty"`
+	ChunkSection  string   `json:"chunk_section,omitempty"`
+	ChunkIndex    int      `json:"chunk_index,omitempty"`
+	Entries       []string `json:"entries,omitempty"`        // EXIT STATUS, FILES and ENVIRONMENT entries
+	Aliases       []string `json:"aliases,omitempty"`        // other names the command is run by
+	OptionDetails []Option `json:"option_details,omitempty"` // structured options of an options document
+	Source        string   `json:"source,omitempty"`         // document source that supplied it, empty for MAN pages
 }
 
 // VectorStore manages document embeddings and similarity search
 type VectorStore struct {
 	indexDir    string
 	documents   map[string]VectorDocument
-	index       map[string][]string // word -> document IDs
+	index       map[string][]posting // term -> documents containing it
+	docLengths  map[string]float64   // weighted document lengths for BM25
+	totalLength float64
+	boosts      []SearchBoost
+	boostsPath  string
+	analyzer    *Analyzer // shared by indexing and querying
+	generation  uint64    // bumped on every index change, for cache invalidation
+	retrieval   RetrievalConfig
+	pageSource  string             // source of MAN-style documents: man, or help on Windows
+	feedback    map[string]float64 // per-document weights learned from /cmd outcomes
+	corrupted   []string           // IDs of stored documents that failed to load
+	dirty       map[string]bool    // document IDs not yet written to disk
+	info        IndexInfo          // how the on-disk index was built
+	stale       bool               // built by an older Helix, rebuild pending
+	resolved    aliasCache         // commands resolved to the page documenting them
+	progress    *progressTracker   // shared with the RAG system; nil when unused
+	pager       documentPager      // document bodies kept within the memory budget
 	mu          sync.RWMutex
 	initialized bool
 }
@@ -48,19 +72,28 @@ func NewVectorStore(env shell.Env) *VectorStore {
 		homeDir = "/tmp"
 	}
 
-	indexDir := filepath.Join(homeDir, ".helix", "vector_index")
+	return newVectorStoreAt(filepath.Join(homeDir, ".helix", "vector_index"),
+		filepath.Join(homeDir, ".helix", boostsFileName))
+}
 
+// newVectorStoreAt creates a vector store kept in the given directory
+func newVectorStoreAt(indexDir, boostsPath string) *VectorStore {
 	return &VectorStore{
-		indexDir:  indexDir,
-		documents: make(map[string]VectorDocument),
-		index:     make(map[string][]string),
+		indexDir:   indexDir,
+		documents:  make(map[string]VectorDocument),
+		index:      make(map[string][]posting),
+		docLengths: make(map[string]float64),
+		boosts:     loadSearchBoosts(boostsPath),
+		boostsPath: boostsPath,
+		analyzer:   loadAnalyzer(filepath.Join(filepath.Dir(boostsPath), analyzerFileName)),
+		dirty:      make(map[string]bool),
+		retrieval:  DefaultRetrievalConfig(),
+		pageSource: SourceMan,
 	}
 }
 
 // IndexMANPages indexes MAN pages in the vector store
 func (vs *VectorStore) IndexMANPages(pages []MANPage) error {
-	color.Blue("🔧 Indexing %d MAN pages in vector store...", len(pages))
-
 	if len(pages) == 0 {
 		color.Red("❌ No MAN pages to index!")
 		return fmt.Errorf("no MAN pages provided")
@@ -68,21 +101,20 @@ func (vs *VectorStore) IndexMANPages(pages []MANPage) error {
 
 	// Validate pages have content
 	validPages := 0
-	for i, page := range pages {
-		if page.Name == "" || page.Description == "" {
-			color.Yellow("⚠️  Page %d has empty content: %+v", i, page)
-			continue
+	for _, page := range pages {
+		if page.Name != "" && page.Description != "" {
+			validPages++
 		}
-		validPages++
 	}
 
-	color.Cyan("🔍 DEBUG: %d valid pages out of %d total", validPages, len(pages))
-
 	if validPages == 0 {
 		color.Red("❌ No valid MAN pages to index!")
 		return fmt.Errorf("no valid MAN pages")
 	}
 
+	vs.progress.setPhase(PhaseEmbedding)
+	vs.progress.update(func(p *IndexingProgress) { p.PagesToEmbed = len(pages) })
+
 	var wg sync.WaitGroup
 	docChan := make(chan VectorDocument, len(pages))
 
@@ -102,14 +134,11 @@ func (vs *VectorStore) IndexMANPages(pages []MANPage) error {
 	count := 0
 	for doc := range docChan {
 		vs.mu.Lock()
-		vs.documents[doc.ID] = doc
+		vs.putDocument(doc)
 		vs.addToIndex(doc)
 		vs.mu.Unlock()
 		count++
-
-		if count%50 == 0 {
-			color.Green("✅ Vector indexed %d documents...", count)
-		}
+		vs.progress.update(func(p *IndexingProgress) { p.DocsEmbedded++ })
 	}
 
 	vs.initialized = true
@@ -118,10 +147,93 @@ func (vs *VectorStore) IndexMANPages(pages []MANPage) error {
 	return vs.saveVectorIndex()
 }
 
+// UpsertMANPages replaces the documents of the given MAN pages without touching other commands.
+// The caller is responsible for persisting the index afterwards.
+func (vs *VectorStore) UpsertMANPages(pages []MANPage) error {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	replaced := make(map[string]bool)
+	for _, page := range pages {
+		replaced[page.Name] = true
+	}
+	for docID, doc := range vs.documents {
+		// Documents from other sources, like tldr examples, are kept
+		if replaced[doc.Metadata.Command] && vs.documentSource(doc) == vs.pageSource {
+			vs.deleteDocument(docID)
+		}
+	}
+
+	vs.progress.setPhase(PhaseEmbedding)
+	vs.progress.update(func(p *IndexingProgress) { p.PagesToEmbed = len(pages) })
+
+	count := 0
+	for _, page := range pages {
+		documents := vs.buildMANPageDocuments(page)
+		for _, doc := range documents {
+			vs.putDocument(doc)
+			count++
+		}
+		vs.progress.update(func(p *IndexingProgress) {
+			p.PagesEmbedded++
+			p.DocsEmbedded += len(documents)
+		})
+	}
+
+	vs.rebuildIndex()
+	vs.initialized = len(vs.documents) > 0
+	color.Green("✅ Vector index updated with %d documents for %d commands", count, len(pages))
+	return nil
+}
+
+// RemoveCommands deletes all documents belonging to the given commands and
+// returns how many documents were removed
+func (vs *VectorStore) RemoveCommands(commands []string) int {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	remove := make(map[string]bool)
+	for _, command := range commands {
+		remove[command] = true
+	}
+
+	removed := 0
+	for docID, doc := range vs.documents {
+		if remove[doc.Metadata.Command] {
+			vs.deleteDocument(docID)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		vs.rebuildIndex()
+	}
+	return removed
+}
+
+// DocumentCount returns the number of documents in the store
+func (vs *VectorStore) DocumentCount() int {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return len(vs.documents)
+}
+
 // processMANPage converts a MAN page to vector documents
 func (vs *VectorStore) processMANPage(page MANPage, wg *sync.WaitGroup, docChan chan<- VectorDocument) {
 	defer wg.Done()
 
+	for _, doc := range vs.buildMANPageDocuments(page) {
+		docChan <- doc
+	}
+	vs.progress.update(func(p *IndexingProgress) { p.PagesEmbedded++ })
+}
+
+// buildMANPageDocuments creates the non-empty documents for each section of a MAN page
+func (vs *VectorStore) buildMANPageDocuments(page MANPage) []VectorDocument {
 	// Create multiple documents from different sections of the MAN page
 	documents := []VectorDocument{
 		vs.createCommandDocument(page),
@@ -130,17 +242,24 @@ func (vs *VectorStore) processMANPage(page MANPage, wg *sync.WaitGroup, docChan
 		vs.createExamplesDocument(page),
 		vs.createSynopsisDocument(page),
 	}
+	documents = append(documents, vs.createReferenceDocuments(page)...)
+	documents = append(documents, vs.createChunkDocuments(page)...)
 
+	var result []VectorDocument
 	for _, doc := range documents {
 		if doc.Content != "" {
-			docChan <- doc
+			result = append(result, doc)
 		}
 	}
+	return result
 }
 
 // createCommandDocument creates a document for command name and basic info
 func (vs *VectorStore) createCommandDocument(page MANPage) VectorDocument {
 	content := fmt.Sprintf("command %s: %s", page.Name, page.Description)
+	if len(page.Aliases) > 0 {
+		content += fmt.Sprintf(" (also run as %s)", strings.Join(page.Aliases, ", "))
+	}
 
 	return VectorDocument{
 		ID:      fmt.Sprintf("%s-command", page.Name),
@@ -149,6 +268,8 @@ func (vs *VectorStore) createCommandDocument(page MANPage) VectorDocument {
 			Command:     page.Name,
 			Section:     "command",
 			Description: page.Description,
+			Language:    page.Language,
+			Aliases:     page.Aliases,
 		},
 	}
 }
@@ -182,9 +303,10 @@ func (vs *VectorStore) createOptionsDocument(page MANPage) VectorDocument {
 		ID:      fmt.Sprintf("%s-options", page.Name),
 		Content: content,
 		Metadata: Metadata{
-			Command: page.Name,
-			Section: "options",
-			Options: page.Options,
+			Command:       page.Name,
+			Section:       "options",
+			Options:       page.Options,
+			OptionDetails: page.OptionDetails,
 		},
 	}
 }
@@ -225,48 +347,35 @@ func (vs *VectorStore) createSynopsisDocument(page MANPage) VectorDocument {
 	}
 }
 
-// addToIndex adds a document to the inverted index
-func (vs *VectorStore) addToIndex(doc VectorDocument) {
-	words := vs.tokenize(doc.Content)
+// createTLDRDocument creates a document from a tldr page's practical examples
+func (vs *VectorStore) createTLDRDocument(page TLDRPage) VectorDocument {
+	if len(page.Examples) == 0 {
+		return VectorDocument{}
+	}
+
+	content := fmt.Sprintf("examples for %s: %s %s", page.Name, page.Description, strings.Join(page.Examples, " | "))
 
-	for _, word := range words {
-		vs.index[word] = append(vs.index[word], doc.ID)
+	return VectorDocument{
+		ID:      fmt.Sprintf("%s-tldr", page.Name),
+		Content: content,
+		Metadata: Metadata{
+			Command:     page.Name,
+			Section:     "tldr",
+			Description: page.Description,
+			Examples:    page.Examples,
+			Source:      SourceTLDR,
+		},
 	}
 }
 
-// tokenize splits text into words for indexing
+// tokenize splits text into index terms with the store's analyzer
 func (vs *VectorStore) tokenize(text string) []string {
-	// Convert to lowercase and split
-	text = strings.ToLower(text)
-	words := strings.Fields(text)
-
-	// Simple stemming and filtering
-	var tokens []string
-	for _, word := range words {
-		// Remove common punctuation
-		word = strings.Trim(word, ".,!?;:\"'()[]{}")
-
-		// Filter out very short words and common stop words
-		if len(word) > 2 && !vs.isStopWord(word) {
-			tokens = append(tokens, word)
-		}
-	}
-
-	return tokens
+	return vs.analyzer.Analyze(text)
 }
 
-// isStopWord checks if a word is a common stop word
+// isStopWord checks if a word is a stopword for the store's analyzer
 func (vs *VectorStore) isStopWord(word string) bool {
-	stopWords := map[string]bool{
-		"the": true, "and": true, "for": true, "with": true, "this": true,
-		"that": true, "from": true, "are": true, "was": true, "were": true,
-		"have": true, "has": true, "had": true, "will": true, "would": true,
-		"could": true, "should": true, "can": true, "may": true, "might": true,
-		"which": true, "what": true, "when": true, "where": true, "why": true,
-		"how": true, "who": true, "whom": true, "whose": true,
-	}
-
-	return stopWords[word]
+	return vs.analyzer.IsStopWord(word)
 }
 
 // Search performs semantic search on the vector store
@@ -280,105 +389,14 @@ func (vs *VectorStore) Search(query string, limit int) ([]VectorDocument, error)
 	vs.mu.RLock()
 	defer vs.mu.RUnlock()
 
-	// Enhanced TF-IDF like scoring with better query understanding
-	queryWords := vs.tokenize(query)
-	docScores := make(map[string]float32)
-	totalDocs := float32(len(vs.documents))
-
-	// Calculate scores for each document
-	for _, word := range queryWords {
-		if docIDs, exists := vs.index[word]; exists {
-			// TF (term frequency) - simple count
-			tf := float32(len(docIDs)) / totalDocs
-
-			// IDF (inverse document frequency)
-			docFreq := float32(len(docIDs))
-			idf := float32(1.0)
-			if docFreq > 0 {
-				idf = float32(math.Log(float64(totalDocs) / float64(docFreq)))
-			}
-
-			// TF-IDF score
-			score := tf * idf
-
-			for _, docID := range docIDs {
-				docScores[docID] += score
-			}
-		}
-	}
-
-	// MAJOR IMPROVEMENT: Add significant bonus for exact command matches
-	queryLower := strings.ToLower(query)
-
-	// Check for common patterns in the query and boost relevant commands
-	if strings.Contains(queryLower, "list") && strings.Contains(queryLower, "file") {
-		// Boost ls, find, dir commands
-		for docID, doc := range vs.documents {
-			cmdLower := strings.ToLower(doc.Metadata.Command)
-			if cmdLower == "ls" || cmdLower == "find" || cmdLower == "dir" {
-				docScores[docID] += 3.0
-			}
-		}
-	}
-
-	if strings.Contains(queryLower, "directory") || strings.Contains(queryLower, "folder") {
-		// Boost directory-related commands
-		for docID, doc := range vs.documents {
-			cmdLower := strings.ToLower(doc.Metadata.Command)
-			if cmdLower == "ls" || cmdLower == "pwd" || cmdLower == "dir" {
-				docScores[docID] += 2.0
-			}
-		}
-	}
-
-	// Add bonus for exact command matches
-	if exactDocs := vs.searchExactCommand(query); len(exactDocs) > 0 {
-		for _, doc := range exactDocs {
-			docScores[doc.ID] += 2.0 // Bonus for exact matches
-		}
-	}
-
-	// Add bonus for partial matches in command names
-	for docID, doc := range vs.documents {
-		commandName := strings.ToLower(doc.Metadata.Command)
-		queryLower := strings.ToLower(query)
-
-		// Bonus if query contains command name
-		if strings.Contains(queryLower, commandName) {
-			docScores[docID] += 1.5
-		}
-
-		// Bonus if command name contains query words
-		for _, word := range queryWords {
-			if strings.Contains(commandName, word) {
-				docScores[docID] += 0.5
-			}
-		}
-	}
-
-	// NEW: Penalize completely irrelevant commands
-	for docID, doc := range vs.documents {
-		cmdLower := strings.ToLower(doc.Metadata.Command)
-		// Penalize git commands for non-git queries (unless git is mentioned)
-		if strings.HasPrefix(cmdLower, "git-") && !strings.Contains(queryLower, "git") {
-			docScores[docID] *= 0.1 // Reduce score by 90%
-		}
-		// Penalize kubectl commands for non-kubernetes queries
-		if strings.HasPrefix(cmdLower, "kubectl") && !strings.Contains(queryLower, "kube") {
-			docScores[docID] *= 0.1
-		}
-		// Penalize dangerous commands for safe queries
-		if (cmdLower == "killall" || cmdLower == "rm") &&
-			!strings.Contains(queryLower, "kill") && !strings.Contains(queryLower, "remove") {
-			docScores[docID] *= 0.1
-		}
-	}
+	// BM25 over field-weighted term frequencies, then configured boosts and source weights
+	docScores := vs.scoreDocuments(query, nil)
 
 	// Convert to results
 	var results []VectorDocument
 	for docID, score := range docScores {
-		if doc, exists := vs.documents[docID]; exists && score > 0.1 { // Increased threshold
-			doc.Similarity = score
+		if doc, exists := vs.documents[docID]; exists && score > vs.retrieval.MinScore {
+			doc.Similarity = float32(score)
 			results = append(results, doc)
 		}
 	}
@@ -392,6 +410,7 @@ func (vs *VectorStore) Search(query string, limit int) ([]VectorDocument, error)
 	if len(results) > limit {
 		results = results[:limit]
 	}
+	results = vs.fullDocuments(results)
 
 	color.Green("✅ Found %d relevant documents for '%s'", len(results), query)
 
@@ -414,47 +433,54 @@ func min(a, b int) int {
 	return b
 }
 
-// searchExactCommand searches for exact command matches
-func (vs *VectorStore) searchExactCommand(query string) []VectorDocument {
-	var results []VectorDocument
-	query = strings.ToLower(strings.TrimSpace(query))
+// GetCommandInfo retrieves comprehensive information about a command. A
+// command documented under another name, such as vi under vim, gets that
+// page's information.
+func (vs *VectorStore) GetCommandInfo(command string) (*CommandInfo, error) {
+	var info CommandInfo
+	info.Name = command
 
-	// Check if query contains a command name
-	for _, doc := range vs.documents {
-		if strings.ToLower(doc.Metadata.Command) == query {
-			results = append(results, doc)
+	var tldrDescription string
+	var tldrExamples []string
+
+	documents := vs.commandDocuments(command)
+	if len(documents) == 0 {
+		if target := vs.ResolveCommand(command); target != "" {
+			documents = vs.commandDocuments(target)
+			info.AliasOf = target
 		}
 	}
 
-	return results
-}
-
-// GetCommandInfo retrieves comprehensive information about a command
-func (vs *VectorStore) GetCommandInfo(command string) (*CommandInfo, error) {
-	if !vs.initialized {
-		return nil, fmt.Errorf("vector store not initialized")
+	// Collect all documents for this command
+	for _, doc := range documents {
+		switch doc.Metadata.Section {
+		case "command":
+			info.Description = doc.Metadata.Description
+			info.Language = doc.Metadata.Language
+		case "synopsis":
+			info.Synopsis = doc.Content
+		case "options":
+			info.Options = append(info.Options, doc.Metadata.Options...)
+		case "examples":
+			info.Examples = append(info.Examples, doc.Metadata.Examples...)
+		case exitStatusSection:
+			info.ExitStatus = doc.Metadata.Entries
+		case filesSection:
+			info.Files = doc.Metadata.Entries
+		case environmentSection:
+			info.Environment = doc.Metadata.Entries
+		case "tldr":
+			tldrDescription = doc.Metadata.Description
+			tldrExamples = doc.Metadata.Examples
+		}
 	}
 
-	vs.mu.RLock()
-	defer vs.mu.RUnlock()
+	// tldr examples are practical one-liners, so they go first
+	info.Examples = append(tldrExamples, info.Examples...)
 
-	var info CommandInfo
-	info.Name = command
-
-	// Collect all documents for this command
-	for _, doc := range vs.documents {
-		if doc.Metadata.Command == command {
-			switch doc.Metadata.Section {
-			case "command":
-				info.Description = doc.Metadata.Description
-			case "synopsis":
-				info.Synopsis = doc.Content
-			case "options":
-				info.Options = append(info.Options, doc.Metadata.Options...)
-			case "examples":
-				info.Examples = append(info.Examples, doc.Metadata.Examples...)
-			}
-		}
+	// Commands without a MAN page can still be described by tldr
+	if info.Description == "" {
+		info.Description = tldrDescription
 	}
 
 	// Remove duplicates
@@ -468,6 +494,25 @@ func (vs *VectorStore) GetCommandInfo(command string) (*CommandInfo, error) {
 	return &info, nil
 }
 
+// commandDocuments returns a command's documents. Before the full index is
+// loaded they are read from disk directly, so single lookups stay cheap.
+func (vs *VectorStore) commandDocuments(command string) []VectorDocument {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	if !vs.initialized {
+		return vs.loadCommandDocuments(command)
+	}
+
+	var documents []VectorDocument
+	for _, doc := range vs.documents {
+		if doc.Metadata.Command == command {
+			documents = append(documents, doc)
+		}
+	}
+	return vs.fullDocuments(documents)
+}
+
 // CommandInfo contains comprehensive command information
 type CommandInfo struct {
 	Name        string   `json:"name"`
@@ -475,6 +520,14 @@ type CommandInfo struct {
 	Synopsis    string   `json:"synopsis"`
 	Options     []string `json:"options"`
 	Examples    []string `json:"examples"`
+	Language    string   `json:"language,omitempty"`
+	Passages    []string `json:"passages,omitempty"`  // section chunks that matched the query
+	Documents   []string `json:"documents,omitempty"` // IDs of the documents that matched the query
+	ExitStatus  []string `json:"exit_status,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	Environment []string `json:"environment,omitempty"`
+	Source      string   `json:"source,omitempty"`   // "project" for /rag-add documents
+	AliasOf     string   `json:"alias_of,omitempty"` // page the command is documented under
 }
 
 // removeDuplicates removes duplicate strings from a slice
@@ -501,18 +554,36 @@ func (vs *VectorStore) GetRelevantCommands(query string, maxResults int) ([]Comm
 
 	// Group by command and get best match for each
 	commandDocs := make(map[string]VectorDocument)
+	passages := make(map[string][]string)
+	matched := make(map[string][]string)
 	for _, doc := range docs {
+		matched[doc.Metadata.Command] = append(matched[doc.Metadata.Command], doc.ID)
 		current, exists := commandDocs[doc.Metadata.Command]
 		if !exists || doc.Similarity > current.Similarity {
 			commandDocs[doc.Metadata.Command] = doc
 		}
+		// Results are sorted, so the best matching chunks come first
+		if isPassageSection(doc.Metadata.Section) && len(passages[doc.Metadata.Command]) < maxPassagesPerResult {
+			passages[doc.Metadata.Command] = append(passages[doc.Metadata.Command], formatPassage(doc))
+		}
+	}
+
+	// Best matching commands first, so callers can take a prefix
+	commands := make([]string, 0, len(commandDocs))
+	for command := range commandDocs {
+		commands = append(commands, command)
 	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commandDocs[commands[i]].Similarity > commandDocs[commands[j]].Similarity
+	})
 
 	// Convert to CommandInfo
 	var results []CommandInfo
-	for command := range commandDocs {
+	for _, command := range commands {
 		info, err := vs.GetCommandInfo(command)
 		if err == nil {
+			info.Passages = passages[command]
+			info.Documents = matched[command]
 			results = append(results, *info)
 		}
 
@@ -547,63 +618,67 @@ func (vs *VectorStore) ensureIndexDir() error {
 	return nil
 }
 
-// saveVectorIndex saves the vector index to disk
+// saveVectorIndex writes the documents changed since the last save to disk
 func (vs *VectorStore) saveVectorIndex() error {
-	indexFile := filepath.Join(vs.indexDir, "vector_index.json")
-	color.Cyan("💾 Saving vector index to: %s", indexFile)
-
 	// Ensure directory exists
 	if err := vs.ensureIndexDir(); err != nil {
 		return fmt.Errorf("failed to ensure index directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(vs.documents, "", "  ")
+	written, err := vs.writeDirtyDocuments()
 	if err != nil {
-		return fmt.Errorf("failed to marshal index: %w", err)
-	}
-
-	// Write with temporary file first to avoid corruption
-	tempFile := indexFile + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
-		color.Red("❌ Failed to write temporary index file: %v", err)
-		return fmt.Errorf("failed to write temporary file: %w", err)
-	}
-
-	// Rename to final file (atomic operation)
-	if err := os.Rename(tempFile, indexFile); err != nil {
-		color.Red("❌ Failed to rename temporary index file: %v", err)
-		return fmt.Errorf("failed to rename file: %w", err)
+		color.Red("❌ Failed to save vector index: %v", err)
+		return err
 	}
 
-	color.Green("💾 Vector index saved successfully: %s", indexFile)
-	color.Green("📊 Index contains %d documents", len(vs.documents))
+	color.Green("💾 Vector index saved: %d documents written to %s", written, vs.dbPath())
+	color.Green("📊 Index contains %d documents", vs.DocumentCount())
 	return nil
 }
 
-// loadVectorIndex loads the vector index from disk
+// loadVectorIndex loads the vector index from disk, migrating an old JSON index if present
 func (vs *VectorStore) loadVectorIndex() error {
-	indexFile := filepath.Join(vs.indexDir, "vector_index.json")
+	if err := vs.migrateLegacyIndex(); err != nil {
+		color.Yellow("⚠️  Could not migrate legacy vector index: %v", err)
+	}
+	if err := vs.migrateSchema(); err != nil {
+		color.Yellow("⚠️  Could not migrate vector database schema: %v", err)
+	}
 
-	data, err := os.ReadFile(indexFile)
+	documents, corrupted, err := vs.readDocuments("")
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, os.ErrNotExist) {
 			color.Yellow("⚠️  No existing vector index found")
 			return nil
 		}
-		return fmt.Errorf("failed to read index file: %w", err)
+		return fmt.Errorf("failed to read vector database: %w", err)
 	}
+	info, infoErr := vs.readIndexInfo()
 
-	if err := json.Unmarshal(data, &vs.documents); err != nil {
-		return fmt.Errorf("failed to unmarshal index: %w", err)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	// Unsaved in-memory changes take precedence over what is on disk
+	for docID := range vs.dirty {
+		if doc, exists := vs.documents[docID]; exists {
+			documents[docID] = doc
+		} else {
+			delete(documents, docID)
+		}
 	}
+	vs.documents = documents
+	vs.corrupted = corrupted
 
 	// Rebuild the inverted index
-	vs.index = make(map[string][]string)
-	for _, doc := range vs.documents {
-		vs.addToIndex(doc)
+	vs.rebuildIndex()
+
+	// Indexes from an older builder are still served until a rebuild replaces them
+	if infoErr == nil {
+		vs.info = info
+		vs.stale = !info.Matches(currentIndexInfo())
 	}
 
-	vs.initialized = true
+	vs.initialized = len(vs.documents) > 0
 	color.Green("✅ Loaded vector index with %d documents", len(vs.documents))
 	return nil
 }
@@ -0,0 +1,290 @@
+package rag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"helix/internal/utils"
+
+	"github.com/fatih/color"
+	bolt "go.etcd.io/bbolt"
+)
+
+// indexBuilderVersion must be bumped whenever MAN, tldr or PowerShell parsing
+// or document construction changes, so existing indexes are rebuilt
+const indexBuilderVersion = "7"
+
+// legacyBuilder marks indexes migrated from vector_index.json
+const legacyBuilder = "legacy-json"
+
+var builderKey = []byte("builder")
+
+// IndexInfo describes how an on-disk index was built
+type IndexInfo struct {
+	Schema  string `json:"schema"`
+	Builder string `json:"builder"`
+}
+
+// currentIndexInfo describes indexes built by this version of Helix
+func currentIndexInfo() IndexInfo {
+	return IndexInfo{Schema: vectorDBSchemaVersion, Builder: builderFingerprint()}
+}
+
+// builderFingerprint hashes the builder version together with the stored
+// document layout, so adding a field also invalidates old indexes
+func builderFingerprint() string {
+	var sb strings.Builder
+	sb.WriteString(indexBuilderVersion)
+	for _, t := range []reflect.Type{
+		reflect.TypeOf(VectorDocument{}),
+		reflect.TypeOf(Metadata{}),
+		reflect.TypeOf(MANPage{}),
+	} {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fmt.Fprintf(&sb, "|%s.%s:%s:%s", t.Name(), field.Name, field.Type, field.Tag.Get("json"))
+		}
+	}
+
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Matches reports whether an index was built by the current builder
+func (info IndexInfo) Matches(other IndexInfo) bool {
+	return info.Schema == other.Schema && info.Builder == other.Builder
+}
+
+// readIndexInfo reads the schema and builder recorded in the database
+func (vs *VectorStore) readIndexInfo() (IndexInfo, error) {
+	db, err := vs.openDB(true)
+	if err != nil {
+		return IndexInfo{}, err
+	}
+	defer db.Close()
+
+	var info IndexInfo
+	err = db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			info.Schema = string(meta.Get(schemaKey))
+			info.Builder = string(meta.Get(builderKey))
+		}
+		return nil
+	})
+	return info, err
+}
+
+// writeIndexInfo records how the index was built
+func (vs *VectorStore) writeIndexInfo(info IndexInfo) error {
+	db, err := vs.openDB(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if err := meta.Put(schemaKey, []byte(info.Schema)); err != nil {
+			return err
+		}
+		return meta.Put(builderKey, []byte(info.Builder))
+	})
+}
+
+// IsStale reports whether the loaded index was built by an older Helix
+func (vs *VectorStore) IsStale() bool {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return vs.stale
+}
+
+// IndexInfo returns how the loaded index was built
+func (vs *VectorStore) IndexInfo() IndexInfo {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return vs.info
+}
+
+// ReplaceAll swaps the whole index for freshly built documents. The new
+// database is written next to the old one and renamed over it, so readers
+// keep using the old index until the new one is complete.
+func (vs *VectorStore) ReplaceAll(documents map[string]VectorDocument) error {
+	if err := vs.ensureIndexDir(); err != nil {
+		return err
+	}
+
+	stagingPath := vs.dbPath() + ".rebuild"
+	os.Remove(stagingPath)
+
+	db, err := bolt.Open(stagingPath, 0644, &bolt.Options{Timeout: vectorDBOpenTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to create staging database: %w", err)
+	}
+
+	info := currentIndexInfo()
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucket(metaBucket)
+		if err != nil {
+			return err
+		}
+		if err := meta.Put(schemaKey, []byte(info.Schema)); err != nil {
+			return err
+		}
+		if err := meta.Put(builderKey, []byte(info.Builder)); err != nil {
+			return err
+		}
+
+		bucket, err := tx.CreateBucket(documentsBucket)
+		if err != nil {
+			return err
+		}
+		checksums, err := tx.CreateBucket(checksumsBucket)
+		if err != nil {
+			return err
+		}
+		for docID, doc := range documents {
+			data, err := json.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(docID), data); err != nil {
+				return err
+			}
+			if err := checksums.Put([]byte(docID), documentChecksum(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(stagingPath)
+		return fmt.Errorf("failed to write rebuilt index: %w", err)
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if err := os.Rename(stagingPath, vs.dbPath()); err != nil {
+		os.Remove(stagingPath)
+		return fmt.Errorf("failed to activate rebuilt index: %w", err)
+	}
+
+	vs.documents = documents
+	vs.dirty = make(map[string]bool)
+	vs.rebuildIndex()
+	vs.info = info
+	vs.stale = false
+	vs.initialized = len(vs.documents) > 0
+	return nil
+}
+
+// IsRebuilding reports whether a background index rebuild is running
+func (rs *RAGSystem) IsRebuilding() bool {
+	return rs.rebuilding.Load()
+}
+
+// rebuildIfStale starts a background rebuild when the loaded index was built
+// by an older version of Helix
+func (rs *RAGSystem) rebuildIfStale() {
+	if !rs.vectorStore.IsStale() {
+		return
+	}
+
+	info := rs.vectorStore.IndexInfo()
+	color.Yellow("🔁 RAG index was built by an older Helix (builder %s, current %s)",
+		displayBuilder(info.Builder), builderFingerprint())
+	color.Yellow("💡 Rebuilding in the background; the existing index stays in use until it is ready")
+	rs.RebuildIndex()
+}
+
+// RebuildIndex rebuilds the whole index in the background while the current
+// one keeps serving queries. It returns false if a rebuild is already running.
+func (rs *RAGSystem) RebuildIndex() bool {
+	if !rs.rebuilding.CompareAndSwap(false, true) {
+		return false
+	}
+
+	rs.progress.start()
+	go func() {
+		defer rs.rebuilding.Store(false)
+		defer rs.progress.finish()
+		startTime := time.Now()
+
+		if err := rs.ensureIndexDir(); err != nil {
+			color.Yellow("⚠️  Index rebuild failed: %v", err)
+			return
+		}
+
+		if err := rs.indexDocumentation(); err != nil {
+			color.Yellow("⚠️  Documentation indexing had issues: %v", err)
+		}
+
+		var pages []MANPage
+		if rs.usesPowerShellHelp() {
+			pages = rs.powershell.GetAllIndexedPages()
+		} else {
+			pages = rs.indexer.GetAllIndexedPages()
+		}
+		if len(pages) == 0 {
+			color.Yellow("⚠️  Index rebuild found no pages; keeping the existing index")
+			return
+		}
+
+		rs.progress.setPhase(PhaseEmbedding)
+		rs.progress.update(func(p *IndexingProgress) { p.PagesToEmbed = len(pages) })
+
+		documents := make(map[string]VectorDocument)
+		for _, page := range pages {
+			built := rs.vectorStore.buildMANPageDocuments(page)
+			for _, doc := range built {
+				documents[doc.ID] = doc
+			}
+			rs.progress.update(func(p *IndexingProgress) {
+				p.PagesEmbedded++
+				p.DocsEmbedded += len(built)
+			})
+		}
+
+		for _, doc := range rs.sourceDocuments() {
+			documents[doc.ID] = doc
+		}
+
+		if err := rs.vectorStore.ReplaceAll(documents); err != nil {
+			color.Yellow("⚠️  Index rebuild failed, keeping the existing index: %v", err)
+			return
+		}
+
+		rs.initialized = rs.vectorStore.IsInitialized()
+		if !rs.usesPowerShellHelp() {
+			rs.recordManifest()
+		}
+		if err := rs.saveSystemState(); err != nil {
+			color.Yellow("⚠️  Could not save RAG state: %v", err)
+		}
+
+		color.Green("✅ RAG index rebuilt: %d pages, %d documents in %s",
+			len(pages), len(documents), utils.FormatDuration(time.Since(startTime)))
+	}()
+
+	return true
+}
+
+// displayBuilder shows a readable builder name for status output
+func displayBuilder(builder string) string {
+	if builder == "" {
+		return "unknown"
+	}
+	return builder
+}
@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"testing"
+)
+
+func TestIndexFromOlderBuilderIsRebuilt(t *testing.T) {
+	dir := t.TempDir()
+	old := newTestStore(t, dir)
+	addTestDocuments(old,
+		testDocument("ls", "command", "list directory contents"),
+		testDocument("cp", "command", "copy files and directories"),
+	)
+	if err := old.saveVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.writeIndexInfo(IndexInfo{Schema: vectorDBSchemaVersion, Builder: "old-builder"}); err != nil {
+		t.Fatal(err)
+	}
+
+	vs := newTestStore(t, dir)
+	if err := vs.loadVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if !vs.IsStale() {
+		t.Fatal("an index from another builder was not marked stale")
+	}
+	// The old index keeps serving until the rebuild replaces it
+	if vs.DocumentCount() != 2 || !vs.IsInitialized() {
+		t.Fatalf("the stale index has %d documents, want 2", vs.DocumentCount())
+	}
+
+	rebuilt := map[string]VectorDocument{}
+	for _, doc := range []VectorDocument{
+		testDocument("ls", "command", "list directory contents"),
+		testDocument("mv", "command", "move or rename files"),
+		testDocument("rm", "command", "remove files or directories"),
+	} {
+		rebuilt[doc.ID] = doc
+	}
+	if err := vs.ReplaceAll(rebuilt); err != nil {
+		t.Fatal(err)
+	}
+	if vs.IsStale() || !vs.IndexInfo().Matches(currentIndexInfo()) {
+		t.Fatalf("after the rebuild the index is stale (info %+v)", vs.IndexInfo())
+	}
+
+	reloaded := newTestStore(t, dir)
+	if err := reloaded.loadVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.IsStale() {
+		t.Fatal("the rebuilt index is stale when loaded again")
+	}
+	if reloaded.DocumentCount() != 3 {
+		t.Fatalf("the rebuilt index has %d documents, want 3", reloaded.DocumentCount())
+	}
+	if _, kept := reloaded.documents["cp-command"]; kept {
+		t.Fatal("a document of the old index survived the rebuild")
+	}
+}
+
+func TestIndexFromCurrentBuilderIsNotStale(t *testing.T) {
+	dir := t.TempDir()
+	vs := newTestStore(t, dir)
+	addTestDocuments(vs, testDocument("ls", "command", "list directory contents"))
+	if err := vs.saveVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newTestStore(t, dir)
+	if err := loaded.loadVectorIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.IsStale() {
+		t.Fatalf("a freshly saved index is stale (info %+v, current %+v)", loaded.IndexInfo(), currentIndexInfo())
+	}
+}
@@ -0,0 +1,740 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Script is a parsed command line: statements joined by ;, &&, || or &
+type Script struct {
+	Stmts []*Stmt
+}
+
+// Stmt is one pipeline and the operator that follows it
+type Stmt struct {
+	Pipeline *Pipeline
+	Op       string // ";", "&&", "||", "&" or "" at the end
+	Text     string // the pipeline as written
+}
+
+// Pipeline is one or more commands joined by |
+type Pipeline struct {
+	Negated  bool // starts with !
+	Commands []*Command
+}
+
+// Command is a simple command, or a subshell or brace group when Subshell
+// or Group is set
+type Command struct {
+	Assigns  []*Word // NAME=value before the program
+	Args     []*Word // the program and its arguments
+	Redirs   []*Redirect
+	Subshell *Script // ( ... )
+	Group    *Script // { ...; }, run in the current shell
+}
+
+// Word is one shell word. Value is the word with quotes removed; expansions
+// stay in it as written, since they are only known at run time.
+type Word struct {
+	Raw     string
+	Value   string
+	Pos     int
+	Quoted  bool      // some of it was quoted or escaped
+	Expands bool      // contains $ expansions or command substitutions
+	Glob    bool      // contains unquoted *, ? or [
+	Substs  []*Script // command substitutions, $(...) and `...`
+}
+
+// Redirect is a redirection like 2> file or < input
+type Redirect struct {
+	Fd      string // explicit file descriptor, e.g. "2"
+	Op      string // <, >, >>, >|, <>, <<, <<-, <<<, <&, >&, &>, &>>
+	Target  *Word
+	Heredoc string // the body of << and <<-, read from the lines that follow
+}
+
+// ParseError reports where a command line stops being valid shell
+type ParseError struct {
+	Pos int // byte offset in the command line
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (column %d)", e.Msg, e.Pos+1)
+}
+
+// Parse parses a POSIX shell command line. Compound commands such as if,
+// for and while are read as plain words; case ... esac, [[ ... ]] and
+// (( ... )) are kept whole, and here-document bodies are not parsed.
+func Parse(src string) (*Script, error) {
+	p := &parser{src: src}
+	script, err := p.script(0)
+	if err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// TrimWrappingQuotes removes quotes around a whole command line, as models
+// sometimes add them, but keeps quotes that belong to the command, as in
+// echo "it's here"
+func TrimWrappingQuotes(command string) string {
+	if script, err := Parse(command); err == nil {
+		commands := script.Commands()
+		wrapped := len(commands) == 1 && len(commands[0].Args) == 1 && len(commands[0].Redirs) == 0 &&
+			strings.ContainsAny(command[:1], `"'`)
+		if !wrapped {
+			return command
+		}
+	}
+	return strings.Trim(command, `"'`)
+}
+
+// Commands returns every simple command in the script, including those in
+// subshells and command substitutions, in the order they appear
+func (s *Script) Commands() []*Command {
+	var commands []*Command
+	for _, stmt := range s.Stmts {
+		for _, cmd := range stmt.Pipeline.Commands {
+			for _, word := range append(append([]*Word{}, cmd.Assigns...), cmd.Args...) {
+				for _, subst := range word.Substs {
+					commands = append(commands, subst.Commands()...)
+				}
+			}
+			if cmd.Subshell != nil {
+				commands = append(commands, cmd.Subshell.Commands()...)
+				continue
+			}
+			if cmd.Group != nil {
+				commands = append(commands, cmd.Group.Commands()...)
+				continue
+			}
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// Background reports whether any statement runs in the background with &
+func (s *Script) Background() bool {
+	for _, stmt := range s.Stmts {
+		if stmt.Op == "&" {
+			return true
+		}
+	}
+	return false
+}
+
+// parser reads a command line one byte at a time; shell syntax is ASCII,
+// other bytes only ever appear inside words
+type parser struct {
+	src      string
+	pos      int
+	base     int         // offset of src in the full command line, for backtick contents
+	heredocs []*Redirect // here-documents whose bodies start after the next newline
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) peekAt(offset int) byte {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *parser) hasPrefix(s string) bool { return strings.HasPrefix(p.src[p.pos:], s) }
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Pos: p.base + pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipBlanks skips spaces, tabs, line continuations and comments
+func (p *parser) skipBlanks() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\\' && p.peekAt(1) == '\n':
+			p.pos += 2
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// newline consumes a newline and the bodies of the here-documents started
+// on the line it ends
+func (p *parser) newline() {
+	p.pos++
+	for _, redir := range p.heredocs {
+		strip := redir.Op == "<<-"
+		var body strings.Builder
+		for !p.eof() {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			line := p.src[p.pos : p.pos+end]
+			p.pos += end
+			if !p.eof() {
+				p.pos++
+			}
+			if strip {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == redir.Target.Value {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+		redir.Heredoc = body.String()
+	}
+	p.heredocs = nil
+}
+
+// skipNewlines skips blank lines and comments between statements
+func (p *parser) skipNewlines() {
+	for p.skipBlanks(); p.peek() == '\n'; p.skipBlanks() {
+		p.newline()
+	}
+}
+
+// atReserved reports whether a reserved word like } or ]] is next, on its own
+func (p *parser) atReserved(word string) bool {
+	if !p.hasPrefix(word) {
+		return false
+	}
+	next := p.peekAt(len(word))
+	return next == 0 || strings.IndexByte(" \t\r\n;&|)", next) >= 0
+}
+
+// script parses statements until the end of input or, inside a subshell,
+// $(...) or a brace group, the closing ) or }
+func (p *parser) script(closing byte) (*Script, error) {
+	script := &Script{}
+	start := p.pos
+	for {
+		p.skipNewlines()
+		if p.eof() {
+			switch closing {
+			case ')':
+				return nil, p.errorf(start-1, "unmatched (")
+			case '}':
+				return nil, p.errorf(start-1, "missing }")
+			}
+			return script, nil
+		}
+		if closing == '}' && p.atReserved("}") {
+			p.pos++
+			return script, nil
+		}
+		if p.peek() == ')' {
+			if closing != ')' {
+				return nil, p.errorf(p.pos, "unexpected )")
+			}
+			p.pos++
+			return script, nil
+		}
+
+		pipelineStart := p.pos
+		pipeline, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		stmt := &Stmt{Pipeline: pipeline, Text: strings.TrimSpace(p.src[pipelineStart:p.pos])}
+		script.Stmts = append(script.Stmts, stmt)
+
+		p.skipBlanks()
+		opPos := p.pos
+		switch {
+		case p.hasPrefix("&&"), p.hasPrefix("||"):
+			stmt.Op = p.src[p.pos : p.pos+2]
+			p.pos += 2
+			// The next pipeline may start on a following line
+			p.skipNewlines()
+			if p.eof() || p.peek() == ')' || p.peek() == ';' || p.peek() == '&' || p.peek() == '|' {
+				return nil, p.errorf(opPos, "missing command after %s", stmt.Op)
+			}
+		case p.hasPrefix(";;"):
+			return nil, p.errorf(opPos, "unexpected ;;")
+		case p.peek() == ';' || p.peek() == '&' || p.peek() == '\n':
+			stmt.Op = string(p.peek())
+			if stmt.Op == "\n" {
+				stmt.Op = ";"
+				p.newline()
+			} else {
+				p.pos++
+			}
+			p.skipBlanks()
+			if c := p.peek(); c == ';' || c == '&' || c == '|' {
+				return nil, p.errorf(p.pos, "unexpected %c", c)
+			}
+		}
+	}
+}
+
+// pipeline parses commands joined by | or |&
+func (p *parser) pipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	if p.peek() == '!' && (p.peekAt(1) == ' ' || p.peekAt(1) == '\t') {
+		pipeline.Negated = true
+		p.pos++
+		p.skipBlanks()
+	}
+
+	for {
+		cmd, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, cmd)
+
+		p.skipBlanks()
+		if p.peek() != '|' || p.peekAt(1) == '|' {
+			return pipeline, nil
+		}
+		pipePos := p.pos
+		p.pos++
+		if p.peek() == '&' {
+			p.pos++
+		}
+		p.skipNewlines()
+		if p.eof() || strings.ContainsRune(");&|", rune(p.peek())) {
+			return nil, p.errorf(pipePos, "missing command after |")
+		}
+	}
+}
+
+// command parses a simple command or a subshell with its redirections
+func (p *parser) command() (*Command, error) {
+	cmd := &Command{}
+	start := p.pos
+
+	switch c := p.peek(); {
+	case c == 0 || c == ';' || c == '&' || c == '|' || c == ')':
+		return nil, p.errorf(p.pos, "unexpected %s", tokenName(c))
+	case p.hasPrefix("(("):
+		word, err := p.arithmetic()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Args = append(cmd.Args, word)
+	case c == '(':
+		p.pos++
+		sub, err := p.script(')')
+		if err != nil {
+			return nil, err
+		}
+		if len(sub.Stmts) == 0 {
+			return nil, p.errorf(start, "empty subshell")
+		}
+		cmd.Subshell = sub
+	case p.atReserved("{"):
+		p.pos++
+		group, err := p.script('}')
+		if err != nil {
+			return nil, err
+		}
+		if len(group.Stmts) == 0 {
+			return nil, p.errorf(start, "empty { }")
+		}
+		cmd.Group = group
+	case p.atReserved("[["):
+		if err := p.conditional(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		p.skipBlanks()
+		c := p.peek()
+		if c == 0 || c == '\n' || c == ';' || c == '|' || c == ')' || (c == '&' && p.peekAt(1) != '>') {
+			break
+		}
+
+		if p.atRedirect() {
+			redir, err := p.redirect()
+			if err != nil {
+				return nil, err
+			}
+			cmd.Redirs = append(cmd.Redirs, redir)
+			continue
+		}
+
+		if c == '(' {
+			// name() starts a function definition
+			if len(cmd.Args) == 1 && len(cmd.Redirs) == 0 && p.peekAt(1) == ')' {
+				p.pos += 2
+				cmd.Args[0].Raw += "()"
+				cmd.Args[0].Value += "()"
+				continue
+			}
+			return nil, p.errorf(p.pos, "unexpected (")
+		}
+		if cmd.Subshell != nil {
+			return nil, p.errorf(p.pos, "unexpected word after subshell")
+		}
+		if cmd.Group != nil {
+			return nil, p.errorf(p.pos, "unexpected word after { }")
+		}
+
+		word, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		if len(cmd.Args) == 0 && isAssignment(word) {
+			cmd.Assigns = append(cmd.Assigns, word)
+			continue
+		}
+		cmd.Args = append(cmd.Args, word)
+
+		if len(cmd.Args) == 1 && word.Raw == "case" {
+			if err := p.caseBody(cmd); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(cmd.Args) == 0 && len(cmd.Assigns) == 0 && len(cmd.Redirs) == 0 && cmd.Subshell == nil && cmd.Group == nil {
+		return nil, p.errorf(start, "missing command")
+	}
+	return cmd, nil
+}
+
+// caseBody keeps case ... esac whole, since its patterns end in ) and its
+// branches in ;;
+func (p *parser) caseBody(cmd *Command) error {
+	start := p.pos
+	for i := p.pos; i+4 <= len(p.src); i++ {
+		if p.src[i:i+4] != "esac" {
+			continue
+		}
+		before := i == 0 || strings.ContainsRune(" \t\n;", rune(p.src[i-1]))
+		after := i+4 == len(p.src) || strings.ContainsRune(" \t\n;&|)", rune(p.src[i+4]))
+		if before && after {
+			body := p.src[start : i+4]
+			cmd.Args = append(cmd.Args, &Word{Raw: body, Value: body, Pos: p.base + start})
+			p.pos = i + 4
+			return nil
+		}
+	}
+	return p.errorf(start, "missing esac")
+}
+
+// conditional keeps [[ ... ]] whole: inside it, &&, ||, (, ), < and > are
+// part of the test, not operators of the command line
+func (p *parser) conditional(cmd *Command) error {
+	start := p.pos
+	cmd.Args = append(cmd.Args, &Word{Raw: "[[", Value: "[[", Pos: p.base + p.pos})
+	p.pos += 2
+	for {
+		p.skipBlanks()
+		pos := p.pos
+		switch {
+		case p.eof():
+			return p.errorf(start, "missing ]]")
+		case p.atReserved("]]"):
+			p.pos += 2
+			cmd.Args = append(cmd.Args, &Word{Raw: "]]", Value: "]]", Pos: p.base + pos})
+			return nil
+		case p.peek() == '\n':
+			p.pos++
+			continue
+		case p.hasPrefix("&&"), p.hasPrefix("||"):
+			p.pos += 2
+		case strings.IndexByte("()<>!|", p.peek()) >= 0:
+			p.pos++
+		default:
+			word, err := p.word()
+			if err != nil {
+				return err
+			}
+			if word.Raw == "" {
+				return p.errorf(p.pos, "unexpected %s in [[ ]]", tokenName(p.peek()))
+			}
+			cmd.Args = append(cmd.Args, word)
+			continue
+		}
+		op := p.src[pos:p.pos]
+		cmd.Args = append(cmd.Args, &Word{Raw: op, Value: op, Pos: p.base + pos})
+	}
+}
+
+// atRedirect reports whether a redirection starts here, e.g. >, 2>> or &>
+func (p *parser) atRedirect() bool {
+	i := p.pos
+	for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+		i++
+	}
+	if i < len(p.src) && (p.src[i] == '<' || p.src[i] == '>') {
+		return true
+	}
+	return i == p.pos && p.hasPrefix("&>")
+}
+
+// redirectOps lists redirection operators, longest first
+var redirectOps = []string{"&>>", "<<<", "<<-", "&>", ">>", ">|", ">&", "<<", "<>", "<&", "<", ">"}
+
+// redirect parses a redirection and its target
+func (p *parser) redirect() (*Redirect, error) {
+	redir := &Redirect{}
+	start := p.pos
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	redir.Fd = p.src[start:p.pos]
+
+	for _, op := range redirectOps {
+		if p.hasPrefix(op) {
+			redir.Op = op
+			p.pos += len(op)
+			break
+		}
+	}
+
+	p.skipBlanks()
+	if c := p.peek(); c == 0 || strings.ContainsRune("\n;&|<>()", rune(c)) {
+		return nil, p.errorf(start, "missing target for %s", redir.Op)
+	}
+	target, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	redir.Target = target
+	if redir.Op == "<<" || redir.Op == "<<-" {
+		p.heredocs = append(p.heredocs, redir)
+	}
+	return redir, nil
+}
+
+// word parses one word, with its quotes, escapes and expansions
+func (p *parser) word() (*Word, error) {
+	word := &Word{Pos: p.base + p.pos}
+	start := p.pos
+	var value strings.Builder
+
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == '&' || c == '|' || c == '<' || c == '>' || c == '(' || c == ')':
+			word.Raw = p.src[start:p.pos]
+			word.Value = value.String()
+			return word, nil
+		case c == '\\':
+			word.Quoted = true
+			p.pos++
+			if !p.eof() {
+				value.WriteByte(p.peek())
+				p.pos++
+			}
+		case c == '\'':
+			word.Quoted = true
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return nil, p.errorf(p.pos, "unterminated single quote")
+			}
+			value.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+		case c == '"':
+			word.Quoted = true
+			if err := p.doubleQuoted(word, &value); err != nil {
+				return nil, err
+			}
+		case c == '$' || c == '`':
+			if err := p.expansion(word, &value); err != nil {
+				return nil, err
+			}
+		case c == '*' || c == '?' || c == '[':
+			word.Glob = true
+			value.WriteByte(c)
+			p.pos++
+		default:
+			value.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	word.Raw = p.src[start:p.pos]
+	word.Value = value.String()
+	return word, nil
+}
+
+// doubleQuoted parses a "..." string, in which only $, ` and \ are special
+func (p *parser) doubleQuoted(word *Word, value *strings.Builder) error {
+	start := p.pos
+	p.pos++
+	for {
+		if p.eof() {
+			return p.errorf(start, "unterminated double quote")
+		}
+		switch c := p.peek(); c {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			p.pos++
+			if !p.eof() {
+				if next := p.peek(); next != '"' && next != '\\' && next != '$' && next != '`' && next != '\n' {
+					value.WriteByte('\\')
+				}
+				value.WriteByte(p.peek())
+				p.pos++
+			}
+		case '$', '`':
+			if err := p.expansion(word, value); err != nil {
+				return err
+			}
+		default:
+			value.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// expansion parses $name, ${...}, $(...), $((...)), $'...' or `...`
+func (p *parser) expansion(word *Word, value *strings.Builder) error {
+	start := p.pos
+	switch {
+	case p.peek() == '`':
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != '`' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return p.errorf(start, "unterminated backtick")
+		}
+		sub := &parser{src: p.src[start+1 : end], base: p.base + start + 1}
+		script, err := sub.script(0)
+		if err != nil {
+			return err
+		}
+		word.Substs = append(word.Substs, script)
+		p.pos = end + 1
+	case p.hasPrefix("$(("):
+		if _, err := p.arithmetic(); err != nil {
+			return err
+		}
+	case p.hasPrefix("$("):
+		p.pos += 2
+		script, err := p.script(')')
+		if err != nil {
+			return err
+		}
+		word.Substs = append(word.Substs, script)
+	case p.hasPrefix("${"):
+		p.pos += 2
+		for depth := 1; depth > 0; p.pos++ {
+			if p.eof() {
+				return p.errorf(start, "unterminated ${")
+			}
+			switch p.peek() {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+	case p.hasPrefix("$'"):
+		end := p.pos + 2
+		for end < len(p.src) && p.src[end] != '\'' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return p.errorf(start, "unterminated $' quote")
+		}
+		word.Quoted = true
+		value.WriteString(p.src[start+2 : end])
+		p.pos = end + 1
+		return nil
+	default:
+		p.pos++
+		if isSpecialParam(p.peek()) {
+			p.pos++
+		} else {
+			for !p.eof() && isNameChar(p.peek()) {
+				p.pos++
+			}
+		}
+		if p.pos == start+1 {
+			value.WriteByte('$') // a lone $ is literal
+			return nil
+		}
+	}
+
+	word.Expands = true
+	value.WriteString(p.src[start:p.pos])
+	return nil
+}
+
+// arithmetic parses $((...)) or ((...)) up to the matching ))
+func (p *parser) arithmetic() (*Word, error) {
+	start := p.pos
+	if p.peek() == '$' {
+		p.pos++
+	}
+	p.pos += 2
+	depth := 2
+	for !p.eof() && depth > 0 {
+		switch p.peek() {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+	}
+	if depth > 0 {
+		return nil, p.errorf(start, "unterminated ((")
+	}
+	raw := p.src[start:p.pos]
+	return &Word{Raw: raw, Value: raw, Pos: p.base + start, Expands: true}, nil
+}
+
+// isAssignment reports whether a word is NAME=value
+func isAssignment(word *Word) bool {
+	name, _, ok := strings.Cut(word.Raw, "=")
+	if !ok || name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isSpecialParam(c byte) bool {
+	return strings.IndexByte("@*#?-$!0123456789", c) >= 0 && c != 0
+}
+
+// tokenName names a character in an error message
+func tokenName(c byte) string {
+	if c == 0 {
+		return "end of command"
+	}
+	return string(c)
+}
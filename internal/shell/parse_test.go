@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+// stmtTexts returns the text of each top-level statement
+func stmtTexts(script *Script) []string {
+	var texts []string
+	for _, stmt := range script.Stmts {
+		texts = append(texts, stmt.Text)
+	}
+	return texts
+}
+
+func TestParseStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"[[ ]] keeps && and ||", "[[ -f go.mod && -d internal ]] && echo ok", []string{"[[ -f go.mod && -d internal ]]", "echo ok"}},
+		{"[[ ]] with < and parentheses", "[[ ( a < b ) || ! -e x ]]; echo done", []string{"[[ ( a < b ) || ! -e x ]]", "echo done"}},
+		{"[[ ]] with a quoted ]]", `[[ "$x" == "]]" ]] && echo same`, []string{`[[ "$x" == "]]" ]]`, "echo same"}},
+		{"(( ))", "(( n > 1 || m < 2 )) || exit 1", []string{"(( n > 1 || m < 2 ))", "exit 1"}},
+		{"subshell", "(a && b) || c", []string{"(a && b)", "c"}},
+		{"brace group", "{ a && b; } && c", []string{"{ a && b; }", "c"}},
+		{"nested brace group", "{ { a; }; b; }; c", []string{"{ { a; }; b; }", "c"}},
+		{"here-document", "cat <<EOF\na && b; c || d\nEOF\necho after", []string{"cat <<EOF", "echo after"}},
+		{"here-document with tabs stripped", "cat <<-END && echo ok\n\tx | y\n\tEND\ntrue", []string{"cat <<-END", "echo ok", "true"}},
+		{"two here-documents", "cat <<A; cat <<'B'\none && two\nA\nthree || four\nB", []string{"cat <<A", "cat <<'B'"}},
+		{"here-string is not a here-document", "cat <<< 'a && b' && echo ok", []string{"cat <<< 'a && b'", "echo ok"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Parse(tt.command)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.command, err)
+			}
+			if got := stmtTexts(script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) statements = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHeredocBody(t *testing.T) {
+	script, err := Parse("cat <<-'EOF' > out.txt\n\tline && one\n\tEOF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := script.Stmts[0].Pipeline.Commands[0]
+	if len(cmd.Redirs) != 2 || cmd.Redirs[0].Heredoc != "line && one\n" {
+		t.Fatalf("here-document body = %q, want %q", cmd.Redirs[0].Heredoc, "line && one\n")
+	}
+}
+
+func TestParseBraceGroupCommands(t *testing.T) {
+	script, err := Parse("{ rm -rf build; make; } > log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var programs []string
+	for _, cmd := range script.Commands() {
+		programs = append(programs, cmd.Args[0].Value)
+	}
+	if want := []string{"rm", "make"}; !reflect.DeepEqual(programs, want) {
+		t.Errorf("Commands() = %q, want %q", programs, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, command := range []string{
+		"[[ -f x && -d y",
+		"{ echo a",
+		"{ }",
+		"(a && b",
+		"a &&",
+	} {
+		if _, err := Parse(command); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", command)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// RemoteSession is an SSH connection commands run through. On Unix one
+// master connection is shared, so authentication happens once.
+type RemoteSession struct {
+	Host        string
+	controlPath string // master connection socket; empty when not multiplexing
+}
+
+// Connect opens an SSH session to a host (anything ssh accepts, e.g.
+// user@server or a Host from ~/.ssh/config). Password or passphrase
+// prompts are answered on the terminal.
+func Connect(host string) (*RemoteSession, error) {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return nil, fmt.Errorf("ssh is not installed")
+	}
+	if strings.HasPrefix(host, "-") || strings.ContainsAny(host, " \t'\"") {
+		return nil, fmt.Errorf("invalid host %q", host)
+	}
+
+	session := &RemoteSession{Host: host}
+	if runtime.GOOS != "windows" {
+		session.controlPath = filepath.Join(os.TempDir(), fmt.Sprintf("helix-ssh-%d-%s", os.Getpid(), sanitizeHost(host)))
+	}
+
+	// -f returns once authenticated, leaving the master connection running
+	args := []string{"-o", "ConnectTimeout=15"}
+	if session.controlPath != "" {
+		args = append(args, "-M", "-S", session.controlPath, "-o", "ControlPersist=yes", "-fN", host)
+	} else {
+		args = append(args, host, "exit")
+	}
+	cmd := exec.Command("ssh", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", host, err)
+	}
+	return session, nil
+}
+
+// sanitizeHost keeps the characters of a host that are safe in a file name
+func sanitizeHost(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, host)
+}
+
+// sshArgs returns the ssh arguments that run a command on the host
+func (s *RemoteSession) sshArgs(command string) []string {
+	args := []string{"ssh"}
+	if s.controlPath != "" {
+		args = append(args, "-S", s.controlPath)
+	}
+	return append(args, "-o", "BatchMode=yes", s.Host, "--", command)
+}
+
+// Command returns a local ssh process that runs a command line in the
+// remote login shell, ending when ctx does
+func (s *RemoteSession) Command(ctx context.Context, command string) *exec.Cmd {
+	args := s.sshArgs(command)
+	return exec.CommandContext(ctx, args[0], args[1:]...)
+}
+
+// Output runs a command on the host and returns its standard output
+func (s *RemoteSession) Output(command string) (string, error) {
+	args := s.sshArgs(command)
+	output, err := exec.Command(args[0], args[1:]...).Output()
+	return string(output), err
+}
+
+// HasProgram reports whether a program is on the remote PATH or a builtin
+// of the remote shell
+func (s *RemoteSession) HasProgram(name string) bool {
+	if name == "" || strings.ContainsAny(name, " \t'\"$`;|&<>()\\") {
+		return false
+	}
+	_, err := s.Output("command -v " + name)
+	return err == nil
+}
+
+// DetectEnvironment inspects the remote OS, login shell, user and home directory
+func (s *RemoteSession) DetectEnvironment() (Env, error) {
+	output, err := s.Output(`uname -s; echo "$SHELL"; id -un; echo "$HOME"`)
+	if err != nil {
+		return Env{}, fmt.Errorf("cannot inspect %s (only Unix hosts are supported): %w", s.Host, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	for len(lines) < 4 {
+		lines = append(lines, "")
+	}
+
+	shellName := "unknown"
+	shellPath := strings.TrimSpace(lines[1])
+	for _, name := range []string{"bash", "zsh", "fish"} {
+		if strings.Contains(filepath.Base(shellPath), name) {
+			shellName = name
+		}
+	}
+
+	return Env{
+		OSName:    strings.ToLower(strings.TrimSpace(lines[0])),
+		Shell:     shellName,
+		ShellPath: shellPath,
+		User:      strings.TrimSpace(lines[2]),
+		HomeDir:   strings.TrimSpace(lines[3]),
+		Remote:    s,
+	}, nil
+}
+
+// Close ends the master connection
+func (s *RemoteSession) Close() error {
+	if s.controlPath == "" {
+		return nil
+	}
+	err := exec.Command("ssh", "-S", s.controlPath, "-O", "exit", s.Host).Run()
+	os.Remove(s.controlPath)
+	return err
+}
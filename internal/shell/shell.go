@@ -9,11 +9,12 @@ import (
 
 // Env contains detected environment info with enhanced details
 type Env struct {
-	OSName    string // windows, linux, darwin
-	Shell     string // bash, zsh, powershell, cmd, fish, unknown
-	ShellPath string // Full path to shell executable
-	User      string // Current username
-	HomeDir   string // User home directory
+	OSName    string         // windows, linux, darwin
+	Shell     string         // bash, zsh, powershell, cmd, fish, unknown
+	ShellPath string         // Full path to shell executable
+	User      string         // Current username
+	HomeDir   string         // User home directory
+	Remote    *RemoteSession // set in /ssh mode: commands run on this host
 }
 
 // PackageManagerInfo represents detected package manager info
@@ -141,10 +142,11 @@ func detectLinuxPackageManager() PackageManagerInfo {
 		test string
 	}{
 		{"apt", "apt --version"},
+		{"dnf", "dnf --version"}, // before yum, which is dnf on current Fedora and RHEL
 		{"yum", "yum --version"},
-		{"dnf", "dnf --version"},
 		{"pacman", "pacman --version"},
 		{"zypper", "zypper --version"},
+		{"apk", "apk --version"},
 		{"snap", "snap --version"},
 		{"flatpak", "flatpak --version"},
 	}
@@ -193,6 +195,18 @@ func (e Env) GetShellCommandPrefix() string {
 	}
 }
 
+// UsesPOSIXSyntax reports whether commands run in a POSIX-style shell, whose
+// syntax Parse understands
+func (e Env) UsesPOSIXSyntax() bool {
+	switch e.Shell {
+	case "bash", "zsh":
+		return true
+	case "unknown", "":
+		return e.OSName != "windows"
+	}
+	return false
+}
+
 // IsUnixLike returns true for Unix-like shells
 func (e Env) IsUnixLike() bool {
 	return e.Shell == "bash" || e.Shell == "zsh" || e.Shell == "fish"
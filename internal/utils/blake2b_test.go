@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// sequence returns n bytes counting up modulo 251
+func sequence(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestBlake2b512(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"empty", nil, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		// RFC 7693, Appendix A
+		{"abc", []byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{"one block", sequence(128), "2319e3789c47e2daa5fe807f61bec2a1a6537fa03f19ff32e87eecbfd64b7e0e8ccff439ac333b040f19b0c4ddd11a61e24ac1fe0f10a039806c5dcc0da3d115"},
+		{"block plus one", sequence(129), "f59711d44a031d5f97a9413c065d1e614c417ede998590325f49bad2fd444d3e4418be19aec4e11449ac1a57207898bc57d76a1bcf3566292c20c683a5c4648f"},
+		{"many blocks", sequence(1000), "c11e1c0340bd7e5a1b275f1230c962fad215ecb1391486e74e31b960a2f2996381a5fad092da06841d5f26e38f6ecfeaf441acbcd1c2de61aef121e7927175f5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Whole, then in uneven pieces that straddle block boundaries
+			for _, chunk := range []int{len(tt.input) + 1, 1, 7, 128, 200} {
+				h := newBlake2b512()
+				for rest := tt.input; len(rest) > 0; {
+					n := min(chunk, len(rest))
+					h.Write(rest[:n])
+					rest = rest[n:]
+				}
+				if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+					t.Fatalf("written %d bytes at a time: got %s, want %s", chunk, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlake2b512SumKeepsState(t *testing.T) {
+	h := newBlake2b512()
+	h.Write([]byte("ab"))
+	h.Sum(nil)
+	h.Write([]byte("c"))
+	if got := hex.EncodeToString(h.Sum(nil)); got[:16] != "ba80a53f981c4d0d" {
+		t.Fatalf("Sum changed the running hash: got %s", got)
+	}
+	h.Reset()
+	if got := hex.EncodeToString(h.Sum(nil)); got[:16] != "786a02f742015903" {
+		t.Fatalf("Reset did not restart the hash: got %s", got)
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// clipboardTool is a program that reads text to copy from stdin
+type clipboardTool struct {
+	name string
+	args []string
+}
+
+// clipboardTools returns the clipboard programs to try on this system, in order
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{name: "pbcopy"}}
+	case "windows":
+		return []clipboardTool{
+			{name: "powershell", args: []string{"-NoProfile", "-Command", "$input | Set-Clipboard"}},
+			{name: "clip"},
+		}
+	}
+
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{name: "wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		tools = append(tools,
+			clipboardTool{name: "xclip", args: []string{"-selection", "clipboard"}},
+			clipboardTool{name: "xsel", args: []string{"--clipboard", "--input"}})
+	}
+	// WSL shares the Windows clipboard
+	return append(tools, clipboardTool{name: "clip.exe"})
+}
+
+// CopyToClipboard puts text on the system clipboard and returns what copied
+// it. Without a clipboard program, e.g. over SSH, it asks the terminal to
+// copy it with an OSC 52 escape sequence, which most modern terminals honor.
+func CopyToClipboard(text string) (string, error) {
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool.name, tool.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return tool.name, nil
+		}
+	}
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return "", fmt.Errorf("no clipboard program found (install xclip, xsel or wl-clipboard)")
+	}
+	fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return "terminal (OSC 52)", nil
+}
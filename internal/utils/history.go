@@ -7,6 +7,7 @@ import (
 )
 
 // AppendHistory appends a line to the history file (creates it if missing).
+// Secrets in the line are masked first.
 func AppendHistory(path, line string) error {
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
 	if err != nil {
@@ -14,7 +15,7 @@ func AppendHistory(path, line string) error {
 	}
 	defer f.Close()
 
-	_, err = fmt.Fprintln(f, line)
+	_, err = fmt.Fprintln(f, RedactSecrets(line))
 	return err
 }
 
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnitSystem selects the multiples used to print byte sizes
+type UnitSystem string
+
+const (
+	BinaryUnits  UnitSystem = "binary"  // powers of 1024: KiB, MiB, GiB
+	DecimalUnits UnitSystem = "decimal" // powers of 1000: kB, MB, GB
+)
+
+// NumberFormat is how sizes, counts and durations are printed
+type NumberFormat struct {
+	Units     UnitSystem
+	Thousands string // separator between groups of three digits, "" for none
+	Decimal   string // separator before the fraction
+}
+
+var (
+	numberFormatMu sync.RWMutex
+	numberFormat   = NumberFormatForLocale(SystemLocale(), BinaryUnits)
+)
+
+// SetNumberFormat changes how every report prints sizes, counts and durations
+func SetNumberFormat(format NumberFormat) {
+	numberFormatMu.Lock()
+	defer numberFormatMu.Unlock()
+	numberFormat = format
+}
+
+// CurrentNumberFormat returns the format in use
+func CurrentNumberFormat() NumberFormat {
+	numberFormatMu.RLock()
+	defer numberFormatMu.RUnlock()
+	return numberFormat
+}
+
+// ParseUnitSystem parses a units preference; an empty one means binary
+func ParseUnitSystem(value string) (UnitSystem, error) {
+	switch UnitSystem(strings.ToLower(strings.TrimSpace(value))) {
+	case "", BinaryUnits, "iec":
+		return BinaryUnits, nil
+	case DecimalUnits, "si":
+		return DecimalUnits, nil
+	}
+	return BinaryUnits, fmt.Errorf("unknown unit system %q (use binary or decimal)", value)
+}
+
+// SystemLocale returns the locale numbers are formatted for, following the
+// precedence of LC_ALL, LC_NUMERIC and LANG
+func SystemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Separators by language, as thousands and decimal separator. Languages not
+// listed use "," and "."; the C and POSIX locales do not group digits.
+// Grouping by space uses a no-break space so numbers never wrap.
+var localeSeparators = map[string][2]string{
+	"de": {".", ","}, "es": {".", ","}, "it": {".", ","}, "nl": {".", ","}, "pt": {".", ","},
+	"da": {".", ","}, "id": {".", ","}, "tr": {".", ","}, "el": {".", ","}, "ro": {".", ","},
+	"hr": {".", ","}, "sl": {".", ","}, "sr": {".", ","},
+	"fr": {"\u00a0", ","}, "ru": {"\u00a0", ","}, "uk": {"\u00a0", ","}, "pl": {"\u00a0", ","},
+	"cs": {"\u00a0", ","}, "sk": {"\u00a0", ","}, "sv": {"\u00a0", ","}, "nb": {"\u00a0", ","},
+	"nn": {"\u00a0", ","}, "fi": {"\u00a0", ","}, "hu": {"\u00a0", ","}, "bg": {"\u00a0", ","},
+	"et": {"\u00a0", ","}, "lt": {"\u00a0", ","}, "lv": {"\u00a0", ","},
+}
+
+// Regional variants whose separators differ from their language's
+var regionSeparators = map[string][2]string{
+	"de_CH": {"\u2019", "."}, "it_CH": {"\u2019", "."}, "fr_CH": {"\u00a0", "."},
+	"es_MX": {",", "."}, "es_US": {",", "."},
+}
+
+// NumberFormatForLocale returns the separators of a POSIX locale name such
+// as de_DE.UTF-8, combined with a unit system
+func NumberFormatForLocale(locale string, units UnitSystem) NumberFormat {
+	format := NumberFormat{Units: units, Thousands: ",", Decimal: "."}
+
+	name := strings.SplitN(strings.SplitN(locale, ".", 2)[0], "@", 2)[0]
+	if name == "" || name == "C" || name == "POSIX" {
+		format.Thousands = ""
+		return format
+	}
+
+	separators, found := regionSeparators[name]
+	if !found {
+		separators, found = localeSeparators[strings.ToLower(strings.SplitN(name, "_", 2)[0])]
+	}
+	if found {
+		format.Thousands, format.Decimal = separators[0], separators[1]
+	}
+	return format
+}
+
+// FormatNumber formats an integer with the locale's thousands separator
+func FormatNumber(n int64) string {
+	return CurrentNumberFormat().number(n)
+}
+
+// FormatFloat formats a number with a fixed number of decimals, using the
+// locale's separators
+func FormatFloat(f float64, decimals int) string {
+	return CurrentNumberFormat().float(f, decimals)
+}
+
+// FormatBytes formats a byte count for human readability
+func FormatBytes(n int64) string {
+	format := CurrentNumberFormat()
+
+	unit, prefixes, suffix := int64(1024), "KMGTPE", "iB"
+	if format.Units == DecimalUnits {
+		unit, prefixes, suffix = 1000, "kMGTPE", "B"
+	}
+	if n < unit && n > -unit {
+		return format.number(n) + " B"
+	}
+
+	div, exp := unit, 0
+	for m := abs64(n) / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%s %c%s", format.float(float64(n)/float64(div), 1), prefixes[exp], suffix)
+}
+
+// ParseBytes parses a byte size such as 512, 64K, 10MB or 1.5GiB. Multiples
+// are powers of 1024 whatever the suffix.
+func ParseBytes(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	number := strings.TrimRight(text, "KMGTIB ")
+	suffix := strings.TrimSpace(text[len(number):])
+
+	multiplier := int64(1)
+	switch strings.TrimSuffix(strings.TrimSuffix(suffix, "B"), "I") {
+	case "":
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	case "T":
+		multiplier = 1 << 40
+	default:
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512K, 10MB or 1G)", value)
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512K, 10MB or 1G)", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatDuration formats a duration for human readability
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	if d < time.Minute {
+		return FormatFloat(d.Seconds(), 1) + "s"
+	}
+
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	hours := int(d.Hours())
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%dm %ds", minutes, seconds)
+}
+
+// number groups the digits of an integer
+func (f NumberFormat) number(n int64) string {
+	digits := fmt.Sprintf("%d", abs64(n))
+	sign := ""
+	if n < 0 {
+		sign = "-"
+	}
+	return sign + f.group(digits)
+}
+
+// float formats a number with fixed decimals and groups its integer part
+func (f NumberFormat) float(value float64, decimals int) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Sprintf("%v", value)
+	}
+	text := fmt.Sprintf("%.*f", decimals, math.Abs(value))
+	whole, fraction, _ := strings.Cut(text, ".")
+
+	sign := ""
+	if value < 0 && strings.Trim(text, "0.") != "" {
+		sign = "-"
+	}
+	if fraction == "" {
+		return sign + f.group(whole)
+	}
+	return sign + f.group(whole) + f.Decimal + fraction
+}
+
+// group inserts the thousands separator into a string of digits
+func (f NumberFormat) group(digits string) string {
+	if f.Thousands == "" || len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(f.Thousands)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// abs64 returns the absolute value of an integer
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Signatures use the minisign format: an Ed25519 signature over the file's
+// BLAKE2b-512 hash ("ED"), or over the file itself ("Ed", legacy), plus a
+// global signature binding a trusted comment to it.
+
+// maxLegacySignedSize bounds files checked against legacy signatures, which
+// need the whole file in memory
+const maxLegacySignedSize = 64 << 20
+
+// AllowUnsignedEnv lets artifacts without a verifiable signature be
+// installed without asking, e.g. HELIX_ALLOW_UNSIGNED=1 for development builds
+const AllowUnsignedEnv = "HELIX_ALLOW_UNSIGNED"
+
+// ErrUnsigned reports an artifact that cannot be checked: it has no
+// signature, or this build pins no keys. Callers may let users override it;
+// a signature that fails to verify is a different error and never overridable.
+var ErrUnsigned = errors.New("artifact is not signed")
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  uint64 // key ID, as minisign prints it in hex
+	Key ed25519.PublicKey
+}
+
+// KeyID returns the key ID as minisign prints it
+func (k PublicKey) KeyID() string {
+	return formatKeyID(k.ID)
+}
+
+// Signature is a parsed minisign signature file
+type Signature struct {
+	Algorithm       string // "ED" (prehashed) or "Ed" (legacy)
+	KeyID           uint64
+	Signature       []byte
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 line alone
+// or a whole .pub file
+func ParsePublicKey(text string) (PublicKey, error) {
+	var key PublicKey
+	data, err := base64.StdEncoding.DecodeString(lastLine(text))
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+		return key, fmt.Errorf("invalid minisign public key")
+	}
+	key.ID = binary.LittleEndian.Uint64(data[2:10])
+	key.Key = ed25519.PublicKey(data[10:])
+	return key, nil
+}
+
+// ParsePublicKeys parses a comma or newline separated list of public keys
+func ParsePublicKeys(list string) ([]PublicKey, error) {
+	var keys []PublicKey
+	for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "untrusted comment:") {
+			continue
+		}
+		key, err := ParsePublicKey(field)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseSignature parses the contents of a .minisig file
+func ParseSignature(data []byte) (Signature, error) {
+	var sig Signature
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment: ") {
+		return sig, fmt.Errorf("invalid minisign signature file")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return sig, fmt.Errorf("invalid minisign signature")
+	}
+	sig.Algorithm = string(raw[:2])
+	if sig.Algorithm != "ED" && sig.Algorithm != "Ed" {
+		return sig, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	sig.KeyID = binary.LittleEndian.Uint64(raw[2:10])
+	sig.Signature = raw[10:]
+
+	sig.TrustedComment = strings.TrimPrefix(lines[2], "trusted comment: ")
+	sig.GlobalSignature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(sig.GlobalSignature) != ed25519.SignatureSize {
+		return sig, fmt.Errorf("invalid minisign global signature")
+	}
+	return sig, nil
+}
+
+// VerifyFile checks a file against a signature made by one of the trusted
+// keys and returns the key that signed it. Listing several keys lets a new
+// signing key take over while artifacts signed by the old one still verify.
+func VerifyFile(path string, sig Signature, keys []PublicKey) (PublicKey, error) {
+	if len(keys) == 0 {
+		return PublicKey{}, fmt.Errorf("%w: no signing keys are pinned in this build", ErrUnsigned)
+	}
+
+	var key PublicKey
+	found := false
+	for _, candidate := range keys {
+		if candidate.ID == sig.KeyID {
+			key, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return key, fmt.Errorf("signed by unknown key %s (a newer Helix may trust it)", formatKeyID(sig.KeyID))
+	}
+
+	message, err := signedMessage(path, sig.Algorithm)
+	if err != nil {
+		return key, err
+	}
+	if !ed25519.Verify(key.Key, message, sig.Signature) {
+		return key, fmt.Errorf("signature verification failed for %s", path)
+	}
+
+	global := append(append([]byte(nil), sig.Signature...), sig.TrustedComment...)
+	if !ed25519.Verify(key.Key, global, sig.GlobalSignature) {
+		return key, fmt.Errorf("trusted comment signature verification failed for %s", path)
+	}
+	return key, nil
+}
+
+// VerifyFileSignature reads a signature file and checks a file against it.
+// A missing signature file is ErrUnsigned.
+func VerifyFileSignature(path, signaturePath string, keys []PublicKey) (Signature, PublicKey, error) {
+	data, err := os.ReadFile(signaturePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Signature{}, PublicKey{}, fmt.Errorf("%w: %s not found", ErrUnsigned, signaturePath)
+	}
+	if err != nil {
+		return Signature{}, PublicKey{}, err
+	}
+	sig, err := ParseSignature(data)
+	if err != nil {
+		return sig, PublicKey{}, err
+	}
+	key, err := VerifyFile(path, sig, keys)
+	return sig, key, err
+}
+
+// signedMessage returns what the signature covers: the file's BLAKE2b-512
+// hash for prehashed signatures, or the file itself
+func signedMessage(path, algorithm string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if algorithm == "ED" {
+		h := newBlake2b512()
+		if _, err := io.Copy(h, file); err != nil {
+			return nil, err
+		}
+		return h.Sum(nil), nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxLegacySignedSize {
+		return nil, fmt.Errorf("legacy signature on a %s file; sign it with minisign -H", FormatBytes(info.Size()))
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatKeyID prints a key ID the way minisign does
+func formatKeyID(id uint64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return strings.ToUpper(hex.EncodeToString(b[:]))
+}
+
+// lastLine returns the last non-empty line of a text
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Signatures made by the minisign tool over the 4-byte file "test"
+const (
+	testPublicKey = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	testOtherKey  = "RWRQhGcHOBlzw4CoKyugkk4ioDfoxlXxC9LBx+VNhJ3w9w+cAxgvPsuo"
+
+	testPrehashedSignature = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+	testLegacySignature = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+)
+
+func TestVerifyFileSignature(t *testing.T) {
+	trusted, err := ParsePublicKeys(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ParsePublicKeys(testOtherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		signature string
+		keys      []PublicKey
+		wantErr   string // "" when the signature must verify
+	}{
+		{name: "prehashed", content: "test", signature: testPrehashedSignature, keys: trusted},
+		{name: "legacy", content: "test", signature: testLegacySignature, keys: trusted},
+		{name: "rotated keys", content: "test", signature: testPrehashedSignature, keys: append(other, trusted...)},
+		{name: "changed file", content: "tesT", signature: testPrehashedSignature, keys: trusted, wantErr: "signature verification failed"},
+		{name: "changed legacy file", content: "test\n", signature: testLegacySignature, keys: trusted, wantErr: "signature verification failed"},
+		{name: "changed trusted comment", content: "test",
+			signature: strings.Replace(testPrehashedSignature, "file:test", "file:evil", 1), keys: trusted,
+			wantErr: "trusted comment signature verification failed"},
+		{name: "unknown key", content: "test", signature: testPrehashedSignature, keys: other, wantErr: "unknown key"},
+		{name: "no keys", content: "test", signature: testPrehashedSignature, wantErr: ErrUnsigned.Error()},
+		{name: "malformed", content: "test", signature: "untrusted comment: x\nnot base64\n", keys: trusted, wantErr: "invalid minisign signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path+".minisig", []byte(tt.signature), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, key, err := VerifyFileSignature(path, path+".minisig", tt.keys)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyFileSignature: %v", err)
+				}
+				if key.KeyID() != "E7620F1842B4E81F" {
+					t.Fatalf("signed by %s, want E7620F1842B4E81F", key.KeyID())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifyFileSignature error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyFileSignatureMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test")
+	os.WriteFile(path, []byte("test"), 0o644)
+	keys, _ := ParsePublicKeys(testPublicKey)
+	if _, _, err := VerifyFileSignature(path, path+".minisig", keys); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("missing signature: got %v, want ErrUnsigned", err)
+	}
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    int
+		wantErr bool
+	}{
+		{name: "none", list: "", want: 0},
+		{name: "one", list: testPublicKey, want: 1},
+		{name: "two for rotation", list: testPublicKey + "," + testOtherKey, want: 2},
+		{name: "pub file", list: "untrusted comment: minisign public key E7620F1842B4E81F\n" + testPublicKey + "\n", want: 1},
+		{name: "truncated", list: testPublicKey[:40], wantErr: true},
+		{name: "not a key", list: "RWQ...", wantErr: true},
+		{name: "one bad among good", list: testPublicKey + ",garbage", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParsePublicKeys(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePublicKeys error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(keys) != tt.want {
+				t.Fatalf("got %d keys, want %d", len(keys), tt.want)
+			}
+		})
+	}
+}
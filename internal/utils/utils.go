@@ -112,7 +112,8 @@ func ExtractPackageName(command string) string {
 	// Simple heuristic to extract package names from common commands
 	patterns := []*regexp.Regexp{
 		regexp.MustCompile(`(?:install|remove|update|search)\s+([a-zA-Z0-9._-]+)`),
-		regexp.MustCompile(`(?:apt|brew|choco|winget|pacman|yum|dnf)\s+(?:install|remove|update)\s+([a-zA-Z0-9._-]+)`),
+		regexp.MustCompile(`(?:apt|brew|choco|winget|pacman|yum|dnf|zypper)\s+(?:install|remove|update)\s+([a-zA-Z0-9._-]+)`),
+		regexp.MustCompile(`apk\s+(?:add|del)\s+([a-zA-Z0-9._-]+)`),
 	}
 
 	for _, pattern := range patterns {
@@ -125,17 +126,6 @@ func ExtractPackageName(command string) string {
 	return ""
 }
 
-// FormatDuration formats a duration for human readability
-func FormatDuration(d time.Duration) string {
-	if d < time.Second {
-		return fmt.Sprintf("%dms", d.Milliseconds())
-	}
-	if d < time.Minute {
-		return fmt.Sprintf("%.1fs", d.Seconds())
-	}
-	return d.String()
-}
-
 // ContainsAny checks if a string contains any of the given substrings
 func ContainsAny(s string, substrings []string) bool {
 	for _, substr := range substrings {
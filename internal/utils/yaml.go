@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FlattenYAML reads a YAML document of nested mappings whose values are
+// scalars or lists of scalars, as Helix's own files are. Keys are returned
+// joined by dots, e.g. "deny.binaries", and a scalar as a list of one value;
+// a key with no value is left out.
+func FlattenYAML(data []byte) (map[string][]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string][]string)
+	if len(doc.Content) == 0 {
+		return values, nil // empty or only comments
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected 'key: value' pairs", root.Line)
+	}
+	if err := flattenYAML(root, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenYAML adds the values of a mapping under prefix
+func flattenYAML(mapping *yaml.Node, prefix string, values map[string][]string) error {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, resolveYAMLAlias(mapping.Content[i+1])
+		if seen[key] {
+			return fmt.Errorf("line %d: %s is given twice", mapping.Content[i].Line, key)
+		}
+		seen[key] = true
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value.Kind {
+		case yaml.MappingNode:
+			if err := flattenYAML(value, key, values); err != nil {
+				return err
+			}
+		case yaml.SequenceNode:
+			list := []string{}
+			for _, item := range value.Content {
+				item = resolveYAMLAlias(item)
+				if item.Kind != yaml.ScalarNode {
+					return fmt.Errorf("line %d: %s may only list plain values", item.Line, key)
+				}
+				list = append(list, item.Value)
+			}
+			values[key] = list
+		case yaml.ScalarNode:
+			if value.Tag != "!!null" {
+				values[key] = []string{value.Value}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveYAMLAlias returns the node an *alias refers to
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:  "nested lists",
+			input: "deny:\n  binaries: [nc, telnet]\n  paths:\n    - \"/etc/**\"\n    - ~/.ssh\n",
+			want:  map[string][]string{"deny.binaries": {"nc", "telnet"}, "deny.paths": {"/etc/**", "~/.ssh"}},
+		},
+		{
+			name:  "scalars and comments",
+			input: "# profile\nsandbox:\n  mode: strict # or current\ndry_run: true\n",
+			want:  map[string][]string{"sandbox.mode": {"strict"}, "dry_run": {"true"}},
+		},
+		{
+			name:  "quoting and escapes",
+			input: "deny:\n  patterns: ['(curl|wget)[^|]*\\|\\s*sh', \"a # b\", 'it''s']\n",
+			want:  map[string][]string{"deny.patterns": {`(curl|wget)[^|]*\|\s*sh`, "a # b", "it's"}},
+		},
+		{
+			name:  "block scalar and anchors",
+			input: "common: &bins [nc, telnet]\ndeny:\n  binaries: *bins\n  patterns:\n    - >-\n      rm -rf\n      /\n",
+			want:  map[string][]string{"common": {"nc", "telnet"}, "deny.binaries": {"nc", "telnet"}, "deny.patterns": {"rm -rf /"}},
+		},
+		{
+			name:  "empty list and key without value",
+			input: "deny:\n  binaries: []\nconfirm:\n",
+			want:  map[string][]string{"deny.binaries": {}},
+		},
+		{name: "empty document", input: "# nothing yet\n", want: map[string][]string{}},
+		{name: "not a mapping", input: "- a\n- b\n", wantErr: true},
+		{name: "list of mappings", input: "deny:\n  binaries:\n    - name: nc\n", wantErr: true},
+		{name: "duplicate key", input: "deny:\n  binaries: [nc]\n  binaries: [telnet]\n", wantErr: true},
+		{name: "tab indent", input: "deny:\n\tbinaries: [nc]\n", wantErr: true},
+		{name: "unterminated list", input: "deny:\n  binaries: [nc, telnet\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FlattenYAML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FlattenYAML error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FlattenYAML = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package ux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Palette names
+const (
+	PaletteDefault    = "default"
+	PaletteColorblind = "colorblind"
+)
+
+// Palette holds the colors of the safety and status signals
+type Palette struct {
+	Low      *color.Color
+	Medium   *color.Color
+	High     *color.Color
+	Critical *color.Color
+	Success  *color.Color
+	Error    *color.Color
+	Warning  *color.Color
+}
+
+// palettes are the available themes. The colorblind palette avoids pairing
+// red with green: levels go from blue through yellow to magenta, which stay
+// distinct with the common forms of color blindness.
+var palettes = map[string]Palette{
+	PaletteDefault: {
+		Low:      color.New(color.FgGreen),
+		Medium:   color.New(color.FgYellow),
+		High:     color.New(color.FgRed, color.Bold),
+		Critical: color.New(color.FgHiRed, color.Bold, color.Underline),
+		Success:  color.New(color.FgGreen, color.Bold),
+		Error:    color.New(color.FgRed, color.Bold),
+		Warning:  color.New(color.FgYellow, color.Bold),
+	},
+	PaletteColorblind: {
+		Low:      color.New(color.FgHiBlue),
+		Medium:   color.New(color.FgHiYellow),
+		High:     color.New(color.FgHiMagenta, color.Bold),
+		Critical: color.New(color.FgHiWhite, color.BgMagenta, color.Bold),
+		Success:  color.New(color.FgHiBlue, color.Bold),
+		Error:    color.New(color.FgHiMagenta, color.Bold),
+		Warning:  color.New(color.FgHiYellow, color.Bold),
+	},
+}
+
+var (
+	paletteMu     sync.RWMutex
+	activePalette = PaletteDefault
+)
+
+// SetPalette switches every signal drawn through the theme to a palette
+func SetPalette(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = PaletteDefault
+	}
+	if _, known := palettes[name]; !known {
+		return fmt.Errorf("unknown palette %q (use %s)", name, strings.Join(PaletteNames(), " or "))
+	}
+
+	paletteMu.Lock()
+	defer paletteMu.Unlock()
+	activePalette = name
+	return nil
+}
+
+// ActivePalette returns the palette in use
+func ActivePalette() Palette {
+	paletteMu.RLock()
+	defer paletteMu.RUnlock()
+	return palettes[activePalette]
+}
+
+// PaletteNames lists the available palettes
+func PaletteNames() []string {
+	var names []string
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RiskColor returns the palette color of a risk level such as "high"
+func RiskColor(level string) *color.Color {
+	palette := ActivePalette()
+	switch level {
+	case "low":
+		return palette.Low
+	case "medium":
+		return palette.Medium
+	case "high":
+		return palette.High
+	}
+	return palette.Critical
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"helix/internal/utils"
+
 	"github.com/fatih/color"
 )
 
@@ -26,16 +28,17 @@ type ColorScheme struct {
 	Suggestion func(a ...interface{}) string
 }
 
-// NewUX creates a new UX manager
+// NewUX creates a new UX manager using the active palette
 func NewUX() *UX {
+	palette := ActivePalette()
 	return &UX{
 		typingSpeed: 30 * time.Millisecond,
 		colors: &ColorScheme{
 			Prompt:     color.New(color.FgCyan, color.Bold).SprintFunc(),
 			AIResponse: color.New(color.FgGreen).SprintFunc(),
-			Success:    color.New(color.FgGreen, color.Bold).SprintFunc(),
-			Error:      color.New(color.FgRed, color.Bold).SprintFunc(),
-			Warning:    color.New(color.FgYellow, color.Bold).SprintFunc(),
+			Success:    palette.Success.SprintFunc(),
+			Error:      palette.Error.SprintFunc(),
+			Warning:    palette.Warning.SprintFunc(),
 			Info:       color.New(color.FgBlue).SprintFunc(),
 			RAG:        color.New(color.FgMagenta, color.Bold).SprintFunc(),
 			Suggestion: color.New(color.FgHiCyan).SprintFunc(),
@@ -140,35 +143,98 @@ func (ux *UX) ShowHelp() {
 	color.Yellow("🤖 AI Commands:")
 	fmt.Println("  /ask <question>     - Ask the AI a question")
 	fmt.Println("  /cmd <request>      - Generate and execute commands from natural language")
+	fmt.Println("                        (answer r at the execute prompt to refine the command with a follow-up)")
+	fmt.Println("  /cmd --plan <request> - Plan several commands and run them step by step")
+	fmt.Println("  /batch <file> [--yes] [--dry-run] - Run one request per line, confirming each, with a transcript")
 	fmt.Println("  /explain <command>  - Explain what a command does")
+	fmt.Println("  /fix                - Diagnose the last failed command and suggest a fix")
+	fmt.Println("  /save [output|response|explanation] [--append] <path> - Save the last command output or AI answer")
+	fmt.Println("  /<command> > file   - Also write what a Helix command prints to a file (>> appends)")
+	fmt.Println("  /copy [auto on|off] - Copy the last generated command to the clipboard, or every accepted one")
+	fmt.Println("  /alias [<name> = <request or !command>|remove <name>] - Save shortcuts, then run them with /<name>")
 	fmt.Println()
 
 	color.Yellow("📦 Package Management:")
-	fmt.Println("  /install <package>  - Install a package")
+	fmt.Println("  /install <package>  - Install a package (--via pip|pipx|npm|yarn|pnpm|cargo|gem|go|snap|... picks the manager)")
+	fmt.Println("  /install -f <file>  - Install every package of a list, Brewfile or requirements.txt, resuming after failures")
 	fmt.Println("  /update <package>   - Update a package")
 	fmt.Println("  /remove <package>   - Remove a package")
+	fmt.Println("  /pkg search <query> - Search the package manager (--via npm, cargo, ...) and rank the matches")
+	fmt.Println("  /pkg list [filter]  - List installed packages (--via pip, npm, ...)")
+	fmt.Println("  /pkg outdated       - List available upgrades and upgrade the ones you pick")
+	fmt.Println("  /pkg managers       - List installed package managers; 'use <manager>|auto' sets the default")
+	fmt.Println("  /pkg history [pkg]  - List the package installs, updates and removals Helix ran")
+	fmt.Println("  /pkg rollback <pkg> - Suggest and run the commands undoing the last change to a package")
+	fmt.Println("  /upgrade-system     - Refresh, preview and upgrade everything the package manager installed")
 	fmt.Println()
 
 	color.Yellow("🧠 RAG System (Command Documentation):")
 	fmt.Println("  /rag-status         - Show RAG system status")
-	fmt.Println("  /rag-reindex        - Force reindex MAN pages")
+	fmt.Println("  /rag-reindex [full] - Reindex new/changed MAN pages (full: rebuild everything)")
+	fmt.Println("  /rag-config [set <key> <value>|reset] - Show or tune top_k, min_score, rerank, memory_mb, weight.<source>, source.<source>")
+	fmt.Println("  /rag-compact        - Remove orphaned documents and shrink the index file")
+	fmt.Println("  /rag-verify         - Check document checksums and rebuild only damaged commands")
+	fmt.Println("  /rag-eval [k] [file] - Score retrieval (precision@k, recall, MRR) on a query test set")
+	fmt.Println("  /rag-export [file]  - Pack the whole index into one .tar.gz for CI or air-gapped hosts")
+	fmt.Println("  /rag-import <file>  - Replace the index with an exported archive")
 	fmt.Println("  /rag-reset          - Reset RAG system completely")
+	fmt.Println("  /rag-add [path]     - Index this repo's README, docs/, Makefile targets and npm scripts")
+	fmt.Println("  /man <command>      - Read a MAN page summary (in your language when localized pages are installed)")
+	fmt.Println("  /whatis <command>   - Show a command's description, synopsis, options and examples (offline, no model)")
+	fmt.Println("  /retrieve <query>   - Show ranked documents and injected context without calling the model")
+	fmt.Println("  /search <query>     - Rank matching commands with descriptions and examples (offline, no model)")
 	fmt.Println("  /test-basic-ai      - Test basic AI functionality")
 	fmt.Println()
 
 	color.Yellow("🔒 Security & Sandbox:")
 	fmt.Println("  /sandbox <mode>     - Set directory restrictions (off/current/strict)")
+	fmt.Println("  /sandbox allow|deny|remove <path> - Allow a path outside the directory, or deny one inside it")
+	fmt.Println("  /sandbox network on|off - Allow or block network access for commands (offline analysis)")
+	fmt.Println("  /sandbox test       - Try escape techniques (.., symlinks, $(..), $HOME) against the sandbox rules")
 	fmt.Println("  /cd <dir>           - Change directory (sandbox-aware)")
-	fmt.Println("  /dry-run            - Toggle dry-run mode")
+	fmt.Println("  /pushd <dir>        - Change directory and remember the current one (no dir: swap)")
+	fmt.Println("  /popd               - Return to the last directory pushed")
+	fmt.Println("  /dirs               - Show the directory stack")
+	fmt.Println("  /dry-run            - Toggle dry-run mode (simulate: files, network and sudo use)")
+	fmt.Println("  /sudo [never|allow] - Show or set whether commands may use sudo")
+	fmt.Println("  /abort - Kill background jobs and processes commands left running (Ctrl+C twice kills a running command)")
+	fmt.Println("  /two-person [on [file]|off] - Hold destructive commands until a second operator runs helix approve")
+	fmt.Println("  /approvals - List the two-person approval requests")
+	fmt.Println("  /snapshots [on|off|keep <n>|size <bytes>] - Save files before commands change them, with retention limits")
+	fmt.Println("  /undo [list|<id>] - Restore the files the last (or a listed) command changed")
+	fmt.Println("  /trust [pattern] - List or add commands (e.g. \"ls *\", \"git status\") that run without confirmation here")
+	fmt.Println("  /untrust <pattern> - Ask for confirmation of a trusted pattern again")
+	fmt.Println("  /limits [timeout|output|nice|ionice|files <value>] - Stop runaway commands, lower their priority, cap glob deletes")
+	fmt.Println("  /jobs [list|logs <id> [lines]|kill <id>] - Manage commands run with /cmd --background or a trailing &")
+	fmt.Println("  /replay [audit-id]  - List recent /cmd actions, or reconstruct one and dry-run it again")
+	fmt.Println("  /record start [file]|stop - Record the session (inputs, commands, answers, output); review with helix replay <file>")
+	fmt.Println("  /policy             - Show the policy file (~/.helix/policy.yaml) and policy packs")
+	fmt.Println("  /policy test <command|--file path> - Show how sandbox, risk and policy packs treat commands, without running them")
+	fmt.Println()
+
+	color.Yellow("👥 Team Sharing:")
+	fmt.Println("  /sync setup <git-url> [branch] [--require-signed] - Sync snippets, git templates and policy packs")
+	fmt.Println("  /sync pull [--force] - Update shared content (--force accepts rewritten history)")
+	fmt.Println("  /sync status        - Show sync state and loaded content")
+	fmt.Println("  /snippets           - List snippets")
+	fmt.Println("  /snippet <name>     - Run a snippet")
 	fmt.Println()
 
 	color.Yellow("⚙️  System Commands:")
+	fmt.Println("  /ps [query]         - List processes, e.g. /ps whatever is using port 8080, then kill or renice them")
+	fmt.Println("  /ps kill <pid>... [--force] | /ps renice <niceness> <pid>... - Stop or reprioritize processes")
+	fmt.Println("  /net <question>     - Port checks, DNS lookups, latency and \"why can't I reach X\" (native, no model)")
 	fmt.Println("  /git <operation>    - Git operations with AI assistance")
+	fmt.Println("  /ssh <host>|exit    - Generate and run /cmd commands on a remote host over SSH")
+	fmt.Println("  /docker <operation> - Docker/Podman operations (prune images, logs, compose rebuilds) with AI fallback")
+	fmt.Println("  /k8s <request>      - kubectl in the current context/namespace; risky verbs need a dry run and the namespace typed")
+	fmt.Println("  /hooks [reload]     - List lifecycle hooks, or reload hook scripts")
 	fmt.Println("  /debug              - Show debug information")
 	fmt.Println("  /test-ai            - Test /ask AI feature")
 	fmt.Println("  /online             - Check internet connectivity")
 	fmt.Println("  /help               - Show this help message")
 	fmt.Println("  /exit               - Exit Helix")
+	fmt.Println("  Ctrl+C              - Interrupt the running command (twice at the prompt to exit)")
 	fmt.Println()
 
 	color.Green("💡 Examples:")
@@ -340,21 +406,7 @@ func (ux *UX) PrintCommandBreakdown(breakdown map[string]string) {
 
 // FormatDuration formats a duration for human readability
 func (ux *UX) FormatDuration(d time.Duration) string {
-	if d < time.Second {
-		return fmt.Sprintf("%dms", d.Milliseconds())
-	}
-	if d < time.Minute {
-		return fmt.Sprintf("%.1fs", d.Seconds())
-	}
-
-	minutes := int(d.Minutes()) % 60
-	seconds := int(d.Seconds()) % 60
-	hours := int(d.Hours())
-
-	if hours > 0 {
-		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
-	}
-	return fmt.Sprintf("%dm %ds", minutes, seconds)
+	return utils.FormatDuration(d)
 }
 
 // ProgressBar shows a simple progress bar
@@ -393,6 +445,19 @@ func (ux *UX) ShowLoadingAnimation(message string, done chan bool) {
 	}()
 }
 
+// ShowProgressLine redraws a single status line in place
+func (ux *UX) ShowProgressLine(text string) {
+	fmt.Printf("\r\033[K%s", color.CyanString(text))
+}
+
+// FinishProgressLine clears the status line and prints its final message
+func (ux *UX) FinishProgressLine(message string) {
+	fmt.Print("\r\033[K")
+	if message != "" {
+		fmt.Println(message)
+	}
+}
+
 // PrintTable prints a simple table format
 func (ux *UX) PrintTable(headers []string, rows [][]string) {
 	// Calculate column widths
@@ -411,16 +476,14 @@ func (ux *UX) PrintTable(headers []string, rows [][]string) {
 
 	// Print headers
 	for i, header := range headers {
-		fmt.Printf("%-*s", widths[i]+2, ux.colors.Info(header))
+		// Pad before coloring, so escape codes do not count toward the width
+		fmt.Print(ux.colors.Info(fmt.Sprintf("%-*s", widths[i]+2, header)))
 	}
 	fmt.Println()
 
 	// Print separator
-	for i, width := range widths {
+	for _, width := range widths {
 		fmt.Printf("%-*s", width+2, strings.Repeat("-", width))
-		if i < len(widths)-1 {
-			fmt.Print("  ")
-		}
 	}
 	fmt.Println()
 